@@ -6,22 +6,35 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	auth "github.com/bricks-cloud/bricksllm/internal/authenticator"
 	"github.com/bricks-cloud/bricksllm/internal/cache"
 	"github.com/bricks-cloud/bricksllm/internal/config"
+	"github.com/bricks-cloud/bricksllm/internal/cors"
+	"github.com/bricks-cloud/bricksllm/internal/digest"
+	"github.com/bricks-cloud/bricksllm/internal/drift"
+	"github.com/bricks-cloud/bricksllm/internal/event"
 	"github.com/bricks-cloud/bricksllm/internal/logger/zap"
 	"github.com/bricks-cloud/bricksllm/internal/manager"
 	"github.com/bricks-cloud/bricksllm/internal/message"
+	"github.com/bricks-cloud/bricksllm/internal/notification"
+	"github.com/bricks-cloud/bricksllm/internal/outage"
+	"github.com/bricks-cloud/bricksllm/internal/privacy"
+	"github.com/bricks-cloud/bricksllm/internal/probe"
 	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
 	"github.com/bricks-cloud/bricksllm/internal/provider/azure"
 	"github.com/bricks-cloud/bricksllm/internal/provider/custom"
 	"github.com/bricks-cloud/bricksllm/internal/provider/openai"
+	"github.com/bricks-cloud/bricksllm/internal/reconciliation"
 	"github.com/bricks-cloud/bricksllm/internal/recorder"
+	"github.com/bricks-cloud/bricksllm/internal/replica"
+	"github.com/bricks-cloud/bricksllm/internal/schedule"
 	"github.com/bricks-cloud/bricksllm/internal/server/web/admin"
 	"github.com/bricks-cloud/bricksllm/internal/server/web/proxy"
+	"github.com/bricks-cloud/bricksllm/internal/spendsync"
 	"github.com/bricks-cloud/bricksllm/internal/stats"
 	"github.com/bricks-cloud/bricksllm/internal/storage/memdb"
 	"github.com/bricks-cloud/bricksllm/internal/storage/postgresql"
@@ -37,7 +50,8 @@ func main() {
 
 	flag.Parse()
 
-	log := zap.NewZapLogger(*modePtr)
+	rf := zap.NewRequestFields()
+	log, logLevel := zap.NewZapLogger(*modePtr, *privacyPtr, rf)
 
 	gin.SetMode(gin.ReleaseMode)
 
@@ -46,11 +60,25 @@ func main() {
 		log.Sugar().Fatalf("cannot parse environment variables: %v", err)
 	}
 
-	err = stats.InitializeClient(cfg.StatsProvider)
+	if err := logLevel.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+		log.Sugar().Fatalf("invalid log level %s: %v", cfg.LogLevel, err)
+	}
+
+	envLabels, err := event.ParseEnvironmentLabels(cfg.EnvironmentLabels)
+	if err != nil {
+		log.Sugar().Fatalf("cannot parse environment labels: %v", err)
+	}
+
+	err = stats.InitializeClient(cfg.StatsProvider, event.EnvironmentTags(envLabels))
 	if err != nil {
 		log.Sugar().Fatalf("cannot connect to telemetry provider: %v", err)
 	}
 
+	cp, err := newCorsPolicy(cfg)
+	if err != nil {
+		log.Sugar().Fatalf("cannot parse cors policy: %v", err)
+	}
+
 	store, err := postgresql.NewStore(
 		fmt.Sprintf("postgresql:///%s?sslmode=%s&user=%s&password=%s&host=%s&port=%s", cfg.PostgresqlDbName, cfg.PostgresqlSslMode, cfg.PostgresqlUsername, cfg.PostgresqlPassword, cfg.PostgresqlHosts, cfg.PostgresqlPort),
 		cfg.PostgresqlWriteTimeout,
@@ -61,6 +89,14 @@ func main() {
 		log.Sugar().Fatalf("cannot connect to postgresql: %v", err)
 	}
 
+	if cfg.ReadOnlyFallback {
+		if err := store.Ping(); err != nil {
+			log.Sugar().Warnf("cannot reach postgresql, starting in read-only mode: %v", err)
+			runReadOnly(cfg, log, *modePtr, cp)
+			return
+		}
+	}
+
 	err = store.CreateCustomProvidersTable()
 	if err != nil {
 		log.Sugar().Fatalf("error creating custom providers table: %v", err)
@@ -71,6 +107,91 @@ func main() {
 		log.Sugar().Fatalf("error creating routes table: %v", err)
 	}
 
+	err = store.AlterRoutesTable()
+	if err != nil {
+		log.Sugar().Fatalf("error altering routes table: %v", err)
+	}
+
+	err = store.CreatePromptTemplatesTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating prompt templates table: %v", err)
+	}
+
+	err = store.CreateMockResponsesTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating mock responses table: %v", err)
+	}
+
+	err = store.CreateRecordingKeysTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating recording keys table: %v", err)
+	}
+
+	err = store.CreateSamplesTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating samples table: %v", err)
+	}
+
+	err = store.CreateSubjectRequestsTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating subject requests table: %v", err)
+	}
+
+	err = store.CreateJobsTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating jobs table: %v", err)
+	}
+
+	err = store.CreateSchedulesTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating schedules table: %v", err)
+	}
+
+	err = store.CreatePoliciesTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating policies table: %v", err)
+	}
+
+	err = store.CreatePassThroughsTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating pass-throughs table: %v", err)
+	}
+
+	err = store.CreateShadowResultsTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating shadow results table: %v", err)
+	}
+
+	err = store.CreateRecordedRequestsTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating recorded requests table: %v", err)
+	}
+
+	err = store.CreateTenantsTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating tenants table: %v", err)
+	}
+
+	err = store.CreatePricesTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating prices table: %v", err)
+	}
+
+	err = store.CreateTagsTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating tags table: %v", err)
+	}
+
+	err = store.CreateNotificationIntegrationsTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating notification integrations table: %v", err)
+	}
+
+	err = store.CreateProbesTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating probes table: %v", err)
+	}
+
 	err = store.CreateKeysTable()
 	if err != nil {
 		log.Sugar().Fatalf("error creating keys table: %v", err)
@@ -101,6 +222,196 @@ func main() {
 		log.Sugar().Fatalf("error altering provider settings table: %v", err)
 	}
 
+	err = store.AlterTablesForTenants()
+	if err != nil {
+		log.Sugar().Fatalf("error altering tables for tenants: %v", err)
+	}
+
+	err = store.AlterTablesForEgress()
+	if err != nil {
+		log.Sugar().Fatalf("error altering tables for egress: %v", err)
+	}
+
+	err = store.AlterRoutesTableForDedup()
+	if err != nil {
+		log.Sugar().Fatalf("error altering routes table for dedup: %v", err)
+	}
+
+	err = store.AlterKeysTableForOutputCaps()
+	if err != nil {
+		log.Sugar().Fatalf("error altering keys table for output caps: %v", err)
+	}
+
+	err = store.AlterTablesForSessions()
+	if err != nil {
+		log.Sugar().Fatalf("error altering tables for sessions: %v", err)
+	}
+
+	err = store.AlterKeysTableForLoopProtection()
+	if err != nil {
+		log.Sugar().Fatalf("error altering keys table for loop protection: %v", err)
+	}
+
+	err = store.AlterKeysTableForModelPolicy()
+	if err != nil {
+		log.Sugar().Fatalf("error altering keys table for model policy: %v", err)
+	}
+
+	err = store.AlterRoutesTableForDowngrade()
+	if err != nil {
+		log.Sugar().Fatalf("error altering routes table for downgrade: %v", err)
+	}
+
+	err = store.AlterKeysTableForSchedule()
+	if err != nil {
+		log.Sugar().Fatalf("error altering keys table for schedule: %v", err)
+	}
+
+	err = store.AlterKeysTableForPersonalCostLimit()
+	if err != nil {
+		log.Sugar().Fatalf("error altering keys table for personal cost limit: %v", err)
+	}
+
+	err = store.AlterTablesForResidency()
+	if err != nil {
+		log.Sugar().Fatalf("error altering tables for residency: %v", err)
+	}
+
+	err = store.AlterTablesForRecordings()
+	if err != nil {
+		log.Sugar().Fatalf("error altering tables for recordings: %v", err)
+	}
+
+	err = store.AlterKeysTableForFeatureFlags()
+	if err != nil {
+		log.Sugar().Fatalf("error altering keys table for feature flags: %v", err)
+	}
+
+	err = store.AlterKeysTableForSigning()
+	if err != nil {
+		log.Sugar().Fatalf("error altering keys table for signing: %v", err)
+	}
+
+	err = store.AlterKeysTableForParameters()
+	if err != nil {
+		log.Sugar().Fatalf("error altering keys table for parameters: %v", err)
+	}
+
+	err = store.AlterKeysTableForStreamGuardrails()
+	if err != nil {
+		log.Sugar().Fatalf("error altering keys table for stream guardrails: %v", err)
+	}
+
+	err = store.AlterTablesForLanguage()
+	if err != nil {
+		log.Sugar().Fatalf("error altering tables for language: %v", err)
+	}
+
+	err = store.AlterRoutesTableForEmbeddings()
+	if err != nil {
+		log.Sugar().Fatalf("error altering routes table for embeddings: %v", err)
+	}
+
+	err = store.AlterRoutesTableForCompression()
+	if err != nil {
+		log.Sugar().Fatalf("error altering routes table for compression: %v", err)
+	}
+
+	err = store.AlterEventsTableForSettings()
+	if err != nil {
+		log.Sugar().Fatalf("error altering events table for settings: %v", err)
+	}
+
+	err = store.AlterTablesForJudge()
+	if err != nil {
+		log.Sugar().Fatalf("error altering tables for judge: %v", err)
+	}
+
+	err = store.AlterRoutesTableForResponseHeaders()
+	if err != nil {
+		log.Sugar().Fatalf("error altering routes table for response headers: %v", err)
+	}
+
+	err = store.AlterRoutesTableForAuth()
+	if err != nil {
+		log.Sugar().Fatalf("error altering routes table for auth: %v", err)
+	}
+
+	err = store.AlterRoutesTableForComplexity()
+	if err != nil {
+		log.Sugar().Fatalf("error altering routes table for complexity: %v", err)
+	}
+
+	err = store.AlterRoutesTableForContextWindow()
+	if err != nil {
+		log.Sugar().Fatalf("error altering routes table for context window: %v", err)
+	}
+
+	err = store.AlterRoutesTableForPipeline()
+	if err != nil {
+		log.Sugar().Fatalf("error altering routes table for pipeline: %v", err)
+	}
+
+	err = store.AlterRoutesTableForRetrieval()
+	if err != nil {
+		log.Sugar().Fatalf("error altering routes table for retrieval: %v", err)
+	}
+
+	err = store.AlterRoutesTableForGuardrails()
+	if err != nil {
+		log.Sugar().Fatalf("error altering routes table for guardrails: %v", err)
+	}
+
+	err = store.AlterRoutesTableForCors()
+	if err != nil {
+		log.Sugar().Fatalf("error altering routes table for cors: %v", err)
+	}
+
+	err = store.AlterEventsTableForFeedback()
+	if err != nil {
+		log.Sugar().Fatalf("error altering events table for feedback: %v", err)
+	}
+
+	err = store.AlterEventsTableForTasks()
+	if err != nil {
+		log.Sugar().Fatalf("error altering events table for tasks: %v", err)
+	}
+
+	err = store.AlterEventsTableForTracing()
+	if err != nil {
+		log.Sugar().Fatalf("error altering events table for tracing: %v", err)
+	}
+
+	err = store.AlterEventsTableForClients()
+	if err != nil {
+		log.Sugar().Fatalf("error altering events table for clients: %v", err)
+	}
+
+	err = store.AlterEventsTableForEnvironment()
+	if err != nil {
+		log.Sugar().Fatalf("error altering events table for environment: %v", err)
+	}
+
+	err = store.AlterEventsTableForDecisions()
+	if err != nil {
+		log.Sugar().Fatalf("error altering events table for decisions: %v", err)
+	}
+
+	err = store.CreateReconciliationsTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating reconciliations table: %v", err)
+	}
+
+	err = store.CreateBundlesTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating bundles table: %v", err)
+	}
+
+	err = store.CreateAdminTokensTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating admin tokens table: %v", err)
+	}
+
 	memStore, err := memdb.NewMemDb(store, log, cfg.InMemoryDbUpdateInterval)
 	if err != nil {
 		log.Sugar().Fatalf("cannot initialize memdb: %v", err)
@@ -125,22 +436,56 @@ func main() {
 	}
 	rMemStore.Listen()
 
-	rateLimitRedisCache := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%s", cfg.RedisHosts, cfg.RedisPort),
-		Password: cfg.RedisPassword,
-		DB:       0,
-	})
+	prMemStore, err := memdb.NewPricesMemDb(store, log, cfg.InMemoryDbUpdateInterval)
+	if err != nil {
+		log.Sugar().Fatalf("cannot initialize prices memdb: %v", err)
+	}
+	prMemStore.Listen()
+
+	ptMemStore, err := memdb.NewPromptTemplatesMemDb(store, log, cfg.InMemoryDbUpdateInterval)
+	if err != nil {
+		log.Sugar().Fatalf("cannot initialize prompt templates memdb: %v", err)
+	}
+	ptMemStore.Listen()
+
+	mrMemStore, err := memdb.NewMockResponsesMemDb(store, log, cfg.InMemoryDbUpdateInterval)
+	if err != nil {
+		log.Sugar().Fatalf("cannot initialize mock responses memdb: %v", err)
+	}
+	mrMemStore.Listen()
+
+	plMemStore, err := memdb.NewPoliciesMemDb(store, log, cfg.InMemoryDbUpdateInterval)
+	if err != nil {
+		log.Sugar().Fatalf("cannot initialize policies memdb: %v", err)
+	}
+	plMemStore.Listen()
+
+	pthMemStore, err := memdb.NewPassThroughsMemDb(store, log, cfg.InMemoryDbUpdateInterval)
+	if err != nil {
+		log.Sugar().Fatalf("cannot initialize pass-throughs memdb: %v", err)
+	}
+	pthMemStore.Listen()
+
+	tMemStore, err := memdb.NewTenantsMemDb(store, log, cfg.InMemoryDbUpdateInterval)
+	if err != nil {
+		log.Sugar().Fatalf("cannot initialize tenants memdb: %v", err)
+	}
+	tMemStore.Listen()
+
+	atMemStore, err := memdb.NewAdminTokensMemDb(store, log, cfg.InMemoryDbUpdateInterval)
+	if err != nil {
+		log.Sugar().Fatalf("cannot initialize admin tokens memdb: %v", err)
+	}
+	atMemStore.Listen()
+
+	rateLimitRedisCache := redis.NewClient(newRedisOptions(cfg, cfg.RedisRateLimitAddr, cfg.RedisRateLimitPassword, 0))
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 	if err := rateLimitRedisCache.Ping(ctx).Err(); err != nil {
 		log.Sugar().Fatalf("error connecting to rate limit redis cache: %v", err)
 	}
 
-	costLimitRedisCache := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%s", cfg.RedisHosts, cfg.RedisPort),
-		Password: cfg.RedisPassword,
-		DB:       1,
-	})
+	costLimitRedisCache := redis.NewClient(newRedisOptions(cfg, cfg.RedisCostAddr, cfg.RedisCostPassword, 1))
 
 	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
@@ -148,11 +493,7 @@ func main() {
 		log.Sugar().Fatalf("error connecting to cost limit redis cache: %v", err)
 	}
 
-	costRedisStorage := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%s", cfg.RedisHosts, cfg.RedisPort),
-		Password: cfg.RedisPassword,
-		DB:       2,
-	})
+	costRedisStorage := redis.NewClient(newRedisOptions(cfg, cfg.RedisCostAddr, cfg.RedisCostPassword, 2))
 
 	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
@@ -160,11 +501,7 @@ func main() {
 		log.Sugar().Fatalf("error connecting to cost limit redis storage: %v", err)
 	}
 
-	apiRedisCache := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%s", cfg.RedisHosts, cfg.RedisPort),
-		Password: cfg.RedisPassword,
-		DB:       3,
-	})
+	apiRedisCache := redis.NewClient(newRedisOptions(cfg, cfg.RedisCacheAddr, cfg.RedisCachePassword, 3))
 
 	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
@@ -172,16 +509,36 @@ func main() {
 		log.Sugar().Fatalf("error connecting to api redis cache: %v", err)
 	}
 
-	accessRedisCache := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%s", cfg.RedisHosts, cfg.RedisPort),
-		Password: cfg.RedisPassword,
-		DB:       4,
-	})
+	quotaRedisStorage := redis.NewClient(newRedisOptions(cfg, "", "", 5))
 
 	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
-	if err := apiRedisCache.Ping(ctx).Err(); err != nil {
-		log.Sugar().Fatalf("error connecting to api redis cache: %v", err)
+	if err := quotaRedisStorage.Ping(ctx).Err(); err != nil {
+		log.Sugar().Fatalf("error connecting to quota redis storage: %v", err)
+	}
+
+	sessionRedisStorage := redis.NewClient(newRedisOptions(cfg, "", "", 6))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := sessionRedisStorage.Ping(ctx).Err(); err != nil {
+		log.Sugar().Fatalf("error connecting to session redis storage: %v", err)
+	}
+
+	adminRedisStorage := redis.NewClient(newRedisOptions(cfg, "", "", 7))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := adminRedisStorage.Ping(ctx).Err(); err != nil {
+		log.Sugar().Fatalf("error connecting to admin redis storage: %v", err)
+	}
+
+	accessRedisCache := redis.NewClient(newRedisOptions(cfg, cfg.RedisAccessAddr, cfg.RedisAccessPassword, 4))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := accessRedisCache.Ping(ctx).Err(); err != nil {
+		log.Sugar().Fatalf("error connecting to access redis cache: %v", err)
 	}
 
 	rateLimitCache := redisStorage.NewCache(rateLimitRedisCache, cfg.RedisWriteTimeout, cfg.RedisReadTimeout)
@@ -189,37 +546,248 @@ func main() {
 	costStorage := redisStorage.NewStore(costRedisStorage, cfg.RedisWriteTimeout, cfg.RedisReadTimeout)
 	apiCache := redisStorage.NewCache(apiRedisCache, cfg.RedisWriteTimeout, cfg.RedisReadTimeout)
 	accessCache := redisStorage.NewAccessCache(accessRedisCache, cfg.RedisWriteTimeout, cfg.RedisReadTimeout)
+	quotaStorage := redisStorage.NewQuotaStore(quotaRedisStorage, cfg.RedisWriteTimeout, cfg.RedisReadTimeout)
+	sessionStorage := redisStorage.NewSessionStore(sessionRedisStorage, cfg.RedisWriteTimeout, cfg.RedisReadTimeout)
+	pauseStorage := redisStorage.NewPauseStore(adminRedisStorage, cfg.RedisWriteTimeout, cfg.RedisReadTimeout)
+	adminAuthStorage := redisStorage.NewAdminAuthStore(adminRedisStorage, cfg.RedisWriteTimeout, cfg.RedisReadTimeout)
 
-	m := manager.NewManager(store)
-	krm := manager.NewReportingManager(costStorage, store, store)
-	psm := manager.NewProviderSettingsManager(store, psMemStore)
-	cpm := manager.NewCustomProvidersManager(store, cpMemStore)
-	rm := manager.NewRouteManager(store, store, rMemStore, psMemStore)
+	paMemStore, err := memdb.NewPausesMemDb(pauseStorage, log, cfg.PauseSyncInterval)
+	if err != nil {
+		log.Sugar().Fatalf("cannot initialize pauses memdb: %v", err)
+	}
+	paMemStore.Listen()
+
+	providerHealthStorage := redisStorage.NewProviderHealthStore(quotaRedisStorage, cfg.RedisWriteTimeout, cfg.RedisReadTimeout)
 
-	as, err := admin.NewAdminServer(log, *modePtr, m, krm, psm, cpm, rm, cfg.AdminPass)
+	phMemStore, err := memdb.NewProviderHealthMemDb(providerHealthStorage, log, cfg.ProviderHealthSyncInterval)
 	if err != nil {
-		log.Sugar().Fatalf("error creating admin http server: %v", err)
+		log.Sugar().Fatalf("cannot initialize provider health memdb: %v", err)
 	}
+	phMemStore.Listen()
+
+	m := manager.NewManager(store)
+	krm := manager.NewReportingManager(costStorage, store, store, store)
+	anm := manager.NewAnalyticsManager(krm, cfg.AnalyticsProxyUrl, cfg.AnalyticsRoute, cfg.AnalyticsApiKey, cfg.AnalyticsTimeout)
+	psm := manager.NewProviderSettingsManager(store, psMemStore, quotaStorage, providerHealthStorage)
+	cpm := manager.NewCustomProvidersManager(store, cpMemStore)
+	rm := manager.NewRouteManager(store, store, rMemStore, psMemStore)
+	bdm := manager.NewBundleManager(store, m, rm, cfg.ConfigExportSecret)
+	pm := manager.NewPricingManager(store)
+	tgm := manager.NewTagManager(store)
+	nf := notification.NewNotifier(store, log)
+	nm := manager.NewNotificationManager(store, nf)
+	pbm := manager.NewProbeManager(store)
 
 	tc := openai.NewTokenCounter()
 	custom.NewTokenCounter()
 
-	as.Run()
-
-	ce := openai.NewCostEstimator(openai.OpenAiPerThousandTokenCost, tc)
+	ce := openai.NewCostEstimator(openai.OpenAiPerThousandTokenCost, tc, prMemStore)
 
 	atc, err := anthropic.NewTokenCounter()
 	if err != nil {
 		log.Sugar().Fatalf("error creating anthropic token counter: %v", err)
 	}
 
-	ace := anthropic.NewCostEstimator(atc)
-	aoe := azure.NewCostEstimator()
+	ace := anthropic.NewCostEstimator(atc, prMemStore)
+	multipliers, err := azure.ParseRegionalMultipliers(cfg.AzureRegionalPriceMultipliers)
+	if err != nil {
+		log.Sugar().Fatalf("error parsing azure regional price multipliers: %v", err)
+	}
+
+	aoe := azure.NewCostEstimator(prMemStore, multipliers)
+	em := manager.NewEstimationManager(ce, aoe, ace)
+	rcm := manager.NewRecomputationManager(store, ce, aoe, ace)
+	ptm := manager.NewPromptTemplateManager(store)
+	mm := manager.NewMockManager(store)
+	plm := manager.NewPolicyManager(store)
+	pthm := manager.NewPassThroughManager(store)
+	rcdm := manager.NewRecordingManager(store, log, cfg.RecordedRequestsPurgeInterval)
+	pz := privacy.NewPseudonymizer(cfg.PseudonymizationSecret, cfg.PseudonymizationRotation)
+	sjm := manager.NewSubjectManager(store, log, cfg.SubjectRequestPollInterval, pz, cfg.PseudonymizationLookback)
+	smpm := manager.NewSampleManager(store)
+	rpm := manager.NewReplayManager(store, ce, aoe)
+	tm := manager.NewTenantManager(store)
+	atm := manager.NewAdminTokenManager(store, atMemStore)
+	pam := manager.NewPauseManager(pauseStorage, store)
+	ssm := manager.NewSelfServiceManager(m, costStorage, costLimitCache)
+	fbm := manager.NewFeedbackManager(store)
+	scm := manager.NewScheduleManager(store, cfg.ScheduleWebhookHosts, len(cfg.ScheduleS3AccessKeyId) != 0 && len(cfg.ScheduleS3SecretAccessKey) != 0)
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Sugar().Infof("error getting host name, async jobs of previous runs are failed once they are stale: %v", err)
+	}
+
+	jbm := manager.NewJobManager(store, cfg.AsyncJobRetention, cfg.AsyncJobWebhookHosts, hostname, log, cfg.AsyncJobCleanUpInterval)
+	cw := manager.NewConfigWatcher(store, log, cfg.WatchPollInterval)
+	cw.Listen()
+
+	ssy := spendsync.NewSyncer(cfg.ClusterId, cfg.SpendSyncPeers, cfg.SpendSyncToken, cfg.SpendSyncInterval, store, costStorage, costLimitCache, log)
+	var rw *replica.Writer
+	if len(cfg.ReplicaSnapshotPath) != 0 {
+		rw = replica.NewWriter(store, cfg.ReplicaSnapshotPath, cfg.ReplicaSnapshotInterval, log)
+		rw.Listen()
+	}
+
+	if len(cfg.SpendSyncPeers) != 0 {
+		if !ssy.Enabled() {
+			log.Sugar().Fatal("cluster id is required for syncing spend with peers")
+		}
+
+		ssy.Listen()
+	}
+
+	ag := admin.NewAuthGuard(adminAuthStorage, cfg.AdminRateLimit, cfg.AdminMaxFailedAttempts, cfg.AdminLockoutDuration, cfg.AdminMaxLockoutDuration)
+
+	// the bootstrap token is only needed until the first admin token exists.
+	var bs *admin.Bootstrap
+	if !atMemStore.HasTokens() {
+		bootstrapToken := cfg.AdminBootstrapToken
+		if len(bootstrapToken) == 0 && cfg.AdminBootstrapGenerate {
+			bootstrapToken, err = manager.NewBootstrapToken()
+			if err != nil {
+				log.Sugar().Fatalf("error generating admin bootstrap token: %v", err)
+			}
+
+			log.Sugar().Warnf("admin bootstrap token: %s. use it once to create an admin token with POST /api/admin-tokens", bootstrapToken)
+		}
+
+		if len(bootstrapToken) != 0 {
+			bs = admin.NewBootstrap(bootstrapToken, atMemStore)
+		}
+	}
+
+	as, err := admin.NewAdminServer(log, *modePtr, m, krm, psm, cpm, rm, em, pm, tgm, nm, pbm, rcm, ptm, mm, plm, pthm, rpm, rcdm, sjm, smpm, tm, pam, scm, cw, bdm, ssy, anm, atm, tMemStore, atMemStore, bs, ag, logLevel, cfg.AdminPass, cfg.TrustedProxies)
+	if err != nil {
+		log.Sugar().Fatalf("error creating admin http server: %v", err)
+	}
+
+	as.Run()
+
+	sm := manager.NewSloMonitor(krm, log, cfg.SloReportingInterval)
+	sm.Listen()
+	rcdm.Listen()
+	sjm.Listen()
+	jbm.Listen()
+
+	var ds *digest.Scheduler
+	if len(cfg.DigestFrequency) != 0 {
+		if cfg.DigestFrequency != digest.FrequencyDaily && cfg.DigestFrequency != digest.FrequencyWeekly {
+			log.Sugar().Fatalf("digest frequency %s is not supported", cfg.DigestFrequency)
+		}
+
+		senders := []digest.Sender{}
+		for _, url := range cfg.DigestSlackWebhookUrls {
+			senders = append(senders, digest.NewSlackSender(url))
+		}
+
+		if len(cfg.DigestEmailAddresses) != 0 {
+			if len(cfg.SmtpHost) == 0 || len(cfg.SmtpFrom) == 0 {
+				log.Sugar().Fatal("smtp host and from address are required for sending digest emails")
+			}
+
+			senders = append(senders, digest.NewEmailSender(cfg.SmtpHost, cfg.SmtpPort, cfg.SmtpUsername, cfg.SmtpPassword, cfg.SmtpFrom, cfg.DigestEmailAddresses))
+		}
+
+		ds = digest.NewScheduler(digest.NewBuilder(krm), senders, cfg.DigestFrequency, log)
+		ds.Start()
+	}
+
+	var rcr *reconciliation.Reconciler
+	if cfg.ReconciliationEnabled {
+		rcr = reconciliation.NewReconciler(store, cfg.ReconciliationThreshold, cfg.ReconciliationMinDifference, cfg.ReconciliationLookbackDays, log)
+		rcr.Start()
+	}
+
+	healthSenders := []digest.Sender{}
+	for _, url := range cfg.ProviderHealthSlackWebhookUrls {
+		healthSenders = append(healthSenders, digest.NewSlackSender(url))
+	}
+
+	if len(cfg.ProviderHealthEmailAddresses) != 0 {
+		if len(cfg.SmtpHost) == 0 || len(cfg.SmtpFrom) == 0 {
+			log.Sugar().Fatal("smtp host and from address are required for sending provider health emails")
+		}
+
+		healthSenders = append(healthSenders, digest.NewEmailSender(cfg.SmtpHost, cfg.SmtpPort, cfg.SmtpUsername, cfg.SmtpPassword, cfg.SmtpFrom, cfg.ProviderHealthEmailAddresses))
+	}
+
+	healthSenders = append(healthSenders, nf.Sender(notification.EventCircuitBreaker))
+	phm := manager.NewProviderHealthManager(providerHealthStorage, healthSenders, cfg.ProviderAuthFailureThreshold, []digest.Sender{nf.Sender(notification.EventProviderOutage)}, cfg.ProviderOutageThreshold, log)
+
+	outageSenders := []digest.Sender{}
+	for _, url := range cfg.RedisAlertSlackWebhookUrls {
+		outageSenders = append(outageSenders, digest.NewSlackSender(url))
+	}
+
+	if len(cfg.RedisAlertEmailAddresses) != 0 {
+		if len(cfg.SmtpHost) == 0 || len(cfg.SmtpFrom) == 0 {
+			log.Sugar().Fatal("smtp host and from address are required for sending redis outage emails")
+		}
+
+		outageSenders = append(outageSenders, digest.NewEmailSender(cfg.SmtpHost, cfg.SmtpPort, cfg.SmtpUsername, cfg.SmtpPassword, cfg.SmtpFrom, cfg.RedisAlertEmailAddresses))
+	}
+
+	outageSenders = append(outageSenders, nf.Sender(notification.EventRedisOutage))
+
+	var dm *drift.Monitor
+	if cfg.TokenDriftSamplingPercentage > 0 {
+		driftSenders := []digest.Sender{}
+		for _, url := range cfg.TokenDriftSlackWebhookUrls {
+			driftSenders = append(driftSenders, digest.NewSlackSender(url))
+		}
+
+		if len(cfg.TokenDriftEmailAddresses) != 0 {
+			if len(cfg.SmtpHost) == 0 || len(cfg.SmtpFrom) == 0 {
+				log.Sugar().Fatal("smtp host and from address are required for sending token drift emails")
+			}
+
+			driftSenders = append(driftSenders, digest.NewEmailSender(cfg.SmtpHost, cfg.SmtpPort, cfg.SmtpUsername, cfg.SmtpPassword, cfg.SmtpFrom, cfg.TokenDriftEmailAddresses))
+		}
+
+		driftSenders = append(driftSenders, nf.Sender(notification.EventAnomaly))
+		dm = drift.NewMonitor(driftSenders, cfg.TokenDriftSamplingPercentage, cfg.TokenDriftThreshold, cfg.TokenDriftMinSamples, cfg.TokenDriftCheckInterval, log)
+		dm.Listen()
+	}
+
+	nw := notification.NewWatcher(nf, krm, cfg.NotificationCheckInterval, log)
+	nw.Listen()
+
+	var pr *probe.Runner
+	if cfg.ProbesEnabled {
+		pr = probe.NewRunner(store, phm, []digest.Sender{nf.Sender(notification.EventProbe)}, cfg.ProbeFailureThreshold, cfg.ProbeCheckInterval, log)
+		pr.Listen()
+	}
+
+	om := outage.NewMonitor(outageSenders, cfg.RedisHealthCheckInterval, cfg.RedisReadTimeout, log)
+	for _, w := range []struct {
+		subsystem string
+		mode      string
+		client    *redis.Client
+	}{
+		{outage.SubsystemRateLimit, cfg.RedisRateLimitFailureMode, rateLimitRedisCache},
+		{outage.SubsystemCostLimit, cfg.RedisCostLimitFailureMode, costLimitRedisCache},
+		{outage.SubsystemCache, cfg.RedisCacheFailureMode, apiRedisCache},
+		{outage.SubsystemAccess, cfg.RedisAccessFailureMode, accessRedisCache},
+	} {
+		if err := om.Watch(w.subsystem, w.mode, w.client); err != nil {
+			log.Sugar().Fatalf("error watching redis availability: %v", err)
+		}
+	}
+	om.Listen()
 
 	v := validator.NewValidator(costLimitCache, rateLimitCache, costStorage)
-	rec := recorder.NewRecorder(costStorage, costLimitCache, ce, store)
+	var eb *recorder.EventBuffer
+	if len(cfg.EventBufferDir) != 0 {
+		eb, err = recorder.NewEventBuffer(cfg.EventBufferDir, cfg.EventBufferMaxSizeInMb*1024*1024, store, cfg.EventBufferReplayInterval, log)
+		if err != nil {
+			log.Sugar().Fatalf("cannot initialize event buffer: %v", err)
+		}
+		eb.Listen()
+	}
+
+	rec := recorder.NewRecorder(costStorage, costLimitCache, ce, store, sessionStorage, eb, pz, envLabels)
 	rlm := manager.NewRateLimitManager(rateLimitCache)
-	a := auth.NewAuthenticator(psm, memStore, rm)
+	a := auth.NewAuthenticator(psm, memStore, rm, phMemStore)
 
 	c := cache.NewCache(apiCache)
 
@@ -232,14 +800,18 @@ func main() {
 	eventConsumer := message.NewConsumer(eventMessageChan, log, 4, handler.HandleEventWithRequestAndResponse)
 	eventConsumer.StartEventMessageConsumers()
 
-	ps, err := proxy.NewProxyServer(log, *modePtr, *privacyPtr, c, m, rm, a, psm, cpm, store, memStore, ce, ace, aoe, v, rec, messageBus, rlm, cfg.ProxyTimeout, accessCache)
+	if cfg.SamplingPercentage < 0 || cfg.SamplingPercentage > 100 {
+		log.Sugar().Fatalf("sampling percentage %v must be between 0 and 100", cfg.SamplingPercentage)
+	}
+
+	ps, err := proxy.NewProxyServer(log, *modePtr, *privacyPtr, c, m, rm, a, psm, cpm, store, memStore, ce, ace, aoe, v, rec, messageBus, rlm, cfg.ProxyTimeout, accessCache, quotaStorage, sessionStorage, ptMemStore, mrMemStore, plMemStore, pthMemStore, paMemStore, phm, om, dm, ssm, fbm, jbm, scm, schedule.NewDeliverer(cfg.ScheduleWebhookHosts, cfg.ScheduleS3AccessKeyId, cfg.ScheduleS3SecretAccessKey), store, store, cfg.ProxyResponseCompression, cfg.RecordRequests, cfg.TracingEnabled, cfg.SamplingPercentage, cfg.AsyncJobWorkers, cfg.AsyncJobQueueSize, cfg.AsyncJobMaxAttempts, cfg.AsyncJobWebhookHosts, cfg.SchedulePollInterval, cp, rf, cfg.TrustedProxies)
 	if err != nil {
 		log.Sugar().Fatalf("error creating proxy http server: %v", err)
 	}
 
 	ps.Run()
 
-	quit := make(chan os.Signal)
+	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
@@ -248,6 +820,50 @@ func main() {
 	psMemStore.Stop()
 	cpMemStore.Stop()
 	rMemStore.Stop()
+	prMemStore.Stop()
+	ptMemStore.Stop()
+	mrMemStore.Stop()
+	plMemStore.Stop()
+	pthMemStore.Stop()
+	tMemStore.Stop()
+	atMemStore.Stop()
+	paMemStore.Stop()
+	phMemStore.Stop()
+	om.Stop()
+	nw.Stop()
+
+	if eb != nil {
+		eb.Stop()
+	}
+	cw.Stop()
+	sm.Stop()
+	rcdm.Stop()
+	sjm.Stop()
+	jbm.Stop()
+
+	if ds != nil {
+		ds.Stop()
+	}
+
+	if dm != nil {
+		dm.Stop()
+	}
+
+	if rcr != nil {
+		rcr.Stop()
+	}
+
+	if pr != nil {
+		pr.Stop()
+	}
+
+	if len(cfg.SpendSyncPeers) != 0 {
+		ssy.Stop()
+	}
+
+	if rw != nil {
+		rw.Stop()
+	}
 
 	log.Sugar().Infof("shutting down server...")
 
@@ -270,3 +886,42 @@ func main() {
 
 	log.Info("server exited")
 }
+
+// newRedisOptions returns the options of a redis client. Functions without a
+// dedicated endpoint fall back to the shared REDIS_HOSTS and REDIS_PORT.
+func newRedisOptions(cfg *config.Config, addr, password string, db int) *redis.Options {
+	if len(addr) == 0 {
+		addr = fmt.Sprintf("%s:%s", cfg.RedisHosts, cfg.RedisPort)
+		password = cfg.RedisPassword
+	} else if len(password) == 0 {
+		password = cfg.RedisPassword
+	}
+
+	return &redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       db,
+	}
+}
+
+// newCorsPolicy returns the global cors policy of the proxy. Cors is disabled
+// for routes without their own policy when no origins are allowed.
+func newCorsPolicy(cfg *config.Config) (*cors.Policy, error) {
+	if len(cfg.CorsAllowedOrigins) == 0 {
+		return nil, nil
+	}
+
+	p := &cors.Policy{
+		AllowedOrigins:   cfg.CorsAllowedOrigins,
+		AllowedHeaders:   cfg.CorsAllowedHeaders,
+		ExposedHeaders:   cfg.CorsExposedHeaders,
+		MaxAge:           int(cfg.CorsMaxAge.Seconds()),
+		AllowCredentials: cfg.CorsAllowCredentials,
+	}
+
+	if invalid := p.Validate("cors"); len(invalid) != 0 {
+		return nil, fmt.Errorf("invalid fields in cors policy: %s", strings.Join(invalid, ","))
+	}
+
+	return p, nil
+}
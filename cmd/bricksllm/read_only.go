@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	auth "github.com/bricks-cloud/bricksllm/internal/authenticator"
+	"github.com/bricks-cloud/bricksllm/internal/cache"
+	"github.com/bricks-cloud/bricksllm/internal/config"
+	"github.com/bricks-cloud/bricksllm/internal/cors"
+	"github.com/bricks-cloud/bricksllm/internal/manager"
+	"github.com/bricks-cloud/bricksllm/internal/replica"
+	"github.com/bricks-cloud/bricksllm/internal/server/web/proxy"
+	"github.com/bricks-cloud/bricksllm/internal/storage/memdb"
+	redisStorage "github.com/bricks-cloud/bricksllm/internal/storage/redis"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// runReadOnly serves the proxy from the last replica snapshot while Postgres
+// is unreachable. The admin server is not started and the proxy has to be
+// restarted once Postgres is back.
+func runReadOnly(cfg *config.Config, log *zap.Logger, mode string, cp *cors.Policy) {
+	if len(cfg.ReplicaSnapshotPath) == 0 {
+		log.Sugar().Fatal("replica snapshot path is required for starting in read-only mode")
+	}
+
+	snapshot, err := replica.Load(cfg.ReplicaSnapshotPath)
+	if err != nil {
+		log.Sugar().Fatalf("cannot load replica snapshot: %v", err)
+	}
+
+	log.Sugar().Warnf("serving read-only from replica snapshot taken at %d", snapshot.CreatedAt)
+
+	clients := map[string]*redis.Client{
+		"api redis cache":     redis.NewClient(newRedisOptions(cfg, cfg.RedisCacheAddr, cfg.RedisCachePassword, 3)),
+		"quota redis storage": redis.NewClient(newRedisOptions(cfg, "", "", 5)),
+		"admin redis storage": redis.NewClient(newRedisOptions(cfg, "", "", 7)),
+	}
+
+	for name, client := range clients {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		err := client.Ping(ctx).Err()
+		cancel()
+		if err != nil {
+			log.Sugar().Fatalf("error connecting to %s: %v", name, err)
+		}
+	}
+
+	memStore, err := memdb.NewMemDb(snapshot, log, cfg.InMemoryDbUpdateInterval)
+	if err != nil {
+		log.Sugar().Fatalf("cannot initialize memdb: %v", err)
+	}
+
+	psMemStore, err := memdb.NewProviderSettingsMemDb(snapshot, log, cfg.InMemoryDbUpdateInterval)
+	if err != nil {
+		log.Sugar().Fatalf("cannot initialize provider settings memdb: %v", err)
+	}
+
+	rMemStore, err := memdb.NewRoutesMemDb(snapshot, log, cfg.InMemoryDbUpdateInterval)
+	if err != nil {
+		log.Sugar().Fatalf("cannot initialize routes memdb: %v", err)
+	}
+
+	mrMemStore, err := memdb.NewMockResponsesMemDb(snapshot, log, cfg.InMemoryDbUpdateInterval)
+	if err != nil {
+		log.Sugar().Fatalf("cannot initialize mock responses memdb: %v", err)
+	}
+
+	pauseStorage := redisStorage.NewPauseStore(clients["admin redis storage"], cfg.RedisWriteTimeout, cfg.RedisReadTimeout)
+	paMemStore, err := memdb.NewPausesMemDb(pauseStorage, log, cfg.PauseSyncInterval)
+	if err != nil {
+		log.Sugar().Fatalf("cannot initialize pauses memdb: %v", err)
+	}
+	paMemStore.Listen()
+
+	providerHealthStorage := redisStorage.NewProviderHealthStore(clients["quota redis storage"], cfg.RedisWriteTimeout, cfg.RedisReadTimeout)
+	phMemStore, err := memdb.NewProviderHealthMemDb(providerHealthStorage, log, cfg.ProviderHealthSyncInterval)
+	if err != nil {
+		log.Sugar().Fatalf("cannot initialize provider health memdb: %v", err)
+	}
+	phMemStore.Listen()
+
+	apiCache := redisStorage.NewCache(clients["api redis cache"], cfg.RedisWriteTimeout, cfg.RedisReadTimeout)
+	psm := manager.NewProviderSettingsManager(nil, psMemStore, nil, nil)
+	rm := manager.NewRouteManager(nil, nil, rMemStore, psMemStore)
+	a := auth.NewAuthenticator(psm, memStore, rm, phMemStore)
+
+	ps := proxy.NewReadOnlyServer(log, mode, a, rm, cache.NewCache(apiCache), mrMemStore, paMemStore, cp)
+	ps.Run()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	paMemStore.Stop()
+	phMemStore.Stop()
+
+	log.Sugar().Infof("shutting down read-only server...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := ps.Shutdown(ctx); err != nil {
+		log.Sugar().Debugf("read-only proxy server shutdown: %v", err)
+	}
+
+	log.Info("server exited")
+}
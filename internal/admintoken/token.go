@@ -0,0 +1,56 @@
+package admintoken
+
+import (
+	"fmt"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+// Token is a credential with full access to the admin API, like ADMIN_PASS.
+type Token struct {
+	Id        string `json:"id"`
+	CreatedAt int64  `json:"createdAt"`
+	UpdatedAt int64  `json:"updatedAt"`
+	Name      string `json:"name"`
+	Revoked   bool   `json:"revoked"`
+
+	// Token is only returned when it is created. Only the hash of the token
+	// is stored.
+	Token     string `json:"token,omitempty"`
+	TokenHash string `json:"-"`
+}
+
+func (t *Token) Validate() error {
+	invalid := []string{}
+
+	if len(strings.TrimSpace(t.Name)) == 0 || len(t.Name) > 255 {
+		invalid = append(invalid, "name")
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
+
+type UpdateToken struct {
+	UpdatedAt int64   `json:"updatedAt"`
+	Name      *string `json:"name"`
+	Revoked   *bool   `json:"revoked"`
+}
+
+func (ut *UpdateToken) Validate() error {
+	invalid := []string{}
+
+	if ut.Name != nil && (len(strings.TrimSpace(*ut.Name)) == 0 || len(*ut.Name) > 255) {
+		invalid = append(invalid, "name")
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
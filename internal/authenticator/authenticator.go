@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -10,7 +11,9 @@ import (
 	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
 
 	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/mock"
 	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/provider/azure"
 	"github.com/bricks-cloud/bricksllm/internal/route"
 )
 
@@ -19,24 +22,32 @@ type providerSettingsManager interface {
 }
 
 type routesManager interface {
-	GetRouteFromMemDb(path string) *route.Route
+	GetRouteFromMemDb(tenantId, path string) *route.Route
+	GetAuthRouteFromMemDb(path string) *route.Route
 }
 
 type keyMemStorage interface {
 	GetKey(hash string) *key.ResponseKey
+	GetKeyById(keyId string) *key.ResponseKey
+}
+
+type providerHealthMemStorage interface {
+	IsUnhealthy(settingId string) bool
 }
 
 type Authenticator struct {
 	psm providerSettingsManager
 	kms keyMemStorage
 	rm  routesManager
+	phs providerHealthMemStorage
 }
 
-func NewAuthenticator(psm providerSettingsManager, kms keyMemStorage, rm routesManager) *Authenticator {
+func NewAuthenticator(psm providerSettingsManager, kms keyMemStorage, rm routesManager, phs providerHealthMemStorage) *Authenticator {
 	return &Authenticator{
 		psm: psm,
 		kms: kms,
 		rm:  rm,
+		phs: phs,
 	}
 }
 
@@ -61,42 +72,68 @@ func getApiKey(req *http.Request) (string, error) {
 	return "", internal_errors.NewAuthError("api key not found")
 }
 
+type keyHashContextKey struct{}
+
+// WithKeyHash authenticates a request made by the gateway itself, such as a
+// scheduled run, with the hash of a key since secrets are never stored.
+// Context values cannot be set by clients.
+func WithKeyHash(ctx context.Context, hash string) context.Context {
+	return context.WithValue(ctx, keyHashContextKey{}, hash)
+}
+
+func getKeyHash(req *http.Request) (string, error) {
+	if hash, ok := req.Context().Value(keyHashContextKey{}).(string); ok && len(hash) != 0 {
+		return hash, nil
+	}
+
+	raw, err := getApiKey(req)
+	if err != nil {
+		return "", err
+	}
+
+	return encrypter.Encrypt(raw), nil
+}
+
 func rewriteHttpAuthHeader(req *http.Request, setting *provider.Setting) error {
 	uri := req.URL.RequestURI()
 	if strings.HasPrefix(uri, "/api/routes") {
 		return nil
 	}
 
-	apiKey := setting.GetParam("apikey")
+	if setting.Provider == mock.ProviderName {
+		return nil
+	}
+
+	if strings.HasPrefix(uri, "/api/providers/azure") {
+		return azure.SetAuthHeader(req.Header, setting)
+	}
+
+	apiKey := setting.NextApiKey()
 
 	if len(apiKey) == 0 {
 		return errors.New("api key is empty in provider setting")
 	}
 
 	if strings.HasPrefix(uri, "/api/providers/anthropic") {
-		req.Header.Set("x-api-key", setting.GetParam("apikey"))
-		return nil
-	}
-
-	if strings.HasPrefix(uri, "/api/providers/azure") {
-		req.Header.Set("api-key", setting.GetParam("apikey"))
+		req.Header.Set("x-api-key", apiKey)
 		return nil
 	}
 
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", setting.GetParam("apikey")))
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiKey))
+	setting.ApplyOpenAiHeaders(req.Header)
 
 	return nil
 }
 
-func (a *Authenticator) canKeyAccessCustomRoute(path string, keyId string) error {
+func (a *Authenticator) canKeyAccessCustomRoute(path string, k *key.ResponseKey) error {
 	trimed := strings.TrimPrefix(path, "/api/routes")
-	rc := a.rm.GetRouteFromMemDb(trimed)
+	rc := a.rm.GetRouteFromMemDb(k.TenantId, trimed)
 	if rc == nil {
 		return internal_errors.NewNotFoundError("route not found")
 	}
 
 	for _, kid := range rc.KeyIds {
-		if kid == keyId {
+		if kid == k.KeyId {
 			return nil
 		}
 	}
@@ -104,9 +141,12 @@ func (a *Authenticator) canKeyAccessCustomRoute(path string, keyId string) error
 	return internal_errors.NewAuthError("not authorized")
 }
 
-func (a *Authenticator) getProviderSettingsThatCanAccessCustomRoute(path string, settings []*provider.Setting) []*provider.Setting {
+// getProviderSettingsThatCanAccessCustomRoute skips the steps of providers
+// whose settings are all unhealthy or outside the regions of the key so that
+// the route falls back to its remaining steps.
+func (a *Authenticator) getProviderSettingsThatCanAccessCustomRoute(path string, k *key.ResponseKey, settings []*provider.Setting, skipped map[string]bool) []*provider.Setting {
 	trimed := strings.TrimPrefix(path, "/api/routes")
-	rc := a.rm.GetRouteFromMemDb(trimed)
+	rc := a.rm.GetRouteFromMemDb(k.TenantId, trimed)
 
 	selected := []*provider.Setting{}
 	if rc == nil {
@@ -124,6 +164,10 @@ func (a *Authenticator) getProviderSettingsThatCanAccessCustomRoute(path string,
 	}
 
 	for p := range target {
+		if source[p] == nil && skipped[p] {
+			continue
+		}
+
 		if source[p] == nil {
 			return []*provider.Setting{}
 		}
@@ -131,6 +175,11 @@ func (a *Authenticator) getProviderSettingsThatCanAccessCustomRoute(path string,
 		selected = append(selected, source[p])
 	}
 
+	// the shadow step is optional and is skipped if its provider is missing.
+	if rc.Shadow != nil && rc.Shadow.Step != nil && !target[rc.Shadow.Step.Provider] && source[rc.Shadow.Step.Provider] != nil {
+		selected = append(selected, source[rc.Shadow.Step.Provider])
+	}
+
 	return selected
 }
 
@@ -150,21 +199,77 @@ func canAccessPath(provider string, path string) bool {
 	return true
 }
 
-func (a *Authenticator) AuthenticateHttpRequest(req *http.Request) (*key.ResponseKey, []*provider.Setting, error) {
-	raw, err := getApiKey(req)
+// AuthenticateKey looks up the key of a request without selecting provider
+// settings.
+func (a *Authenticator) AuthenticateKey(req *http.Request) (*key.ResponseKey, error) {
+	hash, err := getKeyHash(req)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	hash := encrypter.Encrypt(raw)
+	k := a.kms.GetKey(hash)
+	if k == nil || k.Revoked {
+		return nil, internal_errors.NewAuthError("not authorized")
+	}
+
+	return k, nil
+}
+
+// getRouteAuthKey authenticates a request to a route with its own auth as
+// the key of the route. Requests with a BricksLLM key are authenticated as
+// that key instead.
+func (a *Authenticator) getRouteAuthKey(req *http.Request) *key.ResponseKey {
+	if !strings.HasPrefix(req.URL.Path, "/api/routes") {
+		return nil
+	}
+
+	if hash, ok := req.Context().Value(keyHashContextKey{}).(string); ok && len(hash) != 0 {
+		return nil
+	}
+
+	rc := a.rm.GetAuthRouteFromMemDb(strings.TrimPrefix(req.URL.Path, "/api/routes"))
+	if rc == nil {
+		return nil
+	}
+
+	credential, _ := getApiKey(req)
+	if len(credential) != 0 {
+		if k := a.kms.GetKey(encrypter.Encrypt(credential)); k != nil {
+			return nil
+		}
+	}
+
+	if !rc.Auth.Allows(credential) {
+		return nil
+	}
+
+	k := a.kms.GetKeyById(rc.Auth.KeyId)
+	if k == nil || k.TenantId != rc.TenantId {
+		return nil
+	}
+
+	return k
+}
+
+func (a *Authenticator) AuthenticateHttpRequest(req *http.Request) (*key.ResponseKey, []*provider.Setting, error) {
+	key := a.getRouteAuthKey(req)
+	if key == nil {
+		hash, err := getKeyHash(req)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		key = a.kms.GetKey(hash)
+	}
 
-	key := a.kms.GetKey(hash)
 	if key == nil || key.Revoked {
 		return nil, nil, internal_errors.NewAuthError("not authorized")
 	}
 
+	var err error
+
 	if strings.HasPrefix(req.URL.Path, "/api/routes") {
-		err = a.canKeyAccessCustomRoute(req.URL.Path, key.KeyId)
+		err = a.canKeyAccessCustomRoute(req.URL.Path, key)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -173,12 +278,29 @@ func (a *Authenticator) AuthenticateHttpRequest(req *http.Request) (*key.Respons
 	settingIds := key.GetSettingIds()
 	allSettings := []*provider.Setting{}
 	selected := []*provider.Setting{}
+	unhealthy := map[string]bool{}
+	noncompliant := map[string]bool{}
+	blocked := false
+	violated := false
 	for _, settingId := range settingIds {
 		setting, err := a.psm.GetSetting(settingId)
 		if err != nil {
 			return nil, nil, err
 		}
 
+		// settings outside the regions of the key are never selected.
+		if !key.AllowsRegion(setting.Region) {
+			noncompliant[setting.Provider] = true
+			violated = violated || canAccessPath(setting.Provider, req.URL.Path)
+			continue
+		}
+
+		if a.phs.IsUnhealthy(setting.Id) {
+			unhealthy[setting.Provider] = true
+			blocked = blocked || canAccessPath(setting.Provider, req.URL.Path)
+			continue
+		}
+
 		if canAccessPath(setting.Provider, req.URL.Path) {
 
 			selected = append(selected, setting)
@@ -188,13 +310,35 @@ func (a *Authenticator) AuthenticateHttpRequest(req *http.Request) (*key.Respons
 	}
 
 	if strings.HasPrefix(req.URL.Path, "/api/routes") {
-		selected = a.getProviderSettingsThatCanAccessCustomRoute(req.URL.Path, allSettings)
+		skipped := map[string]bool{}
+		for p := range unhealthy {
+			skipped[p] = true
+		}
+
+		for p := range noncompliant {
+			skipped[p] = true
+		}
+
+		selected = a.getProviderSettingsThatCanAccessCustomRoute(req.URL.Path, key, allSettings, skipped)
+
+		if len(selected) == 0 && len(noncompliant) != 0 {
+			return nil, nil, internal_errors.NewResidencyError("no provider setting associated with the key satisfies its allowed regions")
+		}
+
+		if len(selected) == 0 && len(unhealthy) != 0 {
+			return nil, nil, internal_errors.NewUnavailableError("provider settings associated with the key are unhealthy")
+		}
 
 		if len(selected) == 0 {
 			return nil, nil, internal_errors.NewAuthError("provider settings associated with the key are not compatible with the route")
 		}
 	}
 
+	selected, err = applyOverrides(req, selected, allSettings, strings.HasPrefix(req.URL.Path, "/api/routes"))
+	if err != nil {
+		return nil, nil, err
+	}
+
 	if len(selected) != 0 {
 		err := rewriteHttpAuthHeader(req, selected[0])
 
@@ -205,5 +349,13 @@ func (a *Authenticator) AuthenticateHttpRequest(req *http.Request) (*key.Respons
 		return key, selected, nil
 	}
 
+	if blocked {
+		return nil, nil, internal_errors.NewUnavailableError("provider settings associated with the key are unhealthy")
+	}
+
+	if violated {
+		return nil, nil, internal_errors.NewResidencyError("no provider setting associated with the key satisfies its allowed regions")
+	}
+
 	return nil, nil, internal_errors.NewAuthError("provider setting not found")
 }
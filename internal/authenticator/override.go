@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+)
+
+const (
+	providerOverrideHeader = "X-BricksLLM-Provider"
+	settingOverrideHeader  = "X-BricksLLM-Setting-Id"
+)
+
+// applyOverrides narrows the settings selected for a request down to the
+// provider or the setting requested by the caller. Only settings of the key
+// can be selected. Routes keep one setting per provider, so the requested
+// setting replaces the one of its provider.
+func applyOverrides(req *http.Request, selected, all []*provider.Setting, isRoute bool) ([]*provider.Setting, error) {
+	settingId := req.Header.Get(settingOverrideHeader)
+	providerName := req.Header.Get(providerOverrideHeader)
+	req.Header.Del(settingOverrideHeader)
+	req.Header.Del(providerOverrideHeader)
+
+	if len(providerName) != 0 && !isRoute {
+		filtered := []*provider.Setting{}
+		for _, s := range selected {
+			if s.Provider == providerName {
+				filtered = append(filtered, s)
+			}
+		}
+
+		if len(filtered) == 0 {
+			return nil, internal_errors.NewValidationError(fmt.Sprintf("provider %s is not available for the key", providerName))
+		}
+
+		selected = filtered
+	}
+
+	if len(settingId) == 0 {
+		return selected, nil
+	}
+
+	var requested *provider.Setting
+	for _, s := range all {
+		if s.Id == settingId {
+			requested = s
+			break
+		}
+	}
+
+	if requested == nil {
+		return nil, internal_errors.NewValidationError(fmt.Sprintf("provider setting %s is not available for the key", settingId))
+	}
+
+	for index, s := range selected {
+		if !isRoute && s.Id == settingId {
+			return []*provider.Setting{requested}, nil
+		}
+
+		if isRoute && s.Provider == requested.Provider {
+			overridden := make([]*provider.Setting, len(selected))
+			copy(overridden, selected)
+			overridden[index] = requested
+
+			return overridden, nil
+		}
+	}
+
+	return nil, internal_errors.NewValidationError(fmt.Sprintf("provider setting %s cannot be used for the request", settingId))
+}
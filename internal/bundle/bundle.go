@@ -0,0 +1,38 @@
+package bundle
+
+import (
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+)
+
+const (
+	ActionCreated   = "created"
+	ActionUpdated   = "updated"
+	ActionUnchanged = "unchanged"
+)
+
+// Bundle declares the desired state of a set of keys and routes. Bundles are
+// applied as a whole and only when their generation is newer than the one
+// applied last, so that a controller can resend them safely.
+type Bundle struct {
+	Name       string            `json:"name"`
+	TenantId   string            `json:"tenantId"`
+	Generation int64             `json:"generation"`
+	Keys       []*key.RequestKey `json:"keys"`
+	Routes     []*route.Route    `json:"routes"`
+}
+
+type Result struct {
+	Resource string `json:"resource"`
+	Id       string `json:"id"`
+	Action   string `json:"action"`
+}
+
+// Status is the outcome of the last generation of a bundle that was applied.
+type Status struct {
+	Name       string    `json:"name"`
+	TenantId   string    `json:"tenantId,omitempty"`
+	Generation int64     `json:"generation"`
+	AppliedAt  int64     `json:"appliedAt"`
+	Results    []*Result `json:"results"`
+}
@@ -0,0 +1,86 @@
+package bundle
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+)
+
+const SnapshotVersion = 1
+
+// Snapshot is the configuration of a whole cluster, used for restoring it or
+// cloning it into another region. Keys carry their hashed secrets and
+// provider settings their credentials, so snapshots have to be stored like
+// secrets. Revoked keys are left out.
+type Snapshot struct {
+	Version          int                 `json:"version"`
+	CreatedAt        int64               `json:"createdAt"`
+	ProviderSettings []*provider.Setting `json:"providerSettings"`
+	Policies         []*policy.Policy    `json:"policies"`
+	Keys             []*SnapshotKey      `json:"keys"`
+	Routes           []*route.Route      `json:"routes"`
+}
+
+type SnapshotKey struct {
+	*key.RequestKey
+	PersonalCostLimitInUsd float64 `json:"personalCostLimitInUsd,omitempty"`
+}
+
+// SignedSnapshot is a snapshot with the hex encoded HMAC-SHA256 of its JSON
+// encoding. The snapshot has to be imported exactly as it was exported.
+type SignedSnapshot struct {
+	Snapshot  json.RawMessage `json:"snapshot"`
+	Signature string          `json:"signature"`
+}
+
+func signature(data []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(data)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func Sign(s *Snapshot, secret string) (*SignedSnapshot, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SignedSnapshot{
+		Snapshot:  data,
+		Signature: signature(data, secret),
+	}, nil
+}
+
+// Open verifies the signature of the snapshot and returns it.
+func (ss *SignedSnapshot) Open(secret string) (*Snapshot, error) {
+	expected := signature(ss.Snapshot, secret)
+	if !hmac.Equal([]byte(expected), []byte(ss.Signature)) {
+		return nil, internal_errors.NewValidationError("snapshot signature is invalid")
+	}
+
+	s := &Snapshot{}
+	if err := json.Unmarshal(ss.Snapshot, s); err != nil {
+		return nil, internal_errors.NewValidationError("snapshot cannot be parsed: " + err.Error())
+	}
+
+	if s.Version != SnapshotVersion {
+		return nil, internal_errors.NewValidationError("snapshot version is not supported")
+	}
+
+	return s, nil
+}
+
+// ImportReport lists what an import did with every resource of a snapshot.
+// Resources that already exist are left unchanged.
+type ImportReport struct {
+	ImportedAt int64     `json:"importedAt"`
+	Results    []*Result `json:"results"`
+}
@@ -0,0 +1,67 @@
+package bundle
+
+import (
+	"encoding/json"
+	"testing"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignedSnapshot_Open(t *testing.T) {
+	s := &Snapshot{Version: SnapshotVersion, CreatedAt: 1700000000, ProviderSettings: []*provider.Setting{{Id: "s1", Provider: "openai"}}}
+
+	signed, err := Sign(s, "secret")
+	require.NoError(t, err)
+
+	tampered := []byte(string(signed.Snapshot))
+	tampered[len(tampered)-2] = 'x'
+
+	unsupported, err := Sign(&Snapshot{Version: SnapshotVersion + 1}, "secret")
+	require.NoError(t, err)
+
+	malformed := []byte(`{"version":`)
+
+	cases := []struct {
+		name   string
+		ss     *SignedSnapshot
+		secret string
+		err    string
+	}{
+		{name: "snapshots signed with the secret", ss: signed, secret: "secret"},
+		{name: "snapshots signed with other secrets", ss: signed, secret: "other", err: "snapshot signature is invalid"},
+		{name: "snapshots changed after they were signed", ss: &SignedSnapshot{Snapshot: tampered, Signature: signed.Signature}, secret: "secret", err: "snapshot signature is invalid"},
+		{name: "snapshots without a signature", ss: &SignedSnapshot{Snapshot: signed.Snapshot}, secret: "secret", err: "snapshot signature is invalid"},
+		{name: "snapshots of other versions", ss: unsupported, secret: "secret", err: "snapshot version is not supported"},
+		{name: "snapshots that cannot be parsed", ss: &SignedSnapshot{Snapshot: malformed, Signature: signature(malformed, "secret")}, secret: "secret", err: "snapshot cannot be parsed"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			opened, err := tc.ss.Open(tc.secret)
+			if len(tc.err) != 0 {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+				assert.IsType(t, &internal_errors.ValidationError{}, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, s, opened)
+		})
+	}
+
+	t.Run("signed snapshots survive being encoded", func(t *testing.T) {
+		data, err := json.Marshal(signed)
+		require.NoError(t, err)
+
+		decoded := &SignedSnapshot{}
+		require.NoError(t, json.Unmarshal(data, decoded))
+
+		opened, err := decoded.Open("secret")
+		require.NoError(t, err)
+		assert.Equal(t, s, opened)
+	})
+}
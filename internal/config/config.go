@@ -7,26 +7,120 @@ import (
 )
 
 type Config struct {
-	PostgresqlHosts               string        `env:"POSTGRESQL_HOSTS" envSeparator:":" envDefault:"localhost"`
-	PostgresqlDbName              string        `env:"POSTGRESQL_DB_NAME"`
-	PostgresqlUsername            string        `env:"POSTGRESQL_USERNAME"`
-	PostgresqlPassword            string        `env:"POSTGRESQL_PASSWORD"`
-	PostgresqlSslMode             string        `env:"POSTGRESQL_SSL_MODE" envDefault:"disable"`
-	PostgresqlPort                string        `env:"POSTGRESQL_PORT" envDefault:"5432"`
-	RedisHosts                    string        `env:"REDIS_HOSTS" envSeparator:":" envDefault:"localhost"`
-	RedisPort                     string        `env:"REDIS_PORT" envDefault:"6379"`
-	RedisUsername                 string        `env:"REDIS_USERNAME"`
-	RedisPassword                 string        `env:"REDIS_PASSWORD"`
-	RedisReadTimeout              time.Duration `env:"REDIS_READ_TIME_OUT" envDefault:"1s"`
-	RedisWriteTimeout             time.Duration `env:"REDIS_WRITE_TIME_OUT" envDefault:"500ms"`
-	PostgresqlReadTimeout         time.Duration `env:"POSTGRESQL_READ_TIME_OUT" envDefault:"2s"`
-	PostgresqlWriteTimeout        time.Duration `env:"POSTGRESQL_WRITE_TIME_OUT" envDefault:"1s"`
-	InMemoryDbUpdateInterval      time.Duration `env:"IN_MEMORY_DB_UPDATE_INTERVAL" envDefault:"5s"`
-	OpenAiKey                     string        `env:"OPENAI_API_KEY"`
-	StatsProvider                 string        `env:"STATS_PROVIDER"`
-	AdminPass                     string        `env:"ADMIN_PASS"`
-	ProxyTimeout                  time.Duration `env:"PROXY_TIMEOUT" envDefault:"600s"`
-	NumberOfEventMessageConsumers int           `env:"NUMBER_OF_EVENT_MESSAGE_CONSUMERS" envDefault:"3"`
+	PostgresqlHosts                string        `env:"POSTGRESQL_HOSTS" envSeparator:":" envDefault:"localhost"`
+	PostgresqlDbName               string        `env:"POSTGRESQL_DB_NAME"`
+	PostgresqlUsername             string        `env:"POSTGRESQL_USERNAME"`
+	PostgresqlPassword             string        `env:"POSTGRESQL_PASSWORD"`
+	PostgresqlSslMode              string        `env:"POSTGRESQL_SSL_MODE" envDefault:"disable"`
+	PostgresqlPort                 string        `env:"POSTGRESQL_PORT" envDefault:"5432"`
+	RedisHosts                     string        `env:"REDIS_HOSTS" envSeparator:":" envDefault:"localhost"`
+	RedisPort                      string        `env:"REDIS_PORT" envDefault:"6379"`
+	RedisUsername                  string        `env:"REDIS_USERNAME"`
+	RedisPassword                  string        `env:"REDIS_PASSWORD"`
+	RedisReadTimeout               time.Duration `env:"REDIS_READ_TIME_OUT" envDefault:"1s"`
+	RedisWriteTimeout              time.Duration `env:"REDIS_WRITE_TIME_OUT" envDefault:"500ms"`
+	RedisRateLimitAddr             string        `env:"REDIS_RATE_LIMIT_ADDR"`
+	RedisRateLimitPassword         string        `env:"REDIS_RATE_LIMIT_PASSWORD"`
+	RedisCostAddr                  string        `env:"REDIS_COST_ADDR"`
+	RedisCostPassword              string        `env:"REDIS_COST_PASSWORD"`
+	RedisCacheAddr                 string        `env:"REDIS_CACHE_ADDR"`
+	RedisCachePassword             string        `env:"REDIS_CACHE_PASSWORD"`
+	RedisAccessAddr                string        `env:"REDIS_ACCESS_ADDR"`
+	RedisAccessPassword            string        `env:"REDIS_ACCESS_PASSWORD"`
+	RedisRateLimitFailureMode      string        `env:"REDIS_RATE_LIMIT_FAILURE_MODE" envDefault:"open"`
+	RedisCostLimitFailureMode      string        `env:"REDIS_COST_LIMIT_FAILURE_MODE" envDefault:"open"`
+	RedisCacheFailureMode          string        `env:"REDIS_CACHE_FAILURE_MODE" envDefault:"open"`
+	RedisAccessFailureMode         string        `env:"REDIS_ACCESS_FAILURE_MODE" envDefault:"open"`
+	RedisHealthCheckInterval       time.Duration `env:"REDIS_HEALTH_CHECK_INTERVAL" envDefault:"1s"`
+	RedisAlertSlackWebhookUrls     []string      `env:"REDIS_ALERT_SLACK_WEBHOOK_URLS" envSeparator:","`
+	RedisAlertEmailAddresses       []string      `env:"REDIS_ALERT_EMAIL_ADDRESSES" envSeparator:","`
+	EventBufferDir                 string        `env:"EVENT_BUFFER_DIR"`
+	EventBufferMaxSizeInMb         int64         `env:"EVENT_BUFFER_MAX_SIZE_IN_MB" envDefault:"100"`
+	EventBufferReplayInterval      time.Duration `env:"EVENT_BUFFER_REPLAY_INTERVAL" envDefault:"5s"`
+	AsyncJobWorkers                int           `env:"ASYNC_JOB_WORKERS" envDefault:"4"`
+	AsyncJobQueueSize              int           `env:"ASYNC_JOB_QUEUE_SIZE" envDefault:"1000"`
+	AsyncJobMaxAttempts            int           `env:"ASYNC_JOB_MAX_ATTEMPTS" envDefault:"5"`
+	AsyncJobRetention              time.Duration `env:"ASYNC_JOB_RETENTION" envDefault:"24h"`
+	AsyncJobCleanUpInterval        time.Duration `env:"ASYNC_JOB_CLEAN_UP_INTERVAL" envDefault:"1m"`
+	AsyncJobWebhookHosts           []string      `env:"ASYNC_JOB_WEBHOOK_HOSTS" envSeparator:","`
+	SchedulePollInterval           time.Duration `env:"SCHEDULE_POLL_INTERVAL" envDefault:"30s"`
+	ScheduleWebhookHosts           []string      `env:"SCHEDULE_WEBHOOK_HOSTS" envSeparator:","`
+	ScheduleS3AccessKeyId          string        `env:"SCHEDULE_S3_ACCESS_KEY_ID"`
+	ScheduleS3SecretAccessKey      string        `env:"SCHEDULE_S3_SECRET_ACCESS_KEY"`
+	PostgresqlReadTimeout          time.Duration `env:"POSTGRESQL_READ_TIME_OUT" envDefault:"2s"`
+	PostgresqlWriteTimeout         time.Duration `env:"POSTGRESQL_WRITE_TIME_OUT" envDefault:"1s"`
+	InMemoryDbUpdateInterval       time.Duration `env:"IN_MEMORY_DB_UPDATE_INTERVAL" envDefault:"5s"`
+	OpenAiKey                      string        `env:"OPENAI_API_KEY"`
+	StatsProvider                  string        `env:"STATS_PROVIDER"`
+	AdminPass                      string        `env:"ADMIN_PASS"`
+	AdminBootstrapToken            string        `env:"ADMIN_BOOTSTRAP_TOKEN"`
+	AdminBootstrapGenerate         bool          `env:"ADMIN_BOOTSTRAP_GENERATE" envDefault:"false"`
+	AdminRateLimit                 int           `env:"ADMIN_RATE_LIMIT" envDefault:"600"`
+	AdminMaxFailedAttempts         int           `env:"ADMIN_MAX_FAILED_ATTEMPTS" envDefault:"5"`
+	AdminLockoutDuration           time.Duration `env:"ADMIN_LOCKOUT_DURATION" envDefault:"1m"`
+	AdminMaxLockoutDuration        time.Duration `env:"ADMIN_MAX_LOCKOUT_DURATION" envDefault:"1h"`
+	TrustedProxies                 []string      `env:"TRUSTED_PROXIES" envSeparator:","`
+	ProxyTimeout                   time.Duration `env:"PROXY_TIMEOUT" envDefault:"600s"`
+	NumberOfEventMessageConsumers  int           `env:"NUMBER_OF_EVENT_MESSAGE_CONSUMERS" envDefault:"3"`
+	ProxyResponseCompression       bool          `env:"PROXY_RESPONSE_COMPRESSION" envDefault:"true"`
+	RecordRequests                 bool          `env:"RECORD_REQUESTS" envDefault:"false"`
+	RecordedRequestsPurgeInterval  time.Duration `env:"RECORDED_REQUESTS_PURGE_INTERVAL" envDefault:"1h"`
+	SamplingPercentage             float64       `env:"SAMPLING_PERCENTAGE" envDefault:"0"`
+	TracingEnabled                 bool          `env:"TRACING_ENABLED" envDefault:"false"`
+	LogLevel                       string        `env:"LOG_LEVEL" envDefault:"debug"`
+	SubjectRequestPollInterval     time.Duration `env:"SUBJECT_REQUEST_POLL_INTERVAL" envDefault:"10s"`
+	SloReportingInterval           time.Duration `env:"SLO_REPORTING_INTERVAL" envDefault:"1m"`
+	PauseSyncInterval              time.Duration `env:"PAUSE_SYNC_INTERVAL" envDefault:"500ms"`
+	WatchPollInterval              time.Duration `env:"WATCH_POLL_INTERVAL" envDefault:"1s"`
+	ProviderAuthFailureThreshold   int           `env:"PROVIDER_AUTH_FAILURE_THRESHOLD" envDefault:"5"`
+	ProviderHealthSyncInterval     time.Duration `env:"PROVIDER_HEALTH_SYNC_INTERVAL" envDefault:"1s"`
+	ProviderHealthSlackWebhookUrls []string      `env:"PROVIDER_HEALTH_SLACK_WEBHOOK_URLS" envSeparator:","`
+	ProviderHealthEmailAddresses   []string      `env:"PROVIDER_HEALTH_EMAIL_ADDRESSES" envSeparator:","`
+	ProviderOutageThreshold        int           `env:"PROVIDER_OUTAGE_THRESHOLD" envDefault:"10"`
+	NotificationCheckInterval      time.Duration `env:"NOTIFICATION_CHECK_INTERVAL" envDefault:"1h"`
+	ProbesEnabled                  bool          `env:"PROBES_ENABLED" envDefault:"true"`
+	ProbeCheckInterval             time.Duration `env:"PROBE_CHECK_INTERVAL" envDefault:"30s"`
+	ProbeFailureThreshold          int           `env:"PROBE_FAILURE_THRESHOLD" envDefault:"3"`
+	DigestFrequency                string        `env:"DIGEST_FREQUENCY"`
+	DigestSlackWebhookUrls         []string      `env:"DIGEST_SLACK_WEBHOOK_URLS" envSeparator:","`
+	DigestEmailAddresses           []string      `env:"DIGEST_EMAIL_ADDRESSES" envSeparator:","`
+	ReconciliationEnabled          bool          `env:"RECONCILIATION_ENABLED" envDefault:"false"`
+	ReconciliationThreshold        float64       `env:"RECONCILIATION_THRESHOLD_PERCENTAGE" envDefault:"5"`
+	ReconciliationMinDifference    float64       `env:"RECONCILIATION_MIN_DIFFERENCE_IN_USD" envDefault:"1"`
+	ReconciliationLookbackDays     int           `env:"RECONCILIATION_LOOKBACK_DAYS" envDefault:"3"`
+	TokenDriftSamplingPercentage   float64       `env:"TOKEN_DRIFT_SAMPLING_PERCENTAGE" envDefault:"0"`
+	TokenDriftThreshold            float64       `env:"TOKEN_DRIFT_THRESHOLD_PERCENTAGE" envDefault:"5"`
+	TokenDriftMinSamples           int           `env:"TOKEN_DRIFT_MIN_SAMPLES" envDefault:"50"`
+	TokenDriftCheckInterval        time.Duration `env:"TOKEN_DRIFT_CHECK_INTERVAL" envDefault:"1h"`
+	TokenDriftSlackWebhookUrls     []string      `env:"TOKEN_DRIFT_SLACK_WEBHOOK_URLS" envSeparator:","`
+	TokenDriftEmailAddresses       []string      `env:"TOKEN_DRIFT_EMAIL_ADDRESSES" envSeparator:","`
+	AzureRegionalPriceMultipliers  []string      `env:"AZURE_REGIONAL_PRICE_MULTIPLIERS" envSeparator:","`
+	SmtpHost                       string        `env:"SMTP_HOST"`
+	SmtpPort                       string        `env:"SMTP_PORT" envDefault:"587"`
+	SmtpUsername                   string        `env:"SMTP_USERNAME"`
+	SmtpPassword                   string        `env:"SMTP_PASSWORD"`
+	SmtpFrom                       string        `env:"SMTP_FROM"`
+	PseudonymizationSecret         string        `env:"PSEUDONYMIZATION_SECRET"`
+	PseudonymizationRotation       time.Duration `env:"PSEUDONYMIZATION_ROTATION_INTERVAL" envDefault:"720h"`
+	PseudonymizationLookback       time.Duration `env:"PSEUDONYMIZATION_SUBJECT_LOOKBACK" envDefault:"8760h"`
+	ConfigExportSecret             string        `env:"CONFIG_EXPORT_SECRET"`
+	ClusterId                      string        `env:"CLUSTER_ID"`
+	SpendSyncPeers                 []string      `env:"SPEND_SYNC_PEERS" envSeparator:","`
+	SpendSyncToken                 string        `env:"SPEND_SYNC_TOKEN"`
+	SpendSyncInterval              time.Duration `env:"SPEND_SYNC_INTERVAL" envDefault:"10s"`
+	ReadOnlyFallback               bool          `env:"READ_ONLY_FALLBACK" envDefault:"false"`
+	ReplicaSnapshotPath            string        `env:"REPLICA_SNAPSHOT_PATH"`
+	ReplicaSnapshotInterval        time.Duration `env:"REPLICA_SNAPSHOT_INTERVAL" envDefault:"1m"`
+	AnalyticsRoute                 string        `env:"ANALYTICS_ROUTE"`
+	AnalyticsApiKey                string        `env:"ANALYTICS_API_KEY"`
+	AnalyticsProxyUrl              string        `env:"ANALYTICS_PROXY_URL" envDefault:"http://localhost:8002"`
+	AnalyticsTimeout               time.Duration `env:"ANALYTICS_TIMEOUT" envDefault:"1m"`
+	EnvironmentLabels              []string      `env:"ENVIRONMENT_LABELS" envSeparator:","`
+	CorsAllowedOrigins             []string      `env:"CORS_ALLOWED_ORIGINS" envSeparator:","`
+	CorsAllowedHeaders             []string      `env:"CORS_ALLOWED_HEADERS" envSeparator:"," envDefault:"Authorization,Content-Type,X-Api-Key,Api-Key,Anthropic-Version,X-Custom-Event-Id"`
+	CorsExposedHeaders             []string      `env:"CORS_EXPOSED_HEADERS" envSeparator:","`
+	CorsMaxAge                     time.Duration `env:"CORS_MAX_AGE" envDefault:"10m"`
+	CorsAllowCredentials           bool          `env:"CORS_ALLOW_CREDENTIALS" envDefault:"false"`
 }
 
 func ParseEnvVariables() (*Config, error) {
@@ -0,0 +1,116 @@
+package cors
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const maxAgeLimit = 86400
+
+var headerNamePattern = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+\\-.^_`|~]+$")
+
+// Policy lets browsers call the proxy from other origins. Origins can be
+// exact, such as https://app.example.com, match subdomains, such as
+// https://*.example.com, or be * for any origin. MaxAge is in seconds.
+type Policy struct {
+	AllowedOrigins   []string `json:"allowedOrigins"`
+	AllowedHeaders   []string `json:"allowedHeaders,omitempty"`
+	ExposedHeaders   []string `json:"exposedHeaders,omitempty"`
+	MaxAge           int      `json:"maxAge,omitempty"`
+	AllowCredentials bool     `json:"allowCredentials,omitempty"`
+}
+
+func isValidOrigin(origin string) bool {
+	if origin == "*" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return false
+	}
+
+	if len(u.Path) != 0 || len(u.RawQuery) != 0 || len(u.Fragment) != 0 || u.User != nil {
+		return false
+	}
+
+	host := strings.TrimPrefix(u.Host, "*.")
+	return len(host) != 0 && !strings.Contains(host, "*")
+}
+
+func (p *Policy) Validate(prefix string) []string {
+	invalid := []string{}
+
+	if len(p.AllowedOrigins) == 0 {
+		invalid = append(invalid, prefix+".allowedOrigins")
+	}
+
+	for index, origin := range p.AllowedOrigins {
+		if !isValidOrigin(origin) || (origin == "*" && p.AllowCredentials) {
+			invalid = append(invalid, fmt.Sprintf("%s.allowedOrigins.[%d]", prefix, index))
+		}
+	}
+
+	for index, h := range p.AllowedHeaders {
+		if h != "*" && !headerNamePattern.MatchString(h) {
+			invalid = append(invalid, fmt.Sprintf("%s.allowedHeaders.[%d]", prefix, index))
+		}
+	}
+
+	for index, h := range p.ExposedHeaders {
+		if !headerNamePattern.MatchString(h) {
+			invalid = append(invalid, fmt.Sprintf("%s.exposedHeaders.[%d]", prefix, index))
+		}
+	}
+
+	if p.MaxAge < 0 || p.MaxAge > maxAgeLimit {
+		invalid = append(invalid, prefix+".maxAge")
+	}
+
+	return invalid
+}
+
+// AllowsOrigin reports whether requests from the origin of a browser are
+// allowed. Origins are compared case insensitively.
+func (p *Policy) AllowsOrigin(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, allowed := range p.AllowedOrigins {
+		allowed = strings.ToLower(allowed)
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+
+		scheme, host, found := strings.Cut(allowed, "://*.")
+		if found && strings.HasPrefix(origin, scheme+"://") && strings.HasSuffix(origin, "."+host) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AllowsAnyOrigin reports whether the policy allows every origin, in which
+// case responses do not vary by origin.
+func (p *Policy) AllowsAnyOrigin() bool {
+	for _, allowed := range p.AllowedOrigins {
+		if allowed == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AllowsAnyHeader reports whether every request header is allowed, in which
+// case the headers requested by a preflight are allowed as they are.
+func (p *Policy) AllowsAnyHeader() bool {
+	for _, h := range p.AllowedHeaders {
+		if h == "*" {
+			return true
+		}
+	}
+
+	return false
+}
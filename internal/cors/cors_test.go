@@ -0,0 +1,55 @@
+package cors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy_Validate(t *testing.T) {
+	cases := []struct {
+		name     string
+		p        *Policy
+		expected []string
+	}{
+		{name: "valid policies", p: &Policy{AllowedOrigins: []string{"https://app.example.com", "https://*.example.com", "http://localhost:3000"}, AllowedHeaders: []string{"*", "X-Custom"}, ExposedHeaders: []string{"X-Request-Id"}, MaxAge: 600}, expected: []string{}},
+		{name: "policies without origins", p: &Policy{}, expected: []string{"cors.allowedOrigins"}},
+		{name: "origins with paths", p: &Policy{AllowedOrigins: []string{"https://app.example.com/path"}}, expected: []string{"cors.allowedOrigins.[0]"}},
+		{name: "origins with other schemes", p: &Policy{AllowedOrigins: []string{"ftp://app.example.com"}}, expected: []string{"cors.allowedOrigins.[0]"}},
+		{name: "origins with wildcards inside hosts", p: &Policy{AllowedOrigins: []string{"https://app.*.example.com"}}, expected: []string{"cors.allowedOrigins.[0]"}},
+		{name: "any origin with credentials", p: &Policy{AllowedOrigins: []string{"*"}, AllowCredentials: true}, expected: []string{"cors.allowedOrigins.[0]"}},
+		{name: "invalid header names", p: &Policy{AllowedOrigins: []string{"*"}, AllowedHeaders: []string{"X Custom"}, ExposedHeaders: []string{"X-Exposed:"}}, expected: []string{"cors.allowedHeaders.[0]", "cors.exposedHeaders.[0]"}},
+		{name: "max ages out of range", p: &Policy{AllowedOrigins: []string{"*"}, MaxAge: maxAgeLimit + 1}, expected: []string{"cors.maxAge"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.p.Validate("cors"))
+		})
+	}
+}
+
+func TestPolicy_AllowsOrigin(t *testing.T) {
+	cases := []struct {
+		name     string
+		allowed  []string
+		origin   string
+		expected bool
+	}{
+		{name: "exact origins", allowed: []string{"https://app.example.com"}, origin: "https://app.example.com", expected: true},
+		{name: "origins are compared case insensitively", allowed: []string{"https://App.Example.com"}, origin: "https://app.example.COM", expected: true},
+		{name: "other origins", allowed: []string{"https://app.example.com"}, origin: "https://evil.example.com", expected: false},
+		{name: "subdomains", allowed: []string{"https://*.example.com"}, origin: "https://a.b.example.com", expected: true},
+		{name: "subdomain patterns do not match the domain itself", allowed: []string{"https://*.example.com"}, origin: "https://example.com", expected: false},
+		{name: "subdomain patterns do not match other schemes", allowed: []string{"https://*.example.com"}, origin: "http://app.example.com", expected: false},
+		{name: "subdomain patterns do not match suffixes of other domains", allowed: []string{"https://*.example.com"}, origin: "https://app.evilexample.com", expected: false},
+		{name: "any origin", allowed: []string{"*"}, origin: "https://anything.dev", expected: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &Policy{AllowedOrigins: tc.allowed}
+			assert.Equal(t, tc.expected, p.AllowsOrigin(tc.origin))
+		})
+	}
+}
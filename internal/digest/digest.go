@@ -0,0 +1,228 @@
+package digest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+)
+
+const (
+	FrequencyDaily  string = "daily"
+	FrequencyWeekly string = "weekly"
+)
+
+// a key is reported as an anomaly when its spend grew by this factor compared
+// to the previous period and exceeds the minimum spend below.
+const (
+	anomalySpendGrowthFactor = 2
+	anomalyMinimumSpendInUsd = 1
+	digestTopLimit           = 5
+)
+
+type TeamSpend struct {
+	Tag              string
+	NumberOfRequests int64
+	CostInUsd        float64
+}
+
+type Anomaly struct {
+	KeyId             string
+	CostInUsd         float64
+	PreviousCostInUsd float64
+}
+
+type Digest struct {
+	Frequency        string
+	Start            time.Time
+	End              time.Time
+	NumberOfRequests int64
+	CostInUsd        float64
+	Teams            []*TeamSpend
+	TopModels        []*event.TopEntry
+	TopKeys          []*event.TopEntry
+	Anomalies        []*Anomaly
+}
+
+func (d *Digest) Subject() string {
+	return fmt.Sprintf("BricksLLM %s digest for %s", d.Frequency, d.Start.Format("2006-01-02"))
+}
+
+func (d *Digest) Text() string {
+	b := &strings.Builder{}
+
+	fmt.Fprintf(b, "%s\n", d.Subject())
+	fmt.Fprintf(b, "Period: %s - %s UTC\n", d.Start.Format(time.RFC3339), d.End.Format(time.RFC3339))
+	fmt.Fprintf(b, "Total spend: $%.2f across %d requests\n", d.CostInUsd, d.NumberOfRequests)
+
+	b.WriteString("\nSpend by team:\n")
+	if len(d.Teams) == 0 {
+		b.WriteString("  no spend recorded\n")
+	}
+
+	for _, t := range d.Teams {
+		fmt.Fprintf(b, "  %s: $%.2f (%d requests)\n", t.Tag, t.CostInUsd, t.NumberOfRequests)
+	}
+
+	b.WriteString("\nTop models by tokens:\n")
+	if len(d.TopModels) == 0 {
+		b.WriteString("  no usage recorded\n")
+	}
+
+	for _, e := range d.TopModels {
+		fmt.Fprintf(b, "  %s: %.0f tokens (%d requests)\n", e.Name, e.Value, e.NumberOfRequests)
+	}
+
+	b.WriteString("\nTop keys by spend:\n")
+	if len(d.TopKeys) == 0 {
+		b.WriteString("  no spend recorded\n")
+	}
+
+	for _, e := range d.TopKeys {
+		fmt.Fprintf(b, "  %s: $%.2f (%d requests)\n", e.Name, e.Value, e.NumberOfRequests)
+	}
+
+	b.WriteString("\nNotable anomalies:\n")
+	if len(d.Anomalies) == 0 {
+		b.WriteString("  none\n")
+	}
+
+	for _, a := range d.Anomalies {
+		fmt.Fprintf(b, "  key %s spent $%.2f compared to $%.2f in the previous period\n", a.KeyId, a.CostInUsd, a.PreviousCostInUsd)
+	}
+
+	return b.String()
+}
+
+type reportingManager interface {
+	GetAggregatedEventReporting(r *event.AggregationRequest) (*event.AggregationResponse, error)
+	GetTopReporting(r *event.TopRequest) (*event.TopResponse, error)
+}
+
+type Builder struct {
+	rm reportingManager
+}
+
+func NewBuilder(rm reportingManager) *Builder {
+	return &Builder{
+		rm: rm,
+	}
+}
+
+// Build summarizes the period [start, end) and compares key spend against the
+// period of the same length right before it.
+func (b *Builder) Build(frequency string, start, end time.Time) (*Digest, error) {
+	d := &Digest{
+		Frequency: frequency,
+		Start:     start,
+		End:       end,
+		Teams:     []*TeamSpend{},
+		Anomalies: []*Anomaly{},
+	}
+
+	from, to := start.Unix(), end.Unix()-1
+
+	teams, err := b.rm.GetAggregatedEventReporting(&event.AggregationRequest{
+		Start:   from,
+		End:     to,
+		GroupBy: []string{event.DimensionTag},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dp := range teams.DataPoints {
+		tag := dp.Dimensions[event.DimensionTag]
+		if len(tag) == 0 {
+			tag = "untagged"
+		}
+
+		d.Teams = append(d.Teams, &TeamSpend{
+			Tag:              tag,
+			NumberOfRequests: dp.NumberOfRequests,
+			CostInUsd:        dp.CostInUsd,
+		})
+	}
+
+	sort.Slice(d.Teams, func(i, j int) bool {
+		return d.Teams[i].CostInUsd > d.Teams[j].CostInUsd
+	})
+
+	current, err := b.getSpendByKey(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dp := range current {
+		d.NumberOfRequests += dp.NumberOfRequests
+		d.CostInUsd += dp.CostInUsd
+	}
+
+	models, err := b.rm.GetTopReporting(&event.TopRequest{
+		Metric: event.TopModelsByTokens,
+		Start:  from,
+		End:    to,
+		Limit:  digestTopLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	d.TopModels = models.Entries
+
+	keys, err := b.rm.GetTopReporting(&event.TopRequest{
+		Metric: event.TopKeysBySpend,
+		Start:  from,
+		End:    to,
+		Limit:  digestTopLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	d.TopKeys = keys.Entries
+
+	previous, err := b.getSpendByKey(from-(to-from+1), from-1)
+	if err != nil {
+		return nil, err
+	}
+
+	previousSpend := map[string]float64{}
+	for _, dp := range previous {
+		previousSpend[dp.Dimensions[event.DimensionKeyId]] = dp.CostInUsd
+	}
+
+	for _, dp := range current {
+		keyId := dp.Dimensions[event.DimensionKeyId]
+		if dp.CostInUsd < anomalyMinimumSpendInUsd || dp.CostInUsd < previousSpend[keyId]*anomalySpendGrowthFactor {
+			continue
+		}
+
+		d.Anomalies = append(d.Anomalies, &Anomaly{
+			KeyId:             keyId,
+			CostInUsd:         dp.CostInUsd,
+			PreviousCostInUsd: previousSpend[keyId],
+		})
+	}
+
+	sort.Slice(d.Anomalies, func(i, j int) bool {
+		return d.Anomalies[i].CostInUsd-d.Anomalies[i].PreviousCostInUsd > d.Anomalies[j].CostInUsd-d.Anomalies[j].PreviousCostInUsd
+	})
+
+	return d, nil
+}
+
+func (b *Builder) getSpendByKey(start, end int64) ([]*event.AggregatedDataPoint, error) {
+	resp, err := b.rm.GetAggregatedEventReporting(&event.AggregationRequest{
+		Start:   start,
+		End:     end,
+		GroupBy: []string{event.DimensionKeyId},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.DataPoints, nil
+}
@@ -0,0 +1,95 @@
+package digest
+
+import (
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"go.uber.org/zap"
+)
+
+type Scheduler struct {
+	b         *Builder
+	senders   []Sender
+	frequency string
+	done      chan bool
+	log       *zap.Logger
+}
+
+func NewScheduler(b *Builder, senders []Sender, frequency string, log *zap.Logger) *Scheduler {
+	return &Scheduler{
+		b:         b,
+		senders:   senders,
+		frequency: frequency,
+		done:      make(chan bool),
+		log:       log,
+	}
+}
+
+// nextRun returns the start of the next UTC day, or of the next Monday for
+// weekly digests. Digests always cover the period that just ended.
+func (s *Scheduler) nextRun(now time.Time) time.Time {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+
+	if s.frequency == FrequencyWeekly {
+		for next.Weekday() != time.Monday {
+			next = next.AddDate(0, 0, 1)
+		}
+	}
+
+	return next
+}
+
+func (s *Scheduler) period(end time.Time) time.Time {
+	if s.frequency == FrequencyWeekly {
+		return end.AddDate(0, 0, -7)
+	}
+
+	return end.AddDate(0, 0, -1)
+}
+
+func (s *Scheduler) run(end time.Time) {
+	d, err := s.b.Build(s.frequency, s.period(end), end)
+	if err != nil {
+		stats.Incr("bricksllm.digest.scheduler.run.build_error", nil, 1)
+		s.log.Sugar().Debugf("error when building %s digest: %v", s.frequency, err)
+		return
+	}
+
+	text := d.Text()
+	for _, sender := range s.senders {
+		if err := sender.Send(d.Subject(), text); err != nil {
+			stats.Incr("bricksllm.digest.scheduler.run.send_error", nil, 1)
+			s.log.Sugar().Debugf("error when sending %s digest: %v", s.frequency, err)
+			continue
+		}
+
+		stats.Incr("bricksllm.digest.scheduler.run.success", nil, 1)
+	}
+}
+
+func (s *Scheduler) Start() {
+	s.log.Sugar().Infof("digest scheduler started sending %s digests", s.frequency)
+
+	go func() {
+		for {
+			next := s.nextRun(time.Now())
+			timer := time.NewTimer(time.Until(next))
+
+			select {
+			case <-s.done:
+				timer.Stop()
+				s.log.Info("digest scheduler stopped")
+				return
+			case <-timer.C:
+				s.run(next)
+			}
+		}
+	}()
+}
+
+func (s *Scheduler) Stop() {
+	s.log.Info("shutting down digest scheduler...")
+
+	s.done <- true
+}
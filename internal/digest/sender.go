@@ -0,0 +1,90 @@
+package digest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+type Sender interface {
+	Send(subject, text string) error
+}
+
+type SlackSender struct {
+	webhookUrl string
+	client     http.Client
+}
+
+func NewSlackSender(webhookUrl string) *SlackSender {
+	return &SlackSender{
+		webhookUrl: webhookUrl,
+		client: http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+func (ss *SlackSender) Send(subject, text string) error {
+	data, err := json.Marshal(map[string]string{
+		"text": "```" + text + "```",
+	})
+	if err != nil {
+		return err
+	}
+
+	res, err := ss.client.Post(ss.webhookUrl, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("slack webhook responded with status %d: %s", res.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+type EmailSender struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+func NewEmailSender(host, port, username, password, from string, to []string) *EmailSender {
+	return &EmailSender{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+		to:       to,
+	}
+}
+
+func (es *EmailSender) Send(subject, text string) error {
+	var auth smtp.Auth
+	if len(es.username) != 0 {
+		auth = smtp.PlainAuth("", es.username, es.password, es.host)
+	}
+
+	msg := &bytes.Buffer{}
+	fmt.Fprintf(msg, "From: %s\r\n", es.from)
+	fmt.Fprintf(msg, "To: %s\r\n", strings.Join(es.to, ", "))
+	fmt.Fprintf(msg, "Subject: %s\r\n", subject)
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(text, "\n", "\r\n"))
+
+	return smtp.SendMail(net.JoinHostPort(es.host, es.port), auth, es.from, es.to, msg.Bytes())
+}
@@ -0,0 +1,179 @@
+package drift
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/digest"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"go.uber.org/zap"
+)
+
+type counts struct {
+	estimated int64
+	reported  int64
+	samples   int64
+}
+
+// Monitor compares the prompt tokens estimated by the gateway with the usage
+// reported by providers for a sample of live requests. Every interval, the
+// drift of each model with enough samples is reported as a metric, and an
+// alert is sent when it first exceeds the threshold.
+type Monitor struct {
+	percentage          float64
+	thresholdPercentage float64
+	minSamples          int64
+	models              map[string]*counts
+	drifting            map[string]bool
+	lock                sync.Mutex
+	senders             []digest.Sender
+	interval            time.Duration
+	done                chan bool
+	log                 *zap.Logger
+}
+
+func NewMonitor(senders []digest.Sender, percentage, thresholdPercentage float64, minSamples int, interval time.Duration, log *zap.Logger) *Monitor {
+	return &Monitor{
+		percentage:          percentage,
+		thresholdPercentage: thresholdPercentage,
+		minSamples:          int64(minSamples),
+		models:              map[string]*counts{},
+		drifting:            map[string]bool{},
+		senders:             senders,
+		interval:            interval,
+		done:                make(chan bool),
+		log:                 log,
+	}
+}
+
+// ShouldSample reports whether the tokens of a request are estimated to be
+// compared with its usage.
+func (m *Monitor) ShouldSample() bool {
+	return m != nil && m.percentage > 0 && rand.Float64()*100 < m.percentage
+}
+
+func (m *Monitor) Record(model string, estimated, reported int) {
+	if m == nil || len(model) == 0 || estimated <= 0 || reported <= 0 {
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	c, ok := m.models[model]
+	if !ok {
+		c = &counts{}
+		m.models[model] = c
+	}
+
+	c.estimated += int64(estimated)
+	c.reported += int64(reported)
+	c.samples++
+}
+
+// Percentage returns how far the estimated tokens are from the reported ones
+// relative to the reported ones. Underestimates are negative.
+func Percentage(estimated, reported int64) float64 {
+	if reported == 0 {
+		return 0
+	}
+
+	return float64(estimated-reported) / float64(reported) * 100
+}
+
+func (m *Monitor) check() {
+	m.lock.Lock()
+	models := m.models
+	m.models = map[string]*counts{}
+	m.lock.Unlock()
+
+	exceeded := []string{}
+	for model, c := range models {
+		if c.samples < m.minSamples {
+			// samples of quiet models add up until there are enough of them.
+			m.lock.Lock()
+			m.merge(model, c)
+			m.lock.Unlock()
+			continue
+		}
+
+		drift := Percentage(c.estimated, c.reported)
+		tags := []string{"model:" + model}
+		stats.Gauge("bricksllm.drift.monitor.token_drift_percentage", drift, tags, 1)
+		stats.Gauge("bricksllm.drift.monitor.samples", float64(c.samples), tags, 1)
+
+		if math.Abs(drift) <= m.thresholdPercentage {
+			if m.drifting[model] {
+				m.log.Sugar().Infof("token estimates of %s are back within %.1f%% of reported usage", model, m.thresholdPercentage)
+			}
+
+			delete(m.drifting, model)
+			continue
+		}
+
+		stats.Incr("bricksllm.drift.monitor.threshold_exceeded", tags, 1)
+		if m.drifting[model] {
+			continue
+		}
+
+		m.drifting[model] = true
+		m.log.Sugar().Warnf("token estimates of %s drift %.1f%% from reported usage over %d requests", model, drift, c.samples)
+		exceeded = append(exceeded, fmt.Sprintf("%s: estimated %d prompt tokens against %d reported over %d requests (%+.1f%%)", model, c.estimated, c.reported, c.samples, drift))
+	}
+
+	if len(exceeded) != 0 {
+		sort.Strings(exceeded)
+		go m.alert("BricksLLM token estimates are drifting", fmt.Sprintf("Prompt token estimates differ from the usage reported by providers by more than %.1f%%:\n%s\nCost limits and estimates of streamed requests of these models are off until the tokenizer mappings are updated.", m.thresholdPercentage, strings.Join(exceeded, "\n")))
+	}
+}
+
+func (m *Monitor) merge(model string, c *counts) {
+	existing, ok := m.models[model]
+	if !ok {
+		m.models[model] = c
+		return
+	}
+
+	existing.estimated += c.estimated
+	existing.reported += c.reported
+	existing.samples += c.samples
+}
+
+func (m *Monitor) alert(subject, text string) {
+	for _, s := range m.senders {
+		if err := s.Send(subject, text); err != nil {
+			stats.Incr("bricksllm.drift.monitor.alert.send_error", nil, 1)
+
+			m.log.Sugar().Debugf("error when sending token drift alert: %v", err)
+		}
+	}
+}
+
+func (m *Monitor) Listen() {
+	ticker := time.NewTicker(m.interval)
+	m.log.Info("token drift monitor started")
+
+	go func() {
+		for {
+			select {
+			case <-m.done:
+				ticker.Stop()
+				m.log.Info("token drift monitor stopped")
+				return
+			case <-ticker.C:
+				m.check()
+			}
+		}
+	}()
+}
+
+func (m *Monitor) Stop() {
+	m.log.Info("shutting down token drift monitor...")
+
+	m.done <- true
+}
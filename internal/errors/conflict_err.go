@@ -0,0 +1,17 @@
+package errors
+
+type ConflictError struct {
+	message string
+}
+
+func NewConflictError(msg string) *ConflictError {
+	return &ConflictError{
+		message: msg,
+	}
+}
+
+func (ce *ConflictError) Error() string {
+	return ce.message
+}
+
+func (ce *ConflictError) Conflict() {}
@@ -0,0 +1,17 @@
+package errors
+
+type ResidencyError struct {
+	message string
+}
+
+func NewResidencyError(msg string) *ResidencyError {
+	return &ResidencyError{
+		message: msg,
+	}
+}
+
+func (re *ResidencyError) Error() string {
+	return re.message
+}
+
+func (re *ResidencyError) Residency() {}
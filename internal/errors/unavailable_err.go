@@ -0,0 +1,17 @@
+package errors
+
+type UnavailableError struct {
+	message string
+}
+
+func NewUnavailableError(msg string) *UnavailableError {
+	return &UnavailableError{
+		message: msg,
+	}
+}
+
+func (ue *UnavailableError) Error() string {
+	return ue.message
+}
+
+func (ue *UnavailableError) Unavailable() {}
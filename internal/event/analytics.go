@@ -0,0 +1,58 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+const (
+	AnalyticsQueryAggregation string = "aggregation"
+	AnalyticsQueryTop         string = "top"
+)
+
+// MaxAnalyticsQueries is the number of reporting queries a question can be
+// answered with.
+const MaxAnalyticsQueries = 3
+
+type AnalyticsQuestion struct {
+	Question string `json:"question"`
+	TenantId string `json:"-"`
+}
+
+func (aq *AnalyticsQuestion) Validate() error {
+	if len(strings.TrimSpace(aq.Question)) == 0 || len(aq.Question) > 1000 {
+		return internal_errors.NewValidationError("fields [question] are invalid")
+	}
+
+	return nil
+}
+
+// AnalyticsQuery is a reporting query planned by the analytics route. Only
+// one of Aggregation and Top is set, depending on Type.
+type AnalyticsQuery struct {
+	Type        string              `json:"type"`
+	Aggregation *AggregationRequest `json:"aggregation,omitempty"`
+	Top         *TopRequest         `json:"top,omitempty"`
+}
+
+func (aq *AnalyticsQuery) Validate() error {
+	if aq.Type == AnalyticsQueryAggregation && aq.Aggregation != nil {
+		return aq.Aggregation.Validate()
+	}
+
+	if aq.Type == AnalyticsQueryTop && aq.Top != nil {
+		return aq.Top.Validate()
+	}
+
+	return internal_errors.NewValidationError(fmt.Sprintf("analytics query of type %q is invalid", aq.Type))
+}
+
+type AnalyticsAnswer struct {
+	Question  string            `json:"question"`
+	Answer    string            `json:"answer"`
+	Queries   []*AnalyticsQuery `json:"queries"`
+	Results   []any             `json:"results"`
+	Truncated bool              `json:"truncated,omitempty"`
+}
@@ -0,0 +1,106 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+var supportedChargebackDimensions = map[string]bool{
+	DimensionTag:      true,
+	DimensionKeyId:    true,
+	DimensionCustomId: true,
+	DimensionUserId:   true,
+}
+
+type ChargebackRequest struct {
+	Month            string   `json:"month"`
+	GroupBy          string   `json:"groupBy"`
+	MarkupPercentage float64  `json:"markupPercentage"`
+	Tags             []string `json:"tags"`
+	TenantId         string   `json:"-"`
+}
+
+func (cr *ChargebackRequest) Validate() error {
+	invalid := []string{}
+
+	if _, err := time.Parse("2006-01", cr.Month); err != nil {
+		invalid = append(invalid, "month")
+	}
+
+	if !supportedChargebackDimensions[cr.GroupBy] && !isMetadataDimension(cr.GroupBy) && !isTagDimension(cr.GroupBy) {
+		invalid = append(invalid, "groupBy")
+	}
+
+	if cr.MarkupPercentage < 0 {
+		invalid = append(invalid, "markupPercentage")
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
+
+// Range returns the unix timestamps of the first and the last second of the
+// requested month in UTC.
+func (cr *ChargebackRequest) Range() (int64, int64) {
+	parsed, _ := time.Parse("2006-01", cr.Month)
+	return parsed.Unix(), parsed.AddDate(0, 1, 0).Unix() - 1
+}
+
+type ChargebackLineItem struct {
+	Name                 string  `json:"name"`
+	NumberOfRequests     int64   `json:"numberOfRequests"`
+	PromptTokenCount     int     `json:"promptTokenCount"`
+	CompletionTokenCount int     `json:"completionTokenCount"`
+	CostInUsd            float64 `json:"costInUsd"`
+	MarkupInUsd          float64 `json:"markupInUsd"`
+	TotalInUsd           float64 `json:"totalInUsd"`
+}
+
+type ChargebackReport struct {
+	Month            string                `json:"month"`
+	GroupBy          string                `json:"groupBy"`
+	MarkupPercentage float64               `json:"markupPercentage"`
+	LineItems        []*ChargebackLineItem `json:"lineItems"`
+	CostInUsd        float64               `json:"costInUsd"`
+	MarkupInUsd      float64               `json:"markupInUsd"`
+	TotalInUsd       float64               `json:"totalInUsd"`
+}
+
+func (cr *ChargebackReport) Headers() []string {
+	return []string{cr.GroupBy, "requests", "prompt_tokens", "completion_tokens", "cost_usd", "markup_usd", "total_usd"}
+}
+
+func (cr *ChargebackReport) Rows() [][]string {
+	rows := [][]string{}
+	for _, item := range cr.LineItems {
+		name := item.Name
+		if len(name) == 0 {
+			name = "(none)"
+		}
+
+		rows = append(rows, []string{
+			name,
+			fmt.Sprintf("%d", item.NumberOfRequests),
+			fmt.Sprintf("%d", item.PromptTokenCount),
+			fmt.Sprintf("%d", item.CompletionTokenCount),
+			fmt.Sprintf("%.6f", item.CostInUsd),
+			fmt.Sprintf("%.6f", item.MarkupInUsd),
+			fmt.Sprintf("%.6f", item.TotalInUsd),
+		})
+	}
+
+	rows = append(rows, []string{
+		"total", "", "", "",
+		fmt.Sprintf("%.6f", cr.CostInUsd),
+		fmt.Sprintf("%.6f", cr.MarkupInUsd),
+		fmt.Sprintf("%.6f", cr.TotalInUsd),
+	})
+
+	return rows
+}
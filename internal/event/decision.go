@@ -0,0 +1,62 @@
+package event
+
+const (
+	DecisionStageOverride      string = "override"
+	DecisionStageComplexity    string = "complexity"
+	DecisionStageDowngrade     string = "downgrade"
+	DecisionStageContextWindow string = "context_window"
+	DecisionStageCache         string = "cache"
+	DecisionStageGuardrail     string = "guardrail"
+	DecisionStageDedup         string = "dedup"
+	DecisionStageUpstream      string = "upstream"
+	DecisionStageRetry         string = "retry"
+	DecisionStageFallback      string = "fallback"
+)
+
+// Decision is one step the proxy took while handling a request, such as a
+// cache lookup, a guardrail verdict or a call to a provider. Decisions of a
+// request are kept in the order they were taken.
+type Decision struct {
+	Stage       string `json:"stage"`
+	Outcome     string `json:"outcome"`
+	Detail      string `json:"detail,omitempty"`
+	Provider    string `json:"provider,omitempty"`
+	Model       string `json:"model,omitempty"`
+	Status      int    `json:"status,omitempty"`
+	LatencyInMs int    `json:"latency_in_ms,omitempty"`
+}
+
+// DecisionTrail explains how a request reached the model that answered it
+// and what it cost.
+type DecisionTrail struct {
+	RequestId   string      `json:"requestId"`
+	CreatedAt   int64       `json:"createdAt"`
+	Path        string      `json:"path"`
+	Route       string      `json:"route"`
+	Provider    string      `json:"provider"`
+	Model       string      `json:"model"`
+	Status      int         `json:"status"`
+	CostInUsd   float64     `json:"costInUsd"`
+	LatencyInMs int         `json:"latencyInMs"`
+	Decisions   []*Decision `json:"decisions"`
+}
+
+func NewDecisionTrail(e *Event) *DecisionTrail {
+	decisions := e.Decisions
+	if decisions == nil {
+		decisions = []*Decision{}
+	}
+
+	return &DecisionTrail{
+		RequestId:   e.Id,
+		CreatedAt:   e.CreatedAt,
+		Path:        e.Path,
+		Route:       e.Route,
+		Provider:    e.Provider,
+		Model:       e.Model,
+		Status:      e.Status,
+		CostInUsd:   e.CostInUsd,
+		LatencyInMs: e.LatencyInMs,
+		Decisions:   decisions,
+	}
+}
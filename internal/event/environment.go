@@ -0,0 +1,54 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+// EnvironmentDimensionPrefix groups by the value of an environment label, e.g.
+// env.color compares the events of a blue and a green deployment.
+const EnvironmentDimensionPrefix string = "env."
+
+func isEnvironmentDimension(dimension string) bool {
+	return strings.HasPrefix(dimension, EnvironmentDimensionPrefix) && IsValidMetadataKey(strings.TrimPrefix(dimension, EnvironmentDimensionPrefix))
+}
+
+// ParseEnvironmentLabels parses labels in the name:value form. Names follow the
+// charset of metadata keys so they can be used as reporting dimensions.
+func ParseEnvironmentLabels(labels []string) (map[string]string, error) {
+	parsed := map[string]string{}
+	invalid := []string{}
+
+	for _, label := range labels {
+		name, value, found := strings.Cut(strings.TrimSpace(label), ":")
+		if !found || !IsValidMetadataKey(name) || len(value) == 0 || len(value) > maxMetadataValueLength {
+			invalid = append(invalid, label)
+			continue
+		}
+
+		if _, ok := parsed[name]; ok {
+			invalid = append(invalid, label)
+			continue
+		}
+
+		parsed[name] = value
+	}
+
+	if len(invalid) > 0 {
+		return nil, internal_errors.NewValidationError(fmt.Sprintf("environment labels [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return parsed, nil
+}
+
+// EnvironmentTags formats labels as tags of metrics.
+func EnvironmentTags(labels map[string]string) []string {
+	tags := []string{}
+	for name, value := range labels {
+		tags = append(tags, name+":"+value)
+	}
+
+	return tags
+}
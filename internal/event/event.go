@@ -1,18 +1,37 @@
 package event
 
 type Event struct {
-	Id                   string   `json:"id"`
-	CreatedAt            int64    `json:"created_at"`
-	Tags                 []string `json:"tags"`
-	KeyId                string   `json:"key_id"`
-	CostInUsd            float64  `json:"cost_in_usd"`
-	Provider             string   `json:"provider"`
-	Model                string   `json:"model"`
-	Status               int      `json:"status"`
-	PromptTokenCount     int      `json:"prompt_token_count"`
-	CompletionTokenCount int      `json:"completion_token_count"`
-	LatencyInMs          int      `json:"latency_in_ms"`
-	Path                 string   `json:"path"`
-	Method               string   `json:"method"`
-	CustomId             string   `json:"custom_id"`
+	Id                   string            `json:"id"`
+	CreatedAt            int64             `json:"created_at"`
+	Tags                 []string          `json:"tags"`
+	KeyId                string            `json:"key_id"`
+	CostInUsd            float64           `json:"cost_in_usd"`
+	Provider             string            `json:"provider"`
+	Model                string            `json:"model"`
+	Status               int               `json:"status"`
+	PromptTokenCount     int               `json:"prompt_token_count"`
+	CompletionTokenCount int               `json:"completion_token_count"`
+	LatencyInMs          int               `json:"latency_in_ms"`
+	Path                 string            `json:"path"`
+	Method               string            `json:"method"`
+	CustomId             string            `json:"custom_id"`
+	UserId               string            `json:"user_id"`
+	Route                string            `json:"route"`
+	Metadata             map[string]string `json:"metadata"`
+	OriginalCostInUsd    float64           `json:"original_cost_in_usd"`
+	CorrelationId        string            `json:"correlation_id"`
+	TenantId             string            `json:"tenant_id"`
+	SessionId            string            `json:"session_id"`
+	Language             string            `json:"language"`
+	SettingId            string            `json:"setting_id"`
+	Score                *float64          `json:"score,omitempty"`
+	Feedback             *Feedback         `json:"feedback,omitempty"`
+	ParentId             string            `json:"parent_id"`
+	TraceId              string            `json:"trace_id"`
+	SpanId               string            `json:"span_id"`
+	UserAgent            string            `json:"user_agent"`
+	Sdk                  string            `json:"sdk"`
+	SdkVersion           string            `json:"sdk_version"`
+	Environment          map[string]string `json:"environment,omitempty"`
+	Decisions            []*Decision       `json:"decisions,omitempty"`
 }
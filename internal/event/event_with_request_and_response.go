@@ -13,4 +13,6 @@ type EventWithRequestAndContent struct {
 	Content             string
 	Response            interface{}
 	Key                 *key.ResponseKey
+	RecordedRequest     *RecordedRequest
+	Sample              *Sample
 }
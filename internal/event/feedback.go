@@ -0,0 +1,67 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+const (
+	FeedbackThumbUp   = "up"
+	FeedbackThumbDown = "down"
+)
+
+const (
+	minFeedbackRating     = 1
+	maxFeedbackRating     = 5
+	maxFeedbackCommentLen = 2000
+)
+
+// Feedback is what the client of a request thought of the response. It is
+// stored on the event of the request.
+type Feedback struct {
+	Thumb     string `json:"thumb,omitempty"`
+	Rating    *int   `json:"rating,omitempty"`
+	Comment   string `json:"comment,omitempty"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+// FeedbackRequest references a request by the id returned in the
+// X-BricksLLM-Request-Id header of its response.
+type FeedbackRequest struct {
+	RequestId string `json:"requestId"`
+	Thumb     string `json:"thumb"`
+	Rating    *int   `json:"rating"`
+	Comment   string `json:"comment"`
+}
+
+func (r *FeedbackRequest) Validate() error {
+	invalid := []string{}
+
+	if len(r.RequestId) == 0 {
+		invalid = append(invalid, "requestId")
+	}
+
+	if len(r.Thumb) != 0 && r.Thumb != FeedbackThumbUp && r.Thumb != FeedbackThumbDown {
+		invalid = append(invalid, "thumb")
+	}
+
+	if r.Rating != nil && (*r.Rating < minFeedbackRating || *r.Rating > maxFeedbackRating) {
+		invalid = append(invalid, "rating")
+	}
+
+	if len(r.Comment) > maxFeedbackCommentLen {
+		invalid = append(invalid, "comment")
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	if len(r.Thumb) == 0 && r.Rating == nil {
+		return internal_errors.NewValidationError("either thumb or rating has to be set")
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package event
+
+import (
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+// minimumElapsedForecastTime keeps projections made right after the start of
+// a month from extrapolating a handful of requests.
+const minimumElapsedForecastTime = time.Hour
+
+type ForecastRequest struct {
+	GroupBy  string   `json:"groupBy"`
+	Tags     []string `json:"tags"`
+	TenantId string   `json:"-"`
+}
+
+func (fr *ForecastRequest) Validate() error {
+	if fr.GroupBy != DimensionKeyId && fr.GroupBy != DimensionTag {
+		return internal_errors.NewValidationError("fields [groupBy] are invalid")
+	}
+
+	return nil
+}
+
+// Period returns the unix timestamps of the first and the last second of the
+// month containing now in UTC.
+func (fr *ForecastRequest) Period(now time.Time) (int64, int64) {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start.Unix(), start.AddDate(0, 1, 0).Unix() - 1
+}
+
+// Project extrapolates the spend made between start and now to the end of
+// the period.
+func Project(spend float64, start, end, now int64) float64 {
+	elapsed := now - start
+	if elapsed < int64(minimumElapsedForecastTime.Seconds()) {
+		elapsed = int64(minimumElapsedForecastTime.Seconds())
+	}
+
+	return spend * float64(end-start+1) / float64(elapsed)
+}
+
+type SpendForecast struct {
+	Name                     string  `json:"name"`
+	SpendInUsd               float64 `json:"spendInUsd"`
+	ProjectedSpendInUsd      float64 `json:"projectedSpendInUsd"`
+	BudgetInUsd              float64 `json:"budgetInUsd,omitempty"`
+	TotalSpendInUsd          float64 `json:"totalSpendInUsd,omitempty"`
+	ProjectedTotalSpendInUsd float64 `json:"projectedTotalSpendInUsd,omitempty"`
+	CostLimitInUsd           float64 `json:"costLimitInUsd,omitempty"`
+	AtRisk                   bool    `json:"atRisk"`
+}
+
+type ForecastReport struct {
+	GroupBy             string           `json:"groupBy"`
+	PeriodStart         int64            `json:"periodStart"`
+	PeriodEnd           int64            `json:"periodEnd"`
+	GeneratedAt         int64            `json:"generatedAt"`
+	SpendInUsd          float64          `json:"spendInUsd"`
+	ProjectedSpendInUsd float64          `json:"projectedSpendInUsd"`
+	Forecasts           []*SpendForecast `json:"forecasts"`
+}
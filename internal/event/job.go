@@ -0,0 +1,38 @@
+package event
+
+import "encoding/json"
+
+type JobStatus string
+
+const (
+	JobStatusQueued    JobStatus = "queued"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusCompleted JobStatus = "completed"
+	JobStatusFailed    JobStatus = "failed"
+)
+
+// Job is a proxy request submitted asynchronously. The request is sent by the
+// instance that accepted it and its response is kept until ExpiresAt so that
+// clients can poll for it. RequestId is the id of the event of the request.
+// InstanceId identifies the instance sending the request.
+type Job struct {
+	Id         string          `json:"id"`
+	CreatedAt  int64           `json:"createdAt"`
+	UpdatedAt  int64           `json:"updatedAt"`
+	KeyId      string          `json:"keyId"`
+	TenantId   string          `json:"tenantId,omitempty"`
+	Path       string          `json:"path"`
+	Status     JobStatus       `json:"status"`
+	Attempts   int             `json:"attempts"`
+	RequestId  string          `json:"requestId,omitempty"`
+	StatusCode int             `json:"statusCode,omitempty"`
+	Response   json.RawMessage `json:"response,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	WebhookUrl string          `json:"webhookUrl,omitempty"`
+	ExpiresAt  int64           `json:"expiresAt"`
+	InstanceId string          `json:"-"`
+}
+
+func (j *Job) IsFinished() bool {
+	return j.Status == JobStatusCompleted || j.Status == JobStatusFailed
+}
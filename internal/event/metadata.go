@@ -0,0 +1,73 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+const (
+	MetadataDimensionPrefix string = "metadata."
+
+	maxMetadataFields      = 16
+	maxMetadataValueLength = 256
+)
+
+var metadataKeyRegex = regexp.MustCompile(`^[A-Za-z0-9_\-\.]{1,64}$`)
+
+func IsValidMetadataKey(k string) bool {
+	return metadataKeyRegex.MatchString(k)
+}
+
+// ParseMetadata parses a flat JSON object into string key value pairs. Values
+// can be strings, numbers or booleans and are converted to their string form.
+func ParseMetadata(raw []byte) (map[string]string, error) {
+	parsed := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return nil, internal_errors.NewValidationError("metadata must be a json object")
+	}
+
+	if len(parsed) > maxMetadataFields {
+		return nil, internal_errors.NewValidationError(fmt.Sprintf("metadata cannot have more than %d fields", maxMetadataFields))
+	}
+
+	invalid := []string{}
+	metadata := map[string]string{}
+	for k, v := range parsed {
+		if !IsValidMetadataKey(k) {
+			invalid = append(invalid, k)
+			continue
+		}
+
+		val := ""
+		switch converted := v.(type) {
+		case string:
+			val = converted
+		case float64, bool:
+			val = fmt.Sprint(converted)
+		default:
+			invalid = append(invalid, k)
+			continue
+		}
+
+		if len(val) > maxMetadataValueLength {
+			invalid = append(invalid, k)
+			continue
+		}
+
+		metadata[k] = val
+	}
+
+	if len(invalid) > 0 {
+		return nil, internal_errors.NewValidationError(fmt.Sprintf("metadata fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return metadata, nil
+}
+
+func isMetadataDimension(dimension string) bool {
+	return strings.HasPrefix(dimension, MetadataDimensionPrefix) && IsValidMetadataKey(strings.TrimPrefix(dimension, MetadataDimensionPrefix))
+}
@@ -0,0 +1,55 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+type RecomputationRequest struct {
+	Start     int64    `json:"start"`
+	End       int64    `json:"end"`
+	Providers []string `json:"providers"`
+	Models    []string `json:"models"`
+	DryRun    bool     `json:"dryRun"`
+}
+
+func (rr *RecomputationRequest) Validate() error {
+	invalid := []string{}
+
+	if rr.Start <= 0 {
+		invalid = append(invalid, "start")
+	}
+
+	if rr.End <= 0 || rr.End < rr.Start {
+		invalid = append(invalid, "end")
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
+
+type ModelCostDiff struct {
+	Provider            string  `json:"provider"`
+	Model               string  `json:"model"`
+	NumberOfEvents      int     `json:"numberOfEvents"`
+	PreviousCostInUsd   float64 `json:"previousCostInUsd"`
+	RecomputedCostInUsd float64 `json:"recomputedCostInUsd"`
+	DifferenceInUsd     float64 `json:"differenceInUsd"`
+	NumberOfSkipped     int     `json:"numberOfSkippedEvents"`
+}
+
+type RecomputationReport struct {
+	DryRun              bool             `json:"dryRun"`
+	NumberOfScanned     int              `json:"numberOfScannedEvents"`
+	NumberOfUpdated     int              `json:"numberOfUpdatedEvents"`
+	NumberOfSkipped     int              `json:"numberOfSkippedEvents"`
+	PreviousCostInUsd   float64          `json:"previousCostInUsd"`
+	RecomputedCostInUsd float64          `json:"recomputedCostInUsd"`
+	DifferenceInUsd     float64          `json:"differenceInUsd"`
+	Models              []*ModelCostDiff `json:"models"`
+}
@@ -0,0 +1,58 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+// Reconciliation compares the spend recorded for a provider setting on a UTC
+// day with the cost the provider reports for it. Differences are reported as
+// reported minus recorded.
+type Reconciliation struct {
+	Day                  string  `json:"day"`
+	SettingId            string  `json:"settingId"`
+	Provider             string  `json:"provider"`
+	TenantId             string  `json:"tenantId"`
+	RecordedCostInUsd    float64 `json:"recordedCostInUsd"`
+	ReportedCostInUsd    float64 `json:"reportedCostInUsd"`
+	DifferenceInUsd      float64 `json:"differenceInUsd"`
+	DifferencePercentage float64 `json:"differencePercentage"`
+	Flagged              bool    `json:"flagged"`
+	Error                string  `json:"error"`
+	UpdatedAt            int64   `json:"updatedAt"`
+}
+
+type ReconciliationRequest struct {
+	Start     string `json:"start"`
+	End       string `json:"end"`
+	SettingId string `json:"settingId"`
+	Flagged   bool   `json:"flagged"`
+	TenantId  string `json:"-"`
+}
+
+func (rr *ReconciliationRequest) Validate() error {
+	invalid := []string{}
+
+	start, err := time.Parse(usageDateLayout, rr.Start)
+	if err != nil {
+		invalid = append(invalid, "start")
+	}
+
+	end, err := time.Parse(usageDateLayout, rr.End)
+	if err != nil {
+		invalid = append(invalid, "end")
+	}
+
+	if len(invalid) == 0 && end.Before(start) {
+		invalid = append(invalid, "end")
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+// RecordedRequest holds the payloads of a proxied request so that it can be
+// replayed later. Responses of streaming requests are stored as raw events.
+// Payloads are encrypted with the recording key of the tenant, and expired
+// recordings are purged.
+type RecordedRequest struct {
+	EventId   string `json:"eventId"`
+	CreatedAt int64  `json:"createdAt"`
+	Request   []byte `json:"request"`
+	Response  []byte `json:"response"`
+	TenantId  string `json:"tenantId"`
+	ExpiresAt int64  `json:"expiresAt"`
+	Encrypted bool   `json:"encrypted"`
+}
+
+type ReplayRequest struct {
+	Provider string            `json:"provider"`
+	Model    string            `json:"model"`
+	Params   map[string]string `json:"params"`
+	RouteId  string            `json:"routeId"`
+	Timeout  string            `json:"timeout"`
+	TenantId string            `json:"-"`
+}
+
+func (r *ReplayRequest) Validate() error {
+	invalid := []string{}
+
+	if len(r.RouteId) != 0 && (len(r.Provider) != 0 || len(r.Model) != 0 || len(r.Params) != 0) {
+		invalid = append(invalid, "routeId")
+	}
+
+	if len(r.Provider) != 0 && r.Provider != "openai" && r.Provider != "azure" {
+		invalid = append(invalid, "provider")
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
+
+type ReplayOutcome struct {
+	Provider             string  `json:"provider"`
+	Model                string  `json:"model"`
+	Status               int     `json:"status"`
+	LatencyInMs          int     `json:"latencyInMs"`
+	PromptTokenCount     int     `json:"promptTokenCount"`
+	CompletionTokenCount int     `json:"completionTokenCount"`
+	CostInUsd            float64 `json:"costInUsd"`
+	Content              string  `json:"content"`
+	Error                string  `json:"error,omitempty"`
+}
+
+type ReplayDiff struct {
+	CostInUsd            float64  `json:"costInUsd"`
+	LatencyInMs          int      `json:"latencyInMs"`
+	PromptTokenCount     int      `json:"promptTokenCount"`
+	CompletionTokenCount int      `json:"completionTokenCount"`
+	ContentChanged       bool     `json:"contentChanged"`
+	Content              []string `json:"content"`
+}
+
+type ReplayResult struct {
+	EventId  string         `json:"eventId"`
+	Original *ReplayOutcome `json:"original"`
+	Replayed *ReplayOutcome `json:"replayed"`
+	Diff     *ReplayDiff    `json:"diff"`
+}
+
+// NewReplayDiff compares two outcomes. Numeric fields are the replayed value
+// minus the original value.
+func NewReplayDiff(original, replayed *ReplayOutcome) *ReplayDiff {
+	return &ReplayDiff{
+		CostInUsd:            replayed.CostInUsd - original.CostInUsd,
+		LatencyInMs:          replayed.LatencyInMs - original.LatencyInMs,
+		PromptTokenCount:     replayed.PromptTokenCount - original.PromptTokenCount,
+		CompletionTokenCount: replayed.CompletionTokenCount - original.CompletionTokenCount,
+		ContentChanged:       original.Content != replayed.Content,
+		Content:              diffLines(original.Content, replayed.Content),
+	}
+}
+
+// diffLines returns a unified style line diff where removed lines are
+// prefixed with "- ", added lines with "+ " and unchanged lines with "  ".
+func diffLines(a, b string) []string {
+	x := strings.Split(a, "\n")
+	y := strings.Split(b, "\n")
+
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	lines := []string{}
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		if x[i] == y[j] {
+			lines = append(lines, "  "+x[i])
+			i++
+			j++
+		} else if lcs[i+1][j] >= lcs[i][j+1] {
+			lines = append(lines, "- "+x[i])
+			i++
+		} else {
+			lines = append(lines, "+ "+y[j])
+			j++
+		}
+	}
+
+	for ; i < len(x); i++ {
+		lines = append(lines, "- "+x[i])
+	}
+
+	for ; j < len(y); j++ {
+		lines = append(lines, "+ "+y[j])
+	}
+
+	return lines
+}
@@ -1,5 +1,13 @@
 package event
 
+import (
+	"fmt"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/tag"
+)
+
 type DataPoint struct {
 	TimeStamp            int64   `json:"timeStamp"`
 	NumberOfRequests     int64   `json:"numberOfRequests"`
@@ -28,3 +36,137 @@ type ReportingRequest struct {
 	Increment int64    `json:"increment"`
 	Filters   []string `json:"filters"`
 }
+
+const (
+	DimensionKeyId      string = "keyId"
+	DimensionTag        string = "tag"
+	DimensionModel      string = "model"
+	DimensionProvider   string = "provider"
+	DimensionRoute      string = "route"
+	DimensionPath       string = "path"
+	DimensionUserId     string = "userId"
+	DimensionCustomId   string = "customId"
+	DimensionLanguage   string = "language"
+	DimensionSdk        string = "sdk"
+	DimensionSdkVersion string = "sdkVersion"
+	DimensionUserAgent  string = "userAgent"
+
+	// TagDimensionPrefix groups by the value of a tag of the taxonomy, e.g.
+	// tags.team groups events of keys tagged team:search and team:ads.
+	TagDimensionPrefix string = "tags."
+)
+
+var supportedDimensions = map[string]bool{
+	DimensionKeyId:      true,
+	DimensionTag:        true,
+	DimensionModel:      true,
+	DimensionProvider:   true,
+	DimensionRoute:      true,
+	DimensionPath:       true,
+	DimensionUserId:     true,
+	DimensionCustomId:   true,
+	DimensionLanguage:   true,
+	DimensionSdk:        true,
+	DimensionSdkVersion: true,
+	DimensionUserAgent:  true,
+}
+
+func isTagDimension(dimension string) bool {
+	return strings.HasPrefix(dimension, TagDimensionPrefix) && tag.IsValidName(strings.TrimPrefix(dimension, TagDimensionPrefix))
+}
+
+const (
+	GranularityHour string = "hour"
+	GranularityDay  string = "day"
+)
+
+type AggregationRequest struct {
+	Start       int64             `json:"start"`
+	End         int64             `json:"end"`
+	Granularity string            `json:"granularity"`
+	GroupBy     []string          `json:"groupBy"`
+	KeyIds      []string          `json:"keyIds"`
+	Tags        []string          `json:"tags"`
+	Models      []string          `json:"models"`
+	Providers   []string          `json:"providers"`
+	Metadata    map[string]string `json:"metadata"`
+	Environment map[string]string `json:"environment"`
+	TenantId    string            `json:"-"`
+}
+
+func (ar *AggregationRequest) Validate() error {
+	invalid := []string{}
+
+	if ar.Start <= 0 {
+		invalid = append(invalid, "start")
+	}
+
+	if ar.End <= 0 || ar.End < ar.Start {
+		invalid = append(invalid, "end")
+	}
+
+	if len(ar.Granularity) != 0 && ar.Granularity != GranularityHour && ar.Granularity != GranularityDay {
+		invalid = append(invalid, "granularity")
+	}
+
+	seen := map[string]bool{}
+	for index, dimension := range ar.GroupBy {
+		if (!supportedDimensions[dimension] && !isMetadataDimension(dimension) && !isTagDimension(dimension) && !isEnvironmentDimension(dimension)) || seen[dimension] {
+			invalid = append(invalid, fmt.Sprintf("groupBy.[%d]", index))
+		}
+
+		seen[dimension] = true
+	}
+
+	for k := range ar.Metadata {
+		if !IsValidMetadataKey(k) {
+			invalid = append(invalid, fmt.Sprintf("metadata.%s", k))
+		}
+	}
+
+	for k := range ar.Environment {
+		if !IsValidMetadataKey(k) {
+			invalid = append(invalid, fmt.Sprintf("environment.%s", k))
+		}
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
+
+// Increment returns the bucket size in seconds. An empty granularity
+// aggregates the whole time range into a single bucket.
+func (ar *AggregationRequest) Increment() int64 {
+	if ar.Granularity == GranularityHour {
+		return 3600
+	}
+
+	if ar.Granularity == GranularityDay {
+		return 86400
+	}
+
+	return 0
+}
+
+type AggregatedDataPoint struct {
+	TimeStamp            int64             `json:"timeStamp"`
+	NumberOfRequests     int64             `json:"numberOfRequests"`
+	CostInUsd            float64           `json:"costInUsd"`
+	PromptTokenCount     int               `json:"promptTokenCount"`
+	CompletionTokenCount int               `json:"completionTokenCount"`
+	SuccessCount         int               `json:"successCount"`
+	ScoredCount          int64             `json:"scoredCount"`
+	AverageScore         float64           `json:"averageScore"`
+	ThumbsUpCount        int64             `json:"thumbsUpCount"`
+	ThumbsDownCount      int64             `json:"thumbsDownCount"`
+	RatedCount           int64             `json:"ratedCount"`
+	AverageRating        float64           `json:"averageRating"`
+	Dimensions           map[string]string `json:"dimensions"`
+}
+
+type AggregationResponse struct {
+	DataPoints []*AggregatedDataPoint `json:"dataPoints"`
+}
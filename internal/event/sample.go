@@ -0,0 +1,63 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+// Sample is a redacted chat completion captured from live traffic for offline
+// evaluation and fine-tuning. Request and Response hold the raw payloads until
+// the sample is redacted and are never stored.
+type Sample struct {
+	Id         string          `json:"id"`
+	CreatedAt  int64           `json:"createdAt"`
+	EventId    string          `json:"eventId"`
+	TenantId   string          `json:"tenantId"`
+	KeyId      string          `json:"keyId"`
+	Provider   string          `json:"provider"`
+	Model      string          `json:"model"`
+	Messages   json.RawMessage `json:"messages"`
+	Completion string          `json:"completion"`
+	Request    []byte          `json:"-"`
+	Response   []byte          `json:"-"`
+	Streamed   string          `json:"-"`
+}
+
+const (
+	SampleFormatFull     = "full"
+	SampleFormatMessages = "messages"
+)
+
+type SampleExportRequest struct {
+	Start    int64  `json:"start"`
+	End      int64  `json:"end"`
+	Model    string `json:"model"`
+	KeyId    string `json:"keyId"`
+	Format   string `json:"format"`
+	TenantId string `json:"-"`
+}
+
+func (r *SampleExportRequest) Validate() error {
+	invalid := []string{}
+
+	if r.Start <= 0 {
+		invalid = append(invalid, "start")
+	}
+
+	if r.End <= 0 || r.End < r.Start {
+		invalid = append(invalid, "end")
+	}
+
+	if r.Format != SampleFormatFull && r.Format != SampleFormatMessages {
+		invalid = append(invalid, "format")
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+const maxEventSearchLimit = 500
+
+type EventSearchRequest struct {
+	Start          int64    `json:"start"`
+	End            int64    `json:"end"`
+	KeyIds         []string `json:"keyIds"`
+	Models         []string `json:"models"`
+	Providers      []string `json:"providers"`
+	StatusCodes    []int    `json:"statusCodes"`
+	MinCostInUsd   *float64 `json:"minCostInUsd"`
+	MaxCostInUsd   *float64 `json:"maxCostInUsd"`
+	MinLatencyInMs *int     `json:"minLatencyInMs"`
+	MaxLatencyInMs *int     `json:"maxLatencyInMs"`
+	CorrelationId  string   `json:"correlationId"`
+	TraceId        string   `json:"traceId"`
+	Sdks           []string `json:"sdks"`
+	SdkVersions    []string `json:"sdkVersions"`
+	Limit          int      `json:"limit"`
+	Offset         int      `json:"offset"`
+	TenantId       string   `json:"-"`
+}
+
+func (sr *EventSearchRequest) Validate() error {
+	invalid := []string{}
+
+	if sr.Start <= 0 {
+		invalid = append(invalid, "start")
+	}
+
+	if sr.End <= 0 || sr.End < sr.Start {
+		invalid = append(invalid, "end")
+	}
+
+	for index, code := range sr.StatusCodes {
+		if code < 100 || code > 599 {
+			invalid = append(invalid, fmt.Sprintf("statusCodes.[%d]", index))
+		}
+	}
+
+	if sr.MinCostInUsd != nil && *sr.MinCostInUsd < 0 {
+		invalid = append(invalid, "minCostInUsd")
+	}
+
+	if sr.MaxCostInUsd != nil && (*sr.MaxCostInUsd < 0 || (sr.MinCostInUsd != nil && *sr.MaxCostInUsd < *sr.MinCostInUsd)) {
+		invalid = append(invalid, "maxCostInUsd")
+	}
+
+	if sr.MinLatencyInMs != nil && *sr.MinLatencyInMs < 0 {
+		invalid = append(invalid, "minLatencyInMs")
+	}
+
+	if sr.MaxLatencyInMs != nil && (*sr.MaxLatencyInMs < 0 || (sr.MinLatencyInMs != nil && *sr.MaxLatencyInMs < *sr.MinLatencyInMs)) {
+		invalid = append(invalid, "maxLatencyInMs")
+	}
+
+	if sr.Limit < 0 || sr.Limit > maxEventSearchLimit {
+		invalid = append(invalid, "limit")
+	}
+
+	if sr.Offset < 0 {
+		invalid = append(invalid, "offset")
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	if sr.Limit == 0 {
+		sr.Limit = 100
+	}
+
+	return nil
+}
+
+type EventSearchResponse struct {
+	Events  []*Event `json:"events"`
+	Limit   int      `json:"limit"`
+	Offset  int      `json:"offset"`
+	HasMore bool     `json:"hasMore"`
+}
@@ -0,0 +1,60 @@
+package event
+
+import "regexp"
+
+var sessionIdRegex = regexp.MustCompile(`^[A-Za-z0-9_\-\.:]{1,128}$`)
+
+func IsValidSessionId(id string) bool {
+	return sessionIdRegex.MatchString(id)
+}
+
+var requestIdRegex = regexp.MustCompile(`^[A-Za-z0-9\-]{1,64}$`)
+
+// IsValidRequestId reports whether id has the shape of the ids the proxy
+// returns in the X-BricksLLM-Request-Id header.
+func IsValidRequestId(id string) bool {
+	return requestIdRegex.MatchString(id)
+}
+
+// SessionTimeline lists the events of a session in the order they were
+// created along with their totals.
+type SessionTimeline struct {
+	SessionId            string   `json:"sessionId"`
+	KeyIds               []string `json:"keyIds"`
+	StartedAt            int64    `json:"startedAt"`
+	EndedAt              int64    `json:"endedAt"`
+	CostInUsd            float64  `json:"costInUsd"`
+	PromptTokenCount     int      `json:"promptTokenCount"`
+	CompletionTokenCount int      `json:"completionTokenCount"`
+	Events               []*Event `json:"events"`
+}
+
+func NewSessionTimeline(sessionId string, events []*Event) *SessionTimeline {
+	t := &SessionTimeline{
+		SessionId: sessionId,
+		KeyIds:    []string{},
+		Events:    events,
+	}
+
+	seen := map[string]bool{}
+	for _, e := range events {
+		if t.StartedAt == 0 || e.CreatedAt < t.StartedAt {
+			t.StartedAt = e.CreatedAt
+		}
+
+		if e.CreatedAt > t.EndedAt {
+			t.EndedAt = e.CreatedAt
+		}
+
+		if !seen[e.KeyId] {
+			seen[e.KeyId] = true
+			t.KeyIds = append(t.KeyIds, e.KeyId)
+		}
+
+		t.CostInUsd += e.CostInUsd
+		t.PromptTokenCount += e.PromptTokenCount
+		t.CompletionTokenCount += e.CompletionTokenCount
+	}
+
+	return t
+}
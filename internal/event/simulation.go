@@ -0,0 +1,98 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+const maxSimulationTargets = 10
+
+type SimulationTarget struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+}
+
+// SimulationRequest re-prices the traffic of a time range as if it had been
+// sent to each of the targets.
+type SimulationRequest struct {
+	Start     int64               `json:"start"`
+	End       int64               `json:"end"`
+	KeyIds    []string            `json:"keyIds"`
+	Tags      []string            `json:"tags"`
+	Providers []string            `json:"providers"`
+	Models    []string            `json:"models"`
+	Targets   []*SimulationTarget `json:"targets"`
+	TenantId  string              `json:"-"`
+}
+
+func (sr *SimulationRequest) Validate() error {
+	invalid := []string{}
+
+	if sr.Start <= 0 {
+		invalid = append(invalid, "start")
+	}
+
+	if sr.End <= 0 || sr.End < sr.Start {
+		invalid = append(invalid, "end")
+	}
+
+	if len(sr.Targets) == 0 || len(sr.Targets) > maxSimulationTargets {
+		invalid = append(invalid, "targets")
+	}
+
+	for index, t := range sr.Targets {
+		if t == nil {
+			invalid = append(invalid, fmt.Sprintf("targets.[%d]", index))
+			continue
+		}
+
+		if t.Provider != "openai" && t.Provider != "azure" && t.Provider != "anthropic" {
+			invalid = append(invalid, fmt.Sprintf("targets.[%d].provider", index))
+		}
+
+		if len(t.Model) == 0 {
+			invalid = append(invalid, fmt.Sprintf("targets.[%d].model", index))
+		}
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
+
+type SimulatedModelCost struct {
+	Provider             string  `json:"provider"`
+	Model                string  `json:"model"`
+	NumberOfRequests     int64   `json:"numberOfRequests"`
+	PromptTokenCount     int     `json:"promptTokenCount"`
+	CompletionTokenCount int     `json:"completionTokenCount"`
+	ActualCostInUsd      float64 `json:"actualCostInUsd"`
+	SimulatedCostInUsd   float64 `json:"simulatedCostInUsd"`
+	NumberOfSkipped      int64   `json:"numberOfSkippedRequests"`
+}
+
+// SimulationScenario is the cost of the traffic priced under one target.
+// Traffic that cannot be priced under the target, such as embeddings on a
+// chat model, is skipped and excluded from both costs.
+type SimulationScenario struct {
+	Provider           string                `json:"provider"`
+	Model              string                `json:"model"`
+	NumberOfRequests   int64                 `json:"numberOfRequests"`
+	NumberOfSkipped    int64                 `json:"numberOfSkippedRequests"`
+	ActualCostInUsd    float64               `json:"actualCostInUsd"`
+	SimulatedCostInUsd float64               `json:"simulatedCostInUsd"`
+	DifferenceInUsd    float64               `json:"differenceInUsd"`
+	Models             []*SimulatedModelCost `json:"models"`
+}
+
+type SimulationReport struct {
+	Start            int64                 `json:"start"`
+	End              int64                 `json:"end"`
+	NumberOfRequests int64                 `json:"numberOfRequests"`
+	ActualCostInUsd  float64               `json:"actualCostInUsd"`
+	Scenarios        []*SimulationScenario `json:"scenarios"`
+}
@@ -0,0 +1,93 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+type SubjectRequestAction string
+
+const (
+	// SubjectRequestActionExport collects the events and recorded requests
+	// of an end user.
+	SubjectRequestActionExport SubjectRequestAction = "export"
+	// SubjectRequestActionDelete deletes the events and recorded requests of
+	// an end user.
+	SubjectRequestActionDelete SubjectRequestAction = "delete"
+)
+
+type SubjectRequestStatus string
+
+const (
+	SubjectRequestStatusPending   SubjectRequestStatus = "pending"
+	SubjectRequestStatusRunning   SubjectRequestStatus = "running"
+	SubjectRequestStatusCompleted SubjectRequestStatus = "completed"
+	SubjectRequestStatusFailed    SubjectRequestStatus = "failed"
+)
+
+// SubjectRequest is a data subject access or deletion request for an end
+// user. The end user is matched by the user field of events, or by the value
+// of a metadata key when MetadataKey is set. Requests are processed
+// asynchronously.
+type SubjectRequest struct {
+	Id          string               `json:"id"`
+	CreatedAt   int64                `json:"createdAt"`
+	UpdatedAt   int64                `json:"updatedAt"`
+	TenantId    string               `json:"tenantId"`
+	Action      SubjectRequestAction `json:"action"`
+	UserId      string               `json:"userId"`
+	MetadataKey string               `json:"metadataKey"`
+	Status      SubjectRequestStatus `json:"status"`
+	Report      *SubjectReport       `json:"report"`
+	Error       string               `json:"error,omitempty"`
+	// Pseudonyms are matched instead of UserId when user ids of events are
+	// pseudonymized.
+	Pseudonyms []string `json:"-"`
+}
+
+func (r *SubjectRequest) Validate() error {
+	invalid := []string{}
+
+	if r.Action != SubjectRequestActionExport && r.Action != SubjectRequestActionDelete {
+		invalid = append(invalid, "action")
+	}
+
+	if len(r.UserId) == 0 {
+		invalid = append(invalid, "userId")
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
+
+// SubjectReport is the completion report of a subject request. Recorded
+// requests whose recording key was deleted are counted as unreadable and
+// left out of exports. Shadow results are only deleted, never exported.
+type SubjectReport struct {
+	Events                     int   `json:"events"`
+	RecordedRequests           int   `json:"recordedRequests"`
+	ShadowResults              int   `json:"shadowResults"`
+	Samples                    int   `json:"samples"`
+	UnreadableRecordedRequests int   `json:"unreadableRecordedRequests"`
+	CompletedAt                int64 `json:"completedAt"`
+}
+
+type SubjectRecording struct {
+	EventId   string `json:"eventId"`
+	CreatedAt int64  `json:"createdAt"`
+	Request   string `json:"request"`
+	Response  string `json:"response"`
+}
+
+// SubjectExport holds the data of an end user collected by an export
+// request.
+type SubjectExport struct {
+	UserId           string              `json:"userId"`
+	Events           []*Event            `json:"events"`
+	RecordedRequests []*SubjectRecording `json:"recordedRequests"`
+}
@@ -0,0 +1,40 @@
+package event
+
+// TaskReport lists the requests of an agent task, the root request and every
+// request following up on it, along with their totals.
+type TaskReport struct {
+	RootId               string   `json:"rootId"`
+	StartedAt            int64    `json:"startedAt"`
+	EndedAt              int64    `json:"endedAt"`
+	NumberOfRequests     int      `json:"numberOfRequests"`
+	CostInUsd            float64  `json:"costInUsd"`
+	PromptTokenCount     int      `json:"promptTokenCount"`
+	CompletionTokenCount int      `json:"completionTokenCount"`
+	Events               []*Event `json:"events"`
+}
+
+// NewTaskReport expects the events of the task ordered by creation time with
+// the root request first.
+func NewTaskReport(events []*Event) *TaskReport {
+	t := &TaskReport{
+		RootId:           events[0].Id,
+		NumberOfRequests: len(events),
+		Events:           events,
+	}
+
+	for _, e := range events {
+		if t.StartedAt == 0 || e.CreatedAt < t.StartedAt {
+			t.StartedAt = e.CreatedAt
+		}
+
+		if e.CreatedAt > t.EndedAt {
+			t.EndedAt = e.CreatedAt
+		}
+
+		t.CostInUsd += e.CostInUsd
+		t.PromptTokenCount += e.PromptTokenCount
+		t.CompletionTokenCount += e.CompletionTokenCount
+	}
+
+	return t
+}
@@ -0,0 +1,67 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+const (
+	TopKeysBySpend     string = "keys-by-spend"
+	TopModelsByTokens  string = "models-by-tokens"
+	TopKeysByErrors    string = "keys-by-errors"
+	TopRoutesByLatency string = "routes-by-latency"
+)
+
+var supportedTopMetrics = map[string]bool{
+	TopKeysBySpend:     true,
+	TopModelsByTokens:  true,
+	TopKeysByErrors:    true,
+	TopRoutesByLatency: true,
+}
+
+type TopRequest struct {
+	Metric   string `json:"metric"`
+	Start    int64  `json:"start"`
+	End      int64  `json:"end"`
+	Limit    int    `json:"limit"`
+	TenantId string `json:"-"`
+}
+
+func (tr *TopRequest) Validate() error {
+	invalid := []string{}
+
+	if !supportedTopMetrics[tr.Metric] {
+		invalid = append(invalid, "metric")
+	}
+
+	if tr.Start <= 0 {
+		invalid = append(invalid, "start")
+	}
+
+	if tr.End <= 0 || tr.End < tr.Start {
+		invalid = append(invalid, "end")
+	}
+
+	if tr.Limit <= 0 || tr.Limit > 100 {
+		invalid = append(invalid, "limit")
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
+
+type TopEntry struct {
+	Name             string  `json:"name"`
+	Value            float64 `json:"value"`
+	NumberOfRequests int64   `json:"numberOfRequests"`
+}
+
+type TopResponse struct {
+	Metric  string      `json:"metric"`
+	Entries []*TopEntry `json:"entries"`
+}
@@ -0,0 +1,71 @@
+package event
+
+import (
+	"strings"
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+const usageDateLayout = "2006-01-02"
+
+// UsageRequest asks for the usage of a single key on a given UTC date, mirroring
+// the query accepted by OpenAI's usage endpoint.
+type UsageRequest struct {
+	KeyId string `json:"keyId"`
+	Date  string `json:"date"`
+}
+
+func (ur *UsageRequest) Validate() error {
+	if _, err := time.Parse(usageDateLayout, ur.Date); err != nil {
+		return internal_errors.NewValidationError("fields [date] are invalid")
+	}
+
+	return nil
+}
+
+func (ur *UsageRequest) Range() (int64, int64) {
+	day, _ := time.Parse(usageDateLayout, ur.Date)
+	return day.Unix(), day.AddDate(0, 0, 1).Unix() - 1
+}
+
+// UsageDataPoint follows the field names of OpenAI's usage data so that existing
+// dashboards can consume it without changes.
+type UsageDataPoint struct {
+	AggregationTimestamp  int64  `json:"aggregation_timestamp"`
+	NumberOfRequests      int64  `json:"n_requests"`
+	Operation             string `json:"operation"`
+	SnapshotId            string `json:"snapshot_id"`
+	NContextTokensTotal   int    `json:"n_context_tokens_total"`
+	NGeneratedTokensTotal int    `json:"n_generated_tokens_total"`
+}
+
+type UsageResponse struct {
+	Object          string            `json:"object"`
+	Data            []*UsageDataPoint `json:"data"`
+	FtData          []any             `json:"ft_data"`
+	DalleApiData    []any             `json:"dalle_api_data"`
+	WhisperApiData  []any             `json:"whisper_api_data"`
+	TtsApiData      []any             `json:"tts_api_data"`
+	CurrentUsageUsd float64           `json:"current_usage_usd"`
+}
+
+func NewUsageResponse() *UsageResponse {
+	return &UsageResponse{
+		Object:         "list",
+		Data:           []*UsageDataPoint{},
+		FtData:         []any{},
+		DalleApiData:   []any{},
+		WhisperApiData: []any{},
+		TtsApiData:     []any{},
+	}
+}
+
+// UsageOperation maps a proxied path onto the operation names used by OpenAI.
+func UsageOperation(path string) string {
+	if strings.HasSuffix(path, "/embeddings") {
+		return "embeddings"
+	}
+
+	return "completion"
+}
@@ -0,0 +1,137 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+)
+
+const (
+	pdfPageHeight   = 792
+	pdfPageWidth    = 612
+	pdfMargin       = 40
+	pdfLineHeight   = 14
+	pdfFontSize     = 9
+	pdfLinesPerPage = (pdfPageHeight - 2*pdfMargin) / pdfLineHeight
+)
+
+func ToCsv(headers []string, rows [][]string) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+
+	if err := w.Write(headers); err != nil {
+		return nil, err
+	}
+
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ToPdf renders a plain text table into a minimal PDF document using the
+// built in Courier font, so that columns stay aligned without embedding fonts.
+func ToPdf(title string, headers []string, rows [][]string) []byte {
+	widths := make([]int, len(headers))
+	for i, h := range headers {
+		widths[i] = len(h)
+	}
+
+	for _, row := range rows {
+		for i, cell := range row {
+			if i < len(widths) && len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+
+	lines := []string{title, ""}
+	lines = append(lines, formatRow(headers, widths))
+
+	separator := make([]string, len(widths))
+	for i, w := range widths {
+		separator[i] = strings.Repeat("-", w)
+	}
+
+	lines = append(lines, formatRow(separator, widths))
+	for _, row := range rows {
+		lines = append(lines, formatRow(row, widths))
+	}
+
+	pages := [][]string{}
+	for len(lines) > pdfLinesPerPage {
+		pages = append(pages, lines[:pdfLinesPerPage])
+		lines = lines[pdfLinesPerPage:]
+	}
+
+	pages = append(pages, lines)
+
+	return buildPdf(pages)
+}
+
+func formatRow(cells []string, widths []int) string {
+	padded := make([]string, len(widths))
+	for i, w := range widths {
+		cell := ""
+		if i < len(cells) {
+			cell = cells[i]
+		}
+
+		padded[i] = cell + strings.Repeat(" ", w-len(cell))
+	}
+
+	return strings.TrimRight(strings.Join(padded, "  "), " ")
+}
+
+func escapePdfText(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "(", `\(`)
+	return strings.ReplaceAll(s, ")", `\)`)
+}
+
+func buildPdf(pages [][]string) []byte {
+	// object 1 is the catalog, 2 the page tree, 3 the font. every page takes
+	// two objects: the page itself followed by its content stream.
+	objects := []string{"", "", "<< /Type /Font /Subtype /Type1 /BaseFont /Courier >>"}
+	kids := []string{}
+
+	for _, lines := range pages {
+		content := &bytes.Buffer{}
+		fmt.Fprintf(content, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", pdfFontSize, pdfLineHeight, pdfMargin, pdfPageHeight-pdfMargin)
+		for _, line := range lines {
+			fmt.Fprintf(content, "(%s) Tj T*\n", escapePdfText(line))
+		}
+		content.WriteString("ET")
+
+		pageId := len(objects) + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageId))
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", pdfPageWidth, pdfPageHeight, pageId+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+		)
+	}
+
+	objects[0] = "<< /Type /Catalog /Pages 2 0 R >>"
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids))
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(buf, "%010d 00000 n \n", offset)
+	}
+
+	fmt.Fprintf(buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return buf.Bytes()
+}
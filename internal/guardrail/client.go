@@ -0,0 +1,224 @@
+package guardrail
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/prompt"
+)
+
+// azureMaxTextLength is the longest text Azure AI Content Safety analyzes in
+// a single request.
+const azureMaxTextLength = 10000
+
+type Client struct {
+	client http.Client
+}
+
+func NewClient(client http.Client) *Client {
+	return &Client{
+		client: client,
+	}
+}
+
+// CheckAll runs guardrails concurrently and returns their verdicts in the
+// order of the guardrails.
+func (c *Client) CheckAll(gs []*Guardrail, stage string, messages []*prompt.Message) []*Verdict {
+	verdicts := make([]*Verdict, len(gs))
+
+	var wg sync.WaitGroup
+	for index, g := range gs {
+		wg.Add(1)
+		go func(index int, g *Guardrail) {
+			defer wg.Done()
+			verdicts[index] = c.Check(g, stage, messages)
+		}(index, g)
+	}
+
+	wg.Wait()
+
+	return verdicts
+}
+
+// Check runs a guardrail within its latency budget.
+func (c *Client) Check(g *Guardrail, stage string, messages []*prompt.Message) *Verdict {
+	v := &Verdict{
+		Guardrail: g.Name,
+		Provider:  g.Provider,
+		Stage:     stage,
+	}
+
+	if len(messages) == 0 {
+		return v
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), g.GetLatencyBudget())
+	defer cancel()
+
+	var categories []string
+	var err error
+
+	switch g.Provider {
+	case ProviderLakera:
+		categories, err = c.checkLakera(ctx, g, messages)
+	case ProviderAzureContentSafety:
+		categories, err = c.checkAzure(ctx, g, messages)
+	default:
+		err = fmt.Errorf("guardrail provider %s is not supported", g.Provider)
+	}
+
+	v.LatencyInMs = int(time.Since(start).Milliseconds())
+
+	if err != nil {
+		v.Error = err.Error()
+		v.Blocked = !g.FailsOpen()
+		return v
+	}
+
+	v.Flagged = len(categories) != 0
+	v.Blocked = v.Flagged
+	v.Categories = categories
+
+	return v
+}
+
+func (c *Client) post(ctx context.Context, endpoint string, header map[string]string, payload any, out any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("guardrail service responded with status %d: %s", res.StatusCode, string(body))
+	}
+
+	return json.Unmarshal(body, out)
+}
+
+type lakeraResponse struct {
+	Flagged   bool `json:"flagged"`
+	Breakdown []struct {
+		DetectorType string `json:"detector_type"`
+		Detected     bool   `json:"detected"`
+	} `json:"breakdown"`
+}
+
+// checkLakera sends the conversation to Lakera Guard and returns the
+// detectors that flagged it.
+func (c *Client) checkLakera(ctx context.Context, g *Guardrail, messages []*prompt.Message) ([]string, error) {
+	res := &lakeraResponse{}
+	err := c.post(ctx, g.Url, map[string]string{
+		"Authorization": "Bearer " + g.ApiKey,
+	}, map[string]any{
+		"messages":  messages,
+		"breakdown": true,
+	}, res)
+	if err != nil {
+		return nil, err
+	}
+
+	if !res.Flagged {
+		return nil, nil
+	}
+
+	categories := []string{}
+	for _, d := range res.Breakdown {
+		if d.Detected {
+			categories = append(categories, d.DetectorType)
+		}
+	}
+
+	// flagged content is reported even when the breakdown is missing.
+	if len(categories) == 0 {
+		categories = append(categories, "flagged")
+	}
+
+	return categories, nil
+}
+
+type azureResponse struct {
+	CategoriesAnalysis []struct {
+		Category string `json:"category"`
+		Severity int    `json:"severity"`
+	} `json:"categoriesAnalysis"`
+}
+
+func splitText(text string, size int) []string {
+	runes := []rune(text)
+	parts := []string{}
+	for len(runes) > size {
+		parts = append(parts, string(runes[:size]))
+		runes = runes[size:]
+	}
+
+	return append(parts, string(runes))
+}
+
+// checkAzure analyzes the text of the messages with Azure AI Content Safety
+// and returns the categories at or above the severity of the guardrail.
+func (c *Client) checkAzure(ctx context.Context, g *Guardrail, messages []*prompt.Message) ([]string, error) {
+	contents := []string{}
+	for _, m := range messages {
+		contents = append(contents, m.Content)
+	}
+
+	endpoint := strings.TrimSuffix(g.Url, "/") + "/contentsafety/text:analyze?api-version=2023-10-01"
+	found := map[string]bool{}
+	categories := []string{}
+
+	for _, part := range splitText(strings.Join(contents, "\n"), azureMaxTextLength) {
+		payload := map[string]any{
+			"text": part,
+		}
+
+		if len(g.Categories) != 0 {
+			payload["categories"] = g.Categories
+		}
+
+		res := &azureResponse{}
+		err := c.post(ctx, endpoint, map[string]string{
+			"Ocp-Apim-Subscription-Key": g.ApiKey,
+		}, payload, res)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, a := range res.CategoriesAnalysis {
+			if a.Severity >= g.Severity && !found[a.Category] {
+				found[a.Category] = true
+				categories = append(categories, a.Category)
+			}
+		}
+	}
+
+	return categories, nil
+}
@@ -0,0 +1,146 @@
+package guardrail
+
+import (
+	"net/url"
+	"time"
+)
+
+const (
+	ProviderLakera             = "lakera"
+	ProviderAzureContentSafety = "azure_content_safety"
+)
+
+const (
+	FailModeOpen   = "open"
+	FailModeClosed = "closed"
+)
+
+const (
+	defaultLatencyBudget = "1s"
+	maxLatencyBudget     = 30 * time.Second
+	defaultLakeraUrl     = "https://api.lakera.ai/v2/guard"
+	defaultAzureSeverity = 4
+	maxAzureSeverity     = 7
+)
+
+var azureCategories = map[string]bool{
+	"Hate":     true,
+	"SelfHarm": true,
+	"Sexual":   true,
+	"Violence": true,
+}
+
+// Guardrail checks content with an external moderation service. Requests are
+// blocked when the service flags them. When the service fails or does not
+// answer within LatencyBudget, fail open guardrails let the content through
+// while fail closed ones block it.
+type Guardrail struct {
+	Name          string   `json:"name"`
+	Provider      string   `json:"provider"`
+	Url           string   `json:"url,omitempty"`
+	ApiKey        string   `json:"apiKey,omitempty"`
+	Categories    []string `json:"categories,omitempty"`
+	Severity      int      `json:"severity,omitempty"`
+	LatencyBudget string   `json:"latencyBudget,omitempty"`
+	FailMode      string   `json:"failMode,omitempty"`
+}
+
+// Validate returns the invalid fields of the guardrail.
+func (g *Guardrail) Validate() []string {
+	invalid := []string{}
+
+	if len(g.Name) == 0 {
+		invalid = append(invalid, "name")
+	}
+
+	if len(g.ApiKey) == 0 {
+		invalid = append(invalid, "apiKey")
+	}
+
+	if len(g.Url) != 0 {
+		u, err := url.Parse(g.Url)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || len(u.Host) == 0 {
+			invalid = append(invalid, "url")
+		}
+	}
+
+	switch g.Provider {
+	case ProviderLakera:
+		if len(g.Categories) != 0 {
+			invalid = append(invalid, "categories")
+		}
+	case ProviderAzureContentSafety:
+		if len(g.Url) == 0 {
+			invalid = append(invalid, "url")
+		}
+
+		for _, c := range g.Categories {
+			if !azureCategories[c] {
+				invalid = append(invalid, "categories")
+				break
+			}
+		}
+
+		if g.Severity < 0 || g.Severity > maxAzureSeverity {
+			invalid = append(invalid, "severity")
+		}
+	default:
+		invalid = append(invalid, "provider")
+	}
+
+	if len(g.LatencyBudget) != 0 {
+		parsed, err := time.ParseDuration(g.LatencyBudget)
+		if err != nil || parsed <= 0 || parsed > maxLatencyBudget {
+			invalid = append(invalid, "latencyBudget")
+		}
+	}
+
+	if len(g.FailMode) != 0 && g.FailMode != FailModeOpen && g.FailMode != FailModeClosed {
+		invalid = append(invalid, "failMode")
+	}
+
+	return invalid
+}
+
+func (g *Guardrail) SetDefaults() {
+	if len(g.LatencyBudget) == 0 {
+		g.LatencyBudget = defaultLatencyBudget
+	}
+
+	if len(g.FailMode) == 0 {
+		g.FailMode = FailModeOpen
+	}
+
+	if g.Provider == ProviderLakera && len(g.Url) == 0 {
+		g.Url = defaultLakeraUrl
+	}
+
+	if g.Provider == ProviderAzureContentSafety && g.Severity == 0 {
+		g.Severity = defaultAzureSeverity
+	}
+}
+
+// Redacted hides the api key of the service.
+func (g *Guardrail) Redacted() *Guardrail {
+	if g == nil {
+		return nil
+	}
+
+	copied := *g
+	copied.ApiKey = ""
+
+	return &copied
+}
+
+func (g *Guardrail) GetLatencyBudget() time.Duration {
+	parsed, err := time.ParseDuration(g.LatencyBudget)
+	if err != nil {
+		parsed, _ = time.ParseDuration(defaultLatencyBudget)
+	}
+
+	return parsed
+}
+
+func (g *Guardrail) FailsOpen() bool {
+	return g.FailMode != FailModeClosed
+}
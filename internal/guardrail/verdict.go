@@ -0,0 +1,32 @@
+package guardrail
+
+const (
+	StageInput  = "input"
+	StageOutput = "output"
+	StageStream = "stream"
+)
+
+// Verdict is the outcome of a guardrail in the same shape for every
+// service. Error is set when the service could not be reached, in which
+// case Blocked follows the fail mode of the guardrail.
+type Verdict struct {
+	Guardrail   string   `json:"guardrail"`
+	Provider    string   `json:"provider"`
+	Stage       string   `json:"stage"`
+	Flagged     bool     `json:"flagged"`
+	Blocked     bool     `json:"blocked"`
+	Categories  []string `json:"categories,omitempty"`
+	LatencyInMs int      `json:"latencyInMs"`
+	Error       string   `json:"error,omitempty"`
+}
+
+// Blocking returns the first verdict that blocks the content.
+func Blocking(verdicts []*Verdict) *Verdict {
+	for _, v := range verdicts {
+		if v.Blocked {
+			return v
+		}
+	}
+
+	return nil
+}
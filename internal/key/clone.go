@@ -0,0 +1,111 @@
+package key
+
+import (
+	"fmt"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+// Clone copies the configuration of a key into a tenant, e.g. to promote it
+// from a staging tenant to a production one. SettingIds maps the provider
+// settings referenced by the key to their counterparts in the tenant.
+// Settings without a mapping are kept and must belong to the tenant.
+type Clone struct {
+	TenantId   string            `json:"tenantId"`
+	KeyId      string            `json:"keyId,omitempty"`
+	Key        string            `json:"key"`
+	Name       string            `json:"name,omitempty"`
+	SettingIds map[string]string `json:"settingIds,omitempty"`
+}
+
+func (cl *Clone) Validate() error {
+	invalid := []string{}
+
+	if len(cl.Key) == 0 {
+		invalid = append(invalid, "key")
+	}
+
+	for from, to := range cl.SettingIds {
+		if len(from) == 0 || len(to) == 0 {
+			invalid = append(invalid, "settingIds")
+			break
+		}
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
+
+func (cl *Clone) settingId(id string) string {
+	if mapped, ok := cl.SettingIds[id]; ok {
+		return mapped
+	}
+
+	return id
+}
+
+// Apply returns the request creating the clone of rk.
+func (cl *Clone) Apply(rk *ResponseKey) *RequestKey {
+	name := rk.Name
+	if len(cl.Name) != 0 {
+		name = cl.Name
+	}
+
+	settingId := ""
+	if len(rk.SettingId) != 0 {
+		settingId = cl.settingId(rk.SettingId)
+	}
+
+	settingIds := []string{}
+	for _, id := range rk.SettingIds {
+		settingIds = append(settingIds, cl.settingId(id))
+	}
+
+	r := rk.Request()
+	r.Name = name
+	r.KeyId = cl.KeyId
+	r.Key = cl.Key
+	r.SettingId = settingId
+	r.SettingIds = settingIds
+	r.TenantId = cl.TenantId
+
+	return r
+}
+
+// Request returns the request creating rk again with its hashed secret.
+func (rk *ResponseKey) Request() *RequestKey {
+	return &RequestKey{
+		Name:                   rk.Name,
+		CreatedAt:              rk.CreatedAt,
+		UpdatedAt:              rk.UpdatedAt,
+		Tags:                   rk.Tags,
+		KeyId:                  rk.KeyId,
+		Key:                    rk.Key,
+		CostLimitInUsd:         rk.CostLimitInUsd,
+		CostLimitInUsdOverTime: rk.CostLimitInUsdOverTime,
+		CostLimitInUsdUnit:     rk.CostLimitInUsdUnit,
+		RateLimitOverTime:      rk.RateLimitOverTime,
+		RateLimitUnit:          rk.RateLimitUnit,
+		Ttl:                    rk.Ttl,
+		SettingId:              rk.SettingId,
+		AllowedPaths:           rk.AllowedPaths,
+		SettingIds:             rk.SettingIds,
+		SystemPrompt:           rk.SystemPrompt,
+		TenantId:               rk.TenantId,
+		OutputCaps:             rk.OutputCaps,
+		SessionLimits:          rk.SessionLimits,
+		LoopProtection:         rk.LoopProtection,
+		ModelPolicy:            rk.ModelPolicy,
+		Schedule:               rk.Schedule,
+		AllowedRegions:         rk.AllowedRegions,
+		RecordingRetention:     rk.RecordingRetention,
+		FeatureFlags:           rk.FeatureFlags,
+		Signing:                rk.Signing,
+		Parameters:             rk.Parameters,
+		StreamGuardrails:       rk.StreamGuardrails,
+	}
+}
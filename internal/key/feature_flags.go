@@ -0,0 +1,38 @@
+package key
+
+import "fmt"
+
+// Features that can be turned on or off per key. A flag that is not set on a
+// key falls back to the global configuration of the feature.
+const (
+	FeatureRecordRequests = "recordRequests"
+	FeatureSampling       = "sampling"
+	FeatureTracing        = "tracing"
+)
+
+var features = map[string]bool{
+	FeatureRecordRequests: true,
+	FeatureSampling:       true,
+	FeatureTracing:        true,
+}
+
+func validateFeatureFlags(field string, flags map[string]bool) []string {
+	invalid := []string{}
+	for name := range flags {
+		if !features[name] {
+			invalid = append(invalid, fmt.Sprintf("%s.%s", field, name))
+		}
+	}
+
+	return invalid
+}
+
+// IsFeatureEnabled returns the flag of the key for a feature, or the global
+// default when the key does not set it.
+func (rk *ResponseKey) IsFeatureEnabled(feature string, fallback bool) bool {
+	if enabled, ok := rk.FeatureFlags[feature]; ok {
+		return enabled
+	}
+
+	return fallback
+}
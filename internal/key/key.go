@@ -7,17 +7,40 @@ import (
 	"time"
 
 	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/prompt"
 )
 
 type UpdateKey struct {
-	Name          string        `json:"name"`
-	UpdatedAt     int64         `json:"updatedAt"`
-	Tags          []string      `json:"tags"`
-	Revoked       *bool         `json:"revoked"`
-	RevokedReason string        `json:"revokedReason"`
-	SettingId     string        `json:"settingId"`
-	SettingIds    []string      `json:"settingIds"`
-	AllowedPaths  *[]PathConfig `json:"allowedPaths,omitempty"`
+	Name           string               `json:"name"`
+	UpdatedAt      int64                `json:"updatedAt"`
+	Tags           []string             `json:"tags"`
+	Revoked        *bool                `json:"revoked"`
+	RevokedReason  string               `json:"revokedReason"`
+	SettingId      string               `json:"settingId"`
+	SettingIds     []string             `json:"settingIds"`
+	AllowedPaths   *[]PathConfig        `json:"allowedPaths,omitempty"`
+	SystemPrompt   *prompt.SystemPrompt `json:"systemPrompt,omitempty"`
+	OutputCaps     *OutputCaps          `json:"outputCaps,omitempty"`
+	SessionLimits  *SessionLimits       `json:"sessionLimits,omitempty"`
+	LoopProtection *LoopProtection      `json:"loopProtection,omitempty"`
+	ModelPolicy    *ModelPolicy         `json:"modelPolicy,omitempty"`
+	Schedule       *Schedule            `json:"schedule,omitempty"`
+	// Key is the hash of a rotated secret.
+	Key                    string    `json:"-"`
+	PersonalCostLimitInUsd *float64  `json:"personalCostLimitInUsd,omitempty"`
+	AllowedRegions         *[]string `json:"allowedRegions,omitempty"`
+	RecordingRetention     *string   `json:"recordingRetention,omitempty"`
+	// FeatureFlags replaces the flags of the key. An empty map removes them.
+	FeatureFlags map[string]bool `json:"featureFlags,omitempty"`
+	// Signing replaces the signing secret of the key. An empty secret turns
+	// signing off.
+	Signing *Signing `json:"signing,omitempty"`
+	// Parameters replaces the default and overridden parameters of the key.
+	// An empty object removes them.
+	Parameters *Parameters `json:"parameters,omitempty"`
+	// StreamGuardrails replaces the guardrails of streamed chat completions.
+	// An empty object removes them.
+	StreamGuardrails *StreamGuardrails `json:"streamGuardrails,omitempty"`
 }
 
 func (uk *UpdateKey) Validate() error {
@@ -60,6 +83,58 @@ func (uk *UpdateKey) Validate() error {
 		}
 	}
 
+	// a system prompt without content removes the existing one.
+	if uk.SystemPrompt != nil && len(uk.SystemPrompt.Content) != 0 {
+		invalid = append(invalid, uk.SystemPrompt.Validate("systemPrompt")...)
+	}
+
+	if uk.OutputCaps != nil {
+		invalid = append(invalid, uk.OutputCaps.Validate("outputCaps")...)
+	}
+
+	if uk.SessionLimits != nil {
+		invalid = append(invalid, uk.SessionLimits.Validate("sessionLimits")...)
+	}
+
+	if uk.LoopProtection != nil {
+		invalid = append(invalid, uk.LoopProtection.Validate("loopProtection")...)
+	}
+
+	if uk.ModelPolicy != nil {
+		invalid = append(invalid, uk.ModelPolicy.Validate("modelPolicy")...)
+	}
+
+	if uk.Schedule != nil {
+		invalid = append(invalid, uk.Schedule.Validate("schedule")...)
+	}
+
+	if uk.PersonalCostLimitInUsd != nil && *uk.PersonalCostLimitInUsd < 0 {
+		invalid = append(invalid, "personalCostLimitInUsd")
+	}
+
+	if uk.AllowedRegions != nil {
+		invalid = append(invalid, validateRegions("allowedRegions", *uk.AllowedRegions)...)
+	}
+
+	// an empty retention keeps recorded requests until they are deleted.
+	if uk.RecordingRetention != nil && len(*uk.RecordingRetention) != 0 && !validateRecordingRetention(*uk.RecordingRetention) {
+		invalid = append(invalid, "recordingRetention")
+	}
+
+	invalid = append(invalid, validateFeatureFlags("featureFlags", uk.FeatureFlags)...)
+
+	if uk.Signing != nil && len(uk.Signing.Secret) != 0 {
+		invalid = append(invalid, uk.Signing.validate("signing")...)
+	}
+
+	if uk.Parameters != nil {
+		invalid = append(invalid, uk.Parameters.Validate("parameters")...)
+	}
+
+	if !uk.StreamGuardrails.IsEmpty() {
+		invalid = append(invalid, uk.StreamGuardrails.Validate("streamGuardrails")...)
+	}
+
 	if len(invalid) > 0 {
 		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
 	}
@@ -73,21 +148,34 @@ type PathConfig struct {
 }
 
 type RequestKey struct {
-	Name                   string       `json:"name"`
-	CreatedAt              int64        `json:"createdAt"`
-	UpdatedAt              int64        `json:"updatedAt"`
-	Tags                   []string     `json:"tags"`
-	KeyId                  string       `json:"keyId"`
-	Key                    string       `json:"key"`
-	CostLimitInUsd         float64      `json:"costLimitInUsd"`
-	CostLimitInUsdOverTime float64      `json:"costLimitInUsdOverTime"`
-	CostLimitInUsdUnit     TimeUnit     `json:"costLimitInUsdUnit"`
-	RateLimitOverTime      int          `json:"rateLimitOverTime"`
-	RateLimitUnit          TimeUnit     `json:"rateLimitUnit"`
-	Ttl                    string       `json:"ttl"`
-	SettingId              string       `json:"settingId"`
-	AllowedPaths           []PathConfig `json:"allowedPaths"`
-	SettingIds             []string     `json:"settingIds"`
+	Name                   string               `json:"name"`
+	CreatedAt              int64                `json:"createdAt"`
+	UpdatedAt              int64                `json:"updatedAt"`
+	Tags                   []string             `json:"tags"`
+	KeyId                  string               `json:"keyId"`
+	Key                    string               `json:"key"`
+	CostLimitInUsd         float64              `json:"costLimitInUsd"`
+	CostLimitInUsdOverTime float64              `json:"costLimitInUsdOverTime"`
+	CostLimitInUsdUnit     TimeUnit             `json:"costLimitInUsdUnit"`
+	RateLimitOverTime      int                  `json:"rateLimitOverTime"`
+	RateLimitUnit          TimeUnit             `json:"rateLimitUnit"`
+	Ttl                    string               `json:"ttl"`
+	SettingId              string               `json:"settingId"`
+	AllowedPaths           []PathConfig         `json:"allowedPaths"`
+	SettingIds             []string             `json:"settingIds"`
+	SystemPrompt           *prompt.SystemPrompt `json:"systemPrompt,omitempty"`
+	TenantId               string               `json:"tenantId"`
+	OutputCaps             *OutputCaps          `json:"outputCaps,omitempty"`
+	SessionLimits          *SessionLimits       `json:"sessionLimits,omitempty"`
+	LoopProtection         *LoopProtection      `json:"loopProtection,omitempty"`
+	ModelPolicy            *ModelPolicy         `json:"modelPolicy,omitempty"`
+	Schedule               *Schedule            `json:"schedule,omitempty"`
+	AllowedRegions         []string             `json:"allowedRegions,omitempty"`
+	RecordingRetention     string               `json:"recordingRetention,omitempty"`
+	FeatureFlags           map[string]bool      `json:"featureFlags,omitempty"`
+	Signing                *Signing             `json:"signing,omitempty"`
+	Parameters             *Parameters          `json:"parameters,omitempty"`
+	StreamGuardrails       *StreamGuardrails    `json:"streamGuardrails,omitempty"`
 }
 
 func (rk *RequestKey) Validate() error {
@@ -169,6 +257,50 @@ func (rk *RequestKey) Validate() error {
 		}
 	}
 
+	if rk.SystemPrompt != nil {
+		invalid = append(invalid, rk.SystemPrompt.Validate("systemPrompt")...)
+	}
+
+	if rk.OutputCaps != nil {
+		invalid = append(invalid, rk.OutputCaps.Validate("outputCaps")...)
+	}
+
+	if rk.SessionLimits != nil {
+		invalid = append(invalid, rk.SessionLimits.Validate("sessionLimits")...)
+	}
+
+	if rk.LoopProtection != nil {
+		invalid = append(invalid, rk.LoopProtection.Validate("loopProtection")...)
+	}
+
+	if rk.ModelPolicy != nil {
+		invalid = append(invalid, rk.ModelPolicy.Validate("modelPolicy")...)
+	}
+
+	if rk.Schedule != nil {
+		invalid = append(invalid, rk.Schedule.Validate("schedule")...)
+	}
+
+	invalid = append(invalid, validateRegions("allowedRegions", rk.AllowedRegions)...)
+
+	if len(rk.RecordingRetention) != 0 && !validateRecordingRetention(rk.RecordingRetention) {
+		invalid = append(invalid, "recordingRetention")
+	}
+
+	invalid = append(invalid, validateFeatureFlags("featureFlags", rk.FeatureFlags)...)
+
+	if rk.Signing != nil {
+		invalid = append(invalid, rk.Signing.validate("signing")...)
+	}
+
+	if rk.Parameters != nil {
+		invalid = append(invalid, rk.Parameters.Validate("parameters")...)
+	}
+
+	if rk.StreamGuardrails != nil {
+		invalid = append(invalid, rk.StreamGuardrails.Validate("streamGuardrails")...)
+	}
+
 	if len(invalid) > 0 {
 		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
 	}
@@ -215,23 +347,49 @@ const (
 )
 
 type ResponseKey struct {
-	Name                   string       `json:"name"`
-	CreatedAt              int64        `json:"createdAt"`
-	UpdatedAt              int64        `json:"updatedAt"`
-	Tags                   []string     `json:"tags"`
-	KeyId                  string       `json:"keyId"`
-	Revoked                bool         `json:"revoked"`
-	Key                    string       `json:"key"`
-	RevokedReason          string       `json:"revokedReason"`
-	CostLimitInUsd         float64      `json:"costLimitInUsd"`
-	CostLimitInUsdOverTime float64      `json:"costLimitInUsdOverTime"`
-	CostLimitInUsdUnit     TimeUnit     `json:"costLimitInUsdUnit"`
-	RateLimitOverTime      int          `json:"rateLimitOverTime"`
-	RateLimitUnit          TimeUnit     `json:"rateLimitUnit"`
-	Ttl                    string       `json:"ttl"`
-	SettingId              string       `json:"settingId"`
-	AllowedPaths           []PathConfig `json:"allowedPaths"`
-	SettingIds             []string     `json:"settingIds"`
+	Name                   string               `json:"name"`
+	CreatedAt              int64                `json:"createdAt"`
+	UpdatedAt              int64                `json:"updatedAt"`
+	Tags                   []string             `json:"tags"`
+	KeyId                  string               `json:"keyId"`
+	Revoked                bool                 `json:"revoked"`
+	Key                    string               `json:"key"`
+	RevokedReason          string               `json:"revokedReason"`
+	CostLimitInUsd         float64              `json:"costLimitInUsd"`
+	CostLimitInUsdOverTime float64              `json:"costLimitInUsdOverTime"`
+	CostLimitInUsdUnit     TimeUnit             `json:"costLimitInUsdUnit"`
+	RateLimitOverTime      int                  `json:"rateLimitOverTime"`
+	RateLimitUnit          TimeUnit             `json:"rateLimitUnit"`
+	Ttl                    string               `json:"ttl"`
+	SettingId              string               `json:"settingId"`
+	AllowedPaths           []PathConfig         `json:"allowedPaths"`
+	SettingIds             []string             `json:"settingIds"`
+	SystemPrompt           *prompt.SystemPrompt `json:"systemPrompt,omitempty"`
+	TenantId               string               `json:"tenantId"`
+	OutputCaps             *OutputCaps          `json:"outputCaps,omitempty"`
+	SessionLimits          *SessionLimits       `json:"sessionLimits,omitempty"`
+	LoopProtection         *LoopProtection      `json:"loopProtection,omitempty"`
+	ModelPolicy            *ModelPolicy         `json:"modelPolicy,omitempty"`
+	Schedule               *Schedule            `json:"schedule,omitempty"`
+	PersonalCostLimitInUsd float64              `json:"personalCostLimitInUsd,omitempty"`
+	AllowedRegions         []string             `json:"allowedRegions,omitempty"`
+	RecordingRetention     string               `json:"recordingRetention,omitempty"`
+	FeatureFlags           map[string]bool      `json:"featureFlags,omitempty"`
+	Parameters             *Parameters          `json:"parameters,omitempty"`
+	StreamGuardrails       *StreamGuardrails    `json:"streamGuardrails,omitempty"`
+	// Signing is never returned, only whether it is enabled.
+	Signing        *Signing `json:"-"`
+	SigningEnabled bool     `json:"signingEnabled,omitempty"`
+}
+
+// GetCostLimitInUsdOverTime returns the periodic cost limit of the key, which
+// the owner of the key can lower with a personal cost limit.
+func (rk *ResponseKey) GetCostLimitInUsdOverTime() float64 {
+	if rk.PersonalCostLimitInUsd > 0 && rk.PersonalCostLimitInUsd < rk.CostLimitInUsdOverTime {
+		return rk.PersonalCostLimitInUsd
+	}
+
+	return rk.CostLimitInUsdOverTime
 }
 
 func (rk *ResponseKey) GetSettingIds() []string {
@@ -0,0 +1,61 @@
+package key
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	LoopActionThrottle = "throttle"
+	LoopActionBlock    = "block"
+)
+
+// LoopProtection guards against runaway agents. Chat completion requests of
+// a key and session with the same model and last message are counted over
+// Window, and conversations calling the same tool with the same arguments
+// MaxToolCallRepeats times are stopped. Throttled requests can be retried
+// once the window has passed while blocked requests are rejected outright.
+type LoopProtection struct {
+	MaxRepeats         int    `json:"maxRepeats,omitempty"`
+	Window             string `json:"window,omitempty"`
+	MaxToolCallRepeats int    `json:"maxToolCallRepeats,omitempty"`
+	Action             string `json:"action,omitempty"`
+}
+
+func (lp *LoopProtection) IsEmpty() bool {
+	return lp == nil || (lp.MaxRepeats == 0 && lp.MaxToolCallRepeats == 0)
+}
+
+func (lp *LoopProtection) Validate(prefix string) []string {
+	invalid := []string{}
+
+	if lp.MaxRepeats < 0 {
+		invalid = append(invalid, fmt.Sprintf("%s.maxRepeats", prefix))
+	}
+
+	if lp.MaxRepeats > 0 {
+		parsed, err := time.ParseDuration(lp.Window)
+		if err != nil || parsed <= 0 {
+			invalid = append(invalid, fmt.Sprintf("%s.window", prefix))
+		}
+	}
+
+	if lp.MaxToolCallRepeats < 0 {
+		invalid = append(invalid, fmt.Sprintf("%s.maxToolCallRepeats", prefix))
+	}
+
+	if len(lp.Action) != 0 && lp.Action != LoopActionThrottle && lp.Action != LoopActionBlock {
+		invalid = append(invalid, fmt.Sprintf("%s.action", prefix))
+	}
+
+	return invalid
+}
+
+func (lp *LoopProtection) GetWindow() time.Duration {
+	parsed, err := time.ParseDuration(lp.Window)
+	if err != nil {
+		return 0
+	}
+
+	return parsed
+}
@@ -0,0 +1,117 @@
+package key
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const familyPrefix = "family:"
+
+var snapshotSuffix = regexp.MustCompile(`^(\d{4}|\d{8}|\d{4}-\d{2}-\d{2}|latest|preview)$`)
+
+// ModelPolicy restricts the models a key can use. Patterns are either exact
+// model names, wildcards such as gpt-4o-* or families such as family:gpt-4o
+// which match a model and its dated snapshots. Deny rules take precedence
+// over allow rules and an empty allow list allows every model that is not
+// denied.
+type ModelPolicy struct {
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+}
+
+func (mp *ModelPolicy) IsEmpty() bool {
+	return mp == nil || (len(mp.Allow) == 0 && len(mp.Deny) == 0)
+}
+
+func (mp *ModelPolicy) Validate(prefix string) []string {
+	invalid := []string{}
+
+	for index, pattern := range mp.Allow {
+		if !isValidModelPattern(pattern) {
+			invalid = append(invalid, fmt.Sprintf("%s.allow.[%d]", prefix, index))
+		}
+	}
+
+	for index, pattern := range mp.Deny {
+		if !isValidModelPattern(pattern) {
+			invalid = append(invalid, fmt.Sprintf("%s.deny.[%d]", prefix, index))
+		}
+	}
+
+	return invalid
+}
+
+func isValidModelPattern(pattern string) bool {
+	name := strings.TrimPrefix(pattern, familyPrefix)
+	if len(name) == 0 || strings.ContainsAny(name, " \t\n") {
+		return false
+	}
+
+	return !strings.HasPrefix(pattern, familyPrefix) || !strings.Contains(name, "*")
+}
+
+// IsAllowed reports whether a model can be used. A nil policy allows every
+// model.
+func (mp *ModelPolicy) IsAllowed(model string) bool {
+	if mp.IsEmpty() || len(model) == 0 {
+		return true
+	}
+
+	for _, pattern := range mp.Deny {
+		if MatchModel(pattern, model) {
+			return false
+		}
+	}
+
+	if len(mp.Allow) == 0 {
+		return true
+	}
+
+	for _, pattern := range mp.Allow {
+		if MatchModel(pattern, model) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchModel matches a model against an exact name, a wildcard or a family
+// pattern.
+func MatchModel(pattern, model string) bool {
+	if strings.HasPrefix(pattern, familyPrefix) {
+		family := strings.TrimPrefix(pattern, familyPrefix)
+		if model == family {
+			return true
+		}
+
+		return strings.HasPrefix(model, family+"-") && snapshotSuffix.MatchString(strings.TrimPrefix(model, family+"-"))
+	}
+
+	if !strings.Contains(pattern, "*") {
+		return pattern == model
+	}
+
+	return matchWildcard(pattern, model)
+}
+
+func matchWildcard(pattern, model string) bool {
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(model, parts[0]) {
+		return false
+	}
+
+	rest := model[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+
+		rest = rest[i+len(part):]
+	}
+
+	return len(rest) >= len(last) && strings.HasSuffix(rest, last)
+}
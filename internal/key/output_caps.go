@@ -0,0 +1,29 @@
+package key
+
+import "fmt"
+
+// OutputCaps limit how much a key can generate per chat completion request.
+// MaxTokens lowers the max_tokens of requests asking for more, while
+// MaxStreamedTokens stops streams once as many content chunks were sent.
+type OutputCaps struct {
+	MaxTokens         int `json:"maxTokens,omitempty"`
+	MaxStreamedTokens int `json:"maxStreamedTokens,omitempty"`
+}
+
+func (oc *OutputCaps) IsEmpty() bool {
+	return oc == nil || (oc.MaxTokens == 0 && oc.MaxStreamedTokens == 0)
+}
+
+func (oc *OutputCaps) Validate(prefix string) []string {
+	invalid := []string{}
+
+	if oc.MaxTokens < 0 {
+		invalid = append(invalid, fmt.Sprintf("%s.maxTokens", prefix))
+	}
+
+	if oc.MaxStreamedTokens < 0 {
+		invalid = append(invalid, fmt.Sprintf("%s.maxStreamedTokens", prefix))
+	}
+
+	return invalid
+}
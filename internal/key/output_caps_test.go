@@ -0,0 +1,34 @@
+package key
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutputCaps_Validate(t *testing.T) {
+	cases := []struct {
+		name     string
+		oc       *OutputCaps
+		expected []string
+	}{
+		{name: "valid", oc: &OutputCaps{MaxTokens: 100, MaxStreamedTokens: 50}, expected: []string{}},
+		{name: "empty caps", oc: &OutputCaps{}, expected: []string{}},
+		{name: "negative max tokens", oc: &OutputCaps{MaxTokens: -1}, expected: []string{"outputCaps.maxTokens"}},
+		{name: "negative max streamed tokens", oc: &OutputCaps{MaxStreamedTokens: -1}, expected: []string{"outputCaps.maxStreamedTokens"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.oc.Validate("outputCaps"))
+		})
+	}
+}
+
+func TestOutputCaps_IsEmpty(t *testing.T) {
+	var missing *OutputCaps
+
+	assert.True(t, missing.IsEmpty())
+	assert.True(t, (&OutputCaps{}).IsEmpty())
+	assert.False(t, (&OutputCaps{MaxStreamedTokens: 1}).IsEmpty())
+}
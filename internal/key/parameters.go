@@ -0,0 +1,190 @@
+package key
+
+import (
+	"encoding/json"
+	"fmt"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+const maxStopSequences = 4
+
+// ParameterValues are chat completion parameters set by the proxy.
+type ParameterValues struct {
+	Temperature  *float64 `json:"temperature,omitempty"`
+	MaxTokens    *int     `json:"maxTokens,omitempty"`
+	Stop         []string `json:"stop,omitempty"`
+	SystemPrompt string   `json:"systemPrompt,omitempty"`
+}
+
+func (pv *ParameterValues) validate(prefix string) []string {
+	invalid := []string{}
+
+	if pv.Temperature != nil && (*pv.Temperature < 0 || *pv.Temperature > 2) {
+		invalid = append(invalid, prefix+".temperature")
+	}
+
+	if pv.MaxTokens != nil && *pv.MaxTokens <= 0 {
+		invalid = append(invalid, prefix+".maxTokens")
+	}
+
+	if len(pv.Stop) > maxStopSequences {
+		invalid = append(invalid, prefix+".stop")
+	}
+
+	for index, stop := range pv.Stop {
+		if len(stop) == 0 {
+			invalid = append(invalid, fmt.Sprintf("%s.stop.[%d]", prefix, index))
+		}
+	}
+
+	return invalid
+}
+
+// Parameters are applied to the chat completion requests of a key. Defaults
+// fill the parameters a request omits, while overrides replace the ones it
+// sends. A system prompt override replaces every system message.
+type Parameters struct {
+	Defaults  *ParameterValues `json:"defaults,omitempty"`
+	Overrides *ParameterValues `json:"overrides,omitempty"`
+}
+
+func (p *Parameters) IsEmpty() bool {
+	return p == nil || (p.Defaults == nil && p.Overrides == nil)
+}
+
+func (p *Parameters) Validate(prefix string) []string {
+	invalid := []string{}
+
+	if p.Defaults != nil {
+		invalid = append(invalid, p.Defaults.validate(prefix+".defaults")...)
+	}
+
+	if p.Overrides != nil {
+		invalid = append(invalid, p.Overrides.validate(prefix+".overrides")...)
+	}
+
+	return invalid
+}
+
+// Apply returns the chat completion request body with the defaults and
+// overrides applied.
+func (p *Parameters) Apply(body []byte) ([]byte, error) {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, internal_errors.NewValidationError("request body must be a json object")
+	}
+
+	if p.Defaults != nil {
+		if err := p.Defaults.apply(fields, false); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.Overrides != nil {
+		if err := p.Overrides.apply(fields, true); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(fields)
+}
+
+func (pv *ParameterValues) apply(fields map[string]json.RawMessage, override bool) error {
+	if pv.Temperature != nil {
+		if err := setParameter(fields, "temperature", *pv.Temperature, override); err != nil {
+			return err
+		}
+	}
+
+	if pv.MaxTokens != nil {
+		// reasoning models only accept max_completion_tokens.
+		field := "max_tokens"
+		if isSet(fields, "max_completion_tokens") {
+			field = "max_completion_tokens"
+		}
+
+		if err := setParameter(fields, field, *pv.MaxTokens, override); err != nil {
+			return err
+		}
+	}
+
+	if len(pv.Stop) != 0 {
+		if err := setParameter(fields, "stop", pv.Stop, override); err != nil {
+			return err
+		}
+	}
+
+	if len(pv.SystemPrompt) != 0 {
+		return applySystemPrompt(fields, pv.SystemPrompt, override)
+	}
+
+	return nil
+}
+
+func isSet(fields map[string]json.RawMessage, name string) bool {
+	raw, ok := fields[name]
+	return ok && string(raw) != "null"
+}
+
+func setParameter(fields map[string]json.RawMessage, name string, value any, override bool) error {
+	if !override && isSet(fields, name) {
+		return nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	fields[name] = data
+	return nil
+}
+
+// applySystemPrompt prepends content as a system message. Overrides remove
+// the system messages sent by the client, while defaults are only added to
+// requests without one.
+func applySystemPrompt(fields map[string]json.RawMessage, content string, override bool) error {
+	messages := []json.RawMessage{}
+	if isSet(fields, "messages") {
+		if err := json.Unmarshal(fields["messages"], &messages); err != nil {
+			return internal_errors.NewValidationError("messages must be an array")
+		}
+	}
+
+	kept := []json.RawMessage{}
+	for _, raw := range messages {
+		message := struct {
+			Role string `json:"role"`
+		}{}
+
+		if err := json.Unmarshal(raw, &message); err != nil {
+			return internal_errors.NewValidationError("messages must be objects")
+		}
+
+		if message.Role != "system" {
+			kept = append(kept, raw)
+			continue
+		}
+
+		if !override {
+			return nil
+		}
+	}
+
+	system, err := json.Marshal(map[string]string{
+		"role":    "system",
+		"content": content,
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(append([]json.RawMessage{system}, kept...))
+	if err != nil {
+		return err
+	}
+
+	fields["messages"] = data
+	return nil
+}
@@ -0,0 +1,23 @@
+package key
+
+import "time"
+
+// GetRecordingRetention returns how long requests recorded for the key are
+// kept. Zero keeps them until they are deleted.
+func (rk *ResponseKey) GetRecordingRetention() time.Duration {
+	if len(rk.RecordingRetention) == 0 {
+		return 0
+	}
+
+	d, err := time.ParseDuration(rk.RecordingRetention)
+	if err != nil {
+		return 0
+	}
+
+	return d
+}
+
+func validateRecordingRetention(retention string) bool {
+	d, err := time.ParseDuration(retention)
+	return err == nil && d > 0
+}
@@ -0,0 +1,38 @@
+package key
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/util"
+)
+
+func validateRegions(prefix string, regions []string) []string {
+	invalid := []string{}
+
+	for index, region := range regions {
+		if !util.IsValidId(region) {
+			invalid = append(invalid, fmt.Sprintf("%s.[%d]", prefix, index))
+		}
+	}
+
+	return invalid
+}
+
+// AllowsRegion reports whether provider settings in the region satisfy the
+// residency requirement of the key. Keys without allowed regions can use
+// settings in any region, while keys with allowed regions cannot use
+// settings without a region.
+func (rk *ResponseKey) AllowsRegion(region string) bool {
+	if len(rk.AllowedRegions) == 0 {
+		return true
+	}
+
+	for _, allowed := range rk.AllowedRegions {
+		if strings.EqualFold(allowed, region) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,158 @@
+package key
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	// the alpine images do not ship a timezone database.
+	_ "time/tzdata"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+var locations sync.Map
+
+func loadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// ActiveWindow is a daily time range in the format of 15:04. A window ending
+// before it starts runs past midnight and belongs to the day it starts on.
+// An empty list of days means every day.
+type ActiveWindow struct {
+	Days  []string `json:"days,omitempty"`
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+}
+
+// Schedule restricts a key to the windows it is active in.
+type Schedule struct {
+	Timezone string          `json:"timezone,omitempty"`
+	Windows  []*ActiveWindow `json:"windows"`
+}
+
+func (s *Schedule) IsEmpty() bool {
+	return s == nil || len(s.Windows) == 0
+}
+
+func parseClock(clock string) (int, bool) {
+	parsed, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, false
+	}
+
+	return parsed.Hour()*60 + parsed.Minute(), true
+}
+
+func (s *Schedule) Validate(prefix string) []string {
+	invalid := []string{}
+
+	if len(s.Timezone) != 0 {
+		if _, err := loadLocation(s.Timezone); err != nil {
+			invalid = append(invalid, fmt.Sprintf("%s.timezone", prefix))
+		}
+	}
+
+	for index, w := range s.Windows {
+		if w == nil {
+			invalid = append(invalid, fmt.Sprintf("%s.windows.[%d]", prefix, index))
+			continue
+		}
+
+		for _, day := range w.Days {
+			if _, ok := weekdays[day]; !ok {
+				invalid = append(invalid, fmt.Sprintf("%s.windows.[%d].days", prefix, index))
+				break
+			}
+		}
+
+		start, ok := parseClock(w.Start)
+		if !ok {
+			invalid = append(invalid, fmt.Sprintf("%s.windows.[%d].start", prefix, index))
+		}
+
+		end, ok := parseClock(w.End)
+		if !ok || end == start {
+			invalid = append(invalid, fmt.Sprintf("%s.windows.[%d].end", prefix, index))
+		}
+	}
+
+	return invalid
+}
+
+// IsActive reports whether t falls into one of the windows. Keys without a
+// schedule are always active.
+func (s *Schedule) IsActive(t time.Time) bool {
+	if s.IsEmpty() {
+		return true
+	}
+
+	loc := time.UTC
+	if len(s.Timezone) != 0 {
+		parsed, err := loadLocation(s.Timezone)
+		if err != nil {
+			return false
+		}
+
+		loc = parsed
+	}
+
+	local := t.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+
+	for _, w := range s.Windows {
+		start, _ := parseClock(w.Start)
+		end, _ := parseClock(w.End)
+
+		if start < end {
+			if minute >= start && minute < end && w.runsOn(local.Weekday()) {
+				return true
+			}
+
+			continue
+		}
+
+		if minute >= start && w.runsOn(local.Weekday()) {
+			return true
+		}
+
+		if minute < end && w.runsOn(local.AddDate(0, 0, -1).Weekday()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w *ActiveWindow) runsOn(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+
+	for _, d := range w.Days {
+		if weekdays[d] == day {
+			return true
+		}
+	}
+
+	return false
+}
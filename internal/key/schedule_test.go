@@ -0,0 +1,86 @@
+package key
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedule_Validate(t *testing.T) {
+	cases := []struct {
+		name     string
+		s        *Schedule
+		expected []string
+	}{
+		{name: "valid", s: &Schedule{Timezone: "Europe/Berlin", Windows: []*ActiveWindow{{Days: []string{"mon", "sun"}, Start: "22:00", End: "06:00"}}}, expected: []string{}},
+		{name: "utc by default", s: &Schedule{Windows: []*ActiveWindow{{Start: "00:00", End: "23:59"}}}, expected: []string{}},
+		{name: "unknown timezone", s: &Schedule{Timezone: "Mars/Olympus"}, expected: []string{"schedule.timezone"}},
+		{name: "missing window", s: &Schedule{Windows: []*ActiveWindow{nil}}, expected: []string{"schedule.windows.[0]"}},
+		{name: "unknown day", s: &Schedule{Windows: []*ActiveWindow{{Days: []string{"mon", "monday"}, Start: "09:00", End: "17:00"}}}, expected: []string{"schedule.windows.[0].days"}},
+		{name: "invalid start", s: &Schedule{Windows: []*ActiveWindow{{Start: "24:00", End: "17:00"}}}, expected: []string{"schedule.windows.[0].start"}},
+		{name: "invalid end", s: &Schedule{Windows: []*ActiveWindow{{Start: "09:00", End: "9am"}}}, expected: []string{"schedule.windows.[0].end"}},
+		{name: "empty window", s: &Schedule{Windows: []*ActiveWindow{{Start: "09:00", End: "09:00"}}}, expected: []string{"schedule.windows.[0].end"}},
+		{name: "index of the invalid window", s: &Schedule{Windows: []*ActiveWindow{{Start: "09:00", End: "17:00"}, {Start: "", End: "17:00"}}}, expected: []string{"schedule.windows.[1].start"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.s.Validate("schedule"))
+		})
+	}
+}
+
+func TestSchedule_IsActive(t *testing.T) {
+	office := []*ActiveWindow{{Start: "09:00", End: "17:00"}}
+	// 2024-01-05 is a friday.
+	overnight := []*ActiveWindow{{Days: []string{"fri"}, Start: "22:00", End: "06:00"}}
+
+	cases := []struct {
+		name     string
+		s        *Schedule
+		at       time.Time
+		expected bool
+	}{
+		{name: "keys without a schedule", s: nil, at: time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC), expected: true},
+		{name: "keys without windows", s: &Schedule{Timezone: "Europe/Berlin"}, at: time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC), expected: true},
+
+		{name: "before the window", s: &Schedule{Windows: office}, at: time.Date(2024, 1, 1, 8, 59, 59, 0, time.UTC), expected: false},
+		{name: "start of the window", s: &Schedule{Windows: office}, at: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), expected: true},
+		{name: "last minute of the window", s: &Schedule{Windows: office}, at: time.Date(2024, 1, 1, 16, 59, 59, 0, time.UTC), expected: true},
+		{name: "end of the window", s: &Schedule{Windows: office}, at: time.Date(2024, 1, 1, 17, 0, 0, 0, time.UTC), expected: false},
+
+		{name: "day of the window", s: &Schedule{Windows: []*ActiveWindow{{Days: []string{"mon", "wed"}, Start: "09:00", End: "17:00"}}}, at: time.Date(2024, 1, 3, 10, 0, 0, 0, time.UTC), expected: true},
+		{name: "other days", s: &Schedule{Windows: []*ActiveWindow{{Days: []string{"mon", "wed"}, Start: "09:00", End: "17:00"}}}, at: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC), expected: false},
+		{name: "any of the windows", s: &Schedule{Windows: []*ActiveWindow{{Start: "06:00", End: "07:00"}, {Start: "18:00", End: "19:00"}}}, at: time.Date(2024, 1, 1, 18, 30, 0, 0, time.UTC), expected: true},
+		{name: "between the windows", s: &Schedule{Windows: []*ActiveWindow{{Start: "06:00", End: "07:00"}, {Start: "18:00", End: "19:00"}}}, at: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), expected: false},
+
+		{name: "overnight window before midnight", s: &Schedule{Windows: overnight}, at: time.Date(2024, 1, 5, 23, 0, 0, 0, time.UTC), expected: true},
+		{name: "overnight window after midnight belongs to the day it starts on", s: &Schedule{Windows: overnight}, at: time.Date(2024, 1, 6, 5, 59, 0, 0, time.UTC), expected: true},
+		{name: "overnight window ends", s: &Schedule{Windows: overnight}, at: time.Date(2024, 1, 6, 6, 0, 0, 0, time.UTC), expected: false},
+		{name: "overnight window of the previous day", s: &Schedule{Windows: overnight}, at: time.Date(2024, 1, 5, 5, 0, 0, 0, time.UTC), expected: false},
+		{name: "overnight window of the next day", s: &Schedule{Windows: overnight}, at: time.Date(2024, 1, 6, 23, 0, 0, 0, time.UTC), expected: false},
+
+		// timezones
+		{name: "wall clock of the timezone", s: &Schedule{Timezone: "Europe/Berlin", Windows: office}, at: time.Date(2024, 1, 1, 8, 30, 0, 0, time.UTC), expected: true},
+		{name: "wall clock of the timezone after the window", s: &Schedule{Timezone: "Europe/Berlin", Windows: office}, at: time.Date(2024, 1, 1, 16, 30, 0, 0, time.UTC), expected: false},
+		{name: "day of the timezone", s: &Schedule{Timezone: "America/Los_Angeles", Windows: []*ActiveWindow{{Days: []string{"sun"}, Start: "18:00", End: "20:00"}}}, at: time.Date(2024, 1, 8, 3, 0, 0, 0, time.UTC), expected: true},
+		{name: "half hour offsets", s: &Schedule{Timezone: "Asia/Kolkata", Windows: office}, at: time.Date(2024, 1, 1, 3, 29, 0, 0, time.UTC), expected: false},
+		{name: "unknown timezones are never active", s: &Schedule{Timezone: "Mars/Olympus", Windows: office}, at: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), expected: false},
+
+		// new york moves from EST to EDT on 2024-03-10 and back on
+		// 2024-11-03, the window follows the wall clock.
+		{name: "window in standard time", s: &Schedule{Timezone: "America/New_York", Windows: office}, at: time.Date(2024, 3, 9, 14, 30, 0, 0, time.UTC), expected: true},
+		{name: "window in daylight saving time", s: &Schedule{Timezone: "America/New_York", Windows: office}, at: time.Date(2024, 3, 10, 13, 30, 0, 0, time.UTC), expected: true},
+		{name: "same utc time before daylight saving time", s: &Schedule{Timezone: "America/New_York", Windows: office}, at: time.Date(2024, 3, 9, 13, 30, 0, 0, time.UTC), expected: false},
+		{name: "first of repeated hours", s: &Schedule{Timezone: "America/New_York", Windows: []*ActiveWindow{{Start: "01:00", End: "02:00"}}}, at: time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC), expected: true},
+		{name: "second of repeated hours", s: &Schedule{Timezone: "America/New_York", Windows: []*ActiveWindow{{Start: "01:00", End: "02:00"}}}, at: time.Date(2024, 11, 3, 6, 30, 0, 0, time.UTC), expected: true},
+		{name: "window in skipped hours", s: &Schedule{Timezone: "America/New_York", Windows: []*ActiveWindow{{Start: "02:00", End: "03:00"}}}, at: time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC), expected: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.s.IsActive(tc.at))
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package key
+
+// Usage is what the owner of a key sees through the self service API.
+type Usage struct {
+	KeyId                  string   `json:"keyId"`
+	Name                   string   `json:"name"`
+	SpendInUsd             float64  `json:"spendInUsd"`
+	CostLimitInUsd         float64  `json:"costLimitInUsd,omitempty"`
+	PeriodSpendInUsd       float64  `json:"periodSpendInUsd,omitempty"`
+	CostLimitInUsdOverTime float64  `json:"costLimitInUsdOverTime,omitempty"`
+	CostLimitInUsdUnit     TimeUnit `json:"costLimitInUsdUnit,omitempty"`
+	PersonalCostLimitInUsd float64  `json:"personalCostLimitInUsd,omitempty"`
+	RateLimitOverTime      int      `json:"rateLimitOverTime,omitempty"`
+	RateLimitUnit          TimeUnit `json:"rateLimitUnit,omitempty"`
+	Ttl                    string   `json:"ttl,omitempty"`
+}
+
+type RotatedKey struct {
+	KeyId string `json:"keyId"`
+	Key   string `json:"key"`
+}
+
+type PersonalCostLimit struct {
+	PersonalCostLimitInUsd float64 `json:"personalCostLimitInUsd"`
+}
@@ -0,0 +1,28 @@
+package key
+
+import "fmt"
+
+// SessionLimits cap the tokens and cost of the requests of a key sharing a
+// session id. Sessions exceeding a limit are rejected until they expire.
+type SessionLimits struct {
+	MaxTokens    int     `json:"maxTokens,omitempty"`
+	MaxCostInUsd float64 `json:"maxCostInUsd,omitempty"`
+}
+
+func (sl *SessionLimits) IsEmpty() bool {
+	return sl == nil || (sl.MaxTokens == 0 && sl.MaxCostInUsd == 0)
+}
+
+func (sl *SessionLimits) Validate(prefix string) []string {
+	invalid := []string{}
+
+	if sl.MaxTokens < 0 {
+		invalid = append(invalid, fmt.Sprintf("%s.maxTokens", prefix))
+	}
+
+	if sl.MaxCostInUsd < 0 {
+		invalid = append(invalid, fmt.Sprintf("%s.maxCostInUsd", prefix))
+	}
+
+	return invalid
+}
@@ -0,0 +1,93 @@
+package key
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+const (
+	minSigningSecretLength  = 32
+	defaultSigningTolerance = 5 * time.Minute
+	maxSigningTolerance     = time.Hour
+)
+
+// Signing requires requests of a key to be signed with a shared secret on
+// top of the bearer key. The signature is the hex encoded HMAC-SHA256 of
+// "<timestamp>.<method>.<uri>.<hex sha256 of the body>", where uri is the
+// path of the request with its query string, and is sent in
+// X-BricksLLM-Signature together with the unix timestamp in
+// X-BricksLLM-Timestamp. Requests whose timestamp is further from now than
+// the tolerance are rejected.
+type Signing struct {
+	Secret    string `json:"secret"`
+	Tolerance string `json:"tolerance,omitempty"`
+}
+
+func (s *Signing) validate(field string) []string {
+	invalid := []string{}
+	if len(s.Secret) < minSigningSecretLength {
+		invalid = append(invalid, field+".secret")
+	}
+
+	if len(s.Tolerance) != 0 {
+		d, err := time.ParseDuration(s.Tolerance)
+		if err != nil || d <= 0 || d > maxSigningTolerance {
+			invalid = append(invalid, field+".tolerance")
+		}
+	}
+
+	return invalid
+}
+
+// GetTolerance returns how far the timestamp of a signed request can be
+// from now.
+func (s *Signing) GetTolerance() time.Duration {
+	d, err := time.ParseDuration(s.Tolerance)
+	if err != nil || d <= 0 {
+		return defaultSigningTolerance
+	}
+
+	return d
+}
+
+// Sign computes the signature of a request.
+func (s *Signing) Sign(timestamp, method, uri string, body []byte) string {
+	sum := sha256.Sum256(body)
+
+	mac := hmac.New(sha256.New, []byte(s.Secret))
+	mac.Write([]byte(timestamp + "." + method + "." + uri + "." + hex.EncodeToString(sum[:])))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the timestamp and the signature of a request.
+func (s *Signing) Verify(timestamp, signature, method, uri string, body []byte, now time.Time) bool {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	drift := now.Sub(time.Unix(ts, 0))
+	if drift < 0 {
+		drift = -drift
+	}
+
+	if drift > s.GetTolerance() {
+		return false
+	}
+
+	expected, err := hex.DecodeString(s.Sign(timestamp, method, uri, body))
+	if err != nil {
+		return false
+	}
+
+	provided, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal(expected, provided)
+}
@@ -0,0 +1,57 @@
+package key
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSigning_Verify(t *testing.T) {
+	s := &Signing{Secret: "0123456789abcdef0123456789abcdef", Tolerance: "5m"}
+	now := time.Unix(1699933571, 0)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	uri := "/api/providers/azure/openai/deployments/gpt-4/chat/completions?api-version=2024-02-01"
+	body := []byte(`{"messages":[{"role":"user","content":"hi"}]}`)
+	signature := s.Sign(timestamp, "POST", uri, body)
+
+	cases := []struct {
+		name      string
+		timestamp string
+		signature string
+		method    string
+		uri       string
+		body      []byte
+		now       time.Time
+		expected  bool
+	}{
+		{name: "valid signature", timestamp: timestamp, signature: signature, method: "POST", uri: uri, body: body, now: now, expected: true},
+		{name: "clock behind within tolerance", timestamp: timestamp, signature: signature, method: "POST", uri: uri, body: body, now: now.Add(5 * time.Minute), expected: true},
+		{name: "clock ahead within tolerance", timestamp: timestamp, signature: signature, method: "POST", uri: uri, body: body, now: now.Add(-5 * time.Minute), expected: true},
+		{name: "stale timestamp", timestamp: timestamp, signature: signature, method: "POST", uri: uri, body: body, now: now.Add(5*time.Minute + time.Second), expected: false},
+		{name: "timestamp from the future", timestamp: timestamp, signature: signature, method: "POST", uri: uri, body: body, now: now.Add(-5*time.Minute - time.Second), expected: false},
+		{name: "timestamp changed", timestamp: strconv.FormatInt(now.Unix()+1, 10), signature: signature, method: "POST", uri: uri, body: body, now: now, expected: false},
+		{name: "malformed timestamp", timestamp: "yesterday", signature: signature, method: "POST", uri: uri, body: body, now: now, expected: false},
+		{name: "body tampered", timestamp: timestamp, signature: signature, method: "POST", uri: uri, body: []byte(`{"messages":[{"role":"user","content":"bye"}]}`), now: now, expected: false},
+		{name: "body dropped", timestamp: timestamp, signature: signature, method: "POST", uri: uri, body: nil, now: now, expected: false},
+		{name: "query tampered", timestamp: timestamp, signature: signature, method: "POST", uri: "/api/providers/azure/openai/deployments/gpt-4/chat/completions?api-version=2023-05-15", body: body, now: now, expected: false},
+		{name: "query dropped", timestamp: timestamp, signature: signature, method: "POST", uri: "/api/providers/azure/openai/deployments/gpt-4/chat/completions", body: body, now: now, expected: false},
+		{name: "path tampered", timestamp: timestamp, signature: signature, method: "POST", uri: "/api/providers/azure/openai/deployments/gpt-4/embeddings?api-version=2024-02-01", body: body, now: now, expected: false},
+		{name: "method tampered", timestamp: timestamp, signature: signature, method: "PUT", uri: uri, body: body, now: now, expected: false},
+		{name: "malformed signature", timestamp: timestamp, signature: "not-hex", method: "POST", uri: uri, body: body, now: now, expected: false},
+		{name: "signature of another secret", timestamp: timestamp, signature: (&Signing{Secret: "fedcba9876543210fedcba9876543210"}).Sign(timestamp, "POST", uri, body), method: "POST", uri: uri, body: body, now: now, expected: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, s.Verify(tc.timestamp, tc.signature, tc.method, tc.uri, tc.body, tc.now))
+		})
+	}
+}
+
+func TestSigning_GetTolerance(t *testing.T) {
+	assert.Equal(t, defaultSigningTolerance, (&Signing{}).GetTolerance())
+	assert.Equal(t, defaultSigningTolerance, (&Signing{Tolerance: "-1m"}).GetTolerance())
+	assert.Equal(t, 30*time.Second, (&Signing{Tolerance: "30s"}).GetTolerance())
+}
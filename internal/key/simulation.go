@@ -0,0 +1,417 @@
+package key
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+const (
+	defaultSimulationStep       = 60
+	maxSimulationRequests       = 1000000
+	maxSimulationTimelinePoints = 10000
+)
+
+// TrafficSegment sends requests evenly spaced at a constant rate.
+type TrafficSegment struct {
+	DurationInSeconds   int     `json:"durationInSeconds"`
+	RequestsPerMinute   float64 `json:"requestsPerMinute"`
+	CostPerRequestInUsd float64 `json:"costPerRequestInUsd,omitempty"`
+}
+
+// SimulationLimits replace the limits of the simulated key, so that limits
+// can be tried out before they are rolled out.
+type SimulationLimits struct {
+	CostLimitInUsd         *float64  `json:"costLimitInUsd,omitempty"`
+	CostLimitInUsdOverTime *float64  `json:"costLimitInUsdOverTime,omitempty"`
+	CostLimitInUsdUnit     *TimeUnit `json:"costLimitInUsdUnit,omitempty"`
+	RateLimitOverTime      *int      `json:"rateLimitOverTime,omitempty"`
+	RateLimitUnit          *TimeUnit `json:"rateLimitUnit,omitempty"`
+}
+
+// Simulation replays a hypothetical traffic pattern against the rate and
+// cost limits of a key. Traffic segments follow each other from Start, and
+// limit counters start unused.
+type Simulation struct {
+	Start         int64             `json:"start,omitempty"`
+	StepInSeconds int               `json:"stepInSeconds,omitempty"`
+	Traffic       []*TrafficSegment `json:"traffic"`
+	Limits        *SimulationLimits `json:"limits,omitempty"`
+}
+
+// SimulationPoint sums the requests of one step of the timeline. Remaining
+// limits are the ones left at the end of the step, and are only set for the
+// limits the key has.
+type SimulationPoint struct {
+	Start                      int64    `json:"start"`
+	Requests                   int      `json:"requests"`
+	Accepted                   int      `json:"accepted"`
+	RateLimited                int      `json:"rateLimited"`
+	CostLimited                int      `json:"costLimited"`
+	Revoked                    int      `json:"revoked"`
+	CostInUsd                  float64  `json:"costInUsd"`
+	RemainingRequests          *int     `json:"remainingRequests,omitempty"`
+	RemainingCostInUsdOverTime *float64 `json:"remainingCostInUsdOverTime,omitempty"`
+	RemainingCostInUsd         *float64 `json:"remainingCostInUsd,omitempty"`
+}
+
+type SimulationReport struct {
+	KeyId                  string             `json:"keyId"`
+	Start                  int64              `json:"start"`
+	End                    int64              `json:"end"`
+	CostLimitInUsd         float64            `json:"costLimitInUsd"`
+	CostLimitInUsdOverTime float64            `json:"costLimitInUsdOverTime"`
+	CostLimitInUsdUnit     TimeUnit           `json:"costLimitInUsdUnit"`
+	RateLimitOverTime      int                `json:"rateLimitOverTime"`
+	RateLimitUnit          TimeUnit           `json:"rateLimitUnit"`
+	Requests               int                `json:"requests"`
+	Accepted               int                `json:"accepted"`
+	RateLimited            int                `json:"rateLimited"`
+	CostLimited            int                `json:"costLimited"`
+	Revoked                int                `json:"revoked"`
+	CostInUsd              float64            `json:"costInUsd"`
+	FirstRateLimitedAt     int64              `json:"firstRateLimitedAt,omitempty"`
+	FirstCostLimitedAt     int64              `json:"firstCostLimitedAt,omitempty"`
+	RevokedAt              int64              `json:"revokedAt,omitempty"`
+	Timeline               []*SimulationPoint `json:"timeline"`
+}
+
+func (s *Simulation) Validate() error {
+	invalid := []string{}
+
+	if len(s.Traffic) == 0 {
+		invalid = append(invalid, "traffic")
+	}
+
+	if s.Start < 0 {
+		invalid = append(invalid, "start")
+	}
+
+	if s.StepInSeconds < 0 {
+		invalid = append(invalid, "stepInSeconds")
+	}
+
+	duration, requests := 0, 0.0
+	for index, segment := range s.Traffic {
+		if segment == nil || segment.DurationInSeconds <= 0 {
+			invalid = append(invalid, fmt.Sprintf("traffic.[%d].durationInSeconds", index))
+			continue
+		}
+
+		if segment.RequestsPerMinute < 0 {
+			invalid = append(invalid, fmt.Sprintf("traffic.[%d].requestsPerMinute", index))
+		}
+
+		if segment.CostPerRequestInUsd < 0 {
+			invalid = append(invalid, fmt.Sprintf("traffic.[%d].costPerRequestInUsd", index))
+		}
+
+		duration += segment.DurationInSeconds
+		requests += segment.RequestsPerMinute * float64(segment.DurationInSeconds) / 60
+	}
+
+	if requests > maxSimulationRequests {
+		invalid = append(invalid, "traffic")
+	}
+
+	if step := s.step(); step > 0 && duration/step > maxSimulationTimelinePoints {
+		invalid = append(invalid, "stepInSeconds")
+	}
+
+	if s.Limits != nil {
+		invalid = append(invalid, s.Limits.validate()...)
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
+
+func (l *SimulationLimits) validate() []string {
+	invalid := []string{}
+
+	if l.CostLimitInUsd != nil && *l.CostLimitInUsd < 0 {
+		invalid = append(invalid, "limits.costLimitInUsd")
+	}
+
+	if l.CostLimitInUsdOverTime != nil && *l.CostLimitInUsdOverTime < 0 {
+		invalid = append(invalid, "limits.costLimitInUsdOverTime")
+	}
+
+	if l.CostLimitInUsdUnit != nil {
+		if unit := *l.CostLimitInUsdUnit; unit != DayTimeUnit && unit != HourTimeUnit && unit != MonthTimeUnit && unit != MinuteTimeUnit {
+			invalid = append(invalid, "limits.costLimitInUsdUnit")
+		}
+	}
+
+	if l.RateLimitOverTime != nil && *l.RateLimitOverTime < 0 {
+		invalid = append(invalid, "limits.rateLimitOverTime")
+	}
+
+	if l.RateLimitUnit != nil {
+		if unit := *l.RateLimitUnit; unit != HourTimeUnit && unit != MinuteTimeUnit && unit != SecondTimeUnit && unit != DayTimeUnit {
+			invalid = append(invalid, "limits.rateLimitUnit")
+		}
+	}
+
+	return invalid
+}
+
+func (s *Simulation) step() int {
+	if s.StepInSeconds == 0 {
+		return defaultSimulationStep
+	}
+
+	return s.StepInSeconds
+}
+
+// windowEnd returns the end of the limit window of unit containing t. Limit
+// counters are kept per UTC aligned window, the same way as in the cache.
+func windowEnd(unit TimeUnit, t time.Time) time.Time {
+	t = t.UTC()
+
+	switch unit {
+	case SecondTimeUnit:
+		return t.Truncate(time.Second).Add(time.Second)
+	case MinuteTimeUnit:
+		return t.Truncate(time.Minute).Add(time.Minute)
+	case HourTimeUnit:
+		return t.Truncate(time.Hour).Add(time.Hour)
+	case DayTimeUnit:
+		return t.Truncate(24 * time.Hour).Add(24 * time.Hour)
+	case MonthTimeUnit:
+		return time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	return t
+}
+
+func toMicroDollars(dollar float64) int64 {
+	return int64(dollar * 1000000)
+}
+
+// limiter mirrors how the proxy enforces the limits of a key. Spend and
+// requests are counted after a request succeeded, and once a counter reaches
+// its limit the key is blocked until the end of the window. A key reaching
+// its total cost limit is revoked.
+type limiter struct {
+	report           *SimulationReport
+	rateCount        int
+	rateWindowEnd    time.Time
+	rateBlockedUntil time.Time
+	cost             float64
+	costWindowEnd    time.Time
+	costBlockedUntil time.Time
+	total            float64
+	revoked          bool
+}
+
+func (l *limiter) roll(t time.Time) {
+	r := l.report
+
+	if r.RateLimitOverTime > 0 && !t.Before(l.rateWindowEnd) {
+		l.rateCount = 0
+		l.rateWindowEnd = windowEnd(r.RateLimitUnit, t)
+	}
+
+	if r.CostLimitInUsdOverTime > 0 && !t.Before(l.costWindowEnd) {
+		l.cost = 0
+		l.costWindowEnd = windowEnd(r.CostLimitInUsdUnit, t)
+	}
+}
+
+func (l *limiter) send(t time.Time, cost float64, p *SimulationPoint) {
+	r := l.report
+	l.roll(t)
+
+	r.Requests++
+	p.Requests++
+
+	if l.revoked {
+		r.Revoked++
+		p.Revoked++
+		return
+	}
+
+	if t.Before(l.rateBlockedUntil) {
+		if r.RateLimited == 0 {
+			r.FirstRateLimitedAt = t.Unix()
+		}
+
+		r.RateLimited++
+		p.RateLimited++
+		return
+	}
+
+	if t.Before(l.costBlockedUntil) {
+		if r.CostLimited == 0 {
+			r.FirstCostLimitedAt = t.Unix()
+		}
+
+		r.CostLimited++
+		p.CostLimited++
+		return
+	}
+
+	r.Accepted++
+	p.Accepted++
+	r.CostInUsd += cost
+	p.CostInUsd += cost
+
+	l.rateCount++
+	l.cost += cost
+	l.total += cost
+
+	if r.RateLimitOverTime > 0 && l.rateCount >= r.RateLimitOverTime {
+		l.rateBlockedUntil = l.rateWindowEnd
+	}
+
+	if r.CostLimitInUsdOverTime > 0 && toMicroDollars(l.cost) >= toMicroDollars(r.CostLimitInUsdOverTime) {
+		l.costBlockedUntil = l.costWindowEnd
+	}
+
+	if r.CostLimitInUsd > 0 && toMicroDollars(l.total) >= toMicroDollars(r.CostLimitInUsd) {
+		l.revoked = true
+		r.RevokedAt = t.Unix()
+	}
+}
+
+// remaining sets the limits left at end, the exclusive end of a point.
+func (l *limiter) remaining(end time.Time, p *SimulationPoint) {
+	r := l.report
+
+	if r.RateLimitOverTime > 0 {
+		left := r.RateLimitOverTime
+		if !end.After(l.rateWindowEnd) {
+			left -= l.rateCount
+		}
+
+		if left < 0 {
+			left = 0
+		}
+
+		p.RemainingRequests = &left
+	}
+
+	if r.CostLimitInUsdOverTime > 0 {
+		left := r.CostLimitInUsdOverTime
+		if !end.After(l.costWindowEnd) {
+			left = math.Max(0, left-l.cost)
+		}
+
+		p.RemainingCostInUsdOverTime = &left
+	}
+
+	if r.CostLimitInUsd > 0 {
+		left := math.Max(0, r.CostLimitInUsd-l.total)
+		p.RemainingCostInUsd = &left
+	}
+}
+
+// Simulate runs the traffic of the simulation against the limits of rk,
+// overridden by the limits of the simulation.
+func (s *Simulation) Simulate(rk *ResponseKey, now time.Time) *SimulationReport {
+	r := &SimulationReport{
+		KeyId:                  rk.KeyId,
+		CostLimitInUsd:         rk.CostLimitInUsd,
+		CostLimitInUsdOverTime: rk.GetCostLimitInUsdOverTime(),
+		CostLimitInUsdUnit:     rk.CostLimitInUsdUnit,
+		RateLimitOverTime:      rk.RateLimitOverTime,
+		RateLimitUnit:          rk.RateLimitUnit,
+		Timeline:               []*SimulationPoint{},
+	}
+
+	if l := s.Limits; l != nil {
+		if l.CostLimitInUsd != nil {
+			r.CostLimitInUsd = *l.CostLimitInUsd
+		}
+
+		if l.CostLimitInUsdOverTime != nil {
+			r.CostLimitInUsdOverTime = *l.CostLimitInUsdOverTime
+		}
+
+		if l.CostLimitInUsdUnit != nil {
+			r.CostLimitInUsdUnit = *l.CostLimitInUsdUnit
+		}
+
+		if l.RateLimitOverTime != nil {
+			r.RateLimitOverTime = *l.RateLimitOverTime
+		}
+
+		if l.RateLimitUnit != nil {
+			r.RateLimitUnit = *l.RateLimitUnit
+		}
+	}
+
+	// limits without a unit are not enforced.
+	if len(r.RateLimitUnit) == 0 {
+		r.RateLimitOverTime = 0
+	}
+
+	if len(r.CostLimitInUsdUnit) == 0 {
+		r.CostLimitInUsdOverTime = 0
+	}
+
+	start := now.UTC()
+	if s.Start > 0 {
+		start = time.Unix(s.Start, 0).UTC()
+	}
+
+	duration := 0
+	for _, segment := range s.Traffic {
+		duration += segment.DurationInSeconds
+	}
+
+	step := time.Duration(s.step()) * time.Second
+	end := start.Add(time.Duration(duration) * time.Second)
+	r.Start = start.Unix()
+	r.End = end.Unix()
+
+	for pointStart := start; pointStart.Before(end); pointStart = pointStart.Add(step) {
+		r.Timeline = append(r.Timeline, &SimulationPoint{
+			Start: pointStart.Unix(),
+		})
+	}
+
+	l := &limiter{
+		report: r,
+	}
+
+	finalized := 0
+	finalize := func(t time.Time) {
+		for finalized < len(r.Timeline) {
+			pointEnd := start.Add(time.Duration(finalized+1) * step)
+			if pointEnd.After(t) {
+				return
+			}
+
+			l.remaining(pointEnd, r.Timeline[finalized])
+			finalized++
+		}
+	}
+
+	segmentStart := start
+	for _, segment := range s.Traffic {
+		count := int(segment.RequestsPerMinute * float64(segment.DurationInSeconds) / 60)
+		if count > 0 {
+			interval := time.Duration(float64(segment.DurationInSeconds) * float64(time.Second) / float64(count))
+
+			for i := 0; i < count; i++ {
+				t := segmentStart.Add(time.Duration(i) * interval)
+				finalize(t)
+				l.send(t, segment.CostPerRequestInUsd, r.Timeline[int(t.Sub(start)/step)])
+			}
+		}
+
+		segmentStart = segmentStart.Add(time.Duration(segment.DurationInSeconds) * time.Second)
+	}
+
+	finalize(end.Add(step))
+
+	return r
+}
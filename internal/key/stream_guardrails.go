@@ -0,0 +1,94 @@
+package key
+
+import (
+	"fmt"
+
+	"github.com/bricks-cloud/bricksllm/internal/guardrail"
+)
+
+const (
+	maxStreamGuardrails        = 5
+	defaultStreamWindowSize    = 200
+	maxStreamWindowSize        = 10000
+	defaultStreamBlockedReason = "[BricksLLM] response is blocked by a guardrail"
+)
+
+// StreamGuardrails check the streamed chat completions of a key while they
+// are generated. Chunks are held back until WindowSize characters of content
+// are buffered, then the window is checked together with the window before
+// it so content split across windows is caught. Windows that pass are sent
+// to the client, while a blocked window ends the stream with Message.
+type StreamGuardrails struct {
+	Guardrails []*guardrail.Guardrail `json:"guardrails,omitempty"`
+	WindowSize int                    `json:"windowSize,omitempty"`
+	Message    string                 `json:"message,omitempty"`
+}
+
+func (sg *StreamGuardrails) IsEmpty() bool {
+	return sg == nil || len(sg.Guardrails) == 0
+}
+
+func (sg *StreamGuardrails) Validate(prefix string) []string {
+	invalid := []string{}
+
+	if len(sg.Guardrails) > maxStreamGuardrails {
+		invalid = append(invalid, prefix+".guardrails")
+	}
+
+	names := map[string]bool{}
+	for index, g := range sg.Guardrails {
+		if g == nil {
+			invalid = append(invalid, fmt.Sprintf("%s.guardrails.[%d]", prefix, index))
+			continue
+		}
+
+		for _, field := range g.Validate() {
+			invalid = append(invalid, fmt.Sprintf("%s.guardrails.[%d].%s", prefix, index, field))
+		}
+
+		if names[g.Name] {
+			invalid = append(invalid, fmt.Sprintf("%s.guardrails.[%d].name", prefix, index))
+		}
+
+		names[g.Name] = true
+	}
+
+	if sg.WindowSize < 0 || sg.WindowSize > maxStreamWindowSize {
+		invalid = append(invalid, prefix+".windowSize")
+	}
+
+	return invalid
+}
+
+func (sg *StreamGuardrails) SetDefaults() {
+	if sg.IsEmpty() {
+		return
+	}
+
+	for _, g := range sg.Guardrails {
+		g.SetDefaults()
+	}
+
+	if sg.WindowSize == 0 {
+		sg.WindowSize = defaultStreamWindowSize
+	}
+
+	if len(sg.Message) == 0 {
+		sg.Message = defaultStreamBlockedReason
+	}
+}
+
+// Redacted hides the api keys of the guardrails.
+func (sg *StreamGuardrails) Redacted() *StreamGuardrails {
+	if sg == nil {
+		return nil
+	}
+
+	copied := *sg
+	copied.Guardrails = nil
+	for _, g := range sg.Guardrails {
+		copied.Guardrails = append(copied.Guardrails, g.Redacted())
+	}
+
+	return &copied
+}
@@ -0,0 +1,161 @@
+package language
+
+import (
+	"strings"
+	"unicode"
+)
+
+// maxSampleRunes caps how much of a prompt is inspected so that detection
+// stays cheap for long prompts.
+const maxSampleRunes = 2000
+
+// scripts maps the languages that can be told apart by their script alone.
+// Han is ambiguous between Chinese and Japanese, so it is resolved by the
+// presence of kana.
+var scripts = []struct {
+	code  string
+	table *unicode.RangeTable
+}{
+	{"ko", unicode.Hangul},
+	{"ru", unicode.Cyrillic},
+	{"ar", unicode.Arabic},
+	{"he", unicode.Hebrew},
+	{"th", unicode.Thai},
+	{"el", unicode.Greek},
+	{"hi", unicode.Devanagari},
+}
+
+// stopwords holds frequent short words of languages written in the Latin
+// script. Words shared by several of the languages count for each of them.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "is", "are", "was", "of", "to", "in", "that", "it", "you", "for", "with", "this", "what", "how", "be", "have", "not", "on"},
+	"es": {"el", "los", "las", "es", "y", "que", "del", "por", "para", "con", "una", "como", "pero", "muy", "está", "qué", "cómo", "su", "lo", "se"},
+	"fr": {"le", "les", "et", "est", "des", "du", "que", "pour", "avec", "une", "dans", "pas", "qui", "ce", "sur", "au", "je", "vous", "nous", "il"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ich", "sie", "mit", "ein", "eine", "den", "zu", "auf", "für", "sich", "wie", "auch", "es", "wir"},
+	"pt": {"os", "as", "e", "é", "não", "uma", "um", "com", "para", "por", "que", "do", "da", "dos", "das", "em", "mais", "como", "você", "se"},
+	"it": {"il", "gli", "e", "è", "non", "che", "di", "una", "per", "con", "sono", "della", "del", "nel", "come", "mi", "ma", "questo", "anche", "si"},
+	"nl": {"de", "het", "en", "is", "een", "niet", "van", "dat", "ik", "je", "met", "op", "voor", "zijn", "wat", "hoe", "ook", "maar", "er", "te"},
+}
+
+var supported = map[string]bool{
+	"zh": true,
+	"ja": true,
+}
+
+var stopwordLanguages = map[string][]string{}
+
+func init() {
+	for _, s := range scripts {
+		supported[s.code] = true
+	}
+
+	for code, words := range stopwords {
+		supported[code] = true
+
+		for _, w := range words {
+			stopwordLanguages[w] = append(stopwordLanguages[w], code)
+		}
+	}
+}
+
+// IsSupported reports whether code is an ISO 639-1 code Detect can return.
+func IsSupported(code string) bool {
+	return supported[code]
+}
+
+// Detect returns the ISO 639-1 code of the language text is most likely
+// written in, or an empty string when it cannot tell. Non Latin scripts are
+// detected by the script most letters are written in, Latin script languages
+// by their most frequent words.
+func Detect(text string) string {
+	letters := 0
+	latin := 0
+	han := 0
+	kana := 0
+	counts := map[string]int{}
+
+	sampled := 0
+	for _, r := range text {
+		if sampled >= maxSampleRunes {
+			break
+		}
+		sampled++
+
+		if !unicode.IsLetter(r) {
+			continue
+		}
+
+		letters++
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		default:
+			for _, s := range scripts {
+				if unicode.Is(s.table, r) {
+					counts[s.code]++
+					break
+				}
+			}
+		}
+	}
+
+	if letters == 0 {
+		return ""
+	}
+
+	if kana != 0 && (kana+han)*2 >= letters {
+		return "ja"
+	}
+
+	if han*2 >= letters {
+		return "zh"
+	}
+
+	for _, s := range scripts {
+		if counts[s.code]*2 >= letters {
+			return s.code
+		}
+	}
+
+	if latin*2 < letters {
+		return ""
+	}
+
+	return detectLatin(text)
+}
+
+func detectLatin(text string) string {
+	if len(text) > maxSampleRunes*4 {
+		text = text[:maxSampleRunes*4]
+	}
+
+	scores := map[string]int{}
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	for _, w := range words {
+		for _, code := range stopwordLanguages[w] {
+			scores[code]++
+		}
+	}
+
+	best, bestScore, tied := "", 0, false
+	for code, score := range scores {
+		if score > bestScore {
+			best, bestScore, tied = code, score, false
+		} else if score == bestScore {
+			tied = true
+		}
+	}
+
+	if bestScore == 0 || tied {
+		return ""
+	}
+
+	return best
+}
@@ -0,0 +1,15 @@
+package logger
+
+// Keys of the fields structured log lines about a request use, so that the
+// lines of a request can be found with the same query in every server.
+const (
+	FieldRequestId   = "request_id"
+	FieldKeyId       = "key_id"
+	FieldRoute       = "route"
+	FieldProvider    = "provider"
+	FieldModel       = "model"
+	FieldStatusCode  = "status_code"
+	FieldMethod      = "method"
+	FieldPath        = "path"
+	FieldLatencyInMs = "latency_in_ms"
+)
@@ -0,0 +1,90 @@
+package zap
+
+import (
+	"sync"
+
+	"github.com/bricks-cloud/bricksllm/internal/logger"
+	"go.uber.org/zap/zapcore"
+)
+
+// RequestFields holds the fields of requests in flight. Log lines carrying
+// the request_id of a request get its fields added, so that handlers only
+// need to log the request id.
+type RequestFields struct {
+	fields sync.Map
+}
+
+func NewRequestFields() *RequestFields {
+	return &RequestFields{}
+}
+
+// Set replaces the fields of a request.
+func (rf *RequestFields) Set(requestId string, fields ...zapcore.Field) {
+	rf.fields.Store(requestId, fields)
+}
+
+// Delete has to be called once a request is finished.
+func (rf *RequestFields) Delete(requestId string) {
+	rf.fields.Delete(requestId)
+}
+
+func (rf *RequestFields) get(fields []zapcore.Field) []zapcore.Field {
+	for _, f := range fields {
+		if f.Key != logger.FieldRequestId || f.Type != zapcore.StringType {
+			continue
+		}
+
+		stored, ok := rf.fields.Load(f.String)
+		if !ok {
+			return nil
+		}
+
+		return stored.([]zapcore.Field)
+	}
+
+	return nil
+}
+
+type requestFieldsCore struct {
+	zapcore.Core
+	rf *RequestFields
+}
+
+func (c *requestFieldsCore) With(fields []zapcore.Field) zapcore.Core {
+	return &requestFieldsCore{
+		Core: c.Core.With(fields),
+		rf:   c.rf,
+	}
+}
+
+func (c *requestFieldsCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+
+	return ce
+}
+
+// Write adds the fields of the request a line is about. Fields the line
+// already has are left as they are.
+func (c *requestFieldsCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	stored := c.rf.get(fields)
+	if len(stored) == 0 {
+		return c.Core.Write(entry, fields)
+	}
+
+	present := map[string]bool{}
+	for _, f := range fields {
+		present[f.Key] = true
+	}
+
+	merged := make([]zapcore.Field, 0, len(fields)+len(stored))
+	merged = append(merged, fields...)
+	for _, f := range stored {
+		if !present[f.Key] {
+			merged = append(merged, f)
+		}
+	}
+
+	return c.Core.Write(entry, merged)
+}
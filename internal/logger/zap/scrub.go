@@ -0,0 +1,285 @@
+package zap
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const redacted = "[REDACTED]"
+
+var secretPatterns = []*regexp.Regexp{
+	// OpenAI and Anthropic api keys.
+	regexp.MustCompile(`sk-[A-Za-z0-9_\-]{16,}`),
+	regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/=\-]{8,}`),
+	regexp.MustCompile(`(?i)\b(authorization|api[-_]?key|x-api-key)(["']?\s*[:=]\s*["']?)[^\s"',}]+`),
+}
+
+// secretKeys hold credentials whatever their format. Keys are compared in
+// lower case without dashes and underscores.
+var secretKeys = map[string]bool{
+	"authorization":      true,
+	"proxyauthorization": true,
+	"apikey":             true,
+	"xapikey":            true,
+	"key":                true,
+	"secret":             true,
+	"password":           true,
+	"token":              true,
+	"accesstoken":        true,
+}
+
+// contentKeys hold prompts and completions, which are only scrubbed in strict
+// privacy mode.
+var contentKeys = map[string]bool{
+	"content":      true,
+	"prompt":       true,
+	"input":        true,
+	"instructions": true,
+	"messages":     true,
+	"completion":   true,
+	"system":       true,
+	"text":         true,
+	"arguments":    true,
+}
+
+// scrubber removes credentials, and prompt content in strict privacy mode,
+// from log lines before they are encoded.
+type scrubber struct {
+	strict bool
+}
+
+func (s *scrubber) isSensitive(key string) bool {
+	normalized := strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(key))
+	return secretKeys[normalized] || (s.strict && contentKeys[normalized])
+}
+
+func (s *scrubber) scrubString(str string) string {
+	for index, p := range secretPatterns {
+		// the name of the header or the field is kept.
+		if index == len(secretPatterns)-1 {
+			str = p.ReplaceAllString(str, "${1}${2}"+redacted)
+			continue
+		}
+
+		str = p.ReplaceAllString(str, redacted)
+	}
+
+	return str
+}
+
+// scrubValue scrubs values decoded from json.
+func (s *scrubber) scrubValue(v any) any {
+	switch typed := v.(type) {
+	case string:
+		return s.scrubString(typed)
+	case map[string]any:
+		for k, val := range typed {
+			if s.isSensitive(k) {
+				typed[k] = redacted
+				continue
+			}
+
+			typed[k] = s.scrubValue(val)
+		}
+	case []any:
+		for index, val := range typed {
+			typed[index] = s.scrubValue(val)
+		}
+	}
+
+	return v
+}
+
+func (s *scrubber) scrubReflected(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return s.scrubString(fmt.Sprintf("%v", v))
+	}
+
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return s.scrubString(string(data))
+	}
+
+	return s.scrubValue(decoded)
+}
+
+func (s *scrubber) scrubField(f zapcore.Field) zapcore.Field {
+	if s.isSensitive(f.Key) {
+		switch f.Type {
+		case zapcore.BoolType, zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type,
+			zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type, zapcore.Float64Type, zapcore.Float32Type:
+			return f
+		}
+
+		return zapcore.Field{Key: f.Key, Type: zapcore.StringType, String: redacted}
+	}
+
+	switch f.Type {
+	case zapcore.StringType:
+		f.String = s.scrubString(f.String)
+	case zapcore.ByteStringType:
+		if b, ok := f.Interface.([]byte); ok {
+			f.Interface = []byte(s.scrubString(string(b)))
+		}
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok && err != nil {
+			return zapcore.Field{Key: f.Key, Type: zapcore.StringType, String: s.scrubString(err.Error())}
+		}
+	case zapcore.StringerType:
+		if stringer, ok := f.Interface.(fmt.Stringer); ok && stringer != nil {
+			return zapcore.Field{Key: f.Key, Type: zapcore.StringType, String: s.scrubString(stringer.String())}
+		}
+	case zapcore.ObjectMarshalerType:
+		if m, ok := f.Interface.(zapcore.ObjectMarshaler); ok {
+			f.Interface = &scrubbedObject{m: m, s: s}
+		}
+	case zapcore.ArrayMarshalerType:
+		if m, ok := f.Interface.(zapcore.ArrayMarshaler); ok {
+			f.Interface = &scrubbedArray{m: m, s: s}
+		}
+	case zapcore.ReflectType:
+		f.Interface = s.scrubReflected(f.Interface)
+	}
+
+	return f
+}
+
+func (s *scrubber) scrubFields(fields []zapcore.Field) []zapcore.Field {
+	scrubbed := make([]zapcore.Field, 0, len(fields))
+	for _, f := range fields {
+		scrubbed = append(scrubbed, s.scrubField(f))
+	}
+
+	return scrubbed
+}
+
+type scrubbedObject struct {
+	m zapcore.ObjectMarshaler
+	s *scrubber
+}
+
+func (o *scrubbedObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return o.m.MarshalLogObject(&scrubbingObjectEncoder{ObjectEncoder: enc, s: o.s})
+}
+
+type scrubbedArray struct {
+	m zapcore.ArrayMarshaler
+	s *scrubber
+}
+
+func (a *scrubbedArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	return a.m.MarshalLogArray(&scrubbingArrayEncoder{ArrayEncoder: enc, s: a.s})
+}
+
+// scrubbingObjectEncoder scrubs the fields of objects logged with
+// zap.Object.
+type scrubbingObjectEncoder struct {
+	zapcore.ObjectEncoder
+	s *scrubber
+}
+
+func (e *scrubbingObjectEncoder) AddString(key, value string) {
+	if e.s.isSensitive(key) {
+		e.ObjectEncoder.AddString(key, redacted)
+		return
+	}
+
+	e.ObjectEncoder.AddString(key, e.s.scrubString(value))
+}
+
+func (e *scrubbingObjectEncoder) AddByteString(key string, value []byte) {
+	e.AddString(key, string(value))
+}
+
+func (e *scrubbingObjectEncoder) AddBinary(key string, value []byte) {
+	if e.s.isSensitive(key) {
+		e.ObjectEncoder.AddString(key, redacted)
+		return
+	}
+
+	e.ObjectEncoder.AddBinary(key, value)
+}
+
+func (e *scrubbingObjectEncoder) AddObject(key string, m zapcore.ObjectMarshaler) error {
+	if e.s.isSensitive(key) {
+		e.ObjectEncoder.AddString(key, redacted)
+		return nil
+	}
+
+	return e.ObjectEncoder.AddObject(key, &scrubbedObject{m: m, s: e.s})
+}
+
+func (e *scrubbingObjectEncoder) AddArray(key string, m zapcore.ArrayMarshaler) error {
+	if e.s.isSensitive(key) {
+		e.ObjectEncoder.AddString(key, redacted)
+		return nil
+	}
+
+	return e.ObjectEncoder.AddArray(key, &scrubbedArray{m: m, s: e.s})
+}
+
+func (e *scrubbingObjectEncoder) AddReflected(key string, value interface{}) error {
+	if e.s.isSensitive(key) {
+		e.ObjectEncoder.AddString(key, redacted)
+		return nil
+	}
+
+	return e.ObjectEncoder.AddReflected(key, e.s.scrubReflected(value))
+}
+
+type scrubbingArrayEncoder struct {
+	zapcore.ArrayEncoder
+	s *scrubber
+}
+
+func (e *scrubbingArrayEncoder) AppendString(value string) {
+	e.ArrayEncoder.AppendString(e.s.scrubString(value))
+}
+
+func (e *scrubbingArrayEncoder) AppendByteString(value []byte) {
+	e.ArrayEncoder.AppendString(e.s.scrubString(string(value)))
+}
+
+func (e *scrubbingArrayEncoder) AppendObject(m zapcore.ObjectMarshaler) error {
+	return e.ArrayEncoder.AppendObject(&scrubbedObject{m: m, s: e.s})
+}
+
+func (e *scrubbingArrayEncoder) AppendArray(m zapcore.ArrayMarshaler) error {
+	return e.ArrayEncoder.AppendArray(&scrubbedArray{m: m, s: e.s})
+}
+
+func (e *scrubbingArrayEncoder) AppendReflected(value interface{}) error {
+	return e.ArrayEncoder.AppendReflected(e.s.scrubReflected(value))
+}
+
+// scrubbingCore scrubs every line before it reaches the encoder so that call
+// sites cannot leak credentials or, in strict privacy mode, prompts.
+type scrubbingCore struct {
+	zapcore.Core
+	s *scrubber
+}
+
+func (c *scrubbingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &scrubbingCore{
+		Core: c.Core.With(c.s.scrubFields(fields)),
+		s:    c.s,
+	}
+}
+
+func (c *scrubbingCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+
+	return ce
+}
+
+func (c *scrubbingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = c.s.scrubString(entry.Message)
+	return c.Core.Write(entry, c.s.scrubFields(fields))
+}
@@ -116,7 +116,11 @@ func NewLogger(mode string) logger.Logger {
 	return zapLogger.Sugar()
 }
 
-func NewZapLogger(mode string) *zap.Logger {
+// NewZapLogger returns the logger of the servers along with its level, which
+// can be changed at runtime. Lines logging a request_id get the fields rf
+// holds for the request. Credentials are scrubbed from every line, and so is
+// prompt content in strict privacy mode.
+func NewZapLogger(mode, privacyMode string, rf *RequestFields) (*zap.Logger, zap.AtomicLevel) {
 	rawJSON := []byte(`{
 		"level": "debug",
 		"encoding": "json",
@@ -135,9 +139,19 @@ func NewZapLogger(mode string) *zap.Logger {
 		panic(err)
 	}
 
+	wrap := zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		return &requestFieldsCore{
+			Core: &scrubbingCore{
+				Core: c,
+				s:    &scrubber{strict: privacyMode == "strict"},
+			},
+			rf: rf,
+		}
+	})
+
 	if mode == "production" {
 		// cfg.Level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
-		return zap.Must(cfg.Build())
+		return zap.Must(cfg.Build(wrap)), cfg.Level
 	}
 
 	cfg.EncoderConfig.LevelKey = zapcore.OmitKey
@@ -151,8 +165,8 @@ func NewZapLogger(mode string) *zap.Logger {
 	zapLogger := zap.New(zapcore.NewCore(
 		enc,
 		zapcore.AddSync(colorable.NewColorableStdout()),
-		zapcore.DebugLevel,
-	))
+		cfg.Level,
+	), wrap)
 
-	return zapLogger
+	return zapLogger, cfg.Level
 }
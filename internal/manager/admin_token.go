@@ -0,0 +1,90 @@
+package manager
+
+import (
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/admintoken"
+	"github.com/bricks-cloud/bricksllm/internal/encrypter"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+)
+
+type AdminTokensStorage interface {
+	CreateAdminToken(t *admintoken.Token) (*admintoken.Token, error)
+	GetAdminTokens() ([]*admintoken.Token, error)
+	UpdateAdminToken(id string, ut *admintoken.UpdateToken) (*admintoken.Token, error)
+}
+
+type AdminTokensMemStorage interface {
+	SetToken(t *admintoken.Token)
+}
+
+type AdminTokenManager struct {
+	s  AdminTokensStorage
+	ms AdminTokensMemStorage
+}
+
+func NewAdminTokenManager(s AdminTokensStorage, ms AdminTokensMemStorage) *AdminTokenManager {
+	return &AdminTokenManager{
+		s:  s,
+		ms: ms,
+	}
+}
+
+// NewBootstrapToken generates a one-time token for creating the first admin
+// token.
+func NewBootstrapToken() (string, error) {
+	return newToken("bootstrap-")
+}
+
+func (m *AdminTokenManager) CreateAdminToken(t *admintoken.Token) (*admintoken.Token, error) {
+	t.Id = util.NewUuid()
+	t.CreatedAt = time.Now().Unix()
+	t.UpdatedAt = time.Now().Unix()
+	t.Revoked = false
+
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+
+	token, err := newToken("admin-")
+	if err != nil {
+		return nil, err
+	}
+
+	t.TokenHash = encrypter.Encrypt(token)
+
+	created, err := m.s.CreateAdminToken(t)
+	if err != nil {
+		return nil, err
+	}
+
+	m.ms.SetToken(created)
+
+	returned := *created
+	returned.Token = token
+
+	return &returned, nil
+}
+
+func (m *AdminTokenManager) GetAdminTokens() ([]*admintoken.Token, error) {
+	return m.s.GetAdminTokens()
+}
+
+// UpdateAdminToken renames or revokes an admin token. Other instances stop
+// accepting a revoked token once their memdb picks up the change.
+func (m *AdminTokenManager) UpdateAdminToken(id string, ut *admintoken.UpdateToken) (*admintoken.Token, error) {
+	ut.UpdatedAt = time.Now().Unix()
+
+	if err := ut.Validate(); err != nil {
+		return nil, err
+	}
+
+	updated, err := m.s.UpdateAdminToken(id, ut)
+	if err != nil {
+		return nil, err
+	}
+
+	m.ms.SetToken(updated)
+
+	return updated, nil
+}
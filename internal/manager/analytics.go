@@ -0,0 +1,284 @@
+package manager
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/tidwall/gjson"
+)
+
+const analyticsPlanPrompt = `You answer questions about the usage of an LLM gateway by planning reporting queries. The current time is %s (unix %d).
+
+Reply with a JSON object only, in the form {"queries": [...]}, holding up to %d queries of these types:
+
+{"type": "aggregation", "aggregation": {"start": <unix seconds>, "end": <unix seconds>, "granularity": "hour" | "day" | "", "groupBy": [<dimensions>], "keyIds": [], "tags": [], "models": [], "providers": [], "metadata": {}, "environment": {}}}
+Returns the number of requests, cost in usd, prompt and completion tokens and success count per group and time bucket. An empty granularity aggregates the whole time range. Dimensions are keyId, tag, model, provider, route, path, userId, customId, language, sdk, sdkVersion, userAgent, tags.<name> for the value of a tag such as tags.team, env.<name> for the value of an environment label of the deployment such as env.color, and metadata.<key>.
+
+{"type": "top", "top": {"metric": "keys-by-spend" | "models-by-tokens" | "keys-by-errors" | "routes-by-latency", "start": <unix seconds>, "end": <unix seconds>, "limit": <1 to 100>}}
+Returns the top entries of a metric.
+
+Compare periods with several queries or with a granularity, for example to find what grew fastest.`
+
+const analyticsAnswerPrompt = `You answer questions about the usage of an LLM gateway. Answer the question in a few sentences using only the results of the reporting queries below, which were run for it. Costs are in usd. Say so when the results do not answer the question.
+
+Queries: %s
+
+Results: %s`
+
+// analyticsTruncatedNote tells the analytics route that rows were left out
+// of the results.
+const analyticsTruncatedNote = "\n\nThe results are truncated, the last rows of the largest results were left out."
+
+// maxAnalyticsResultsSize caps the results sent to the analytics route so
+// large aggregations do not exceed its context window.
+const maxAnalyticsResultsSize = 32000
+
+type analyticsReporter interface {
+	GetAggregatedEventReporting(r *event.AggregationRequest) (*event.AggregationResponse, error)
+	GetTopReporting(r *event.TopRequest) (*event.TopResponse, error)
+}
+
+// AnalyticsManager answers questions about usage by letting an LLM route plan
+// reporting queries and summarize their results. The route is called through
+// the proxy with a regular key, so the gateway accounts for its own
+// analytics like any other traffic. Planned queries only ever run through the
+// reporting manager and are scoped to the tenant of the question.
+type AnalyticsManager struct {
+	r        analyticsReporter
+	proxyUrl string
+	route    string
+	apiKey   string
+	client   http.Client
+}
+
+func NewAnalyticsManager(r analyticsReporter, proxyUrl, route, apiKey string, timeout time.Duration) *AnalyticsManager {
+	return &AnalyticsManager{
+		r:        r,
+		proxyUrl: strings.TrimSuffix(proxyUrl, "/"),
+		route:    "/" + strings.TrimPrefix(route, "/"),
+		apiKey:   apiKey,
+		client: http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+func (m *AnalyticsManager) Ask(q *event.AnalyticsQuestion) (*event.AnalyticsAnswer, error) {
+	if len(m.apiKey) == 0 || m.route == "/" {
+		return nil, internal_errors.NewValidationError("analytics route is not configured")
+	}
+
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	plan, err := m.complete(fmt.Sprintf(analyticsPlanPrompt, now.UTC().Format(time.RFC3339), now.Unix(), event.MaxAnalyticsQueries), q.Question)
+	if err != nil {
+		return nil, err
+	}
+
+	queries, err := parseAnalyticsQueries(plan)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []any{}
+	for _, query := range queries {
+		result, err := m.run(query, q.TenantId)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, result)
+	}
+
+	queriesJson, err := json.Marshal(queries)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsJson, truncated, err := truncateAnalyticsResults(results, maxAnalyticsResultsSize)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt := fmt.Sprintf(analyticsAnswerPrompt, queriesJson, resultsJson)
+	if truncated {
+		prompt += analyticsTruncatedNote
+	}
+
+	answer, err := m.complete(prompt, q.Question)
+	if err != nil {
+		return nil, err
+	}
+
+	return &event.AnalyticsAnswer{
+		Question:  q.Question,
+		Answer:    strings.TrimSpace(answer),
+		Queries:   queries,
+		Results:   results,
+		Truncated: truncated,
+	}, nil
+}
+
+// truncateAnalyticsResults marshals results and drops the last rows of the
+// results with the most rows until they fit into max, so that the route is
+// never sent partial rows. Results themselves are left untouched.
+func truncateAnalyticsResults(results []any, max int) ([]byte, bool, error) {
+	data, err := json.Marshal(results)
+	if err != nil || len(data) <= max {
+		return data, false, err
+	}
+
+	sizes := make([][]int, len(results))
+	for i, result := range results {
+		var rows []any
+		switch r := result.(type) {
+		case *event.AggregationResponse:
+			for _, dp := range r.DataPoints {
+				rows = append(rows, dp)
+			}
+		case *event.TopResponse:
+			for _, entry := range r.Entries {
+				rows = append(rows, entry)
+			}
+		}
+
+		for _, row := range rows {
+			rowJson, err := json.Marshal(row)
+			if err != nil {
+				return nil, false, err
+			}
+
+			sizes[i] = append(sizes[i], len(rowJson))
+		}
+	}
+
+	size := len(data)
+	for size > max {
+		longest := -1
+		for i := range sizes {
+			if len(sizes[i]) != 0 && (longest < 0 || len(sizes[i]) > len(sizes[longest])) {
+				longest = i
+			}
+		}
+
+		if longest < 0 {
+			break
+		}
+
+		last := len(sizes[longest]) - 1
+		size -= sizes[longest][last]
+		if last != 0 {
+			// the comma before the row.
+			size--
+		}
+
+		sizes[longest] = sizes[longest][:last]
+	}
+
+	kept := make([]any, len(results))
+	for i, result := range results {
+		switch r := result.(type) {
+		case *event.AggregationResponse:
+			kept[i] = &event.AggregationResponse{DataPoints: r.DataPoints[:len(sizes[i])]}
+		case *event.TopResponse:
+			kept[i] = &event.TopResponse{Metric: r.Metric, Entries: r.Entries[:len(sizes[i])]}
+		default:
+			kept[i] = result
+		}
+	}
+
+	data, err = json.Marshal(kept)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return data, true, nil
+}
+
+func (m *AnalyticsManager) run(query *event.AnalyticsQuery, tenantId string) (any, error) {
+	if query.Type == event.AnalyticsQueryTop {
+		query.Top.TenantId = tenantId
+		return m.r.GetTopReporting(query.Top)
+	}
+
+	query.Aggregation.TenantId = tenantId
+	return m.r.GetAggregatedEventReporting(query.Aggregation)
+}
+
+// parseAnalyticsQueries reads the queries planned by the analytics route,
+// tolerating the markdown code fences models tend to wrap json in.
+func parseAnalyticsQueries(plan string) ([]*event.AnalyticsQuery, error) {
+	plan = strings.TrimSpace(plan)
+	if start, end := strings.Index(plan, "{"), strings.LastIndex(plan, "}"); start >= 0 && end > start {
+		plan = plan[start : end+1]
+	}
+
+	parsed := struct {
+		Queries []*event.AnalyticsQuery `json:"queries"`
+	}{}
+
+	if err := json.Unmarshal([]byte(plan), &parsed); err != nil {
+		return nil, internal_errors.NewUnavailableError("analytics route did not plan valid reporting queries")
+	}
+
+	if len(parsed.Queries) == 0 || len(parsed.Queries) > event.MaxAnalyticsQueries {
+		return nil, internal_errors.NewUnavailableError(fmt.Sprintf("analytics route planned %d reporting queries", len(parsed.Queries)))
+	}
+
+	for _, query := range parsed.Queries {
+		if err := query.Validate(); err != nil {
+			return nil, internal_errors.NewUnavailableError("analytics route planned an invalid reporting query: " + err.Error())
+		}
+	}
+
+	return parsed.Queries, nil
+}
+
+// complete sends a chat completion request to the analytics route through
+// the proxy and returns the content of the first choice.
+func (m *AnalyticsManager) complete(system, user string) (string, error) {
+	body, err := json.Marshal(map[string]any{
+		"messages": []map[string]string{
+			{"role": "system", "content": system},
+			{"role": "user", "content": user},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, m.proxyUrl+"/api/routes"+m.route, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+m.apiKey)
+
+	res, err := m.client.Do(req)
+	if err != nil {
+		return "", internal_errors.NewUnavailableError("analytics route cannot be reached: " + err.Error())
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return "", internal_errors.NewUnavailableError(fmt.Sprintf("analytics route responded with status %d: %s", res.StatusCode, string(data)))
+	}
+
+	return gjson.GetBytes(data, "choices.0.message.content").Str, nil
+}
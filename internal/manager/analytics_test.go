@@ -0,0 +1,79 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTopResponse(entries int) *event.TopResponse {
+	r := &event.TopResponse{Metric: "keys-by-spend", Entries: []*event.TopEntry{}}
+	for i := 0; i < entries; i++ {
+		r.Entries = append(r.Entries, &event.TopEntry{Name: fmt.Sprintf("key-%d", i), Value: float64(i), NumberOfRequests: int64(i)})
+	}
+
+	return r
+}
+
+func newAggregationResponse(dataPoints int) *event.AggregationResponse {
+	r := &event.AggregationResponse{DataPoints: []*event.AggregatedDataPoint{}}
+	for i := 0; i < dataPoints; i++ {
+		r.DataPoints = append(r.DataPoints, &event.AggregatedDataPoint{TimeStamp: int64(i), NumberOfRequests: int64(i), Dimensions: map[string]string{"model": "gpt-4o"}})
+	}
+
+	return r
+}
+
+func TestTruncateAnalyticsResults(t *testing.T) {
+	cases := []struct {
+		name      string
+		results   []any
+		max       int
+		truncated bool
+		rows      []int
+	}{
+		{name: "results that fit", results: []any{newTopResponse(3), newAggregationResponse(3)}, max: 10000, truncated: false, rows: []int{3, 3}},
+		{name: "rows of the largest result are dropped first", results: []any{newTopResponse(2), newAggregationResponse(40)}, max: 1000, truncated: true, rows: []int{2, 3}},
+		{name: "rows are dropped from every result", results: []any{newTopResponse(20), newAggregationResponse(20)}, max: 600, truncated: true, rows: []int{1, 1}},
+		{name: "every row is dropped when nothing else fits", results: []any{newTopResponse(5), newAggregationResponse(5)}, max: 10, truncated: true, rows: []int{0, 0}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			original, err := json.Marshal(tc.results)
+			require.NoError(t, err)
+
+			data, truncated, err := truncateAnalyticsResults(tc.results, tc.max)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.truncated, truncated)
+			if truncated && tc.rows[0]+tc.rows[1] != 0 {
+				assert.LessOrEqual(t, len(data), tc.max)
+			}
+
+			parsed := []struct {
+				Entries    []*event.TopEntry            `json:"entries"`
+				DataPoints []*event.AggregatedDataPoint `json:"dataPoints"`
+			}{}
+			require.NoError(t, json.Unmarshal(data, &parsed))
+			require.Len(t, parsed, 2)
+
+			assert.Len(t, parsed[0].Entries, tc.rows[0])
+			assert.Len(t, parsed[1].DataPoints, tc.rows[1])
+
+			// the rows that are kept are the first rows of the results.
+			if tc.rows[0] != 0 {
+				assert.Equal(t, "key-0", parsed[0].Entries[0].Name)
+			}
+
+			// results themselves are not changed.
+			unchanged, err := json.Marshal(tc.results)
+			require.NoError(t, err)
+			assert.Equal(t, original, unchanged)
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package manager
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/bundle"
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/prompt"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+)
+
+type BundleStorage interface {
+	GetBundleStatus(tenantId, name string) (*bundle.Status, error)
+	ApplyBundle(st *bundle.Status, created []*key.RequestKey, updated map[string]*key.UpdateKey, routes []*route.Route) error
+	GetProviderSettings(withSecret bool, ids []string) ([]*provider.Setting, error)
+	GetPolicies() ([]*policy.Policy, error)
+	GetAllKeys() ([]*key.ResponseKey, error)
+	GetRoutes() ([]*route.Route, error)
+	ImportSnapshot(s *bundle.Snapshot, importedAt int64) (*bundle.ImportReport, error)
+}
+
+// BundleManager applies declarative bundles of keys and routes. Every
+// resource of a bundle is validated before anything is written, and the
+// writes happen in a single transaction. It also exports and imports
+// snapshots of the whole configuration signed with secret.
+type BundleManager struct {
+	s      BundleStorage
+	km     *Manager
+	rm     *RouteManager
+	secret string
+}
+
+func NewBundleManager(s BundleStorage, km *Manager, rm *RouteManager, secret string) *BundleManager {
+	return &BundleManager{
+		s:      s,
+		km:     km,
+		rm:     rm,
+		secret: secret,
+	}
+}
+
+func (m *BundleManager) GetStatus(tenantId, name string) (*bundle.Status, error) {
+	return m.s.GetBundleStatus(tenantId, name)
+}
+
+// Apply applies a bundle unless the same or a newer generation of it was
+// applied already. Resending the generation applied last returns its status.
+func (m *BundleManager) Apply(b *bundle.Bundle) (*bundle.Status, error) {
+	if !util.IsValidId(b.Name) {
+		return nil, internal_errors.NewValidationError("bundle name can only contain up to 64 letters, digits, dots, underscores and dashes")
+	}
+
+	if b.Generation <= 0 {
+		return nil, internal_errors.NewValidationError("bundle generation has to be positive")
+	}
+
+	current, err := m.s.GetBundleStatus(b.TenantId, b.Name)
+	if err != nil {
+		if _, ok := err.(notFoundError); !ok {
+			return nil, err
+		}
+	}
+
+	if current != nil {
+		if current.Generation == b.Generation {
+			return current, nil
+		}
+
+		if current.Generation > b.Generation {
+			return nil, internal_errors.NewConflictError(fmt.Sprintf("bundle %s is already at generation %d", b.Name, current.Generation))
+		}
+	}
+
+	st := &bundle.Status{
+		Name:       b.Name,
+		TenantId:   b.TenantId,
+		Generation: b.Generation,
+		AppliedAt:  time.Now().Unix(),
+		Results:    []*bundle.Result{},
+	}
+
+	created, updated, err := m.planKeys(b, st)
+	if err != nil {
+		return nil, err
+	}
+
+	routes, err := m.planRoutes(b, st)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.s.ApplyBundle(st, created, updated, routes); err != nil {
+		return nil, err
+	}
+
+	return st, nil
+}
+
+func (m *BundleManager) planKeys(b *bundle.Bundle, st *bundle.Status) ([]*key.RequestKey, map[string]*key.UpdateKey, error) {
+	created := []*key.RequestKey{}
+	updated := map[string]*key.UpdateKey{}
+	seen := map[string]bool{}
+
+	for _, rk := range b.Keys {
+		if len(rk.KeyId) == 0 {
+			return nil, nil, internal_errors.NewValidationError("keys of a bundle require a keyId")
+		}
+
+		if seen[rk.KeyId] {
+			return nil, nil, internal_errors.NewValidationError("key is declared more than once: " + rk.KeyId)
+		}
+		seen[rk.KeyId] = true
+
+		rk.TenantId = b.TenantId
+
+		existing, err := m.km.GetKeys(nil, []string{rk.KeyId}, "")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(existing) == 0 {
+			if err := m.km.prepareKey(rk); err != nil {
+				return nil, nil, err
+			}
+
+			created = append(created, rk)
+			st.Results = append(st.Results, &bundle.Result{Resource: "key", Id: rk.KeyId, Action: bundle.ActionCreated})
+			continue
+		}
+
+		if existing[0].TenantId != b.TenantId {
+			return nil, nil, internal_errors.NewConflictError("key already exists: " + rk.KeyId)
+		}
+
+		uk := desiredKeyUpdate(rk)
+		if err := m.km.prepareKeyUpdate(rk.KeyId, uk); err != nil {
+			return nil, nil, err
+		}
+
+		updated[rk.KeyId] = uk
+		st.Results = append(st.Results, &bundle.Result{Resource: "key", Id: rk.KeyId, Action: bundle.ActionUpdated})
+	}
+
+	return created, updated, nil
+}
+
+// desiredKeyUpdate updates an existing key to the fields of a declared key
+// that can be updated. Optional policies missing from the declaration are
+// removed from the key.
+func desiredKeyUpdate(rk *key.RequestKey) *key.UpdateKey {
+	paths := rk.AllowedPaths
+	if paths == nil {
+		paths = []key.PathConfig{}
+	}
+
+	regions := rk.AllowedRegions
+	if regions == nil {
+		regions = []string{}
+	}
+
+	flags := rk.FeatureFlags
+	if flags == nil {
+		flags = map[string]bool{}
+	}
+
+	uk := &key.UpdateKey{
+		Name:               rk.Name,
+		Tags:               rk.Tags,
+		SettingId:          rk.SettingId,
+		SettingIds:         rk.SettingIds,
+		AllowedPaths:       &paths,
+		SystemPrompt:       rk.SystemPrompt,
+		OutputCaps:         rk.OutputCaps,
+		SessionLimits:      rk.SessionLimits,
+		LoopProtection:     rk.LoopProtection,
+		ModelPolicy:        rk.ModelPolicy,
+		Schedule:           rk.Schedule,
+		AllowedRegions:     &regions,
+		RecordingRetention: &rk.RecordingRetention,
+		FeatureFlags:       flags,
+		Signing:            rk.Signing,
+		Parameters:         rk.Parameters,
+		StreamGuardrails:   rk.StreamGuardrails,
+	}
+
+	if uk.SystemPrompt == nil {
+		uk.SystemPrompt = &prompt.SystemPrompt{}
+	}
+
+	if uk.OutputCaps == nil {
+		uk.OutputCaps = &key.OutputCaps{}
+	}
+
+	if uk.SessionLimits == nil {
+		uk.SessionLimits = &key.SessionLimits{}
+	}
+
+	if uk.LoopProtection == nil {
+		uk.LoopProtection = &key.LoopProtection{}
+	}
+
+	if uk.ModelPolicy == nil {
+		uk.ModelPolicy = &key.ModelPolicy{}
+	}
+
+	if uk.Schedule == nil {
+		uk.Schedule = &key.Schedule{}
+	}
+
+	if uk.Signing == nil {
+		uk.Signing = &key.Signing{}
+	}
+
+	if uk.Parameters == nil {
+		uk.Parameters = &key.Parameters{}
+	}
+
+	if uk.StreamGuardrails == nil {
+		uk.StreamGuardrails = &key.StreamGuardrails{}
+	}
+
+	return uk
+}
+
+// planRoutes creates the routes of a bundle that do not exist yet. Routes
+// cannot be updated, so existing ones are left as they are.
+func (m *BundleManager) planRoutes(b *bundle.Bundle, st *bundle.Status) ([]*route.Route, error) {
+	created := []*route.Route{}
+	seen := map[string]bool{}
+	paths := map[string]bool{}
+
+	for _, r := range b.Routes {
+		if len(r.Id) == 0 {
+			return nil, internal_errors.NewValidationError("routes of a bundle require an id")
+		}
+
+		if seen[r.Id] {
+			return nil, internal_errors.NewValidationError("route is declared more than once: " + r.Id)
+		}
+		seen[r.Id] = true
+
+		if paths[route.NormalizePath(r.Path)] {
+			return nil, internal_errors.NewValidationError("route path is declared more than once: " + r.Path)
+		}
+		paths[route.NormalizePath(r.Path)] = true
+
+		r.TenantId = b.TenantId
+
+		existing, _ := m.rm.s.GetRoute(r.Id)
+		if existing != nil {
+			if existing.TenantId != b.TenantId {
+				return nil, internal_errors.NewConflictError("route already exists: " + r.Id)
+			}
+
+			st.Results = append(st.Results, &bundle.Result{Resource: "route", Id: r.Id, Action: bundle.ActionUnchanged})
+			continue
+		}
+
+		if err := m.rm.prepareRoute(r); err != nil {
+			return nil, err
+		}
+
+		created = append(created, r)
+		st.Results = append(st.Results, &bundle.Result{Resource: "route", Id: r.Id, Action: bundle.ActionCreated})
+	}
+
+	return created, nil
+}
@@ -0,0 +1,43 @@
+package manager
+
+import (
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+)
+
+// CloneKey creates a key with the configuration of the key id in the tenant
+// of cl. The clone is validated like any created key, so the provider
+// settings it references must belong to that tenant.
+func (m *Manager) CloneKey(id string, cl *key.Clone) (*key.ResponseKey, error) {
+	if err := cl.Validate(); err != nil {
+		return nil, err
+	}
+
+	existing, err := m.s.GetKeys(nil, []string{id}, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(existing) == 0 {
+		return nil, internal_errors.NewNotFoundError("key is not found: " + id)
+	}
+
+	return m.CreateKey(cl.Apply(existing[0]))
+}
+
+// CloneRoute creates a route with the configuration of the route id in the
+// tenant of cl. The clone is validated like any created route, so the keys
+// it references must belong to that tenant.
+func (m *RouteManager) CloneRoute(id string, cl *route.Clone) (*route.Route, error) {
+	existing, err := m.s.GetRoute(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cl.Validate(existing); err != nil {
+		return nil, err
+	}
+
+	return m.CreateRoute(cl.Apply(existing))
+}
@@ -0,0 +1,130 @@
+package manager
+
+import (
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+type openAiEstimator interface {
+	EstimateChatCompletionPromptTokenCounts(model string, r *goopenai.ChatCompletionRequest) (int, error)
+	EstimatePromptCost(model string, tks int) (float64, error)
+	EstimateCompletionCost(model string, tks int) (float64, error)
+	EstimateEmbeddingsCost(r *goopenai.EmbeddingRequest) (float64, error)
+}
+
+type azureEstimator interface {
+	EstimatePromptCost(model string, tks int) (float64, error)
+	EstimateCompletionCost(model string, tks int) (float64, error)
+	EstimateEmbeddingsCost(r *goopenai.EmbeddingRequest) (float64, error)
+}
+
+type anthropicEstimator interface {
+	Count(input string) int
+	EstimatePromptCost(model string, tks int) (float64, error)
+	EstimateCompletionCost(model string, tks int) (float64, error)
+}
+
+type promptCompletionEstimator interface {
+	EstimatePromptCost(model string, tks int) (float64, error)
+	EstimateCompletionCost(model string, tks int) (float64, error)
+}
+
+const anthropicPromptMagicNum int = 1
+
+// azureTokenizerModel is used for counting azure prompt tokens since azure
+// deployments share the cl100k_base encoding with gpt-4.
+const azureTokenizerModel = "gpt-4"
+
+type EstimationManager struct {
+	oe  openAiEstimator
+	aoe azureEstimator
+	ae  anthropicEstimator
+}
+
+func NewEstimationManager(oe openAiEstimator, aoe azureEstimator, ae anthropicEstimator) *EstimationManager {
+	return &EstimationManager{
+		oe:  oe,
+		aoe: aoe,
+		ae:  ae,
+	}
+}
+
+// Estimate computes token counts and cost of a prospective request without
+// sending it to the provider. Completion cost assumes all max_tokens are used.
+func (m *EstimationManager) Estimate(r *provider.EstimationRequest) (*provider.EstimationResponse, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	resp := &provider.EstimationResponse{
+		Provider:             r.Provider,
+		Model:                r.Model,
+		CompletionTokenCount: r.MaxTokens,
+	}
+
+	if r.IsEmbeddingsRequest() {
+		er := &goopenai.EmbeddingRequest{
+			Input: r.Input,
+			Model: goopenai.EmbeddingModel(r.Model),
+		}
+
+		var cost float64
+		var err error
+		if r.Provider == "azure" {
+			cost, err = m.aoe.EstimateEmbeddingsCost(er)
+		} else {
+			cost, err = m.oe.EstimateEmbeddingsCost(er)
+		}
+
+		if err != nil {
+			return nil, internal_errors.NewValidationError(err.Error())
+		}
+
+		resp.PromptCostInUsd = cost
+		resp.TotalCostInUsd = cost
+		resp.CompletionTokenCount = 0
+
+		return resp, nil
+	}
+
+	var pe promptCompletionEstimator
+
+	switch r.Provider {
+	case "anthropic":
+		pe = m.ae
+		resp.PromptTokenCount = m.ae.Count(r.Prompt) + anthropicPromptMagicNum
+	case "azure":
+		pe = m.aoe
+		tks, err := m.oe.EstimateChatCompletionPromptTokenCounts(azureTokenizerModel, &goopenai.ChatCompletionRequest{Messages: r.Messages})
+		if err != nil {
+			return nil, err
+		}
+
+		resp.PromptTokenCount = tks
+	default:
+		pe = m.oe
+		tks, err := m.oe.EstimateChatCompletionPromptTokenCounts(r.Model, &goopenai.ChatCompletionRequest{Model: r.Model, Messages: r.Messages})
+		if err != nil {
+			return nil, internal_errors.NewValidationError(err.Error())
+		}
+
+		resp.PromptTokenCount = tks
+	}
+
+	promptCost, err := pe.EstimatePromptCost(r.Model, resp.PromptTokenCount)
+	if err != nil {
+		return nil, internal_errors.NewValidationError(err.Error())
+	}
+
+	completionCost, err := pe.EstimateCompletionCost(r.Model, resp.CompletionTokenCount)
+	if err != nil {
+		return nil, internal_errors.NewValidationError(err.Error())
+	}
+
+	resp.PromptCostInUsd = promptCost
+	resp.CompletionCostInUsd = completionCost
+	resp.TotalCostInUsd = promptCost + completionCost
+
+	return resp, nil
+}
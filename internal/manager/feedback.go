@@ -0,0 +1,51 @@
+package manager
+
+import (
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+)
+
+type FeedbackStorage interface {
+	SetEventFeedback(eventId, keyId string, f *event.Feedback) (bool, error)
+}
+
+// FeedbackManager stores what clients thought of responses on the events of
+// their requests.
+type FeedbackManager struct {
+	s FeedbackStorage
+}
+
+func NewFeedbackManager(s FeedbackStorage) *FeedbackManager {
+	return &FeedbackManager{
+		s: s,
+	}
+}
+
+// SubmitFeedback stores feedback on a request made with k. Requests made with
+// other keys are reported as not found.
+func (m *FeedbackManager) SubmitFeedback(k *key.ResponseKey, r *event.FeedbackRequest) (*event.Feedback, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	f := &event.Feedback{
+		Thumb:     r.Thumb,
+		Rating:    r.Rating,
+		Comment:   r.Comment,
+		CreatedAt: time.Now().Unix(),
+	}
+
+	stored, err := m.s.SetEventFeedback(r.RequestId, k.KeyId, f)
+	if err != nil {
+		return nil, err
+	}
+
+	if !stored {
+		return nil, internal_errors.NewNotFoundError("request is not found")
+	}
+
+	return f, nil
+}
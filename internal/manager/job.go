@@ -0,0 +1,165 @@
+package manager
+
+import (
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/bricks-cloud/bricksllm/internal/webhook"
+	"go.uber.org/zap"
+)
+
+// jobTimeout is how long a job can go without progress before it is failed.
+// Jobs are only sent by the instance that accepted them, so jobs of stopped
+// instances never finish. Jobs of restarted instances are failed right away.
+const jobTimeout = 30 * time.Minute
+
+type JobStorage interface {
+	CreateJob(j *event.Job) error
+	GetJob(id string) (*event.Job, error)
+	UpdateJob(j *event.Job) error
+	FailStaleJobs(staleBefore, now int64, reason string) (int64, error)
+	FailInstanceJobs(instanceId string, now int64, reason string) (int64, error)
+	DeleteExpiredJobs(now int64) (int64, error)
+}
+
+// JobManager keeps track of asynchronous proxy requests and deletes them once
+// their retention has passed. The instance is the host name of the
+// instance, which stays the same across restarts.
+type JobManager struct {
+	s            JobStorage
+	retention    time.Duration
+	webhookHosts []string
+	instance     string
+	done         chan bool
+	interval     time.Duration
+	log          *zap.Logger
+}
+
+func NewJobManager(s JobStorage, retention time.Duration, webhookHosts []string, instance string, log *zap.Logger, interval time.Duration) *JobManager {
+	return &JobManager{
+		s:            s,
+		retention:    retention,
+		webhookHosts: webhookHosts,
+		instance:     instance,
+		done:         make(chan bool),
+		interval:     interval,
+		log:          log,
+	}
+}
+
+func (m *JobManager) CreateJob(k *key.ResponseKey, path, webhookUrl string) (*event.Job, error) {
+	if len(webhookUrl) != 0 {
+		if err := webhook.ValidateUrl(webhookUrl, m.webhookHosts); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	j := &event.Job{
+		Id:         util.NewUuid(),
+		CreatedAt:  now.Unix(),
+		UpdatedAt:  now.Unix(),
+		KeyId:      k.KeyId,
+		TenantId:   k.TenantId,
+		Path:       path,
+		Status:     event.JobStatusQueued,
+		WebhookUrl: webhookUrl,
+		ExpiresAt:  now.Add(m.retention).Unix(),
+		InstanceId: m.instance,
+	}
+
+	if err := m.s.CreateJob(j); err != nil {
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// GetJob returns a job submitted with k. Jobs of other keys are reported as
+// not found.
+func (m *JobManager) GetJob(k *key.ResponseKey, id string) (*event.Job, error) {
+	j, err := m.s.GetJob(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if j.KeyId != k.KeyId {
+		return nil, internal_errors.NewNotFoundError("job is not found for: " + id)
+	}
+
+	return j, nil
+}
+
+func (m *JobManager) UpdateJob(j *event.Job) error {
+	j.UpdatedAt = time.Now().Unix()
+
+	return m.s.UpdateJob(j)
+}
+
+func (m *JobManager) cleanUp() {
+	now := time.Now()
+
+	failed, err := m.s.FailStaleJobs(now.Add(-jobTimeout).Unix(), now.Unix(), "job was not finished by the instance that accepted it")
+	if err != nil {
+		stats.Incr("bricksllm.manager.job_manager.clean_up.fail_stale_jobs_error", nil, 1)
+		m.log.Sugar().Debugf("error when failing stale jobs: %v", err)
+	}
+
+	if failed != 0 {
+		m.log.Sugar().Infof("job manager failed %d stale jobs", failed)
+	}
+
+	if _, err := m.s.DeleteExpiredJobs(now.Unix()); err != nil {
+		stats.Incr("bricksllm.manager.job_manager.clean_up.delete_expired_jobs_error", nil, 1)
+		m.log.Sugar().Debugf("error when deleting expired jobs: %v", err)
+	}
+}
+
+// failUnfinishedJobs fails the jobs that were queued in the memory of this
+// instance before it was restarted.
+func (m *JobManager) failUnfinishedJobs() {
+	if len(m.instance) == 0 {
+		return
+	}
+
+	failed, err := m.s.FailInstanceJobs(m.instance, time.Now().Unix(), "job was not finished before the instance that accepted it restarted")
+	if err != nil {
+		stats.Incr("bricksllm.manager.job_manager.fail_unfinished_jobs.fail_instance_jobs_error", nil, 1)
+		m.log.Sugar().Debugf("error when failing unfinished jobs: %v", err)
+		return
+	}
+
+	if failed != 0 {
+		m.log.Sugar().Infof("job manager failed %d unfinished jobs", failed)
+	}
+}
+
+func (m *JobManager) Listen() {
+	m.failUnfinishedJobs()
+
+	ticker := time.NewTicker(m.interval)
+	m.log.Info("job manager started cleaning up jobs")
+
+	go func() {
+		for {
+			select {
+			case <-m.done:
+				ticker.Stop()
+				m.log.Info("job manager stopped")
+				return
+			case <-ticker.C:
+				m.cleanUp()
+			}
+		}
+	}()
+}
+
+func (m *JobManager) Stop() {
+	m.log.Info("shutting down job manager...")
+
+	m.done <- true
+}
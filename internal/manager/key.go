@@ -2,11 +2,14 @@ package manager
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/bricks-cloud/bricksllm/internal/encrypter"
 	"github.com/bricks-cloud/bricksllm/internal/key"
 	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/tag"
 	"github.com/bricks-cloud/bricksllm/internal/util"
 
 	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
@@ -19,6 +22,7 @@ type Storage interface {
 	DeleteKey(id string) error
 	GetProviderSetting(id string) (*provider.Setting, error)
 	GetProviderSettings(withSecret bool, ids []string) ([]*provider.Setting, error)
+	GetTags() ([]*tag.Tag, error)
 }
 
 type Encrypter interface {
@@ -36,7 +40,25 @@ func NewManager(s Storage) *Manager {
 }
 
 func (m *Manager) GetKeys(tags, keyIds []string, provider string) ([]*key.ResponseKey, error) {
-	return m.s.GetKeys(tags, keyIds, provider)
+	keys, err := m.s.GetKeys(tags, keyIds, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, k := range keys {
+		redactKey(k)
+	}
+
+	return keys, nil
+}
+
+// redactKey hides the api keys of the stream guardrails of a key.
+func redactKey(k *key.ResponseKey) *key.ResponseKey {
+	if k != nil {
+		k.StreamGuardrails = k.StreamGuardrails.Redacted()
+	}
+
+	return k
 }
 
 func (m *Manager) areProviderSettingsUniqueness(settings []*provider.Setting) bool {
@@ -53,70 +75,185 @@ func (m *Manager) areProviderSettingsUniqueness(settings []*provider.Setting) bo
 	return true
 }
 
+func (m *Manager) areProviderSettingsOfTenant(tenantId string, settings []*provider.Setting) bool {
+	for _, setting := range settings {
+		if setting.TenantId != tenantId {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validateTags rejects tags that are not part of the tag taxonomy.
+func (m *Manager) validateTags(tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	taxonomy, err := m.s.GetTags()
+	if err != nil {
+		return err
+	}
+
+	if invalid := tag.Invalid(taxonomy, tags); len(invalid) != 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("tags [%s] are not part of the tag taxonomy", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
+
 func (m *Manager) CreateKey(rk *key.RequestKey) (*key.ResponseKey, error) {
+	if err := m.prepareKey(rk); err != nil {
+		return nil, err
+	}
+
+	created, err := m.s.CreateKey(rk)
+	if err != nil {
+		return nil, err
+	}
+
+	return redactKey(created), nil
+}
+
+// prepareKey validates a key before it is created and fills in its
+// generated fields.
+func (m *Manager) prepareKey(rk *key.RequestKey) error {
 	rk.CreatedAt = time.Now().Unix()
 	rk.UpdatedAt = time.Now().Unix()
 	rk.Key = encrypter.Encrypt(rk.Key)
-	rk.KeyId = util.NewUuid()
+
+	if len(rk.KeyId) == 0 {
+		rk.KeyId = util.NewUuid()
+	} else {
+		if !util.IsValidId(rk.KeyId) {
+			return internal_errors.NewValidationError("key id can only contain up to 64 letters, digits, dots, underscores and dashes")
+		}
+
+		existing, err := m.s.GetKeys(nil, []string{rk.KeyId}, "")
+		if err != nil {
+			return err
+		}
+
+		if len(existing) != 0 {
+			return internal_errors.NewConflictError("key already exists: " + rk.KeyId)
+		}
+	}
 
 	if err := rk.Validate(); err != nil {
-		return nil, err
+		return err
+	}
+
+	rk.StreamGuardrails.SetDefaults()
+
+	if err := m.validateTags(rk.Tags); err != nil {
+		return err
 	}
 
 	if len(rk.SettingId) != 0 {
-		if _, err := m.s.GetProviderSetting(rk.SettingId); err != nil {
-			return nil, err
+		setting, err := m.s.GetProviderSetting(rk.SettingId)
+		if err != nil {
+			return err
+		}
+
+		if setting.TenantId != rk.TenantId {
+			return internal_errors.NewNotFoundError("provider setting is not found for: " + rk.SettingId)
 		}
 	}
 
 	if len(rk.SettingIds) != 0 {
 		existing, err := m.s.GetProviderSettings(false, rk.SettingIds)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		if len(existing) == 0 {
-			return nil, errors.New("provider settings not found")
+			return errors.New("provider settings not found")
 		}
 
 		if !m.areProviderSettingsUniqueness(existing) {
-			return nil, internal_errors.NewValidationError("key can only be assoicated with one setting per provider")
+			return internal_errors.NewValidationError("key can only be assoicated with one setting per provider")
 		}
 
+		if !m.areProviderSettingsOfTenant(rk.TenantId, existing) {
+			return errors.New("provider settings not found")
+		}
 	}
 
-	return m.s.CreateKey(rk)
+	return nil
 }
 
 func (m *Manager) UpdateKey(id string, uk *key.UpdateKey) (*key.ResponseKey, error) {
+	if err := m.prepareKeyUpdate(id, uk); err != nil {
+		return nil, err
+	}
+
+	updated, err := m.s.UpdateKey(id, uk)
+	if err != nil {
+		return nil, err
+	}
+
+	return redactKey(updated), nil
+}
+
+func (m *Manager) prepareKeyUpdate(id string, uk *key.UpdateKey) error {
 	uk.UpdatedAt = time.Now().Unix()
 
 	if err := uk.Validate(); err != nil {
-		return nil, err
+		return err
+	}
+
+	uk.StreamGuardrails.SetDefaults()
+
+	if err := m.validateTags(uk.Tags); err != nil {
+		return err
+	}
+
+	tenantId := ""
+	if len(uk.SettingId) != 0 || len(uk.SettingIds) != 0 {
+		existing, err := m.s.GetKeys(nil, []string{id}, "")
+		if err != nil {
+			return err
+		}
+
+		if len(existing) == 0 {
+			return internal_errors.NewNotFoundError("key is not found for: " + id)
+		}
+
+		tenantId = existing[0].TenantId
 	}
 
 	if len(uk.SettingId) != 0 {
-		if _, err := m.s.GetProviderSetting(uk.SettingId); err != nil {
-			return nil, err
+		setting, err := m.s.GetProviderSetting(uk.SettingId)
+		if err != nil {
+			return err
+		}
+
+		if setting.TenantId != tenantId {
+			return internal_errors.NewNotFoundError("provider setting is not found for: " + uk.SettingId)
 		}
 	}
 
 	if len(uk.SettingIds) != 0 {
 		existing, err := m.s.GetProviderSettings(false, uk.SettingIds)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		if len(existing) == 0 {
-			return nil, errors.New("provider settings not found")
+			return errors.New("provider settings not found")
 		}
 
 		if !m.areProviderSettingsUniqueness(existing) {
-			return nil, internal_errors.NewValidationError("key can only be assoicated with one setting per provider")
+			return internal_errors.NewValidationError("key can only be assoicated with one setting per provider")
+		}
+
+		if !m.areProviderSettingsOfTenant(tenantId, existing) {
+			return errors.New("provider settings not found")
 		}
 	}
 
-	return m.s.UpdateKey(id, uk)
+	return nil
 }
 
 func (m *Manager) DeleteKey(id string) error {
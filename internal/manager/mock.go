@@ -0,0 +1,65 @@
+package manager
+
+import (
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/mock"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+)
+
+type MockResponsesStorage interface {
+	CreateMockResponse(r *mock.Response) (*mock.Response, error)
+	GetMockResponses() ([]*mock.Response, error)
+	UpdateMockResponse(id string, ur *mock.UpdateResponse) (*mock.Response, error)
+	DeleteMockResponse(id string) error
+}
+
+type MockManager struct {
+	s MockResponsesStorage
+}
+
+func NewMockManager(s MockResponsesStorage) *MockManager {
+	return &MockManager{
+		s: s,
+	}
+}
+
+func (m *MockManager) CreateMockResponse(r *mock.Response) (*mock.Response, error) {
+	r.Id = util.NewUuid()
+	r.CreatedAt = time.Now().Unix()
+	r.UpdatedAt = time.Now().Unix()
+
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	created, err := m.s.CreateMockResponse(r)
+	if err != nil {
+		if _, ok := err.(duplicationError); ok {
+			return nil, internal_errors.NewValidationError(err.Error())
+		}
+
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (m *MockManager) GetMockResponses() ([]*mock.Response, error) {
+	return m.s.GetMockResponses()
+}
+
+func (m *MockManager) UpdateMockResponse(id string, ur *mock.UpdateResponse) (*mock.Response, error) {
+	ur.UpdatedAt = time.Now().Unix()
+
+	if err := ur.Validate(); err != nil {
+		return nil, err
+	}
+
+	return m.s.UpdateMockResponse(id, ur)
+}
+
+func (m *MockManager) DeleteMockResponse(id string) error {
+	return m.s.DeleteMockResponse(id)
+}
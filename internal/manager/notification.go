@@ -0,0 +1,109 @@
+package manager
+
+import (
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/notification"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+)
+
+type IntegrationsStorage interface {
+	CreateIntegration(i *notification.Integration) (*notification.Integration, error)
+	GetIntegrations() ([]*notification.Integration, error)
+	GetIntegration(id string) (*notification.Integration, error)
+	UpdateIntegration(i *notification.Integration) (*notification.Integration, error)
+	DeleteIntegration(id string) error
+}
+
+type integrationSender interface {
+	Send(i *notification.Integration, event, subject, text string) error
+}
+
+type NotificationManager struct {
+	s  IntegrationsStorage
+	is integrationSender
+}
+
+func NewNotificationManager(s IntegrationsStorage, is integrationSender) *NotificationManager {
+	return &NotificationManager{
+		s:  s,
+		is: is,
+	}
+}
+
+func (m *NotificationManager) CreateIntegration(i *notification.Integration) (*notification.Integration, error) {
+	i.Id = util.NewUuid()
+	i.CreatedAt = time.Now().Unix()
+	i.UpdatedAt = time.Now().Unix()
+
+	if err := i.Validate(); err != nil {
+		return nil, err
+	}
+
+	created, err := m.s.CreateIntegration(i)
+	if err != nil {
+		return nil, err
+	}
+
+	return created.Redacted(), nil
+}
+
+func (m *NotificationManager) GetIntegrations() ([]*notification.Integration, error) {
+	integrations, err := m.s.GetIntegrations()
+	if err != nil {
+		return nil, err
+	}
+
+	redacted := []*notification.Integration{}
+	for _, i := range integrations {
+		redacted = append(redacted, i.Redacted())
+	}
+
+	return redacted, nil
+}
+
+func (m *NotificationManager) UpdateIntegration(id string, ui *notification.UpdateIntegration) (*notification.Integration, error) {
+	i, err := m.s.GetIntegration(id)
+	if err != nil {
+		return nil, err
+	}
+
+	ui.Apply(i)
+	i.UpdatedAt = time.Now().Unix()
+
+	if err := i.Validate(); err != nil {
+		return nil, err
+	}
+
+	updated, err := m.s.UpdateIntegration(i)
+	if err != nil {
+		return nil, err
+	}
+
+	return updated.Redacted(), nil
+}
+
+func (m *NotificationManager) DeleteIntegration(id string) error {
+	return m.s.DeleteIntegration(id)
+}
+
+// TestIntegration sends a test notification so that the delivery of an
+// integration can be checked before an incident.
+func (m *NotificationManager) TestIntegration(id string) error {
+	i, err := m.s.GetIntegration(id)
+	if err != nil {
+		return err
+	}
+
+	event := "test"
+	if len(i.Events) != 0 {
+		event = i.Events[0]
+	}
+
+	if err := m.is.Send(i, event, "BricksLLM test notification", "This is a test notification of integration "+i.Id+"."); err != nil {
+		return internal_errors.NewUnavailableError("test notification could not be delivered: " + err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package manager
+
+import (
+	"fmt"
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/passthrough"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+)
+
+type PassThroughsStorage interface {
+	CreatePassThrough(p *passthrough.PassThrough) (*passthrough.PassThrough, error)
+	GetPassThroughs() ([]*passthrough.PassThrough, error)
+	UpdatePassThrough(id string, up *passthrough.UpdatePassThrough) (*passthrough.PassThrough, error)
+	DeletePassThrough(id string) error
+}
+
+type PassThroughManager struct {
+	s PassThroughsStorage
+}
+
+func NewPassThroughManager(s PassThroughsStorage) *PassThroughManager {
+	return &PassThroughManager{
+		s: s,
+	}
+}
+
+func (m *PassThroughManager) CreatePassThrough(p *passthrough.PassThrough) (*passthrough.PassThrough, error) {
+	p.Id = util.NewUuid()
+	p.CreatedAt = time.Now().Unix()
+	p.UpdatedAt = time.Now().Unix()
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	existing, err := m.s.GetPassThroughs()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range existing {
+		if e.Provider == p.Provider {
+			return nil, internal_errors.NewValidationError(fmt.Sprintf("pass-through of provider %s already exists", p.Provider))
+		}
+	}
+
+	return m.s.CreatePassThrough(p)
+}
+
+func (m *PassThroughManager) GetPassThroughs() ([]*passthrough.PassThrough, error) {
+	return m.s.GetPassThroughs()
+}
+
+func (m *PassThroughManager) UpdatePassThrough(id string, up *passthrough.UpdatePassThrough) (*passthrough.PassThrough, error) {
+	up.UpdatedAt = time.Now().Unix()
+
+	if err := up.Validate(); err != nil {
+		return nil, err
+	}
+
+	return m.s.UpdatePassThrough(id, up)
+}
+
+func (m *PassThroughManager) DeletePassThrough(id string) error {
+	return m.s.DeletePassThrough(id)
+}
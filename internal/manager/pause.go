@@ -0,0 +1,86 @@
+package manager
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+
+	"github.com/bricks-cloud/bricksllm/internal/pause"
+)
+
+type PausesStorage interface {
+	SetPause(p *pause.Pause) error
+	DeletePause(id string) error
+	GetPauses() ([]*pause.Pause, error)
+}
+
+type PauseManager struct {
+	s  PausesStorage
+	rs routeStorage
+}
+
+func NewPauseManager(s PausesStorage, rs routeStorage) *PauseManager {
+	return &PauseManager{
+		s:  s,
+		rs: rs,
+	}
+}
+
+func (m *PauseManager) Pause(scope, target, reason string, mt *pause.Maintenance) (*pause.Pause, error) {
+	if mt != nil {
+		if invalid := mt.Validate(); len(invalid) != 0 {
+			return nil, internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+		}
+	}
+
+	p := &pause.Pause{
+		Scope:       scope,
+		Target:      target,
+		Reason:      reason,
+		Maintenance: mt,
+		CreatedAt:   time.Now().Unix(),
+	}
+
+	if scope == pause.ScopeRoute {
+		r, err := m.rs.GetRoute(target)
+		if err != nil {
+			return nil, err
+		}
+
+		p.TenantId = r.TenantId
+	}
+
+	if err := m.s.SetPause(p); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (m *PauseManager) Resume(scope, target string) error {
+	return m.s.DeletePause(pause.Id(scope, target))
+}
+
+// GetPauses returns the pauses affecting a tenant, which are global and
+// provider pauses along with pauses of its own routes.
+func (m *PauseManager) GetPauses(tenantId string) ([]*pause.Pause, error) {
+	pauses, err := m.s.GetPauses()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tenantId) == 0 {
+		return pauses, nil
+	}
+
+	filtered := []*pause.Pause{}
+	for _, p := range pauses {
+		if p.Scope != pause.ScopeRoute || p.TenantId == tenantId {
+			filtered = append(filtered, p)
+		}
+	}
+
+	return filtered, nil
+}
@@ -0,0 +1,88 @@
+package manager
+
+import (
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+)
+
+type PoliciesStorage interface {
+	CreatePolicy(p *policy.Policy) (*policy.Policy, error)
+	GetPolicy(id string) (*policy.Policy, error)
+	GetPolicies() ([]*policy.Policy, error)
+	UpdatePolicy(id string, up *policy.UpdatePolicy) (*policy.Policy, error)
+	DeletePolicy(id string) error
+}
+
+type PolicyManager struct {
+	s PoliciesStorage
+}
+
+func NewPolicyManager(s PoliciesStorage) *PolicyManager {
+	return &PolicyManager{
+		s: s,
+	}
+}
+
+func (m *PolicyManager) CreatePolicy(p *policy.Policy) (*policy.Policy, error) {
+	p.Id = util.NewUuid()
+	p.CreatedAt = time.Now().Unix()
+	p.UpdatedAt = time.Now().Unix()
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	return m.s.CreatePolicy(p)
+}
+
+func (m *PolicyManager) GetPolicies() ([]*policy.Policy, error) {
+	return m.s.GetPolicies()
+}
+
+func (m *PolicyManager) UpdatePolicy(id string, up *policy.UpdatePolicy) (*policy.Policy, error) {
+	up.UpdatedAt = time.Now().Unix()
+
+	if err := up.Validate(); err != nil {
+		return nil, err
+	}
+
+	existing, err := m.s.GetPolicy(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// the action of a policy has to stay consistent with its model and
+	// max tokens, so the update is validated as a whole.
+	merged := *existing
+	if up.Name != nil {
+		merged.Name = *up.Name
+	}
+
+	if up.Expression != nil {
+		merged.Expression = *up.Expression
+	}
+
+	if up.Action != nil {
+		merged.Action = *up.Action
+	}
+
+	if up.Model != nil {
+		merged.Model = *up.Model
+	}
+
+	if up.MaxTokens != nil {
+		merged.MaxTokens = *up.MaxTokens
+	}
+
+	if err := merged.Validate(); err != nil {
+		return nil, err
+	}
+
+	return m.s.UpdatePolicy(id, up)
+}
+
+func (m *PolicyManager) DeletePolicy(id string) error {
+	return m.s.DeletePolicy(id)
+}
@@ -0,0 +1,70 @@
+package manager
+
+import (
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/pricing"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+)
+
+type duplicationError interface {
+	Error() string
+	Duplication()
+}
+
+type PricesStorage interface {
+	CreatePrice(p *pricing.Price) (*pricing.Price, error)
+	GetPrices() ([]*pricing.Price, error)
+	UpdatePrice(id string, up *pricing.UpdatePrice) (*pricing.Price, error)
+	DeletePrice(id string) error
+}
+
+type PricingManager struct {
+	s PricesStorage
+}
+
+func NewPricingManager(s PricesStorage) *PricingManager {
+	return &PricingManager{
+		s: s,
+	}
+}
+
+func (m *PricingManager) CreatePrice(p *pricing.Price) (*pricing.Price, error) {
+	p.Id = util.NewUuid()
+	p.CreatedAt = time.Now().Unix()
+	p.UpdatedAt = time.Now().Unix()
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	created, err := m.s.CreatePrice(p)
+	if err != nil {
+		if _, ok := err.(duplicationError); ok {
+			return nil, internal_errors.NewValidationError(err.Error())
+		}
+
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (m *PricingManager) GetPrices() ([]*pricing.Price, error) {
+	return m.s.GetPrices()
+}
+
+func (m *PricingManager) UpdatePrice(id string, up *pricing.UpdatePrice) (*pricing.Price, error) {
+	up.UpdatedAt = time.Now().Unix()
+
+	if err := up.Validate(); err != nil {
+		return nil, err
+	}
+
+	return m.s.UpdatePrice(id, up)
+}
+
+func (m *PricingManager) DeletePrice(id string) error {
+	return m.s.DeletePrice(id)
+}
@@ -0,0 +1,124 @@
+package manager
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/probe"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+)
+
+type ProbesStorage interface {
+	CreateProbe(p *probe.Probe) (*probe.Probe, error)
+	GetProbes() ([]*probe.Probe, error)
+	GetProbe(id string) (*probe.Probe, error)
+	DeleteProbe(id string) error
+	GetProbeResults(probeId string, start, end int64, limit int) ([]*probe.Result, error)
+	GetProviderSettings(withSecret bool, ids []string) ([]*provider.Setting, error)
+	GetRoute(id string) (*route.Route, error)
+}
+
+type ProbeManager struct {
+	s ProbesStorage
+}
+
+func NewProbeManager(s ProbesStorage) *ProbeManager {
+	return &ProbeManager{
+		s: s,
+	}
+}
+
+func (m *ProbeManager) CreateProbe(p *probe.Probe) (*probe.Probe, error) {
+	p.Id = util.NewUuid()
+	p.CreatedAt = time.Now().Unix()
+	p.UpdatedAt = time.Now().Unix()
+	p.SetDefaults()
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	if p.IsRouteProbe() {
+		if err := m.validateRouteProbe(p); err != nil {
+			return nil, err
+		}
+	} else {
+		settings, err := m.s.GetProviderSettings(false, []string{p.SettingId})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(settings) == 0 {
+			return nil, internal_errors.NewValidationError("provider setting of the probe is not found")
+		}
+
+		if err := p.ValidateProvider(settings[0].Provider); err != nil {
+			return nil, err
+		}
+	}
+
+	return m.s.CreateProbe(p)
+}
+
+func (m *ProbeManager) validateRouteProbe(p *probe.Probe) error {
+	r, err := m.s.GetRoute(p.RouteId)
+	if err != nil {
+		if _, ok := err.(notFoundError); ok {
+			return internal_errors.NewValidationError("route of the probe is not found")
+		}
+
+		return err
+	}
+
+	settings, err := m.s.GetProviderSettings(false, p.SettingIds)
+	if err != nil {
+		return err
+	}
+
+	if len(settings) != len(p.SettingIds) {
+		return internal_errors.NewValidationError("provider settings of the probe are not found")
+	}
+
+	if !r.ValidateSettings(settings) {
+		return internal_errors.NewValidationError("provider settings of the probe do not cover the steps of the route")
+	}
+
+	return nil
+}
+
+func (m *ProbeManager) GetProbes() ([]*probe.Probe, error) {
+	return m.s.GetProbes()
+}
+
+func (m *ProbeManager) DeleteProbe(id string) error {
+	return m.s.DeleteProbe(id)
+}
+
+func (m *ProbeManager) GetProbeResults(probeId string, start, end int64, limit int) ([]*probe.Result, error) {
+	invalid := []string{}
+	if start <= 0 {
+		invalid = append(invalid, "start")
+	}
+
+	if end <= 0 || end < start {
+		invalid = append(invalid, "end")
+	}
+
+	if limit <= 0 || limit > 1000 {
+		invalid = append(invalid, "limit")
+	}
+
+	if len(invalid) > 0 {
+		return nil, internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	if _, err := m.s.GetProbe(probeId); err != nil {
+		return nil, err
+	}
+
+	return m.s.GetProbeResults(probeId, start, end, limit)
+}
@@ -0,0 +1,128 @@
+package manager
+
+import (
+	"fmt"
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/prompt"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+)
+
+type PromptTemplatesStorage interface {
+	CreatePromptTemplate(t *prompt.Template) (*prompt.Template, error)
+	GetPromptTemplates() ([]*prompt.Template, error)
+	GetPromptTemplateVersions(name string) ([]*prompt.Template, error)
+	GetPromptTemplate(name string, version int) (*prompt.Template, error)
+	DeletePromptTemplate(name string) error
+	GetRoutes() ([]*route.Route, error)
+}
+
+type PromptTemplateManager struct {
+	s PromptTemplatesStorage
+}
+
+func NewPromptTemplateManager(s PromptTemplatesStorage) *PromptTemplateManager {
+	return &PromptTemplateManager{
+		s: s,
+	}
+}
+
+func withVariables(t *prompt.Template) *prompt.TemplateWithVariables {
+	return &prompt.TemplateWithVariables{
+		Template:  t,
+		Variables: t.Variables(),
+	}
+}
+
+// CreatePromptTemplate stores a new version of the template. The first version
+// of a name creates the template.
+func (m *PromptTemplateManager) CreatePromptTemplate(t *prompt.Template) (*prompt.TemplateWithVariables, error) {
+	t.Id = util.NewUuid()
+	t.CreatedAt = time.Now().Unix()
+
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+
+	created, err := m.s.CreatePromptTemplate(t)
+	if err != nil {
+		if _, ok := err.(duplicationError); ok {
+			return nil, internal_errors.NewValidationError(err.Error())
+		}
+
+		return nil, err
+	}
+
+	return withVariables(created), nil
+}
+
+// GetPromptTemplates returns the latest version of every template.
+func (m *PromptTemplateManager) GetPromptTemplates() ([]*prompt.TemplateWithVariables, error) {
+	templates, err := m.s.GetPromptTemplates()
+	if err != nil {
+		return nil, err
+	}
+
+	latest := map[string]*prompt.Template{}
+	names := []string{}
+	for _, t := range templates {
+		if _, ok := latest[t.Name]; !ok {
+			names = append(names, t.Name)
+		}
+
+		latest[t.Name] = t
+	}
+
+	result := []*prompt.TemplateWithVariables{}
+	for _, name := range names {
+		result = append(result, withVariables(latest[name]))
+	}
+
+	return result, nil
+}
+
+func (m *PromptTemplateManager) GetPromptTemplateVersions(name string) ([]*prompt.TemplateWithVariables, error) {
+	templates, err := m.s.GetPromptTemplateVersions(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(templates) == 0 {
+		return nil, internal_errors.NewNotFoundError(fmt.Sprintf("prompt template %s is not found", name))
+	}
+
+	result := []*prompt.TemplateWithVariables{}
+	for _, t := range templates {
+		result = append(result, withVariables(t))
+	}
+
+	return result, nil
+}
+
+func (m *PromptTemplateManager) GetPromptTemplate(name string, version int) (*prompt.TemplateWithVariables, error) {
+	t, err := m.s.GetPromptTemplate(name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return withVariables(t), nil
+}
+
+// DeletePromptTemplate removes every version of a template. Templates that
+// are still referenced by routes cannot be deleted.
+func (m *PromptTemplateManager) DeletePromptTemplate(name string) error {
+	routes, err := m.s.GetRoutes()
+	if err != nil {
+		return err
+	}
+
+	for _, r := range routes {
+		if r.PromptTemplate != nil && r.PromptTemplate.Name == name {
+			return internal_errors.NewValidationError(fmt.Sprintf("prompt template %s is used by route %s", name, r.Path))
+		}
+	}
+
+	return m.s.DeletePromptTemplate(name)
+}
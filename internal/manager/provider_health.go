@@ -0,0 +1,169 @@
+package manager
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/digest"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"go.uber.org/zap"
+)
+
+type ProviderHealthStorage interface {
+	IncrementAuthFailures(settingId string) (int64, error)
+	ResetAuthFailures(settingId string) error
+	GetAuthFailures(settingIds []string) (map[string]int64, error)
+	MarkUnhealthy(h *provider.Health) (bool, error)
+	DeleteUnhealthy(settingId string) error
+	GetUnhealthy() ([]*provider.Health, error)
+}
+
+// ProviderHealthManager marks provider settings unhealthy after consecutive
+// upstream authentication failures so that requests stop being sent with
+// revoked credentials. It also alerts when a provider setting keeps
+// responding with server errors, which this instance counts on its own.
+type ProviderHealthManager struct {
+	s               ProviderHealthStorage
+	senders         []digest.Sender
+	threshold       int64
+	outageSenders   []digest.Sender
+	outageThreshold int64
+	serverErrors    map[string]int64
+	outages         map[string]bool
+	lock            sync.Mutex
+	log             *zap.Logger
+}
+
+func NewProviderHealthManager(s ProviderHealthStorage, senders []digest.Sender, threshold int, outageSenders []digest.Sender, outageThreshold int, log *zap.Logger) *ProviderHealthManager {
+	return &ProviderHealthManager{
+		s:               s,
+		senders:         senders,
+		threshold:       int64(threshold),
+		outageSenders:   outageSenders,
+		outageThreshold: int64(outageThreshold),
+		serverErrors:    map[string]int64{},
+		outages:         map[string]bool{},
+		log:             log,
+	}
+}
+
+// recordOutage counts consecutive server errors of a setting. An alert is
+// sent when the count reaches the threshold and when the setting responds
+// successfully again.
+func (m *ProviderHealthManager) recordOutage(setting *provider.Setting, status int) {
+	if m.outageThreshold <= 0 {
+		return
+	}
+
+	m.lock.Lock()
+	started, recovered := false, false
+	if status >= 500 {
+		m.serverErrors[setting.Id]++
+		if m.serverErrors[setting.Id] >= m.outageThreshold && !m.outages[setting.Id] {
+			m.outages[setting.Id] = true
+			started = true
+		}
+	} else if status >= 200 && status < 300 {
+		delete(m.serverErrors, setting.Id)
+		if m.outages[setting.Id] {
+			delete(m.outages, setting.Id)
+			recovered = true
+		}
+	}
+	failures := m.serverErrors[setting.Id]
+	m.lock.Unlock()
+
+	name := setting.Id
+	if len(setting.Name) != 0 {
+		name = fmt.Sprintf("%s (%s)", setting.Name, setting.Id)
+	}
+
+	if started {
+		stats.Incr("bricksllm.manager.provider_health_manager.record_outage.outage_started", []string{"provider:" + setting.Provider}, 1)
+		m.log.Sugar().Warnf("provider setting %s responded with %d consecutive server errors", setting.Id, failures)
+
+		go m.send(m.outageSenders, fmt.Sprintf("BricksLLM provider %s is failing", setting.Provider), fmt.Sprintf("Provider setting %s of %s responded with %d consecutive server errors, last with status %d.", name, setting.Provider, failures, status))
+	}
+
+	if recovered {
+		stats.Incr("bricksllm.manager.provider_health_manager.record_outage.outage_recovered", []string{"provider:" + setting.Provider}, 1)
+		m.log.Sugar().Infof("provider setting %s recovered from server errors", setting.Id)
+
+		go m.send(m.outageSenders, fmt.Sprintf("BricksLLM provider %s recovered", setting.Provider), fmt.Sprintf("Provider setting %s of %s is responding successfully again.", name, setting.Provider))
+	}
+}
+
+// RecordStatus records the status of an upstream response sent with the
+// setting. Statuses other than authentication failures end the streak.
+func (m *ProviderHealthManager) RecordStatus(setting *provider.Setting, status int) error {
+	m.recordOutage(setting, status)
+
+	if m.threshold <= 0 {
+		return nil
+	}
+
+	if !provider.IsAuthFailure(status) {
+		if status >= 200 && status < 300 {
+			return m.s.ResetAuthFailures(setting.Id)
+		}
+
+		return nil
+	}
+
+	failures, err := m.s.IncrementAuthFailures(setting.Id)
+	if err != nil {
+		return err
+	}
+
+	if failures < m.threshold {
+		return nil
+	}
+
+	h := &provider.Health{
+		SettingId:               setting.Id,
+		Provider:                setting.Provider,
+		TenantId:                setting.TenantId,
+		ConsecutiveAuthFailures: failures,
+		Unhealthy:               true,
+		LastStatus:              status,
+		UnhealthySince:          time.Now().Unix(),
+	}
+
+	marked, err := m.s.MarkUnhealthy(h)
+	if err != nil {
+		return err
+	}
+
+	if marked {
+		stats.Incr("bricksllm.manager.provider_health_manager.record_status.marked_unhealthy", []string{"provider:" + setting.Provider}, 1)
+		m.log.Sugar().Warnf("provider setting %s is marked unhealthy after %d consecutive authentication failures", setting.Id, failures)
+
+		go m.alert(setting, h)
+	}
+
+	return nil
+}
+
+func (m *ProviderHealthManager) alert(setting *provider.Setting, h *provider.Health) {
+	name := setting.Id
+	if len(setting.Name) != 0 {
+		name = fmt.Sprintf("%s (%s)", setting.Name, setting.Id)
+	}
+
+	subject := fmt.Sprintf("BricksLLM provider setting %s is unhealthy", name)
+	text := fmt.Sprintf("Provider setting %s of %s received %d consecutive authentication failures, last with status %d.\nIt is no longer used for requests until it is updated or its health is reset.", name, h.Provider, h.ConsecutiveAuthFailures, h.LastStatus)
+
+	m.send(m.senders, subject, text)
+}
+
+func (m *ProviderHealthManager) send(senders []digest.Sender, subject, text string) {
+	for _, s := range senders {
+		if err := s.Send(subject, text); err != nil {
+			stats.Incr("bricksllm.manager.provider_health_manager.alert.send_error", nil, 1)
+
+			m.log.Sugar().Debugf("error when sending provider health alert: %v", err)
+		}
+	}
+}
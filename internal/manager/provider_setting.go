@@ -2,11 +2,14 @@ package manager
 
 import (
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
 
 	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/mock"
 	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/provider/azure"
 	"github.com/bricks-cloud/bricksllm/internal/provider/custom"
 	"github.com/bricks-cloud/bricksllm/internal/util"
 )
@@ -24,15 +27,23 @@ type ProviderSettingsMemStorage interface {
 	GetSettings(ids []string) []*provider.Setting
 }
 
+type ProviderQuotaStorage interface {
+	GetQuota(settingId string) (*provider.Quota, error)
+}
+
 type ProviderSettingsManager struct {
 	Storage ProviderSettingsStorage
 	MemDb   ProviderSettingsMemStorage
+	Quotas  ProviderQuotaStorage
+	Health  ProviderHealthStorage
 }
 
-func NewProviderSettingsManager(s ProviderSettingsStorage, memdb ProviderSettingsMemStorage) *ProviderSettingsManager {
+func NewProviderSettingsManager(s ProviderSettingsStorage, memdb ProviderSettingsMemStorage, qs ProviderQuotaStorage, hs ProviderHealthStorage) *ProviderSettingsManager {
 	return &ProviderSettingsManager{
 		Storage: s,
 		MemDb:   memdb,
+		Quotas:  qs,
+		Health:  hs,
 	}
 }
 
@@ -44,9 +55,8 @@ func findMissingAuthParams(providerName string, params map[string]string) string
 	missingFields := []string{}
 
 	if providerName == "openai" || providerName == "anthropic" {
-		val, _ := params["apikey"]
-		if len(val) == 0 {
-			missingFields = append(missingFields, "apikey")
+		if len(params[provider.ApiKeyParam]) == 0 && len(params[provider.ApiKeysParam]) == 0 {
+			missingFields = append(missingFields, provider.ApiKeyParam)
 		}
 
 		return strings.Join(missingFields, " ,")
@@ -58,9 +68,9 @@ func findMissingAuthParams(providerName string, params map[string]string) string
 			missingFields = append(missingFields, "resourceName")
 		}
 
-		val, _ = params["apikey"]
-		if len(val) == 0 {
-			missingFields = append(missingFields, "apikey")
+		missing, err := azure.FindMissingAuthParams(params)
+		if err == nil {
+			missingFields = append(missingFields, missing...)
 		}
 	}
 
@@ -68,6 +78,10 @@ func findMissingAuthParams(providerName string, params map[string]string) string
 }
 
 func (m *ProviderSettingsManager) validateSettings(providerName string, setting map[string]string) error {
+	if providerName == mock.ProviderName {
+		return nil
+	}
+
 	if !isProviderNativelySupported(providerName) {
 		provider, err := m.Storage.GetCustomProviderByName(providerName)
 		_, ok := err.(notFoundError)
@@ -83,11 +97,31 @@ func (m *ProviderSettingsManager) validateSettings(providerName string, setting
 		}
 	}
 
+	if providerName == "azure" {
+		if _, err := azure.FindMissingAuthParams(setting); err != nil {
+			return internal_errors.NewValidationError(fmt.Sprintf("provider %s field %s", providerName, err.Error()))
+		}
+	}
+
 	missing := findMissingAuthParams(providerName, setting)
 	if len(missing) != 0 {
 		return internal_errors.NewValidationError(fmt.Sprintf("provider %s is missing fields %s", providerName, missing))
 	}
 
+	if rotation, ok := setting[provider.KeyRotationParam]; ok && rotation != provider.KeyRotationRoundRobin && rotation != provider.KeyRotationOnRateLimit {
+		return internal_errors.NewValidationError(fmt.Sprintf("provider %s field %s must be %s or %s", providerName, provider.KeyRotationParam, provider.KeyRotationRoundRobin, provider.KeyRotationOnRateLimit))
+	}
+
+	if strip, ok := setting[provider.OpenAiStripClientHeadersParam]; ok && providerName == "openai" && strip != "true" && strip != "false" {
+		return internal_errors.NewValidationError(fmt.Sprintf("provider %s field %s must be true or false", providerName, provider.OpenAiStripClientHeadersParam))
+	}
+
+	if exportUrl, ok := setting[provider.AzureCostExportUrlParam]; ok && providerName == "azure" {
+		if parsed, err := url.Parse(exportUrl); err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || len(parsed.Host) == 0 {
+			return internal_errors.NewValidationError(fmt.Sprintf("provider %s field %s must be a http or https url", providerName, provider.AzureCostExportUrlParam))
+		}
+	}
+
 	return nil
 }
 
@@ -100,11 +134,39 @@ func (m *ProviderSettingsManager) CreateSetting(setting *provider.Setting) (*pro
 		return nil, err
 	}
 
-	setting.Id = util.NewUuid()
+	if setting.Egress != nil {
+		if err := setting.Egress.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(setting.Region) != 0 && !util.IsValidId(setting.Region) {
+		return nil, internal_errors.NewValidationError("provider setting region can only contain up to 64 letters, digits, dots, underscores and dashes")
+	}
+
+	if len(setting.Id) == 0 {
+		setting.Id = util.NewUuid()
+	} else {
+		if !util.IsValidId(setting.Id) {
+			return nil, internal_errors.NewValidationError("provider setting id can only contain up to 64 letters, digits, dots, underscores and dashes")
+		}
+
+		if existing, _ := m.Storage.GetProviderSetting(setting.Id); existing != nil {
+			return nil, internal_errors.NewConflictError("provider setting already exists: " + setting.Id)
+		}
+	}
+
 	setting.CreatedAt = time.Now().Unix()
 	setting.UpdatedAt = time.Now().Unix()
 
-	return m.Storage.CreateProviderSetting(setting)
+	created, err := m.Storage.CreateProviderSetting(setting)
+	if err != nil {
+		return nil, err
+	}
+
+	created.Egress = created.Egress.Redacted()
+
+	return created, nil
 }
 
 func (m *ProviderSettingsManager) UpdateSetting(id string, setting *provider.UpdateSetting) (*provider.Setting, error) {
@@ -123,9 +185,34 @@ func (m *ProviderSettingsManager) UpdateSetting(id string, setting *provider.Upd
 		}
 	}
 
+	if setting.Egress != nil {
+		if err := setting.Egress.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	// an empty region removes the existing one.
+	if setting.Region != nil && len(*setting.Region) != 0 && !util.IsValidId(*setting.Region) {
+		return nil, internal_errors.NewValidationError("provider setting region can only contain up to 64 letters, digits, dots, underscores and dashes")
+	}
+
 	setting.UpdatedAt = time.Now().Unix()
 
-	return m.Storage.UpdateProviderSetting(id, setting)
+	updated, err := m.Storage.UpdateProviderSetting(id, setting)
+	if err != nil {
+		return nil, err
+	}
+
+	// new credentials get a fresh start instead of staying disabled.
+	if len(setting.Setting) != 0 {
+		if err := m.ResetHealth(id); err != nil {
+			return nil, err
+		}
+	}
+
+	updated.Egress = updated.Egress.Redacted()
+
+	return updated, nil
 }
 
 func (m *ProviderSettingsManager) GetSetting(id string) (*provider.Setting, error) {
@@ -138,6 +225,94 @@ func (m *ProviderSettingsManager) GetSetting(id string) (*provider.Setting, erro
 	return setting, nil
 }
 
+func (m *ProviderSettingsManager) GetQuota(id string) (*provider.Quota, error) {
+	if _, err := m.GetSetting(id); err != nil {
+		return nil, err
+	}
+
+	q, err := m.Quotas.GetQuota(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if q == nil {
+		return nil, internal_errors.NewNotFoundError("provider quota has not been reported for this setting yet")
+	}
+
+	return q, nil
+}
+
+// GetHealths reports the health of the given settings or of every setting if
+// no ids are given.
+func (m *ProviderSettingsManager) GetHealths(ids []string) ([]*provider.Health, error) {
+	settings, err := m.Storage.GetProviderSettings(false, ids)
+	if err != nil {
+		return nil, internal_errors.NewNotFoundError("provider setting is not found")
+	}
+
+	settingIds := []string{}
+	for _, setting := range settings {
+		settingIds = append(settingIds, setting.Id)
+	}
+
+	failures, err := m.Health.GetAuthFailures(settingIds)
+	if err != nil {
+		return nil, err
+	}
+
+	unhealthy, err := m.Health.GetUnhealthy()
+	if err != nil {
+		return nil, err
+	}
+
+	marked := map[string]*provider.Health{}
+	for _, h := range unhealthy {
+		marked[h.SettingId] = h
+	}
+
+	healths := []*provider.Health{}
+	for _, setting := range settings {
+		h := &provider.Health{
+			SettingId:               setting.Id,
+			Provider:                setting.Provider,
+			TenantId:                setting.TenantId,
+			ConsecutiveAuthFailures: failures[setting.Id],
+		}
+
+		if mh, ok := marked[setting.Id]; ok {
+			h.Unhealthy = true
+			h.LastStatus = mh.LastStatus
+			h.UnhealthySince = mh.UnhealthySince
+		}
+
+		healths = append(healths, h)
+	}
+
+	return healths, nil
+}
+
+func (m *ProviderSettingsManager) GetHealth(id string) (*provider.Health, error) {
+	healths, err := m.GetHealths([]string{id})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(healths) == 0 {
+		return nil, internal_errors.NewNotFoundError("provider setting is not found")
+	}
+
+	return healths[0], nil
+}
+
+// ResetHealth puts an unhealthy setting back into use.
+func (m *ProviderSettingsManager) ResetHealth(id string) error {
+	if err := m.Health.ResetAuthFailures(id); err != nil {
+		return err
+	}
+
+	return m.Health.DeleteUnhealthy(id)
+}
+
 func (m *ProviderSettingsManager) GetSettings(ids []string) ([]*provider.Setting, error) {
 	settings, err := m.Storage.GetProviderSettings(false, ids)
 	if err != nil {
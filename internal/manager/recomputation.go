@@ -0,0 +1,291 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/event"
+)
+
+const recomputationBatchSize = 1000
+
+type recomputationStorage interface {
+	GetEventsForRecomputation(r *event.RecomputationRequest, afterCreatedAt int64, afterId string, limit int) ([]*event.Event, error)
+	UpdateEventCosts(ids []string, costs []float64) error
+	GetAggregatedEventDataPoints(r *event.AggregationRequest) ([]*event.AggregatedDataPoint, error)
+}
+
+type embeddingsCostEstimator interface {
+	EstimatePromptCost(model string, tks int) (float64, error)
+	EstimateCompletionCost(model string, tks int) (float64, error)
+	EstimateEmbeddingsInputCost(model string, tks int) (float64, error)
+}
+
+type RecomputationManager struct {
+	s   recomputationStorage
+	oe  embeddingsCostEstimator
+	aoe embeddingsCostEstimator
+	ae  promptCompletionEstimator
+}
+
+func NewRecomputationManager(s recomputationStorage, oe, aoe embeddingsCostEstimator, ae promptCompletionEstimator) *RecomputationManager {
+	return &RecomputationManager{
+		s:   s,
+		oe:  oe,
+		aoe: aoe,
+		ae:  ae,
+	}
+}
+
+func (m *RecomputationManager) recompute(e *event.Event) (float64, error) {
+	return m.price(e.Provider, e.Model, e.Path, e.PromptTokenCount, e.CompletionTokenCount)
+}
+
+// price estimates the cost of a request to path from its token counts.
+func (m *RecomputationManager) price(provider, model, path string, promptTks, completionTks int) (float64, error) {
+	var pe embeddingsCostEstimator
+
+	switch provider {
+	case "openai":
+		pe = m.oe
+	case "azure":
+		pe = m.aoe
+	case "anthropic":
+		if strings.HasSuffix(path, "/embeddings") {
+			return 0, errors.New("anthropic does not support embeddings")
+		}
+
+		prompt, err := m.ae.EstimatePromptCost(model, promptTks)
+		if err != nil {
+			return 0, err
+		}
+
+		completion, err := m.ae.EstimateCompletionCost(model, completionTks)
+		if err != nil {
+			return 0, err
+		}
+
+		return prompt + completion, nil
+	default:
+		return 0, errors.New("provider is not supported for recomputation")
+	}
+
+	if strings.HasSuffix(path, "/embeddings") {
+		return pe.EstimateEmbeddingsInputCost(model, promptTks)
+	}
+
+	prompt, err := pe.EstimatePromptCost(model, promptTks)
+	if err != nil {
+		return 0, err
+	}
+
+	completion, err := pe.EstimateCompletionCost(model, completionTks)
+	if err != nil {
+		return 0, err
+	}
+
+	return prompt + completion, nil
+}
+
+// Recompute re-runs cost estimation over stored events using the current
+// prices. Events that cannot be estimated, e.g. images or custom providers,
+// are skipped and keep their recorded cost.
+func (m *RecomputationManager) Recompute(r *event.RecomputationRequest) (*event.RecomputationReport, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	report := &event.RecomputationReport{
+		DryRun: r.DryRun,
+		Models: []*event.ModelCostDiff{},
+	}
+
+	diffs := map[string]*event.ModelCostDiff{}
+
+	var afterCreatedAt int64 = 0
+	afterId := ""
+	for {
+		events, err := m.s.GetEventsForRecomputation(r, afterCreatedAt, afterId, recomputationBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		ids := []string{}
+		costs := []float64{}
+		for _, e := range events {
+			report.NumberOfScanned++
+
+			diff, ok := diffs[e.Provider+"/"+e.Model]
+			if !ok {
+				diff = &event.ModelCostDiff{
+					Provider: e.Provider,
+					Model:    e.Model,
+				}
+
+				diffs[e.Provider+"/"+e.Model] = diff
+				report.Models = append(report.Models, diff)
+			}
+
+			cost, err := m.recompute(e)
+			if err != nil {
+				diff.NumberOfSkipped++
+				report.NumberOfSkipped++
+				continue
+			}
+
+			diff.NumberOfEvents++
+			diff.PreviousCostInUsd += e.CostInUsd
+			diff.RecomputedCostInUsd += cost
+			report.PreviousCostInUsd += e.CostInUsd
+			report.RecomputedCostInUsd += cost
+
+			if cost != e.CostInUsd {
+				ids = append(ids, e.Id)
+				costs = append(costs, cost)
+			}
+		}
+
+		if !r.DryRun && len(ids) != 0 {
+			if err := m.s.UpdateEventCosts(ids, costs); err != nil {
+				return nil, err
+			}
+		}
+
+		report.NumberOfUpdated += len(ids)
+
+		if len(events) < recomputationBatchSize {
+			break
+		}
+
+		last := events[len(events)-1]
+		afterCreatedAt = last.CreatedAt
+		afterId = last.Id
+	}
+
+	for _, diff := range report.Models {
+		diff.DifferenceInUsd = diff.RecomputedCostInUsd - diff.PreviousCostInUsd
+	}
+
+	report.DifferenceInUsd = report.RecomputedCostInUsd - report.PreviousCostInUsd
+
+	sort.Slice(report.Models, func(i, j int) bool {
+		return report.Models[i].DifferenceInUsd > report.Models[j].DifferenceInUsd
+	})
+
+	return report, nil
+}
+
+// Simulate re-prices the traffic of a time range under each target using the
+// current prices, without touching any event. Traffic that cannot be
+// estimated from token counts, e.g. images or custom providers, is skipped.
+func (m *RecomputationManager) Simulate(r *event.SimulationRequest) (*event.SimulationReport, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	for _, t := range r.Targets {
+		_, chatErr := m.price(t.Provider, t.Model, "/chat/completions", 0, 0)
+		_, embeddingsErr := m.price(t.Provider, t.Model, "/embeddings", 0, 0)
+		if chatErr != nil && embeddingsErr != nil {
+			return nil, internal_errors.NewValidationError(fmt.Sprintf("model %s of %s cannot be priced", t.Model, t.Provider))
+		}
+	}
+
+	dataPoints, err := m.s.GetAggregatedEventDataPoints(&event.AggregationRequest{
+		Start:     r.Start,
+		End:       r.End,
+		GroupBy:   []string{event.DimensionProvider, event.DimensionModel, event.DimensionPath},
+		KeyIds:    r.KeyIds,
+		Tags:      r.Tags,
+		Models:    r.Models,
+		Providers: r.Providers,
+		TenantId:  r.TenantId,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &event.SimulationReport{
+		Start:     r.Start,
+		End:       r.End,
+		Scenarios: []*event.SimulationScenario{},
+	}
+
+	for _, t := range r.Targets {
+		report.Scenarios = append(report.Scenarios, &event.SimulationScenario{
+			Provider: t.Provider,
+			Model:    t.Model,
+			Models:   []*event.SimulatedModelCost{},
+		})
+	}
+
+	costs := make([]map[string]*event.SimulatedModelCost, len(r.Targets))
+	for i := range costs {
+		costs[i] = map[string]*event.SimulatedModelCost{}
+	}
+
+	for _, dp := range dataPoints {
+		provider := dp.Dimensions[event.DimensionProvider]
+		model := dp.Dimensions[event.DimensionModel]
+		path := dp.Dimensions[event.DimensionPath]
+
+		report.NumberOfRequests += dp.NumberOfRequests
+		report.ActualCostInUsd += dp.CostInUsd
+
+		_, err := m.price(provider, model, path, dp.PromptTokenCount, dp.CompletionTokenCount)
+		estimable := err == nil
+
+		for i, t := range r.Targets {
+			scenario := report.Scenarios[i]
+
+			mc, ok := costs[i][provider+"/"+model]
+			if !ok {
+				mc = &event.SimulatedModelCost{
+					Provider: provider,
+					Model:    model,
+				}
+
+				costs[i][provider+"/"+model] = mc
+				scenario.Models = append(scenario.Models, mc)
+			}
+
+			simulated := 0.0
+			if estimable {
+				simulated, err = m.price(t.Provider, t.Model, path, dp.PromptTokenCount, dp.CompletionTokenCount)
+			}
+
+			if !estimable || err != nil {
+				mc.NumberOfSkipped += dp.NumberOfRequests
+				scenario.NumberOfSkipped += dp.NumberOfRequests
+				continue
+			}
+
+			mc.NumberOfRequests += dp.NumberOfRequests
+			mc.PromptTokenCount += dp.PromptTokenCount
+			mc.CompletionTokenCount += dp.CompletionTokenCount
+			mc.ActualCostInUsd += dp.CostInUsd
+			mc.SimulatedCostInUsd += simulated
+
+			scenario.NumberOfRequests += dp.NumberOfRequests
+			scenario.ActualCostInUsd += dp.CostInUsd
+			scenario.SimulatedCostInUsd += simulated
+		}
+	}
+
+	for _, scenario := range report.Scenarios {
+		scenario.DifferenceInUsd = scenario.SimulatedCostInUsd - scenario.ActualCostInUsd
+
+		sort.Slice(scenario.Models, func(i, j int) bool {
+			return scenario.Models[i].ActualCostInUsd > scenario.Models[j].ActualCostInUsd
+		})
+	}
+
+	sort.SliceStable(report.Scenarios, func(i, j int) bool {
+		return report.Scenarios[i].SimulatedCostInUsd < report.Scenarios[j].SimulatedCostInUsd
+	})
+
+	return report, nil
+}
@@ -0,0 +1,69 @@
+package manager
+
+import (
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"go.uber.org/zap"
+)
+
+type RecordingStorage interface {
+	DeleteRecordingKey(tenantId string) error
+	DeleteExpiredRecordedRequests(now int64) (int64, error)
+}
+
+// RecordingManager purges recorded requests past the retention of their key
+// and shreds the recordings of a tenant by deleting its recording key.
+type RecordingManager struct {
+	s        RecordingStorage
+	done     chan bool
+	interval time.Duration
+	log      *zap.Logger
+}
+
+func NewRecordingManager(s RecordingStorage, log *zap.Logger, interval time.Duration) *RecordingManager {
+	return &RecordingManager{
+		s:        s,
+		done:     make(chan bool),
+		interval: interval,
+		log:      log,
+	}
+}
+
+// ShredRecordings makes the recorded requests of a tenant unreadable. New
+// recordings of the tenant are encrypted with a new key.
+func (rm *RecordingManager) ShredRecordings(tenantId string) error {
+	return rm.s.DeleteRecordingKey(tenantId)
+}
+
+func (rm *RecordingManager) Listen() {
+	ticker := time.NewTicker(rm.interval)
+	rm.log.Info("recording manager started purging expired recorded requests")
+
+	go func() {
+		for {
+			select {
+			case <-rm.done:
+				ticker.Stop()
+				rm.log.Info("recording manager stopped")
+				return
+			case <-ticker.C:
+				purged, err := rm.s.DeleteExpiredRecordedRequests(time.Now().Unix())
+				if err != nil {
+					stats.Incr("bricksllm.manager.recording_manager.listen.delete_expired_recorded_requests_error", nil, 1)
+
+					rm.log.Sugar().Debugf("recording manager failed to delete expired recorded requests: %v", err)
+					continue
+				}
+
+				stats.Gauge("bricksllm.manager.recording_manager.listen.purged", float64(purged), nil, 1)
+			}
+		}
+	}()
+}
+
+func (rm *RecordingManager) Stop() {
+	rm.log.Info("shutting down recording manager...")
+
+	rm.done <- true
+}
@@ -0,0 +1,334 @@
+package manager
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/encrypter"
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/tidwall/gjson"
+)
+
+type ReplayStorage interface {
+	GetEvent(id string) (*event.Event, error)
+	GetRecordedRequest(eventId string) (*event.RecordedRequest, error)
+	GetRecordingKey(tenantId string) ([]byte, error)
+	GetRoute(id string) (*route.Route, error)
+	GetKey(keyId string) (*key.ResponseKey, error)
+	GetProviderSettings(withSecret bool, ids []string) ([]*provider.Setting, error)
+}
+
+type replayEstimator interface {
+	EstimateTotalCost(model string, promptTks, completionTks int) (float64, error)
+	EstimateEmbeddingsInputCost(model string, tks int) (float64, error)
+}
+
+type ReplayManager struct {
+	s      ReplayStorage
+	e      replayEstimator
+	aoe    replayEstimator
+	client http.Client
+}
+
+func NewReplayManager(s ReplayStorage, e replayEstimator, aoe replayEstimator) *ReplayManager {
+	return &ReplayManager{
+		s:      s,
+		e:      e,
+		aoe:    aoe,
+		client: http.Client{},
+	}
+}
+
+// decrypt opens the payloads of a recorded request. Recordings whose tenant
+// key was deleted cannot be opened anymore.
+func (m *ReplayManager) decrypt(recorded *event.RecordedRequest) error {
+	if !recorded.Encrypted {
+		return nil
+	}
+
+	shredded := internal_errors.NewNotFoundError("recorded request is not readable since its recording key was deleted")
+
+	rk, err := m.s.GetRecordingKey(recorded.TenantId)
+	if err != nil {
+		if _, ok := err.(notFoundError); ok {
+			return shredded
+		}
+
+		return err
+	}
+
+	request, err := encrypter.Open(rk, recorded.Request)
+	if err != nil {
+		return shredded
+	}
+
+	response, err := encrypter.Open(rk, recorded.Response)
+	if err != nil {
+		return shredded
+	}
+
+	recorded.Request = request
+	recorded.Response = response
+
+	return nil
+}
+
+func isEmbeddingsEvent(evt *event.Event) bool {
+	return strings.HasSuffix(evt.Path, "/embeddings") || (strings.HasPrefix(evt.Path, "/api/routes/") && contains(evt.Model, adaModels))
+}
+
+// Replay sends the recorded request of an event again, against the original
+// provider and model unless overridden, and compares both responses.
+func (m *ReplayManager) Replay(eventId string, rr *event.ReplayRequest) (*event.ReplayResult, error) {
+	if err := rr.Validate(); err != nil {
+		return nil, err
+	}
+
+	evt, err := m.s.GetEvent(eventId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rr.TenantId) != 0 && evt.TenantId != rr.TenantId {
+		return nil, internal_errors.NewNotFoundError("event is not found")
+	}
+
+	recorded, err := m.s.GetRecordedRequest(eventId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.decrypt(recorded); err != nil {
+		return nil, err
+	}
+
+	embeddings := isEmbeddingsEvent(evt)
+
+	r, err := m.buildReplayRoute(evt, rr, embeddings)
+	if err != nil {
+		return nil, err
+	}
+
+	k, err := m.s.GetKey(evt.KeyId)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := m.s.GetProviderSettings(true, k.SettingIds)
+	if err != nil {
+		return nil, err
+	}
+
+	if !r.ValidateSettings(settings) {
+		return nil, internal_errors.NewValidationError("key of the event does not have provider settings for the replay")
+	}
+
+	settingsMap := map[string]*provider.Setting{}
+	for _, setting := range settings {
+		settingsMap[setting.Id] = setting
+	}
+
+	body, err := disableStreaming(recorded.Request)
+	if err != nil {
+		return nil, err
+	}
+
+	forwarded, err := http.NewRequest(http.MethodPost, evt.Path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	forwarded.Header.Set("Content-Type", "application/json")
+
+	original := &event.ReplayOutcome{
+		Provider:             evt.Provider,
+		Model:                evt.Model,
+		Status:               evt.Status,
+		LatencyInMs:          evt.LatencyInMs,
+		PromptTokenCount:     evt.PromptTokenCount,
+		CompletionTokenCount: evt.CompletionTokenCount,
+		CostInUsd:            evt.CostInUsd,
+		Content:              extractContent(recorded.Response),
+	}
+
+	start := time.Now()
+	replayed := &event.ReplayOutcome{
+		Provider: r.Steps[0].Provider,
+		Model:    r.Steps[0].Model,
+	}
+
+	runRes, err := r.RunSteps(&route.Request{
+		Settings:  settingsMap,
+		Key:       k,
+		Client:    m.client,
+		Forwarded: forwarded,
+	})
+
+	if err != nil {
+		replayed.LatencyInMs = int(time.Now().Sub(start).Milliseconds())
+		replayed.Error = err.Error()
+	}
+
+	if err == nil {
+		defer runRes.Cancel()
+		defer runRes.Response.Body.Close()
+
+		data, err := io.ReadAll(runRes.Response.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		replayed = m.newReplayOutcome(embeddings, runRes.Provider, runRes.Model, runRes.Response.StatusCode, time.Now().Sub(start), data)
+	}
+
+	return &event.ReplayResult{
+		EventId:  eventId,
+		Original: original,
+		Replayed: replayed,
+		Diff:     event.NewReplayDiff(original, replayed),
+	}, nil
+}
+
+func (m *ReplayManager) buildReplayRoute(evt *event.Event, rr *event.ReplayRequest, embeddings bool) (*route.Route, error) {
+	if len(rr.RouteId) != 0 {
+		r, err := m.s.GetRoute(rr.RouteId)
+		if err != nil {
+			return nil, err
+		}
+
+		if r.TenantId != evt.TenantId {
+			return nil, internal_errors.NewNotFoundError("route is not found")
+		}
+
+		if r.ShouldRunEmbeddings() != embeddings {
+			return nil, internal_errors.NewValidationError("route does not serve the same kind of request as the event")
+		}
+
+		return r, nil
+	}
+
+	step := &route.Step{
+		Provider: evt.Provider,
+		Model:    evt.Model,
+		Params:   rr.Params,
+		Timeout:  "5m",
+	}
+
+	if len(rr.Provider) != 0 {
+		step.Provider = rr.Provider
+	}
+
+	if len(rr.Model) != 0 {
+		step.Model = rr.Model
+	}
+
+	if len(rr.Timeout) != 0 {
+		step.Timeout = rr.Timeout
+	}
+
+	invalid := []string{}
+	if step.Provider != "openai" && step.Provider != "azure" {
+		invalid = append(invalid, "provider")
+	}
+
+	if step.Provider == "azure" && (len(step.Params["deploymentId"]) == 0 || len(step.Params["apiVersion"]) == 0) {
+		invalid = append(invalid, "params")
+	}
+
+	if (embeddings && !contains(step.Model, adaModels)) || (!embeddings && !contains(step.Model, chatCompletionModels)) {
+		invalid = append(invalid, "model")
+	}
+
+	if _, err := time.ParseDuration(step.Timeout); err != nil {
+		invalid = append(invalid, "timeout")
+	}
+
+	if len(invalid) > 0 {
+		return nil, internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return &route.Route{
+		Steps: []*route.Step{step},
+	}, nil
+}
+
+func (m *ReplayManager) newReplayOutcome(embeddings bool, provider, model string, status int, latency time.Duration, data []byte) *event.ReplayOutcome {
+	outcome := &event.ReplayOutcome{
+		Provider:    provider,
+		Model:       model,
+		Status:      status,
+		LatencyInMs: int(latency.Milliseconds()),
+	}
+
+	if status != http.StatusOK {
+		outcome.Error = gjson.GetBytes(data, "error.message").Str
+		return outcome
+	}
+
+	outcome.PromptTokenCount = int(gjson.GetBytes(data, "usage.prompt_tokens").Int())
+	outcome.CompletionTokenCount = int(gjson.GetBytes(data, "usage.completion_tokens").Int())
+
+	e := m.e
+	if provider == "azure" {
+		e = m.aoe
+	}
+
+	var err error
+	if embeddings {
+		outcome.CostInUsd, err = e.EstimateEmbeddingsInputCost(model, int(gjson.GetBytes(data, "usage.total_tokens").Int()))
+	}
+
+	if !embeddings {
+		outcome.Content = gjson.GetBytes(data, "choices.0.message.content").Str
+		outcome.CostInUsd, err = e.EstimateTotalCost(gjson.GetBytes(data, "model").Str, outcome.PromptTokenCount, outcome.CompletionTokenCount)
+	}
+
+	if err != nil {
+		outcome.Error = err.Error()
+	}
+
+	return outcome
+}
+
+// disableStreaming makes sure the replayed response can be compared as a
+// whole, even if the recorded request was streamed.
+func disableStreaming(body []byte) ([]byte, error) {
+	parsed := map[string]any{}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, internal_errors.NewValidationError("recorded request is not valid json")
+	}
+
+	delete(parsed, "stream")
+	delete(parsed, "stream_options")
+
+	return json.Marshal(parsed)
+}
+
+// extractContent reads the content of the first choice from a recorded chat
+// completion response or from the events of a recorded stream.
+func extractContent(response []byte) string {
+	if gjson.ValidBytes(response) {
+		return gjson.GetBytes(response, "choices.0.message.content").Str
+	}
+
+	content := ""
+	for _, line := range strings.Split(string(response), "\n") {
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if len(data) == len(strings.TrimSpace(line)) || data == "[DONE]" {
+			continue
+		}
+
+		content += gjson.Get(data, "choices.0.delta.content").Str
+	}
+
+	return content
+}
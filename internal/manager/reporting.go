@@ -1,9 +1,15 @@
 package manager
 
 import (
+	"fmt"
+	"sort"
+	"time"
+
 	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
 	"github.com/bricks-cloud/bricksllm/internal/event"
 	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
 )
 
 type costStorage interface {
@@ -18,20 +24,250 @@ type eventStorage interface {
 	GetEvents(customId string, keyIds []string, start, end int64) ([]*event.Event, error)
 	GetEventDataPoints(start, end, increment int64, tags, keyIds, customIds []string, filters []string) ([]*event.DataPoint, error)
 	GetLatencyPercentiles(start, end int64, tags, keyIds []string) ([]float64, error)
+	GetAggregatedEventDataPoints(r *event.AggregationRequest) ([]*event.AggregatedDataPoint, error)
+	GetTopEntries(r *event.TopRequest) ([]*event.TopEntry, error)
+	SearchEvents(r *event.EventSearchRequest, limit, offset int) ([]*event.Event, error)
+	GetRouteSloCounts(tenantId, path string, start, end int64, latencyThresholdInMs int) (int64, int64, int64, error)
+	GetSessionEvents(tenantId, sessionId, keyId string) ([]*event.Event, error)
+	GetTaskEvents(tenantId, eventId string) ([]*event.Event, error)
+	GetRequestEvent(tenantId, requestId string) (*event.Event, error)
+	GetReconciliations(r *event.ReconciliationRequest) ([]*event.Reconciliation, error)
+}
+
+type routeStorage interface {
+	GetRoute(id string) (*route.Route, error)
+	GetRoutes() ([]*route.Route, error)
 }
 
 type ReportingManager struct {
 	es eventStorage
 	cs costStorage
 	ks keyStorage
+	rs routeStorage
 }
 
-func NewReportingManager(cs costStorage, ks keyStorage, es eventStorage) *ReportingManager {
+func NewReportingManager(cs costStorage, ks keyStorage, es eventStorage, rs routeStorage) *ReportingManager {
 	return &ReportingManager{
 		cs: cs,
 		ks: ks,
 		es: es,
+		rs: rs,
+	}
+}
+
+func (rm *ReportingManager) GetAggregatedEventReporting(r *event.AggregationRequest) (*event.AggregationResponse, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	dataPoints, err := rm.es.GetAggregatedEventDataPoints(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &event.AggregationResponse{
+		DataPoints: dataPoints,
+	}, nil
+}
+
+func (rm *ReportingManager) GetTopReporting(r *event.TopRequest) (*event.TopResponse, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	entries, err := rm.es.GetTopEntries(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &event.TopResponse{
+		Metric:  r.Metric,
+		Entries: entries,
+	}, nil
+}
+
+func (rm *ReportingManager) GetUsage(r *event.UsageRequest) (*event.UsageResponse, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	k, err := rm.ks.GetKey(r.KeyId)
+	if err != nil {
+		return nil, err
+	}
+
+	if k == nil {
+		return nil, internal_errors.NewNotFoundError("api key is not found")
+	}
+
+	start, end := r.Range()
+	dataPoints, err := rm.es.GetAggregatedEventDataPoints(&event.AggregationRequest{
+		Start:       start,
+		End:         end,
+		Granularity: event.GranularityHour,
+		GroupBy:     []string{event.DimensionModel, event.DimensionPath},
+		KeyIds:      []string{r.KeyId},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := event.NewUsageResponse()
+	for _, dp := range dataPoints {
+		resp.Data = append(resp.Data, &event.UsageDataPoint{
+			AggregationTimestamp:  dp.TimeStamp,
+			NumberOfRequests:      dp.NumberOfRequests,
+			Operation:             event.UsageOperation(dp.Dimensions[event.DimensionPath]),
+			SnapshotId:            dp.Dimensions[event.DimensionModel],
+			NContextTokensTotal:   dp.PromptTokenCount,
+			NGeneratedTokensTotal: dp.CompletionTokenCount,
+		})
+
+		resp.CurrentUsageUsd += dp.CostInUsd
+	}
+
+	return resp, nil
+}
+
+func (rm *ReportingManager) GetChargebackReport(r *event.ChargebackRequest) (*event.ChargebackReport, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	start, end := r.Range()
+	dataPoints, err := rm.es.GetAggregatedEventDataPoints(&event.AggregationRequest{
+		Start:    start,
+		End:      end,
+		GroupBy:  []string{r.GroupBy},
+		Tags:     r.Tags,
+		TenantId: r.TenantId,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &event.ChargebackReport{
+		Month:            r.Month,
+		GroupBy:          r.GroupBy,
+		MarkupPercentage: r.MarkupPercentage,
+		LineItems:        []*event.ChargebackLineItem{},
+	}
+
+	for _, dp := range dataPoints {
+		markup := dp.CostInUsd * r.MarkupPercentage / 100
+		report.LineItems = append(report.LineItems, &event.ChargebackLineItem{
+			Name:                 dp.Dimensions[r.GroupBy],
+			NumberOfRequests:     dp.NumberOfRequests,
+			PromptTokenCount:     dp.PromptTokenCount,
+			CompletionTokenCount: dp.CompletionTokenCount,
+			CostInUsd:            dp.CostInUsd,
+			MarkupInUsd:          markup,
+			TotalInUsd:           dp.CostInUsd + markup,
+		})
+
+		report.CostInUsd += dp.CostInUsd
+		report.MarkupInUsd += markup
+	}
+
+	report.TotalInUsd = report.CostInUsd + report.MarkupInUsd
+
+	sort.Slice(report.LineItems, func(i, j int) bool {
+		return report.LineItems[i].TotalInUsd > report.LineItems[j].TotalInUsd
+	})
+
+	return report, nil
+}
+
+// GetSpendForecast projects the spend of the current month from its run rate.
+// Keys are at risk when the projection exceeds their monthly cost limit, or
+// when their total spend is on track to exceed their cost limit.
+func (rm *ReportingManager) GetSpendForecast(r *event.ForecastRequest) (*event.ForecastReport, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	start, end := r.Period(now)
+	dataPoints, err := rm.es.GetAggregatedEventDataPoints(&event.AggregationRequest{
+		Start:    start,
+		End:      now.Unix(),
+		GroupBy:  []string{r.GroupBy},
+		Tags:     r.Tags,
+		TenantId: r.TenantId,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &event.ForecastReport{
+		GroupBy:     r.GroupBy,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		GeneratedAt: now.Unix(),
+		Forecasts:   []*event.SpendForecast{},
+	}
+
+	for _, dp := range dataPoints {
+		f := &event.SpendForecast{
+			Name:                dp.Dimensions[r.GroupBy],
+			SpendInUsd:          dp.CostInUsd,
+			ProjectedSpendInUsd: event.Project(dp.CostInUsd, start, end, now.Unix()),
+		}
+
+		if r.GroupBy == event.DimensionKeyId && len(f.Name) != 0 {
+			if err := rm.checkKeyBudget(f); err != nil {
+				return nil, err
+			}
+		}
+
+		report.SpendInUsd += f.SpendInUsd
+		report.ProjectedSpendInUsd += f.ProjectedSpendInUsd
+		report.Forecasts = append(report.Forecasts, f)
+	}
+
+	sort.SliceStable(report.Forecasts, func(i, j int) bool {
+		if report.Forecasts[i].AtRisk != report.Forecasts[j].AtRisk {
+			return report.Forecasts[i].AtRisk
+		}
+
+		return report.Forecasts[i].ProjectedSpendInUsd > report.Forecasts[j].ProjectedSpendInUsd
+	})
+
+	return report, nil
+}
+
+func (rm *ReportingManager) checkKeyBudget(f *event.SpendForecast) error {
+	k, err := rm.ks.GetKey(f.Name)
+	if err != nil {
+		if _, ok := err.(notFoundError); ok {
+			return nil
+		}
+
+		return err
+	}
+
+	if k == nil {
+		return nil
 	}
+
+	if k.CostLimitInUsdUnit == key.MonthTimeUnit && k.GetCostLimitInUsdOverTime() > 0 {
+		f.BudgetInUsd = k.GetCostLimitInUsdOverTime()
+		f.AtRisk = f.ProjectedSpendInUsd > f.BudgetInUsd
+	}
+
+	if k.CostLimitInUsd > 0 {
+		micros, err := rm.cs.GetCounter(k.KeyId)
+		if err != nil {
+			return err
+		}
+
+		f.CostLimitInUsd = k.CostLimitInUsd
+		f.TotalSpendInUsd = float64(micros) / 1000000
+		f.ProjectedTotalSpendInUsd = f.TotalSpendInUsd + f.ProjectedSpendInUsd - f.SpendInUsd
+		f.AtRisk = f.AtRisk || f.ProjectedTotalSpendInUsd > f.CostLimitInUsd
+	}
+
+	return nil
 }
 
 func (rm *ReportingManager) GetEventReporting(e *event.ReportingRequest) (*event.ReportingResponse, error) {
@@ -85,3 +321,162 @@ func (rm *ReportingManager) GetEvents(customId string, keyIds []string, start, e
 
 	return events, nil
 }
+
+func (rm *ReportingManager) SearchEvents(r *event.EventSearchRequest) (*event.EventSearchResponse, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	events, err := rm.es.SearchEvents(r, r.Limit+1, r.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	hasMore := len(events) > r.Limit
+	if hasMore {
+		events = events[:r.Limit]
+	}
+
+	return &event.EventSearchResponse{
+		Events:  events,
+		Limit:   r.Limit,
+		Offset:  r.Offset,
+		HasMore: hasMore,
+	}, nil
+}
+
+func (rm *ReportingManager) GetSessionTimeline(tenantId, sessionId, keyId string) (*event.SessionTimeline, error) {
+	if !event.IsValidSessionId(sessionId) {
+		return nil, internal_errors.NewValidationError("session id is invalid")
+	}
+
+	events, err := rm.es.GetSessionEvents(tenantId, sessionId, keyId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(events) == 0 {
+		return nil, internal_errors.NewNotFoundError(fmt.Sprintf("session %s is not found", sessionId))
+	}
+
+	return event.NewSessionTimeline(sessionId, events), nil
+}
+
+// GetTaskReport returns the task of any of its requests.
+func (rm *ReportingManager) GetTaskReport(tenantId, requestId string) (*event.TaskReport, error) {
+	if !event.IsValidRequestId(requestId) {
+		return nil, internal_errors.NewValidationError("request id is invalid")
+	}
+
+	events, err := rm.es.GetTaskEvents(tenantId, requestId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(events) == 0 {
+		return nil, internal_errors.NewNotFoundError(fmt.Sprintf("request %s is not found", requestId))
+	}
+
+	return event.NewTaskReport(events), nil
+}
+
+// GetDecisionTrail returns the decisions the proxy took for a request.
+func (rm *ReportingManager) GetDecisionTrail(tenantId, requestId string) (*event.DecisionTrail, error) {
+	if !event.IsValidRequestId(requestId) {
+		return nil, internal_errors.NewValidationError("request id is invalid")
+	}
+
+	e, err := rm.es.GetRequestEvent(tenantId, requestId)
+	if err != nil {
+		return nil, err
+	}
+
+	return event.NewDecisionTrail(e), nil
+}
+
+func (rm *ReportingManager) GetRouteSloReport(routeId string) (*route.SloReport, error) {
+	r, err := rm.rs.GetRoute(routeId)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Slo == nil {
+		return nil, internal_errors.NewNotFoundError(fmt.Sprintf("route %s does not have slo configured", routeId))
+	}
+
+	return rm.computeSloReport(r)
+}
+
+// GetRouteSloReports computes reports of every route with an slo configured
+// and publishes them as gauges tagged by route.
+func (rm *ReportingManager) GetRouteSloReports() ([]*route.SloReport, error) {
+	routes, err := rm.rs.GetRoutes()
+	if err != nil {
+		return nil, err
+	}
+
+	reports := []*route.SloReport{}
+	for _, r := range routes {
+		if r.Slo == nil {
+			continue
+		}
+
+		report, err := rm.computeSloReport(r)
+		if err != nil {
+			return nil, err
+		}
+
+		tags := []string{"route:" + r.Path}
+		if report.Availability != nil {
+			stats.Gauge("bricksllm.reporting.slo.availability", report.Availability.Compliance, tags, 1)
+			stats.Gauge("bricksllm.reporting.slo.availability_error_budget_remaining", report.Availability.ErrorBudgetRemaining, tags, 1)
+		}
+
+		if report.Latency != nil {
+			stats.Gauge("bricksllm.reporting.slo.latency", report.Latency.Compliance, tags, 1)
+			stats.Gauge("bricksllm.reporting.slo.latency_error_budget_remaining", report.Latency.ErrorBudgetRemaining, tags, 1)
+		}
+
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+func (rm *ReportingManager) computeSloReport(r *route.Route) (*route.SloReport, error) {
+	now := time.Now()
+	start := now.Add(-r.Slo.GetWindow()).Unix()
+	end := now.Unix()
+
+	total, failed, slow, err := rm.es.GetRouteSloCounts(r.TenantId, r.Path, start, end, r.Slo.LatencyThresholdInMs)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &route.SloReport{
+		RouteId:          r.Id,
+		Path:             r.Path,
+		Window:           r.Slo.Window,
+		Start:            start,
+		End:              end,
+		NumberOfRequests: total,
+	}
+
+	if r.Slo.AvailabilityTarget > 0 {
+		report.Availability = route.NewSloCompliance(r.Slo.AvailabilityTarget, total, failed)
+	}
+
+	if r.Slo.LatencyTarget > 0 {
+		report.Latency = route.NewSloCompliance(r.Slo.LatencyTarget, total, slow)
+	}
+
+	return report, nil
+}
+
+func (rm *ReportingManager) GetReconciliations(r *event.ReconciliationRequest) ([]*event.Reconciliation, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	return rm.es.GetReconciliations(r)
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/prompt"
 	"github.com/bricks-cloud/bricksllm/internal/route"
 	"github.com/bricks-cloud/bricksllm/internal/util"
 )
@@ -15,11 +16,15 @@ type RoutesStorage interface {
 	CreateRoute(r *route.Route) (*route.Route, error)
 	GetRoute(id string) (*route.Route, error)
 	GetRoutes() ([]*route.Route, error)
-	GetRouteByPath(path string) (*route.Route, error)
+	GetRouteByPath(tenantId, path string) (*route.Route, error)
+	GetPromptTemplate(name string, version int) (*prompt.Template, error)
+	GetShadowResults(routeId string, start, end int64, limit int) ([]*route.ShadowResult, error)
 }
 
 type RoutesMemStorage interface {
-	GetRoute(id string) *route.Route
+	GetRoute(tenantId, path string) *route.Route
+	GetAuthRoute(path string) *route.Route
+	GetCorsRoute(path string) *route.Route
 }
 
 type RouteManager struct {
@@ -38,30 +43,141 @@ func NewRouteManager(s RoutesStorage, ks Storage, ms RoutesMemStorage, psm Provi
 	}
 }
 
-func (m *RouteManager) GetRouteFromMemDb(path string) *route.Route {
-	return m.ms.GetRoute(path)
+func (m *RouteManager) GetRouteFromMemDb(tenantId, path string) *route.Route {
+	return m.ms.GetRoute(tenantId, path)
+}
+
+func (m *RouteManager) GetAuthRouteFromMemDb(path string) *route.Route {
+	return m.ms.GetAuthRoute(path)
+}
+
+func (m *RouteManager) GetCorsRouteFromMemDb(path string) *route.Route {
+	return m.ms.GetCorsRoute(path)
 }
 
 func (m *RouteManager) GetRoute(id string) (*route.Route, error) {
-	return m.s.GetRoute(id)
+	r, err := m.s.GetRoute(id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Egress = r.Egress.Redacted()
+	r.Auth = r.Auth.Redacted()
+	r.Retrieval = r.Retrieval.Redacted()
+	r.Guardrails = r.Guardrails.Redacted()
+
+	return r, nil
 }
 
 func (m *RouteManager) GetRoutes() ([]*route.Route, error) {
-	return m.s.GetRoutes()
+	routes, err := m.s.GetRoutes()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range routes {
+		r.Egress = r.Egress.Redacted()
+		r.Auth = r.Auth.Redacted()
+		r.Retrieval = r.Retrieval.Redacted()
+		r.Guardrails = r.Guardrails.Redacted()
+	}
+
+	return routes, nil
+}
+
+func (m *RouteManager) GetShadowResults(routeId string, start, end int64, limit int) ([]*route.ShadowResult, error) {
+	invalid := []string{}
+	if start <= 0 {
+		invalid = append(invalid, "start")
+	}
+
+	if end <= 0 || end < start {
+		invalid = append(invalid, "end")
+	}
+
+	if limit <= 0 || limit > 1000 {
+		invalid = append(invalid, "limit")
+	}
+
+	if len(invalid) > 0 {
+		return nil, internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	if _, err := m.s.GetRoute(routeId); err != nil {
+		return nil, err
+	}
+
+	return m.s.GetShadowResults(routeId, start, end, limit)
 }
 
 func (m *RouteManager) CreateRoute(r *route.Route) (*route.Route, error) {
+	if err := m.prepareRoute(r); err != nil {
+		return nil, err
+	}
+
+	created, err := m.s.CreateRoute(r)
+	if err != nil {
+		return nil, err
+	}
+
+	created.Egress = created.Egress.Redacted()
+	created.Auth = created.Auth.Redacted()
+	created.Retrieval = created.Retrieval.Redacted()
+	created.Guardrails = created.Guardrails.Redacted()
+
+	return created, nil
+}
+
+// prepareRoute validates a route before it is created and fills in its
+// generated fields and default values.
+func (m *RouteManager) prepareRoute(r *route.Route) error {
 	r.CreatedAt = time.Now().Unix()
 	r.UpdatedAt = time.Now().Unix()
-	r.Id = util.NewUuid()
+	if len(r.Id) == 0 {
+		r.Id = util.NewUuid()
+	} else {
+		if !util.IsValidId(r.Id) {
+			return internal_errors.NewValidationError("route id can only contain up to 64 letters, digits, dots, underscores and dashes")
+		}
+
+		if existing, _ := m.s.GetRoute(r.Id); existing != nil {
+			return internal_errors.NewConflictError("route already exists: " + r.Id)
+		}
+	}
 
 	if err := m.validateRoute(r); err != nil {
-		return nil, err
+		return err
 	}
 
 	addDefaultValues(r)
+	r.Auth.HashToken()
+
+	return nil
+}
 
-	return m.s.CreateRoute(r)
+// validatePathIsDistinct rejects a pattern that matches the same requests as
+// a pattern of another route of the tenant, such as /a/:x and /a/:y, since
+// neither would take precedence. Routes with their own auth or cors policy are
+// looked up before the tenant is known, so their paths must be distinct across
+// tenants.
+func (m *RouteManager) validatePathIsDistinct(r *route.Route) error {
+	routes, err := m.s.GetRoutes()
+	if err != nil {
+		return err
+	}
+
+	normalized := route.NormalizePath(r.Path)
+	for _, existing := range routes {
+		if existing.Id == r.Id || route.NormalizePath(existing.Path) != normalized {
+			continue
+		}
+
+		if existing.TenantId == r.TenantId || (r.Auth != nil && existing.Auth != nil) || (r.Cors != nil && existing.Cors != nil) {
+			return internal_errors.NewValidationError("path conflicts with the path of route: " + existing.Id)
+		}
+	}
+
+	return nil
 }
 
 func addDefaultValues(r *route.Route) {
@@ -75,6 +191,44 @@ func addDefaultValues(r *route.Route) {
 		}
 	}
 
+	if r.Shadow != nil && r.Shadow.Step != nil && len(r.Shadow.Step.Timeout) == 0 {
+		r.Shadow.Step.Timeout = "5m"
+	}
+
+	if r.Compression != nil && r.Compression.Step != nil && len(r.Compression.Step.Timeout) == 0 {
+		r.Compression.Step.Timeout = "5m"
+	}
+
+	if r.Judge != nil && r.Judge.Step != nil && len(r.Judge.Step.Timeout) == 0 {
+		r.Judge.Step.Timeout = "5m"
+	}
+
+	if r.Language != nil {
+		for _, steps := range r.Language.Steps {
+			for _, step := range steps {
+				if step != nil && len(step.Timeout) == 0 {
+					step.Timeout = "5m"
+				}
+			}
+		}
+	}
+
+	if r.Retrieval != nil {
+		r.Retrieval.SetDefaults()
+	}
+
+	if r.Guardrails != nil {
+		r.Guardrails.SetDefaults()
+	}
+
+	if r.Pipeline != nil {
+		for _, stage := range r.Pipeline.Stages {
+			if stage != nil && stage.Step != nil && len(stage.Step.Timeout) == 0 {
+				stage.Step.Timeout = "5m"
+			}
+		}
+	}
+
 }
 
 func checkModelValidity(provider, model string) bool {
@@ -126,6 +280,8 @@ var (
 		"gpt-3.5-turbo-16k",
 		"gpt-3.5-turbo-16k-0613",
 		"text-embedding-ada-002",
+		"text-embedding-3-small",
+		"text-embedding-3-large",
 	}
 
 	supportedModels = []string{
@@ -153,11 +309,15 @@ var (
 		"gpt-3.5-turbo-16k-0613",
 		"ada",
 		"text-embedding-ada-002",
+		"text-embedding-3-small",
+		"text-embedding-3-large",
 	}
 
 	adaModels = []string{
 		"ada",
 		"text-embedding-ada-002",
+		"text-embedding-3-small",
+		"text-embedding-3-large",
 	}
 
 	chatCompletionModels = []string{
@@ -208,7 +368,7 @@ func (m *RouteManager) validateRoute(r *route.Route) error {
 		fields = append(fields, "name")
 	}
 
-	if len(r.Path) == 0 {
+	if len(r.Path) == 0 || (route.IsPathPattern(r.Path) && !route.ValidatePathPattern(r.Path)) {
 		fields = append(fields, "path")
 	}
 
@@ -287,12 +447,279 @@ func (m *RouteManager) validateRoute(r *route.Route) error {
 		}
 	}
 
+	if r.Slo != nil {
+		fields = append(fields, r.Slo.Validate()...)
+	}
+
+	if r.PromptTemplate != nil {
+		if containAda {
+			return internal_errors.NewValidationError("prompt templates can only be used with chat completion routes")
+		}
+
+		if r.PromptTemplate.Version < 0 {
+			fields = append(fields, "promptTemplate.version")
+		}
+
+		if _, err := m.s.GetPromptTemplate(r.PromptTemplate.Name, r.PromptTemplate.Version); err != nil {
+			if _, ok := err.(notFoundError); ok {
+				return internal_errors.NewValidationError(err.Error())
+			}
+
+			return err
+		}
+	}
+
+	if r.SystemPrompt != nil {
+		if containAda {
+			return internal_errors.NewValidationError("system prompts can only be used with chat completion routes")
+		}
+
+		fields = append(fields, r.SystemPrompt.Validate("systemPrompt")...)
+	}
+
+	for index, rr := range r.RequestRules {
+		if rr == nil {
+			fields = append(fields, fmt.Sprintf("requestRules.[%d]", index))
+			continue
+		}
+
+		fields = append(fields, rr.Validate(index)...)
+	}
+
+	if len(r.ResponseTransforms) != 0 && containAda {
+		return internal_errors.NewValidationError("response transforms can only be used with chat completion routes")
+	}
+
+	for index, rt := range r.ResponseTransforms {
+		if rt == nil {
+			fields = append(fields, fmt.Sprintf("responseTransforms.[%d]", index))
+			continue
+		}
+
+		fields = append(fields, rt.Validate(index)...)
+	}
+
+	fields = append(fields, route.ValidateResponseHeaders(r.ResponseHeaders)...)
+	fields = append(fields, route.ValidateAuth(r.Auth, r.KeyIds)...)
+
+	if r.Cors != nil {
+		fields = append(fields, r.Cors.Validate("cors")...)
+	}
+
+	if r.Shadow != nil {
+		fields = append(fields, r.Shadow.Validate()...)
+
+		if step := r.Shadow.Step; step != nil {
+			if !checkModelValidity(step.Provider, step.Model) {
+				return internal_errors.NewValidationError(fmt.Sprintf("shadow model: %s is not supported for provider: %s", step.Model, step.Provider))
+			}
+
+			if containAda != contains(step.Model, adaModels) {
+				return internal_errors.NewValidationError("shadow step must have a model congruent with the route steps")
+			}
+
+			if step.Provider == "azure" && (len(step.Params["apiVersion"]) == 0 || len(step.Params["deploymentId"]) == 0) {
+				fields = append(fields, "shadow.step.params")
+			}
+		}
+	}
+
+	if r.Dedup != nil {
+		fields = append(fields, r.Dedup.Validate()...)
+	}
+
+	if r.Downgrade != nil {
+		fields = append(fields, r.Downgrade.Validate()...)
+	}
+
+	if r.Embeddings != nil {
+		if !containAda {
+			return internal_errors.NewValidationError("embeddings can only be configured for embeddings routes")
+		}
+
+		fields = append(fields, r.Embeddings.Validate()...)
+
+		for index, step := range r.Steps {
+			if r.Embeddings.Dimensions > 0 && !r.Embeddings.CanProduce(step.Model) {
+				return internal_errors.NewValidationError(fmt.Sprintf("steps.[%d].model %s cannot produce %d dimensions", index, step.Model, r.Embeddings.Dimensions))
+			}
+		}
+	}
+
+	if r.Compression != nil {
+		if containAda {
+			return internal_errors.NewValidationError("compression can only be used with chat completion routes")
+		}
+
+		fields = append(fields, r.Compression.Validate()...)
+
+		if step := r.Compression.Step; step != nil && len(step.Model) != 0 {
+			if !checkModelValidity(step.Provider, step.Model) || !contains(step.Model, chatCompletionModels) {
+				return internal_errors.NewValidationError(fmt.Sprintf("compression model: %s is not supported for provider: %s", step.Model, step.Provider))
+			}
+
+			if step.Provider == "azure" && (len(step.Params["apiVersion"]) == 0 || len(step.Params["deploymentId"]) == 0) {
+				fields = append(fields, "compression.step.params")
+			}
+		}
+	}
+
+	if r.Judge != nil {
+		if containAda {
+			return internal_errors.NewValidationError("judge can only be used with chat completion routes")
+		}
+
+		fields = append(fields, r.Judge.Validate()...)
+
+		if step := r.Judge.Step; step != nil && len(step.Model) != 0 {
+			if !checkModelValidity(step.Provider, step.Model) || !contains(step.Model, chatCompletionModels) {
+				return internal_errors.NewValidationError(fmt.Sprintf("judge model: %s is not supported for provider: %s", step.Model, step.Provider))
+			}
+
+			if step.Provider == "azure" && (len(step.Params["apiVersion"]) == 0 || len(step.Params["deploymentId"]) == 0) {
+				fields = append(fields, "judge.step.params")
+			}
+		}
+	}
+
+	if r.Language != nil {
+		if containAda {
+			return internal_errors.NewValidationError("language routing can only be used with chat completion routes")
+		}
+
+		invalid := r.Language.Validate()
+		if len(invalid) != 0 {
+			fields = append(fields, invalid...)
+		} else {
+			for code, steps := range r.Language.Steps {
+				for index, step := range steps {
+					if !checkModelValidity(step.Provider, step.Model) || !contains(step.Model, chatCompletionModels) {
+						return internal_errors.NewValidationError(fmt.Sprintf("language.steps.%s.[%d] model: %s is not supported for provider: %s", code, index, step.Model, step.Provider))
+					}
+
+					if step.Provider == "azure" && (len(step.Params["apiVersion"]) == 0 || len(step.Params["deploymentId"]) == 0) {
+						fields = append(fields, fmt.Sprintf("language.steps.%s.[%d].params", code, index))
+					}
+				}
+			}
+		}
+	}
+
+	if r.Complexity != nil {
+		if containAda {
+			return internal_errors.NewValidationError("complexity routing can only be used with chat completion routes")
+		}
+
+		invalid := r.Complexity.Validate()
+		if len(invalid) != 0 {
+			fields = append(fields, invalid...)
+		} else {
+			for index, step := range r.Complexity.CheapSteps {
+				if !checkModelValidity(step.Provider, step.Model) || !contains(step.Model, chatCompletionModels) {
+					return internal_errors.NewValidationError(fmt.Sprintf("complexity.cheapSteps.[%d] model: %s is not supported for provider: %s", index, step.Model, step.Provider))
+				}
+
+				if step.Provider == "azure" && (len(step.Params["apiVersion"]) == 0 || len(step.Params["deploymentId"]) == 0) {
+					fields = append(fields, fmt.Sprintf("complexity.cheapSteps.[%d].params", index))
+				}
+			}
+		}
+	}
+
+	if r.ContextWindow != nil {
+		if containAda {
+			return internal_errors.NewValidationError("context window policies can only be used with chat completion routes")
+		}
+
+		invalid := r.ContextWindow.Validate()
+		if len(invalid) != 0 {
+			fields = append(fields, invalid...)
+		} else {
+			for index, step := range r.ContextWindow.LongContextSteps {
+				if !checkModelValidity(step.Provider, step.Model) || !contains(step.Model, chatCompletionModels) {
+					return internal_errors.NewValidationError(fmt.Sprintf("contextWindow.longContextSteps.[%d] model: %s is not supported for provider: %s", index, step.Model, step.Provider))
+				}
+
+				if step.Provider == "azure" && (len(step.Params["apiVersion"]) == 0 || len(step.Params["deploymentId"]) == 0) {
+					fields = append(fields, fmt.Sprintf("contextWindow.longContextSteps.[%d].params", index))
+				}
+			}
+		}
+	}
+
+	if r.Pipeline != nil {
+		if containAda {
+			return internal_errors.NewValidationError("pipelines can only be used with chat completion routes")
+		}
+
+		invalid := r.Pipeline.Validate()
+		if len(invalid) != 0 {
+			fields = append(fields, invalid...)
+		} else {
+			for index, stage := range r.Pipeline.Stages {
+				step := stage.Step
+				if !checkModelValidity(step.Provider, step.Model) || !contains(step.Model, chatCompletionModels) {
+					return internal_errors.NewValidationError(fmt.Sprintf("pipeline.stages.[%d] model: %s is not supported for provider: %s", index, step.Model, step.Provider))
+				}
+
+				if step.Provider == "azure" && (len(step.Params["apiVersion"]) == 0 || len(step.Params["deploymentId"]) == 0) {
+					fields = append(fields, fmt.Sprintf("pipeline.stages.[%d].step.params", index))
+				}
+			}
+		}
+	}
+
+	if r.Retrieval != nil {
+		if containAda {
+			return internal_errors.NewValidationError("retrieval can only be used with chat completion routes")
+		}
+
+		invalid := r.Retrieval.Validate()
+		if len(invalid) != 0 {
+			fields = append(fields, invalid...)
+		} else {
+			step := r.Retrieval.EmbeddingStep
+			if (step.Provider != "openai" && step.Provider != "azure") || !checkModelValidity(step.Provider, step.Model) || !contains(step.Model, adaModels) {
+				return internal_errors.NewValidationError(fmt.Sprintf("retrieval embedding model: %s is not supported for provider: %s", step.Model, step.Provider))
+			}
+
+			if step.Provider == "azure" && (len(step.Params["apiVersion"]) == 0 || len(step.Params["deploymentId"]) == 0) {
+				fields = append(fields, "retrieval.embeddingStep.params")
+			}
+
+			if r.PromptTemplate != nil {
+				t, err := m.s.GetPromptTemplate(r.PromptTemplate.Name, r.PromptTemplate.Version)
+				if err == nil && !contains(r.Retrieval.GetVariable(), t.Variables()) {
+					fields = append(fields, "retrieval.variable")
+				}
+			}
+		}
+	}
+
+	if r.Guardrails != nil {
+		if containAda {
+			return internal_errors.NewValidationError("guardrails can only be used with chat completion routes")
+		}
+
+		fields = append(fields, r.Guardrails.Validate()...)
+	}
+
+	if r.Egress != nil {
+		if err := r.Egress.Validate(); err != nil {
+			return err
+		}
+	}
+
 	found, err := m.ks.GetKeys(nil, r.KeyIds, "")
 	if err != nil {
 		return err
 	}
 
 	for _, key := range found {
+		if key.TenantId != r.TenantId {
+			return internal_errors.NewValidationError("specified key ids are not found")
+		}
+
 		settingIds := key.GetSettingIds()
 		settings := m.ps.GetSettings(settingIds)
 
@@ -301,7 +728,7 @@ func (m *RouteManager) validateRoute(r *route.Route) error {
 		}
 	}
 
-	_, err = m.s.GetRouteByPath(r.Path)
+	_, err = m.s.GetRouteByPath(r.TenantId, r.Path)
 	if err == nil {
 		return internal_errors.NewValidationError("path is not unique")
 	}
@@ -310,6 +737,12 @@ func (m *RouteManager) validateRoute(r *route.Route) error {
 		return err
 	}
 
+	if route.IsPathPattern(r.Path) || r.Auth != nil || r.Cors != nil {
+		if err := m.validatePathIsDistinct(r); err != nil {
+			return err
+		}
+	}
+
 	if len(found) != len(r.KeyIds) {
 		return internal_errors.NewValidationError("specified key ids are not found")
 	}
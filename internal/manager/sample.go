@@ -0,0 +1,62 @@
+package manager
+
+import (
+	"io"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/sampling"
+)
+
+const sampleExportBatchSize = 500
+
+type SampleStorage interface {
+	GetSamples(r *event.SampleExportRequest, afterCreatedAt int64, afterId string, limit int) ([]*event.Sample, error)
+}
+
+type SampleManager struct {
+	s SampleStorage
+}
+
+func NewSampleManager(s SampleStorage) *SampleManager {
+	return &SampleManager{
+		s: s,
+	}
+}
+
+// ExportSamples writes the samples matching r to w as JSONL. Nothing is
+// written when the request is invalid.
+func (m *SampleManager) ExportSamples(r *event.SampleExportRequest, w io.Writer) error {
+	if len(r.Format) == 0 {
+		r.Format = event.SampleFormatFull
+	}
+
+	if err := r.Validate(); err != nil {
+		return err
+	}
+
+	afterCreatedAt, afterId := int64(0), ""
+	for {
+		samples, err := m.s.GetSamples(r, afterCreatedAt, afterId, sampleExportBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, s := range samples {
+			line, err := sampling.Line(s, r.Format)
+			if err != nil {
+				return err
+			}
+
+			if _, err := w.Write(line); err != nil {
+				return err
+			}
+		}
+
+		if len(samples) < sampleExportBatchSize {
+			return nil
+		}
+
+		last := samples[len(samples)-1]
+		afterCreatedAt, afterId = last.CreatedAt, last.Id
+	}
+}
@@ -0,0 +1,191 @@
+package manager
+
+import (
+	"fmt"
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/schedule"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/bricks-cloud/bricksllm/internal/webhook"
+)
+
+// maxScheduleRuns is the number of latest runs returned for a schedule.
+const maxScheduleRuns = 100
+
+type ScheduleStorage interface {
+	CreateSchedule(s *schedule.Schedule) error
+	GetSchedule(id string) (*schedule.Schedule, error)
+	GetSchedules(tenantId string) ([]*schedule.Schedule, error)
+	UpdateSchedule(s *schedule.Schedule) error
+	DeleteSchedule(id string) error
+	GetDueSchedules(now int64) ([]*schedule.Schedule, error)
+	ClaimSchedule(id string, nextRunAt, newNextRunAt, now int64) (bool, error)
+	CreateScheduleRun(r *schedule.Run) error
+	GetScheduleRuns(scheduleId string, limit int) ([]*schedule.Run, error)
+	GetKey(keyId string) (*key.ResponseKey, error)
+	GetRouteByPath(tenantId, path string) (*route.Route, error)
+}
+
+// ScheduleManager stores schedules and hands due schedules to the proxy,
+// which sends their requests.
+type ScheduleManager struct {
+	s            ScheduleStorage
+	webhookHosts []string
+	s3Configured bool
+}
+
+func NewScheduleManager(s ScheduleStorage, webhookHosts []string, s3Configured bool) *ScheduleManager {
+	return &ScheduleManager{
+		s:            s,
+		webhookHosts: webhookHosts,
+		s3Configured: s3Configured,
+	}
+}
+
+// validate checks the parts of a schedule that depend on stored
+// configuration. Keys and routes of other tenants are reported as not found.
+func (m *ScheduleManager) validate(s *schedule.Schedule, tenantId string) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	if s.Destination.Type == schedule.DestinationWebhook {
+		if err := webhook.ValidateUrl(s.Destination.WebhookUrl, m.webhookHosts); err != nil {
+			return err
+		}
+	}
+
+	if s.Destination.Type == schedule.DestinationS3 && !m.s3Configured {
+		return internal_errors.NewValidationError("s3 destinations require SCHEDULE_S3_ACCESS_KEY_ID and SCHEDULE_S3_SECRET_ACCESS_KEY")
+	}
+
+	k, err := m.s.GetKey(s.KeyId)
+	if err != nil {
+		return err
+	}
+
+	if k == nil || (len(tenantId) != 0 && k.TenantId != tenantId) {
+		return internal_errors.NewValidationError(fmt.Sprintf("key %s is not found", s.KeyId))
+	}
+
+	if _, err := m.s.GetRouteByPath(k.TenantId, s.RoutePath); err != nil {
+		if _, ok := err.(notFoundError); ok {
+			return internal_errors.NewValidationError(fmt.Sprintf("route %s is not found", s.RoutePath))
+		}
+
+		return err
+	}
+
+	s.TenantId = k.TenantId
+
+	return nil
+}
+
+func (m *ScheduleManager) CreateSchedule(tenantId string, s *schedule.Schedule) (*schedule.Schedule, error) {
+	now := time.Now()
+	s.Id = util.NewUuid()
+	s.CreatedAt = now.Unix()
+	s.UpdatedAt = now.Unix()
+	s.LastRunAt = 0
+
+	if err := m.validate(s, tenantId); err != nil {
+		return nil, err
+	}
+
+	s.NextRunAt = s.NextRun(now)
+
+	if err := m.s.CreateSchedule(s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (m *ScheduleManager) GetSchedules(tenantId string) ([]*schedule.Schedule, error) {
+	return m.s.GetSchedules(tenantId)
+}
+
+// GetSchedule returns a schedule of the tenant. Every schedule is returned
+// when tenantId is empty.
+func (m *ScheduleManager) GetSchedule(tenantId, id string) (*schedule.Schedule, error) {
+	s, err := m.s.GetSchedule(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tenantId) != 0 && s.TenantId != tenantId {
+		return nil, internal_errors.NewNotFoundError("schedule is not found for: " + id)
+	}
+
+	return s, nil
+}
+
+func (m *ScheduleManager) UpdateSchedule(tenantId, id string, us *schedule.UpdateSchedule) (*schedule.Schedule, error) {
+	s, err := m.GetSchedule(tenantId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	us.Apply(s)
+
+	if err := m.validate(s, tenantId); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	s.UpdatedAt = now.Unix()
+	s.NextRunAt = s.NextRun(now)
+
+	if err := m.s.UpdateSchedule(s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (m *ScheduleManager) DeleteSchedule(tenantId, id string) error {
+	if _, err := m.GetSchedule(tenantId, id); err != nil {
+		return err
+	}
+
+	return m.s.DeleteSchedule(id)
+}
+
+func (m *ScheduleManager) GetScheduleRuns(tenantId, id string) ([]*schedule.Run, error) {
+	if _, err := m.GetSchedule(tenantId, id); err != nil {
+		return nil, err
+	}
+
+	return m.s.GetScheduleRuns(id, maxScheduleRuns)
+}
+
+func (m *ScheduleManager) GetDueSchedules(now time.Time) ([]*schedule.Schedule, error) {
+	return m.s.GetDueSchedules(now.Unix())
+}
+
+// ClaimSchedule moves a due schedule to its next run. Only one instance
+// succeeds in claiming a run, so that every run is sent once.
+func (m *ScheduleManager) ClaimSchedule(s *schedule.Schedule, now time.Time) (bool, error) {
+	return m.s.ClaimSchedule(s.Id, s.NextRunAt, s.NextRun(now), now.Unix())
+}
+
+// GetScheduleKey returns the key that runs of a schedule are sent with.
+func (m *ScheduleManager) GetScheduleKey(s *schedule.Schedule) (*key.ResponseKey, error) {
+	k, err := m.s.GetKey(s.KeyId)
+	if err != nil {
+		return nil, err
+	}
+
+	if k == nil {
+		return nil, internal_errors.NewNotFoundError(fmt.Sprintf("key %s is not found", s.KeyId))
+	}
+
+	return k, nil
+}
+
+func (m *ScheduleManager) CreateScheduleRun(r *schedule.Run) error {
+	return m.s.CreateScheduleRun(r)
+}
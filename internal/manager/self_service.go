@@ -0,0 +1,110 @@
+package manager
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/bricks-cloud/bricksllm/internal/encrypter"
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+)
+
+type costLimitCache interface {
+	GetCounter(keyId string, rateLimitUnit key.TimeUnit) (int64, error)
+}
+
+type selfServiceKeyStorage interface {
+	UpdateKey(id string, key *key.UpdateKey) (*key.ResponseKey, error)
+}
+
+// SelfServiceManager backs the API owners of keys use to look after their
+// own key without going through the admin API.
+type SelfServiceManager struct {
+	km  selfServiceKeyStorage
+	cs  costStorage
+	clc costLimitCache
+}
+
+func NewSelfServiceManager(km selfServiceKeyStorage, cs costStorage, clc costLimitCache) *SelfServiceManager {
+	return &SelfServiceManager{
+		km:  km,
+		cs:  cs,
+		clc: clc,
+	}
+}
+
+func (m *SelfServiceManager) GetUsage(k *key.ResponseKey) (*key.Usage, error) {
+	u := &key.Usage{
+		KeyId:                  k.KeyId,
+		Name:                   k.Name,
+		CostLimitInUsd:         k.CostLimitInUsd,
+		CostLimitInUsdOverTime: k.CostLimitInUsdOverTime,
+		CostLimitInUsdUnit:     k.CostLimitInUsdUnit,
+		PersonalCostLimitInUsd: k.PersonalCostLimitInUsd,
+		RateLimitOverTime:      k.RateLimitOverTime,
+		RateLimitUnit:          k.RateLimitUnit,
+		Ttl:                    k.Ttl,
+	}
+
+	micros, err := m.cs.GetCounter(k.KeyId)
+	if err != nil {
+		return nil, err
+	}
+
+	u.SpendInUsd = float64(micros) / 1000000
+
+	if len(k.CostLimitInUsdUnit) != 0 {
+		micros, err := m.clc.GetCounter(k.KeyId, k.CostLimitInUsdUnit)
+		if err != nil {
+			return nil, err
+		}
+
+		u.PeriodSpendInUsd = float64(micros) / 1000000
+	}
+
+	return u, nil
+}
+
+// RotateKey replaces the secret of a key. The previous secret stops working
+// once the memdb picks up the change.
+func (m *SelfServiceManager) RotateKey(k *key.ResponseKey) (*key.RotatedKey, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	secret := hex.EncodeToString(b)
+	if _, err := m.km.UpdateKey(k.KeyId, &key.UpdateKey{
+		Key: encrypter.Encrypt(secret),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &key.RotatedKey{
+		KeyId: k.KeyId,
+		Key:   secret,
+	}, nil
+}
+
+// SetPersonalCostLimit lowers the periodic cost limit of a key. A limit of 0
+// removes the personal cost limit.
+func (m *SelfServiceManager) SetPersonalCostLimit(k *key.ResponseKey, pcl *key.PersonalCostLimit) (*key.Usage, error) {
+	if k.CostLimitInUsdOverTime <= 0 {
+		return nil, internal_errors.NewValidationError("key does not have a periodic cost limit to lower")
+	}
+
+	limit := pcl.PersonalCostLimitInUsd
+	if limit < 0 || limit >= k.CostLimitInUsdOverTime {
+		return nil, internal_errors.NewValidationError(fmt.Sprintf("personalCostLimitInUsd must be lower than %f", k.CostLimitInUsdOverTime))
+	}
+
+	updated, err := m.km.UpdateKey(k.KeyId, &key.UpdateKey{
+		PersonalCostLimitInUsd: &limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return m.GetUsage(updated)
+}
@@ -0,0 +1,27 @@
+package manager
+
+import (
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+)
+
+// SimulateLimits runs a hypothetical traffic pattern against the limits of
+// the key id without touching its counters.
+func (m *Manager) SimulateLimits(id string, s *key.Simulation) (*key.SimulationReport, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	existing, err := m.s.GetKeys(nil, []string{id}, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(existing) == 0 {
+		return nil, internal_errors.NewNotFoundError("key is not found: " + id)
+	}
+
+	return s.Simulate(existing[0], time.Now()), nil
+}
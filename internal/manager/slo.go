@@ -0,0 +1,59 @@
+package manager
+
+import (
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"go.uber.org/zap"
+)
+
+type sloReporter interface {
+	GetRouteSloReports() ([]*route.SloReport, error)
+}
+
+// SloMonitor periodically recomputes route slo reports so that compliance and
+// remaining error budgets are available as metrics without polling the admin api.
+type SloMonitor struct {
+	r        sloReporter
+	done     chan bool
+	interval time.Duration
+	log      *zap.Logger
+}
+
+func NewSloMonitor(r sloReporter, log *zap.Logger, interval time.Duration) *SloMonitor {
+	return &SloMonitor{
+		r:        r,
+		done:     make(chan bool),
+		interval: interval,
+		log:      log,
+	}
+}
+
+func (sm *SloMonitor) Listen() {
+	ticker := time.NewTicker(sm.interval)
+	sm.log.Info("slo monitor started reporting route slos")
+
+	go func() {
+		for {
+			select {
+			case <-sm.done:
+				ticker.Stop()
+				sm.log.Info("slo monitor stopped")
+				return
+			case <-ticker.C:
+				if _, err := sm.r.GetRouteSloReports(); err != nil {
+					stats.Incr("bricksllm.manager.slo_monitor.listen.get_route_slo_reports_error", nil, 1)
+
+					sm.log.Sugar().Debugf("slo monitor failed to get route slo reports: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+func (sm *SloMonitor) Stop() {
+	sm.log.Info("shutting down slo monitor...")
+
+	sm.done <- true
+}
@@ -0,0 +1,83 @@
+package manager
+
+import (
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/bundle"
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+func (m *BundleManager) snapshotSecret() (string, error) {
+	if len(m.secret) == 0 {
+		return "", internal_errors.NewValidationError("CONFIG_EXPORT_SECRET is not set")
+	}
+
+	return m.secret, nil
+}
+
+// ExportSnapshot returns the configuration of the cluster signed with the
+// export secret.
+func (m *BundleManager) ExportSnapshot() (*bundle.SignedSnapshot, error) {
+	secret, err := m.snapshotSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := m.s.GetProviderSettings(true, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := m.s.GetPolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := m.s.GetAllKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	routes, err := m.s.GetRoutes()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &bundle.Snapshot{
+		Version:          bundle.SnapshotVersion,
+		CreatedAt:        time.Now().Unix(),
+		ProviderSettings: settings,
+		Policies:         policies,
+		Keys:             []*bundle.SnapshotKey{},
+		Routes:           routes,
+	}
+
+	for _, k := range keys {
+		if k.Revoked {
+			continue
+		}
+
+		s.Keys = append(s.Keys, &bundle.SnapshotKey{
+			RequestKey:             k.Request(),
+			PersonalCostLimitInUsd: k.PersonalCostLimitInUsd,
+		})
+	}
+
+	return bundle.Sign(s, secret)
+}
+
+// ImportSnapshot creates the resources of a signed snapshot that do not exist
+// in the cluster yet.
+func (m *BundleManager) ImportSnapshot(ss *bundle.SignedSnapshot) (*bundle.ImportReport, error) {
+	secret, err := m.snapshotSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := ss.Open(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.s.ImportSnapshot(s, time.Now().Unix())
+}
@@ -0,0 +1,95 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/bricks-cloud/bricksllm/internal/bundle"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeBundleStorage struct {
+	BundleStorage
+	keys     []*key.ResponseKey
+	imported *bundle.Snapshot
+}
+
+func (s *fakeBundleStorage) GetProviderSettings(withSecret bool, ids []string) ([]*provider.Setting, error) {
+	return []*provider.Setting{{Id: "s1", Provider: "openai"}}, nil
+}
+
+func (s *fakeBundleStorage) GetPolicies() ([]*policy.Policy, error) {
+	return []*policy.Policy{}, nil
+}
+
+func (s *fakeBundleStorage) GetAllKeys() ([]*key.ResponseKey, error) {
+	return s.keys, nil
+}
+
+func (s *fakeBundleStorage) GetRoutes() ([]*route.Route, error) {
+	return []*route.Route{}, nil
+}
+
+func (s *fakeBundleStorage) ImportSnapshot(snapshot *bundle.Snapshot, importedAt int64) (*bundle.ImportReport, error) {
+	s.imported = snapshot
+	return &bundle.ImportReport{ImportedAt: importedAt}, nil
+}
+
+func TestBundleManager_ExportSnapshot(t *testing.T) {
+	s := &fakeBundleStorage{keys: []*key.ResponseKey{
+		{KeyId: "active", Name: "active", PersonalCostLimitInUsd: 5},
+		{KeyId: "revoked", Name: "revoked", Revoked: true},
+	}}
+
+	ss, err := NewBundleManager(s, nil, nil, "secret").ExportSnapshot()
+	require.NoError(t, err)
+
+	snapshot, err := ss.Open("secret")
+	require.NoError(t, err)
+
+	require.Len(t, snapshot.Keys, 1)
+	assert.Equal(t, "active", snapshot.Keys[0].Name)
+	assert.Equal(t, float64(5), snapshot.Keys[0].PersonalCostLimitInUsd)
+	assert.Len(t, snapshot.ProviderSettings, 1)
+
+	t.Run("clusters without an export secret", func(t *testing.T) {
+		_, err := NewBundleManager(s, nil, nil, "").ExportSnapshot()
+		assert.Error(t, err)
+	})
+}
+
+func TestBundleManager_ImportSnapshot(t *testing.T) {
+	signed, err := bundle.Sign(&bundle.Snapshot{Version: bundle.SnapshotVersion}, "secret")
+	require.NoError(t, err)
+
+	cases := []struct {
+		name     string
+		secret   string
+		imported bool
+	}{
+		{name: "snapshots signed with the secret of the cluster", secret: "secret", imported: true},
+		{name: "snapshots signed with other secrets", secret: "other", imported: false},
+		{name: "clusters without an export secret", secret: "", imported: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &fakeBundleStorage{}
+			report, err := NewBundleManager(s, nil, nil, tc.secret).ImportSnapshot(signed)
+
+			if !tc.imported {
+				assert.Error(t, err)
+				assert.Nil(t, s.imported)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NotZero(t, report.ImportedAt)
+			assert.NotNil(t, s.imported)
+		})
+	}
+}
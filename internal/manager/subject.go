@@ -0,0 +1,336 @@
+package manager
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/encrypter"
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/privacy"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"go.uber.org/zap"
+)
+
+const (
+	subjectRequestBatchSize = 500
+	// subjectRequestTimeout is how long a subject request can run before
+	// another instance claims it again.
+	subjectRequestTimeout = time.Hour
+)
+
+type SubjectStorage interface {
+	CreateSubjectRequest(r *event.SubjectRequest) (*event.SubjectRequest, error)
+	GetSubjectRequest(id string) (*event.SubjectRequest, error)
+	GetSubjectExport(id string) ([]byte, error)
+	ClaimSubjectRequest(now, staleBefore int64) (*event.SubjectRequest, error)
+	FinishSubjectRequest(r *event.SubjectRequest, export []byte) error
+	GetSubjectEvents(r *event.SubjectRequest, afterCreatedAt int64, afterId string, limit int) ([]*event.Event, error)
+	GetRecordedRequests(eventIds []string) ([]*event.RecordedRequest, error)
+	DeleteSubjectEvents(eventIds []string) (*event.SubjectReport, error)
+	GetRecordingKey(tenantId string) ([]byte, error)
+}
+
+// SubjectManager accepts subject access and deletion requests for end users
+// and processes them in the background.
+type SubjectManager struct {
+	s        SubjectStorage
+	done     chan bool
+	interval time.Duration
+	log      *zap.Logger
+	pz       *privacy.Pseudonymizer
+	lookback time.Duration
+}
+
+// NewSubjectManager matches the pseudonyms an end user had within lookback
+// when user ids of events are pseudonymized.
+func NewSubjectManager(s SubjectStorage, log *zap.Logger, interval time.Duration, pz *privacy.Pseudonymizer, lookback time.Duration) *SubjectManager {
+	return &SubjectManager{
+		s:        s,
+		done:     make(chan bool),
+		interval: interval,
+		log:      log,
+		pz:       pz,
+		lookback: lookback,
+	}
+}
+
+func (m *SubjectManager) CreateSubjectRequest(r *event.SubjectRequest) (*event.SubjectRequest, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	r.Id = util.NewUuid()
+	r.CreatedAt = time.Now().Unix()
+	r.UpdatedAt = time.Now().Unix()
+	r.Status = event.SubjectRequestStatusPending
+	r.Report = nil
+	r.Error = ""
+
+	return m.s.CreateSubjectRequest(r)
+}
+
+// GetSubjectRequest returns a subject request. Requests of other tenants are
+// reported as not found.
+func (m *SubjectManager) GetSubjectRequest(tenantId, id string) (*event.SubjectRequest, error) {
+	r, err := m.s.GetSubjectRequest(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tenantId) != 0 && r.TenantId != tenantId {
+		return nil, internal_errors.NewNotFoundError("subject request is not found for: " + id)
+	}
+
+	return r, nil
+}
+
+func (m *SubjectManager) GetSubjectExport(tenantId, id string) ([]byte, error) {
+	r, err := m.GetSubjectRequest(tenantId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Action != event.SubjectRequestActionExport {
+		return nil, internal_errors.NewValidationError("subject request is not an export")
+	}
+
+	if r.Status != event.SubjectRequestStatusCompleted {
+		return nil, internal_errors.NewValidationError("subject request is " + string(r.Status))
+	}
+
+	return m.s.GetSubjectExport(id)
+}
+
+func (m *SubjectManager) export(r *event.SubjectRequest) (*event.SubjectReport, []byte, error) {
+	report := &event.SubjectReport{}
+	export := &event.SubjectExport{
+		UserId:           r.UserId,
+		Events:           []*event.Event{},
+		RecordedRequests: []*event.SubjectRecording{},
+	}
+
+	keys := map[string][]byte{}
+	afterCreatedAt, afterId := int64(0), ""
+	for {
+		events, err := m.s.GetSubjectEvents(r, afterCreatedAt, afterId, subjectRequestBatchSize)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(events) == 0 {
+			break
+		}
+
+		ids := []string{}
+		for _, e := range events {
+			ids = append(ids, e.Id)
+		}
+
+		recorded, err := m.s.GetRecordedRequests(ids)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, rr := range recorded {
+			ok, err := m.open(rr, keys)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if !ok {
+				report.UnreadableRecordedRequests++
+				continue
+			}
+
+			export.RecordedRequests = append(export.RecordedRequests, &event.SubjectRecording{
+				EventId:   rr.EventId,
+				CreatedAt: rr.CreatedAt,
+				Request:   string(rr.Request),
+				Response:  string(rr.Response),
+			})
+		}
+
+		export.Events = append(export.Events, events...)
+
+		last := events[len(events)-1]
+		afterCreatedAt, afterId = last.CreatedAt, last.Id
+	}
+
+	report.Events = len(export.Events)
+	report.RecordedRequests = len(export.RecordedRequests)
+
+	data, err := json.Marshal(export)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return report, data, nil
+}
+
+// open decrypts a recorded request with the recording key of its tenant. It
+// returns false for recordings whose key was deleted.
+func (m *SubjectManager) open(rr *event.RecordedRequest, keys map[string][]byte) (bool, error) {
+	if !rr.Encrypted {
+		return true, nil
+	}
+
+	rk, ok := keys[rr.TenantId]
+	if !ok {
+		retrieved, err := m.s.GetRecordingKey(rr.TenantId)
+		if err != nil {
+			if _, ok := err.(notFoundError); !ok {
+				return false, err
+			}
+		}
+
+		keys[rr.TenantId] = retrieved
+		rk = retrieved
+	}
+
+	if rk == nil {
+		return false, nil
+	}
+
+	request, err := encrypter.Open(rk, rr.Request)
+	if err != nil {
+		return false, nil
+	}
+
+	response, err := encrypter.Open(rk, rr.Response)
+	if err != nil {
+		return false, nil
+	}
+
+	rr.Request = request
+	rr.Response = response
+
+	return true, nil
+}
+
+func (m *SubjectManager) delete(r *event.SubjectRequest) (*event.SubjectReport, error) {
+	report := &event.SubjectReport{}
+
+	for {
+		// deleted events drop out of the results, so the first page is
+		// always the next batch.
+		events, err := m.s.GetSubjectEvents(r, 0, "", subjectRequestBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(events) == 0 {
+			break
+		}
+
+		ids := []string{}
+		for _, e := range events {
+			ids = append(ids, e.Id)
+		}
+
+		deleted, err := m.s.DeleteSubjectEvents(ids)
+		if err != nil {
+			return nil, err
+		}
+
+		report.Events += deleted.Events
+		report.RecordedRequests += deleted.RecordedRequests
+		report.ShadowResults += deleted.ShadowResults
+		report.Samples += deleted.Samples
+
+		if deleted.Events == 0 {
+			break
+		}
+	}
+
+	return report, nil
+}
+
+func (m *SubjectManager) process(r *event.SubjectRequest) {
+	var report *event.SubjectReport
+	var export []byte
+	var err error
+
+	if m.pz != nil && len(r.MetadataKey) == 0 {
+		now := time.Now()
+		r.Pseudonyms = m.pz.Pseudonyms(r.UserId, now.Add(-m.lookback), now)
+	}
+
+	switch r.Action {
+	case event.SubjectRequestActionExport:
+		report, export, err = m.export(r)
+	case event.SubjectRequestActionDelete:
+		report, err = m.delete(r)
+	}
+
+	r.UpdatedAt = time.Now().Unix()
+	if err != nil {
+		stats.Incr("bricksllm.manager.subject_manager.process.error", []string{
+			"action:" + string(r.Action),
+		}, 1)
+
+		m.log.Sugar().Debugf("subject manager failed to process subject request %s: %v", r.Id, err)
+
+		r.Status = event.SubjectRequestStatusFailed
+		r.Error = err.Error()
+	} else {
+		stats.Incr("bricksllm.manager.subject_manager.process.success", []string{
+			"action:" + string(r.Action),
+		}, 1)
+
+		report.CompletedAt = r.UpdatedAt
+		r.Status = event.SubjectRequestStatusCompleted
+		r.Report = report
+	}
+
+	if err := m.s.FinishSubjectRequest(r, export); err != nil {
+		stats.Incr("bricksllm.manager.subject_manager.process.finish_subject_request_error", nil, 1)
+
+		m.log.Sugar().Debugf("subject manager failed to finish subject request %s: %v", r.Id, err)
+	}
+}
+
+// processPending works through the pending subject requests until none is
+// left.
+func (m *SubjectManager) processPending() {
+	for {
+		now := time.Now()
+		r, err := m.s.ClaimSubjectRequest(now.Unix(), now.Add(-subjectRequestTimeout).Unix())
+		if err != nil {
+			if _, ok := err.(notFoundError); !ok {
+				stats.Incr("bricksllm.manager.subject_manager.listen.claim_subject_request_error", nil, 1)
+
+				m.log.Sugar().Debugf("subject manager failed to claim subject request: %v", err)
+			}
+
+			return
+		}
+
+		m.process(r)
+	}
+}
+
+func (m *SubjectManager) Listen() {
+	ticker := time.NewTicker(m.interval)
+	m.log.Info("subject manager started processing subject requests")
+
+	go func() {
+		for {
+			select {
+			case <-m.done:
+				ticker.Stop()
+				m.log.Info("subject manager stopped")
+				return
+			case <-ticker.C:
+				m.processPending()
+			}
+		}
+	}()
+}
+
+func (m *SubjectManager) Stop() {
+	m.log.Info("shutting down subject manager...")
+
+	m.done <- true
+}
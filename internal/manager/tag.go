@@ -0,0 +1,69 @@
+package manager
+
+import (
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/tag"
+)
+
+type TagsStorage interface {
+	CreateTag(t *tag.Tag) (*tag.Tag, error)
+	GetTags() ([]*tag.Tag, error)
+	UpdateTag(name string, ut *tag.UpdateTag) (*tag.Tag, error)
+	DeleteTag(name string) error
+}
+
+type TagManager struct {
+	s TagsStorage
+}
+
+func NewTagManager(s TagsStorage) *TagManager {
+	return &TagManager{
+		s: s,
+	}
+}
+
+func (m *TagManager) CreateTag(t *tag.Tag) (*tag.Tag, error) {
+	t.CreatedAt = time.Now().Unix()
+	t.UpdatedAt = time.Now().Unix()
+
+	if t.AllowedValues == nil {
+		t.AllowedValues = []string{}
+	}
+
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+
+	created, err := m.s.CreateTag(t)
+	if err != nil {
+		if _, ok := err.(duplicationError); ok {
+			return nil, internal_errors.NewConflictError(err.Error())
+		}
+
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (m *TagManager) GetTags() ([]*tag.Tag, error) {
+	return m.s.GetTags()
+}
+
+// UpdateTag changes a tag of the taxonomy. Keys that already use a value
+// removed from the allowed values keep it until their tags are updated.
+func (m *TagManager) UpdateTag(name string, ut *tag.UpdateTag) (*tag.Tag, error) {
+	ut.UpdatedAt = time.Now().Unix()
+
+	if err := ut.Validate(); err != nil {
+		return nil, err
+	}
+
+	return m.s.UpdateTag(name, ut)
+}
+
+func (m *TagManager) DeleteTag(name string) error {
+	return m.s.DeleteTag(name)
+}
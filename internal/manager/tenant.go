@@ -0,0 +1,107 @@
+package manager
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/encrypter"
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/tenant"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+)
+
+type TenantsStorage interface {
+	CreateTenant(t *tenant.Tenant) (*tenant.Tenant, error)
+	GetTenant(id string) (*tenant.Tenant, error)
+	GetTenants() ([]*tenant.Tenant, error)
+	UpdateTenant(id string, ut *tenant.UpdateTenant, tokenHash string) (*tenant.Tenant, error)
+}
+
+type TenantManager struct {
+	s TenantsStorage
+}
+
+func NewTenantManager(s TenantsStorage) *TenantManager {
+	return &TenantManager{
+		s: s,
+	}
+}
+
+func newToken(prefix string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return prefix + hex.EncodeToString(b), nil
+}
+
+func (m *TenantManager) CreateTenant(t *tenant.Tenant) (*tenant.Tenant, error) {
+	t.Id = util.NewUuid()
+	t.CreatedAt = time.Now().Unix()
+	t.UpdatedAt = time.Now().Unix()
+
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+
+	token, err := newToken("tenant-")
+	if err != nil {
+		return nil, err
+	}
+
+	t.AdminTokenHash = encrypter.Encrypt(token)
+
+	created, err := m.s.CreateTenant(t)
+	if err != nil {
+		if _, ok := err.(duplicationError); ok {
+			return nil, internal_errors.NewValidationError(err.Error())
+		}
+
+		return nil, err
+	}
+
+	created.AdminToken = token
+
+	return created, nil
+}
+
+func (m *TenantManager) GetTenant(id string) (*tenant.Tenant, error) {
+	return m.s.GetTenant(id)
+}
+
+func (m *TenantManager) GetTenants() ([]*tenant.Tenant, error) {
+	return m.s.GetTenants()
+}
+
+// UpdateTenant issues a new admin token when asked to. The previous token
+// stops working once the memdb picks up the change.
+func (m *TenantManager) UpdateTenant(id string, ut *tenant.UpdateTenant) (*tenant.Tenant, error) {
+	ut.UpdatedAt = time.Now().Unix()
+
+	if err := ut.Validate(); err != nil {
+		return nil, err
+	}
+
+	token := ""
+	tokenHash := ""
+	if ut.RotateToken {
+		var err error
+		token, err = newToken("tenant-")
+		if err != nil {
+			return nil, err
+		}
+
+		tokenHash = encrypter.Encrypt(token)
+	}
+
+	updated, err := m.s.UpdateTenant(id, ut, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+
+	updated.AdminToken = token
+
+	return updated, nil
+}
@@ -0,0 +1,202 @@
+package manager
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/bricks-cloud/bricksllm/internal/watch"
+	"go.uber.org/zap"
+)
+
+// subscribers that fall this far behind are disconnected so that they
+// reconnect and fetch the current configuration instead of missing changes.
+const watchBufferSize = 256
+
+type configStorage interface {
+	GetUpdatedKeys(updatedAt int64) ([]*key.ResponseKey, error)
+	GetUpdatedRoutes(updatedAt int64) ([]*route.Route, error)
+	GetUpdatedProviderSettings(updatedAt int64) ([]*provider.Setting, error)
+}
+
+// ConfigWatcher polls the database for keys, routes and provider settings
+// changed by any admin server and notifies its subscribers.
+type ConfigWatcher struct {
+	s           configStorage
+	subscribers map[chan *watch.Change]bool
+	lock        sync.Mutex
+	seen        map[string]int64
+	lastUpdated int64
+	done        chan bool
+	interval    time.Duration
+	log         *zap.Logger
+}
+
+func NewConfigWatcher(s configStorage, log *zap.Logger, interval time.Duration) *ConfigWatcher {
+	return &ConfigWatcher{
+		s:           s,
+		subscribers: map[chan *watch.Change]bool{},
+		seen:        map[string]int64{},
+		lastUpdated: time.Now().Unix(),
+		done:        make(chan bool),
+		interval:    interval,
+		log:         log,
+	}
+}
+
+// Subscribe returns a channel receiving every change and a function that
+// ends the subscription. The channel is closed when the subscriber falls
+// behind.
+func (cw *ConfigWatcher) Subscribe() (<-chan *watch.Change, func()) {
+	ch := make(chan *watch.Change, watchBufferSize)
+
+	cw.lock.Lock()
+	cw.subscribers[ch] = true
+	cw.lock.Unlock()
+
+	stats.Incr("bricksllm.manager.config_watcher.subscribe", nil, 1)
+
+	return ch, func() {
+		cw.lock.Lock()
+		defer cw.lock.Unlock()
+
+		if cw.subscribers[ch] {
+			delete(cw.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Notify publishes changes that cannot be picked up from the database such
+// as deletions.
+func (cw *ConfigWatcher) Notify(c *watch.Change) {
+	cw.lock.Lock()
+	defer cw.lock.Unlock()
+
+	for ch := range cw.subscribers {
+		select {
+		case ch <- c:
+		default:
+			stats.Incr("bricksllm.manager.config_watcher.notify.slow_subscriber", nil, 1)
+
+			delete(cw.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// changed reports whether a resource was not published at this update time
+// yet. Queries include the last update time, so rows are returned more than
+// once.
+func (cw *ConfigWatcher) changed(resource, id string, updatedAt int64) bool {
+	k := resource + ":" + id
+	if cw.seen[k] >= updatedAt {
+		return false
+	}
+
+	cw.seen[k] = updatedAt
+	return true
+}
+
+func (cw *ConfigWatcher) poll() error {
+	since := cw.lastUpdated
+	changes := []*watch.Change{}
+
+	for k, updatedAt := range cw.seen {
+		if updatedAt < since {
+			delete(cw.seen, k)
+		}
+	}
+
+	keys, err := cw.s.GetUpdatedKeys(since)
+	if err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		if cw.changed(watch.ResourceKey, k.KeyId, k.UpdatedAt) {
+			changes = append(changes, &watch.Change{
+				Resource:  watch.ResourceKey,
+				Action:    watch.GetAction(k.CreatedAt, k.UpdatedAt),
+				Id:        k.KeyId,
+				TenantId:  k.TenantId,
+				UpdatedAt: k.UpdatedAt,
+			})
+		}
+	}
+
+	routes, err := cw.s.GetUpdatedRoutes(since)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range routes {
+		if cw.changed(watch.ResourceRoute, r.Id, r.UpdatedAt) {
+			changes = append(changes, &watch.Change{
+				Resource:  watch.ResourceRoute,
+				Action:    watch.GetAction(r.CreatedAt, r.UpdatedAt),
+				Id:        r.Id,
+				TenantId:  r.TenantId,
+				UpdatedAt: r.UpdatedAt,
+			})
+		}
+	}
+
+	settings, err := cw.s.GetUpdatedProviderSettings(since)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range settings {
+		if cw.changed(watch.ResourceProviderSetting, s.Id, s.UpdatedAt) {
+			changes = append(changes, &watch.Change{
+				Resource:  watch.ResourceProviderSetting,
+				Action:    watch.GetAction(s.CreatedAt, s.UpdatedAt),
+				Id:        s.Id,
+				TenantId:  s.TenantId,
+				UpdatedAt: s.UpdatedAt,
+			})
+		}
+	}
+
+	for _, c := range changes {
+		if c.UpdatedAt > cw.lastUpdated {
+			cw.lastUpdated = c.UpdatedAt
+		}
+
+		cw.Notify(c)
+	}
+
+	return nil
+}
+
+func (cw *ConfigWatcher) Listen() {
+	ticker := time.NewTicker(cw.interval)
+	cw.log.Info("config watcher started watching for configuration changes")
+
+	go func() {
+		for {
+			select {
+			case <-cw.done:
+				ticker.Stop()
+				cw.log.Info("config watcher stopped")
+				return
+			case <-ticker.C:
+				if err := cw.poll(); err != nil {
+					stats.Incr("bricksllm.manager.config_watcher.listen.poll_error", nil, 1)
+
+					cw.log.Sugar().Debugf("config watcher failed to poll configuration changes: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+func (cw *ConfigWatcher) Stop() {
+	cw.log.Info("shutting down config watcher...")
+
+	cw.done <- true
+}
@@ -16,7 +16,10 @@ type Consumer struct {
 
 type recorder interface {
 	RecordKeySpend(keyId string, micros int64, costLimitUnit key.TimeUnit) error
+	RecordSessionUsage(keyId, sessionId string, tokens int, micros int64) error
 	RecordEvent(e *event.Event) error
+	RecordRequest(r *event.RecordedRequest) error
+	RecordSample(s *event.Sample) error
 }
 
 func NewConsumer(mc <-chan Message, log *zap.Logger, num int, handle func(Message) error) *Consumer {
@@ -2,6 +2,7 @@ package message
 
 import (
 	"errors"
+	"net/http"
 	"strings"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
 	"github.com/bricks-cloud/bricksllm/internal/provider/custom"
 	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/bricks-cloud/bricksllm/internal/util"
 	"github.com/tidwall/gjson"
 	"go.uber.org/zap"
 
@@ -234,6 +236,15 @@ func (h *Handler) HandleEventWithRequestAndResponse(m Message) error {
 			}
 		}
 
+		if len(e.Event.SessionId) != 0 {
+			tokens := e.Event.PromptTokenCount + e.Event.CompletionTokenCount
+			micros := int64(e.Event.CostInUsd * 1000000)
+			if err := h.recorder.RecordSessionUsage(e.Event.KeyId, e.Event.SessionId, tokens, micros); err != nil {
+				stats.Incr("bricksllm.message.handler.handle_event_with_request_and_response.record_session_usage_error", nil, 1)
+				h.log.Debug("error when recording session usage", zap.Error(err))
+			}
+		}
+
 		// tested
 		if len(e.Key.RateLimitUnit) != 0 {
 			if err := h.rlm.Increment(e.Key.KeyId, e.Key.RateLimitUnit); err != nil {
@@ -260,6 +271,41 @@ func (h *Handler) HandleEventWithRequestAndResponse(m Message) error {
 		return err
 	}
 
+	if e.RecordedRequest != nil {
+		e.RecordedRequest.EventId = e.Event.Id
+		e.RecordedRequest.CreatedAt = e.Event.CreatedAt
+		e.RecordedRequest.TenantId = e.Event.TenantId
+
+		if e.Key != nil {
+			if retention := e.Key.GetRecordingRetention(); retention > 0 {
+				e.RecordedRequest.ExpiresAt = e.Event.CreatedAt + int64(retention.Seconds())
+			}
+		}
+
+		err = h.recorder.RecordRequest(e.RecordedRequest)
+		if err != nil {
+			stats.Incr("bricksllm.message.handler.handle_event_with_request_and_response.record_request_error", nil, 1)
+			h.log.Debug("error when recording request", zap.Error(err))
+		}
+	}
+
+	// only successful completions are useful for evaluations.
+	if e.Sample != nil && e.Event.Status == http.StatusOK {
+		e.Sample.Id = util.NewUuid()
+		e.Sample.EventId = e.Event.Id
+		e.Sample.CreatedAt = e.Event.CreatedAt
+		e.Sample.TenantId = e.Event.TenantId
+		e.Sample.KeyId = e.Event.KeyId
+		e.Sample.Provider = e.Event.Provider
+		e.Sample.Model = e.Event.Model
+
+		err = h.recorder.RecordSample(e.Sample)
+		if err != nil {
+			stats.Incr("bricksllm.message.handler.handle_event_with_request_and_response.record_sample_error", nil, 1)
+			h.log.Debug("error when recording sample", zap.Error(err))
+		}
+	}
+
 	stats.Timing("bricksllm.message.handler.handle_event_with_request_and_response.latency", time.Now().Sub(start), nil, 1)
 	stats.Incr("bricksllm.message.handler.handle_event_with_request_and_response.success", nil, 1)
 
@@ -380,16 +426,48 @@ func (h *Handler) decorateEvent(m Message) error {
 			return errors.New("event request data cannot be parsed as anthropic completon request")
 		}
 
-		tks, err := countTokensFromJson(body, e.RouteConfig.RequestPromptLocation)
-		if err != nil {
-			stats.Incr("bricksllm.message.handler.decorate_event.count_tokens_from_json_error", nil, 1)
+		usage := &custom.Usage{}
+		result := gjson.Get(string(body), e.RouteConfig.StreamLocation)
+		if result.IsBool() && result.Bool() {
+			// usage found in stream chunks is already set on the event by the proxy
+			if e.Event.PromptTokenCount != 0 {
+				usage.PromptTokens = &e.Event.PromptTokenCount
+			}
 
-			return err
+			if e.Event.CompletionTokenCount != 0 {
+				usage.CompletionTokens = &e.Event.CompletionTokenCount
+			}
 		}
 
-		e.Event.PromptTokenCount = tks
+		content, isResponse := e.Response.([]byte)
+		if isResponse {
+			e.RouteConfig.ExtractUsage(content, usage)
+		}
+
+		if usage.PromptTokens != nil {
+			e.Event.PromptTokenCount = *usage.PromptTokens
+		}
+
+		if usage.PromptTokens == nil {
+			tks, err := countTokensFromJson(body, e.RouteConfig.RequestPromptLocation)
+			if err != nil {
+				stats.Incr("bricksllm.message.handler.decorate_event.count_tokens_from_json_error", nil, 1)
+
+				return err
+			}
+
+			e.Event.PromptTokenCount = tks
+		}
+
+		if usage.CostInUsd != nil {
+			e.Event.CostInUsd = *usage.CostInUsd
+		}
+
+		if usage.CompletionTokens != nil {
+			e.Event.CompletionTokenCount = *usage.CompletionTokens
+			return nil
+		}
 
-		result := gjson.Get(string(body), e.RouteConfig.StreamLocation)
 		if result.IsBool() {
 			completiontks, err := custom.Count(e.Content)
 			if err != nil {
@@ -401,8 +479,7 @@ func (h *Handler) decorateEvent(m Message) error {
 		}
 
 		if !result.IsBool() {
-			content, ok := e.Response.([]byte)
-			if !ok {
+			if !isResponse {
 				stats.Incr("bricksllm.message.handler.decorate_event.event_response_custom_provider_parsing_error", nil, 1)
 				h.log.Debug("event contains response that cannot be converted to bytes", zap.Any("data", m.Data))
 				return errors.New("event response data cannot be converted to bytes")
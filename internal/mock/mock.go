@@ -0,0 +1,110 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+// ProviderName is the provider of settings that make the proxy answer with
+// mock responses instead of calling the upstream provider.
+const ProviderName = "mock"
+
+// AnyModel is the model of the mock response used for models without one.
+const AnyModel = "*"
+
+// Response is a canned or recorded answer served to keys using a mock
+// provider setting. Recorded responses set Body, which is returned as is.
+type Response struct {
+	Id              string          `json:"id"`
+	CreatedAt       int64           `json:"createdAt"`
+	UpdatedAt       int64           `json:"updatedAt"`
+	Model           string          `json:"model"`
+	Content         string          `json:"content"`
+	Body            json.RawMessage `json:"body,omitempty"`
+	LatencyInMs     int             `json:"latencyInMs"`
+	ChunkDelayInMs  int             `json:"chunkDelayInMs"`
+	ErrorRate       float64         `json:"errorRate"`
+	ErrorStatusCode int             `json:"errorStatusCode"`
+}
+
+func validate(invalid []string, body *json.RawMessage, latencyInMs, chunkDelayInMs *int, errorRate *float64, errorStatusCode *int) []string {
+	if body != nil && len(*body) != 0 && !json.Valid(*body) {
+		invalid = append(invalid, "body")
+	}
+
+	if latencyInMs != nil && *latencyInMs < 0 {
+		invalid = append(invalid, "latencyInMs")
+	}
+
+	if chunkDelayInMs != nil && *chunkDelayInMs < 0 {
+		invalid = append(invalid, "chunkDelayInMs")
+	}
+
+	if errorRate != nil && (*errorRate < 0 || *errorRate > 1) {
+		invalid = append(invalid, "errorRate")
+	}
+
+	if errorStatusCode != nil && *errorStatusCode != 0 && (*errorStatusCode < 400 || *errorStatusCode > 599) {
+		invalid = append(invalid, "errorStatusCode")
+	}
+
+	return invalid
+}
+
+func (r *Response) Validate() error {
+	invalid := []string{}
+
+	if len(r.Model) == 0 {
+		invalid = append(invalid, "model")
+	}
+
+	if len(r.Content) == 0 && len(r.Body) == 0 {
+		invalid = append(invalid, "content")
+	}
+
+	invalid = validate(invalid, &r.Body, &r.LatencyInMs, &r.ChunkDelayInMs, &r.ErrorRate, &r.ErrorStatusCode)
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
+
+// ShouldFail decides whether the current request simulates an error.
+func (r *Response) ShouldFail() bool {
+	return r.ErrorRate > 0 && rand.Float64() < r.ErrorRate
+}
+
+func (r *Response) GetErrorStatusCode() int {
+	if r.ErrorStatusCode == 0 {
+		return http.StatusInternalServerError
+	}
+
+	return r.ErrorStatusCode
+}
+
+type UpdateResponse struct {
+	UpdatedAt       int64            `json:"updatedAt"`
+	Content         *string          `json:"content"`
+	Body            *json.RawMessage `json:"body"`
+	LatencyInMs     *int             `json:"latencyInMs"`
+	ChunkDelayInMs  *int             `json:"chunkDelayInMs"`
+	ErrorRate       *float64         `json:"errorRate"`
+	ErrorStatusCode *int             `json:"errorStatusCode"`
+}
+
+func (ur *UpdateResponse) Validate() error {
+	invalid := validate([]string{}, ur.Body, ur.LatencyInMs, ur.ChunkDelayInMs, ur.ErrorRate, ur.ErrorStatusCode)
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
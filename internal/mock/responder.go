@@ -0,0 +1,162 @@
+package mock
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+const (
+	// DefaultContent is returned for models without a mock response.
+	DefaultContent = "This is a mock response from BricksLLM."
+
+	embeddingDimensions = 1536
+)
+
+type ErrorResponse struct {
+	Error *ErrorDetail `json:"error"`
+}
+
+type ErrorDetail struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+}
+
+func NewErrorResponse(message string) *ErrorResponse {
+	return &ErrorResponse{
+		Error: &ErrorDetail{
+			Message: message,
+			Type:    "mock_error",
+		},
+	}
+}
+
+func countTokens(content string) int {
+	return len(strings.Fields(content))
+}
+
+func NewChatCompletionResponse(model, content string) *goopenai.ChatCompletionResponse {
+	return &goopenai.ChatCompletionResponse{
+		ID:      "chatcmpl-mock-" + util.NewUuid(),
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   model,
+		Choices: []goopenai.ChatCompletionChoice{
+			{
+				Index: 0,
+				Message: goopenai.ChatCompletionMessage{
+					Role:    goopenai.ChatMessageRoleAssistant,
+					Content: content,
+				},
+				FinishReason: goopenai.FinishReasonStop,
+			},
+		},
+		Usage: goopenai.Usage{
+			CompletionTokens: countTokens(content),
+			TotalTokens:      countTokens(content),
+		},
+	}
+}
+
+// NewChatCompletionChunks splits the content into one streaming chunk per
+// word, followed by a chunk carrying the finish reason.
+func NewChatCompletionChunks(model, content string) []*goopenai.ChatCompletionStreamResponse {
+	id := "chatcmpl-mock-" + util.NewUuid()
+	created := time.Now().Unix()
+
+	newChunk := func(delta goopenai.ChatCompletionStreamChoiceDelta, finishReason goopenai.FinishReason) *goopenai.ChatCompletionStreamResponse {
+		return &goopenai.ChatCompletionStreamResponse{
+			ID:      id,
+			Object:  "chat.completion.chunk",
+			Created: created,
+			Model:   model,
+			Choices: []goopenai.ChatCompletionStreamChoice{
+				{
+					Index:        0,
+					Delta:        delta,
+					FinishReason: finishReason,
+				},
+			},
+		}
+	}
+
+	chunks := []*goopenai.ChatCompletionStreamResponse{
+		newChunk(goopenai.ChatCompletionStreamChoiceDelta{Role: goopenai.ChatMessageRoleAssistant}, ""),
+	}
+
+	words := strings.SplitAfter(content, " ")
+	for _, word := range words {
+		if len(word) == 0 {
+			continue
+		}
+
+		chunks = append(chunks, newChunk(goopenai.ChatCompletionStreamChoiceDelta{Content: word}, ""))
+	}
+
+	return append(chunks, newChunk(goopenai.ChatCompletionStreamChoiceDelta{}, goopenai.FinishReasonStop))
+}
+
+// NewEmbeddingResponse returns deterministic vectors so that the same input
+// always maps to the same embedding.
+func NewEmbeddingResponse(model string, inputs []string) *goopenai.EmbeddingResponse {
+	data := []goopenai.Embedding{}
+	tokens := 0
+
+	for index, input := range inputs {
+		h := fnv.New64a()
+		h.Write([]byte(input))
+
+		r := rand.New(rand.NewSource(int64(h.Sum64())))
+		vector := make([]float32, embeddingDimensions)
+		for i := range vector {
+			vector[i] = r.Float32()*2 - 1
+		}
+
+		data = append(data, goopenai.Embedding{
+			Object:    "embedding",
+			Embedding: vector,
+			Index:     index,
+		})
+
+		tokens += countTokens(input)
+	}
+
+	return &goopenai.EmbeddingResponse{
+		Object: "list",
+		Data:   data,
+		Model:  goopenai.EmbeddingModel(model),
+		Usage: goopenai.Usage{
+			PromptTokens: tokens,
+			TotalTokens:  tokens,
+		},
+	}
+}
+
+// ParseEmbeddingInputs reads the input of an embeddings request, which can
+// either be a string or an array of strings.
+func ParseEmbeddingInputs(body []byte) []string {
+	request := struct {
+		Input json.RawMessage `json:"input"`
+	}{}
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return []string{}
+	}
+
+	single := ""
+	if err := json.Unmarshal(request.Input, &single); err == nil {
+		return []string{single}
+	}
+
+	multiple := []string{}
+	if err := json.Unmarshal(request.Input, &multiple); err == nil {
+		return multiple
+	}
+
+	return []string{}
+}
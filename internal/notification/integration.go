@@ -0,0 +1,200 @@
+package notification
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+const (
+	TypeSlack     = "slack"
+	TypePagerDuty = "pagerduty"
+	TypeOpsgenie  = "opsgenie"
+)
+
+// events integrations can subscribe to.
+const (
+	EventBudget         = "budget"
+	EventProviderOutage = "provider_outage"
+	EventCircuitBreaker = "circuit_breaker"
+	EventAnomaly        = "anomaly"
+	EventRedisOutage    = "redis_outage"
+	EventProbe          = "probe"
+)
+
+var supportedEvents = map[string]bool{
+	EventBudget:         true,
+	EventProviderOutage: true,
+	EventCircuitBreaker: true,
+	EventAnomaly:        true,
+	EventRedisOutage:    true,
+	EventProbe:          true,
+}
+
+var pagerDutySeverities = map[string]bool{
+	"critical": true,
+	"error":    true,
+	"warning":  true,
+	"info":     true,
+}
+
+var opsgeniePriorities = map[string]bool{
+	"P1": true,
+	"P2": true,
+	"P3": true,
+	"P4": true,
+	"P5": true,
+}
+
+type Slack struct {
+	WebhookUrl string `json:"webhookUrl,omitempty"`
+}
+
+type PagerDuty struct {
+	RoutingKey string `json:"routingKey,omitempty"`
+	Severity   string `json:"severity,omitempty"`
+}
+
+type Opsgenie struct {
+	ApiKey   string `json:"apiKey,omitempty"`
+	Region   string `json:"region,omitempty"`
+	Priority string `json:"priority,omitempty"`
+}
+
+// Integration delivers the notifications of the events it subscribes to.
+// Only the settings of its type are used.
+type Integration struct {
+	Id        string     `json:"id"`
+	CreatedAt int64      `json:"createdAt"`
+	UpdatedAt int64      `json:"updatedAt"`
+	Name      string     `json:"name"`
+	Type      string     `json:"type"`
+	Events    []string   `json:"events"`
+	Slack     *Slack     `json:"slack,omitempty"`
+	PagerDuty *PagerDuty `json:"pagerDuty,omitempty"`
+	Opsgenie  *Opsgenie  `json:"opsgenie,omitempty"`
+}
+
+func isHttpsUrl(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && u.Scheme == "https" && len(u.Host) != 0
+}
+
+func (i *Integration) Validate() error {
+	invalid := []string{}
+
+	if len(i.Events) == 0 {
+		invalid = append(invalid, "events")
+	}
+
+	for index, e := range i.Events {
+		if !supportedEvents[e] {
+			invalid = append(invalid, fmt.Sprintf("events.[%d]", index))
+		}
+	}
+
+	switch i.Type {
+	case TypeSlack:
+		if i.Slack == nil || !isHttpsUrl(i.Slack.WebhookUrl) {
+			invalid = append(invalid, "slack.webhookUrl")
+		}
+	case TypePagerDuty:
+		if i.PagerDuty == nil || len(i.PagerDuty.RoutingKey) == 0 {
+			invalid = append(invalid, "pagerDuty.routingKey")
+		}
+
+		if i.PagerDuty != nil && len(i.PagerDuty.Severity) != 0 && !pagerDutySeverities[i.PagerDuty.Severity] {
+			invalid = append(invalid, "pagerDuty.severity")
+		}
+	case TypeOpsgenie:
+		if i.Opsgenie == nil || len(i.Opsgenie.ApiKey) == 0 {
+			invalid = append(invalid, "opsgenie.apiKey")
+		}
+
+		if i.Opsgenie != nil && len(i.Opsgenie.Region) != 0 && i.Opsgenie.Region != "us" && i.Opsgenie.Region != "eu" {
+			invalid = append(invalid, "opsgenie.region")
+		}
+
+		if i.Opsgenie != nil && len(i.Opsgenie.Priority) != 0 && !opsgeniePriorities[i.Opsgenie.Priority] {
+			invalid = append(invalid, "opsgenie.priority")
+		}
+	default:
+		invalid = append(invalid, "type")
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
+
+// Subscribes reports whether the integration is notified of the event.
+func (i *Integration) Subscribes(event string) bool {
+	for _, e := range i.Events {
+		if e == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Redacted returns the integration without its webhook url, routing key and
+// api key.
+func (i *Integration) Redacted() *Integration {
+	copied := *i
+
+	if i.Slack != nil {
+		copied.Slack = &Slack{}
+	}
+
+	if i.PagerDuty != nil {
+		copied.PagerDuty = &PagerDuty{
+			Severity: i.PagerDuty.Severity,
+		}
+	}
+
+	if i.Opsgenie != nil {
+		copied.Opsgenie = &Opsgenie{
+			Region:   i.Opsgenie.Region,
+			Priority: i.Opsgenie.Priority,
+		}
+	}
+
+	return &copied
+}
+
+// UpdateIntegration replaces the fields that are set. Settings replace the
+// settings of the type of the integration as a whole.
+type UpdateIntegration struct {
+	Name      *string    `json:"name"`
+	Events    []string   `json:"events"`
+	Slack     *Slack     `json:"slack"`
+	PagerDuty *PagerDuty `json:"pagerDuty"`
+	Opsgenie  *Opsgenie  `json:"opsgenie"`
+}
+
+func (ui *UpdateIntegration) Apply(i *Integration) {
+	if ui.Name != nil {
+		i.Name = *ui.Name
+	}
+
+	if ui.Events != nil {
+		i.Events = ui.Events
+	}
+
+	if ui.Slack != nil {
+		i.Slack = ui.Slack
+	}
+
+	if ui.PagerDuty != nil {
+		i.PagerDuty = ui.PagerDuty
+	}
+
+	if ui.Opsgenie != nil {
+		i.Opsgenie = ui.Opsgenie
+	}
+}
@@ -0,0 +1,217 @@
+package notification
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/digest"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"go.uber.org/zap"
+)
+
+const (
+	pagerDutyEventsUrl     = "https://events.pagerduty.com/v2/enqueue"
+	opsgenieUsAlertsUrl    = "https://api.opsgenie.com/v2/alerts"
+	opsgenieEuAlertsUrl    = "https://api.eu.opsgenie.com/v2/alerts"
+	opsgenieMaxMessageSize = 130
+	notificationSource     = "bricksllm"
+)
+
+type Storage interface {
+	GetIntegrations() ([]*Integration, error)
+}
+
+// Notifier delivers notifications to the integrations subscribed to their
+// event. Integrations are read on every notification so that changes made
+// through the admin API apply right away.
+type Notifier struct {
+	s      Storage
+	client http.Client
+	log    *zap.Logger
+}
+
+func NewNotifier(s Storage, log *zap.Logger) *Notifier {
+	return &Notifier{
+		s: s,
+		client: http.Client{
+			Timeout: 10 * time.Second,
+		},
+		log: log,
+	}
+}
+
+// Subscribed reports whether any integration is subscribed to the event.
+func (n *Notifier) Subscribed(event string) bool {
+	integrations, err := n.s.GetIntegrations()
+	if err != nil {
+		return false
+	}
+
+	for _, i := range integrations {
+		if i.Subscribes(event) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Notify sends the notification to every integration subscribed to the
+// event and returns the last delivery error.
+func (n *Notifier) Notify(event, subject, text string) error {
+	integrations, err := n.s.GetIntegrations()
+	if err != nil {
+		return err
+	}
+
+	var last error
+	for _, i := range integrations {
+		if !i.Subscribes(event) {
+			continue
+		}
+
+		if err := n.Send(i, event, subject, text); err != nil {
+			stats.Incr("bricksllm.notification.notifier.notify.send_error", []string{"type:" + i.Type, "event:" + event}, 1)
+			n.log.Sugar().Debugf("error when sending %s notification to integration %s: %v", event, i.Id, err)
+
+			last = err
+			continue
+		}
+
+		stats.Incr("bricksllm.notification.notifier.notify.success", []string{"type:" + i.Type, "event:" + event}, 1)
+	}
+
+	return last
+}
+
+// Send delivers a notification to a single integration.
+func (n *Notifier) Send(i *Integration, event, subject, text string) error {
+	switch i.Type {
+	case TypeSlack:
+		return n.sendSlack(i.Slack, subject, text)
+	case TypePagerDuty:
+		return n.sendPagerDuty(i.PagerDuty, event, subject, text)
+	case TypeOpsgenie:
+		return n.sendOpsgenie(i.Opsgenie, event, subject, text)
+	}
+
+	return fmt.Errorf("integration type %s is not supported", i.Type)
+}
+
+func (n *Notifier) post(url string, header http.Header, payload any, expected int) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != expected {
+		body, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("responded with status %d: %s", res.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+func (n *Notifier) sendSlack(s *Slack, subject, text string) error {
+	return n.post(s.WebhookUrl, nil, map[string]string{
+		"text": "*" + subject + "*\n```" + text + "```",
+	}, http.StatusOK)
+}
+
+// dedupKey groups repeated notifications with the same subject into one
+// PagerDuty incident or Opsgenie alert.
+func dedupKey(event, subject string) string {
+	sum := sha256.Sum256([]byte(event + "\n" + subject))
+	return notificationSource + "-" + hex.EncodeToString(sum[:16])
+}
+
+func (n *Notifier) sendPagerDuty(pd *PagerDuty, event, subject, text string) error {
+	severity := pd.Severity
+	if len(severity) == 0 {
+		severity = "error"
+	}
+
+	return n.post(pagerDutyEventsUrl, nil, map[string]any{
+		"routing_key":  pd.RoutingKey,
+		"event_action": "trigger",
+		"dedup_key":    dedupKey(event, subject),
+		"payload": map[string]any{
+			"summary":  subject,
+			"source":   notificationSource,
+			"severity": severity,
+			"class":    event,
+			"custom_details": map[string]string{
+				"details": text,
+			},
+		},
+	}, http.StatusAccepted)
+}
+
+func (n *Notifier) sendOpsgenie(og *Opsgenie, event, subject, text string) error {
+	url := opsgenieUsAlertsUrl
+	if og.Region == "eu" {
+		url = opsgenieEuAlertsUrl
+	}
+
+	priority := og.Priority
+	if len(priority) == 0 {
+		priority = "P3"
+	}
+
+	message := subject
+	if len(message) > opsgenieMaxMessageSize {
+		message = message[:opsgenieMaxMessageSize]
+	}
+
+	return n.post(url, http.Header{
+		"Authorization": []string{"GenieKey " + og.ApiKey},
+	}, map[string]any{
+		"message":     message,
+		"alias":       dedupKey(event, subject),
+		"description": text,
+		"source":      notificationSource,
+		"priority":    priority,
+		"tags":        []string{event},
+	}, http.StatusAccepted)
+}
+
+type eventSender struct {
+	n     *Notifier
+	event string
+}
+
+// Sender returns a sender that notifies the integrations subscribed to the
+// event, so that they receive the alerts of the existing alert senders.
+func (n *Notifier) Sender(event string) digest.Sender {
+	return &eventSender{
+		n:     n,
+		event: event,
+	}
+}
+
+func (es *eventSender) Send(subject, text string) error {
+	return es.n.Notify(es.event, subject, text)
+}
@@ -0,0 +1,153 @@
+package notification
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/digest"
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"go.uber.org/zap"
+)
+
+type reportingManager interface {
+	GetSpendForecast(r *event.ForecastRequest) (*event.ForecastReport, error)
+	GetAggregatedEventReporting(r *event.AggregationRequest) (*event.AggregationResponse, error)
+	GetTopReporting(r *event.TopRequest) (*event.TopResponse, error)
+}
+
+// Watcher checks spend for keys at risk of exceeding their budget and for
+// spend anomalies, and notifies the integrations subscribed to them. A key
+// is notified once per month and anomalies once per day.
+type Watcher struct {
+	n          *Notifier
+	rm         reportingManager
+	b          *digest.Builder
+	interval   time.Duration
+	atRisk     map[string]bool
+	month      string
+	anomalyDay string
+	done       chan bool
+	log        *zap.Logger
+}
+
+func NewWatcher(n *Notifier, rm reportingManager, interval time.Duration, log *zap.Logger) *Watcher {
+	return &Watcher{
+		n:        n,
+		rm:       rm,
+		b:        digest.NewBuilder(rm),
+		interval: interval,
+		atRisk:   map[string]bool{},
+		done:     make(chan bool),
+		log:      log,
+	}
+}
+
+func (w *Watcher) checkBudgets(now time.Time) error {
+	month := now.UTC().Format("2006-01")
+	if month != w.month {
+		w.month = month
+		w.atRisk = map[string]bool{}
+	}
+
+	report, err := w.rm.GetSpendForecast(&event.ForecastRequest{
+		GroupBy: event.DimensionKeyId,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, f := range report.Forecasts {
+		if !f.AtRisk || w.atRisk[f.Name] {
+			continue
+		}
+
+		w.atRisk[f.Name] = true
+
+		b := &strings.Builder{}
+		fmt.Fprintf(b, "Key %s spent $%.2f this month and is projected to spend $%.2f.\n", f.Name, f.SpendInUsd, f.ProjectedSpendInUsd)
+		if f.BudgetInUsd > 0 {
+			fmt.Fprintf(b, "Monthly budget: $%.2f\n", f.BudgetInUsd)
+		}
+
+		if f.CostLimitInUsd > 0 {
+			fmt.Fprintf(b, "Cost limit: $%.2f with $%.2f spent and $%.2f projected\n", f.CostLimitInUsd, f.TotalSpendInUsd, f.ProjectedTotalSpendInUsd)
+		}
+
+		go w.n.Notify(EventBudget, fmt.Sprintf("BricksLLM key %s is at risk of exceeding its budget", f.Name), b.String())
+	}
+
+	return nil
+}
+
+// checkAnomalies compares the spend of keys on the previous UTC day with the
+// day before, the same way as daily digests.
+func (w *Watcher) checkAnomalies(now time.Time) error {
+	end := now.UTC().Truncate(24 * time.Hour)
+	day := end.Format("2006-01-02")
+	if day == w.anomalyDay {
+		return nil
+	}
+
+	d, err := w.b.Build(digest.FrequencyDaily, end.AddDate(0, 0, -1), end)
+	if err != nil {
+		return err
+	}
+
+	w.anomalyDay = day
+	if len(d.Anomalies) == 0 {
+		return nil
+	}
+
+	b := &strings.Builder{}
+	for _, a := range d.Anomalies {
+		fmt.Fprintf(b, "Key %s spent $%.2f, up from $%.2f the day before\n", a.KeyId, a.CostInUsd, a.PreviousCostInUsd)
+	}
+
+	go w.n.Notify(EventAnomaly, fmt.Sprintf("BricksLLM detected spend anomalies on %s", d.Start.Format("2006-01-02")), b.String())
+
+	return nil
+}
+
+func (w *Watcher) check() {
+	now := time.Now()
+
+	if w.n.Subscribed(EventBudget) {
+		if err := w.checkBudgets(now); err != nil {
+			stats.Incr("bricksllm.notification.watcher.check_budgets_error", nil, 1)
+			w.log.Sugar().Debugf("error when checking budgets for notifications: %v", err)
+		}
+	}
+
+	if w.n.Subscribed(EventAnomaly) {
+		if err := w.checkAnomalies(now); err != nil {
+			stats.Incr("bricksllm.notification.watcher.check_anomalies_error", nil, 1)
+			w.log.Sugar().Debugf("error when checking spend anomalies for notifications: %v", err)
+		}
+	}
+}
+
+func (w *Watcher) Listen() {
+	ticker := time.NewTicker(w.interval)
+	w.log.Info("notification watcher started checking budgets and spend anomalies")
+
+	go func() {
+		for {
+			select {
+			case <-w.done:
+				ticker.Stop()
+				w.log.Info("notification watcher stopped")
+				return
+			case <-ticker.C:
+				w.check()
+			}
+		}
+	}()
+}
+
+func (w *Watcher) Stop() {
+	w.log.Info("shutting down notification watcher...")
+
+	w.done <- true
+}
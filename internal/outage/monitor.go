@@ -0,0 +1,191 @@
+package outage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/digest"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+type Pinger interface {
+	Ping(ctx context.Context) *redis.StatusCmd
+}
+
+type subsystem struct {
+	mode        string
+	pinger      Pinger
+	available   bool
+	unavailable time.Time
+}
+
+// Monitor pings the Redis of every watched subsystem and decides whether
+// requests that depend on an unavailable subsystem are let through.
+type Monitor struct {
+	subsystems map[string]*subsystem
+	lock       sync.RWMutex
+	senders    []digest.Sender
+	interval   time.Duration
+	timeout    time.Duration
+	done       chan bool
+	log        *zap.Logger
+}
+
+func NewMonitor(senders []digest.Sender, interval, timeout time.Duration, log *zap.Logger) *Monitor {
+	return &Monitor{
+		subsystems: map[string]*subsystem{},
+		senders:    senders,
+		interval:   interval,
+		timeout:    timeout,
+		done:       make(chan bool),
+		log:        log,
+	}
+}
+
+func (m *Monitor) Watch(name, mode string, p Pinger) error {
+	if !IsValidMode(mode) {
+		return fmt.Errorf("failure mode of %s must be %s or %s", name, ModeOpen, ModeClosed)
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.subsystems[name] = &subsystem{
+		mode:      mode,
+		pinger:    p,
+		available: true,
+	}
+
+	return nil
+}
+
+// Allow reports whether a request that depends on the subsystem continues.
+// Requests are only stopped while the subsystem is unavailable and fails
+// closed.
+func (m *Monitor) Allow(name string) bool {
+	m.lock.RLock()
+	s, ok := m.subsystems[name]
+	if !ok || s.available {
+		m.lock.RUnlock()
+		return true
+	}
+
+	mode := s.mode
+	m.lock.RUnlock()
+
+	return m.degrade(name, mode)
+}
+
+// AllowFailure reports whether a request continues after a call to the
+// subsystem failed.
+func (m *Monitor) AllowFailure(name string) bool {
+	m.lock.RLock()
+	s, ok := m.subsystems[name]
+	if !ok {
+		m.lock.RUnlock()
+		return true
+	}
+
+	mode := s.mode
+	m.lock.RUnlock()
+
+	return m.degrade(name, mode)
+}
+
+func (m *Monitor) degrade(name, mode string) bool {
+	stats.Incr("bricksllm.outage.monitor.degraded_request", []string{"subsystem:" + name, "mode:" + mode}, 1)
+
+	return mode == ModeOpen
+}
+
+func (m *Monitor) check() {
+	m.lock.RLock()
+	names := make([]string, 0, len(m.subsystems))
+	for name := range m.subsystems {
+		names = append(names, name)
+	}
+	m.lock.RUnlock()
+
+	for _, name := range names {
+		m.lock.RLock()
+		s := m.subsystems[name]
+		p := s.pinger
+		m.lock.RUnlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+		err := p.Ping(ctx).Err()
+		cancel()
+
+		available := 0.0
+		if err == nil {
+			available = 1
+		}
+
+		stats.Gauge("bricksllm.outage.monitor.redis_available", available, []string{"subsystem:" + name}, 1)
+
+		m.lock.Lock()
+		changed := s.available != (err == nil)
+		s.available = err == nil
+		since := s.unavailable
+		if changed && err != nil {
+			s.unavailable = time.Now()
+		}
+		mode := s.mode
+		m.lock.Unlock()
+
+		if !changed {
+			continue
+		}
+
+		if err != nil {
+			stats.Incr("bricksllm.outage.monitor.redis_unavailable", []string{"subsystem:" + name}, 1)
+			m.log.Sugar().Warnf("redis of %s is unavailable and fails %s: %v", name, mode, err)
+
+			go m.alert(fmt.Sprintf("BricksLLM %s redis is unavailable", name), fmt.Sprintf("Redis of %s stopped responding: %v.\nRequests that depend on it fail %s until it recovers.", name, err, mode))
+			continue
+		}
+
+		stats.Incr("bricksllm.outage.monitor.redis_recovered", []string{"subsystem:" + name}, 1)
+		m.log.Sugar().Infof("redis of %s recovered", name)
+
+		go m.alert(fmt.Sprintf("BricksLLM %s redis recovered", name), fmt.Sprintf("Redis of %s is responding again after %s.", name, time.Since(since).Round(time.Second)))
+	}
+}
+
+func (m *Monitor) alert(subject, text string) {
+	for _, s := range m.senders {
+		if err := s.Send(subject, text); err != nil {
+			stats.Incr("bricksllm.outage.monitor.alert.send_error", nil, 1)
+
+			m.log.Sugar().Debugf("error when sending redis outage alert: %v", err)
+		}
+	}
+}
+
+func (m *Monitor) Listen() {
+	ticker := time.NewTicker(m.interval)
+	m.log.Info("outage monitor started checking redis availability")
+
+	go func() {
+		for {
+			select {
+			case <-m.done:
+				ticker.Stop()
+				m.log.Info("outage monitor stopped")
+				return
+			case <-ticker.C:
+				m.check()
+			}
+		}
+	}()
+}
+
+func (m *Monitor) Stop() {
+	m.log.Info("shutting down outage monitor...")
+
+	m.done <- true
+}
@@ -0,0 +1,122 @@
+package outage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/digest"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	stats.InitializeClient("", nil)
+
+	os.Exit(m.Run())
+}
+
+type fakePinger struct {
+	err error
+}
+
+func (p *fakePinger) Ping(ctx context.Context) *redis.StatusCmd {
+	return redis.NewStatusResult("PONG", p.err)
+}
+
+type fakeSender struct {
+	lock     sync.Mutex
+	subjects []string
+}
+
+func (s *fakeSender) Send(subject, text string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.subjects = append(s.subjects, subject)
+	return nil
+}
+
+func (s *fakeSender) sent() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return append([]string{}, s.subjects...)
+}
+
+func TestMonitor_Watch(t *testing.T) {
+	m := NewMonitor(nil, time.Second, time.Second, zap.NewNop())
+
+	assert.NoError(t, m.Watch(SubsystemCache, ModeOpen, &fakePinger{}))
+	assert.NoError(t, m.Watch(SubsystemCostLimit, ModeClosed, &fakePinger{}))
+	assert.Error(t, m.Watch(SubsystemRateLimit, "half-open", &fakePinger{}))
+}
+
+func TestMonitor_Allow(t *testing.T) {
+	cases := []struct {
+		name         string
+		mode         string
+		err          error
+		allow        bool
+		allowFailure bool
+	}{
+		{name: "available subsystems failing closed", mode: ModeClosed, allow: true, allowFailure: false},
+		{name: "available subsystems failing open", mode: ModeOpen, allow: true, allowFailure: true},
+		{name: "unavailable subsystems failing closed", mode: ModeClosed, err: errors.New("connection refused"), allow: false, allowFailure: false},
+		{name: "unavailable subsystems failing open", mode: ModeOpen, err: errors.New("connection refused"), allow: true, allowFailure: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := NewMonitor(nil, time.Second, time.Second, zap.NewNop())
+			require.NoError(t, m.Watch(SubsystemCostLimit, tc.mode, &fakePinger{err: tc.err}))
+			m.check()
+
+			assert.Equal(t, tc.allow, m.Allow(SubsystemCostLimit))
+			assert.Equal(t, tc.allowFailure, m.AllowFailure(SubsystemCostLimit))
+		})
+	}
+
+	t.Run("subsystems that are not watched", func(t *testing.T) {
+		m := NewMonitor(nil, time.Second, time.Second, zap.NewNop())
+
+		assert.True(t, m.Allow(SubsystemCache))
+		assert.True(t, m.AllowFailure(SubsystemCache))
+	})
+}
+
+func TestMonitor_Check(t *testing.T) {
+	s := &fakeSender{}
+	p := &fakePinger{}
+
+	m := NewMonitor([]digest.Sender{s}, time.Second, time.Second, zap.NewNop())
+	require.NoError(t, m.Watch(SubsystemRateLimit, ModeClosed, p))
+
+	steps := []struct {
+		err     error
+		allow   bool
+		alerted []string
+	}{
+		{err: nil, allow: true, alerted: []string{}},
+		{err: errors.New("i/o timeout"), allow: false, alerted: []string{"BricksLLM rate_limit redis is unavailable"}},
+		{err: errors.New("i/o timeout"), allow: false, alerted: []string{"BricksLLM rate_limit redis is unavailable"}},
+		{err: nil, allow: true, alerted: []string{"BricksLLM rate_limit redis is unavailable", "BricksLLM rate_limit redis recovered"}},
+	}
+
+	for _, step := range steps {
+		p.err = step.err
+		m.check()
+
+		assert.Equal(t, step.allow, m.Allow(SubsystemRateLimit))
+		// alerts are sent in the background.
+		assert.Eventually(t, func() bool {
+			return assert.ObjectsAreEqual(step.alerted, s.sent())
+		}, time.Second, time.Millisecond)
+	}
+}
@@ -0,0 +1,19 @@
+package outage
+
+const (
+	ModeOpen   = "open"
+	ModeClosed = "closed"
+)
+
+// Subsystems backed by their own Redis endpoint. Each one fails open or
+// closed independently when its Redis is unavailable.
+const (
+	SubsystemRateLimit = "rate_limit"
+	SubsystemCostLimit = "cost_limit"
+	SubsystemCache     = "cache"
+	SubsystemAccess    = "access"
+)
+
+func IsValidMode(mode string) bool {
+	return mode == ModeOpen || mode == ModeClosed
+}
@@ -0,0 +1,188 @@
+package passthrough
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/tidwall/gjson"
+)
+
+// Providers are the providers whose endpoints can be passed through. Their
+// requests are authenticated with the provider settings of the key like any
+// other request to the provider.
+var Providers = []string{"openai", "anthropic", "azure"}
+
+// PassThrough forwards requests to endpoints of a provider that are not
+// modelled by the proxy yet, such as beta apis. Requests are charged with the
+// usage found by the first usage rule matching their method and path.
+type PassThrough struct {
+	Id         string       `json:"id"`
+	CreatedAt  int64        `json:"createdAt"`
+	UpdatedAt  int64        `json:"updatedAt"`
+	Provider   string       `json:"provider"`
+	UsageRules []*UsageRule `json:"usageRules"`
+	Disabled   bool         `json:"disabled"`
+}
+
+// UsageRule tells where the usage of the responses of an endpoint is found.
+// Path is matched against the path of the provider api, such as
+// /v1/realtime/sessions, and * matches a single segment. Locations are gjson
+// paths into the response body, or into every event of streamed responses.
+type UsageRule struct {
+	Method                   string `json:"method,omitempty"`
+	Path                     string `json:"path"`
+	ModelLocation            string `json:"modelLocation,omitempty"`
+	PromptTokensLocation     string `json:"promptTokensLocation,omitempty"`
+	CompletionTokensLocation string `json:"completionTokensLocation,omitempty"`
+	CostLocation             string `json:"costLocation,omitempty"`
+}
+
+func isSupportedProvider(p string) bool {
+	for _, supported := range Providers {
+		if p == supported {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Methods are the methods of the requests that can be passed through.
+var Methods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+func isSupportedMethod(m string) bool {
+	for _, supported := range Methods {
+		if m == supported {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validateUsageRules(invalid []string, rules []*UsageRule) []string {
+	for index, r := range rules {
+		prefix := fmt.Sprintf("usageRules.[%d]", index)
+		if r == nil {
+			invalid = append(invalid, prefix)
+			continue
+		}
+
+		if _, err := path.Match(r.Path, ""); err != nil || !strings.HasPrefix(r.Path, "/") {
+			invalid = append(invalid, prefix+".path")
+		}
+
+		if len(r.Method) != 0 && !isSupportedMethod(r.Method) {
+			invalid = append(invalid, prefix+".method")
+		}
+
+		if len(r.PromptTokensLocation) == 0 && len(r.CompletionTokensLocation) == 0 && len(r.CostLocation) == 0 {
+			invalid = append(invalid, prefix+".costLocation")
+		}
+	}
+
+	return invalid
+}
+
+func (p *PassThrough) Validate() error {
+	invalid := []string{}
+
+	if !isSupportedProvider(p.Provider) {
+		invalid = append(invalid, "provider")
+	}
+
+	invalid = validateUsageRules(invalid, p.UsageRules)
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
+
+// MatchUsageRule returns the first usage rule matching a request to the
+// provider api.
+func (p *PassThrough) MatchUsageRule(method, apiPath string) *UsageRule {
+	for _, r := range p.UsageRules {
+		if len(r.Method) != 0 && r.Method != method {
+			continue
+		}
+
+		if matched, _ := path.Match(r.Path, apiPath); matched {
+			return r
+		}
+	}
+
+	return nil
+}
+
+type UpdatePassThrough struct {
+	UpdatedAt  int64         `json:"updatedAt"`
+	UsageRules *[]*UsageRule `json:"usageRules"`
+	Disabled   *bool         `json:"disabled"`
+}
+
+func (up *UpdatePassThrough) Validate() error {
+	invalid := []string{}
+
+	if up.UsageRules != nil {
+		invalid = validateUsageRules(invalid, *up.UsageRules)
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
+
+// Usage holds the values found at the locations of a usage rule. Fields are
+// empty when the location is not configured or absent from the response.
+type Usage struct {
+	Model            string
+	PromptTokens     *int
+	CompletionTokens *int
+	CostInUsd        *float64
+}
+
+func getNumber(data []byte, loc string) (gjson.Result, bool) {
+	if len(loc) == 0 {
+		return gjson.Result{}, false
+	}
+
+	result := gjson.GetBytes(data, loc)
+	if result.Type != gjson.Number {
+		return gjson.Result{}, false
+	}
+
+	return result, true
+}
+
+// Extract reads the usage of a response body or of an event of a stream.
+// Values found in data replace the ones already held by u so that the usage
+// reported by the last event of a stream wins.
+func (r *UsageRule) Extract(data []byte, u *Usage) {
+	if len(r.ModelLocation) != 0 {
+		if model := gjson.GetBytes(data, r.ModelLocation); model.Type == gjson.String && len(model.Str) != 0 {
+			u.Model = model.Str
+		}
+	}
+
+	if result, ok := getNumber(data, r.PromptTokensLocation); ok {
+		tks := int(result.Int())
+		u.PromptTokens = &tks
+	}
+
+	if result, ok := getNumber(data, r.CompletionTokensLocation); ok {
+		tks := int(result.Int())
+		u.CompletionTokens = &tks
+	}
+
+	if result, ok := getNumber(data, r.CostLocation); ok {
+		cost := result.Float()
+		u.CostInUsd = &cost
+	}
+}
@@ -0,0 +1,113 @@
+package passthrough
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPassThrough_Validate(t *testing.T) {
+	cases := []struct {
+		name    string
+		pt      *PassThrough
+		invalid string
+	}{
+		{name: "valid pass-throughs", pt: &PassThrough{Provider: "openai", UsageRules: []*UsageRule{{Method: http.MethodPost, Path: "/v1/realtime/*", CostLocation: "usage.cost"}}}},
+		{name: "pass-throughs without usage rules", pt: &PassThrough{Provider: "anthropic"}},
+		{name: "unsupported providers", pt: &PassThrough{Provider: "vllm"}, invalid: "provider"},
+		{name: "paths without a leading slash", pt: &PassThrough{Provider: "openai", UsageRules: []*UsageRule{{Path: "v1/files", CostLocation: "cost"}}}, invalid: "usageRules.[0].path"},
+		{name: "malformed path patterns", pt: &PassThrough{Provider: "openai", UsageRules: []*UsageRule{{Path: "/v1/[", CostLocation: "cost"}}}, invalid: "usageRules.[0].path"},
+		{name: "unsupported methods", pt: &PassThrough{Provider: "openai", UsageRules: []*UsageRule{{Method: http.MethodHead, Path: "/v1/files", CostLocation: "cost"}}}, invalid: "usageRules.[0].method"},
+		{name: "rules without usage locations", pt: &PassThrough{Provider: "openai", UsageRules: []*UsageRule{{Path: "/v1/files", ModelLocation: "model"}}}, invalid: "usageRules.[0].costLocation"},
+		{name: "empty rules", pt: &PassThrough{Provider: "openai", UsageRules: []*UsageRule{nil}}, invalid: "usageRules.[0]"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.pt.Validate()
+			if len(tc.invalid) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.invalid)
+		})
+	}
+}
+
+func TestPassThrough_MatchUsageRule(t *testing.T) {
+	pt := &PassThrough{UsageRules: []*UsageRule{
+		{Method: http.MethodPost, Path: "/v1/realtime/sessions", CostLocation: "first"},
+		{Path: "/v1/realtime/*", CostLocation: "second"},
+	}}
+
+	cases := []struct {
+		name     string
+		method   string
+		path     string
+		expected string
+	}{
+		{name: "rules are matched in order", method: http.MethodPost, path: "/v1/realtime/sessions", expected: "first"},
+		{name: "rules of other methods are skipped", method: http.MethodGet, path: "/v1/realtime/sessions", expected: "second"},
+		{name: "wildcards match a single segment", method: http.MethodGet, path: "/v1/realtime/sessions/s1", expected: ""},
+		{name: "paths without rules", method: http.MethodPost, path: "/v1/files", expected: ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := pt.MatchUsageRule(tc.method, tc.path)
+			if len(tc.expected) == 0 {
+				assert.Nil(t, r)
+				return
+			}
+
+			require.NotNil(t, r)
+			assert.Equal(t, tc.expected, r.CostLocation)
+		})
+	}
+}
+
+func TestUsageRule_Extract(t *testing.T) {
+	r := &UsageRule{
+		ModelLocation:            "model",
+		PromptTokensLocation:     "usage.input_tokens",
+		CompletionTokensLocation: "usage.output_tokens",
+		CostLocation:             "usage.cost",
+	}
+
+	t.Run("values found in the body", func(t *testing.T) {
+		u := &Usage{}
+		r.Extract([]byte(`{"model":"gpt-4o","usage":{"input_tokens":10,"output_tokens":20,"cost":0.5}}`), u)
+
+		assert.Equal(t, "gpt-4o", u.Model)
+		require.NotNil(t, u.PromptTokens)
+		assert.Equal(t, 10, *u.PromptTokens)
+		require.NotNil(t, u.CompletionTokens)
+		assert.Equal(t, 20, *u.CompletionTokens)
+		require.NotNil(t, u.CostInUsd)
+		assert.Equal(t, 0.5, *u.CostInUsd)
+	})
+
+	t.Run("values that are not numbers are ignored", func(t *testing.T) {
+		u := &Usage{}
+		r.Extract([]byte(`{"model":"","usage":{"input_tokens":"10","cost":null}}`), u)
+
+		assert.Empty(t, u.Model)
+		assert.Nil(t, u.PromptTokens)
+		assert.Nil(t, u.CompletionTokens)
+		assert.Nil(t, u.CostInUsd)
+	})
+
+	t.Run("events of streams replace earlier values", func(t *testing.T) {
+		u := &Usage{}
+		r.Extract([]byte(`{"model":"gpt-4o","usage":{"input_tokens":10,"output_tokens":1}}`), u)
+		r.Extract([]byte(`{"usage":{"output_tokens":30}}`), u)
+
+		assert.Equal(t, "gpt-4o", u.Model)
+		assert.Equal(t, 10, *u.PromptTokens)
+		assert.Equal(t, 30, *u.CompletionTokens)
+	})
+}
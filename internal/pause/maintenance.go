@@ -0,0 +1,42 @@
+package pause
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Maintenance replaces the error returned for a pause with a static response.
+// With CacheOnly set, routes keep serving cached responses and only fall back
+// to the static response on cache misses.
+type Maintenance struct {
+	StatusCode int             `json:"statusCode,omitempty"`
+	Message    string          `json:"message,omitempty"`
+	Body       json.RawMessage `json:"body,omitempty"`
+	CacheOnly  bool            `json:"cacheOnly,omitempty"`
+}
+
+func (m *Maintenance) Validate() []string {
+	invalid := []string{}
+
+	if m.StatusCode != 0 && (m.StatusCode < 200 || m.StatusCode > 599) {
+		invalid = append(invalid, "maintenance.statusCode")
+	}
+
+	if len(m.Body) != 0 && (len(m.Message) != 0 || !json.Valid(m.Body)) {
+		invalid = append(invalid, "maintenance.body")
+	}
+
+	return invalid
+}
+
+func (m *Maintenance) GetStatusCode() int {
+	if m == nil || m.StatusCode == 0 {
+		return http.StatusServiceUnavailable
+	}
+
+	return m.StatusCode
+}
+
+func (m *Maintenance) ServesCache() bool {
+	return m != nil && m.CacheOnly
+}
@@ -0,0 +1,32 @@
+package pause
+
+const (
+	ScopeGlobal   = "global"
+	ScopeProvider = "provider"
+	ScopeRoute    = "route"
+)
+
+// Pause stops proxy traffic during an incident. A global pause stops every
+// request, a provider pause stops requests to the provider and a route
+// pause stops requests to the route with the id in Target. Pauses with
+// Maintenance set put their scope into maintenance mode.
+type Pause struct {
+	Scope       string       `json:"scope"`
+	Target      string       `json:"target,omitempty"`
+	TenantId    string       `json:"tenantId,omitempty"`
+	Reason      string       `json:"reason,omitempty"`
+	Maintenance *Maintenance `json:"maintenance,omitempty"`
+	CreatedAt   int64        `json:"createdAt"`
+}
+
+func (p *Pause) Id() string {
+	return Id(p.Scope, p.Target)
+}
+
+func Id(scope, target string) string {
+	if scope == ScopeGlobal {
+		return ScopeGlobal
+	}
+
+	return scope + ":" + target
+}
@@ -0,0 +1,387 @@
+package policy
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Expressions use the subset of CEL that is also valid go syntax: string,
+// number and boolean literals, the variables of an Input, comparisons, &&,
+// || and ! as well as a few functions. Expressions are type checked when
+// they are compiled so that evaluating them cannot fail.
+
+type kind int
+
+const (
+	kindString kind = iota
+	kindNumber
+	kindBool
+)
+
+func (k kind) String() string {
+	switch k {
+	case kindString:
+		return "string"
+	case kindNumber:
+		return "number"
+	}
+
+	return "bool"
+}
+
+// Input holds the attributes of a request that expressions are evaluated
+// against.
+type Input struct {
+	Model    string
+	Provider string
+	Path     string
+	Method   string
+	KeyId    string
+	KeyName  string
+	Tags     []string
+	Headers  http.Header
+	Time     time.Time
+
+	// CountTokens estimates the prompt tokens of the request. It is only
+	// called by expressions using tokens, and at most once.
+	CountTokens func() int
+	tokens      *int
+}
+
+func (in *Input) getTokens() float64 {
+	if in.tokens == nil {
+		count := 0
+		if in.CountTokens != nil {
+			count = in.CountTokens()
+		}
+
+		in.tokens = &count
+	}
+
+	return float64(*in.tokens)
+}
+
+func (in *Input) hasTag(tag string) bool {
+	for _, t := range in.Tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+type node struct {
+	kind kind
+	str  func(in *Input) string
+	num  func(in *Input) float64
+	cond func(in *Input) bool
+}
+
+func stringNode(f func(in *Input) string) *node {
+	return &node{kind: kindString, str: f}
+}
+
+func numberNode(f func(in *Input) float64) *node {
+	return &node{kind: kindNumber, num: f}
+}
+
+func boolNode(f func(in *Input) bool) *node {
+	return &node{kind: kindBool, cond: f}
+}
+
+var variables = map[string]*node{
+	"model":    stringNode(func(in *Input) string { return in.Model }),
+	"provider": stringNode(func(in *Input) string { return in.Provider }),
+	"path":     stringNode(func(in *Input) string { return in.Path }),
+	"method":   stringNode(func(in *Input) string { return in.Method }),
+	"keyId":    stringNode(func(in *Input) string { return in.KeyId }),
+	"keyName":  stringNode(func(in *Input) string { return in.KeyName }),
+	"tokens":   numberNode(func(in *Input) float64 { return in.getTokens() }),
+	"hour":     numberNode(func(in *Input) float64 { return float64(in.Time.UTC().Hour()) }),
+	"minute":   numberNode(func(in *Input) float64 { return float64(in.Time.UTC().Minute()) }),
+	"weekday": stringNode(func(in *Input) string {
+		return strings.ToLower(in.Time.UTC().Weekday().String()[:3])
+	}),
+}
+
+// Program is a compiled expression.
+type Program struct {
+	root *node
+}
+
+func (p *Program) Eval(in *Input) bool {
+	return p.root.cond(in)
+}
+
+func Compile(expr string) (*Program, error) {
+	parsed, err := parser.ParseExpr(expr)
+	if err != nil {
+		return nil, fmt.Errorf("expression cannot be parsed: %v", err)
+	}
+
+	root, err := compile(parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	if root.kind != kindBool {
+		return nil, fmt.Errorf("expression evaluates to a %s instead of a bool", root.kind)
+	}
+
+	return &Program{root: root}, nil
+}
+
+func compile(e ast.Expr) (*node, error) {
+	switch e := e.(type) {
+	case *ast.ParenExpr:
+		return compile(e.X)
+	case *ast.BasicLit:
+		return compileLiteral(e)
+	case *ast.Ident:
+		if e.Name == "true" || e.Name == "false" {
+			value := e.Name == "true"
+			return boolNode(func(*Input) bool { return value }), nil
+		}
+
+		if v, ok := variables[e.Name]; ok {
+			return v, nil
+		}
+
+		return nil, fmt.Errorf("variable %s is unknown", e.Name)
+	case *ast.UnaryExpr:
+		return compileUnary(e)
+	case *ast.BinaryExpr:
+		return compileBinary(e)
+	case *ast.CallExpr:
+		return compileCall(e)
+	}
+
+	return nil, fmt.Errorf("expression %T is not supported", e)
+}
+
+func compileLiteral(e *ast.BasicLit) (*node, error) {
+	switch e.Kind {
+	case token.STRING:
+		value, err := strconv.Unquote(e.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		return stringNode(func(*Input) string { return value }), nil
+	case token.INT, token.FLOAT:
+		value, err := strconv.ParseFloat(e.Value, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		return numberNode(func(*Input) float64 { return value }), nil
+	}
+
+	return nil, fmt.Errorf("literal %s is not supported, strings have to be double quoted", e.Value)
+}
+
+func compileUnary(e *ast.UnaryExpr) (*node, error) {
+	x, err := compile(e.X)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case e.Op == token.NOT && x.kind == kindBool:
+		return boolNode(func(in *Input) bool { return !x.cond(in) }), nil
+	case e.Op == token.SUB && x.kind == kindNumber:
+		return numberNode(func(in *Input) float64 { return -x.num(in) }), nil
+	}
+
+	return nil, fmt.Errorf("operator %s cannot be applied to a %s", e.Op, x.kind)
+}
+
+func compileBinary(e *ast.BinaryExpr) (*node, error) {
+	x, err := compile(e.X)
+	if err != nil {
+		return nil, err
+	}
+
+	y, err := compile(e.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	if x.kind != y.kind {
+		return nil, fmt.Errorf("operator %s cannot compare a %s with a %s", e.Op, x.kind, y.kind)
+	}
+
+	switch e.Op {
+	case token.LAND, token.LOR:
+		if x.kind != kindBool {
+			return nil, fmt.Errorf("operator %s requires bools", e.Op)
+		}
+
+		if e.Op == token.LAND {
+			return boolNode(func(in *Input) bool { return x.cond(in) && y.cond(in) }), nil
+		}
+
+		return boolNode(func(in *Input) bool { return x.cond(in) || y.cond(in) }), nil
+	case token.EQL, token.NEQ:
+		equal := func(in *Input) bool {
+			switch x.kind {
+			case kindString:
+				return x.str(in) == y.str(in)
+			case kindNumber:
+				return x.num(in) == y.num(in)
+			}
+
+			return x.cond(in) == y.cond(in)
+		}
+
+		if e.Op == token.EQL {
+			return boolNode(equal), nil
+		}
+
+		return boolNode(func(in *Input) bool { return !equal(in) }), nil
+	case token.LSS, token.LEQ, token.GTR, token.GEQ:
+		if x.kind == kindBool {
+			return nil, fmt.Errorf("operator %s cannot compare bools", e.Op)
+		}
+
+		op := e.Op
+		return boolNode(func(in *Input) bool {
+			var cmp int
+			if x.kind == kindString {
+				cmp = strings.Compare(x.str(in), y.str(in))
+			} else if a, b := x.num(in), y.num(in); a < b {
+				cmp = -1
+			} else if a > b {
+				cmp = 1
+			}
+
+			switch op {
+			case token.LSS:
+				return cmp < 0
+			case token.LEQ:
+				return cmp <= 0
+			case token.GTR:
+				return cmp > 0
+			}
+
+			return cmp >= 0
+		}), nil
+	}
+
+	return nil, fmt.Errorf("operator %s is not supported", e.Op)
+}
+
+// compileCall supports both function calls such as startsWith(model, "gpt")
+// and the CEL method syntax model.startsWith("gpt").
+func compileCall(e *ast.CallExpr) (*node, error) {
+	name := ""
+	args := e.Args
+
+	switch fun := e.Fun.(type) {
+	case *ast.Ident:
+		name = fun.Name
+	case *ast.SelectorExpr:
+		name = fun.Sel.Name
+		args = append([]ast.Expr{fun.X}, e.Args...)
+	default:
+		return nil, fmt.Errorf("function call %T is not supported", e.Fun)
+	}
+
+	if name == "matches" {
+		return compileMatches(args)
+	}
+
+	compiled := []*node{}
+	for _, arg := range args {
+		n, err := compile(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		if n.kind != kindString {
+			return nil, fmt.Errorf("function %s only takes strings", name)
+		}
+
+		compiled = append(compiled, n)
+	}
+
+	arity := map[string]int{
+		"hasTag":     1,
+		"header":     1,
+		"lower":      1,
+		"startsWith": 2,
+		"endsWith":   2,
+		"contains":   2,
+	}
+
+	expected, ok := arity[name]
+	if !ok {
+		return nil, fmt.Errorf("function %s is unknown", name)
+	}
+
+	if len(compiled) != expected {
+		return nil, fmt.Errorf("function %s takes %d arguments", name, expected)
+	}
+
+	a := compiled[0]
+	switch name {
+	case "hasTag":
+		return boolNode(func(in *Input) bool { return in.hasTag(a.str(in)) }), nil
+	case "header":
+		return stringNode(func(in *Input) string { return in.Headers.Get(a.str(in)) }), nil
+	case "lower":
+		return stringNode(func(in *Input) string { return strings.ToLower(a.str(in)) }), nil
+	}
+
+	b := compiled[1]
+	switch name {
+	case "startsWith":
+		return boolNode(func(in *Input) bool { return strings.HasPrefix(a.str(in), b.str(in)) }), nil
+	case "endsWith":
+		return boolNode(func(in *Input) bool { return strings.HasSuffix(a.str(in), b.str(in)) }), nil
+	}
+
+	return boolNode(func(in *Input) bool { return strings.Contains(a.str(in), b.str(in)) }), nil
+}
+
+// compileMatches compiles the pattern of matches once, so it has to be a
+// string literal.
+func compileMatches(args []ast.Expr) (*node, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("function matches takes 2 arguments")
+	}
+
+	a, err := compile(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if a.kind != kindString {
+		return nil, fmt.Errorf("function matches only takes strings")
+	}
+
+	lit, ok := args[1].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return nil, fmt.Errorf("pattern of matches has to be a string literal")
+	}
+
+	pattern, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("pattern of matches is invalid: %v", err)
+	}
+
+	return boolNode(func(in *Input) bool { return re.MatchString(a.str(in)) }), nil
+}
@@ -0,0 +1,156 @@
+package policy
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newInput() *Input {
+	return &Input{
+		Model:    "gpt-4o",
+		Provider: "openai",
+		Path:     "/api/providers/openai/v1/chat/completions",
+		Method:   http.MethodPost,
+		KeyId:    "key-1",
+		KeyName:  "production",
+		Tags:     []string{"team-a"},
+		Headers:  http.Header{"X-Env": []string{"staging"}},
+		// a tuesday
+		Time:        time.Date(2024, 1, 2, 15, 30, 0, 0, time.UTC),
+		CountTokens: func() int { return 1200 },
+	}
+}
+
+func TestProgram_Eval(t *testing.T) {
+	cases := []struct {
+		name     string
+		expr     string
+		in       *Input
+		expected bool
+	}{
+		// precedence
+		{name: "&& binds tighter than ||", expr: `true || false && false`, expected: true},
+		{name: "&& binds tighter than || on the left", expr: `false && false || true`, expected: true},
+		{name: "parentheses override precedence", expr: `(true || false) && false`, expected: false},
+		{name: "! binds tighter than &&", expr: `!false && false`, expected: false},
+		{name: "! applies to parentheses", expr: `!(false && false)`, expected: true},
+		{name: "comparisons bind tighter than &&", expr: `tokens > 1000 && model == "gpt-4o"`, expected: true},
+		{name: "comparisons bind tighter than ||", expr: `model == "gpt-4" || model == "gpt-4o" && provider == "azure"`, expected: false},
+		{name: "grouped alternatives", expr: `(model == "gpt-4" || model == "gpt-4o") && provider == "openai"`, expected: true},
+		{name: "negated numbers", expr: `-tokens < -1000`, expected: true},
+		{name: "bools compare after their operands", expr: `model == "gpt-4o" == true`, expected: true},
+
+		// operators
+		{name: "string equality", expr: `keyId == "key-1"`, expected: true},
+		{name: "string inequality", expr: `method != "POST"`, expected: false},
+		{name: "string ordering", expr: `"a" < "b" && "b" >= "b"`, expected: true},
+		{name: "number ordering", expr: `tokens >= 1200.0 && tokens <= 1200`, expected: true},
+		{name: "bool equality", expr: `(hour > 12) == (minute > 12)`, expected: true},
+
+		// functions
+		{name: "function syntax", expr: `startsWith(model, "gpt")`, expected: true},
+		{name: "method syntax", expr: `model.startsWith("gpt")`, expected: true},
+		{name: "endsWith", expr: `path.endsWith("/completions")`, expected: true},
+		{name: "contains", expr: `contains(keyName, "duct")`, expected: true},
+		{name: "lower", expr: `lower("GPT-4o") == model`, expected: true},
+		{name: "matches", expr: `matches(model, "^gpt-4(o|-turbo)$")`, expected: true},
+		{name: "hasTag", expr: `hasTag("team-a")`, expected: true},
+		{name: "headers are case insensitive", expr: `header("x-env") == "staging"`, expected: true},
+		{name: "time of the request", expr: `weekday == "tue" && hour == 15 && minute == 30`, expected: true},
+		{name: "time in another zone is read in utc", expr: `hour == 15`, in: &Input{Time: time.Date(2024, 1, 2, 16, 30, 0, 0, time.FixedZone("CET", 3600))}, expected: true},
+
+		// missing attributes
+		{name: "missing tag", expr: `hasTag("team-b")`, expected: false},
+		{name: "missing header", expr: `header("X-Missing") == ""`, expected: true},
+		{name: "missing headers", expr: `header("X-Env") == ""`, in: &Input{}, expected: true},
+		{name: "missing model", expr: `model == ""`, in: &Input{}, expected: true},
+		{name: "missing tags", expr: `!hasTag("team-a")`, in: &Input{}, expected: true},
+		{name: "missing token counter", expr: `tokens == 0`, in: &Input{}, expected: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := Compile(tc.expr)
+			require.NoError(t, err)
+
+			in := tc.in
+			if in == nil {
+				in = newInput()
+			}
+
+			assert.Equal(t, tc.expected, p.Eval(in))
+		})
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	cases := []struct {
+		name string
+		expr string
+		err  string
+	}{
+		{name: "syntax error", expr: `model ==`, err: "cannot be parsed"},
+		{name: "single quoted string", expr: `model == 'g'`, err: "strings have to be double quoted"},
+		{name: "unknown variable", expr: `region == "eu"`, err: "variable region is unknown"},
+		{name: "unknown function", expr: `upper(model) == "GPT"`, err: "function upper is unknown"},
+		{name: "unsupported operator", expr: `tokens + 1 > 2`, err: "operator + is not supported"},
+		{name: "unsupported expression", expr: `model[0] == "g"`, err: "is not supported"},
+
+		// type mismatches
+		{name: "string result", expr: `model`, err: "evaluates to a string instead of a bool"},
+		{name: "number result", expr: `tokens`, err: "evaluates to a number instead of a bool"},
+		{name: "string compared with number", expr: `model == 1`, err: "cannot compare a string with a number"},
+		{name: "number compared with string", expr: `tokens > "1000"`, err: "cannot compare a number with a string"},
+		{name: "bool compared with string", expr: `hasTag("a") == "true"`, err: "cannot compare a bool with a string"},
+		{name: "and of strings", expr: `model && provider`, err: "operator && requires bools"},
+		{name: "or of numbers", expr: `tokens || hour`, err: "operator || requires bools"},
+		{name: "ordered bools", expr: `true < false`, err: "cannot compare bools"},
+		{name: "negated string", expr: `!model`, err: "operator ! cannot be applied to a string"},
+		{name: "negative string", expr: `-model == ""`, err: "operator - cannot be applied to a string"},
+		{name: "function with a number", expr: `startsWith(model, 1)`, err: "function startsWith only takes strings"},
+		{name: "function with too few arguments", expr: `startsWith(model)`, err: "function startsWith takes 2 arguments"},
+		{name: "function with too many arguments", expr: `hasTag("a", "b")`, err: "function hasTag takes 1 arguments"},
+		{name: "matches on a number", expr: `matches(tokens, "1")`, err: "function matches only takes strings"},
+		{name: "matches with a variable pattern", expr: `matches(model, keyName)`, err: "has to be a string literal"},
+		{name: "matches with an invalid pattern", expr: `matches(model, "[")`, err: "pattern of matches is invalid"},
+		{name: "errors of nested operands", expr: `true && (model == 1)`, err: "cannot compare a string with a number"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := Compile(tc.expr)
+			assert.Nil(t, p)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.err)
+		})
+	}
+}
+
+func TestProgram_EvalCountsTokensOnce(t *testing.T) {
+	p, err := Compile(`tokens > 100 && tokens < 2000 || tokens == 0`)
+	require.NoError(t, err)
+
+	calls := 0
+	in := &Input{CountTokens: func() int {
+		calls++
+		return 1200
+	}}
+
+	assert.True(t, p.Eval(in))
+	assert.True(t, p.Eval(in))
+	assert.Equal(t, 1, calls)
+
+	p, err = Compile(`model == "gpt-4o" && tokens > 100`)
+	require.NoError(t, err)
+
+	calls = 0
+	assert.False(t, p.Eval(&Input{CountTokens: func() int {
+		calls++
+		return 1200
+	}}))
+	assert.Equal(t, 0, calls, "&& short circuits before counting tokens")
+}
@@ -0,0 +1,141 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+type Action string
+
+const (
+	// ActionAllow lets matching requests through without evaluating the
+	// remaining policies.
+	ActionAllow Action = "allow"
+	// ActionDeny rejects matching requests.
+	ActionDeny Action = "deny"
+	// ActionSetModel replaces the model of matching requests.
+	ActionSetModel Action = "setModel"
+	// ActionCapMaxTokens lowers max_tokens of matching requests.
+	ActionCapMaxTokens Action = "capMaxTokens"
+)
+
+// Policy applies an action to the proxied requests its expression matches.
+// Policies are evaluated in the order of their priority, lowest first.
+type Policy struct {
+	Id         string `json:"id"`
+	CreatedAt  int64  `json:"createdAt"`
+	UpdatedAt  int64  `json:"updatedAt"`
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+	Action     Action `json:"action"`
+	Model      string `json:"model"`
+	MaxTokens  int    `json:"maxTokens"`
+	Priority   int    `json:"priority"`
+	Message    string `json:"message"`
+	Disabled   bool   `json:"disabled"`
+}
+
+func validate(invalid []string, expression *string, action *Action, model *string, maxTokens *int) ([]string, error) {
+	if expression != nil {
+		if _, err := Compile(*expression); err != nil {
+			return nil, internal_errors.NewValidationError(fmt.Sprintf("expression is invalid: %v", err))
+		}
+	}
+
+	if action == nil {
+		return invalid, nil
+	}
+
+	switch *action {
+	case ActionAllow, ActionDeny:
+	case ActionSetModel:
+		if model == nil || len(*model) == 0 {
+			invalid = append(invalid, "model")
+		}
+	case ActionCapMaxTokens:
+		if maxTokens == nil || *maxTokens <= 0 {
+			invalid = append(invalid, "maxTokens")
+		}
+	default:
+		invalid = append(invalid, "action")
+	}
+
+	return invalid, nil
+}
+
+func (p *Policy) Validate() error {
+	invalid := []string{}
+
+	if len(p.Name) == 0 {
+		invalid = append(invalid, "name")
+	}
+
+	if len(p.Expression) == 0 {
+		invalid = append(invalid, "expression")
+	}
+
+	invalid, err := validate(invalid, &p.Expression, &p.Action, &p.Model, &p.MaxTokens)
+	if err != nil {
+		return err
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
+
+type UpdatePolicy struct {
+	UpdatedAt  int64   `json:"updatedAt"`
+	Name       *string `json:"name"`
+	Expression *string `json:"expression"`
+	Action     *Action `json:"action"`
+	Model      *string `json:"model"`
+	MaxTokens  *int    `json:"maxTokens"`
+	Priority   *int    `json:"priority"`
+	Message    *string `json:"message"`
+	Disabled   *bool   `json:"disabled"`
+}
+
+// Validate checks the updated fields on their own. Whether the action of
+// the updated policy has the fields it needs is checked against the merged
+// policy by the manager.
+func (up *UpdatePolicy) Validate() error {
+	invalid := []string{}
+
+	if up.Name != nil && len(*up.Name) == 0 {
+		invalid = append(invalid, "name")
+	}
+
+	invalid, err := validate(invalid, up.Expression, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
+
+// Compiled is an enabled policy with its compiled expression.
+type Compiled struct {
+	*Policy
+	Program *Program
+}
+
+func NewCompiled(p *Policy) (*Compiled, error) {
+	program, err := Compile(p.Expression)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Compiled{
+		Policy:  p,
+		Program: program,
+	}, nil
+}
@@ -0,0 +1,114 @@
+package pricing
+
+import (
+	"fmt"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+const (
+	PromptCost     string = "prompt"
+	CompletionCost string = "completion"
+	EmbeddingsCost string = "embeddings"
+	FineTuneCost   string = "fine_tune"
+)
+
+var supportedProviders = map[string]bool{
+	"openai":    true,
+	"azure":     true,
+	"anthropic": true,
+}
+
+// Price overrides the built in token costs of a model. Costs that are not
+// set fall back to the defaults shipped with BricksLLM.
+type Price struct {
+	Id                             string   `json:"id"`
+	CreatedAt                      int64    `json:"createdAt"`
+	UpdatedAt                      int64    `json:"updatedAt"`
+	Provider                       string   `json:"provider"`
+	Model                          string   `json:"model"`
+	PromptCostPerMillionTokens     *float64 `json:"promptCostPerMillionTokens"`
+	CompletionCostPerMillionTokens *float64 `json:"completionCostPerMillionTokens"`
+	EmbeddingsCostPerMillionTokens *float64 `json:"embeddingsCostPerMillionTokens"`
+	FineTuneCostPerMillionTokens   *float64 `json:"fineTuneCostPerMillionTokens"`
+}
+
+func (p *Price) GetCost(costType string) (float64, bool) {
+	var cost *float64
+
+	switch costType {
+	case PromptCost:
+		cost = p.PromptCostPerMillionTokens
+	case CompletionCost:
+		cost = p.CompletionCostPerMillionTokens
+	case EmbeddingsCost:
+		cost = p.EmbeddingsCostPerMillionTokens
+	case FineTuneCost:
+		cost = p.FineTuneCostPerMillionTokens
+	}
+
+	if cost == nil {
+		return 0, false
+	}
+
+	return *cost, true
+}
+
+func validateCosts(invalid []string, costs map[string]*float64) []string {
+	for field, cost := range costs {
+		if cost != nil && *cost < 0 {
+			invalid = append(invalid, field)
+		}
+	}
+
+	return invalid
+}
+
+func (p *Price) Validate() error {
+	invalid := []string{}
+
+	if !supportedProviders[p.Provider] {
+		invalid = append(invalid, "provider")
+	}
+
+	if len(p.Model) == 0 {
+		invalid = append(invalid, "model")
+	}
+
+	invalid = validateCosts(invalid, map[string]*float64{
+		"promptCostPerMillionTokens":     p.PromptCostPerMillionTokens,
+		"completionCostPerMillionTokens": p.CompletionCostPerMillionTokens,
+		"embeddingsCostPerMillionTokens": p.EmbeddingsCostPerMillionTokens,
+		"fineTuneCostPerMillionTokens":   p.FineTuneCostPerMillionTokens,
+	})
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
+
+type UpdatePrice struct {
+	UpdatedAt                      int64    `json:"updatedAt"`
+	PromptCostPerMillionTokens     *float64 `json:"promptCostPerMillionTokens"`
+	CompletionCostPerMillionTokens *float64 `json:"completionCostPerMillionTokens"`
+	EmbeddingsCostPerMillionTokens *float64 `json:"embeddingsCostPerMillionTokens"`
+	FineTuneCostPerMillionTokens   *float64 `json:"fineTuneCostPerMillionTokens"`
+}
+
+func (up *UpdatePrice) Validate() error {
+	invalid := validateCosts([]string{}, map[string]*float64{
+		"promptCostPerMillionTokens":     up.PromptCostPerMillionTokens,
+		"completionCostPerMillionTokens": up.CompletionCostPerMillionTokens,
+		"embeddingsCostPerMillionTokens": up.EmbeddingsCostPerMillionTokens,
+		"fineTuneCostPerMillionTokens":   up.FineTuneCostPerMillionTokens,
+	})
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package privacy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+const pseudonymPrefix = "psn_"
+
+// Pseudonymizer replaces user ids with HMAC-SHA256 pseudonyms. The salt of
+// the HMAC is derived from the secret and the rotation period, so a user
+// keeps the same pseudonym within a period while pseudonyms of different
+// periods cannot be linked without the secret. A rotation of 0 never
+// rotates the salt.
+type Pseudonymizer struct {
+	secret   []byte
+	rotation time.Duration
+}
+
+// NewPseudonymizer returns nil when no secret is set.
+func NewPseudonymizer(secret string, rotation time.Duration) *Pseudonymizer {
+	if len(secret) == 0 {
+		return nil
+	}
+
+	return &Pseudonymizer{
+		secret:   []byte(secret),
+		rotation: rotation,
+	}
+}
+
+func (p *Pseudonymizer) period(at time.Time) int64 {
+	seconds := int64(p.rotation / time.Second)
+	if seconds <= 0 {
+		return 0
+	}
+
+	return at.Unix() / seconds
+}
+
+func (p *Pseudonymizer) pseudonym(id string, period int64) string {
+	salt := hmac.New(sha256.New, p.secret)
+	salt.Write([]byte(strconv.FormatInt(period, 10)))
+
+	mac := hmac.New(sha256.New, salt.Sum(nil))
+	mac.Write([]byte(id))
+
+	return pseudonymPrefix + hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// Pseudonymize returns the pseudonym of a user id at a time. Empty ids stay
+// empty.
+func (p *Pseudonymizer) Pseudonymize(id string, at time.Time) string {
+	if len(id) == 0 {
+		return ""
+	}
+
+	return p.pseudonym(id, p.period(at))
+}
+
+// Pseudonyms returns the pseudonyms a user id had in every period between
+// from and to.
+func (p *Pseudonymizer) Pseudonyms(id string, from, to time.Time) []string {
+	pseudonyms := []string{}
+	for period := p.period(from); period <= p.period(to); period++ {
+		pseudonyms = append(pseudonyms, p.pseudonym(id, period))
+	}
+
+	return pseudonyms
+}
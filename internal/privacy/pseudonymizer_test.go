@@ -0,0 +1,84 @@
+package privacy
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPseudonymizer(t *testing.T) {
+	assert.Nil(t, NewPseudonymizer("", time.Hour))
+	assert.NotNil(t, NewPseudonymizer("secret", time.Hour))
+}
+
+func TestPseudonymizer_Pseudonymize(t *testing.T) {
+	start := time.Unix(1700000000, 0).Truncate(time.Hour)
+
+	cases := []struct {
+		name     string
+		secret   string
+		rotation time.Duration
+		id       string
+		at       time.Time
+		same     bool
+	}{
+		{name: "pseudonyms are stable within a period", secret: "secret", rotation: time.Hour, id: "user-1", at: start.Add(59 * time.Minute), same: true},
+		{name: "pseudonyms change across periods", secret: "secret", rotation: time.Hour, id: "user-1", at: start.Add(time.Hour), same: false},
+		{name: "pseudonyms never change without rotation", secret: "secret", rotation: 0, id: "user-1", at: start.Add(1000 * time.Hour), same: true},
+		{name: "pseudonyms depend on the secret", secret: "other", rotation: time.Hour, id: "user-1", at: start, same: false},
+		{name: "pseudonyms depend on the user id", secret: "secret", rotation: time.Hour, id: "user-2", at: start, same: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			base := NewPseudonymizer("secret", tc.rotation).Pseudonymize("user-1", start)
+			p := NewPseudonymizer(tc.secret, tc.rotation).Pseudonymize(tc.id, tc.at)
+
+			assert.True(t, strings.HasPrefix(p, pseudonymPrefix))
+			assert.NotContains(t, p, tc.id)
+			assert.Equal(t, tc.same, base == p)
+		})
+	}
+
+	t.Run("empty ids stay empty", func(t *testing.T) {
+		assert.Empty(t, NewPseudonymizer("secret", time.Hour).Pseudonymize("", start))
+	})
+}
+
+func TestPseudonymizer_Pseudonyms(t *testing.T) {
+	p := NewPseudonymizer("secret", time.Hour)
+	start := time.Unix(1700000000, 0).Truncate(time.Hour)
+
+	cases := []struct {
+		name     string
+		from     time.Time
+		to       time.Time
+		expected []time.Time
+	}{
+		{name: "a single period", from: start, to: start.Add(30 * time.Minute), expected: []time.Time{start}},
+		{name: "every period of the range", from: start.Add(30 * time.Minute), to: start.Add(2 * time.Hour), expected: []time.Time{start, start.Add(time.Hour), start.Add(2 * time.Hour)}},
+		{name: "ranges ending before they start", from: start.Add(time.Hour), to: start, expected: []time.Time{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			expected := []string{}
+			for _, at := range tc.expected {
+				expected = append(expected, p.Pseudonymize("user-1", at))
+			}
+
+			assert.Equal(t, expected, p.Pseudonyms("user-1", tc.from, tc.to))
+		})
+	}
+
+	t.Run("pseudonyms without rotation", func(t *testing.T) {
+		p := NewPseudonymizer("secret", 0)
+		pseudonyms := p.Pseudonyms("user-1", start, start.Add(1000*time.Hour))
+
+		require.Len(t, pseudonyms, 1)
+		assert.Equal(t, p.Pseudonymize("user-1", start), pseudonyms[0])
+	})
+}
@@ -0,0 +1,152 @@
+package probe
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+)
+
+const (
+	defaultInterval = "5m"
+	defaultTimeout  = "10s"
+	minInterval     = 30 * time.Second
+)
+
+var supportedProviders = map[string]bool{
+	"openai":    true,
+	"azure":     true,
+	"anthropic": true,
+}
+
+// Probe periodically sends a canary request with a single completion token
+// either through a provider setting or through a route. Route probes run the
+// steps of the route with the provider settings in SettingIds, the same way
+// as a key with these settings would.
+type Probe struct {
+	Id         string            `json:"id"`
+	CreatedAt  int64             `json:"createdAt"`
+	UpdatedAt  int64             `json:"updatedAt"`
+	Name       string            `json:"name"`
+	SettingId  string            `json:"settingId,omitempty"`
+	Model      string            `json:"model,omitempty"`
+	Params     map[string]string `json:"params,omitempty"`
+	RouteId    string            `json:"routeId,omitempty"`
+	SettingIds []string          `json:"settingIds,omitempty"`
+	Interval   string            `json:"interval"`
+	Timeout    string            `json:"timeout"`
+}
+
+// Result is the outcome of a single run of a probe. A probe is available
+// when the upstream responded with 200.
+type Result struct {
+	Id          string `json:"id"`
+	ProbeId     string `json:"probeId"`
+	CreatedAt   int64  `json:"createdAt"`
+	SettingId   string `json:"settingId,omitempty"`
+	Available   bool   `json:"available"`
+	Status      int    `json:"status"`
+	LatencyInMs int    `json:"latencyInMs"`
+	Error       string `json:"error,omitempty"`
+}
+
+func (p *Probe) IsRouteProbe() bool {
+	return len(p.RouteId) != 0
+}
+
+func (p *Probe) SetDefaults() {
+	if len(p.Interval) == 0 {
+		p.Interval = defaultInterval
+	}
+
+	if len(p.Timeout) == 0 {
+		p.Timeout = defaultTimeout
+	}
+}
+
+func (p *Probe) Validate() error {
+	invalid := []string{}
+
+	if len(p.Name) == 0 {
+		invalid = append(invalid, "name")
+	}
+
+	if len(p.SettingId) == 0 && len(p.RouteId) == 0 {
+		invalid = append(invalid, "settingId", "routeId")
+	}
+
+	if len(p.SettingId) != 0 && len(p.RouteId) != 0 {
+		invalid = append(invalid, "routeId")
+	}
+
+	if len(p.SettingId) != 0 && len(p.Model) == 0 {
+		invalid = append(invalid, "model")
+	}
+
+	if p.IsRouteProbe() && len(p.SettingIds) == 0 {
+		invalid = append(invalid, "settingIds")
+	}
+
+	if !p.IsRouteProbe() && len(p.SettingIds) != 0 {
+		invalid = append(invalid, "settingIds")
+	}
+
+	if p.IsRouteProbe() && (len(p.Model) != 0 || len(p.Params) != 0) {
+		invalid = append(invalid, "model")
+	}
+
+	interval, err := time.ParseDuration(p.Interval)
+	if err != nil || interval < minInterval {
+		invalid = append(invalid, "interval")
+	}
+
+	timeout, err := time.ParseDuration(p.Timeout)
+	if err != nil || timeout <= 0 || (interval > 0 && timeout > interval) {
+		invalid = append(invalid, "timeout")
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
+
+// ValidateProvider checks that the provider of the setting of a probe can be
+// probed, and that azure probes name the deployment to send requests to.
+func (p *Probe) ValidateProvider(provider string) error {
+	if !supportedProviders[provider] {
+		return internal_errors.NewValidationError(fmt.Sprintf("provider %s cannot be probed", provider))
+	}
+
+	if provider == "azure" && (len(p.Params["deploymentId"]) == 0 || len(p.Params["apiVersion"]) == 0) {
+		return internal_errors.NewValidationError("fields [params.deploymentId, params.apiVersion] are invalid")
+	}
+
+	return nil
+}
+
+// Due reports whether the probe should run again after last.
+func (p *Probe) Due(last, now time.Time) bool {
+	interval, err := time.ParseDuration(p.Interval)
+	if err != nil {
+		return false
+	}
+
+	return now.Sub(last) >= interval
+}
+
+// Route returns the route with the single step a setting probe of an openai
+// or azure setting runs.
+func (p *Probe) Route(provider string) *route.Route {
+	return &route.Route{
+		Steps: []*route.Step{{
+			Provider: provider,
+			Model:    p.Model,
+			Params:   p.Params,
+			Timeout:  p.Timeout,
+		}},
+	}
+}
@@ -0,0 +1,408 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/digest"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+)
+
+const canaryContent = "ping"
+
+type Storage interface {
+	GetProbes() ([]*Probe, error)
+	GetProviderSettings(withSecret bool, ids []string) ([]*provider.Setting, error)
+	GetRoute(id string) (*route.Route, error)
+	InsertProbeResult(r *Result) error
+}
+
+type statusRecorder interface {
+	RecordStatus(setting *provider.Setting, status int) error
+}
+
+// Runner runs the probes that are due every interval. Statuses of setting
+// probes are recorded like the ones of proxied requests, so that failing
+// probes open circuit breakers and raise provider outage alerts before users
+// are affected. Probes failing threshold times in a row are also alerted on
+// directly, along with their recovery.
+type Runner struct {
+	s         Storage
+	sr        statusRecorder
+	senders   []digest.Sender
+	threshold int
+	egress    *provider.EgressClients
+	interval  time.Duration
+	lastRuns  map[string]time.Time
+	running   map[string]bool
+	failures  map[string]int
+	failing   map[string]bool
+	lock      sync.Mutex
+	done      chan bool
+	log       *zap.Logger
+}
+
+func NewRunner(s Storage, sr statusRecorder, senders []digest.Sender, threshold int, interval time.Duration, log *zap.Logger) *Runner {
+	return &Runner{
+		s:         s,
+		sr:        sr,
+		senders:   senders,
+		threshold: threshold,
+		egress:    provider.NewEgressClients(http.Client{}),
+		interval:  interval,
+		lastRuns:  map[string]time.Time{},
+		running:   map[string]bool{},
+		failures:  map[string]int{},
+		failing:   map[string]bool{},
+		done:      make(chan bool),
+		log:       log,
+	}
+}
+
+func (r *Runner) check() {
+	probes, err := r.s.GetProbes()
+	if err != nil {
+		stats.Incr("bricksllm.probe.runner.get_probes_error", nil, 1)
+		r.log.Sugar().Debugf("error when getting probes: %v", err)
+		return
+	}
+
+	now := time.Now()
+	ids := map[string]bool{}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, p := range probes {
+		ids[p.Id] = true
+
+		if r.running[p.Id] {
+			continue
+		}
+
+		if last, ok := r.lastRuns[p.Id]; ok && !p.Due(last, now) {
+			continue
+		}
+
+		r.lastRuns[p.Id] = now
+		r.running[p.Id] = true
+
+		go r.run(p)
+	}
+
+	// state of deleted probes is forgotten.
+	for id := range r.lastRuns {
+		if !ids[id] {
+			delete(r.lastRuns, id)
+			delete(r.failures, id)
+			delete(r.failing, id)
+		}
+	}
+}
+
+func (r *Runner) run(p *Probe) {
+	defer func() {
+		r.lock.Lock()
+		delete(r.running, p.Id)
+		r.lock.Unlock()
+	}()
+
+	res := &Result{
+		Id:        util.NewUuid(),
+		ProbeId:   p.Id,
+		CreatedAt: time.Now().Unix(),
+	}
+
+	setting, err := r.probe(p, res)
+	if err != nil {
+		res.Error = err.Error()
+	}
+
+	res.Available = res.Status == http.StatusOK
+
+	tags := []string{"probe:" + p.Id}
+	available := 0.0
+	if res.Available {
+		available = 1
+	}
+
+	stats.Gauge("bricksllm.probe.runner.available", available, tags, 1)
+	stats.Timing("bricksllm.probe.runner.latency", time.Duration(res.LatencyInMs)*time.Millisecond, tags, 1)
+
+	if setting != nil && res.Status != 0 {
+		if err := r.sr.RecordStatus(setting, res.Status); err != nil {
+			stats.Incr("bricksllm.probe.runner.record_status_error", nil, 1)
+			r.log.Sugar().Debugf("error when recording status of probe %s: %v", p.Id, err)
+		}
+	}
+
+	if err := r.s.InsertProbeResult(res); err != nil {
+		stats.Incr("bricksllm.probe.runner.insert_probe_result_error", nil, 1)
+		r.log.Sugar().Debugf("error when inserting result of probe %s: %v", p.Id, err)
+	}
+
+	r.recordOutcome(p, res)
+}
+
+// probe sends the canary request of a probe and returns the provider
+// setting that served it.
+func (r *Runner) probe(p *Probe, res *Result) (*provider.Setting, error) {
+	if p.IsRouteProbe() {
+		return r.probeRoute(p, res)
+	}
+
+	settings, err := r.s.GetProviderSettings(true, []string{p.SettingId})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(settings) == 0 {
+		return nil, errors.New("provider setting is not found")
+	}
+
+	setting := settings[0]
+	res.SettingId = setting.Id
+
+	if setting.Provider == "anthropic" {
+		return setting, r.probeAnthropic(p, setting, res)
+	}
+
+	rt := p.Route(setting.Provider)
+	return setting, r.runSteps(rt, map[string]*provider.Setting{setting.Id: setting}, res)
+}
+
+func (r *Runner) probeRoute(p *Probe, res *Result) (*provider.Setting, error) {
+	rt, err := r.s.GetRoute(p.RouteId)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := r.s.GetProviderSettings(true, p.SettingIds)
+	if err != nil {
+		return nil, err
+	}
+
+	settingsMap := map[string]*provider.Setting{}
+	for _, setting := range settings {
+		settingsMap[setting.Id] = setting
+	}
+
+	err = r.runSteps(rt, settingsMap, res)
+
+	return settingsMap[res.SettingId], err
+}
+
+func (r *Runner) runSteps(rt *route.Route, settings map[string]*provider.Setting, res *Result) error {
+	body := map[string]interface{}{
+		"messages": []map[string]string{{
+			"role":    "user",
+			"content": canaryContent,
+		}},
+		"max_tokens": 1,
+	}
+
+	if rt.ShouldRunEmbeddings() {
+		body = map[string]interface{}{
+			"input": canaryContent,
+		}
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	forwarded, err := http.NewRequest(http.MethodPost, rt.Path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	forwarded.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	runRes, err := rt.RunSteps(&route.Request{
+		Settings:  settings,
+		Egress:    r.egress,
+		Forwarded: forwarded,
+	})
+	res.LatencyInMs = int(time.Now().Sub(start).Milliseconds())
+	if err != nil {
+		return err
+	}
+
+	defer runRes.Cancel()
+	defer runRes.Response.Body.Close()
+
+	res.SettingId = runRes.SettingId
+
+	return r.readResponse(runRes.Response, res)
+}
+
+func (r *Runner) probeAnthropic(p *Probe, setting *provider.Setting, res *Result) error {
+	timeout, err := time.ParseDuration(p.Timeout)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"model":      p.Model,
+		"max_tokens": 1,
+		"messages": []map[string]string{{
+			"role":    "user",
+			"content": canaryContent,
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.anthropic.com/v1/messages", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	apiKey := setting.NextApiKey()
+	if len(apiKey) == 0 {
+		return errors.New("anthropic setting param: apikey not found")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", apiKey)
+	req.Header.Set("anthropic-version", "2023-06-01")
+
+	client, err := r.egress.Get(setting.Egress)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	hres, err := client.Do(req)
+	res.LatencyInMs = int(time.Now().Sub(start).Milliseconds())
+	if err != nil {
+		return err
+	}
+	defer hres.Body.Close()
+
+	if hres.StatusCode == http.StatusTooManyRequests {
+		setting.RateLimited(apiKey)
+	}
+
+	return r.readResponse(hres, res)
+}
+
+func (r *Runner) readResponse(hres *http.Response, res *Result) error {
+	res.Status = hres.StatusCode
+
+	data, err := io.ReadAll(hres.Body)
+	if err != nil {
+		return err
+	}
+
+	if hres.StatusCode != http.StatusOK {
+		if message := gjson.GetBytes(data, "error.message").Str; len(message) != 0 {
+			return errors.New(message)
+		}
+
+		return fmt.Errorf("upstream responded with status %d", hres.StatusCode)
+	}
+
+	return nil
+}
+
+// recordOutcome counts consecutive failures of a probe, alerting when the
+// count reaches the threshold and when the probe succeeds again.
+func (r *Runner) recordOutcome(p *Probe, res *Result) {
+	if r.threshold <= 0 {
+		return
+	}
+
+	r.lock.Lock()
+	if res.Available {
+		failing := r.failing[p.Id]
+		delete(r.failures, p.Id)
+		delete(r.failing, p.Id)
+		r.lock.Unlock()
+
+		if failing {
+			r.log.Sugar().Infof("probe %s recovered", p.Id)
+			r.send(fmt.Sprintf("BricksLLM probe %s recovered", p.Name), fmt.Sprintf("Probe %s (%s) succeeded again in %dms.", p.Name, p.Id, res.LatencyInMs))
+		}
+
+		return
+	}
+
+	r.failures[p.Id]++
+	failures := r.failures[p.Id]
+	alert := failures >= r.threshold && !r.failing[p.Id]
+	if alert {
+		r.failing[p.Id] = true
+	}
+	r.lock.Unlock()
+
+	if !alert {
+		return
+	}
+
+	stats.Incr("bricksllm.probe.runner.failing", []string{"probe:" + p.Id}, 1)
+	r.log.Sugar().Warnf("probe %s failed %d times in a row", p.Id, failures)
+
+	target := "provider setting " + p.SettingId
+	if p.IsRouteProbe() {
+		target = "route " + p.RouteId
+	}
+
+	text := fmt.Sprintf("Probe %s (%s) of %s failed %d times in a row, last with status %d.", p.Name, p.Id, target, failures, res.Status)
+	if len(res.Error) != 0 {
+		text += "\nError: " + res.Error
+	}
+
+	r.send(fmt.Sprintf("BricksLLM probe %s is failing", p.Name), text)
+}
+
+func (r *Runner) send(subject, text string) {
+	for _, s := range r.senders {
+		if err := s.Send(subject, text); err != nil {
+			stats.Incr("bricksllm.probe.runner.alert.send_error", nil, 1)
+			r.log.Sugar().Debugf("error when sending probe alert: %v", err)
+		}
+	}
+}
+
+func (r *Runner) Listen() {
+	ticker := time.NewTicker(r.interval)
+	r.log.Info("probe runner started")
+
+	go func() {
+		for {
+			select {
+			case <-r.done:
+				ticker.Stop()
+				r.log.Info("probe runner stopped")
+				return
+			case <-ticker.C:
+				r.check()
+			}
+		}
+	}()
+}
+
+func (r *Runner) Stop() {
+	r.log.Info("shutting down probe runner...")
+
+	r.done <- true
+}
@@ -0,0 +1,88 @@
+package prompt
+
+import (
+	"encoding/json"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+type SystemPromptMode string
+
+const (
+	// PrependSystemPromptMode inserts the system prompt in front of the
+	// messages sent by the client.
+	PrependSystemPromptMode SystemPromptMode = "prepend"
+	// PrefixSystemPromptMode rejects requests whose first message is not a
+	// system message starting with the system prompt.
+	PrefixSystemPromptMode SystemPromptMode = "prefix"
+)
+
+type SystemPrompt struct {
+	Content string           `json:"content"`
+	Mode    SystemPromptMode `json:"mode"`
+}
+
+func (sp *SystemPrompt) Validate(field string) []string {
+	invalid := []string{}
+
+	if len(sp.Content) == 0 {
+		invalid = append(invalid, field+".content")
+	}
+
+	if len(sp.Mode) != 0 && sp.Mode != PrependSystemPromptMode && sp.Mode != PrefixSystemPromptMode {
+		invalid = append(invalid, field+".mode")
+	}
+
+	return invalid
+}
+
+func (sp *SystemPrompt) GetMode() SystemPromptMode {
+	if len(sp.Mode) == 0 {
+		return PrependSystemPromptMode
+	}
+
+	return sp.Mode
+}
+
+// EnforceSystemPrompts verifies every prefix prompt against the messages sent
+// by the client before prepending the remaining prompts in the given order.
+func EnforceSystemPrompts(messages []json.RawMessage, prompts ...*SystemPrompt) ([]json.RawMessage, error) {
+	for _, sp := range prompts {
+		if sp == nil || sp.GetMode() != PrefixSystemPromptMode {
+			continue
+		}
+
+		if len(messages) == 0 || !startsWithSystemPrompt(messages[0], sp.Content) {
+			return nil, internal_errors.NewValidationError("the first message must be a system message starting with the required system prompt")
+		}
+	}
+
+	prepended := []json.RawMessage{}
+	for _, sp := range prompts {
+		if sp == nil || sp.GetMode() != PrependSystemPromptMode {
+			continue
+		}
+
+		data, err := json.Marshal(&Message{
+			Role:    "system",
+			Content: sp.Content,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		prepended = append(prepended, data)
+	}
+
+	return append(prepended, messages...), nil
+}
+
+func startsWithSystemPrompt(raw json.RawMessage, content string) bool {
+	m := &Message{}
+	if err := json.Unmarshal(raw, m); err != nil {
+		return false
+	}
+
+	return m.Role == "system" && strings.HasPrefix(m.Content, content)
+}
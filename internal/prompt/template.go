@@ -0,0 +1,116 @@
+package prompt
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+var (
+	variablePattern     = regexp.MustCompile(`{{\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*}}`)
+	templateNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_\-.]{1,255}$`)
+)
+
+var supportedRoles = map[string]bool{
+	"system":    true,
+	"user":      true,
+	"assistant": true,
+}
+
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Template is an immutable version of a named prompt. Updating a template
+// creates a new version so that routes pinned to older versions keep working.
+type Template struct {
+	Id          string     `json:"id"`
+	CreatedAt   int64      `json:"createdAt"`
+	Name        string     `json:"name"`
+	Version     int        `json:"version"`
+	Description string     `json:"description"`
+	Messages    []*Message `json:"messages"`
+}
+
+func (t *Template) Validate() error {
+	invalid := []string{}
+
+	if !templateNamePattern.MatchString(t.Name) {
+		invalid = append(invalid, "name")
+	}
+
+	if len(t.Messages) == 0 {
+		invalid = append(invalid, "messages")
+	}
+
+	for index, m := range t.Messages {
+		if m == nil || !supportedRoles[m.Role] {
+			invalid = append(invalid, fmt.Sprintf("messages.[%d].role", index))
+		}
+
+		if m == nil || len(m.Content) == 0 {
+			invalid = append(invalid, fmt.Sprintf("messages.[%d].content", index))
+		}
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
+
+// Variables returns the sorted names of the variables referenced by the template.
+func (t *Template) Variables() []string {
+	seen := map[string]bool{}
+	for _, m := range t.Messages {
+		for _, match := range variablePattern.FindAllStringSubmatch(m.Content, -1) {
+			seen[match[1]] = true
+		}
+	}
+
+	names := []string{}
+	for name := range seen {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Render substitutes {{variable}} placeholders. Every referenced variable has
+// to be provided so that half rendered prompts are never sent upstream.
+func (t *Template) Render(variables map[string]string) ([]*Message, error) {
+	missing := []string{}
+	for _, name := range t.Variables() {
+		if _, ok := variables[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return nil, internal_errors.NewValidationError(fmt.Sprintf("variables [%s] are missing", strings.Join(missing, ", ")))
+	}
+
+	rendered := []*Message{}
+	for _, m := range t.Messages {
+		rendered = append(rendered, &Message{
+			Role: m.Role,
+			Content: variablePattern.ReplaceAllStringFunc(m.Content, func(placeholder string) string {
+				return variables[variablePattern.FindStringSubmatch(placeholder)[1]]
+			}),
+		})
+	}
+
+	return rendered, nil
+}
+
+type TemplateWithVariables struct {
+	*Template
+	Variables []string `json:"variables"`
+}
@@ -21,15 +21,21 @@ type tokenCounter interface {
 	Count(input string) int
 }
 
+type priceStorage interface {
+	GetCostPerMillionTokens(provider, model, costType string) (float64, bool)
+}
+
 type CostEstimator struct {
 	tokenCostMap map[string]map[string]float64
 	tc           tokenCounter
+	ps           priceStorage
 }
 
-func NewCostEstimator(tc tokenCounter) *CostEstimator {
+func NewCostEstimator(tc tokenCounter, ps priceStorage) *CostEstimator {
 	return &CostEstimator{
 		tokenCostMap: AnthropicPerMillionTokenCost,
 		tc:           tc,
+		ps:           ps,
 	}
 }
 
@@ -48,6 +54,10 @@ func (ce *CostEstimator) EstimateTotalCost(model string, promptTks, completionTk
 }
 
 func (ce *CostEstimator) EstimatePromptCost(model string, tks int) (float64, error) {
+	if cost, ok := ce.getOverriddenCost("prompt", model); ok {
+		return float64(tks) / 1000000 * cost, nil
+	}
+
 	costMap, ok := ce.tokenCostMap["prompt"]
 	if !ok {
 		return 0, errors.New("prompt token cost is not provided")
@@ -75,6 +85,10 @@ func selectModel(model string) string {
 }
 
 func (ce *CostEstimator) EstimateCompletionCost(model string, tks int) (float64, error) {
+	if cost, ok := ce.getOverriddenCost("completion", model); ok {
+		return float64(tks) / 1000000 * cost, nil
+	}
+
 	costMap, ok := ce.tokenCostMap["completion"]
 	if !ok {
 		return 0, errors.New("prompt token cost is not provided")
@@ -93,3 +107,13 @@ func (ce *CostEstimator) EstimateCompletionCost(model string, tks int) (float64,
 func (ce *CostEstimator) Count(input string) int {
 	return ce.tc.Count(input)
 }
+
+// overrides are matched against the full model name before selectModel
+// collapses it into a model family.
+func (ce *CostEstimator) getOverriddenCost(costType, model string) (float64, bool) {
+	if ce.ps == nil {
+		return 0, false
+	}
+
+	return ce.ps.GetCostPerMillionTokens("anthropic", model, costType)
+}
@@ -3,10 +3,17 @@ package azure
 import (
 	"errors"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 
+	"github.com/bricks-cloud/bricksllm/internal/provider/openai"
 	goopenai "github.com/sashabaranov/go-openai"
 )
 
+// AzureOpenAiPerThousandTokenCost overrides the OpenAI prices for models that
+// Azure names or prices differently. Models that are not listed here are
+// priced with openai.OpenAiPerThousandTokenCost.
 var AzureOpenAiPerThousandTokenCost = map[string]map[string]float64{
 	"prompt": {
 		"gpt-4":                 0.03,
@@ -29,14 +36,61 @@ var AzureOpenAiPerThousandTokenCost = map[string]map[string]float64{
 	},
 }
 
+// versionSuffixPattern matches the version that Azure appends to the model
+// of a deployment, e.g. gpt-4-0613 or gpt-4o-2024-05-13.
+var versionSuffixPattern = regexp.MustCompile(`-(\d{4}|\d{4}-\d{2}-\d{2})$`)
+
+type priceStorage interface {
+	GetCostPerMillionTokens(provider, model, costType string) (float64, bool)
+}
+
 type CostEstimator struct {
-	tokenCostMap map[string]map[string]float64
+	tokenCostMap       map[string]map[string]float64
+	openAiTokenCostMap map[string]map[string]float64
+	multipliers        map[string]float64
+	ps                 priceStorage
 }
 
-func NewCostEstimator() *CostEstimator {
+// NewCostEstimator creates an estimator that prices Azure models with the
+// Azure overrides first and the OpenAI prices second. multipliers are keyed
+// by the region of a provider setting.
+func NewCostEstimator(ps priceStorage, multipliers map[string]float64) *CostEstimator {
 	return &CostEstimator{
-		tokenCostMap: AzureOpenAiPerThousandTokenCost,
+		tokenCostMap:       AzureOpenAiPerThousandTokenCost,
+		openAiTokenCostMap: openai.OpenAiPerThousandTokenCost,
+		multipliers:        multipliers,
+		ps:                 ps,
+	}
+}
+
+// ParseRegionalMultipliers parses multipliers formatted as region:multiplier.
+func ParseRegionalMultipliers(values []string) (map[string]float64, error) {
+	multipliers := map[string]float64{}
+	for _, value := range values {
+		region, raw, found := strings.Cut(strings.TrimSpace(value), ":")
+		if !found || len(region) == 0 {
+			return nil, fmt.Errorf("regional multiplier %s is not formatted as region:multiplier", value)
+		}
+
+		multiplier, err := strconv.ParseFloat(raw, 64)
+		if err != nil || multiplier <= 0 {
+			return nil, fmt.Errorf("regional multiplier of %s must be a positive number", region)
+		}
+
+		multipliers[region] = multiplier
 	}
+
+	return multipliers, nil
+}
+
+// RegionalMultiplier returns the multiplier applied to the cost of requests
+// served by a provider setting in region.
+func (ce *CostEstimator) RegionalMultiplier(region string) float64 {
+	if multiplier, ok := ce.multipliers[region]; ok {
+		return multiplier
+	}
+
+	return 1
 }
 
 func (ce *CostEstimator) EstimateTotalCost(model string, promptTks, completionTks int) (float64, error) {
@@ -54,12 +108,11 @@ func (ce *CostEstimator) EstimateTotalCost(model string, promptTks, completionTk
 }
 
 func (ce *CostEstimator) EstimatePromptCost(model string, tks int) (float64, error) {
-	costMap, ok := ce.tokenCostMap["prompt"]
-	if !ok {
-		return 0, errors.New("prompt token cost is not provided")
+	if cost, ok := ce.getOverriddenCost("prompt", model); ok {
+		return float64(tks) / 1000000 * cost, nil
 	}
 
-	cost, ok := costMap[model]
+	cost, ok := ce.getCost("prompt", model)
 	if !ok {
 		return 0, fmt.Errorf("%s is not present in the cost map provided", model)
 	}
@@ -69,13 +122,11 @@ func (ce *CostEstimator) EstimatePromptCost(model string, tks int) (float64, err
 }
 
 func (ce *CostEstimator) EstimateEmbeddingsInputCost(model string, tks int) (float64, error) {
-	costMap, ok := ce.tokenCostMap["embeddings"]
-	if !ok {
-		return 0, errors.New("embeddings token cost is not provided")
-
+	if cost, ok := ce.getOverriddenCost("embeddings", model); ok {
+		return float64(tks) / 1000000 * cost, nil
 	}
 
-	cost, ok := costMap[model]
+	cost, ok := ce.getCost("embeddings", model)
 	if !ok {
 		return 0, fmt.Errorf("%s is not present in the cost map provided", model)
 	}
@@ -85,14 +136,13 @@ func (ce *CostEstimator) EstimateEmbeddingsInputCost(model string, tks int) (flo
 }
 
 func (ce *CostEstimator) EstimateCompletionCost(model string, tks int) (float64, error) {
-	costMap, ok := ce.tokenCostMap["completion"]
-	if !ok {
-		return 0, errors.New("prompt token cost is not provided")
+	if cost, ok := ce.getOverriddenCost("completion", model); ok {
+		return float64(tks) / 1000000 * cost, nil
 	}
 
-	cost, ok := costMap[model]
+	cost, ok := ce.getCost("completion", model)
 	if !ok {
-		return 0, errors.New("model is not present in the cost map provided")
+		return 0, fmt.Errorf("%s is not present in the cost map provided", model)
 	}
 
 	tksInFloat := float64(tks)
@@ -148,3 +198,39 @@ func (ce *CostEstimator) EstimateEmbeddingsCost(r *goopenai.EmbeddingRequest) (f
 
 	return 0, errors.New("input format is not recognized")
 }
+
+// getCost looks up the price of model in the Azure overrides and then in the
+// OpenAI prices under its OpenAI name, with and without its version suffix.
+func (ce *CostEstimator) getCost(costType, model string) (float64, bool) {
+	candidates := []string{model}
+	if unversioned := versionSuffixPattern.ReplaceAllString(model, ""); unversioned != model {
+		candidates = append(candidates, unversioned)
+	}
+
+	for _, candidate := range candidates {
+		if cost, ok := ce.tokenCostMap[costType][candidate]; ok {
+			return cost, true
+		}
+
+		if cost, ok := ce.openAiTokenCostMap[costType][toOpenAiModel(candidate)]; ok {
+			return cost, true
+		}
+	}
+
+	return 0, false
+}
+
+// toOpenAiModel converts an Azure model name like gpt-35-turbo to its OpenAI
+// name gpt-3.5-turbo.
+func toOpenAiModel(model string) string {
+	return strings.Replace(model, "gpt-35-", "gpt-3.5-", 1)
+}
+
+// prices set through the admin API are keyed by the azure model name, e.g. gpt-35-turbo.
+func (ce *CostEstimator) getOverriddenCost(costType, model string) (float64, bool) {
+	if ce.ps == nil {
+		return 0, false
+	}
+
+	return ce.ps.GetCostPerMillionTokens("azure", model, costType)
+}
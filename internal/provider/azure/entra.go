@@ -0,0 +1,257 @@
+package azure
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+)
+
+// params of Azure settings selecting how requests are authenticated to
+// Azure OpenAI.
+const (
+	AuthTypeParam     = "authType"
+	TenantIdParam     = "tenantId"
+	ClientIdParam     = "clientId"
+	ClientSecretParam = "clientSecret"
+)
+
+const (
+	AuthTypeApiKey            = "apiKey"
+	AuthTypeClientCredentials = "clientCredentials"
+	AuthTypeManagedIdentity   = "managedIdentity"
+)
+
+const (
+	cognitiveServicesScope    = "https://cognitiveservices.azure.com/.default"
+	cognitiveServicesResource = "https://cognitiveservices.azure.com/"
+	entraTokenUrl             = "https://login.microsoftonline.com/%s/oauth2/v2.0/token"
+	imdsTokenUrl              = "http://169.254.169.254/metadata/identity/oauth2/token"
+)
+
+// tokens are refreshed once they are this close to expiring.
+const refreshMargin = 5 * time.Minute
+
+var tokens = newTokenCache(&http.Client{Timeout: 10 * time.Second})
+
+// UsesEntraId reports whether the setting authenticates with Entra ID tokens
+// instead of a static api key.
+func UsesEntraId(s *provider.Setting) bool {
+	if s == nil {
+		return false
+	}
+
+	t := s.GetParam(AuthTypeParam)
+	return t == AuthTypeClientCredentials || t == AuthTypeManagedIdentity
+}
+
+// FindMissingAuthParams lists the params an Azure setting needs for its
+// auth type.
+func FindMissingAuthParams(params map[string]string) ([]string, error) {
+	required := []string{}
+
+	switch params[AuthTypeParam] {
+	case "", AuthTypeApiKey:
+		if len(params[provider.ApiKeysParam]) == 0 {
+			required = append(required, provider.ApiKeyParam)
+		}
+	case AuthTypeClientCredentials:
+		required = append(required, TenantIdParam, ClientIdParam, ClientSecretParam)
+	case AuthTypeManagedIdentity:
+	default:
+		return nil, fmt.Errorf("%s must be one of %s, %s or %s", AuthTypeParam, AuthTypeApiKey, AuthTypeClientCredentials, AuthTypeManagedIdentity)
+	}
+
+	missing := []string{}
+	for _, param := range required {
+		if len(params[param]) == 0 {
+			missing = append(missing, param)
+		}
+	}
+
+	return missing, nil
+}
+
+// SetAuthHeader authenticates a request to Azure OpenAI with either the api
+// key or a bearer token of the setting.
+func SetAuthHeader(h http.Header, s *provider.Setting) error {
+	if s == nil {
+		return errors.New("azure setting is not found")
+	}
+
+	if !UsesEntraId(s) {
+		apiKey := s.NextApiKey()
+		if len(apiKey) == 0 {
+			return errors.New("api key is empty in provider setting")
+		}
+
+		h.Set("api-key", apiKey)
+		return nil
+	}
+
+	token, err := tokens.get(s)
+	if err != nil {
+		return err
+	}
+
+	h.Del("api-key")
+	h.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+type accessToken struct {
+	value     string
+	expiresAt time.Time
+}
+
+type tokenEntry struct {
+	mu    sync.Mutex
+	token *accessToken
+}
+
+type tokenCache struct {
+	client  *http.Client
+	mu      sync.Mutex
+	entries map[string]*tokenEntry
+}
+
+func newTokenCache(client *http.Client) *tokenCache {
+	return &tokenCache{
+		client:  client,
+		entries: map[string]*tokenEntry{},
+	}
+}
+
+// entryKey changes whenever the setting is updated so that tokens issued for
+// old credentials are not reused.
+func entryKey(s *provider.Setting) string {
+	return s.Id + ":" + strconv.FormatInt(s.UpdatedAt, 10)
+}
+
+func (tc *tokenCache) entry(s *provider.Setting) *tokenEntry {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	key := entryKey(s)
+	if e, ok := tc.entries[key]; ok {
+		return e
+	}
+
+	prefix := s.Id + ":"
+	for k := range tc.entries {
+		if strings.HasPrefix(k, prefix) {
+			delete(tc.entries, k)
+		}
+	}
+
+	e := &tokenEntry{}
+	tc.entries[key] = e
+	return e
+}
+
+func (tc *tokenCache) get(s *provider.Setting) (string, error) {
+	e := tc.entry(s)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.token != nil && time.Now().Add(refreshMargin).Before(e.token.expiresAt) {
+		return e.token.value, nil
+	}
+
+	token, err := tc.fetch(s)
+	if err != nil {
+		// a token that has not expired yet is still usable while Entra ID is unreachable.
+		if e.token != nil && time.Now().Before(e.token.expiresAt) {
+			return e.token.value, nil
+		}
+
+		return "", err
+	}
+
+	e.token = token
+	return token.value, nil
+}
+
+func (tc *tokenCache) fetch(s *provider.Setting) (*accessToken, error) {
+	var req *http.Request
+	var err error
+
+	if s.GetParam(AuthTypeParam) == AuthTypeClientCredentials {
+		form := url.Values{}
+		form.Set("grant_type", "client_credentials")
+		form.Set("client_id", s.GetParam(ClientIdParam))
+		form.Set("client_secret", s.GetParam(ClientSecretParam))
+		form.Set("scope", cognitiveServicesScope)
+
+		req, err = http.NewRequest(http.MethodPost, fmt.Sprintf(entraTokenUrl, url.PathEscape(s.GetParam(TenantIdParam))), strings.NewReader(form.Encode()))
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		query := url.Values{}
+		query.Set("api-version", "2018-02-01")
+		query.Set("resource", cognitiveServicesResource)
+
+		// a client id selects a user assigned identity.
+		if clientId := s.GetParam(ClientIdParam); len(clientId) != 0 {
+			query.Set("client_id", clientId)
+		}
+
+		req, err = http.NewRequest(http.MethodGet, imdsTokenUrl+"?"+query.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Metadata", "true")
+	}
+
+	res, err := tc.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("entra id token request failed with status code %d: %s", res.StatusCode, string(data))
+	}
+
+	// managed identity endpoints encode expires_in as a string.
+	parsed := struct {
+		AccessToken string      `json:"access_token"`
+		ExpiresIn   json.Number `json:"expires_in"`
+	}{}
+
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, err
+	}
+
+	if len(parsed.AccessToken) == 0 {
+		return nil, errors.New("entra id token response does not contain an access token")
+	}
+
+	seconds, err := parsed.ExpiresIn.Int64()
+	if err != nil {
+		return nil, err
+	}
+
+	return &accessToken{
+		value:     parsed.AccessToken,
+		expiresAt: time.Now().Add(time.Duration(seconds) * time.Second),
+	}, nil
+}
@@ -19,6 +19,10 @@ type RouteConfig struct {
 	StreamEndWord                    string `json:"stream_end_word"`
 	StreamResponseCompletionLocation string `json:"stream_response_completion_location"`
 	StreamMaxEmptyMessages           int    `json:"stream_max_empty_messages"`
+	ResponsePromptTokensLocation     string `json:"response_prompt_tokens_location"`
+	ResponseCompletionTokensLocation string `json:"response_completion_tokens_location"`
+	ResponseCostLocation             string `json:"response_cost_location"`
+	ResponseErrorLocation            string `json:"response_error_location"`
 }
 
 type UpdateProvider struct {
@@ -0,0 +1,61 @@
+package custom
+
+import (
+	"github.com/tidwall/gjson"
+)
+
+// Usage holds the values found at the usage locations of a route config.
+// Fields are nil when the location is not configured or absent from the
+// response.
+type Usage struct {
+	PromptTokens     *int
+	CompletionTokens *int
+	CostInUsd        *float64
+}
+
+func getNumber(data []byte, loc string) (gjson.Result, bool) {
+	if len(loc) == 0 {
+		return gjson.Result{}, false
+	}
+
+	result := gjson.GetBytes(data, loc)
+	if result.Type != gjson.Number {
+		return gjson.Result{}, false
+	}
+
+	return result, true
+}
+
+// ExtractUsage reads token counts and cost from a response body or a stream
+// chunk. Values found in data replace the ones already held by u so that the
+// usage reported in the last chunk of a stream wins.
+func (rc *RouteConfig) ExtractUsage(data []byte, u *Usage) {
+	if result, ok := getNumber(data, rc.ResponsePromptTokensLocation); ok {
+		tks := int(result.Int())
+		u.PromptTokens = &tks
+	}
+
+	if result, ok := getNumber(data, rc.ResponseCompletionTokensLocation); ok {
+		tks := int(result.Int())
+		u.CompletionTokens = &tks
+	}
+
+	if result, ok := getNumber(data, rc.ResponseCostLocation); ok {
+		cost := result.Float()
+		u.CostInUsd = &cost
+	}
+}
+
+// ExtractError returns the error message of an upstream error response.
+func (rc *RouteConfig) ExtractError(data []byte) string {
+	if len(rc.ResponseErrorLocation) == 0 {
+		return ""
+	}
+
+	result := gjson.GetBytes(data, rc.ResponseErrorLocation)
+	if result.Type == gjson.String {
+		return result.Str
+	}
+
+	return result.Raw
+}
@@ -0,0 +1,146 @@
+package provider
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+var supportedProxySchemes = map[string]bool{
+	"http":    true,
+	"https":   true,
+	"socks5":  true,
+	"socks5h": true,
+}
+
+// Egress describes how requests leave the gateway for an upstream. Requests
+// go through ProxyUrl when it is set, and certificates of the upstream or of
+// the proxy are also verified against CaBundle, a list of PEM certificates.
+type Egress struct {
+	ProxyUrl string `json:"proxyUrl,omitempty"`
+	CaBundle string `json:"caBundle,omitempty"`
+}
+
+func (e *Egress) IsEmpty() bool {
+	return e == nil || (len(e.ProxyUrl) == 0 && len(e.CaBundle) == 0)
+}
+
+// Redacted hides the password of the proxy url.
+func (e *Egress) Redacted() *Egress {
+	if e == nil || len(e.ProxyUrl) == 0 {
+		return e
+	}
+
+	u, err := url.Parse(e.ProxyUrl)
+	if err != nil {
+		return e
+	}
+
+	return &Egress{
+		ProxyUrl: u.Redacted(),
+		CaBundle: e.CaBundle,
+	}
+}
+
+func (e *Egress) Validate() error {
+	invalid := []string{}
+
+	if len(e.ProxyUrl) != 0 {
+		u, err := url.Parse(e.ProxyUrl)
+		if err != nil || !supportedProxySchemes[strings.ToLower(u.Scheme)] || len(u.Host) == 0 {
+			invalid = append(invalid, "egress.proxyUrl")
+		}
+	}
+
+	if len(e.CaBundle) != 0 && !x509.NewCertPool().AppendCertsFromPEM([]byte(e.CaBundle)) {
+		invalid = append(invalid, "egress.caBundle")
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
+
+func (e *Egress) id() string {
+	h := sha256.New()
+	h.Write([]byte(e.ProxyUrl))
+	h.Write([]byte{0})
+	h.Write([]byte(e.CaBundle))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// EgressClients keeps one http client per egress configuration so that
+// connections to the proxy and the upstream are reused across requests.
+type EgressClients struct {
+	base    http.Client
+	lock    sync.Mutex
+	clients map[string]*http.Client
+}
+
+func NewEgressClients(base http.Client) *EgressClients {
+	return &EgressClients{
+		base:    base,
+		clients: map[string]*http.Client{},
+	}
+}
+
+// Get returns the default client when the egress configuration is empty.
+func (ec *EgressClients) Get(e *Egress) (*http.Client, error) {
+	if e.IsEmpty() {
+		return &ec.base, nil
+	}
+
+	id := e.id()
+
+	ec.lock.Lock()
+	defer ec.lock.Unlock()
+
+	if client, ok := ec.clients[id]; ok {
+		return client, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if len(e.ProxyUrl) != 0 {
+		u, err := url.Parse(e.ProxyUrl)
+		if err != nil {
+			return nil, err
+		}
+
+		transport.Proxy = http.ProxyURL(u)
+	}
+
+	if len(e.CaBundle) != 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM([]byte(e.CaBundle)) {
+			return nil, internal_errors.NewValidationError("egress ca bundle does not contain any pem certificate")
+		}
+
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs: pool,
+		}
+	}
+
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   ec.base.Timeout,
+	}
+
+	ec.clients[id] = client
+
+	return client, nil
+}
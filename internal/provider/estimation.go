@@ -0,0 +1,66 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+type EstimationRequest struct {
+	Provider  string                           `json:"provider"`
+	Model     string                           `json:"model"`
+	Messages  []goopenai.ChatCompletionMessage `json:"messages"`
+	Prompt    string                           `json:"prompt"`
+	Input     interface{}                      `json:"input"`
+	MaxTokens int                              `json:"max_tokens"`
+}
+
+func (er *EstimationRequest) IsEmbeddingsRequest() bool {
+	return er.Input != nil
+}
+
+func (er *EstimationRequest) Validate() error {
+	invalid := []string{}
+
+	if er.Provider != "openai" && er.Provider != "azure" && er.Provider != "anthropic" {
+		invalid = append(invalid, "provider")
+	}
+
+	if len(er.Model) == 0 {
+		invalid = append(invalid, "model")
+	}
+
+	if er.MaxTokens < 0 {
+		invalid = append(invalid, "max_tokens")
+	}
+
+	if er.Provider == "anthropic" && len(er.Prompt) == 0 {
+		invalid = append(invalid, "prompt")
+	}
+
+	if er.Provider == "anthropic" && er.IsEmbeddingsRequest() {
+		invalid = append(invalid, "input")
+	}
+
+	if er.Provider != "anthropic" && len(er.Messages) == 0 && !er.IsEmbeddingsRequest() {
+		invalid = append(invalid, "messages")
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
+
+type EstimationResponse struct {
+	Provider             string  `json:"provider"`
+	Model                string  `json:"model"`
+	PromptTokenCount     int     `json:"promptTokenCount"`
+	CompletionTokenCount int     `json:"completionTokenCount"`
+	PromptCostInUsd      float64 `json:"promptCostInUsd"`
+	CompletionCostInUsd  float64 `json:"completionCostInUsd"`
+	TotalCostInUsd       float64 `json:"totalCostInUsd"`
+}
@@ -0,0 +1,22 @@
+package provider
+
+import "net/http"
+
+// Health tracks the consecutive upstream authentication failures of a provider
+// setting. A setting is unhealthy once the failures reach the configured
+// threshold and stays unhealthy until it is updated or reset.
+type Health struct {
+	SettingId               string `json:"settingId"`
+	Provider                string `json:"provider"`
+	TenantId                string `json:"tenantId,omitempty"`
+	ConsecutiveAuthFailures int64  `json:"consecutiveAuthFailures"`
+	Unhealthy               bool   `json:"unhealthy"`
+	LastStatus              int    `json:"lastStatus,omitempty"`
+	UnhealthySince          int64  `json:"unhealthySince,omitempty"`
+}
+
+// IsAuthFailure reports whether an upstream status code means the credentials
+// of a setting were rejected.
+func IsAuthFailure(status int) bool {
+	return status == http.StatusUnauthorized || status == http.StatusForbidden
+}
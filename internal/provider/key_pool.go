@@ -0,0 +1,102 @@
+package provider
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// params of settings holding a pool of upstream api keys instead of a single
+// one. Keys are separated by commas or new lines.
+const (
+	ApiKeyParam      = "apikey"
+	ApiKeysParam     = "apikeys"
+	KeyRotationParam = "keyRotation"
+)
+
+const (
+	// KeyRotationRoundRobin uses the next key of the pool for every request.
+	KeyRotationRoundRobin = "roundRobin"
+	// KeyRotationOnRateLimit keeps using a key until the upstream rate limits it.
+	KeyRotationOnRateLimit = "onRateLimit"
+)
+
+type keyCursor struct {
+	next uint64
+}
+
+var keyCursors sync.Map
+
+func (s *Setting) cursor() *keyCursor {
+	c, _ := keyCursors.LoadOrStore(s.Id, &keyCursor{})
+	return c.(*keyCursor)
+}
+
+// ApiKeys returns the pool of the setting followed by its single api key.
+// Duplicates and blank entries are dropped.
+func (s *Setting) ApiKeys() []string {
+	keys := []string{}
+	seen := map[string]bool{}
+
+	add := func(k string) {
+		k = strings.TrimSpace(k)
+		if len(k) == 0 || seen[k] {
+			return
+		}
+
+		seen[k] = true
+		keys = append(keys, k)
+	}
+
+	for _, k := range strings.FieldsFunc(s.GetParam(ApiKeysParam), func(r rune) bool {
+		return r == ',' || r == '\n'
+	}) {
+		add(k)
+	}
+
+	add(s.GetParam(ApiKeyParam))
+
+	return keys
+}
+
+// NextApiKey selects the upstream api key of a request according to the key
+// rotation of the setting.
+func (s *Setting) NextApiKey() string {
+	keys := s.ApiKeys()
+	if len(keys) == 0 {
+		return ""
+	}
+
+	if len(keys) == 1 {
+		return keys[0]
+	}
+
+	c := s.cursor()
+	if s.GetParam(KeyRotationParam) == KeyRotationOnRateLimit {
+		return keys[atomic.LoadUint64(&c.next)%uint64(len(keys))]
+	}
+
+	return keys[(atomic.AddUint64(&c.next, 1)-1)%uint64(len(keys))]
+}
+
+// RateLimited moves a setting rotating on rate limits past the key that was
+// rate limited. Requests that were rate limited concurrently with the same
+// key only advance the pool once.
+func (s *Setting) RateLimited(apiKey string) {
+	if s.GetParam(KeyRotationParam) != KeyRotationOnRateLimit {
+		return
+	}
+
+	keys := s.ApiKeys()
+	if len(keys) < 2 {
+		return
+	}
+
+	c := s.cursor()
+	current := atomic.LoadUint64(&c.next)
+	if keys[current%uint64(len(keys))] != apiKey {
+		return
+	}
+
+	atomic.CompareAndSwapUint64(&c.next, current, current+1)
+}
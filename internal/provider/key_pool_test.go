@@ -0,0 +1,102 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetting_ApiKeys(t *testing.T) {
+	cases := []struct {
+		name     string
+		params   map[string]string
+		expected []string
+	}{
+		{name: "single keys", params: map[string]string{ApiKeyParam: "k1"}, expected: []string{"k1"}},
+		{name: "pools are followed by the single key", params: map[string]string{ApiKeysParam: "k1,k2\nk3", ApiKeyParam: "k4"}, expected: []string{"k1", "k2", "k3", "k4"}},
+		{name: "duplicates and blank entries are dropped", params: map[string]string{ApiKeysParam: " k1 , ,k2,\n\nk1", ApiKeyParam: "k2"}, expected: []string{"k1", "k2"}},
+		{name: "settings without keys", params: map[string]string{}, expected: []string{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &Setting{Setting: tc.params}
+			assert.Equal(t, tc.expected, s.ApiKeys())
+		})
+	}
+}
+
+func TestSetting_NextApiKey(t *testing.T) {
+	cases := []struct {
+		name        string
+		params      map[string]string
+		rateLimited map[int]bool
+		expected    []string
+	}{
+		{
+			name:     "round robin pools use the next key for every request",
+			params:   map[string]string{ApiKeysParam: "k1,k2,k3"},
+			expected: []string{"k1", "k2", "k3", "k1"},
+		},
+		{
+			name:        "round robin pools are not moved by rate limits",
+			params:      map[string]string{ApiKeysParam: "k1,k2,k3", KeyRotationParam: KeyRotationRoundRobin},
+			rateLimited: map[int]bool{0: true},
+			expected:    []string{"k1", "k2", "k3"},
+		},
+		{
+			name:        "rate limited keys are rotated",
+			params:      map[string]string{ApiKeysParam: "k1,k2", KeyRotationParam: KeyRotationOnRateLimit},
+			rateLimited: map[int]bool{1: true, 3: true},
+			expected:    []string{"k1", "k1", "k2", "k2", "k1"},
+		},
+		{
+			name:        "single keys",
+			params:      map[string]string{ApiKeyParam: "k1", KeyRotationParam: KeyRotationOnRateLimit},
+			rateLimited: map[int]bool{0: true},
+			expected:    []string{"k1", "k1"},
+		},
+		{
+			name:     "settings without keys",
+			params:   map[string]string{},
+			expected: []string{""},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &Setting{Id: t.Name(), Setting: tc.params}
+
+			keys := []string{}
+			for i := range tc.expected {
+				k := s.NextApiKey()
+				keys = append(keys, k)
+
+				if tc.rateLimited[i] {
+					s.RateLimited(k)
+				}
+			}
+
+			assert.Equal(t, tc.expected, keys)
+		})
+	}
+}
+
+func TestSetting_RateLimited(t *testing.T) {
+	t.Run("concurrent rate limits of the same key rotate the pool once", func(t *testing.T) {
+		s := &Setting{Id: t.Name(), Setting: map[string]string{ApiKeysParam: "k1,k2,k3", KeyRotationParam: KeyRotationOnRateLimit}}
+
+		s.RateLimited("k1")
+		s.RateLimited("k1")
+
+		assert.Equal(t, "k2", s.NextApiKey())
+	})
+
+	t.Run("rate limits of keys that are not current", func(t *testing.T) {
+		s := &Setting{Id: t.Name(), Setting: map[string]string{ApiKeysParam: "k1,k2,k3", KeyRotationParam: KeyRotationOnRateLimit}}
+
+		s.RateLimited("k3")
+
+		assert.Equal(t, "k1", s.NextApiKey())
+	})
+}
@@ -79,15 +79,21 @@ type tokenCounter interface {
 	Count(model string, input string) (int, error)
 }
 
+type priceStorage interface {
+	GetCostPerMillionTokens(provider, model, costType string) (float64, bool)
+}
+
 type CostEstimator struct {
 	tokenCostMap map[string]map[string]float64
 	tc           tokenCounter
+	ps           priceStorage
 }
 
-func NewCostEstimator(m map[string]map[string]float64, tc tokenCounter) *CostEstimator {
+func NewCostEstimator(m map[string]map[string]float64, tc tokenCounter, ps priceStorage) *CostEstimator {
 	return &CostEstimator{
 		tokenCostMap: m,
 		tc:           tc,
+		ps:           ps,
 	}
 }
 
@@ -106,6 +112,10 @@ func (ce *CostEstimator) EstimateTotalCost(model string, promptTks, completionTk
 }
 
 func (ce *CostEstimator) EstimatePromptCost(model string, tks int) (float64, error) {
+	if cost, ok := ce.getOverriddenCost("prompt", model); ok {
+		return float64(tks) / 1000000 * cost, nil
+	}
+
 	costMap, ok := ce.tokenCostMap["prompt"]
 	if !ok {
 		return 0, errors.New("prompt token cost is not provided")
@@ -122,6 +132,10 @@ func (ce *CostEstimator) EstimatePromptCost(model string, tks int) (float64, err
 }
 
 func (ce *CostEstimator) EstimateEmbeddingsInputCost(model string, tks int) (float64, error) {
+	if cost, ok := ce.getOverriddenCost("embeddings", model); ok {
+		return float64(tks) / 1000000 * cost, nil
+	}
+
 	costMap, ok := ce.tokenCostMap["embeddings"]
 	if !ok {
 		return 0, errors.New("embeddings token cost is not provided")
@@ -138,6 +152,10 @@ func (ce *CostEstimator) EstimateEmbeddingsInputCost(model string, tks int) (flo
 }
 
 func (ce *CostEstimator) EstimateCompletionCost(model string, tks int) (float64, error) {
+	if cost, ok := ce.getOverriddenCost("completion", model); ok {
+		return float64(tks) / 1000000 * cost, nil
+	}
+
 	costMap, ok := ce.tokenCostMap["completion"]
 	if !ok {
 		return 0, errors.New("prompt token cost is not provided")
@@ -364,3 +382,13 @@ func countTotalTokens(model string, r *goopenai.ChatCompletionRequest, tc tokenC
 
 	return tks + ftks + mtks, err
 }
+
+// getOverriddenCost looks up a per million token cost configured through the
+// admin pricing API, which takes precedence over the built in cost map.
+func (ce *CostEstimator) getOverriddenCost(costType, model string) (float64, bool) {
+	if ce.ps == nil {
+		return 0, false
+	}
+
+	return ce.ps.GetCostPerMillionTokens("openai", model, costType)
+}
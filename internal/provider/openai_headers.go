@@ -0,0 +1,40 @@
+package provider
+
+import (
+	"net/http"
+)
+
+// params of OpenAI settings selecting the organization and the project
+// requests are billed to.
+const (
+	OpenAiOrganizationParam       = "organization"
+	OpenAiProjectParam            = "project"
+	OpenAiStripClientHeadersParam = "stripClientHeaders"
+)
+
+const (
+	openAiOrganizationHeader = "OpenAI-Organization"
+	openAiProjectHeader      = "OpenAI-Project"
+)
+
+// ApplyOpenAiHeaders pins the organization and the project of the setting.
+// Values sent by clients are kept for headers that are not pinned unless
+// the setting strips them.
+func (s *Setting) ApplyOpenAiHeaders(h http.Header) {
+	if s == nil || s.Provider != "openai" {
+		return
+	}
+
+	if s.GetParam(OpenAiStripClientHeadersParam) == "true" {
+		h.Del(openAiOrganizationHeader)
+		h.Del(openAiProjectHeader)
+	}
+
+	if org := s.GetParam(OpenAiOrganizationParam); len(org) != 0 {
+		h.Set(openAiOrganizationHeader, org)
+	}
+
+	if project := s.GetParam(OpenAiProjectParam); len(project) != 0 {
+		h.Set(openAiProjectHeader, project)
+	}
+}
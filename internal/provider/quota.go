@@ -0,0 +1,110 @@
+package provider
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Quota is the remaining upstream rate limit of a provider setting as last
+// reported by the provider in its response headers.
+type Quota struct {
+	SettingId         string `json:"settingId"`
+	Provider          string `json:"provider"`
+	LimitRequests     *int64 `json:"limitRequests,omitempty"`
+	RemainingRequests *int64 `json:"remainingRequests,omitempty"`
+	RequestsResetAt   *int64 `json:"requestsResetAt,omitempty"`
+	LimitTokens       *int64 `json:"limitTokens,omitempty"`
+	RemainingTokens   *int64 `json:"remainingTokens,omitempty"`
+	TokensResetAt     *int64 `json:"tokensResetAt,omitempty"`
+	UpdatedAt         int64  `json:"updatedAt"`
+}
+
+type quotaHeaders struct {
+	limitRequests     string
+	remainingRequests string
+	resetRequests     string
+	limitTokens       string
+	remainingTokens   string
+	resetTokens       string
+}
+
+// OpenAI and Azure OpenAI report reset times as durations while Anthropic uses
+// RFC 3339 timestamps. Both forms are accepted for every provider.
+var (
+	openAiQuotaHeaders = quotaHeaders{
+		limitRequests:     "x-ratelimit-limit-requests",
+		remainingRequests: "x-ratelimit-remaining-requests",
+		resetRequests:     "x-ratelimit-reset-requests",
+		limitTokens:       "x-ratelimit-limit-tokens",
+		remainingTokens:   "x-ratelimit-remaining-tokens",
+		resetTokens:       "x-ratelimit-reset-tokens",
+	}
+
+	anthropicQuotaHeaders = quotaHeaders{
+		limitRequests:     "anthropic-ratelimit-requests-limit",
+		remainingRequests: "anthropic-ratelimit-requests-remaining",
+		resetRequests:     "anthropic-ratelimit-requests-reset",
+		limitTokens:       "anthropic-ratelimit-tokens-limit",
+		remainingTokens:   "anthropic-ratelimit-tokens-remaining",
+		resetTokens:       "anthropic-ratelimit-tokens-reset",
+	}
+)
+
+func parseQuotaCount(h http.Header, name string) *int64 {
+	parsed, err := strconv.ParseInt(h.Get(name), 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	return &parsed
+}
+
+func parseQuotaReset(h http.Header, name string, now time.Time) *int64 {
+	raw := h.Get(name)
+	if len(raw) == 0 {
+		return nil
+	}
+
+	if d, err := time.ParseDuration(raw); err == nil {
+		ts := now.Add(d).Unix()
+		return &ts
+	}
+
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		ts := t.Unix()
+		return &ts
+	}
+
+	return nil
+}
+
+func (q *Quota) isEmpty() bool {
+	return q.LimitRequests == nil && q.RemainingRequests == nil && q.LimitTokens == nil && q.RemainingTokens == nil
+}
+
+// ParseQuota extracts rate limit information from upstream response headers.
+// It returns nil when the response does not carry any.
+func ParseQuota(settingId, providerName string, h http.Header) *Quota {
+	now := time.Now()
+
+	for _, names := range []quotaHeaders{openAiQuotaHeaders, anthropicQuotaHeaders} {
+		q := &Quota{
+			SettingId:         settingId,
+			Provider:          providerName,
+			LimitRequests:     parseQuotaCount(h, names.limitRequests),
+			RemainingRequests: parseQuotaCount(h, names.remainingRequests),
+			RequestsResetAt:   parseQuotaReset(h, names.resetRequests, now),
+			LimitTokens:       parseQuotaCount(h, names.limitTokens),
+			RemainingTokens:   parseQuotaCount(h, names.remainingTokens),
+			TokensResetAt:     parseQuotaReset(h, names.resetTokens, now),
+			UpdatedAt:         now.Unix(),
+		}
+
+		if !q.isEmpty() {
+			return q
+		}
+	}
+
+	return nil
+}
@@ -8,6 +8,9 @@ type Setting struct {
 	Id            string            `json:"id"`
 	Name          string            `json:"name"`
 	AllowedModels []string          `json:"allowedModels"`
+	TenantId      string            `json:"tenantId"`
+	Egress        *Egress           `json:"egress,omitempty"`
+	Region        string            `json:"region,omitempty"`
 }
 
 func (s *Setting) GetParam(key string) string {
@@ -19,4 +22,6 @@ type UpdateSetting struct {
 	Setting       map[string]string `json:"setting,omitempty"`
 	Name          *string           `json:"name"`
 	AllowedModels *[]string         `json:"allowedModels,omitempty"`
+	Egress        *Egress           `json:"egress,omitempty"`
+	Region        *string           `json:"region,omitempty"`
 }
@@ -0,0 +1,9 @@
+package provider
+
+// params of settings used to fetch the usage reported by providers. OpenAI
+// settings need an admin key for the costs api, Azure settings a link to a
+// csv cost export.
+const (
+	OpenAiUsageApiKeyParam  = "usageApiKey"
+	AzureCostExportUrlParam = "costExportUrl"
+)
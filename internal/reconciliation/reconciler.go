@@ -0,0 +1,171 @@
+package reconciliation
+
+import (
+	"math"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"go.uber.org/zap"
+)
+
+const dayLayout = "2006-01-02"
+
+type Storage interface {
+	GetProviderSettings(withSecret bool, ids []string) ([]*provider.Setting, error)
+	GetRecordedCostsBySetting(start, end int64) (map[string]float64, error)
+	UpsertReconciliation(r *event.Reconciliation) error
+}
+
+// Reconciler compares the spend recorded per provider setting with the cost
+// reported by providers every night. Providers publish usage with a delay,
+// so the last lookbackDays days are reconciled again on every run.
+type Reconciler struct {
+	s                   Storage
+	fetchers            map[string]UsageFetcher
+	thresholdPercentage float64
+	minDifferenceInUsd  float64
+	lookbackDays        int
+	done                chan bool
+	log                 *zap.Logger
+}
+
+func NewReconciler(s Storage, thresholdPercentage, minDifferenceInUsd float64, lookbackDays int, log *zap.Logger) *Reconciler {
+	return &Reconciler{
+		s: s,
+		fetchers: map[string]UsageFetcher{
+			"openai": NewOpenAiFetcher(),
+			"azure":  NewAzureFetcher(),
+		},
+		thresholdPercentage: thresholdPercentage,
+		minDifferenceInUsd:  minDifferenceInUsd,
+		lookbackDays:        lookbackDays,
+		done:                make(chan bool),
+		log:                 log,
+	}
+}
+
+// compare fills in the difference between the recorded and the reported cost
+// and flags it when it is above both thresholds.
+func (r *Reconciler) compare(rc *event.Reconciliation) {
+	rc.DifferenceInUsd = rc.ReportedCostInUsd - rc.RecordedCostInUsd
+
+	base := math.Max(rc.ReportedCostInUsd, rc.RecordedCostInUsd)
+	if base > 0 {
+		rc.DifferencePercentage = math.Abs(rc.DifferenceInUsd) / base * 100
+	}
+
+	rc.Flagged = math.Abs(rc.DifferenceInUsd) >= r.minDifferenceInUsd && rc.DifferencePercentage > r.thresholdPercentage
+}
+
+// Reconcile reconciles every configured provider setting for the UTC day
+// starting at day.
+func (r *Reconciler) Reconcile(day time.Time) error {
+	start := day.UTC().Truncate(24 * time.Hour)
+	end := start.AddDate(0, 0, 1)
+
+	settings, err := r.s.GetProviderSettings(true, nil)
+	if err != nil {
+		return err
+	}
+
+	recorded, err := r.s.GetRecordedCostsBySetting(start.Unix(), end.Unix())
+	if err != nil {
+		return err
+	}
+
+	for _, setting := range settings {
+		fetcher, ok := r.fetchers[setting.Provider]
+		if !ok {
+			continue
+		}
+
+		reported, err := fetcher.FetchCost(setting, start, end)
+		if err == errNotConfigured {
+			continue
+		}
+
+		rc := &event.Reconciliation{
+			Day:               start.Format(dayLayout),
+			SettingId:         setting.Id,
+			Provider:          setting.Provider,
+			TenantId:          setting.TenantId,
+			RecordedCostInUsd: recorded[setting.Id],
+			ReportedCostInUsd: reported,
+			UpdatedAt:         time.Now().Unix(),
+		}
+
+		if err != nil {
+			stats.Incr("bricksllm.reconciliation.reconciler.reconcile.fetch_cost_error", []string{
+				"provider:" + setting.Provider,
+			}, 1)
+
+			r.log.Sugar().Debugf("error when fetching cost of provider setting %s: %v", setting.Id, err)
+			rc.Error = err.Error()
+		} else {
+			r.compare(rc)
+		}
+
+		if rc.Flagged {
+			stats.Incr("bricksllm.reconciliation.reconciler.reconcile.flagged", []string{
+				"provider:" + setting.Provider,
+			}, 1)
+
+			r.log.Sugar().Warnf("spend of provider setting %s on %s differs from the cost reported by %s by %.2f%% (recorded %.4f usd, reported %.4f usd)", setting.Id, rc.Day, setting.Provider, rc.DifferencePercentage, rc.RecordedCostInUsd, rc.ReportedCostInUsd)
+		}
+
+		if err := r.s.UpsertReconciliation(rc); err != nil {
+			stats.Incr("bricksllm.reconciliation.reconciler.reconcile.upsert_reconciliation_error", nil, 1)
+			r.log.Sugar().Debugf("error when storing reconciliation of provider setting %s: %v", setting.Id, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *Reconciler) run(now time.Time) {
+	today := now.UTC().Truncate(24 * time.Hour)
+
+	for i := 1; i <= r.lookbackDays; i++ {
+		day := today.AddDate(0, 0, -i)
+		if err := r.Reconcile(day); err != nil {
+			stats.Incr("bricksllm.reconciliation.reconciler.run.reconcile_error", nil, 1)
+			r.log.Sugar().Debugf("error when reconciling %s: %v", day.Format(dayLayout), err)
+			continue
+		}
+
+		stats.Incr("bricksllm.reconciliation.reconciler.run.success", nil, 1)
+	}
+}
+
+// nextRun returns the start of the next UTC day.
+func nextRun(now time.Time) time.Time {
+	return now.UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+}
+
+func (r *Reconciler) Start() {
+	r.log.Sugar().Infof("reconciler started reconciling the last %d days every night", r.lookbackDays)
+
+	go func() {
+		for {
+			next := nextRun(time.Now())
+			timer := time.NewTimer(time.Until(next))
+
+			select {
+			case <-r.done:
+				timer.Stop()
+				r.log.Info("reconciler stopped")
+				return
+			case <-timer.C:
+				r.run(next)
+			}
+		}
+	}()
+}
+
+func (r *Reconciler) Stop() {
+	r.log.Info("shutting down reconciler...")
+
+	r.done <- true
+}
@@ -0,0 +1,245 @@
+package reconciliation
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+)
+
+const openAiCostsUrl = "https://api.openai.com/v1/organization/costs"
+
+// errNotConfigured is returned for settings that have no way of fetching the
+// usage reported by their provider. They are left out of reconciliations.
+var errNotConfigured = errors.New("setting is not configured for reconciliation")
+
+// UsageFetcher returns the cost in usd a provider reports for a setting
+// between start and end.
+type UsageFetcher interface {
+	FetchCost(setting *provider.Setting, start, end time.Time) (float64, error)
+}
+
+type openAiCostsResponse struct {
+	Data []struct {
+		Results []struct {
+			Amount struct {
+				Value    float64 `json:"value"`
+				Currency string  `json:"currency"`
+			} `json:"amount"`
+		} `json:"results"`
+	} `json:"data"`
+	HasMore  bool   `json:"has_more"`
+	NextPage string `json:"next_page"`
+}
+
+// OpenAiFetcher reads the organization costs api with the admin key of the
+// setting. Costs are narrowed down to the project of the setting when one is
+// pinned.
+type OpenAiFetcher struct {
+	client http.Client
+	url    string
+}
+
+func NewOpenAiFetcher() *OpenAiFetcher {
+	return &OpenAiFetcher{
+		client: http.Client{
+			Timeout: 30 * time.Second,
+		},
+		url: openAiCostsUrl,
+	}
+}
+
+func (f *OpenAiFetcher) FetchCost(setting *provider.Setting, start, end time.Time) (float64, error) {
+	adminKey := setting.GetParam(provider.OpenAiUsageApiKeyParam)
+	if len(adminKey) == 0 {
+		return 0, errNotConfigured
+	}
+
+	params := url.Values{}
+	params.Set("start_time", strconv.FormatInt(start.Unix(), 10))
+	params.Set("end_time", strconv.FormatInt(end.Unix(), 10))
+	params.Set("bucket_width", "1d")
+	if project := setting.GetParam(provider.OpenAiProjectParam); len(project) != 0 {
+		params.Set("project_ids", project)
+	}
+
+	total := 0.0
+	for {
+		req, err := http.NewRequest(http.MethodGet, f.url+"?"+params.Encode(), nil)
+		if err != nil {
+			return 0, err
+		}
+
+		req.Header.Set("Authorization", "Bearer "+adminKey)
+		if org := setting.GetParam(provider.OpenAiOrganizationParam); len(org) != 0 {
+			req.Header.Set("OpenAI-Organization", org)
+		}
+
+		res, err := f.client.Do(req)
+		if err != nil {
+			return 0, err
+		}
+
+		data, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return 0, err
+		}
+
+		if res.StatusCode != http.StatusOK {
+			return 0, fmt.Errorf("openai costs api responded with status %d: %s", res.StatusCode, string(data))
+		}
+
+		parsed := &openAiCostsResponse{}
+		if err := json.Unmarshal(data, parsed); err != nil {
+			return 0, err
+		}
+
+		for _, bucket := range parsed.Data {
+			for _, result := range bucket.Results {
+				if len(result.Amount.Currency) != 0 && !strings.EqualFold(result.Amount.Currency, "usd") {
+					return 0, fmt.Errorf("openai costs api reported currency %s", result.Amount.Currency)
+				}
+
+				total += result.Amount.Value
+			}
+		}
+
+		if !parsed.HasMore || len(parsed.NextPage) == 0 {
+			return total, nil
+		}
+
+		params.Set("page", parsed.NextPage)
+	}
+}
+
+// cost export columns differ between export types and schema versions.
+var (
+	azureDateColumns     = []string{"date", "usagedatetime"}
+	azureCostColumns     = []string{"costinusd", "costinbillingcurrency", "pretaxcost", "cost"}
+	azureResourceColumns = []string{"resourceid", "instanceid", "resourcename"}
+	azureDateLayouts     = []string{"2006-01-02", "01/02/2006", time.RFC3339}
+)
+
+// AzureFetcher reads a csv cost management export, for example through a
+// blob SAS url, and sums the cost of the rows belonging to the resource of
+// the setting.
+type AzureFetcher struct {
+	client http.Client
+}
+
+func NewAzureFetcher() *AzureFetcher {
+	return &AzureFetcher{
+		client: http.Client{
+			Timeout: 2 * time.Minute,
+		},
+	}
+}
+
+func findColumn(header []string, names []string) int {
+	for _, name := range names {
+		for idx, column := range header {
+			// exports saved by spreadsheet tools start with a byte order mark.
+			if strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")), name) {
+				return idx
+			}
+		}
+	}
+
+	return -1
+}
+
+func parseAzureDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range azureDateLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("cannot parse azure cost export date %s", value)
+}
+
+// matchesResource reports whether a resource id or name belongs to the azure
+// openai resource of a setting.
+func matchesResource(value, resourceName string) bool {
+	segments := strings.Split(strings.TrimRight(value, "/"), "/")
+	return strings.EqualFold(segments[len(segments)-1], resourceName)
+}
+
+func (f *AzureFetcher) FetchCost(setting *provider.Setting, start, end time.Time) (float64, error) {
+	exportUrl := setting.GetParam(provider.AzureCostExportUrlParam)
+	if len(exportUrl) == 0 {
+		return 0, errNotConfigured
+	}
+
+	res, err := f.client.Get(exportUrl)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("azure cost export responded with status %d", res.StatusCode)
+	}
+
+	r := csv.NewReader(res.Body)
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if err != nil {
+		return 0, err
+	}
+
+	dateIdx := findColumn(header, azureDateColumns)
+	costIdx := findColumn(header, azureCostColumns)
+	resourceIdx := findColumn(header, azureResourceColumns)
+	if dateIdx == -1 || costIdx == -1 || resourceIdx == -1 {
+		return 0, errors.New("azure cost export is missing date, cost or resource columns")
+	}
+
+	resourceName := setting.GetParam("resourceName")
+	total := 0.0
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return total, nil
+		}
+
+		if err != nil {
+			return 0, err
+		}
+
+		if len(record) <= dateIdx || len(record) <= costIdx || len(record) <= resourceIdx {
+			continue
+		}
+
+		if !matchesResource(record[resourceIdx], resourceName) {
+			continue
+		}
+
+		day, err := parseAzureDate(record[dateIdx])
+		if err != nil {
+			return 0, err
+		}
+
+		if day.Before(start) || !day.Before(end) {
+			continue
+		}
+
+		cost, err := strconv.ParseFloat(strings.TrimSpace(record[costIdx]), 64)
+		if err != nil {
+			return 0, fmt.Errorf("cannot parse azure cost export cost %s", record[costIdx])
+		}
+
+		total += cost
+	}
+}
@@ -0,0 +1,204 @@
+package recorder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"go.uber.org/zap"
+)
+
+type unavailableError interface {
+	Unavailable()
+}
+
+type eventInserter interface {
+	InsertEvent(e *event.Event) error
+}
+
+// EventBuffer keeps events on local disk while Postgresql is unavailable and
+// inserts them once it is reachable again. Each event is stored in its own
+// file so that a crash loses at most the event being written.
+type EventBuffer struct {
+	dir      string
+	maxBytes int64
+	size     int64
+	es       eventInserter
+	lock     sync.Mutex
+	interval time.Duration
+	done     chan bool
+	log      *zap.Logger
+}
+
+func NewEventBuffer(dir string, maxBytes int64, es eventInserter, interval time.Duration, log *zap.Logger) (*EventBuffer, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	eb := &EventBuffer{
+		dir:      dir,
+		maxBytes: maxBytes,
+		es:       es,
+		interval: interval,
+		done:     make(chan bool),
+		log:      log,
+	}
+
+	entries, err := eb.entries()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		eb.size += info.Size()
+	}
+
+	if len(entries) != 0 {
+		log.Sugar().Infof("event buffer found %d events to replay", len(entries))
+	}
+
+	return eb, nil
+}
+
+func (eb *EventBuffer) Add(e *event.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	eb.lock.Lock()
+	defer eb.lock.Unlock()
+
+	if eb.size+int64(len(data)) > eb.maxBytes {
+		stats.Incr("bricksllm.recorder.event_buffer.add.full", nil, 1)
+		return errors.New("event buffer is full")
+	}
+
+	name := fmt.Sprintf("%020d-%s.json", time.Now().UnixNano(), e.Id)
+	tmp := filepath.Join(eb.dir, name+".tmp")
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, filepath.Join(eb.dir, name)); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	eb.size += int64(len(data))
+	stats.Incr("bricksllm.recorder.event_buffer.add.success", nil, 1)
+
+	return nil
+}
+
+func (eb *EventBuffer) entries() ([]os.DirEntry, error) {
+	all, err := os.ReadDir(eb.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []os.DirEntry{}
+	for _, entry := range all {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+// replay inserts buffered events in the order they were buffered and stops at
+// the first event that cannot be inserted because Postgresql is still
+// unavailable.
+func (eb *EventBuffer) replay() error {
+	entries, err := eb.entries()
+	if err != nil {
+		return err
+	}
+
+	replayed := 0
+	for _, entry := range entries {
+		path := filepath.Join(eb.dir, entry.Name())
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		e := &event.Event{}
+		if err := json.Unmarshal(data, e); err != nil {
+			stats.Incr("bricksllm.recorder.event_buffer.replay.unmarshal_error", nil, 1)
+			eb.log.Sugar().Debugf("dropping buffered event %s that cannot be parsed: %v", entry.Name(), err)
+		} else if err := eb.es.InsertEvent(e); err != nil {
+			if _, ok := err.(unavailableError); ok {
+				break
+			}
+
+			// events that were inserted before the connection dropped fail
+			// with a conflict and are not replayed again.
+			stats.Incr("bricksllm.recorder.event_buffer.replay.insert_error", nil, 1)
+			eb.log.Sugar().Debugf("dropping buffered event %s that cannot be inserted: %v", e.Id, err)
+		} else {
+			stats.Incr("bricksllm.recorder.event_buffer.replay.success", nil, 1)
+			replayed++
+		}
+
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+
+		eb.lock.Lock()
+		eb.size -= int64(len(data))
+		eb.lock.Unlock()
+	}
+
+	if replayed != 0 {
+		eb.log.Sugar().Infof("event buffer replayed %d events", replayed)
+	}
+
+	eb.lock.Lock()
+	stats.Gauge("bricksllm.recorder.event_buffer.size_in_bytes", float64(eb.size), nil, 1)
+	eb.lock.Unlock()
+
+	return nil
+}
+
+func (eb *EventBuffer) Listen() {
+	ticker := time.NewTicker(eb.interval)
+	eb.log.Info("event buffer started replaying buffered events")
+
+	go func() {
+		for {
+			select {
+			case <-eb.done:
+				ticker.Stop()
+				eb.log.Info("event buffer stopped")
+				return
+			case <-ticker.C:
+				if err := eb.replay(); err != nil {
+					stats.Incr("bricksllm.recorder.event_buffer.listen.replay_error", nil, 1)
+
+					eb.log.Sugar().Debugf("error when replaying buffered events: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+func (eb *EventBuffer) Stop() {
+	eb.log.Info("shutting down event buffer...")
+
+	eb.done <- true
+}
@@ -0,0 +1,223 @@
+package recorder
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/privacy"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	stats.InitializeClient("", nil)
+
+	os.Exit(m.Run())
+}
+
+type fakeUnavailableError struct{}
+
+func (fakeUnavailableError) Error() string {
+	return "postgresql is unavailable"
+}
+
+func (fakeUnavailableError) Unavailable() {}
+
+// fakeEventInserter inserts events until unavailableAfter events were
+// inserted, and fails the events in failed.
+type fakeEventInserter struct {
+	inserted         []string
+	last             *event.Event
+	unavailableAfter int
+	failed           map[string]bool
+}
+
+func (s *fakeEventInserter) InsertEvent(e *event.Event) error {
+	if s.unavailableAfter >= 0 && len(s.inserted) >= s.unavailableAfter {
+		return fakeUnavailableError{}
+	}
+
+	if s.failed[e.Id] {
+		return errors.New("duplicate key value violates unique constraint")
+	}
+
+	s.inserted = append(s.inserted, e.Id)
+	s.last = e
+	return nil
+}
+
+type fakeEventsStore struct {
+	EventsStore
+	*fakeEventInserter
+}
+
+func (s *fakeEventsStore) InsertEvent(e *event.Event) error {
+	return s.fakeEventInserter.InsertEvent(e)
+}
+
+func bufferedFiles(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	return names
+}
+
+func TestEventBuffer_Replay(t *testing.T) {
+	cases := []struct {
+		name             string
+		ids              []string
+		unavailableAfter int
+		failed           map[string]bool
+		corrupted        bool
+		inserted         []string
+		remaining        int
+	}{
+		{name: "events are replayed in order", ids: []string{"e1", "e2", "e3"}, unavailableAfter: -1, inserted: []string{"e1", "e2", "e3"}, remaining: 0},
+		{name: "replays stop while postgresql is unavailable", ids: []string{"e1", "e2", "e3"}, unavailableAfter: 1, inserted: []string{"e1"}, remaining: 2},
+		{name: "postgresql is unavailable from the start", ids: []string{"e1", "e2"}, unavailableAfter: 0, inserted: []string{}, remaining: 2},
+		{name: "events that cannot be inserted are dropped", ids: []string{"e1", "e2", "e3"}, unavailableAfter: -1, failed: map[string]bool{"e2": true}, inserted: []string{"e1", "e3"}, remaining: 0},
+		{name: "events that cannot be parsed are dropped", ids: []string{"e1"}, unavailableAfter: -1, corrupted: true, inserted: []string{"e1"}, remaining: 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			es := &fakeEventInserter{unavailableAfter: -1, failed: tc.failed}
+
+			eb, err := NewEventBuffer(dir, 1<<20, es, 0, zap.NewNop())
+			require.NoError(t, err)
+
+			for _, id := range tc.ids {
+				require.NoError(t, eb.Add(&event.Event{Id: id}))
+			}
+
+			if tc.corrupted {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "00000000000000000000-corrupted.json"), []byte("{"), 0o600))
+				eb.size++
+			}
+
+			es.inserted = []string{}
+			es.unavailableAfter = tc.unavailableAfter
+			require.NoError(t, eb.replay())
+
+			assert.Equal(t, tc.inserted, es.inserted)
+			assert.Len(t, bufferedFiles(t, dir), tc.remaining)
+
+			if tc.remaining == 0 {
+				assert.Zero(t, eb.size)
+			}
+
+			// events left behind are replayed once postgresql is back.
+			es.unavailableAfter = -1
+			require.NoError(t, eb.replay())
+
+			assert.Empty(t, bufferedFiles(t, dir))
+			assert.Zero(t, eb.size)
+		})
+	}
+}
+
+func TestEventBuffer_Add(t *testing.T) {
+	t.Run("events are rejected once the buffer is full", func(t *testing.T) {
+		data, err := json.Marshal(&event.Event{Id: "e"})
+		require.NoError(t, err)
+
+		dir := t.TempDir()
+		eb, err := NewEventBuffer(dir, int64(len(data))*3+1, &fakeEventInserter{}, 0, zap.NewNop())
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, eb.Add(&event.Event{Id: "e"}))
+		}
+
+		assert.Error(t, eb.Add(&event.Event{Id: "e"}))
+		assert.Equal(t, int64(len(data))*3, eb.size)
+		assert.Len(t, bufferedFiles(t, dir), 3)
+	})
+
+	t.Run("buffers pick up the events of a previous run", func(t *testing.T) {
+		dir := t.TempDir()
+		eb, err := NewEventBuffer(dir, 1<<20, &fakeEventInserter{}, 0, zap.NewNop())
+		require.NoError(t, err)
+
+		require.NoError(t, eb.Add(&event.Event{Id: "e1"}))
+		require.NoError(t, eb.Add(&event.Event{Id: "e2"}))
+
+		es := &fakeEventInserter{unavailableAfter: -1}
+		restarted, err := NewEventBuffer(dir, 1<<20, es, 0, zap.NewNop())
+		require.NoError(t, err)
+		assert.Equal(t, eb.size, restarted.size)
+
+		require.NoError(t, restarted.replay())
+		assert.Equal(t, []string{"e1", "e2"}, es.inserted)
+	})
+
+	t.Run("files being written are not replayed", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "00000000000000000001-e1.json.tmp"), []byte(`{"id":"e1"}`), 0o600))
+
+		es := &fakeEventInserter{unavailableAfter: -1}
+		eb, err := NewEventBuffer(dir, 1<<20, es, 0, zap.NewNop())
+		require.NoError(t, err)
+
+		require.NoError(t, eb.replay())
+		assert.Empty(t, es.inserted)
+	})
+}
+
+func TestRecorder_RecordEvent(t *testing.T) {
+	cases := []struct {
+		name             string
+		unavailableAfter int
+		buffered         bool
+	}{
+		{name: "events are inserted", unavailableAfter: -1, buffered: false},
+		{name: "events are buffered while postgresql is unavailable", unavailableAfter: 0, buffered: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			es := &fakeEventInserter{unavailableAfter: tc.unavailableAfter}
+			eb, err := NewEventBuffer(dir, 1<<20, es, 0, zap.NewNop())
+			require.NoError(t, err)
+
+			r := NewRecorder(nil, nil, nil, &fakeEventsStore{fakeEventInserter: es}, nil, eb, nil, nil)
+			require.NoError(t, r.RecordEvent(&event.Event{Id: "e1"}))
+
+			assert.Equal(t, tc.buffered, len(bufferedFiles(t, dir)) == 1)
+		})
+	}
+
+	t.Run("user ids are pseudonymized", func(t *testing.T) {
+		pz := privacy.NewPseudonymizer("secret", time.Hour)
+		es := &fakeEventInserter{unavailableAfter: -1}
+		r := NewRecorder(nil, nil, nil, &fakeEventsStore{fakeEventInserter: es}, nil, nil, pz, nil)
+
+		e := &event.Event{Id: "e1", UserId: "user-1", CreatedAt: 1700000000}
+		require.NoError(t, r.RecordEvent(e))
+
+		require.NotNil(t, es.last)
+		assert.Equal(t, pz.Pseudonymize("user-1", time.Unix(1700000000, 0)), es.last.UserId)
+		assert.Equal(t, "user-1", e.UserId)
+	})
+
+	t.Run("events are not buffered without a buffer", func(t *testing.T) {
+		es := &fakeEventInserter{unavailableAfter: 0}
+		r := NewRecorder(nil, nil, nil, &fakeEventsStore{fakeEventInserter: es}, nil, nil, nil, nil)
+
+		assert.Error(t, r.RecordEvent(&event.Event{Id: "e1"}))
+	})
+}
@@ -1,19 +1,36 @@
 package recorder
 
 import (
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/encrypter"
 	"github.com/bricks-cloud/bricksllm/internal/event"
 	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/privacy"
+	"github.com/bricks-cloud/bricksllm/internal/sampling"
 )
 
 type Recorder struct {
-	s  Store
-	c  Cache
-	ce CostEstimator
-	es EventsStore
+	s   Store
+	c   Cache
+	ce  CostEstimator
+	es  EventsStore
+	ss  SessionStore
+	eb  *EventBuffer
+	pz  *privacy.Pseudonymizer
+	env map[string]string
 }
 
 type EventsStore interface {
 	InsertEvent(e *event.Event) error
+	InsertRecordedRequest(r *event.RecordedRequest) error
+	InsertSample(s *event.Sample) error
+	GetRecordingKey(tenantId string) ([]byte, error)
+	CreateRecordingKey(tenantId string, createdAt int64, key []byte) ([]byte, error)
+}
+
+type notFoundError interface {
+	NotFound()
 }
 
 type Store interface {
@@ -24,17 +41,25 @@ type Cache interface {
 	IncrementCounter(keyId string, rateLimitUnit key.TimeUnit, incr int64) error
 }
 
+type SessionStore interface {
+	IncrementSessionUsage(keyId, sessionId string, tokens, micros int64) error
+}
+
 type CostEstimator interface {
 	EstimatePromptCost(model string, tks int) (float64, error)
 	EstimateCompletionCost(model string, tks int) (float64, error)
 }
 
-func NewRecorder(s Store, c Cache, ce CostEstimator, es EventsStore) *Recorder {
+func NewRecorder(s Store, c Cache, ce CostEstimator, es EventsStore, ss SessionStore, eb *EventBuffer, pz *privacy.Pseudonymizer, env map[string]string) *Recorder {
 	return &Recorder{
-		s:  s,
-		c:  c,
-		ce: ce,
-		es: es,
+		s:   s,
+		c:   c,
+		ce:  ce,
+		es:  es,
+		ss:  ss,
+		eb:  eb,
+		pz:  pz,
+		env: env,
 	}
 }
 
@@ -54,6 +79,84 @@ func (r *Recorder) RecordKeySpend(keyId string, micros int64, costLimitUnit key.
 	return nil
 }
 
+func (r *Recorder) RecordSessionUsage(keyId, sessionId string, tokens int, micros int64) error {
+	return r.ss.IncrementSessionUsage(keyId, sessionId, int64(tokens), micros)
+}
+
+// RecordEvent buffers events on local disk when Postgresql is unavailable and
+// an event buffer is configured. User ids are pseudonymized before the event
+// is stored or buffered when a pseudonymizer is configured, and events are
+// stamped with the environment labels of the deployment that served them.
 func (r *Recorder) RecordEvent(e *event.Event) error {
-	return r.es.InsertEvent(e)
+	if r.pz != nil && len(e.UserId) != 0 {
+		pseudonymized := *e
+		pseudonymized.UserId = r.pz.Pseudonymize(e.UserId, time.Unix(e.CreatedAt, 0))
+		e = &pseudonymized
+	}
+
+	if len(r.env) != 0 && e.Environment == nil {
+		stamped := *e
+		stamped.Environment = r.env
+		e = &stamped
+	}
+
+	err := r.es.InsertEvent(e)
+	if _, ok := err.(unavailableError); ok && r.eb != nil {
+		return r.eb.Add(e)
+	}
+
+	return err
+}
+
+// RecordRequest encrypts the payloads with the recording key of the tenant,
+// so that deleting the key makes them unreadable. The key is created with the
+// first recording of the tenant.
+func (r *Recorder) RecordRequest(rr *event.RecordedRequest) error {
+	rk, err := r.getRecordingKey(rr.TenantId)
+	if err != nil {
+		return err
+	}
+
+	request, err := encrypter.Seal(rk, rr.Request)
+	if err != nil {
+		return err
+	}
+
+	response, err := encrypter.Seal(rk, rr.Response)
+	if err != nil {
+		return err
+	}
+
+	rr.Request = request
+	rr.Response = response
+	rr.Encrypted = true
+
+	return r.es.InsertRecordedRequest(rr)
+}
+
+// RecordSample redacts a sampled chat completion before storing it.
+func (r *Recorder) RecordSample(s *event.Sample) error {
+	if err := sampling.Redacted(s); err != nil {
+		return err
+	}
+
+	return r.es.InsertSample(s)
+}
+
+func (r *Recorder) getRecordingKey(tenantId string) ([]byte, error) {
+	rk, err := r.es.GetRecordingKey(tenantId)
+	if err == nil {
+		return rk, nil
+	}
+
+	if _, ok := err.(notFoundError); !ok {
+		return nil, err
+	}
+
+	generated, err := encrypter.NewDataKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return r.es.CreateRecordingKey(tenantId, time.Now().Unix(), generated)
 }
@@ -0,0 +1,113 @@
+package replica
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/mock"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+)
+
+// Snapshot is the last known configuration a proxy serves from when it
+// starts in read-only mode. Provider credentials and the secrets of
+// guardrails, egress and retrieval are left out since read-only proxies
+// never call providers.
+type Snapshot struct {
+	CreatedAt        int64               `json:"createdAt"`
+	Keys             []*key.ResponseKey  `json:"keys"`
+	ProviderSettings []*provider.Setting `json:"providerSettings"`
+	Routes           []*route.Route      `json:"routes"`
+	MockResponses    []*mock.Response    `json:"mockResponses"`
+}
+
+// Load reads a snapshot written by a Writer.
+func Load(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Snapshot{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// save replaces the snapshot at path at once, so a proxy starting while it
+// is written never loads a partial snapshot.
+func save(path string, s *Snapshot) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// The methods below let the in memory stores of the proxy load their
+// content from the snapshot. Snapshots never change, so there are no
+// updates.
+
+func (s *Snapshot) GetAllKeys() ([]*key.ResponseKey, error) {
+	return s.Keys, nil
+}
+
+func (s *Snapshot) GetUpdatedKeys(updatedAt int64) ([]*key.ResponseKey, error) {
+	return nil, nil
+}
+
+func (s *Snapshot) GetProviderSettings(withSecret bool, ids []string) ([]*provider.Setting, error) {
+	if len(ids) == 0 {
+		return s.ProviderSettings, nil
+	}
+
+	wanted := map[string]bool{}
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	settings := []*provider.Setting{}
+	for _, setting := range s.ProviderSettings {
+		if wanted[setting.Id] {
+			settings = append(settings, setting)
+		}
+	}
+
+	return settings, nil
+}
+
+func (s *Snapshot) GetUpdatedProviderSettings(updatedAt int64) ([]*provider.Setting, error) {
+	return nil, nil
+}
+
+func (s *Snapshot) GetRoutes() ([]*route.Route, error) {
+	return s.Routes, nil
+}
+
+func (s *Snapshot) GetUpdatedRoutes(updatedAt int64) ([]*route.Route, error) {
+	return nil, nil
+}
+
+func (s *Snapshot) GetMockResponses() ([]*mock.Response, error) {
+	return s.MockResponses, nil
+}
@@ -0,0 +1,129 @@
+package replica
+
+import (
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/mock"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"go.uber.org/zap"
+)
+
+type Storage interface {
+	GetAllKeys() ([]*key.ResponseKey, error)
+	GetProviderSettings(withSecret bool, ids []string) ([]*provider.Setting, error)
+	GetRoutes() ([]*route.Route, error)
+	GetMockResponses() ([]*mock.Response, error)
+}
+
+// Writer keeps a snapshot of the configuration on disk while Postgres is
+// reachable, for proxies that start in read-only mode during an outage.
+type Writer struct {
+	s        Storage
+	path     string
+	interval time.Duration
+	done     chan bool
+	log      *zap.Logger
+}
+
+func NewWriter(s Storage, path string, interval time.Duration, log *zap.Logger) *Writer {
+	return &Writer{
+		s:        s,
+		path:     path,
+		interval: interval,
+		done:     make(chan bool),
+		log:      log,
+	}
+}
+
+func (w *Writer) snapshot() (*Snapshot, error) {
+	keys, err := w.s.GetAllKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	active := []*key.ResponseKey{}
+	for _, k := range keys {
+		if k.Revoked {
+			continue
+		}
+
+		k.StreamGuardrails = k.StreamGuardrails.Redacted()
+		active = append(active, k)
+	}
+
+	settings, err := w.s.GetProviderSettings(false, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	routes, err := w.s.GetRoutes()
+	if err != nil {
+		return nil, err
+	}
+
+	// route auth is kept since requests to routes can be authenticated by it.
+	for _, r := range routes {
+		r.Egress = r.Egress.Redacted()
+		r.Retrieval = r.Retrieval.Redacted()
+		r.Guardrails = r.Guardrails.Redacted()
+	}
+
+	mrs, err := w.s.GetMockResponses()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Snapshot{
+		CreatedAt:        time.Now().Unix(),
+		Keys:             active,
+		ProviderSettings: settings,
+		Routes:           routes,
+		MockResponses:    mrs,
+	}, nil
+}
+
+func (w *Writer) write() {
+	s, err := w.snapshot()
+	if err != nil {
+		stats.Incr("bricksllm.replica.writer.snapshot_error", nil, 1)
+		w.log.Sugar().Debugf("error when taking a replica snapshot: %v", err)
+		return
+	}
+
+	if err := save(w.path, s); err != nil {
+		stats.Incr("bricksllm.replica.writer.save_error", nil, 1)
+		w.log.Sugar().Debugf("error when saving a replica snapshot: %v", err)
+		return
+	}
+
+	stats.Incr("bricksllm.replica.writer.success", nil, 1)
+}
+
+func (w *Writer) Listen() {
+	ticker := time.NewTicker(w.interval)
+	w.log.Sugar().Infof("replica writer started saving snapshots to %s", w.path)
+
+	go func() {
+		w.write()
+
+		for {
+			select {
+			case <-w.done:
+				ticker.Stop()
+				w.log.Info("replica writer stopped")
+				return
+			case <-ticker.C:
+				w.write()
+			}
+		}
+	}()
+}
+
+func (w *Writer) Stop() {
+	w.log.Info("shutting down replica writer...")
+
+	w.done <- true
+}
@@ -0,0 +1,116 @@
+package route
+
+import (
+	"crypto/subtle"
+
+	"github.com/bricks-cloud/bricksllm/internal/encrypter"
+)
+
+const (
+	AuthModeToken  = "token"
+	AuthModePublic = "public"
+
+	minAuthTokenLength       = 32
+	maxAuthRequestsPerMinute = 600
+)
+
+// Auth lets a route be called without a BricksLLM key, either with a token
+// of the route or without any credential. Such requests are made as KeyId,
+// which must be one of the keys of the route, so that they are limited and
+// recorded like requests of the key. RequestsPerMinute limits requests per
+// client ip and is required for public routes. TotalRequestsPerMinute caps
+// the requests of every client together.
+type Auth struct {
+	Mode                   string `json:"mode"`
+	KeyId                  string `json:"keyId"`
+	Token                  string `json:"token,omitempty"`
+	RequestsPerMinute      int    `json:"requestsPerMinute,omitempty"`
+	TotalRequestsPerMinute int    `json:"totalRequestsPerMinute,omitempty"`
+}
+
+func (a *Auth) validate(keyIds []string) []string {
+	invalid := []string{}
+
+	switch a.Mode {
+	case AuthModeToken:
+		if len(a.Token) < minAuthTokenLength {
+			invalid = append(invalid, "auth.token")
+		}
+	case AuthModePublic:
+		if len(a.Token) != 0 {
+			invalid = append(invalid, "auth.token")
+		}
+
+		if a.RequestsPerMinute <= 0 {
+			invalid = append(invalid, "auth.requestsPerMinute")
+		}
+	default:
+		invalid = append(invalid, "auth.mode")
+	}
+
+	if a.RequestsPerMinute < 0 || a.RequestsPerMinute > maxAuthRequestsPerMinute {
+		invalid = append(invalid, "auth.requestsPerMinute")
+	}
+
+	if a.TotalRequestsPerMinute < 0 {
+		invalid = append(invalid, "auth.totalRequestsPerMinute")
+	}
+
+	found := false
+	for _, id := range keyIds {
+		if id == a.KeyId {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		invalid = append(invalid, "auth.keyId")
+	}
+
+	return invalid
+}
+
+// ValidateAuth returns the invalid fields of the auth of a route.
+func ValidateAuth(a *Auth, keyIds []string) []string {
+	if a == nil {
+		return []string{}
+	}
+
+	return a.validate(keyIds)
+}
+
+// HashToken replaces the token with its hash before the route is stored.
+func (a *Auth) HashToken() {
+	if a != nil && len(a.Token) != 0 {
+		a.Token = encrypter.Encrypt(a.Token)
+	}
+}
+
+// Redacted drops the hash of the token.
+func (a *Auth) Redacted() *Auth {
+	if a == nil {
+		return nil
+	}
+
+	return &Auth{
+		Mode:                   a.Mode,
+		KeyId:                  a.KeyId,
+		RequestsPerMinute:      a.RequestsPerMinute,
+		TotalRequestsPerMinute: a.TotalRequestsPerMinute,
+	}
+}
+
+// Allows reports whether a credential sent to the route is accepted. Public
+// routes accept any request.
+func (a *Auth) Allows(credential string) bool {
+	if a.Mode == AuthModePublic {
+		return true
+	}
+
+	if len(credential) == 0 {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(encrypter.Encrypt(credential)), []byte(a.Token)) == 1
+}
@@ -0,0 +1,83 @@
+package route
+
+import (
+	"fmt"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+// Clone copies the configuration of a route into a tenant. KeyIds maps the
+// keys of the route to their counterparts in the tenant. Keys without a
+// mapping are kept and must belong to the tenant. The token of a route with
+// token auth is never read back, so AuthToken sets the token of the clone.
+type Clone struct {
+	TenantId  string            `json:"tenantId"`
+	Id        string            `json:"id,omitempty"`
+	Name      string            `json:"name,omitempty"`
+	Path      string            `json:"path,omitempty"`
+	KeyIds    map[string]string `json:"keyIds,omitempty"`
+	AuthToken string            `json:"authToken,omitempty"`
+}
+
+func (cl *Clone) Validate(r *Route) error {
+	invalid := []string{}
+
+	for from, to := range cl.KeyIds {
+		if len(from) == 0 || len(to) == 0 {
+			invalid = append(invalid, "keyIds")
+			break
+		}
+	}
+
+	if r.Auth != nil && r.Auth.Mode == AuthModeToken && len(cl.AuthToken) == 0 {
+		invalid = append(invalid, "authToken")
+	}
+
+	if (r.Auth == nil || r.Auth.Mode != AuthModeToken) && len(cl.AuthToken) != 0 {
+		invalid = append(invalid, "authToken")
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
+
+func (cl *Clone) keyId(id string) string {
+	if mapped, ok := cl.KeyIds[id]; ok {
+		return mapped
+	}
+
+	return id
+}
+
+// Apply returns the clone of r to be created.
+func (cl *Clone) Apply(r *Route) *Route {
+	copied := *r
+	copied.Id = cl.Id
+	copied.TenantId = cl.TenantId
+
+	if len(cl.Name) != 0 {
+		copied.Name = cl.Name
+	}
+
+	if len(cl.Path) != 0 {
+		copied.Path = cl.Path
+	}
+
+	copied.KeyIds = []string{}
+	for _, id := range r.KeyIds {
+		copied.KeyIds = append(copied.KeyIds, cl.keyId(id))
+	}
+
+	if r.Auth != nil {
+		auth := *r.Auth
+		auth.KeyId = cl.keyId(r.Auth.KeyId)
+		auth.Token = cl.AuthToken
+		copied.Auth = &auth
+	}
+
+	return &copied
+}
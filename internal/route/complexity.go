@@ -0,0 +1,108 @@
+package route
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+const (
+	ComplexitySimple  = "simple"
+	ComplexityComplex = "complex"
+)
+
+// prompts this long are scored as long as they can be. Roughly 2000 tokens.
+const longPromptLength = 8000
+
+var (
+	codePattern = regexp.MustCompile("(?m)```|^\\s*(func|def|class|import|package|public|private|const|let|var|SELECT|#include)\\b|[{};]\\s*$|=>|:=")
+	mathPattern = regexp.MustCompile(`\\(frac|sum|int|sqrt|begin)|\$[^$\n]+\$|\d+\s*[+\-*/^=]\s*\d+|(?i)\b(equation|integral|derivative|theorem|prove|probability|matrix|solve for)\b`)
+
+	reasoningPattern = regexp.MustCompile(`(?i)\b(step by step|explain why|analy[sz]e|compare|trade-?offs?|reason(ing)? about|justify|evaluate|design|architect|plan|debug|optimi[sz]e|pros and cons|what if)\b`)
+)
+
+// ComplexityRouting runs CheapSteps for prompts scored below Threshold and
+// the steps of the route for the others. Scores range from 0 to 1.
+type ComplexityRouting struct {
+	Threshold  float64 `json:"threshold"`
+	CheapSteps []*Step `json:"cheapSteps"`
+}
+
+func (cr *ComplexityRouting) Validate() []string {
+	invalid := []string{}
+
+	if cr.Threshold <= 0 || cr.Threshold > 1 {
+		invalid = append(invalid, "complexity.threshold")
+	}
+
+	if len(cr.CheapSteps) == 0 {
+		invalid = append(invalid, "complexity.cheapSteps")
+	}
+
+	for index, step := range cr.CheapSteps {
+		if step == nil || len(step.Provider) == 0 || len(step.Model) == 0 {
+			invalid = append(invalid, fmt.Sprintf("complexity.cheapSteps.[%d]", index))
+		}
+	}
+
+	return invalid
+}
+
+// ScoreComplexity estimates how demanding a prompt is from its length and
+// whether it contains code, math or asks for reasoning.
+func ScoreComplexity(text string) float64 {
+	if len(strings.TrimSpace(text)) == 0 {
+		return 0
+	}
+
+	score := 0.35 * math.Min(float64(len(text))/longPromptLength, 1)
+
+	if codePattern.MatchString(text) {
+		score += 0.25
+	}
+
+	if mathPattern.MatchString(text) {
+		score += 0.2
+	}
+
+	switch matches := len(reasoningPattern.FindAllStringIndex(text, -1)); {
+	case matches >= 3:
+		score += 0.3
+	case matches > 0:
+		score += 0.2
+	}
+
+	if strings.Count(text, "?") > 2 {
+		score += 0.1
+	}
+
+	return math.Min(score, 1)
+}
+
+// ForComplexity returns the route running the steps for a score along with
+// the decision. An override of simple or complex takes precedence over the
+// score.
+func (r *Route) ForComplexity(score float64, override string) (*Route, string) {
+	if r.Complexity == nil {
+		return r, ""
+	}
+
+	decision := ComplexityComplex
+	if score < r.Complexity.Threshold {
+		decision = ComplexitySimple
+	}
+
+	if override == ComplexitySimple || override == ComplexityComplex {
+		decision = override
+	}
+
+	if decision == ComplexityComplex {
+		return r, decision
+	}
+
+	copied := *r
+	copied.Steps = r.Complexity.CheapSteps
+
+	return &copied, decision
+}
@@ -0,0 +1,176 @@
+package route
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+// minDedupLength keeps short paragraphs such as "Yes." from being treated
+// as repeated context.
+const minDedupLength = 64
+
+const omittedContent = "(repeated content omitted)"
+
+var (
+	htmlCommentRegex = regexp.MustCompile(`(?s)<!--.*?-->`)
+	spacesRegex      = regexp.MustCompile(`[ \t]{2,}`)
+	blankLinesRegex  = regexp.MustCompile(`\n{3,}`)
+)
+
+// Compression shrinks the prompts of chat completion requests before they
+// are forwarded. Prompts estimated below MinTokens are left as they are.
+// Deduplicate drops paragraphs repeated later in the conversation,
+// StripBoilerplate removes comments and redundant whitespace, and Step has a
+// cheap model rewrite long system and user messages to Rate of their length.
+type Compression struct {
+	MinTokens        int     `json:"minTokens"`
+	Deduplicate      bool    `json:"deduplicate"`
+	StripBoilerplate bool    `json:"stripBoilerplate"`
+	Step             *Step   `json:"step,omitempty"`
+	Rate             float64 `json:"rate"`
+}
+
+func (c *Compression) Validate() []string {
+	invalid := []string{}
+
+	if c.MinTokens < 0 {
+		invalid = append(invalid, "compression.minTokens")
+	}
+
+	if !c.Deduplicate && !c.StripBoilerplate && c.Step == nil {
+		invalid = append(invalid, "compression")
+	}
+
+	if c.Step != nil && (len(c.Step.Provider) == 0 || len(c.Step.Model) == 0) {
+		invalid = append(invalid, "compression.step")
+	}
+
+	if c.Rate < 0 || c.Rate >= 1 {
+		invalid = append(invalid, "compression.rate")
+	}
+
+	return invalid
+}
+
+func (c *Compression) GetRate() float64 {
+	if c.Rate == 0 {
+		return 0.5
+	}
+
+	return c.Rate
+}
+
+// Instruction is the system prompt sent to the compression model.
+func (c *Compression) Instruction() string {
+	return fmt.Sprintf("Compress the text given by the user to about %d%% of its length. Keep every fact, number, name, instruction and code snippet needed to act on it, drop filler words and redundant phrasing, and keep the original language. Reply with the compressed text only.", int(c.GetRate()*100))
+}
+
+// Compress applies the deterministic compressions to the messages of r in
+// place. Only messages with string content are changed.
+func (c *Compression) Compress(r *goopenai.ChatCompletionRequest) {
+	if c.StripBoilerplate {
+		for i := range r.Messages {
+			if len(r.Messages[i].MultiContent) == 0 {
+				r.Messages[i].Content = stripBoilerplate(r.Messages[i].Content)
+			}
+		}
+	}
+
+	if c.Deduplicate {
+		deduplicate(r.Messages)
+	}
+}
+
+// stripBoilerplate removes html comments, trailing whitespace and runs of
+// spaces and blank lines. Code blocks are left untouched.
+func stripBoilerplate(content string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	parts := strings.Split(content, "```")
+	for i := range parts {
+		// odd parts are inside code fences.
+		if i%2 == 1 {
+			continue
+		}
+
+		part := htmlCommentRegex.ReplaceAllString(parts[i], "")
+
+		lines := strings.Split(part, "\n")
+		for j, line := range lines {
+			lines[j] = spacesRegex.ReplaceAllString(strings.TrimRight(line, " \t"), " ")
+		}
+
+		parts[i] = blankLinesRegex.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	}
+
+	return strings.TrimSpace(strings.Join(parts, "```"))
+}
+
+// deduplicate drops paragraphs that appear again later in the conversation
+// so that the latest occurrence, which the model is most likely to need,
+// is kept.
+func deduplicate(messages []goopenai.ChatCompletionMessage) {
+	seen := map[string]bool{}
+
+	for i := len(messages) - 1; i >= 0; i-- {
+		if len(messages[i].MultiContent) != 0 || len(messages[i].Content) == 0 {
+			continue
+		}
+
+		paragraphs := strings.Split(messages[i].Content, "\n\n")
+		kept := make([]string, len(paragraphs))
+		removed := false
+
+		for j := len(paragraphs) - 1; j >= 0; j-- {
+			p := strings.TrimSpace(paragraphs[j])
+			if len(p) < minDedupLength {
+				kept[j] = paragraphs[j]
+				continue
+			}
+
+			if seen[p] {
+				removed = true
+				continue
+			}
+
+			seen[p] = true
+			kept[j] = paragraphs[j]
+		}
+
+		if !removed {
+			continue
+		}
+
+		remaining := []string{}
+		for _, p := range kept {
+			if len(p) != 0 {
+				remaining = append(remaining, p)
+			}
+		}
+
+		if len(remaining) == 0 {
+			messages[i].Content = omittedContent
+			continue
+		}
+
+		messages[i].Content = strings.Join(remaining, "\n\n")
+	}
+}
+
+// CompressionRoute returns a route running the compression step of r.
+func (r *Route) CompressionRoute() *Route {
+	if r.Compression == nil || r.Compression.Step == nil {
+		return nil
+	}
+
+	return &Route{
+		Id:     r.Id,
+		Path:   r.Path,
+		KeyIds: r.KeyIds,
+		Steps:  []*Step{r.Compression.Step},
+		Egress: r.Egress,
+	}
+}
@@ -0,0 +1,131 @@
+package route
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	ContextWindowReject   = "reject"
+	ContextWindowTruncate = "truncate"
+	ContextWindowReroute  = "reroute"
+)
+
+// contextWindows are the context windows in tokens of known chat models.
+// Versioned models such as gpt-4-0613 use the window of their longest
+// matching prefix.
+var contextWindows = map[string]int{
+	"gpt-3.5-turbo":          16385,
+	"gpt-3.5-turbo-0301":     4096,
+	"gpt-3.5-turbo-0613":     4096,
+	"gpt-3.5-turbo-instruct": 4096,
+	"gpt-35-turbo":           16385,
+	"gpt-35-turbo-0301":      4096,
+	"gpt-35-turbo-0613":      4096,
+	"gpt-35-turbo-instruct":  4096,
+	"gpt-4":                  8192,
+	"gpt-4-32k":              32768,
+	"gpt-4-1106":             128000,
+	"gpt-4-0125":             128000,
+	"gpt-4-turbo":            128000,
+	"gpt-4-vision":           128000,
+	"gpt-4o":                 128000,
+	"claude-2":               100000,
+	"claude-2.1":             200000,
+	"claude-instant":         100000,
+	"claude-3":               200000,
+}
+
+// ContextWindow returns the context window of a model in tokens.
+func ContextWindow(model string) (int, bool) {
+	if window, ok := contextWindows[model]; ok {
+		return window, true
+	}
+
+	prefixes := []string{}
+	for prefix := range contextWindows {
+		if strings.HasPrefix(model, prefix+"-") {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+
+	if len(prefixes) == 0 {
+		return 0, false
+	}
+
+	sort.Slice(prefixes, func(i, j int) bool {
+		return len(prefixes[i]) > len(prefixes[j])
+	})
+
+	return contextWindows[prefixes[0]], true
+}
+
+// ContextWindowPolicy decides what happens to prompts that do not fit the
+// context window of the steps of a route. Prompts are rejected, truncated
+// by dropping their oldest messages, or sent to LongContextSteps. Tokens
+// reserved for the completion are subtracted from the window.
+type ContextWindowPolicy struct {
+	Action                   string  `json:"action"`
+	LongContextSteps         []*Step `json:"longContextSteps,omitempty"`
+	ReservedCompletionTokens int     `json:"reservedCompletionTokens,omitempty"`
+}
+
+func (p *ContextWindowPolicy) Validate() []string {
+	invalid := []string{}
+
+	if p.Action != ContextWindowReject && p.Action != ContextWindowTruncate && p.Action != ContextWindowReroute {
+		invalid = append(invalid, "contextWindow.action")
+	}
+
+	if p.Action == ContextWindowReroute && len(p.LongContextSteps) == 0 {
+		invalid = append(invalid, "contextWindow.longContextSteps")
+	}
+
+	if p.Action != ContextWindowReroute && len(p.LongContextSteps) != 0 {
+		invalid = append(invalid, "contextWindow.longContextSteps")
+	}
+
+	for index, step := range p.LongContextSteps {
+		if step == nil || len(step.Provider) == 0 || len(step.Model) == 0 {
+			invalid = append(invalid, fmt.Sprintf("contextWindow.longContextSteps.[%d]", index))
+		}
+	}
+
+	if p.ReservedCompletionTokens < 0 {
+		invalid = append(invalid, "contextWindow.reservedCompletionTokens")
+	}
+
+	return invalid
+}
+
+// PromptLimit returns the largest prompt in tokens that fits every step,
+// and the model with the smallest window. Steps of unknown models are
+// ignored.
+func (p *ContextWindowPolicy) PromptLimit(steps []*Step) (int, string) {
+	limit, model := 0, ""
+	for _, step := range steps {
+		window, ok := ContextWindow(step.Model)
+		if !ok {
+			continue
+		}
+
+		if len(model) == 0 || window < limit {
+			limit, model = window, step.Model
+		}
+	}
+
+	if len(model) == 0 {
+		return 0, ""
+	}
+
+	return limit - p.ReservedCompletionTokens, model
+}
+
+// Rerouted returns the route running the long context steps.
+func (r *Route) Rerouted() *Route {
+	copied := *r
+	copied.Steps = r.ContextWindow.LongContextSteps
+
+	return &copied
+}
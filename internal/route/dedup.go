@@ -0,0 +1,76 @@
+package route
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// Dedup coalesces identical requests made with the same key while one of
+// them is in flight. Once the upstream call finishes, its response keeps
+// being handed to identical requests for the duration of Window.
+type Dedup struct {
+	Window string `json:"window,omitempty"`
+}
+
+func (d *Dedup) Validate() []string {
+	if len(d.Window) == 0 {
+		return nil
+	}
+
+	parsed, err := time.ParseDuration(d.Window)
+	if err != nil || parsed < 0 || parsed > time.Minute {
+		return []string{"dedup.window"}
+	}
+
+	return nil
+}
+
+func (d *Dedup) GetWindow() time.Duration {
+	parsed, err := time.ParseDuration(d.Window)
+	if err != nil {
+		return 0
+	}
+
+	return parsed
+}
+
+// Variant identifies the steps a request of the route resolved to, so that
+// requests selecting different steps by language, complexity or budget are
+// not coalesced.
+func (r *Route) Variant() string {
+	data, err := json.Marshal(r.Steps)
+	if err != nil {
+		return ""
+	}
+
+	return string(data)
+}
+
+// ComputeDedupKey normalizes json bodies so that requests only differing in
+// field order or whitespace are considered identical.
+func ComputeDedupKey(keyId, path, variant string, body []byte) string {
+	normalized := body
+
+	var parsed any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&parsed); err == nil {
+		if data, err := json.Marshal(parsed); err == nil {
+			normalized = data
+		}
+	}
+
+	h := sha256.New()
+	h.Write([]byte(keyId))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write([]byte(variant))
+	h.Write([]byte{0})
+	h.Write(normalized)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
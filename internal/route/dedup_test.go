@@ -0,0 +1,61 @@
+package route
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeDedupKey(t *testing.T) {
+	base := ComputeDedupKey("key-1", "route-1", "variant", []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`))
+
+	cases := []struct {
+		name    string
+		keyId   string
+		path    string
+		variant string
+		body    string
+		same    bool
+	}{
+		{name: "field order", keyId: "key-1", path: "route-1", variant: "variant", body: `{"messages":[{"content":"hi","role":"user"}],"model":"gpt-4o"}`, same: true},
+		{name: "whitespace", keyId: "key-1", path: "route-1", variant: "variant", body: "{ \"model\": \"gpt-4o\",\n \"messages\": [{\"role\": \"user\", \"content\": \"hi\"}] }", same: true},
+		{name: "other key", keyId: "key-2", path: "route-1", variant: "variant", body: `{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`, same: false},
+		{name: "other route", keyId: "key-1", path: "route-2", variant: "variant", body: `{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`, same: false},
+		{name: "other variant", keyId: "key-1", path: "route-1", variant: "downgraded", body: `{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`, same: false},
+		{name: "other body", keyId: "key-1", path: "route-1", variant: "variant", body: `{"model":"gpt-4o","messages":[{"role":"user","content":"hello"}]}`, same: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.same, base == ComputeDedupKey(tc.keyId, tc.path, tc.variant, []byte(tc.body)))
+		})
+	}
+
+	t.Run("separators keep fields apart", func(t *testing.T) {
+		assert.NotEqual(t, ComputeDedupKey("a", "bc", "", nil), ComputeDedupKey("ab", "c", "", nil))
+	})
+
+	t.Run("bodies that are not json are compared as they are", func(t *testing.T) {
+		assert.Equal(t, ComputeDedupKey("k", "r", "", []byte("not json")), ComputeDedupKey("k", "r", "", []byte("not json")))
+		assert.NotEqual(t, ComputeDedupKey("k", "r", "", []byte("not json")), ComputeDedupKey("k", "r", "", []byte("not  json")))
+	})
+}
+
+func TestRoute_Variant(t *testing.T) {
+	r := &Route{
+		Steps:    []*Step{{Provider: "openai", Model: "gpt-4o"}},
+		Language: &LanguageRouting{Steps: map[string][]*Step{"de": {{Provider: "openai", Model: "gpt-4o-mini"}}}},
+	}
+
+	german, ok := r.ForLanguage("de")
+	assert.True(t, ok)
+	assert.NotEqual(t, r.Variant(), german.Variant())
+
+	english, _ := r.ForLanguage("en")
+	assert.Equal(t, r.Variant(), english.Variant())
+
+	r.Downgrade = &Downgrade{Models: map[string]string{"gpt-4o": "gpt-4o-mini"}}
+	downgraded, _ := r.Downgraded()
+	assert.NotEqual(t, r.Variant(), downgraded.Variant())
+	assert.Equal(t, german.Variant(), downgraded.Variant())
+}
@@ -0,0 +1,65 @@
+package route
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Downgrade switches the steps of a route to cheaper models once the key
+// making the request has used BudgetPercentage of its cost limits. Models
+// maps the model of a step to the model it is downgraded to.
+type Downgrade struct {
+	BudgetPercentage float64           `json:"budgetPercentage"`
+	Models           map[string]string `json:"models"`
+}
+
+func (d *Downgrade) Validate() []string {
+	invalid := []string{}
+
+	if d.BudgetPercentage <= 0 || d.BudgetPercentage > 100 {
+		invalid = append(invalid, "downgrade.budgetPercentage")
+	}
+
+	if len(d.Models) == 0 {
+		invalid = append(invalid, "downgrade.models")
+	}
+
+	for from, to := range d.Models {
+		if len(from) == 0 || len(to) == 0 || from == to {
+			invalid = append(invalid, fmt.Sprintf("downgrade.models.%s", from))
+		}
+	}
+
+	return invalid
+}
+
+// Downgraded returns a copy of the route with the models of its steps
+// replaced, along with the models that were replaced.
+func (r *Route) Downgraded() (*Route, string) {
+	if r.Downgrade == nil {
+		return r, ""
+	}
+
+	copied := *r
+	copied.Steps = make([]*Step, 0, len(r.Steps))
+
+	replaced := []string{}
+	for _, step := range r.Steps {
+		to, ok := r.Downgrade.Models[step.Model]
+		if !ok {
+			copied.Steps = append(copied.Steps, step)
+			continue
+		}
+
+		s := *step
+		s.Model = to
+		copied.Steps = append(copied.Steps, &s)
+		replaced = append(replaced, step.Model)
+	}
+
+	if len(replaced) == 0 {
+		return r, ""
+	}
+
+	return &copied, strings.Join(replaced, ",")
+}
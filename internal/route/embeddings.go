@@ -0,0 +1,73 @@
+package route
+
+import "fmt"
+
+type embeddingModel struct {
+	dimensions int
+	shortened  bool
+}
+
+// embeddingModels holds the native dimensions of embedding models and
+// whether they can be shortened with the dimensions request field.
+var embeddingModels = map[string]embeddingModel{
+	"ada":                    {dimensions: 1536},
+	"text-embedding-ada-002": {dimensions: 1536},
+	"text-embedding-3-small": {dimensions: 1536, shortened: true},
+	"text-embedding-3-large": {dimensions: 3072, shortened: true},
+}
+
+// EmbeddingsConfig describes the vector index the embeddings of a route are
+// written to. Requests that would produce vectors of other dimensions are
+// rejected instead of being forwarded.
+type EmbeddingsConfig struct {
+	Dimensions int `json:"dimensions"`
+}
+
+func (e *EmbeddingsConfig) Validate() []string {
+	invalid := []string{}
+
+	if e.Dimensions <= 0 {
+		invalid = append(invalid, "embeddings.dimensions")
+	}
+
+	return invalid
+}
+
+// CanProduce reports whether model can produce vectors of the configured
+// dimensions, either natively or by being shortened.
+func (e *EmbeddingsConfig) CanProduce(model string) bool {
+	m, ok := embeddingModels[model]
+	if !ok {
+		return false
+	}
+
+	return m.dimensions == e.Dimensions || (m.shortened && e.Dimensions < m.dimensions)
+}
+
+// CheckDimensions returns an error when a step of the route would produce
+// vectors whose dimensions differ from the configured ones for a request
+// asking for the given dimensions. A request without dimensions gets the
+// native dimensions of the model.
+func (e *EmbeddingsConfig) CheckDimensions(steps []*Step, requested int) error {
+	for _, step := range steps {
+		m, ok := embeddingModels[step.Model]
+		if !ok {
+			return fmt.Errorf("dimensions of model %s are unknown", step.Model)
+		}
+
+		if requested != 0 && !m.shortened {
+			return fmt.Errorf("model %s does not support the dimensions field", step.Model)
+		}
+
+		produced := m.dimensions
+		if requested != 0 {
+			produced = requested
+		}
+
+		if produced != e.Dimensions {
+			return fmt.Errorf("model %s would produce %d dimensions while the route expects %d", step.Model, produced, e.Dimensions)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package route
+
+import (
+	"fmt"
+
+	"github.com/bricks-cloud/bricksllm/internal/guardrail"
+	"github.com/bricks-cloud/bricksllm/internal/prompt"
+	goopenai "github.com/sashabaranov/go-openai"
+	"github.com/tidwall/gjson"
+)
+
+const maxGuardrailsPerStage = 5
+
+// Guardrails check the prompts of a route before its steps are run and the
+// responses of its steps before they are returned.
+type Guardrails struct {
+	Input  []*guardrail.Guardrail `json:"input,omitempty"`
+	Output []*guardrail.Guardrail `json:"output,omitempty"`
+}
+
+func validateGuardrails(prefix string, gs []*guardrail.Guardrail) []string {
+	invalid := []string{}
+
+	if len(gs) > maxGuardrailsPerStage {
+		invalid = append(invalid, prefix)
+	}
+
+	names := map[string]bool{}
+	for index, g := range gs {
+		if g == nil {
+			invalid = append(invalid, fmt.Sprintf("%s.[%d]", prefix, index))
+			continue
+		}
+
+		for _, field := range g.Validate() {
+			invalid = append(invalid, fmt.Sprintf("%s.[%d].%s", prefix, index, field))
+		}
+
+		if names[g.Name] {
+			invalid = append(invalid, fmt.Sprintf("%s.[%d].name", prefix, index))
+		}
+
+		names[g.Name] = true
+	}
+
+	return invalid
+}
+
+func (g *Guardrails) Validate() []string {
+	invalid := []string{}
+
+	if len(g.Input) == 0 && len(g.Output) == 0 {
+		invalid = append(invalid, "guardrails")
+	}
+
+	invalid = append(invalid, validateGuardrails("guardrails.input", g.Input)...)
+	invalid = append(invalid, validateGuardrails("guardrails.output", g.Output)...)
+
+	return invalid
+}
+
+func (g *Guardrails) SetDefaults() {
+	for _, gr := range append(g.Input, g.Output...) {
+		gr.SetDefaults()
+	}
+}
+
+// Redacted hides the api keys of the guardrails.
+func (g *Guardrails) Redacted() *Guardrails {
+	if g == nil {
+		return nil
+	}
+
+	redacted := &Guardrails{}
+	for _, gr := range g.Input {
+		redacted.Input = append(redacted.Input, gr.Redacted())
+	}
+
+	for _, gr := range g.Output {
+		redacted.Output = append(redacted.Output, gr.Redacted())
+	}
+
+	return redacted
+}
+
+// GuardrailInput returns the text messages of a chat completion request.
+func GuardrailInput(r *goopenai.ChatCompletionRequest) []*prompt.Message {
+	messages := []*prompt.Message{}
+	for _, m := range r.Messages {
+		content := m.Content
+		for _, part := range m.MultiContent {
+			if part.Type == goopenai.ChatMessagePartTypeText {
+				content += part.Text
+			}
+		}
+
+		if len(content) != 0 {
+			messages = append(messages, &prompt.Message{Role: m.Role, Content: content})
+		}
+	}
+
+	return messages
+}
+
+// GuardrailOutput returns the message of the first choice of a chat
+// completion response.
+func GuardrailOutput(body []byte) []*prompt.Message {
+	content := gjson.GetBytes(body, "choices.0.message.content").Str
+	if len(content) == 0 {
+		return nil
+	}
+
+	return []*prompt.Message{
+		{Role: goopenai.ChatMessageRoleAssistant, Content: content},
+	}
+}
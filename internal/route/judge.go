@@ -0,0 +1,105 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	minJudgeScore = 1
+	maxJudgeScore = 10
+)
+
+const judgeInstruction = `You are grading the response of an AI assistant. Score the response from %d (unusable) to %d (excellent) following the rubric below.
+
+Rubric:
+%s
+
+Reply with a json object only, for example {"score": 7, "reason": "one sentence explaining the score"}.`
+
+var errJudgeScoreNotFound = errors.New("judge response has no score")
+
+// Judge has a model score a percentage of the responses of a route against a
+// rubric. Responses are scored after the client has been answered, and the
+// scores are stored on the events of the requests.
+type Judge struct {
+	Percentage float64 `json:"percentage"`
+	Step       *Step   `json:"step"`
+	Rubric     string  `json:"rubric"`
+}
+
+func (j *Judge) Validate() []string {
+	invalid := []string{}
+
+	if j.Percentage <= 0 || j.Percentage > 100 {
+		invalid = append(invalid, "judge.percentage")
+	}
+
+	if len(strings.TrimSpace(j.Rubric)) == 0 {
+		invalid = append(invalid, "judge.rubric")
+	}
+
+	if j.Step == nil || len(j.Step.Provider) == 0 || len(j.Step.Model) == 0 {
+		return append(invalid, "judge.step")
+	}
+
+	if len(j.Step.Timeout) != 0 {
+		if _, err := time.ParseDuration(j.Step.Timeout); err != nil {
+			invalid = append(invalid, "judge.step.timeout")
+		}
+	}
+
+	return invalid
+}
+
+func (j *Judge) ShouldJudge() bool {
+	return rand.Float64()*100 < j.Percentage
+}
+
+// Instruction is the system prompt sent to the judge model.
+func (j *Judge) Instruction() string {
+	return fmt.Sprintf(judgeInstruction, minJudgeScore, maxJudgeScore, j.Rubric)
+}
+
+// ParseScore reads the score and the reason from the reply of the judge
+// model. Replies wrapping the json object in text or code fences are
+// accepted.
+func ParseScore(content string) (float64, string, error) {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start == -1 || end < start {
+		return 0, "", errJudgeScoreNotFound
+	}
+
+	parsed := gjson.Parse(content[start : end+1])
+	score := parsed.Get("score")
+	if score.Type != gjson.Number {
+		return 0, "", errJudgeScoreNotFound
+	}
+
+	if score.Float() < minJudgeScore || score.Float() > maxJudgeScore {
+		return 0, "", fmt.Errorf("judge score %v is out of range", score.Float())
+	}
+
+	return score.Float(), parsed.Get("reason").String(), nil
+}
+
+// JudgeRoute returns a route running the judge step of r.
+func (r *Route) JudgeRoute() *Route {
+	if r.Judge == nil || r.Judge.Step == nil {
+		return nil
+	}
+
+	return &Route{
+		Id:     r.Id,
+		Path:   r.Path,
+		KeyIds: r.KeyIds,
+		Steps:  []*Step{r.Judge.Step},
+		Egress: r.Egress,
+	}
+}
@@ -0,0 +1,59 @@
+package route
+
+import (
+	"fmt"
+
+	"github.com/bricks-cloud/bricksllm/internal/language"
+)
+
+// LanguageRouting replaces the steps of a route for requests whose prompt is
+// detected to be in one of the languages of Steps, keyed by ISO 639-1 code.
+// Requests in other languages run the steps of the route.
+type LanguageRouting struct {
+	Steps map[string][]*Step `json:"steps"`
+}
+
+func (l *LanguageRouting) Validate() []string {
+	invalid := []string{}
+
+	if len(l.Steps) == 0 {
+		invalid = append(invalid, "language.steps")
+	}
+
+	for code, steps := range l.Steps {
+		if !language.IsSupported(code) {
+			invalid = append(invalid, fmt.Sprintf("language.steps.%s", code))
+			continue
+		}
+
+		if len(steps) == 0 {
+			invalid = append(invalid, fmt.Sprintf("language.steps.%s", code))
+		}
+
+		for index, step := range steps {
+			if step == nil || len(step.Provider) == 0 || len(step.Model) == 0 {
+				invalid = append(invalid, fmt.Sprintf("language.steps.%s.[%d]", code, index))
+			}
+		}
+	}
+
+	return invalid
+}
+
+// ForLanguage returns a copy of the route running the steps configured for
+// a language. The route itself is returned when the language has none.
+func (r *Route) ForLanguage(code string) (*Route, bool) {
+	if r.Language == nil || len(code) == 0 {
+		return r, false
+	}
+
+	steps, ok := r.Language.Steps[code]
+	if !ok || len(steps) == 0 {
+		return r, false
+	}
+
+	copied := *r
+	copied.Steps = steps
+
+	return &copied, true
+}
@@ -0,0 +1,134 @@
+package route
+
+import (
+	"regexp"
+	"strings"
+)
+
+var pathParameterPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// IsPathPattern reports whether a route path has parameters such as
+// /my-route/:version/chat or ends with a wildcard such as /files/*name.
+func IsPathPattern(path string) bool {
+	return strings.ContainsAny(path, ":*")
+}
+
+// ValidatePathPattern checks that every parameter of a path is named, that
+// names are unique and that a wildcard is only used as the last segment.
+func ValidatePathPattern(path string) bool {
+	if !strings.HasPrefix(path, "/") {
+		return false
+	}
+
+	segments := strings.Split(path[1:], "/")
+	names := map[string]bool{}
+	for index, segment := range segments {
+		if !strings.HasPrefix(segment, ":") && !strings.HasPrefix(segment, "*") {
+			if strings.ContainsAny(segment, ":*") {
+				return false
+			}
+
+			continue
+		}
+
+		if strings.HasPrefix(segment, "*") && index != len(segments)-1 {
+			return false
+		}
+
+		name := segment[1:]
+		if !pathParameterPattern.MatchString(name) || names[name] {
+			return false
+		}
+
+		names[name] = true
+	}
+
+	return true
+}
+
+// NormalizePath drops the names of the parameters of a path so that
+// patterns matching the same requests, like /a/:x and /a/:y, are equal.
+func NormalizePath(path string) string {
+	segments := strings.Split(path, "/")
+	for index, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[index] = ":"
+		} else if strings.HasPrefix(segment, "*") {
+			segments[index] = "*"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// MatchPath matches a request path against a pattern and returns the values
+// of its parameters. A wildcard matches the rest of the path.
+func MatchPath(pattern, path string) (map[string]string, bool) {
+	patternSegments := strings.Split(strings.TrimPrefix(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+
+	params := map[string]string{}
+	for index, segment := range patternSegments {
+		if index >= len(pathSegments) {
+			return nil, false
+		}
+
+		if strings.HasPrefix(segment, "*") {
+			params[segment[1:]] = strings.Join(pathSegments[index:], "/")
+			return params, true
+		}
+
+		if strings.HasPrefix(segment, ":") {
+			if len(pathSegments[index]) == 0 {
+				return nil, false
+			}
+
+			params[segment[1:]] = pathSegments[index]
+			continue
+		}
+
+		if segment != pathSegments[index] {
+			return nil, false
+		}
+	}
+
+	if len(pathSegments) != len(patternSegments) {
+		return nil, false
+	}
+
+	return params, true
+}
+
+// HasPrecedence reports whether pattern a is tried before pattern b. Like
+// the router of the proxy, segments are compared from left to right and a
+// literal segment wins over a parameter, which wins over a wildcard. Longer
+// patterns win when one is a prefix of the other.
+func HasPrecedence(a, b string) bool {
+	as := strings.Split(strings.TrimPrefix(a, "/"), "/")
+	bs := strings.Split(strings.TrimPrefix(b, "/"), "/")
+
+	for index := 0; index < len(as) && index < len(bs); index++ {
+		ra, rb := segmentRank(as[index]), segmentRank(bs[index])
+		if ra != rb {
+			return ra < rb
+		}
+	}
+
+	if len(as) != len(bs) {
+		return len(as) > len(bs)
+	}
+
+	return a < b
+}
+
+func segmentRank(segment string) int {
+	if strings.HasPrefix(segment, "*") {
+		return 2
+	}
+
+	if strings.HasPrefix(segment, ":") {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,124 @@
+package route
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePathPattern(t *testing.T) {
+	cases := []struct {
+		path     string
+		expected bool
+	}{
+		{path: "/a/:version/chat", expected: true},
+		{path: "/files/*name", expected: true},
+		{path: "/a/:x/:y/*rest", expected: true},
+		{path: "/:x", expected: true},
+		{path: "/*all", expected: true},
+		{path: "a/:x", expected: false},
+		{path: "/a/:", expected: false},
+		{path: "/a/*", expected: false},
+		{path: "/a/:1x", expected: false},
+		{path: "/a/:x-y", expected: false},
+		{path: "/a/:x/:x", expected: false},
+		{path: "/a/:x/*x", expected: false},
+		{path: "/a/*rest/b", expected: false},
+		{path: "/a/b:x", expected: false},
+		{path: "/a/b*", expected: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ValidatePathPattern(tc.path))
+		})
+	}
+}
+
+func TestNormalizePath(t *testing.T) {
+	assert.Equal(t, NormalizePath("/a/:x/c"), NormalizePath("/a/:y/c"))
+	assert.Equal(t, NormalizePath("/a/*x"), NormalizePath("/a/*rest"))
+	assert.NotEqual(t, NormalizePath("/a/:x"), NormalizePath("/a/*x"))
+	assert.NotEqual(t, NormalizePath("/a/:x"), NormalizePath("/a/b"))
+	assert.NotEqual(t, NormalizePath("/a/:x"), NormalizePath("/a/:x/"))
+	assert.Equal(t, "/a/b", NormalizePath("/a/b"))
+}
+
+func TestMatchPath(t *testing.T) {
+	cases := []struct {
+		name     string
+		pattern  string
+		path     string
+		params   map[string]string
+		expected bool
+	}{
+		{name: "literal", pattern: "/a/b", path: "/a/b", params: map[string]string{}, expected: true},
+		{name: "different literal", pattern: "/a/b", path: "/a/c", expected: false},
+		{name: "root", pattern: "/", path: "/", params: map[string]string{}, expected: true},
+		{name: "parameter", pattern: "/a/:version/chat", path: "/a/v1/chat", params: map[string]string{"version": "v1"}, expected: true},
+		{name: "parameters", pattern: "/:x/:y", path: "/a/b", params: map[string]string{"x": "a", "y": "b"}, expected: true},
+		{name: "parameter does not span segments", pattern: "/a/:x", path: "/a/b/c", expected: false},
+		{name: "parameter does not match empty segments", pattern: "/a/:x", path: "/a/", expected: false},
+		{name: "missing parameter", pattern: "/a/:x", path: "/a", expected: false},
+		{name: "wildcard", pattern: "/files/*name", path: "/files/a", params: map[string]string{"name": "a"}, expected: true},
+		{name: "wildcard spans segments", pattern: "/files/*name", path: "/files/a/b/c", params: map[string]string{"name": "a/b/c"}, expected: true},
+		{name: "wildcard keeps trailing slashes", pattern: "/files/*name", path: "/files/a/", params: map[string]string{"name": "a/"}, expected: true},
+		{name: "wildcard matches an empty rest", pattern: "/files/*name", path: "/files/", params: map[string]string{"name": ""}, expected: true},
+		{name: "wildcard requires its segment", pattern: "/files/*name", path: "/files", expected: false},
+		{name: "wildcard after parameter", pattern: "/:x/*rest", path: "/a/b/c", params: map[string]string{"x": "a", "rest": "b/c"}, expected: true},
+
+		// trailing slashes are not redirected like by the router of the
+		// proxy, so they have to match exactly.
+		{name: "trailing slash of the path", pattern: "/a/b", path: "/a/b/", expected: false},
+		{name: "trailing slash of the pattern", pattern: "/a/b/", path: "/a/b", expected: false},
+		{name: "trailing slashes of both", pattern: "/a/b/", path: "/a/b/", params: map[string]string{}, expected: true},
+		{name: "trailing slash after parameter", pattern: "/a/:x", path: "/a/b/", expected: false},
+		{name: "double slashes", pattern: "/a/:x/c", path: "/a//c", expected: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			params, ok := MatchPath(tc.pattern, tc.path)
+			assert.Equal(t, tc.expected, ok)
+			assert.Equal(t, tc.params, params)
+		})
+	}
+}
+
+func TestHasPrecedence(t *testing.T) {
+	cases := []struct {
+		name   string
+		first  string
+		second string
+	}{
+		{name: "literal over parameter", first: "/a/b", second: "/a/:x"},
+		{name: "parameter over wildcard", first: "/a/:x", second: "/a/*rest"},
+		{name: "literal over wildcard", first: "/a/b", second: "/a/*rest"},
+		{name: "earlier segments decide", first: "/a/b/*rest", second: "/a/:x/c"},
+		{name: "literal prefix over parameter", first: "/a/:x", second: "/:x/b"},
+		{name: "longer over prefix", first: "/a/:x/b", second: "/a/:x"},
+		{name: "longer parameter over wildcard", first: "/a/:x/:y", second: "/a/*rest"},
+		{name: "conflicting parameters are ordered by name", first: "/a/:x", second: "/a/:y"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.True(t, HasPrecedence(tc.first, tc.second))
+			assert.False(t, HasPrecedence(tc.second, tc.first))
+		})
+	}
+
+	t.Run("a pattern does not take precedence over itself", func(t *testing.T) {
+		assert.False(t, HasPrecedence("/a/:x", "/a/:x"))
+	})
+
+	t.Run("sorting orders every kind of segment", func(t *testing.T) {
+		paths := []string{"/*all", "/a/*rest", "/:x/b", "/a/:x", "/a/b", "/a/b/c", "/:x"}
+		sort.SliceStable(paths, func(i, j int) bool {
+			return HasPrecedence(paths[i], paths[j])
+		})
+
+		assert.Equal(t, []string{"/a/b/c", "/a/b", "/a/:x", "/a/*rest", "/:x/b", "/:x", "/*all"}, paths)
+	})
+}
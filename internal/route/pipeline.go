@@ -0,0 +1,203 @@
+package route
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/prompt"
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+// pipelineInputVariable holds the content of the last user message of the
+// request.
+const pipelineInputVariable = "input"
+
+var (
+	variableNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	messageRoles        = map[string]bool{
+		"system":    true,
+		"user":      true,
+		"assistant": true,
+	}
+)
+
+// PipelineStage sends a chat completion rendered from its messages to its
+// step. Messages can reference {{input}} and the outputs of earlier stages by
+// their names. When the output of a stage matches StopPattern, the route
+// responds with StopMessage, or the output itself, without running later
+// stages or the steps of the route.
+type PipelineStage struct {
+	Name        string            `json:"name"`
+	Step        *Step             `json:"step"`
+	Messages    []*prompt.Message `json:"messages"`
+	StopPattern string            `json:"stopPattern,omitempty"`
+	StopMessage string            `json:"stopMessage,omitempty"`
+}
+
+// Pipeline chains stages before the steps of a route, e.g. to summarize
+// then answer or to moderate then complete. Messages replace the messages of
+// the request sent to the steps and can reference the outputs of every stage.
+// Without messages the request is forwarded as it is. The cost of every stage
+// is charged to the key of the request.
+type Pipeline struct {
+	Stages   []*PipelineStage  `json:"stages"`
+	Messages []*prompt.Message `json:"messages,omitempty"`
+}
+
+func validatePipelineMessages(prefix string, messages []*prompt.Message, known map[string]bool) []string {
+	invalid := []string{}
+
+	for index, m := range messages {
+		if m == nil || !messageRoles[m.Role] {
+			invalid = append(invalid, fmt.Sprintf("%s.[%d].role", prefix, index))
+			continue
+		}
+
+		if len(m.Content) == 0 {
+			invalid = append(invalid, fmt.Sprintf("%s.[%d].content", prefix, index))
+			continue
+		}
+
+		t := &prompt.Template{Messages: []*prompt.Message{m}}
+		for _, name := range t.Variables() {
+			if !known[name] {
+				invalid = append(invalid, fmt.Sprintf("%s.[%d].content", prefix, index))
+				break
+			}
+		}
+	}
+
+	return invalid
+}
+
+func (p *Pipeline) Validate() []string {
+	invalid := []string{}
+
+	if len(p.Stages) == 0 {
+		invalid = append(invalid, "pipeline.stages")
+	}
+
+	known := map[string]bool{
+		pipelineInputVariable: true,
+	}
+
+	for index, stage := range p.Stages {
+		prefix := fmt.Sprintf("pipeline.stages.[%d]", index)
+		if stage == nil {
+			invalid = append(invalid, prefix)
+			continue
+		}
+
+		if !variableNamePattern.MatchString(stage.Name) || known[stage.Name] {
+			invalid = append(invalid, prefix+".name")
+		}
+
+		if stage.Step == nil || len(stage.Step.Provider) == 0 || len(stage.Step.Model) == 0 {
+			invalid = append(invalid, prefix+".step")
+		} else if len(stage.Step.Timeout) != 0 {
+			if _, err := time.ParseDuration(stage.Step.Timeout); err != nil {
+				invalid = append(invalid, prefix+".step.timeout")
+			}
+		}
+
+		if len(stage.Messages) == 0 {
+			invalid = append(invalid, prefix+".messages")
+		}
+
+		invalid = append(invalid, validatePipelineMessages(prefix+".messages", stage.Messages, known)...)
+
+		if len(stage.StopPattern) != 0 {
+			if _, err := regexp.Compile(stage.StopPattern); err != nil {
+				invalid = append(invalid, prefix+".stopPattern")
+			}
+		}
+
+		if len(stage.StopPattern) == 0 && len(stage.StopMessage) != 0 {
+			invalid = append(invalid, prefix+".stopMessage")
+		}
+
+		known[stage.Name] = true
+	}
+
+	invalid = append(invalid, validatePipelineMessages("pipeline.messages", p.Messages, known)...)
+
+	return invalid
+}
+
+// LastUserContent returns the content of the last user message with text
+// content.
+func LastUserContent(r *goopenai.ChatCompletionRequest) string {
+	for i := len(r.Messages) - 1; i >= 0; i-- {
+		m := r.Messages[i]
+		if m.Role != goopenai.ChatMessageRoleUser {
+			continue
+		}
+
+		if len(m.MultiContent) == 0 {
+			return m.Content
+		}
+
+		for _, part := range m.MultiContent {
+			if part.Type == goopenai.ChatMessagePartTypeText {
+				return part.Text
+			}
+		}
+	}
+
+	return ""
+}
+
+// NewPipelineVariables returns the variables available to the first stage.
+func NewPipelineVariables(input string) map[string]string {
+	return map[string]string{
+		pipelineInputVariable: input,
+	}
+}
+
+// RenderMessages renders messages with the variables of a pipeline.
+func RenderMessages(messages []*prompt.Message, variables map[string]string) ([]goopenai.ChatCompletionMessage, error) {
+	rendered, err := (&prompt.Template{Messages: messages}).Render(variables)
+	if err != nil {
+		return nil, err
+	}
+
+	converted := []goopenai.ChatCompletionMessage{}
+	for _, m := range rendered {
+		converted = append(converted, goopenai.ChatCompletionMessage{
+			Role:    m.Role,
+			Content: m.Content,
+		})
+	}
+
+	return converted, nil
+}
+
+// Stops returns the response of the route when output stops the pipeline.
+func (s *PipelineStage) Stops(output string) (string, bool) {
+	if len(s.StopPattern) == 0 {
+		return "", false
+	}
+
+	matched, err := regexp.MatchString(s.StopPattern, output)
+	if err != nil || !matched {
+		return "", false
+	}
+
+	if len(s.StopMessage) != 0 {
+		return s.StopMessage, true
+	}
+
+	return output, true
+}
+
+// PipelineStageRoute returns a route running the step of a stage of r.
+func (r *Route) PipelineStageRoute(s *PipelineStage) *Route {
+	return &Route{
+		Id:     r.Id,
+		Path:   r.Path,
+		KeyIds: r.KeyIds,
+		Steps:  []*Step{s.Step},
+		Egress: r.Egress,
+	}
+}
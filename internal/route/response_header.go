@@ -0,0 +1,95 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+const maxResponseHeaders = 20
+
+var (
+	headerNamePattern     = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+\\-.^_`|~]+$")
+	headerVariablePattern = regexp.MustCompile(`{{\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*}}`)
+)
+
+// responseHeaderVariables are the values of a request that can be rendered
+// into response headers.
+var responseHeaderVariables = map[string]bool{
+	"model":       true,
+	"provider":    true,
+	"cache":       true,
+	"costInUsd":   true,
+	"latencyInMs": true,
+	"route":       true,
+}
+
+// reservedResponseHeaders are set by the proxy and cannot be overridden.
+var reservedResponseHeaders = map[string]bool{
+	"Content-Type":           true,
+	"Content-Length":         true,
+	"Content-Encoding":       true,
+	"Transfer-Encoding":      true,
+	"Connection":             true,
+	"Set-Cookie":             true,
+	"X-Bricksllm-Request-Id": true,
+}
+
+// ResponseHeader is added to the responses of a route. Value can reference
+// {{model}}, {{provider}}, {{cache}}, {{costInUsd}}, {{latencyInMs}} and
+// {{route}}, which are rendered for every response.
+type ResponseHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func ValidateResponseHeaders(headers []*ResponseHeader) []string {
+	invalid := []string{}
+
+	if len(headers) > maxResponseHeaders {
+		invalid = append(invalid, "responseHeaders")
+	}
+
+	seen := map[string]bool{}
+	for index, h := range headers {
+		prefix := fmt.Sprintf("responseHeaders.[%d]", index)
+		if h == nil {
+			invalid = append(invalid, prefix)
+			continue
+		}
+
+		name := http.CanonicalHeaderKey(h.Name)
+		if !headerNamePattern.MatchString(h.Name) || reservedResponseHeaders[name] || seen[name] {
+			invalid = append(invalid, prefix+".name")
+		}
+
+		seen[name] = true
+
+		if len(h.Value) == 0 || strings.ContainsAny(h.Value, "\r\n") {
+			invalid = append(invalid, prefix+".value")
+			continue
+		}
+
+		for _, match := range headerVariablePattern.FindAllStringSubmatch(h.Value, -1) {
+			if !responseHeaderVariables[match[1]] {
+				invalid = append(invalid, prefix+".value")
+				break
+			}
+		}
+	}
+
+	return invalid
+}
+
+// RenderResponseHeaders substitutes the variables referenced by the headers.
+func RenderResponseHeaders(headers []*ResponseHeader, variables map[string]string) map[string]string {
+	rendered := map[string]string{}
+	for _, h := range headers {
+		rendered[h.Name] = headerVariablePattern.ReplaceAllStringFunc(h.Value, func(placeholder string) string {
+			return variables[headerVariablePattern.FindStringSubmatch(placeholder)[1]]
+		})
+	}
+
+	return rendered
+}
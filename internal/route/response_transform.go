@@ -0,0 +1,198 @@
+package route
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+type ResponseTransformAction string
+
+const (
+	RemoveFieldsResponseTransformAction      ResponseTransformAction = "removeFields"
+	StripReasoningResponseTransformAction    ResponseTransformAction = "stripReasoning"
+	TruncateSentencesResponseTransformAction ResponseTransformAction = "truncateSentences"
+	MarkdownToTextResponseTransformAction    ResponseTransformAction = "markdownToText"
+)
+
+// reasoningFields are message fields some providers use to return the chain
+// of thought next to the answer.
+var reasoningFields = []string{"reasoning", "reasoning_content", "thinking"}
+
+var (
+	thinkTagPattern       = regexp.MustCompile(`(?s)<think(?:ing)?>.*?</think(?:ing)?>\s*`)
+	codeFencePattern      = regexp.MustCompile("(?m)^```[^\n]*\n?")
+	imagePattern          = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	linkPattern           = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	headingPattern        = regexp.MustCompile(`(?m)^\s{0,3}#{1,6}\s+`)
+	blockquotePattern     = regexp.MustCompile(`(?m)^\s{0,3}>\s?`)
+	listBulletPattern     = regexp.MustCompile(`(?m)^(\s*)[-*+]\s+`)
+	horizontalRulePattern = regexp.MustCompile(`(?m)^\s{0,3}([-*_]\s*){3,}$\n?`)
+	emphasisPattern       = regexp.MustCompile(`(\*\*|__|\*|~~)([^\s*_~](?:.*?[^\s*_~])?)(\*\*|__|\*|~~)`)
+	inlineCodePattern     = regexp.MustCompile("`([^`]*)`")
+)
+
+// ResponseTransform post processes successful chat completion responses of a
+// route before they are cached and returned.
+type ResponseTransform struct {
+	Action    ResponseTransformAction `json:"action"`
+	Fields    []string                `json:"fields,omitempty"`
+	Sentences int                     `json:"sentences,omitempty"`
+}
+
+func (rt *ResponseTransform) Validate(index int) []string {
+	invalid := []string{}
+	prefix := fmt.Sprintf("responseTransforms.[%d]", index)
+
+	switch rt.Action {
+	case RemoveFieldsResponseTransformAction:
+		if len(rt.Fields) == 0 {
+			invalid = append(invalid, prefix+".fields")
+		}
+
+		for _, field := range rt.Fields {
+			if len(field) == 0 {
+				invalid = append(invalid, prefix+".fields")
+				break
+			}
+		}
+	case TruncateSentencesResponseTransformAction:
+		if rt.Sentences <= 0 {
+			invalid = append(invalid, prefix+".sentences")
+		}
+	case StripReasoningResponseTransformAction, MarkdownToTextResponseTransformAction:
+	default:
+		invalid = append(invalid, prefix+".action")
+	}
+
+	return invalid
+}
+
+// ApplyResponseTransforms evaluates the transforms in order against a chat
+// completion response body.
+func ApplyResponseTransforms(transforms []*ResponseTransform, body []byte) ([]byte, error) {
+	parsed := map[string]any{}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, err
+	}
+
+	for _, rt := range transforms {
+		switch rt.Action {
+		case RemoveFieldsResponseTransformAction:
+			for _, field := range rt.Fields {
+				removeField(parsed, strings.Split(field, "."))
+			}
+		case StripReasoningResponseTransformAction:
+			forEachMessage(parsed, func(message map[string]any) {
+				for _, field := range reasoningFields {
+					delete(message, field)
+				}
+
+				if content, ok := message["content"].(string); ok {
+					message["content"] = strings.TrimSpace(thinkTagPattern.ReplaceAllString(content, ""))
+				}
+			})
+		case TruncateSentencesResponseTransformAction:
+			forEachMessage(parsed, func(message map[string]any) {
+				if content, ok := message["content"].(string); ok {
+					message["content"] = truncateSentences(content, rt.Sentences)
+				}
+			})
+		case MarkdownToTextResponseTransformAction:
+			forEachMessage(parsed, func(message map[string]any) {
+				if content, ok := message["content"].(string); ok {
+					message["content"] = markdownToText(content)
+				}
+			})
+		}
+	}
+
+	return json.Marshal(parsed)
+}
+
+// removeField deletes a dot separated path. Arrays along the path are
+// traversed element by element, e.g. choices.logprobs.
+func removeField(value any, path []string) {
+	switch typed := value.(type) {
+	case []any:
+		for _, elem := range typed {
+			removeField(elem, path)
+		}
+	case map[string]any:
+		if len(path) == 1 {
+			delete(typed, path[0])
+			return
+		}
+
+		if next, ok := typed[path[0]]; ok {
+			removeField(next, path[1:])
+		}
+	}
+}
+
+func forEachMessage(parsed map[string]any, f func(message map[string]any)) {
+	choices, ok := parsed["choices"].([]any)
+	if !ok {
+		return
+	}
+
+	for _, choice := range choices {
+		c, ok := choice.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		if message, ok := c["message"].(map[string]any); ok {
+			f(message)
+		}
+	}
+}
+
+func truncateSentences(content string, sentences int) string {
+	runes := []rune(content)
+	count := 0
+
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '.' && runes[i] != '!' && runes[i] != '?' {
+			continue
+		}
+
+		for i+1 < len(runes) && (runes[i+1] == '.' || runes[i+1] == '!' || runes[i+1] == '?') {
+			i++
+		}
+
+		if i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+			continue
+		}
+
+		count++
+		if count == sentences {
+			return string(runes[:i+1])
+		}
+	}
+
+	return content
+}
+
+func markdownToText(content string) string {
+	text := codeFencePattern.ReplaceAllString(content, "")
+	text = horizontalRulePattern.ReplaceAllString(text, "")
+	text = imagePattern.ReplaceAllString(text, "$1")
+	text = linkPattern.ReplaceAllString(text, "$1")
+	text = headingPattern.ReplaceAllString(text, "")
+	text = blockquotePattern.ReplaceAllString(text, "")
+	text = listBulletPattern.ReplaceAllString(text, "$1")
+	text = inlineCodePattern.ReplaceAllString(text, "$1")
+	text = emphasisPattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := emphasisPattern.FindStringSubmatch(match)
+		if parts[1] != parts[3] {
+			return match
+		}
+
+		return parts[2]
+	})
+
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,146 @@
+package route
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/vector"
+)
+
+const (
+	defaultRetrievalTopK     = 4
+	maxRetrievalTopK         = 50
+	defaultRetrievalVariable = "context"
+	defaultRetrievalTimeout  = "10s"
+)
+
+// Retrieval embeds the query of a chat completion request with its embedding
+// step and fetches the closest chunks from a vector store before the steps
+// of the route are run. The chunks are passed to the prompt template of the
+// route as Variable or, without a template, prepended as a system message.
+type Retrieval struct {
+	Store         *vector.Config `json:"store"`
+	EmbeddingStep *Step          `json:"embeddingStep"`
+	TopK          int            `json:"topK,omitempty"`
+	MinScore      float64        `json:"minScore,omitempty"`
+	Variable      string         `json:"variable,omitempty"`
+	QueryVariable string         `json:"queryVariable,omitempty"`
+	Timeout       string         `json:"timeout,omitempty"`
+}
+
+func (r *Retrieval) Validate() []string {
+	invalid := []string{}
+
+	if r.Store == nil {
+		invalid = append(invalid, "retrieval.store")
+	} else {
+		for _, field := range r.Store.Validate() {
+			invalid = append(invalid, "retrieval."+field)
+		}
+	}
+
+	if r.EmbeddingStep == nil || len(r.EmbeddingStep.Provider) == 0 || len(r.EmbeddingStep.Model) == 0 {
+		invalid = append(invalid, "retrieval.embeddingStep")
+	}
+
+	if r.TopK < 0 || r.TopK > maxRetrievalTopK {
+		invalid = append(invalid, "retrieval.topK")
+	}
+
+	if len(r.Variable) != 0 && !variableNamePattern.MatchString(r.Variable) {
+		invalid = append(invalid, "retrieval.variable")
+	}
+
+	if len(r.Timeout) != 0 {
+		if _, err := time.ParseDuration(r.Timeout); err != nil {
+			invalid = append(invalid, "retrieval.timeout")
+		}
+	}
+
+	return invalid
+}
+
+func (r *Retrieval) SetDefaults() {
+	if r.TopK == 0 {
+		r.TopK = defaultRetrievalTopK
+	}
+
+	if len(r.Variable) == 0 {
+		r.Variable = defaultRetrievalVariable
+	}
+
+	if len(r.Timeout) == 0 {
+		r.Timeout = defaultRetrievalTimeout
+	}
+
+	if r.EmbeddingStep != nil && len(r.EmbeddingStep.Timeout) == 0 {
+		r.EmbeddingStep.Timeout = "5m"
+	}
+}
+
+// Redacted hides the credentials of the vector store.
+func (r *Retrieval) Redacted() *Retrieval {
+	if r == nil {
+		return nil
+	}
+
+	copied := *r
+	copied.Store = r.Store.Redacted()
+
+	return &copied
+}
+
+func (r *Retrieval) GetVariable() string {
+	if len(r.Variable) == 0 {
+		return defaultRetrievalVariable
+	}
+
+	return r.Variable
+}
+
+func (r *Retrieval) GetTimeout() time.Duration {
+	parsed, err := time.ParseDuration(r.Timeout)
+	if err != nil {
+		parsed, _ = time.ParseDuration(defaultRetrievalTimeout)
+	}
+
+	return parsed
+}
+
+// Relevant drops chunks scored below the minimum score.
+func (r *Retrieval) Relevant(chunks []*vector.Chunk) []*vector.Chunk {
+	relevant := []*vector.Chunk{}
+	for _, c := range chunks {
+		if c.Score >= r.MinScore && len(c.Content) != 0 {
+			relevant = append(relevant, c)
+		}
+	}
+
+	return relevant
+}
+
+// FormatChunks joins chunks in the order they were ranked.
+func FormatChunks(chunks []*vector.Chunk) string {
+	parts := []string{}
+	for index, c := range chunks {
+		parts = append(parts, fmt.Sprintf("[%d] %s", index+1, c.Content))
+	}
+
+	return strings.Join(parts, "\n\n")
+}
+
+// RetrievalRoute returns a route running the embedding step of r.
+func (r *Route) RetrievalRoute() *Route {
+	if r.Retrieval == nil || r.Retrieval.EmbeddingStep == nil {
+		return nil
+	}
+
+	return &Route{
+		Id:     r.Id,
+		Path:   r.Path,
+		KeyIds: r.KeyIds,
+		Steps:  []*Step{r.Retrieval.EmbeddingStep},
+		Egress: r.Egress,
+	}
+}
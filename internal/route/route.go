@@ -13,8 +13,11 @@ import (
 
 	goopenai "github.com/sashabaranov/go-openai"
 
+	"github.com/bricks-cloud/bricksllm/internal/cors"
 	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/prompt"
 	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/provider/azure"
 )
 
 type CacheConfig struct {
@@ -30,15 +33,44 @@ type Step struct {
 	Timeout  string            `json:"timeout"`
 }
 
+// PromptTemplateReference points a route at a prompt template. A version of 0
+// always resolves to the latest version of the template.
+type PromptTemplateReference struct {
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+}
+
 type Route struct {
-	Id          string       `json:"id"`
-	CreatedAt   int64        `json:"createdAt"`
-	UpdatedAt   int64        `json:"updatedAt"`
-	Name        string       `json:"name"`
-	Path        string       `json:"path"`
-	KeyIds      []string     `json:"keyIds"`
-	Steps       []*Step      `json:"steps"`
-	CacheConfig *CacheConfig `json:"cacheConfig"`
+	Id                 string                   `json:"id"`
+	CreatedAt          int64                    `json:"createdAt"`
+	UpdatedAt          int64                    `json:"updatedAt"`
+	Name               string                   `json:"name"`
+	Path               string                   `json:"path"`
+	KeyIds             []string                 `json:"keyIds"`
+	Steps              []*Step                  `json:"steps"`
+	CacheConfig        *CacheConfig             `json:"cacheConfig"`
+	Slo                *Slo                     `json:"slo,omitempty"`
+	PromptTemplate     *PromptTemplateReference `json:"promptTemplate,omitempty"`
+	SystemPrompt       *prompt.SystemPrompt     `json:"systemPrompt,omitempty"`
+	RequestRules       []*RequestRule           `json:"requestRules,omitempty"`
+	ResponseTransforms []*ResponseTransform     `json:"responseTransforms,omitempty"`
+	Shadow             *Shadow                  `json:"shadow,omitempty"`
+	TenantId           string                   `json:"tenantId"`
+	Egress             *provider.Egress         `json:"egress,omitempty"`
+	Dedup              *Dedup                   `json:"dedup,omitempty"`
+	Downgrade          *Downgrade               `json:"downgrade,omitempty"`
+	Language           *LanguageRouting         `json:"language,omitempty"`
+	Embeddings         *EmbeddingsConfig        `json:"embeddings,omitempty"`
+	Compression        *Compression             `json:"compression,omitempty"`
+	Judge              *Judge                   `json:"judge,omitempty"`
+	ResponseHeaders    []*ResponseHeader        `json:"responseHeaders,omitempty"`
+	Auth               *Auth                    `json:"auth,omitempty"`
+	Complexity         *ComplexityRouting       `json:"complexity,omitempty"`
+	ContextWindow      *ContextWindowPolicy     `json:"contextWindow,omitempty"`
+	Pipeline           *Pipeline                `json:"pipeline,omitempty"`
+	Retrieval          *Retrieval               `json:"retrieval,omitempty"`
+	Guardrails         *Guardrails              `json:"guardrails,omitempty"`
+	Cors               *cors.Policy             `json:"cors,omitempty"`
 }
 
 func (r *Route) ValidateSettings(settings []*provider.Setting) bool {
@@ -47,6 +79,50 @@ func (r *Route) ValidateSettings(settings []*provider.Setting) bool {
 		target[s.Provider] = true
 	}
 
+	if r.Shadow != nil && r.Shadow.Step != nil {
+		target[r.Shadow.Step.Provider] = true
+	}
+
+	if r.Compression != nil && r.Compression.Step != nil {
+		target[r.Compression.Step.Provider] = true
+	}
+
+	if r.Judge != nil && r.Judge.Step != nil {
+		target[r.Judge.Step.Provider] = true
+	}
+
+	if r.Language != nil {
+		for _, steps := range r.Language.Steps {
+			for _, s := range steps {
+				target[s.Provider] = true
+			}
+		}
+	}
+
+	if r.Complexity != nil {
+		for _, s := range r.Complexity.CheapSteps {
+			target[s.Provider] = true
+		}
+	}
+
+	if r.ContextWindow != nil {
+		for _, s := range r.ContextWindow.LongContextSteps {
+			target[s.Provider] = true
+		}
+	}
+
+	if r.Pipeline != nil {
+		for _, s := range r.Pipeline.Stages {
+			if s != nil && s.Step != nil {
+				target[s.Step.Provider] = true
+			}
+		}
+	}
+
+	if r.Retrieval != nil && r.Retrieval.EmbeddingStep != nil {
+		target[r.Retrieval.EmbeddingStep.Provider] = true
+	}
+
 	source := map[string]bool{}
 	for _, s := range settings {
 		source[s.Provider] = true
@@ -70,7 +146,7 @@ func (r *Route) ShouldRunEmbeddings() bool {
 		return false
 	}
 
-	if strings.Contains(r.Steps[0].Model, "ada") {
+	if strings.Contains(r.Steps[0].Model, "ada") || strings.HasPrefix(r.Steps[0].Model, "text-embedding-") {
 		return true
 	}
 
@@ -122,7 +198,12 @@ func (r *Route) RunSteps(req *Request) (*Response, error) {
 			resourceName = val
 		}
 
-		key, err := req.GetSettingValue(step.Provider, "apikey")
+		setting := req.getSetting(step.Provider)
+		if setting == nil {
+			return nil, errors.New(fmt.Sprintf("%s setting is not found", step.Provider))
+		}
+
+		client, err := req.getClient(r.Egress, step.Provider)
 		if err != nil {
 			return nil, err
 		}
@@ -158,7 +239,7 @@ func (r *Route) RunSteps(req *Request) (*Response, error) {
 						continue
 					}
 
-					embeddingsReq.Model = goopenai.AdaEmbeddingV2
+					embeddingsReq.Model = goopenai.EmbeddingModel(step.Model)
 
 					selected, err = json.Marshal(embeddingsReq)
 					if err != nil {
@@ -192,8 +273,6 @@ func (r *Route) RunSteps(req *Request) (*Response, error) {
 				continue
 			}
 
-			setHttpRequestAuthHeader(step.Provider, hreq, key)
-
 			for k := range req.Forwarded.Header {
 				if strings.HasPrefix(strings.ToLower(k), "authorization") {
 					continue
@@ -203,18 +282,53 @@ func (r *Route) RunSteps(req *Request) (*Response, error) {
 					continue
 				}
 
+				// the http client negotiates compression itself so that
+				// responses are decompressed before they are read.
+				if strings.ToLower(k) == "accept-encoding" {
+					continue
+				}
+
 				hreq.Header.Set(k, req.Forwarded.Header.Get(k))
 			}
 
-			res, err := req.Client.Do(hreq)
+			key := ""
+			if step.Provider == "azure" {
+				err = azure.SetAuthHeader(hreq.Header, setting)
+				key = hreq.Header.Get("api-key")
+			} else {
+				key = setting.NextApiKey()
+				if len(key) == 0 {
+					err = errors.New(fmt.Sprintf("%s setting param: apikey not found", step.Provider))
+				}
+
+				setHttpRequestAuthHeader(step.Provider, hreq, key)
+			}
+
+			if err != nil {
+				lastErr = err
+				retries -= 1
+				continue
+			}
+
+			setting.ApplyOpenAiHeaders(hreq.Header)
+
+			sent := time.Now()
+			res, err := client.Do(hreq)
 			lastErr = err
 			stopStep = idx
 
+			req.addAttempt(idx, step, res, err, time.Since(sent))
+
 			if err != nil {
 				retries -= 1
 				continue
 			}
 
+			// a retry of a rate limited request is sent with the next key of the pool.
+			if res.StatusCode == http.StatusTooManyRequests {
+				setting.RateLimited(key)
+			}
+
 			responses = append(responses, res)
 
 			if res.StatusCode != http.StatusOK {
@@ -235,12 +349,18 @@ func (r *Route) RunSteps(req *Request) (*Response, error) {
 	}
 
 	if len(responses) >= 1 {
-		return &Response{
+		res := &Response{
 			Response: responses[len(responses)-1],
 			Cancel:   cancelFuncs[len(cancelFuncs)-1],
 			Provider: r.Steps[stopStep].Provider,
 			Model:    r.Steps[stopStep].Model,
-		}, nil
+		}
+
+		if setting := req.getSetting(res.Provider); setting != nil {
+			res.SettingId = setting.Id
+		}
+
+		return res, nil
 	}
 
 	noResponses = true
@@ -252,7 +372,75 @@ type Request struct {
 	Settings  map[string]*provider.Setting
 	Key       *key.ResponseKey
 	Client    http.Client
+	Egress    *provider.EgressClients
 	Forwarded *http.Request
+	Attempts  []*Attempt
+}
+
+// Attempt is a call made to the provider of a step while running the steps
+// of a route. Retry counts the earlier attempts of the same step.
+type Attempt struct {
+	Step        int
+	Retry       int
+	Provider    string
+	Model       string
+	Status      int
+	Error       string
+	LatencyInMs int
+}
+
+func (r *Request) addAttempt(idx int, step *Step, res *http.Response, err error, latency time.Duration) {
+	a := &Attempt{
+		Step:        idx,
+		Provider:    step.Provider,
+		Model:       step.Model,
+		LatencyInMs: int(latency.Milliseconds()),
+	}
+
+	for _, previous := range r.Attempts {
+		if previous.Step == idx {
+			a.Retry++
+		}
+	}
+
+	if err != nil {
+		a.Error = err.Error()
+	}
+
+	if res != nil {
+		a.Status = res.StatusCode
+	}
+
+	r.Attempts = append(r.Attempts, a)
+}
+
+// getClient picks the egress of the route over the one of the provider
+// setting used by a step.
+func (r *Request) getClient(egress *provider.Egress, p string) (*http.Client, error) {
+	if r.Egress == nil {
+		return &r.Client, nil
+	}
+
+	if egress.IsEmpty() {
+		for _, setting := range r.Settings {
+			if setting.Provider == p {
+				egress = setting.Egress
+				break
+			}
+		}
+	}
+
+	return r.Egress.Get(egress)
+}
+
+func (r *Request) getSetting(p string) *provider.Setting {
+	for _, setting := range r.Settings {
+		if setting.Provider == p {
+			return setting
+		}
+	}
+
+	return nil
 }
 
 func (r *Request) GetSettingValue(provider string, param string) (string, error) {
@@ -271,10 +459,11 @@ func (r *Request) GetSettingValue(provider string, param string) (string, error)
 }
 
 type Response struct {
-	Provider string
-	Model    string
-	Cancel   context.CancelFunc
-	Response *http.Response
+	Provider  string
+	Model     string
+	SettingId string
+	Cancel    context.CancelFunc
+	Response  *http.Response
 }
 
 func buildRequestUrl(provider string, runEmbeddings bool, resourceName string, params map[string]string) string {
@@ -301,10 +490,5 @@ func buildRequestUrl(provider string, runEmbeddings bool, resourceName string, p
 }
 
 func setHttpRequestAuthHeader(provider string, req *http.Request, key string) {
-	if provider == "azure" {
-		req.Header.Set("api-key", key)
-		return
-	}
-
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", key))
 }
@@ -0,0 +1,73 @@
+package route
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Shadow mirrors a percentage of the requests of a route to a secondary step.
+// Shadow requests run after the client has been answered and their results
+// are only recorded for offline comparison.
+type Shadow struct {
+	Percentage float64 `json:"percentage"`
+	Step       *Step   `json:"step"`
+}
+
+func (s *Shadow) Validate() []string {
+	invalid := []string{}
+
+	if s.Percentage <= 0 || s.Percentage > 100 {
+		invalid = append(invalid, "shadow.percentage")
+	}
+
+	if s.Step == nil {
+		return append(invalid, "shadow.step")
+	}
+
+	if len(s.Step.Timeout) != 0 {
+		if _, err := time.ParseDuration(s.Step.Timeout); err != nil {
+			invalid = append(invalid, "shadow.step.timeout")
+		}
+	}
+
+	return invalid
+}
+
+func (s *Shadow) ShouldShadow() bool {
+	return rand.Float64()*100 < s.Percentage
+}
+
+// ShadowRoute returns a route that only runs the shadow step of the route.
+func (r *Route) ShadowRoute() *Route {
+	if r.Shadow == nil || r.Shadow.Step == nil {
+		return nil
+	}
+
+	return &Route{
+		Id:     r.Id,
+		Path:   r.Path,
+		KeyIds: r.KeyIds,
+		Steps:  []*Step{r.Shadow.Step},
+	}
+}
+
+type ShadowOutcome struct {
+	Provider             string  `json:"provider"`
+	Model                string  `json:"model"`
+	Status               int     `json:"status"`
+	LatencyInMs          int     `json:"latencyInMs"`
+	PromptTokenCount     int     `json:"promptTokenCount"`
+	CompletionTokenCount int     `json:"completionTokenCount"`
+	CostInUsd            float64 `json:"costInUsd"`
+	Content              string  `json:"content"`
+	Error                string  `json:"error,omitempty"`
+}
+
+type ShadowResult struct {
+	Id            string         `json:"id"`
+	CreatedAt     int64          `json:"createdAt"`
+	RouteId       string         `json:"routeId"`
+	CorrelationId string         `json:"correlationId"`
+	Primary       *ShadowOutcome `json:"primary"`
+	Shadow        *ShadowOutcome `json:"shadow"`
+}
@@ -0,0 +1,98 @@
+package route
+
+import (
+	"time"
+)
+
+// Slo declares the objectives of a route. Targets are percentages of requests
+// within the rolling window that have to be successful or fast enough.
+type Slo struct {
+	Window               string  `json:"window"`
+	AvailabilityTarget   float64 `json:"availabilityTarget"`
+	LatencyThresholdInMs int     `json:"latencyThresholdInMs"`
+	LatencyTarget        float64 `json:"latencyTarget"`
+}
+
+func (s *Slo) Validate() []string {
+	invalid := []string{}
+
+	parsed, err := time.ParseDuration(s.Window)
+	if err != nil || parsed <= 0 {
+		invalid = append(invalid, "slo.window")
+	}
+
+	if s.AvailabilityTarget < 0 || s.AvailabilityTarget >= 100 {
+		invalid = append(invalid, "slo.availabilityTarget")
+	}
+
+	if s.LatencyThresholdInMs < 0 {
+		invalid = append(invalid, "slo.latencyThresholdInMs")
+	}
+
+	if s.LatencyTarget < 0 || s.LatencyTarget >= 100 || (s.LatencyTarget > 0 && s.LatencyThresholdInMs == 0) {
+		invalid = append(invalid, "slo.latencyTarget")
+	}
+
+	if s.AvailabilityTarget == 0 && s.LatencyTarget == 0 {
+		invalid = append(invalid, "slo")
+	}
+
+	return invalid
+}
+
+func (s *Slo) GetWindow() time.Duration {
+	parsed, _ := time.ParseDuration(s.Window)
+	return parsed
+}
+
+type SloCompliance struct {
+	Target                     float64 `json:"target"`
+	Compliance                 float64 `json:"compliance"`
+	NumberOfViolations         int64   `json:"numberOfViolations"`
+	ErrorBudgetRemaining       float64 `json:"errorBudgetRemaining"`
+	ErrorBudgetRemainingInReqs float64 `json:"errorBudgetRemainingInRequests"`
+}
+
+// NewSloCompliance computes the compliance of a target against the number of
+// requests in a window. The remaining error budget is a percentage of the
+// violations the target allows, and turns negative once the budget is spent.
+func NewSloCompliance(target float64, total, violations int64) *SloCompliance {
+	c := &SloCompliance{
+		Target:               target,
+		Compliance:           100,
+		NumberOfViolations:   violations,
+		ErrorBudgetRemaining: 100,
+	}
+
+	if total == 0 {
+		return c
+	}
+
+	c.Compliance = float64(total-violations) / float64(total) * 100
+
+	allowed := float64(total) * (100 - target) / 100
+	c.ErrorBudgetRemainingInReqs = allowed - float64(violations)
+
+	if allowed == 0 {
+		if violations > 0 {
+			c.ErrorBudgetRemaining = -100
+		}
+
+		return c
+	}
+
+	c.ErrorBudgetRemaining = c.ErrorBudgetRemainingInReqs / allowed * 100
+
+	return c
+}
+
+type SloReport struct {
+	RouteId          string         `json:"routeId"`
+	Path             string         `json:"path"`
+	Window           string         `json:"window"`
+	Start            int64          `json:"start"`
+	End              int64          `json:"end"`
+	NumberOfRequests int64          `json:"numberOfRequests"`
+	Availability     *SloCompliance `json:"availability,omitempty"`
+	Latency          *SloCompliance `json:"latency,omitempty"`
+}
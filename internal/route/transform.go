@@ -0,0 +1,141 @@
+package route
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+type RequestRuleAction string
+
+const (
+	SetHeaderRequestRuleAction          RequestRuleAction = "setHeader"
+	RemoveHeaderRequestRuleAction       RequestRuleAction = "removeHeader"
+	SetFieldRequestRuleAction           RequestRuleAction = "setField"
+	RemoveFieldRequestRuleAction        RequestRuleAction = "removeField"
+	CapFieldRequestRuleAction           RequestRuleAction = "capField"
+	SetFieldFromHeaderRequestRuleAction RequestRuleAction = "setFieldFromHeader"
+)
+
+// RequestRule is a declarative mutation of a route request. Fields refer to
+// top level fields of the json request body.
+type RequestRule struct {
+	Action RequestRuleAction `json:"action"`
+	Header string            `json:"header,omitempty"`
+	Field  string            `json:"field,omitempty"`
+	Value  any               `json:"value,omitempty"`
+	Max    *float64          `json:"max,omitempty"`
+}
+
+func (rr *RequestRule) Validate(index int) []string {
+	invalid := []string{}
+	prefix := fmt.Sprintf("requestRules.[%d]", index)
+
+	needsHeader := false
+	needsField := false
+
+	switch rr.Action {
+	case SetHeaderRequestRuleAction:
+		needsHeader = true
+		if _, ok := rr.Value.(string); !ok {
+			invalid = append(invalid, prefix+".value")
+		}
+	case RemoveHeaderRequestRuleAction:
+		needsHeader = true
+	case SetFieldRequestRuleAction:
+		needsField = true
+		if rr.Value == nil {
+			invalid = append(invalid, prefix+".value")
+		}
+	case RemoveFieldRequestRuleAction:
+		needsField = true
+	case CapFieldRequestRuleAction:
+		needsField = true
+		if rr.Max == nil {
+			invalid = append(invalid, prefix+".max")
+		}
+	case SetFieldFromHeaderRequestRuleAction:
+		needsHeader = true
+		needsField = true
+	default:
+		invalid = append(invalid, prefix+".action")
+	}
+
+	if needsHeader && len(rr.Header) == 0 {
+		invalid = append(invalid, prefix+".header")
+	}
+
+	if needsField && len(rr.Field) == 0 {
+		invalid = append(invalid, prefix+".field")
+	}
+
+	return invalid
+}
+
+// ApplyRequestRules evaluates the rules in order against the request headers
+// and the json request body, returning the rewritten body.
+func ApplyRequestRules(rules []*RequestRule, header http.Header, body []byte) ([]byte, error) {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, internal_errors.NewValidationError("request body must be a json object")
+	}
+
+	for _, rr := range rules {
+		switch rr.Action {
+		case SetHeaderRequestRuleAction:
+			if value, ok := rr.Value.(string); ok {
+				header.Set(rr.Header, value)
+			}
+		case RemoveHeaderRequestRuleAction:
+			header.Del(rr.Header)
+		case SetFieldRequestRuleAction:
+			data, err := json.Marshal(rr.Value)
+			if err != nil {
+				return nil, err
+			}
+
+			fields[rr.Field] = data
+		case RemoveFieldRequestRuleAction:
+			delete(fields, rr.Field)
+		case CapFieldRequestRuleAction:
+			if rr.Max == nil {
+				continue
+			}
+
+			current, ok := fields[rr.Field]
+			if ok && string(current) != "null" {
+				var parsed float64
+				if err := json.Unmarshal(current, &parsed); err != nil {
+					return nil, internal_errors.NewValidationError(fmt.Sprintf("field %s must be a number", rr.Field))
+				}
+
+				if parsed <= *rr.Max {
+					continue
+				}
+			}
+
+			data, err := json.Marshal(*rr.Max)
+			if err != nil {
+				return nil, err
+			}
+
+			fields[rr.Field] = data
+		case SetFieldFromHeaderRequestRuleAction:
+			value := header.Get(rr.Header)
+			if len(value) == 0 {
+				continue
+			}
+
+			data, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+
+			fields[rr.Field] = data
+		}
+	}
+
+	return json.Marshal(fields)
+}
@@ -23,7 +23,12 @@ func ComputeCacheKeyForEmbeddingsRequest(path string, req *goopenai.EmbeddingReq
 		input += ele
 	}
 
-	return fmt.Sprintf("%s-%s-%s-%s", path, input, req.EncodingFormat, req.User)
+	key := fmt.Sprintf("%s-%s-%s-%s", path, input, req.EncodingFormat, req.User)
+	if req.Dimensions != 0 {
+		key += fmt.Sprintf("-%d", req.Dimensions)
+	}
+
+	return key
 }
 
 func ComputeCacheKeyForChatCompletionRequest(path string, req *goopenai.ChatCompletionRequest) string {
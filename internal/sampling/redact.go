@@ -0,0 +1,99 @@
+package sampling
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// redactions are applied in order, so that api keys and card numbers are
+// replaced before the broader phone number pattern sees their digits.
+var redactions = []struct {
+	regex       *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`(?i)\bbearer\s+[a-z0-9._~+/=-]{16,}`), "[TOKEN]"},
+	{regexp.MustCompile(`\b(sk|pk|rk)-[A-Za-z0-9_-]{16,}\b`), "[API_KEY]"},
+	{regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`), "[API_KEY]"},
+	{regexp.MustCompile(`(?i)\b[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}\b`), "[EMAIL]"},
+	{regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`), "[CARD_NUMBER]"},
+	{regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`), "[SSN]"},
+	{regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`), "[IP_ADDRESS]"},
+	{regexp.MustCompile(`(?:\+\d{1,3}[ .-]?|\(|\b)\d{2,4}\)?[ .-]?\d{3,4}[ .-]?\d{3,4}\b`), "[PHONE_NUMBER]"},
+}
+
+// isCardNumber runs the luhn check so that long order or tracking numbers are
+// not mistaken for card numbers.
+func isCardNumber(match string) bool {
+	sum := 0
+	double := false
+	digits := 0
+
+	for i := len(match) - 1; i >= 0; i-- {
+		r := rune(match[i])
+		if !unicode.IsDigit(r) {
+			continue
+		}
+
+		d := int(r - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+
+		sum += d
+		double = !double
+		digits++
+	}
+
+	return digits >= 13 && sum%10 == 0
+}
+
+// Redact replaces emails, phone numbers, card numbers, social security
+// numbers, ip addresses and api keys in text with placeholders.
+func Redact(text string) string {
+	for _, r := range redactions {
+		if r.replacement == "[CARD_NUMBER]" {
+			text = r.regex.ReplaceAllStringFunc(text, func(match string) string {
+				if isCardNumber(match) {
+					return r.replacement
+				}
+
+				return match
+			})
+			continue
+		}
+
+		text = r.regex.ReplaceAllString(text, r.replacement)
+	}
+
+	return text
+}
+
+// redactValue redacts every string of a decoded json value.
+func redactValue(v any) any {
+	switch val := v.(type) {
+	case string:
+		return Redact(val)
+	case []any:
+		for i := range val {
+			val[i] = redactValue(val[i])
+		}
+		return val
+	case map[string]any:
+		for k := range val {
+			// images cannot be redacted, so they are left out.
+			if strings.EqualFold(k, "image_url") {
+				val[k] = map[string]any{"url": "[IMAGE]"}
+				continue
+			}
+
+			val[k] = redactValue(val[k])
+		}
+		return val
+	}
+
+	return v
+}
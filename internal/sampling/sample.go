@@ -0,0 +1,102 @@
+package sampling
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/tidwall/gjson"
+)
+
+var (
+	errNoMessages   = errors.New("request has no messages")
+	errNoCompletion = errors.New("response has no completion")
+)
+
+// Redacted fills in the redacted messages of the request and the completion
+// of the response of a sample. Completions of streaming requests are taken
+// from the aggregated stream.
+func Redacted(s *event.Sample) error {
+	messages := gjson.GetBytes(s.Request, "messages")
+	if !messages.IsArray() {
+		return errNoMessages
+	}
+
+	var decoded any
+	if err := json.Unmarshal([]byte(messages.Raw), &decoded); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(redactValue(decoded))
+	if err != nil {
+		return err
+	}
+
+	completion := s.Streamed
+	if len(completion) == 0 {
+		completion = gjson.GetBytes(s.Response, "choices.0.message.content").String()
+	}
+
+	if len(completion) == 0 {
+		return errNoCompletion
+	}
+
+	s.Messages = data
+	s.Completion = Redact(completion)
+	s.Request = nil
+	s.Response = nil
+	s.Streamed = ""
+
+	return nil
+}
+
+type exportedMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type exportedSample struct {
+	Id        string            `json:"id,omitempty"`
+	CreatedAt int64             `json:"created_at,omitempty"`
+	Provider  string            `json:"provider,omitempty"`
+	Model     string            `json:"model,omitempty"`
+	KeyId     string            `json:"key_id,omitempty"`
+	Messages  []json.RawMessage `json:"messages"`
+}
+
+// Line returns the JSONL line of a sample with the completion appended to the
+// messages as an assistant message. The messages format only keeps the
+// messages so that lines can be used as fine-tuning data as they are.
+func Line(s *event.Sample, format string) ([]byte, error) {
+	messages := []json.RawMessage{}
+	if err := json.Unmarshal(s.Messages, &messages); err != nil {
+		return nil, err
+	}
+
+	completion, err := json.Marshal(&exportedMessage{
+		Role:    "assistant",
+		Content: s.Completion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	exported := &exportedSample{
+		Messages: append(messages, completion),
+	}
+
+	if format != event.SampleFormatMessages {
+		exported.Id = s.Id
+		exported.CreatedAt = s.CreatedAt
+		exported.Provider = s.Provider
+		exported.Model = s.Model
+		exported.KeyId = s.KeyId
+	}
+
+	data, err := json.Marshal(exported)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, '\n'), nil
+}
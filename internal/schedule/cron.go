@@ -0,0 +1,208 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxCronSteps bounds the search for the next run so that expressions that
+// never match, such as the 30th of February, do not loop forever.
+const maxCronSteps = 100000
+
+var cronDescriptors = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+type cronField uint64
+
+func (f cronField) has(v int) bool {
+	return f&(1<<uint(v)) != 0
+}
+
+// Cron is a parsed five field cron expression: minute, hour, day of month,
+// month and day of week.
+type Cron struct {
+	minutes    cronField
+	hours      cronField
+	days       cronField
+	months     cronField
+	weekdays   cronField
+	anyDay     bool
+	anyWeekday bool
+}
+
+func ParseCron(expr string) (*Cron, error) {
+	expr = strings.TrimSpace(expr)
+	if descriptor, ok := cronDescriptors[expr]; ok {
+		expr = descriptor
+	}
+
+	parts := strings.Fields(expr)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("cron expression must have 5 fields")
+	}
+
+	c := &Cron{
+		anyDay:     parts[2] == "*",
+		anyWeekday: parts[4] == "*",
+	}
+
+	var err error
+	if c.minutes, err = parseCronField(parts[0], 0, 59); err != nil {
+		return nil, err
+	}
+
+	if c.hours, err = parseCronField(parts[1], 0, 23); err != nil {
+		return nil, err
+	}
+
+	if c.days, err = parseCronField(parts[2], 1, 31); err != nil {
+		return nil, err
+	}
+
+	if c.months, err = parseCronField(parts[3], 1, 12); err != nil {
+		return nil, err
+	}
+
+	if c.weekdays, err = parseCronField(parts[4], 0, 7); err != nil {
+		return nil, err
+	}
+
+	// both 0 and 7 are Sunday.
+	if c.weekdays.has(7) {
+		c.weekdays |= 1
+	}
+
+	return c, nil
+}
+
+func parseCronField(field string, min, max int) (cronField, error) {
+	var f cronField
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if index := strings.Index(part, "/"); index != -1 {
+			parsed, err := strconv.Atoi(part[index+1:])
+			if err != nil || parsed <= 0 {
+				return 0, fmt.Errorf("invalid step in cron field %s", field)
+			}
+
+			rng, step = part[:index], parsed
+		}
+
+		start, end := min, max
+		if rng != "*" {
+			bounds := strings.SplitN(rng, "-", 2)
+
+			var err error
+			if start, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid value in cron field %s", field)
+			}
+
+			end = start
+			if len(bounds) == 2 {
+				if end, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("invalid value in cron field %s", field)
+				}
+			}
+		}
+
+		if start < min || end > max || start > end {
+			return 0, fmt.Errorf("cron field %s is out of range", field)
+		}
+
+		for v := start; v <= end; v += step {
+			f |= 1 << uint(v)
+		}
+	}
+
+	return f, nil
+}
+
+// matchesDay follows the usual cron rule that a day matches either field
+// when both day of month and day of week are restricted.
+func (c *Cron) matchesDay(t time.Time) bool {
+	day := c.days.has(t.Day())
+	weekday := c.weekdays.has(int(t.Weekday()))
+
+	if c.anyDay || c.anyWeekday {
+		return day && weekday
+	}
+
+	return day || weekday
+}
+
+// Next returns the first minute after t that matches the expression on the
+// wall clock of the location of t, or the zero time if there is none. When
+// clocks are turned forward, times in the skipped hour run at the moment of
+// the change. When clocks are turned back, repeated times run only once.
+func (c *Cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+
+	// the search runs on a wall clock without daylight saving time, which
+	// is only mapped back into the location once a minute matches.
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC).Add(time.Minute)
+
+	for i := 0; i < maxCronSteps; i++ {
+		if !c.months.has(int(wall.Month())) {
+			wall = time.Date(wall.Year(), wall.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+
+		if !c.matchesDay(wall) {
+			wall = time.Date(wall.Year(), wall.Month(), wall.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+
+		if !c.hours.has(wall.Hour()) {
+			wall = time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour()+1, 0, 0, 0, time.UTC)
+			continue
+		}
+
+		if !c.minutes.has(wall.Minute()) {
+			wall = wall.Add(time.Minute)
+			continue
+		}
+
+		if next := inLocation(wall, loc); next.After(t) {
+			return next
+		}
+
+		wall = wall.Add(time.Minute)
+	}
+
+	return time.Time{}
+}
+
+// inLocation returns the first moment the wall clock of loc shows the time
+// of wall, or the moment clocks were turned forward past it.
+func inLocation(wall time.Time, loc *time.Location) time.Time {
+	t := time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), 0, 0, loc)
+
+	if shown := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC); !shown.Equal(wall) {
+		// the time was skipped, the zone of t either ends or starts with
+		// the change depending on the side it was normalized to.
+		start, end := t.ZoneBounds()
+		if shown.After(wall) {
+			return start
+		}
+
+		return end
+	}
+
+	// when clocks were turned back within the last hours, the same time
+	// may have been shown before in the previous zone.
+	_, offset := t.Zone()
+	if _, previous := t.Add(-12 * time.Hour).Zone(); previous > offset {
+		earlier := t.Add(-time.Duration(previous-offset) * time.Second)
+		if _, o := earlier.Zone(); o == previous {
+			return earlier
+		}
+	}
+
+	return t
+}
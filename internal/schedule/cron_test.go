@@ -0,0 +1,246 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	// tests do not depend on the timezone database of the host.
+	_ "time/tzdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadLocation(t *testing.T, name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	require.NoError(t, err)
+
+	return loc
+}
+
+func TestParseCron(t *testing.T) {
+	valid := []string{
+		"* * * * *",
+		"*/15 * * * *",
+		"0 9-17/2 * * 1-5",
+		"0,30 0 1,15 * *",
+		"0 0 * * 7",
+		" @hourly ",
+		"@daily",
+		"@weekly",
+		"@monthly",
+	}
+
+	for _, expr := range valid {
+		t.Run(expr, func(t *testing.T) {
+			_, err := ParseCron(expr)
+			assert.NoError(t, err)
+		})
+	}
+
+	invalid := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"@yearly",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 0 *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"1-a * * * *",
+		"1,,2 * * * *",
+		"-1 * * * *",
+	}
+
+	for _, expr := range invalid {
+		t.Run(expr, func(t *testing.T) {
+			_, err := ParseCron(expr)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCron_Next(t *testing.T) {
+	utc := time.UTC
+	tokyo := loadLocation(t, "Asia/Tokyo")
+	kolkata := loadLocation(t, "Asia/Kolkata")
+	losAngeles := loadLocation(t, "America/Los_Angeles")
+
+	cases := []struct {
+		name     string
+		expr     string
+		from     time.Time
+		expected time.Time
+	}{
+		{name: "next step", expr: "*/15 * * * *", from: time.Date(2024, 1, 1, 10, 7, 30, 0, utc), expected: time.Date(2024, 1, 1, 10, 15, 0, 0, utc)},
+		{name: "strictly after a matching minute", expr: "*/15 * * * *", from: time.Date(2024, 1, 1, 10, 15, 0, 0, utc), expected: time.Date(2024, 1, 1, 10, 30, 0, 0, utc)},
+		{name: "seconds are ignored", expr: "0 12 * * *", from: time.Date(2024, 1, 1, 11, 59, 59, 999, utc), expected: time.Date(2024, 1, 1, 12, 0, 0, 0, utc)},
+		{name: "hourly", expr: "@hourly", from: time.Date(2024, 1, 1, 10, 0, 0, 0, utc), expected: time.Date(2024, 1, 1, 11, 0, 0, 0, utc)},
+		{name: "daily across months", expr: "@daily", from: time.Date(2024, 1, 31, 12, 0, 0, 0, utc), expected: time.Date(2024, 2, 1, 0, 0, 0, 0, utc)},
+		{name: "weekly on sunday", expr: "@weekly", from: time.Date(2024, 1, 1, 0, 0, 0, 0, utc), expected: time.Date(2024, 1, 7, 0, 0, 0, 0, utc)},
+		{name: "monthly", expr: "@monthly", from: time.Date(2024, 1, 15, 0, 0, 0, 0, utc), expected: time.Date(2024, 2, 1, 0, 0, 0, 0, utc)},
+		{name: "7 is sunday", expr: "0 0 * * 7", from: time.Date(2024, 1, 1, 0, 0, 0, 0, utc), expected: time.Date(2024, 1, 7, 0, 0, 0, 0, utc)},
+		{name: "weekdays skip the weekend", expr: "0 9 * * 1-5", from: time.Date(2024, 1, 5, 10, 0, 0, 0, utc), expected: time.Date(2024, 1, 8, 9, 0, 0, 0, utc)},
+		{name: "ranges with steps", expr: "0 9-17/4 * * *", from: time.Date(2024, 1, 1, 13, 30, 0, 0, utc), expected: time.Date(2024, 1, 1, 17, 0, 0, 0, utc)},
+		{name: "day of month or day of week", expr: "0 0 1 * 1", from: time.Date(2024, 1, 2, 0, 0, 0, 0, utc), expected: time.Date(2024, 1, 8, 0, 0, 0, 0, utc)},
+		{name: "day of week or day of month", expr: "0 0 13 * 5", from: time.Date(2024, 1, 6, 0, 0, 0, 0, utc), expected: time.Date(2024, 1, 12, 0, 0, 0, 0, utc)},
+		{name: "restricted day of month with any day of week", expr: "0 0 13 * *", from: time.Date(2024, 1, 6, 0, 0, 0, 0, utc), expected: time.Date(2024, 1, 13, 0, 0, 0, 0, utc)},
+		{name: "across years", expr: "30 23 31 12 *", from: time.Date(2024, 12, 31, 23, 30, 0, 0, utc), expected: time.Date(2025, 12, 31, 23, 30, 0, 0, utc)},
+		{name: "leap days", expr: "0 0 29 2 *", from: time.Date(2024, 3, 1, 0, 0, 0, 0, utc), expected: time.Date(2028, 2, 29, 0, 0, 0, 0, utc)},
+		{name: "days that never exist", expr: "0 0 30 2 *", from: time.Date(2024, 1, 1, 0, 0, 0, 0, utc), expected: time.Time{}},
+
+		// timezones
+		{name: "wall clock of the location", expr: "0 9 * * *", from: time.Date(2024, 1, 1, 9, 0, 0, 0, tokyo), expected: time.Date(2024, 1, 2, 9, 0, 0, 0, tokyo)},
+		{name: "half hour offsets", expr: "0 9 * * *", from: time.Date(2024, 1, 1, 0, 0, 0, 0, utc).In(kolkata), expected: time.Date(2024, 1, 1, 3, 30, 0, 0, utc)},
+		{name: "day of week of the location", expr: "0 9 * * 1", from: time.Date(2024, 1, 8, 3, 0, 0, 0, utc).In(losAngeles), expected: time.Date(2024, 1, 8, 17, 0, 0, 0, utc)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := ParseCron(tc.expr)
+			require.NoError(t, err)
+
+			next := c.Next(tc.from)
+			assert.True(t, tc.expected.Equal(next), "expected %s, got %s", tc.expected, next)
+			if !next.IsZero() {
+				assert.Equal(t, tc.from.Location(), next.Location())
+			}
+		})
+	}
+}
+
+func TestCron_NextAcrossDaylightSavingTime(t *testing.T) {
+	newYork := loadLocation(t, "America/New_York")
+	berlin := loadLocation(t, "Europe/Berlin")
+
+	// clocks in new york go from 02:00 EST to 03:00 EDT at 07:00 utc on
+	// 2024-03-10 and from 02:00 EDT back to 01:00 EST at 06:00 utc on
+	// 2024-11-03. clocks in berlin go from 02:00 CET to 03:00 CEST at 01:00
+	// utc on 2024-03-31 and from 03:00 CEST back to 02:00 CET at 01:00 utc on
+	// 2024-10-27.
+	cases := []struct {
+		name     string
+		expr     string
+		loc      *time.Location
+		from     time.Time
+		expected []time.Time
+	}{
+		{
+			name: "skipped times run when clocks are turned forward",
+			expr: "30 2 * * *",
+			loc:  newYork,
+			from: time.Date(2024, 3, 9, 17, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC),
+				time.Date(2024, 3, 11, 6, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "hours keep running when clocks are turned forward",
+			expr: "0 * * * *",
+			loc:  newYork,
+			from: time.Date(2024, 3, 10, 5, 30, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2024, 3, 10, 6, 0, 0, 0, time.UTC),
+				time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC),
+				time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "repeated times run once when clocks are turned back",
+			expr: "30 1 * * *",
+			loc:  newYork,
+			from: time.Date(2024, 11, 2, 16, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC),
+				time.Date(2024, 11, 4, 6, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "repeated hours are skipped by frequent runs",
+			expr: "*/30 * * * *",
+			loc:  newYork,
+			from: time.Date(2024, 11, 3, 5, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC),
+				time.Date(2024, 11, 3, 7, 0, 0, 0, time.UTC),
+				time.Date(2024, 11, 3, 7, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "times that already ran are not repeated from within the repeated hour",
+			expr: "30 1 * * *",
+			loc:  newYork,
+			from: time.Date(2024, 11, 3, 6, 10, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2024, 11, 4, 6, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "skipped times run when clocks are turned forward east of utc",
+			expr: "30 2 * * *",
+			loc:  berlin,
+			from: time.Date(2024, 3, 30, 12, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2024, 3, 31, 1, 0, 0, 0, time.UTC),
+				time.Date(2024, 4, 1, 0, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "repeated times run once when clocks are turned back east of utc",
+			expr: "30 2 * * *",
+			loc:  berlin,
+			from: time.Date(2024, 10, 26, 12, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2024, 10, 27, 0, 30, 0, 0, time.UTC),
+				time.Date(2024, 10, 28, 1, 30, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := ParseCron(tc.expr)
+			require.NoError(t, err)
+
+			runs := []time.Time{}
+			from := tc.from.In(tc.loc)
+			for range tc.expected {
+				from = c.Next(from)
+				runs = append(runs, from.UTC())
+			}
+
+			assert.Equal(t, tc.expected, runs)
+		})
+	}
+}
+
+func TestSchedule_NextRun(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name     string
+		s        *Schedule
+		expected int64
+	}{
+		{name: "utc by default", s: &Schedule{Cron: "0 9 * * *"}, expected: time.Date(2024, 3, 11, 9, 0, 0, 0, time.UTC).Unix()},
+		{name: "timezone of the schedule", s: &Schedule{Cron: "0 9 * * *", Timezone: "America/New_York"}, expected: time.Date(2024, 3, 10, 13, 0, 0, 0, time.UTC).Unix()},
+		{name: "paused", s: &Schedule{Cron: "0 9 * * *", Paused: true}, expected: 0},
+		{name: "invalid cron", s: &Schedule{Cron: "0 9 * *"}, expected: 0},
+		{name: "invalid timezone", s: &Schedule{Cron: "0 9 * * *", Timezone: "Mars/Olympus"}, expected: 0},
+		{name: "never matching cron", s: &Schedule{Cron: "0 0 31 4 *"}, expected: 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.s.NextRun(now))
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package schedule
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/webhook"
+)
+
+// Deliverer sends the results of runs to the destination of their schedule.
+type Deliverer struct {
+	client          http.Client
+	webhookClient   http.Client
+	accessKeyId     string
+	secretAccessKey string
+}
+
+func NewDeliverer(webhookHosts []string, accessKeyId, secretAccessKey string) *Deliverer {
+	return &Deliverer{
+		client: http.Client{
+			Timeout: 30 * time.Second,
+		},
+		webhookClient:   webhook.NewClient(webhookHosts, 30*time.Second),
+		accessKeyId:     accessKeyId,
+		secretAccessKey: secretAccessKey,
+	}
+}
+
+// Deliver returns the location the result was delivered to.
+func (d *Deliverer) Deliver(s *Schedule, r *Result, data []byte) (string, error) {
+	if s.Destination.Type == DestinationS3 {
+		return d.putObject(s.Destination.S3, objectKey(s.Destination.S3.Prefix, r), data)
+	}
+
+	return s.Destination.WebhookUrl, d.post(s, data)
+}
+
+func (d *Deliverer) post(s *Schedule, data []byte) error {
+	req, err := http.NewRequest(http.MethodPost, s.Destination.WebhookUrl, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-BricksLLM-Schedule-Id", s.Id)
+
+	res, err := d.webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+// objectKey groups results by schedule and orders them by time.
+func objectKey(prefix string, r *Result) string {
+	name := fmt.Sprintf("%s/%s-%s.json", r.ScheduleId, time.Unix(r.RanAt, 0).UTC().Format("20060102T150405Z"), r.RunId)
+	prefix = strings.Trim(prefix, "/")
+	if len(prefix) == 0 {
+		return name
+	}
+
+	return prefix + "/" + name
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// putObject uploads an object signed with AWS signature version 4. Object
+// keys only contain characters that do not need to be escaped.
+func (d *Deliverer) putObject(dest *S3Destination, objectKey string, data []byte) (string, error) {
+	if len(d.accessKeyId) == 0 || len(d.secretAccessKey) == 0 {
+		return "", fmt.Errorf("s3 credentials are not configured")
+	}
+
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	host := fmt.Sprintf("%s.s3.%s.amazonaws.com", dest.Bucket, dest.Region)
+	payloadHash := sha256Hex(data)
+
+	signedHeaders := "content-type;host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		http.MethodPut,
+		"/" + objectKey,
+		"",
+		"content-type:application/json",
+		"host:" + host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + dest.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := hmacSha256([]byte("AWS4"+d.secretAccessKey), date)
+	signingKey = hmacSha256(signingKey, dest.Region)
+	signingKey = hmacSha256(signingKey, "s3")
+	signingKey = hmacSha256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSha256(signingKey, stringToSign))
+
+	req, err := http.NewRequest(http.MethodPut, "https://"+host+"/"+objectKey, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", d.accessKeyId, scope, signedHeaders, signature))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		return "", fmt.Errorf("s3 responded with status %d: %s", res.StatusCode, string(body))
+	}
+
+	return fmt.Sprintf("s3://%s/%s", dest.Bucket, objectKey), nil
+}
@@ -0,0 +1,230 @@
+package schedule
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+const (
+	DestinationWebhook = "webhook"
+	DestinationS3      = "s3"
+)
+
+var (
+	bucketPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9.\-]{1,61}[a-z0-9]$`)
+	regionPattern = regexp.MustCompile(`^[a-z0-9\-]{1,32}$`)
+	prefixPattern = regexp.MustCompile(`^[A-Za-z0-9_\-./]{0,255}$`)
+)
+
+type S3Destination struct {
+	Bucket string `json:"bucket"`
+	Region string `json:"region"`
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// Destination is where the results of the runs of a schedule are delivered.
+type Destination struct {
+	Type       string         `json:"type"`
+	WebhookUrl string         `json:"webhookUrl,omitempty"`
+	S3         *S3Destination `json:"s3,omitempty"`
+}
+
+func (d *Destination) validate() []string {
+	if d == nil {
+		return []string{"destination"}
+	}
+
+	invalid := []string{}
+	switch d.Type {
+	case DestinationWebhook:
+		if len(d.WebhookUrl) == 0 {
+			invalid = append(invalid, "destination.webhookUrl")
+		}
+	case DestinationS3:
+		if d.S3 == nil {
+			return append(invalid, "destination.s3")
+		}
+
+		if !bucketPattern.MatchString(d.S3.Bucket) {
+			invalid = append(invalid, "destination.s3.bucket")
+		}
+
+		if !regionPattern.MatchString(d.S3.Region) {
+			invalid = append(invalid, "destination.s3.region")
+		}
+
+		if !prefixPattern.MatchString(d.S3.Prefix) {
+			invalid = append(invalid, "destination.s3.prefix")
+		}
+	default:
+		invalid = append(invalid, "destination.type")
+	}
+
+	return invalid
+}
+
+// Schedule sends a stored request through a route of its key on a cron
+// schedule. Runs are sent through the proxy, so they are limited and
+// recorded as events like any other request of the key.
+type Schedule struct {
+	Id          string          `json:"id"`
+	CreatedAt   int64           `json:"createdAt"`
+	UpdatedAt   int64           `json:"updatedAt"`
+	TenantId    string          `json:"tenantId,omitempty"`
+	Name        string          `json:"name"`
+	Cron        string          `json:"cron"`
+	Timezone    string          `json:"timezone,omitempty"`
+	KeyId       string          `json:"keyId"`
+	RoutePath   string          `json:"routePath"`
+	Request     json.RawMessage `json:"request"`
+	Destination *Destination    `json:"destination"`
+	Paused      bool            `json:"paused"`
+	LastRunAt   int64           `json:"lastRunAt,omitempty"`
+	NextRunAt   int64           `json:"nextRunAt,omitempty"`
+}
+
+func (s *Schedule) location() (*time.Location, error) {
+	if len(s.Timezone) == 0 {
+		return time.UTC, nil
+	}
+
+	return time.LoadLocation(s.Timezone)
+}
+
+func (s *Schedule) Validate() error {
+	invalid := []string{}
+
+	if len(s.Name) == 0 {
+		invalid = append(invalid, "name")
+	}
+
+	if c, err := ParseCron(s.Cron); err != nil || c.Next(time.Now()).IsZero() {
+		invalid = append(invalid, "cron")
+	}
+
+	if _, err := s.location(); err != nil {
+		invalid = append(invalid, "timezone")
+	}
+
+	if len(s.KeyId) == 0 {
+		invalid = append(invalid, "keyId")
+	}
+
+	if !strings.HasPrefix(s.RoutePath, "/") {
+		invalid = append(invalid, "routePath")
+	}
+
+	var request map[string]any
+	if err := json.Unmarshal(s.Request, &request); err != nil {
+		invalid = append(invalid, "request")
+	}
+
+	if stream, ok := request["stream"].(bool); ok && stream {
+		invalid = append(invalid, "request.stream")
+	}
+
+	invalid = append(invalid, s.Destination.validate()...)
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
+
+// NextRun returns the unix timestamp of the first run after now, or zero if
+// the schedule is paused or its expression never matches.
+func (s *Schedule) NextRun(now time.Time) int64 {
+	if s.Paused {
+		return 0
+	}
+
+	c, err := ParseCron(s.Cron)
+	if err != nil {
+		return 0
+	}
+
+	loc, err := s.location()
+	if err != nil {
+		return 0
+	}
+
+	next := c.Next(now.In(loc))
+	if next.IsZero() {
+		return 0
+	}
+
+	return next.Unix()
+}
+
+type UpdateSchedule struct {
+	Name        *string          `json:"name"`
+	Cron        *string          `json:"cron"`
+	Timezone    *string          `json:"timezone"`
+	Request     *json.RawMessage `json:"request"`
+	Destination *Destination     `json:"destination"`
+	Paused      *bool            `json:"paused"`
+}
+
+// Apply copies the fields set in us onto s.
+func (us *UpdateSchedule) Apply(s *Schedule) {
+	if us.Name != nil {
+		s.Name = *us.Name
+	}
+
+	if us.Cron != nil {
+		s.Cron = *us.Cron
+	}
+
+	if us.Timezone != nil {
+		s.Timezone = *us.Timezone
+	}
+
+	if us.Request != nil {
+		s.Request = *us.Request
+	}
+
+	if us.Destination != nil {
+		s.Destination = us.Destination
+	}
+
+	if us.Paused != nil {
+		s.Paused = *us.Paused
+	}
+}
+
+type RunStatus string
+
+const (
+	RunStatusCompleted RunStatus = "completed"
+	RunStatusFailed    RunStatus = "failed"
+)
+
+// Run is one execution of a schedule. RequestId is the id of the event of
+// the request and Location is where its result was delivered.
+type Run struct {
+	Id         string    `json:"id"`
+	ScheduleId string    `json:"scheduleId"`
+	StartedAt  int64     `json:"startedAt"`
+	FinishedAt int64     `json:"finishedAt"`
+	Status     RunStatus `json:"status"`
+	RequestId  string    `json:"requestId,omitempty"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	Location   string    `json:"location,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Result is the payload delivered for a run.
+type Result struct {
+	ScheduleId string          `json:"scheduleId"`
+	RunId      string          `json:"runId"`
+	RequestId  string          `json:"requestId,omitempty"`
+	StatusCode int             `json:"statusCode"`
+	Response   json.RawMessage `json:"response,omitempty"`
+	RanAt      int64           `json:"ranAt"`
+}
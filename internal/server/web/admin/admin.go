@@ -11,9 +11,14 @@ import (
 
 	"github.com/bricks-cloud/bricksllm/internal/event"
 	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/logger"
+	"github.com/bricks-cloud/bricksllm/internal/pause"
 	"github.com/bricks-cloud/bricksllm/internal/provider"
 	"github.com/bricks-cloud/bricksllm/internal/provider/custom"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/server/web"
 	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/bricks-cloud/bricksllm/internal/watch"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -27,6 +32,10 @@ type ProviderSettingsManager interface {
 	UpdateSetting(id string, setting *provider.UpdateSetting) (*provider.Setting, error)
 	GetSetting(id string) (*provider.Setting, error)
 	GetSettings(ids []string) ([]*provider.Setting, error)
+	GetQuota(id string) (*provider.Quota, error)
+	GetHealths(ids []string) ([]*provider.Health, error)
+	GetHealth(id string) (*provider.Health, error)
+	ResetHealth(id string) error
 }
 
 type KeyManager interface {
@@ -34,12 +43,25 @@ type KeyManager interface {
 	UpdateKey(id string, key *key.UpdateKey) (*key.ResponseKey, error)
 	CreateKey(key *key.RequestKey) (*key.ResponseKey, error)
 	DeleteKey(id string) error
+	CloneKey(id string, cl *key.Clone) (*key.ResponseKey, error)
+	SimulateLimits(id string, s *key.Simulation) (*key.SimulationReport, error)
 }
 
 type KeyReportingManager interface {
 	GetKeyReporting(keyId string) (*key.KeyReporting, error)
 	GetEvents(customId string, keyIds []string, start int64, end int64) ([]*event.Event, error)
 	GetEventReporting(e *event.ReportingRequest) (*event.ReportingResponse, error)
+	GetAggregatedEventReporting(r *event.AggregationRequest) (*event.AggregationResponse, error)
+	GetChargebackReport(r *event.ChargebackRequest) (*event.ChargebackReport, error)
+	GetSpendForecast(r *event.ForecastRequest) (*event.ForecastReport, error)
+	GetTopReporting(r *event.TopRequest) (*event.TopResponse, error)
+	GetUsage(r *event.UsageRequest) (*event.UsageResponse, error)
+	SearchEvents(r *event.EventSearchRequest) (*event.EventSearchResponse, error)
+	GetRouteSloReport(routeId string) (*route.SloReport, error)
+	GetSessionTimeline(tenantId, sessionId, keyId string) (*event.SessionTimeline, error)
+	GetTaskReport(tenantId, requestId string) (*event.TaskReport, error)
+	GetDecisionTrail(tenantId, requestId string) (*event.DecisionTrail, error)
+	GetReconciliations(r *event.ReconciliationRequest) ([]*event.Reconciliation, error)
 }
 
 type ErrorResponse struct {
@@ -56,34 +78,152 @@ type AdminServer struct {
 	m      KeyManager
 }
 
-func NewAdminServer(log *zap.Logger, mode string, m KeyManager, krm KeyReportingManager, psm ProviderSettingsManager, cpm CustomProvidersManager, rm RouteManager, adminPass string) (*AdminServer, error) {
-	router := gin.New()
+func NewAdminServer(log *zap.Logger, mode string, m KeyManager, krm KeyReportingManager, psm ProviderSettingsManager, cpm CustomProvidersManager, rm RouteManager, em EstimationManager, pm PricingManager, tgm TagManager, nm NotificationManager, pbm ProbeManager, rcm RecomputationManager, ptm PromptTemplateManager, mm MockManager, plm PolicyManager, pthm PassThroughManager, rpm ReplayManager, rcdm RecordingManager, sjm SubjectManager, smpm SampleManager, tm TenantManager, pam PauseManager, scm ScheduleManager, cw ConfigWatcher, bdm BundleManager, ssy SpendSyncer, anm AnalyticsManager, atm AdminTokenManager, tms tenantMemStorage, ats adminTokenMemStorage, bs *Bootstrap, ag *AuthGuard, ll LogLevel, adminPass string, trustedProxies []string) (*AdminServer, error) {
+	router, err := web.NewRouter(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	locks := &resourceLocks{}
 
 	prod := mode == "production"
-	router.Use(getAdminLoggerMiddleware(log, "admin", prod, adminPass))
+	router.Use(getAdminLoggerMiddleware(log, "admin", prod, adminPass, tms, ats, bs, ag))
 
 	router.GET("/api/health", getGetHealthCheckHandler())
 
 	router.GET("/api/key-management/keys", getGetKeysHandler(m, log, prod))
 	router.PUT("/api/key-management/keys", getCreateKeyHandler(m, log, prod))
-	router.PATCH("/api/key-management/keys/:id", getUpdateKeyHandler(m, log, prod))
-	router.DELETE("/api/key-management/keys/:id", getDeleteKeyHandler(m, log, prod))
-
-	router.GET("/api/reporting/keys/:id", getGetKeyReportingHandler(krm, log, prod))
-	router.POST("/api/reporting/events", getGetEventMetricsHandler(krm, log, prod))
+	router.GET("/api/key-management/keys/:id", getKeyOfTenantMiddleware(m, log, prod), getGetKeyHandler(m, log, prod))
+	router.PATCH("/api/key-management/keys/:id", getKeyOfTenantMiddleware(m, log, prod), getUpdateKeyHandler(m, locks, log, prod))
+	router.DELETE("/api/key-management/keys/:id", getKeyOfTenantMiddleware(m, log, prod), getDeleteKeyHandler(m, cw, log, prod))
+	router.POST("/api/key-management/keys/:id/clone", getKeyOfTenantMiddleware(m, log, prod), getCloneKeyHandler(m, log, prod))
+	router.POST("/api/key-management/keys/:id/limit-simulation", getKeyOfTenantMiddleware(m, log, prod), getSimulateLimitsHandler(m, log, prod))
+
+	router.GET("/api/reporting/keys/:id", getKeyOfTenantMiddleware(m, log, prod), getGetKeyReportingHandler(krm, log, prod))
+	router.GET("/api/reporting/keys/:id/v1/usage", getKeyOfTenantMiddleware(m, log, prod), getGetUsageHandler(krm, log, prod))
+	router.POST("/api/reporting/events", superAdminOnly, getGetEventMetricsHandler(krm, log, prod))
+	router.POST("/api/reporting/aggregations", getGetAggregatedEventReportingHandler(krm, log, prod))
+	router.GET("/api/reporting/chargeback", getGetChargebackReportHandler(krm, log, prod))
+	router.GET("/api/reporting/forecast", getGetSpendForecastHandler(krm, log, prod))
+	router.GET("/api/reporting/top/:metric", getGetTopReportingHandler(krm, log, prod))
+	router.GET("/api/reporting/reconciliations", getGetReconciliationsHandler(krm, log, prod))
+	router.POST("/api/reporting/simulations", getSimulateSpendHandler(rcm, log, prod))
+	router.POST("/api/reporting/questions", getAskAnalyticsQuestionHandler(anm, log, prod))
+	router.GET("/api/reporting/routes/:id/slo", getRouteOfTenantMiddleware(rm, log, prod), getGetRouteSloReportHandler(krm, log, prod))
 	router.GET("/api/events", getGetEventsHandler(krm, log, prod))
+	router.POST("/api/events/search", getSearchEventsHandler(krm, log, prod))
+	router.GET("/api/sessions/:id", getGetSessionTimelineHandler(krm, log, prod))
+	router.GET("/api/tasks/:id", getGetTaskReportHandler(krm, log, prod))
+	router.GET("/api/events/:id/decisions", getGetDecisionTrailHandler(krm, log, prod))
+
+	router.GET("/api/log-level", superAdminOnly, getGetLogLevelHandler(ll))
+	router.PUT("/api/log-level", superAdminOnly, getSetLogLevelHandler(ll, log, prod))
 
 	router.PUT("/api/provider-settings", getCreateProviderSettingHandler(psm, log, prod))
 	router.GET("/api/provider-settings", getGetProviderSettingsHandler(psm, log, prod))
-	router.PATCH("/api/provider-settings/:id", getUpdateProviderSettingHandler(psm, log, prod))
+	router.GET("/api/provider-settings/:id", getSettingOfTenantMiddleware(psm, log, prod), getGetProviderSettingHandler(psm, log, prod))
+	router.PATCH("/api/provider-settings/:id", getSettingOfTenantMiddleware(psm, log, prod), getUpdateProviderSettingHandler(psm, locks, log, prod))
+	router.GET("/api/provider-settings/:id/quota", getSettingOfTenantMiddleware(psm, log, prod), getGetProviderQuotaHandler(psm, log, prod))
+	router.GET("/api/provider-settings/:id/health", getSettingOfTenantMiddleware(psm, log, prod), getGetProviderHealthHandler(psm, log, prod))
+	router.DELETE("/api/provider-settings/:id/health", getSettingOfTenantMiddleware(psm, log, prod), getResetProviderHealthHandler(psm, log, prod))
+	router.GET("/api/provider-health", getGetProviderHealthsHandler(psm, log, prod))
 
-	router.POST("/api/custom/providers", getCreateCustomProviderHandler(cpm, log, prod))
-	router.GET("/api/custom/providers", getGetCustomProvidersHandler(cpm, log, prod))
-	router.PATCH("/api/custom/providers/:id", getUpdateCustomProvidersHandler(cpm, log, prod))
+	router.POST("/api/custom/providers", superAdminOnly, getCreateCustomProviderHandler(cpm, log, prod))
+	router.GET("/api/custom/providers", superAdminOnly, getGetCustomProvidersHandler(cpm, log, prod))
+	router.PATCH("/api/custom/providers/:id", superAdminOnly, getUpdateCustomProvidersHandler(cpm, log, prod))
 
 	router.POST("/api/routes", getCreateRouteHandler(rm, log, prod))
-	router.GET("/api/routes/:id", getGetRouteHandler(rm, log, prod))
+	router.GET("/api/routes/:id", getRouteOfTenantMiddleware(rm, log, prod), getGetRouteHandler(rm, log, prod))
 	router.GET("/api/routes", getGetRoutesHandler(rm, log, prod))
+	router.GET("/api/routes/:id/shadow-results", getRouteOfTenantMiddleware(rm, log, prod), getGetShadowResultsHandler(rm, log, prod))
+	router.POST("/api/routes/:id/clone", getRouteOfTenantMiddleware(rm, log, prod), getCloneRouteHandler(rm, log, prod))
+
+	router.POST("/api/cost/estimate", getEstimateCostHandler(em, log, prod))
+
+	router.POST("/api/pricing", superAdminOnly, getCreatePriceHandler(pm, log, prod))
+	router.GET("/api/pricing", getGetPricesHandler(pm, log, prod))
+	router.PATCH("/api/pricing/:id", superAdminOnly, getUpdatePriceHandler(pm, log, prod))
+	router.DELETE("/api/pricing/:id", superAdminOnly, getDeletePriceHandler(pm, log, prod))
+
+	router.POST("/api/tags", superAdminOnly, getCreateTagHandler(tgm, log, prod))
+	router.GET("/api/tags", getGetTagsHandler(tgm, log, prod))
+	router.PATCH("/api/tags/:name", superAdminOnly, getUpdateTagHandler(tgm, log, prod))
+	router.DELETE("/api/tags/:name", superAdminOnly, getDeleteTagHandler(tgm, log, prod))
+
+	router.POST("/api/notification-integrations", superAdminOnly, getCreateIntegrationHandler(nm, log, prod))
+	router.GET("/api/notification-integrations", superAdminOnly, getGetIntegrationsHandler(nm, log, prod))
+	router.PATCH("/api/notification-integrations/:id", superAdminOnly, getUpdateIntegrationHandler(nm, log, prod))
+	router.DELETE("/api/notification-integrations/:id", superAdminOnly, getDeleteIntegrationHandler(nm, log, prod))
+	router.POST("/api/notification-integrations/:id/test", superAdminOnly, getTestIntegrationHandler(nm, log, prod))
+
+	router.POST("/api/probes", superAdminOnly, getCreateProbeHandler(pbm, log, prod))
+	router.GET("/api/probes", superAdminOnly, getGetProbesHandler(pbm, log, prod))
+	router.DELETE("/api/probes/:id", superAdminOnly, getDeleteProbeHandler(pbm, log, prod))
+	router.GET("/api/probes/:id/results", superAdminOnly, getGetProbeResultsHandler(pbm, log, prod))
+
+	router.POST("/api/jobs/spend-recomputation", superAdminOnly, getRecomputeSpendHandler(rcm, log, prod))
+	router.POST("/api/events/:id/replay", getReplayEventHandler(rpm, log, prod))
+	router.DELETE("/api/recorded-requests/encryption-key", getShredRecordingsHandler(rcdm, log, prod))
+
+	router.POST("/api/subject-requests", getCreateSubjectRequestHandler(sjm, log, prod))
+	router.GET("/api/subject-requests/:id", getGetSubjectRequestHandler(sjm, log, prod))
+	router.GET("/api/subject-requests/:id/export", getGetSubjectExportHandler(sjm, log, prod))
+
+	router.GET("/api/samples/export", getExportSamplesHandler(smpm, log, prod))
+
+	router.POST("/api/prompt-templates", superAdminOnly, getCreatePromptTemplateHandler(ptm, log, prod))
+	router.GET("/api/prompt-templates", getGetPromptTemplatesHandler(ptm, log, prod))
+	router.GET("/api/prompt-templates/:name", getGetPromptTemplateVersionsHandler(ptm, log, prod))
+	router.GET("/api/prompt-templates/:name/versions/:version", getGetPromptTemplateVersionHandler(ptm, log, prod))
+	router.DELETE("/api/prompt-templates/:name", superAdminOnly, getDeletePromptTemplateHandler(ptm, log, prod))
+
+	router.POST("/api/mock-responses", superAdminOnly, getCreateMockResponseHandler(mm, log, prod))
+	router.GET("/api/mock-responses", superAdminOnly, getGetMockResponsesHandler(mm, log, prod))
+	router.PATCH("/api/mock-responses/:id", superAdminOnly, getUpdateMockResponseHandler(mm, log, prod))
+	router.DELETE("/api/mock-responses/:id", superAdminOnly, getDeleteMockResponseHandler(mm, log, prod))
+
+	router.POST("/api/policies", superAdminOnly, getCreatePolicyHandler(plm, log, prod))
+	router.GET("/api/policies", superAdminOnly, getGetPoliciesHandler(plm, log, prod))
+	router.PATCH("/api/policies/:id", superAdminOnly, getUpdatePolicyHandler(plm, log, prod))
+	router.DELETE("/api/policies/:id", superAdminOnly, getDeletePolicyHandler(plm, log, prod))
+
+	router.POST("/api/pass-throughs", superAdminOnly, getCreatePassThroughHandler(pthm, log, prod))
+	router.GET("/api/pass-throughs", superAdminOnly, getGetPassThroughsHandler(pthm, log, prod))
+	router.PATCH("/api/pass-throughs/:id", superAdminOnly, getUpdatePassThroughHandler(pthm, log, prod))
+	router.DELETE("/api/pass-throughs/:id", superAdminOnly, getDeletePassThroughHandler(pthm, log, prod))
+
+	router.POST("/api/tenants", superAdminOnly, getCreateTenantHandler(tm, log, prod))
+	router.GET("/api/tenants", superAdminOnly, getGetTenantsHandler(tm, log, prod))
+	router.GET("/api/tenants/:id", superAdminOnly, getGetTenantHandler(tm, log, prod))
+	router.PATCH("/api/tenants/:id", superAdminOnly, getUpdateTenantHandler(tm, log, prod))
+
+	router.GET("/api/watch", getWatchHandler(cw, log, prod))
+
+	router.PUT("/api/bundles/:name", getApplyBundleHandler(bdm, log, prod))
+	router.GET("/api/bundles/:name", getGetBundleStatusHandler(bdm, log, prod))
+	router.GET("/api/config/export", superAdminOnly, getExportSnapshotHandler(bdm, log, prod))
+	router.POST("/api/config/import", superAdminOnly, getImportSnapshotHandler(bdm, log, prod))
+
+	router.GET("/api/spend/counters", superAdminOnly, getGetSpendCountersHandler(ssy, log, prod))
+
+	router.POST("/api/admin-tokens", superAdminOnly, getCreateAdminTokenHandler(atm, log, prod))
+	router.GET("/api/admin-tokens", superAdminOnly, getGetAdminTokensHandler(atm, log, prod))
+	router.PATCH("/api/admin-tokens/:id", superAdminOnly, getUpdateAdminTokenHandler(atm, log, prod))
+
+	router.GET("/api/pauses", getGetPausesHandler(pam, log, prod))
+	router.PUT("/api/pauses/global", superAdminOnly, getPauseHandler(pam, pause.ScopeGlobal, "/api/pauses/global", log, prod))
+	router.DELETE("/api/pauses/global", superAdminOnly, getResumeHandler(pam, pause.ScopeGlobal, "/api/pauses/global", log, prod))
+	router.PUT("/api/pauses/providers/:provider", superAdminOnly, getPauseHandler(pam, pause.ScopeProvider, "/api/pauses/providers/:provider", log, prod))
+	router.DELETE("/api/pauses/providers/:provider", superAdminOnly, getResumeHandler(pam, pause.ScopeProvider, "/api/pauses/providers/:provider", log, prod))
+	router.PUT("/api/pauses/routes/:id", getRouteOfTenantMiddleware(rm, log, prod), getPauseHandler(pam, pause.ScopeRoute, "/api/pauses/routes/:id", log, prod))
+	router.DELETE("/api/pauses/routes/:id", getRouteOfTenantMiddleware(rm, log, prod), getResumeHandler(pam, pause.ScopeRoute, "/api/pauses/routes/:id", log, prod))
+
+	router.POST("/api/schedules", getCreateScheduleHandler(scm, log, prod))
+	router.GET("/api/schedules", getGetSchedulesHandler(scm, log, prod))
+	router.GET("/api/schedules/:id", getGetScheduleHandler(scm, log, prod))
+	router.PATCH("/api/schedules/:id", getUpdateScheduleHandler(scm, log, prod))
+	router.DELETE("/api/schedules/:id", getDeleteScheduleHandler(scm, log, prod))
+	router.GET("/api/schedules/:id/runs", getGetScheduleRunsHandler(scm, log, prod))
 
 	srv := &http.Server{
 		Addr:    ":8001",
@@ -103,18 +243,111 @@ func (as *AdminServer) Run() {
 		as.log.Info("PORT 8001 | GET   | /api/health is set up for health checking the admin server")
 		as.log.Info("PORT 8001 | GET   | /api/key-management/keys is set up for retrieving keys using a query param called tag")
 		as.log.Info("PORT 8001 | PUT   | /api/key-management/keys is set up for creating a key")
+		as.log.Info("PORT 8001 | GET   | /api/key-management/keys/:id is set up for retrieving a key using an id")
 		as.log.Info("PORT 8001 | PATCH | /api/key-management/keys/:id is set up for updating a key using an id")
+		as.log.Info("PORT 8001 | POST  | /api/key-management/keys/:id/clone is set up for cloning a key into a tenant")
+		as.log.Info("PORT 8001 | POST  | /api/key-management/keys/:id/limit-simulation is set up for simulating the limits of a key")
 		as.log.Info("PORT 8001 | GET   | /api/provider-settings is set up for getting provider settings")
 		as.log.Info("PORT 8001 | PUT   | /api/provider-settings is set up for creating a provider setting")
+		as.log.Info("PORT 8001 | GET   | /api/provider-settings/:id is set up for getting a provider setting")
 		as.log.Info("PORT 8001 | PATCH | /api/provider-settings:id is set up for updating provider setting")
+		as.log.Info("PORT 8001 | GET   | /api/provider-settings/:id/quota is set up for retrieving the upstream rate limit quota of a provider setting")
+		as.log.Info("PORT 8001 | GET   | /api/provider-settings/:id/health is set up for retrieving the health of a provider setting")
+		as.log.Info("PORT 8001 | DELETE | /api/provider-settings/:id/health is set up for putting an unhealthy provider setting back into use")
+		as.log.Info("PORT 8001 | GET   | /api/provider-health is set up for retrieving the health of provider settings")
 		as.log.Info("PORT 8001 | POST  | /api/reporting/events is set up for retrieving api metrics")
+		as.log.Info("PORT 8001 | POST  | /api/reporting/aggregations is set up for retrieving spend aggregated by dimensions")
+		as.log.Info("PORT 8001 | GET   | /api/reporting/chargeback is set up for retrieving monthly chargeback reports")
+		as.log.Info("PORT 8001 | GET   | /api/reporting/forecast is set up for forecasting spend of the current month")
+		as.log.Info("PORT 8001 | GET   | /api/reporting/top/:metric is set up for retrieving top keys, models and routes")
+		as.log.Info("PORT 8001 | GET   | /api/reporting/reconciliations is set up for retrieving reconciliations of recorded and provider reported spend")
+		as.log.Info("PORT 8001 | POST  | /api/reporting/simulations is set up for simulating spend under alternative models")
+		as.log.Info("PORT 8001 | POST  | /api/reporting/questions is set up for answering questions about usage through an llm route")
+		as.log.Info("PORT 8001 | GET   | /api/reporting/routes/:id/slo is set up for retrieving slo compliance of a route")
+		as.log.Info("PORT 8001 | GET   | /api/reporting/keys/:id/v1/usage is set up for retrieving key usage in the OpenAI usage format")
 		as.log.Info("PORT 8001 | GET   | /api/events is set up for retrieving events")
+		as.log.Info("PORT 8001 | POST  | /api/events/search is set up for searching events")
+		as.log.Info("PORT 8001 | GET   | /api/sessions/:id is set up for retrieving the timeline of a session")
+		as.log.Info("PORT 8001 | GET   | /api/tasks/:id is set up for retrieving the requests and the cost of an agent task")
+		as.log.Info("PORT 8001 | GET   | /api/events/:id/decisions is set up for retrieving the decision trail of a request")
+		as.log.Info("PORT 8001 | GET   | /api/log-level is set up for retrieving the log level")
+		as.log.Info("PORT 8001 | PUT   | /api/log-level is set up for changing the log level")
 		as.log.Info("PORT 8001 | POST  | /api/custom/providers is set up for creating a custom provider")
 		as.log.Info("PORT 8001 | GET   | /api/custom/providers is set up for retrieving all custom providers")
 		as.log.Info("PORT 8001 | PATCH | /api/custom/providers/:id is set up for updating a custom provider")
 		as.log.Info("PORT 8001 | POST  | /api/routes is set up for creating a custom route")
 		as.log.Info("PORT 8001 | GET   | /api/routes/:id is set up for retrieving a route")
 		as.log.Info("PORT 8001 | GET   | /api/routes is set up for retrieving routes")
+		as.log.Info("PORT 8001 | GET   | /api/routes/:id/shadow-results is set up for retrieving shadow results of a route")
+		as.log.Info("PORT 8001 | POST  | /api/routes/:id/clone is set up for cloning a route into a tenant")
+		as.log.Info("PORT 8001 | POST  | /api/cost/estimate is set up for estimating the cost of a request")
+		as.log.Info("PORT 8001 | POST  | /api/pricing is set up for creating a model price")
+		as.log.Info("PORT 8001 | GET   | /api/pricing is set up for retrieving model prices")
+		as.log.Info("PORT 8001 | PATCH | /api/pricing/:id is set up for updating a model price")
+		as.log.Info("PORT 8001 | DELETE | /api/pricing/:id is set up for deleting a model price")
+		as.log.Info("PORT 8001 | POST  | /api/tags is set up for creating a tag of the tag taxonomy")
+		as.log.Info("PORT 8001 | GET   | /api/tags is set up for retrieving the tag taxonomy")
+		as.log.Info("PORT 8001 | PATCH | /api/tags/:name is set up for updating a tag of the tag taxonomy")
+		as.log.Info("PORT 8001 | DELETE | /api/tags/:name is set up for deleting a tag of the tag taxonomy")
+		as.log.Info("PORT 8001 | POST  | /api/notification-integrations is set up for creating a notification integration")
+		as.log.Info("PORT 8001 | GET   | /api/notification-integrations is set up for retrieving notification integrations")
+		as.log.Info("PORT 8001 | PATCH | /api/notification-integrations/:id is set up for updating a notification integration")
+		as.log.Info("PORT 8001 | DELETE | /api/notification-integrations/:id is set up for deleting a notification integration")
+		as.log.Info("PORT 8001 | POST  | /api/notification-integrations/:id/test is set up for sending a test notification")
+		as.log.Info("PORT 8001 | POST  | /api/probes is set up for creating a probe")
+		as.log.Info("PORT 8001 | GET   | /api/probes is set up for retrieving probes")
+		as.log.Info("PORT 8001 | DELETE | /api/probes/:id is set up for deleting a probe")
+		as.log.Info("PORT 8001 | GET   | /api/probes/:id/results is set up for retrieving the results of a probe")
+		as.log.Info("PORT 8001 | POST  | /api/jobs/spend-recomputation is set up for recomputing the spend of stored events")
+		as.log.Info("PORT 8001 | POST  | /api/events/:id/replay is set up for replaying the recorded request of an event")
+		as.log.Info("PORT 8001 | DELETE | /api/recorded-requests/encryption-key is set up for shredding recorded requests of a tenant")
+		as.log.Info("PORT 8001 | POST  | /api/subject-requests is set up for creating a subject access or deletion request")
+		as.log.Info("PORT 8001 | GET   | /api/subject-requests/:id is set up for retrieving a subject request")
+		as.log.Info("PORT 8001 | GET   | /api/subject-requests/:id/export is set up for retrieving the export of a subject request")
+		as.log.Info("PORT 8001 | GET   | /api/samples/export is set up for exporting sampled requests as JSONL")
+		as.log.Info("PORT 8001 | POST  | /api/prompt-templates is set up for creating a prompt template version")
+		as.log.Info("PORT 8001 | GET   | /api/prompt-templates is set up for retrieving the latest version of prompt templates")
+		as.log.Info("PORT 8001 | GET   | /api/prompt-templates/:name is set up for retrieving every version of a prompt template")
+		as.log.Info("PORT 8001 | GET   | /api/prompt-templates/:name/versions/:version is set up for retrieving a prompt template version")
+		as.log.Info("PORT 8001 | DELETE | /api/prompt-templates/:name is set up for deleting a prompt template")
+		as.log.Info("PORT 8001 | POST  | /api/mock-responses is set up for creating a mock response")
+		as.log.Info("PORT 8001 | GET   | /api/mock-responses is set up for retrieving mock responses")
+		as.log.Info("PORT 8001 | PATCH | /api/mock-responses/:id is set up for updating a mock response")
+		as.log.Info("PORT 8001 | DELETE | /api/mock-responses/:id is set up for deleting a mock response")
+		as.log.Info("PORT 8001 | POST  | /api/policies is set up for creating a policy")
+		as.log.Info("PORT 8001 | GET   | /api/policies is set up for retrieving policies")
+		as.log.Info("PORT 8001 | PATCH | /api/policies/:id is set up for updating a policy")
+		as.log.Info("PORT 8001 | DELETE | /api/policies/:id is set up for deleting a policy")
+		as.log.Info("PORT 8001 | POST  | /api/pass-throughs is set up for creating a pass-through")
+		as.log.Info("PORT 8001 | GET   | /api/pass-throughs is set up for retrieving pass-throughs")
+		as.log.Info("PORT 8001 | PATCH | /api/pass-throughs/:id is set up for updating a pass-through")
+		as.log.Info("PORT 8001 | DELETE | /api/pass-throughs/:id is set up for deleting a pass-through")
+		as.log.Info("PORT 8001 | POST  | /api/tenants is set up for creating a tenant")
+		as.log.Info("PORT 8001 | GET   | /api/tenants is set up for retrieving tenants")
+		as.log.Info("PORT 8001 | GET   | /api/tenants/:id is set up for retrieving a tenant")
+		as.log.Info("PORT 8001 | PATCH | /api/tenants/:id is set up for updating a tenant")
+		as.log.Info("PORT 8001 | GET   | /api/pauses is set up for retrieving pauses")
+		as.log.Info("PORT 8001 | GET   | /api/watch is set up for streaming configuration changes")
+		as.log.Info("PORT 8001 | PUT   | /api/bundles/:name is set up for applying a bundle of keys and routes")
+		as.log.Info("PORT 8001 | GET   | /api/bundles/:name is set up for retrieving the status of a bundle")
+		as.log.Info("PORT 8001 | GET   | /api/config/export is set up for exporting a signed snapshot of the configuration")
+		as.log.Info("PORT 8001 | POST  | /api/config/import is set up for importing a signed snapshot of the configuration")
+		as.log.Info("PORT 8001 | GET   | /api/spend/counters is set up for retrieving the spend counters of the cluster")
+		as.log.Info("PORT 8001 | POST  | /api/admin-tokens is set up for creating an admin token")
+		as.log.Info("PORT 8001 | GET   | /api/admin-tokens is set up for retrieving admin tokens")
+		as.log.Info("PORT 8001 | PATCH | /api/admin-tokens/:id is set up for renaming or revoking an admin token")
+		as.log.Info("PORT 8001 | PUT   | /api/pauses/global is set up for pausing all traffic")
+		as.log.Info("PORT 8001 | DELETE | /api/pauses/global is set up for resuming all traffic")
+		as.log.Info("PORT 8001 | PUT   | /api/pauses/providers/:provider is set up for pausing traffic to a provider")
+		as.log.Info("PORT 8001 | DELETE | /api/pauses/providers/:provider is set up for resuming traffic to a provider")
+		as.log.Info("PORT 8001 | PUT   | /api/pauses/routes/:id is set up for pausing traffic to a route")
+		as.log.Info("PORT 8001 | DELETE | /api/pauses/routes/:id is set up for resuming traffic to a route")
+		as.log.Info("PORT 8001 | POST  | /api/schedules is set up for creating a schedule")
+		as.log.Info("PORT 8001 | GET   | /api/schedules is set up for retrieving schedules")
+		as.log.Info("PORT 8001 | GET   | /api/schedules/:id is set up for retrieving a schedule")
+		as.log.Info("PORT 8001 | PATCH | /api/schedules/:id is set up for updating a schedule")
+		as.log.Info("PORT 8001 | DELETE | /api/schedules/:id is set up for deleting a schedule")
+		as.log.Info("PORT 8001 | GET   | /api/schedules/:id/runs is set up for retrieving the runs of a schedule")
 
 		if err := as.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			as.log.Sugar().Fatalf("error admin server listening: %v", err)
@@ -178,6 +411,9 @@ func getGetKeysHandler(m KeyManager, log *zap.Logger, prod bool) gin.HandlerFunc
 
 		cid := c.GetString(correlationId)
 		keys, err := m.GetKeys(selected, nil, provider)
+		if err == nil {
+			keys = keysOfTenant(c.GetString(tenantIdKey), keys)
+		}
 		if err != nil {
 			stats.Incr("bricksllm.admin.get_get_keys_handler.get_keys_by_tag_err", nil, 1)
 
@@ -197,6 +433,63 @@ func getGetKeysHandler(m KeyManager, log *zap.Logger, prod bool) gin.HandlerFunc
 	}
 }
 
+func getGetKeyHandler(m KeyManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_get_key_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_get_key_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/key-management/keys/:id"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		cid := c.GetString(correlationId)
+		keys, err := m.GetKeys(nil, []string{c.Param("id")}, "")
+		if err != nil {
+			stats.Incr("bricksllm.admin.get_get_key_handler.get_keys_err", nil, 1)
+
+			logError(log, "error when getting an api key", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/getting-keys",
+				Title:    "getting key errored out",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		if len(keys) == 0 {
+			stats.Incr("bricksllm.admin.get_get_key_handler.not_found", nil, 1)
+
+			c.JSON(http.StatusNotFound, &ErrorResponse{
+				Type:     "/errors/not-found",
+				Title:    "key not found",
+				Status:   http.StatusNotFound,
+				Detail:   "key is not found",
+				Instance: path,
+			})
+			return
+		}
+
+		stats.Incr("bricksllm.admin.get_get_key_handler.success", nil, 1)
+		setETag(c, keyETag(keys[0]))
+		c.JSON(http.StatusOK, keys[0])
+	}
+}
+
 type validationError interface {
 	Error() string
 	Validation()
@@ -226,6 +519,9 @@ func getGetProviderSettingsHandler(m ProviderSettingsManager, log *zap.Logger, p
 
 		cid := c.GetString(correlationId)
 		created, err := m.GetSettings(c.QueryArray("ids"))
+		if err == nil {
+			created = settingsOfTenant(c.GetString(tenantIdKey), created)
+		}
 		if err != nil {
 			errType := "internal"
 
@@ -252,6 +548,63 @@ func getGetProviderSettingsHandler(m ProviderSettingsManager, log *zap.Logger, p
 	}
 }
 
+func getGetProviderSettingHandler(m ProviderSettingsManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_get_provider_setting.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_get_provider_setting.latency", dur, nil, 1)
+		}()
+
+		path := "/api/provider-settings/:id"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		cid := c.GetString(correlationId)
+		settings, err := m.GetSettings([]string{c.Param("id")})
+		if err != nil {
+			stats.Incr("bricksllm.admin.get_get_provider_setting.get_settings_error", nil, 1)
+
+			logError(log, "error when getting a provider setting", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/provider-settings-manager",
+				Title:    "get provider setting failed",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		if len(settings) == 0 {
+			stats.Incr("bricksllm.admin.get_get_provider_setting.not_found", nil, 1)
+
+			c.JSON(http.StatusNotFound, &ErrorResponse{
+				Type:     "/errors/not-found",
+				Title:    "provider setting not found",
+				Status:   http.StatusNotFound,
+				Detail:   "provider setting is not found",
+				Instance: path,
+			})
+			return
+		}
+
+		stats.Incr("bricksllm.admin.get_get_provider_setting.success", nil, 1)
+		setETag(c, settingETag(settings[0]))
+		c.JSON(http.StatusOK, settings[0])
+	}
+}
+
 func getCreateProviderSettingHandler(m ProviderSettingsManager, log *zap.Logger, prod bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		stats.Incr("bricksllm.admin.get_create_provider_setting_handler.requests", nil, 1)
@@ -302,6 +655,10 @@ func getCreateProviderSettingHandler(m ProviderSettingsManager, log *zap.Logger,
 			return
 		}
 
+		if tenantId := c.GetString(tenantIdKey); len(tenantId) != 0 {
+			setting.TenantId = tenantId
+		}
+
 		created, err := m.CreateSetting(setting)
 		if err != nil {
 			errType := "internal"
@@ -325,6 +682,12 @@ func getCreateProviderSettingHandler(m ProviderSettingsManager, log *zap.Logger,
 				return
 			}
 
+			if _, ok := err.(conflictError); ok {
+				errType = "conflict"
+				abortWithConflict(c, path, err)
+				return
+			}
+
 			logError(log, "error when creating a provider setting", prod, cid, err)
 			c.JSON(http.StatusInternalServerError, &ErrorResponse{
 				Type:     "/errors/provider-settings-manager",
@@ -338,6 +701,7 @@ func getCreateProviderSettingHandler(m ProviderSettingsManager, log *zap.Logger,
 
 		stats.Incr("bricksllm.admin.get_create_provider_setting_handler.success", nil, 1)
 
+		setETag(c, settingETag(created))
 		c.JSON(http.StatusOK, created)
 	}
 }
@@ -392,6 +756,10 @@ func getCreateKeyHandler(m KeyManager, log *zap.Logger, prod bool) gin.HandlerFu
 			return
 		}
 
+		if tenantId := c.GetString(tenantIdKey); len(tenantId) != 0 {
+			rk.TenantId = tenantId
+		}
+
 		resk, err := m.CreateKey(rk)
 		if err != nil {
 			errType := "internal"
@@ -415,6 +783,12 @@ func getCreateKeyHandler(m KeyManager, log *zap.Logger, prod bool) gin.HandlerFu
 				return
 			}
 
+			if _, ok := err.(conflictError); ok {
+				errType = "conflict"
+				abortWithConflict(c, path, err)
+				return
+			}
+
 			logError(log, "error when creating api key", prod, id, err)
 			c.JSON(http.StatusInternalServerError, &ErrorResponse{
 				Type:     "/errors/key-manager",
@@ -428,11 +802,12 @@ func getCreateKeyHandler(m KeyManager, log *zap.Logger, prod bool) gin.HandlerFu
 
 		stats.Incr("bricksllm.admin.get_create_key_handler.success", nil, 1)
 
+		setETag(c, keyETag(resk))
 		c.JSON(http.StatusOK, resk)
 	}
 }
 
-func getUpdateProviderSettingHandler(m ProviderSettingsManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+func getUpdateProviderSettingHandler(m ProviderSettingsManager, locks *resourceLocks, log *zap.Logger, prod bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		stats.Incr("bricksllm.admin.get_update_provider_setting_handler.requests", nil, 1)
 
@@ -483,6 +858,18 @@ func getUpdateProviderSettingHandler(m ProviderSettingsManager, log *zap.Logger,
 			return
 		}
 
+		unlock := locks.lock("setting:" + id)
+		defer unlock()
+
+		if len(c.GetHeader("If-Match")) != 0 {
+			existing, err := m.GetSettings([]string{id})
+			if err != nil || len(existing) == 0 || !matchesIfMatch(c, settingETag(existing[0])) {
+				stats.Incr("bricksllm.admin.get_update_provider_setting_handler.precondition_failed", nil, 1)
+				abortWithPreconditionFailed(c, path, "provider setting")
+				return
+			}
+		}
+
 		updated, err := m.UpdateSetting(id, setting)
 		if err != nil {
 			errType := "internal"
@@ -530,11 +917,12 @@ func getUpdateProviderSettingHandler(m ProviderSettingsManager, log *zap.Logger,
 
 		stats.Incr("bricksllm.admin.get_update_provider_setting_handler.success", nil, 1)
 
+		setETag(c, settingETag(updated))
 		c.JSON(http.StatusOK, updated)
 	}
 }
 
-func getUpdateKeyHandler(m KeyManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+func getUpdateKeyHandler(m KeyManager, locks *resourceLocks, log *zap.Logger, prod bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		stats.Incr("bricksllm.admin.get_update_key_handler.requests", nil, 1)
 
@@ -597,6 +985,30 @@ func getUpdateKeyHandler(m KeyManager, log *zap.Logger, prod bool) gin.HandlerFu
 			return
 		}
 
+		unlock := locks.lock("key:" + id)
+		defer unlock()
+
+		if len(c.GetHeader("If-Match")) != 0 {
+			existing, err := m.GetKeys(nil, []string{id}, "")
+			if err != nil {
+				logError(log, "error when getting api key", prod, cid, err)
+				c.JSON(http.StatusInternalServerError, &ErrorResponse{
+					Type:     "/errors/key-manager",
+					Title:    "update key error",
+					Status:   http.StatusInternalServerError,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			if len(existing) == 0 || !matchesIfMatch(c, keyETag(existing[0])) {
+				stats.Incr("bricksllm.admin.get_update_key_handler.precondition_failed", nil, 1)
+				abortWithPreconditionFailed(c, path, "key")
+				return
+			}
+		}
+
 		resk, err := m.UpdateKey(id, uk)
 		if err != nil {
 			errType := "internal"
@@ -643,11 +1055,12 @@ func getUpdateKeyHandler(m KeyManager, log *zap.Logger, prod bool) gin.HandlerFu
 
 		stats.Incr("bricksllm.admin.get_update_key_handler.success", nil, 1)
 
+		setETag(c, keyETag(resk))
 		c.JSON(http.StatusOK, resk)
 	}
 }
 
-func getDeleteKeyHandler(m KeyManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+func getDeleteKeyHandler(m KeyManager, cw ConfigWatcher, log *zap.Logger, prod bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		path := "/api/key-management/keys/:id"
 		if c == nil || c.Request == nil {
@@ -675,6 +1088,11 @@ func getDeleteKeyHandler(m KeyManager, log *zap.Logger, prod bool) gin.HandlerFu
 			return
 		}
 
+		tenantId := c.GetString(tenantIdKey)
+		if existing, err := m.GetKeys(nil, []string{id}, ""); err == nil && len(existing) != 0 {
+			tenantId = existing[0].TenantId
+		}
+
 		err := m.DeleteKey(id)
 		if err != nil {
 			logError(log, "error when deleting api key", prod, cid, err)
@@ -688,6 +1106,14 @@ func getDeleteKeyHandler(m KeyManager, log *zap.Logger, prod bool) gin.HandlerFu
 			return
 		}
 
+		cw.Notify(&watch.Change{
+			Resource:  watch.ResourceKey,
+			Action:    watch.ActionDeleted,
+			Id:        id,
+			TenantId:  tenantId,
+			UpdatedAt: time.Now().Unix(),
+		})
+
 		c.Status(http.StatusOK)
 	}
 }
@@ -896,6 +1322,9 @@ func getGetEventsHandler(m KeyReportingManager, log *zap.Logger, prod bool) gin.
 		}
 
 		evs, err := m.GetEvents(customId, keyIds, qstart, qend)
+		if err == nil {
+			evs = eventsOfTenant(c.GetString(tenantIdKey), evs)
+		}
 		if err != nil {
 			stats.Incr("bricksllm.admin.get_get_events_handler.get_events_error", nil, 1)
 
@@ -1239,9 +1668,9 @@ func getUpdateCustomProvidersHandler(m CustomProvidersManager, log *zap.Logger,
 
 func logError(log *zap.Logger, msg string, prod bool, id string, err error) {
 	if prod {
-		log.Debug(msg, zap.String(correlationId, id), zap.Error(err))
+		log.Debug(msg, zap.String(logger.FieldRequestId, id), zap.Error(err))
 		return
 	}
 
-	log.Sugar().Debugf("correlationId:%s | %s | %v", id, msg, err)
+	log.Sugar().Debugf("request_id:%s | %s | %v", id, msg, err)
 }
@@ -0,0 +1,284 @@
+package admin
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/admintoken"
+	"github.com/bricks-cloud/bricksllm/internal/encrypter"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type AdminTokenManager interface {
+	CreateAdminToken(t *admintoken.Token) (*admintoken.Token, error)
+	GetAdminTokens() ([]*admintoken.Token, error)
+	UpdateAdminToken(id string, ut *admintoken.UpdateToken) (*admintoken.Token, error)
+}
+
+type adminTokenMemStorage interface {
+	GetTokenByHash(hash string) *admintoken.Token
+	HasTokens() bool
+}
+
+// Bootstrap accepts a one-time token for creating the first admin token.
+// The token stops working as soon as any admin token exists.
+type Bootstrap struct {
+	hash string
+	ats  adminTokenMemStorage
+}
+
+func NewBootstrap(token string, ats adminTokenMemStorage) *Bootstrap {
+	return &Bootstrap{
+		hash: encrypter.Encrypt(token),
+		ats:  ats,
+	}
+}
+
+func (b *Bootstrap) active() bool {
+	return b != nil && !b.ats.HasTokens()
+}
+
+func (b *Bootstrap) matches(token string) bool {
+	return b.active() && len(token) != 0 && encrypter.Encrypt(token) == b.hash
+}
+
+// allowsBootstrap reports whether a request authenticated with the bootstrap
+// token only creates the first admin token.
+func allowsBootstrap(c *gin.Context) bool {
+	return c.Request.Method == http.MethodPost && c.FullPath() == "/api/admin-tokens"
+}
+
+func getCreateAdminTokenHandler(m AdminTokenManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_create_admin_token_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_create_admin_token_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/admin-tokens"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		cid := c.GetString(correlationId)
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			logError(log, "error when reading create an admin token request body", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/request-body-read",
+				Title:    "request body reader error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		t := &admintoken.Token{}
+		err = json.Unmarshal(data, t)
+		if err != nil {
+			logError(log, "error when unmarshalling create an admin token request body", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/json-unmarshal",
+				Title:    "json unmarshaller error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		created, err := m.CreateAdminToken(t)
+		if err != nil {
+			errType := "internal"
+
+			defer func() {
+				stats.Incr("bricksllm.admin.get_create_admin_token_handler.create_admin_token_error", []string{
+					"error_type:" + errType,
+				}, 1)
+			}()
+
+			if _, ok := err.(validationError); ok {
+				errType = "validation"
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "admin token validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			logError(log, "error when creating an admin token", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/admin-token-manager",
+				Title:    "creating an admin token error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		stats.Incr("bricksllm.admin.get_create_admin_token_handler.success", nil, 1)
+		c.JSON(http.StatusOK, created)
+	}
+}
+
+func getGetAdminTokensHandler(m AdminTokenManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_get_admin_tokens_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_get_admin_tokens_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/admin-tokens"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		cid := c.GetString(correlationId)
+		tokens, err := m.GetAdminTokens()
+		if err != nil {
+			stats.Incr("bricksllm.admin.get_get_admin_tokens_handler.get_admin_tokens_error", nil, 1)
+
+			logError(log, "error when getting admin tokens", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/admin-token-manager",
+				Title:    "getting admin tokens error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		stats.Incr("bricksllm.admin.get_get_admin_tokens_handler.success", nil, 1)
+		c.JSON(http.StatusOK, tokens)
+	}
+}
+
+func getUpdateAdminTokenHandler(m AdminTokenManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_update_admin_token_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_update_admin_token_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/admin-tokens/:id"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		cid := c.GetString(correlationId)
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			logError(log, "error when reading update an admin token request body", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/request-body-read",
+				Title:    "request body reader error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		ut := &admintoken.UpdateToken{}
+		err = json.Unmarshal(data, ut)
+		if err != nil {
+			logError(log, "error when unmarshalling update an admin token request body", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/json-unmarshal",
+				Title:    "json unmarshaller error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		updated, err := m.UpdateAdminToken(c.Param("id"), ut)
+		if err != nil {
+			errType := "internal"
+
+			defer func() {
+				stats.Incr("bricksllm.admin.get_update_admin_token_handler.update_admin_token_error", []string{
+					"error_type:" + errType,
+				}, 1)
+			}()
+
+			if _, ok := err.(validationError); ok {
+				errType = "validation"
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "admin token validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			if _, ok := err.(notFoundError); ok {
+				errType = "not_found"
+				c.JSON(http.StatusNotFound, &ErrorResponse{
+					Type:     "/errors/not-found",
+					Title:    "admin token not found",
+					Status:   http.StatusNotFound,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			logError(log, "error when updating an admin token", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/admin-token-manager",
+				Title:    "updating an admin token error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		stats.Incr("bricksllm.admin.get_update_admin_token_handler.success", nil, 1)
+		c.JSON(http.StatusOK, updated)
+	}
+}
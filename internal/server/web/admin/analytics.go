@@ -0,0 +1,118 @@
+package admin
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type AnalyticsManager interface {
+	Ask(q *event.AnalyticsQuestion) (*event.AnalyticsAnswer, error)
+}
+
+func getAskAnalyticsQuestionHandler(m AnalyticsManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_ask_analytics_question_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_ask_analytics_question_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/reporting/questions"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		cid := c.GetString(correlationId)
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			logError(log, "error when reading analytics question request body", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/request-body-read",
+				Title:    "request body reader error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		q := &event.AnalyticsQuestion{}
+		err = json.Unmarshal(data, q)
+		if err != nil {
+			logError(log, "error when unmarshalling analytics question request body", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/json-unmarshal",
+				Title:    "json unmarshaller error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		q.TenantId = c.GetString(tenantIdKey)
+		answer, err := m.Ask(q)
+		if err != nil {
+			errType := "internal"
+
+			defer func() {
+				stats.Incr("bricksllm.admin.get_ask_analytics_question_handler.ask_error", []string{
+					"error_type:" + errType,
+				}, 1)
+			}()
+
+			if _, ok := err.(validationError); ok {
+				errType = "validation"
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "analytics question validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			if _, ok := err.(unavailableError); ok {
+				errType = "route"
+				c.JSON(http.StatusBadGateway, &ErrorResponse{
+					Type:     "/errors/analytics-route",
+					Title:    "analytics route failed to answer",
+					Status:   http.StatusBadGateway,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			logError(log, "error when answering analytics question", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/analytics-manager",
+				Title:    "analytics question error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		stats.Incr("bricksllm.admin.get_ask_analytics_question_handler.success", nil, 1)
+		c.JSON(http.StatusOK, answer)
+	}
+}
@@ -0,0 +1,158 @@
+package admin
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type adminAuthStorage interface {
+	IncrementRequests(client string) (int64, error)
+	IncrementFailures(client string) (int64, error)
+	ResetFailures(client string) error
+	SetLockout(client string, d time.Duration) error
+	GetLockout(client string) (time.Duration, error)
+}
+
+// AuthGuard rate limits the admin API per client ip and locks clients out
+// after repeated failed authentication attempts. Lockouts double with every
+// failed attempt past MaxFailedAttempts up to MaxLockout. Storage errors let
+// requests through so that redis outages do not lock operators out.
+type AuthGuard struct {
+	s                 adminAuthStorage
+	requestsPerMinute int64
+	maxFailedAttempts int64
+	lockout           time.Duration
+	maxLockout        time.Duration
+}
+
+func NewAuthGuard(s adminAuthStorage, requestsPerMinute, maxFailedAttempts int, lockout, maxLockout time.Duration) *AuthGuard {
+	return &AuthGuard{
+		s:                 s,
+		requestsPerMinute: int64(requestsPerMinute),
+		maxFailedAttempts: int64(maxFailedAttempts),
+		lockout:           lockout,
+		maxLockout:        maxLockout,
+	}
+}
+
+// getLockoutDuration returns the lockout following a number of failed
+// attempts.
+func (ag *AuthGuard) getLockoutDuration(failures int64) time.Duration {
+	if ag.maxFailedAttempts <= 0 || failures < ag.maxFailedAttempts {
+		return 0
+	}
+
+	d := float64(ag.lockout) * math.Pow(2, float64(failures-ag.maxFailedAttempts))
+	if d > float64(ag.maxLockout) {
+		return ag.maxLockout
+	}
+
+	return time.Duration(d)
+}
+
+// allow rejects clients that are rate limited or locked out.
+func (ag *AuthGuard) allow(c *gin.Context, log *zap.Logger) bool {
+	if ag == nil {
+		return true
+	}
+
+	client := c.ClientIP()
+
+	if ag.requestsPerMinute > 0 {
+		count, err := ag.s.IncrementRequests(client)
+		if err != nil {
+			stats.Incr("bricksllm.admin.auth_guard.increment_requests_error", nil, 1)
+			log.Debug("error when incrementing admin requests", zap.Error(err))
+		}
+
+		if err == nil && count > ag.requestsPerMinute {
+			stats.Incr("bricksllm.admin.auth_guard.rate_limited", nil, 1)
+			c.Header("Retry-After", "60")
+			c.Status(http.StatusTooManyRequests)
+			return false
+		}
+	}
+
+	if ag.maxFailedAttempts <= 0 {
+		return true
+	}
+
+	left, err := ag.s.GetLockout(client)
+	if err != nil {
+		stats.Incr("bricksllm.admin.auth_guard.get_lockout_error", nil, 1)
+		log.Debug("error when getting admin lockout", zap.Error(err))
+		return true
+	}
+
+	if left > 0 {
+		stats.Incr("bricksllm.admin.auth_guard.locked_out", nil, 1)
+		audit(log, "admin_request_locked_out", c, zap.Duration("lockoutLeft", left))
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(left.Seconds()))))
+		c.Status(http.StatusTooManyRequests)
+		return false
+	}
+
+	return true
+}
+
+func (ag *AuthGuard) recordFailure(c *gin.Context, log *zap.Logger) {
+	if ag == nil || ag.maxFailedAttempts <= 0 {
+		audit(log, "admin_authentication_failed", c)
+		return
+	}
+
+	client := c.ClientIP()
+	failures, err := ag.s.IncrementFailures(client)
+	if err != nil {
+		stats.Incr("bricksllm.admin.auth_guard.increment_failures_error", nil, 1)
+		log.Debug("error when incrementing admin authentication failures", zap.Error(err))
+	}
+
+	stats.Incr("bricksllm.admin.auth_guard.authentication_failed", nil, 1)
+	audit(log, "admin_authentication_failed", c, zap.Int64("failedAttempts", failures))
+
+	d := ag.getLockoutDuration(failures)
+	if d == 0 {
+		return
+	}
+
+	if err := ag.s.SetLockout(client, d); err != nil {
+		stats.Incr("bricksllm.admin.auth_guard.set_lockout_error", nil, 1)
+		log.Debug("error when setting admin lockout", zap.Error(err))
+		return
+	}
+
+	stats.Incr("bricksllm.admin.auth_guard.lockout", nil, 1)
+	audit(log, "admin_client_locked_out", c, zap.Int64("failedAttempts", failures), zap.Duration("lockout", d))
+}
+
+func (ag *AuthGuard) recordSuccess(c *gin.Context, log *zap.Logger) {
+	if ag == nil || ag.maxFailedAttempts <= 0 {
+		return
+	}
+
+	if err := ag.s.ResetFailures(c.ClientIP()); err != nil {
+		stats.Incr("bricksllm.admin.auth_guard.reset_failures_error", nil, 1)
+		log.Debug("error when resetting admin authentication failures", zap.Error(err))
+	}
+}
+
+// audit logs security relevant events of the admin API regardless of the
+// mode so that they can be shipped to a SIEM.
+func audit(log *zap.Logger, action string, c *gin.Context, fields ...zap.Field) {
+	fields = append([]zap.Field{
+		zap.String("audit", action),
+		zap.String("clientIp", c.ClientIP()),
+		zap.String("method", c.Request.Method),
+		zap.String("path", c.Request.URL.Path),
+		zap.String("userAgent", c.Request.UserAgent()),
+	}, fields...)
+
+	log.Warn("admin audit event", fields...)
+}
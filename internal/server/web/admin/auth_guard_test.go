@@ -0,0 +1,165 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/server/web"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	stats.InitializeClient("", nil)
+	gin.SetMode(gin.TestMode)
+
+	os.Exit(m.Run())
+}
+
+type fakeAdminAuthStorage struct {
+	requests map[string]int64
+	failures map[string]int64
+	lockouts map[string]time.Duration
+}
+
+func newFakeAdminAuthStorage() *fakeAdminAuthStorage {
+	return &fakeAdminAuthStorage{
+		requests: map[string]int64{},
+		failures: map[string]int64{},
+		lockouts: map[string]time.Duration{},
+	}
+}
+
+func (s *fakeAdminAuthStorage) IncrementRequests(client string) (int64, error) {
+	s.requests[client]++
+	return s.requests[client], nil
+}
+
+func (s *fakeAdminAuthStorage) IncrementFailures(client string) (int64, error) {
+	s.failures[client]++
+	return s.failures[client], nil
+}
+
+func (s *fakeAdminAuthStorage) ResetFailures(client string) error {
+	delete(s.failures, client)
+	return nil
+}
+
+func (s *fakeAdminAuthStorage) SetLockout(client string, d time.Duration) error {
+	s.lockouts[client] = d
+	return nil
+}
+
+func (s *fakeAdminAuthStorage) GetLockout(client string) (time.Duration, error) {
+	return s.lockouts[client], nil
+}
+
+// newGuardedRouter answers every request that gets past the guard as a
+// failed login.
+func newGuardedRouter(t *testing.T, ag *AuthGuard, trustedProxies []string) *gin.Engine {
+	router, err := web.NewRouter(trustedProxies)
+	require.NoError(t, err)
+
+	log := zap.NewNop()
+	router.Use(func(c *gin.Context) {
+		if !ag.allow(c, log) {
+			c.Abort()
+			return
+		}
+
+		ag.recordFailure(c, log)
+		c.AbortWithStatus(http.StatusUnauthorized)
+	})
+	router.GET("/api/key-management/keys", func(c *gin.Context) {})
+
+	return router
+}
+
+func sendLogin(router *gin.Engine, remoteAddr, forwardedFor string) int {
+	req := httptest.NewRequest(http.MethodGet, "/api/key-management/keys", nil)
+	req.RemoteAddr = remoteAddr
+	if len(forwardedFor) != 0 {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	return w.Code
+}
+
+func TestAuthGuard_Lockout(t *testing.T) {
+	t.Run("changing X-Forwarded-For does not reset the lockout", func(t *testing.T) {
+		s := newFakeAdminAuthStorage()
+		router := newGuardedRouter(t, NewAuthGuard(s, 0, 3, time.Minute, time.Hour), nil)
+
+		spoofed := []string{"1.1.1.1", "2.2.2.2", "3.3.3.3"}
+		for _, xff := range spoofed {
+			assert.Equal(t, http.StatusUnauthorized, sendLogin(router, "203.0.113.7:4000", xff))
+		}
+
+		assert.Equal(t, http.StatusTooManyRequests, sendLogin(router, "203.0.113.7:4000", "4.4.4.4"))
+		assert.Equal(t, http.StatusTooManyRequests, sendLogin(router, "203.0.113.7:4001", ""))
+		assert.Equal(t, int64(3), s.failures["203.0.113.7"])
+		assert.Len(t, s.lockouts, 1)
+	})
+
+	t.Run("other clients are not locked out", func(t *testing.T) {
+		s := newFakeAdminAuthStorage()
+		router := newGuardedRouter(t, NewAuthGuard(s, 0, 3, time.Minute, time.Hour), nil)
+
+		for i := 0; i < 3; i++ {
+			sendLogin(router, "203.0.113.7:4000", "")
+		}
+
+		assert.Equal(t, http.StatusUnauthorized, sendLogin(router, "198.51.100.2:4000", ""))
+	})
+
+	t.Run("clients behind trusted proxies are told apart by X-Forwarded-For", func(t *testing.T) {
+		s := newFakeAdminAuthStorage()
+		router := newGuardedRouter(t, NewAuthGuard(s, 0, 3, time.Minute, time.Hour), []string{"10.0.0.0/8"})
+
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusUnauthorized, sendLogin(router, "10.0.0.1:4000", "203.0.113.7"))
+		}
+
+		assert.Equal(t, http.StatusTooManyRequests, sendLogin(router, "10.0.0.2:4000", "203.0.113.7"))
+		assert.Equal(t, http.StatusUnauthorized, sendLogin(router, "10.0.0.1:4000", "198.51.100.2"))
+	})
+}
+
+func TestAuthGuard_RateLimit(t *testing.T) {
+	t.Run("changing X-Forwarded-For does not reset the rate limit", func(t *testing.T) {
+		s := newFakeAdminAuthStorage()
+		router := newGuardedRouter(t, NewAuthGuard(s, 2, 0, time.Minute, time.Hour), nil)
+
+		assert.Equal(t, http.StatusUnauthorized, sendLogin(router, "203.0.113.7:4000", "1.1.1.1"))
+		assert.Equal(t, http.StatusUnauthorized, sendLogin(router, "203.0.113.7:4000", "2.2.2.2"))
+		assert.Equal(t, http.StatusTooManyRequests, sendLogin(router, "203.0.113.7:4000", "3.3.3.3"))
+	})
+}
+
+func TestAuthGuard_GetLockoutDuration(t *testing.T) {
+	ag := NewAuthGuard(nil, 0, 3, time.Minute, 10*time.Minute)
+
+	cases := []struct {
+		failures int64
+		expected time.Duration
+	}{
+		{failures: 2, expected: 0},
+		{failures: 3, expected: time.Minute},
+		{failures: 4, expected: 2 * time.Minute},
+		{failures: 5, expected: 4 * time.Minute},
+		{failures: 7, expected: 10 * time.Minute},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.expected, ag.getLockoutDuration(tc.failures))
+	}
+}
@@ -0,0 +1,328 @@
+package admin
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/bundle"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type BundleManager interface {
+	Apply(b *bundle.Bundle) (*bundle.Status, error)
+	GetStatus(tenantId, name string) (*bundle.Status, error)
+	ExportSnapshot() (*bundle.SignedSnapshot, error)
+	ImportSnapshot(ss *bundle.SignedSnapshot) (*bundle.ImportReport, error)
+}
+
+func getApplyBundleHandler(m BundleManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_apply_bundle_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_apply_bundle_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/bundles/:name"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		cid := c.GetString(correlationId)
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			logError(log, "error when reading apply bundle request body", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/request-body-read",
+				Title:    "request body reader error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		b := &bundle.Bundle{}
+		if err := json.Unmarshal(data, b); err != nil {
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/json-unmarshal",
+				Title:    "json unmarshaller error",
+				Status:   http.StatusBadRequest,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		b.Name = c.Param("name")
+		if tenantId := c.GetString(tenantIdKey); len(tenantId) != 0 {
+			b.TenantId = tenantId
+		}
+
+		st, err := m.Apply(b)
+		if err != nil {
+			errType := "internal"
+
+			defer func() {
+				stats.Incr("bricksllm.admin.get_apply_bundle_handler.apply_error", []string{
+					"error_type:" + errType,
+				}, 1)
+			}()
+
+			if _, ok := err.(validationError); ok {
+				errType = "validation"
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "bundle validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			if _, ok := err.(notFoundError); ok {
+				errType = "not_found"
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "bundle references missing resources",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			if _, ok := err.(conflictError); ok {
+				errType = "conflict"
+				abortWithConflict(c, path, err)
+				return
+			}
+
+			logError(log, "error when applying a bundle", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/bundle-manager",
+				Title:    "applying a bundle error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		stats.Incr("bricksllm.admin.get_apply_bundle_handler.success", nil, 1)
+		c.JSON(http.StatusOK, st)
+	}
+}
+
+func getGetBundleStatusHandler(m BundleManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_get_bundle_status_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_get_bundle_status_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/bundles/:name"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		cid := c.GetString(correlationId)
+		tenantId := c.GetString(tenantIdKey)
+		if len(tenantId) == 0 {
+			tenantId = c.Query("tenantId")
+		}
+
+		st, err := m.GetStatus(tenantId, c.Param("name"))
+		if err != nil {
+			if _, ok := err.(notFoundError); ok {
+				c.JSON(http.StatusNotFound, &ErrorResponse{
+					Type:     "/errors/not-found",
+					Title:    "bundle not found",
+					Status:   http.StatusNotFound,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			stats.Incr("bricksllm.admin.get_get_bundle_status_handler.get_status_error", nil, 1)
+
+			logError(log, "error when getting bundle status", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/bundle-manager",
+				Title:    "getting bundle status error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		stats.Incr("bricksllm.admin.get_get_bundle_status_handler.success", nil, 1)
+		c.JSON(http.StatusOK, st)
+	}
+}
+
+func getExportSnapshotHandler(m BundleManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_export_snapshot_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_export_snapshot_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/config/export"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		cid := c.GetString(correlationId)
+		ss, err := m.ExportSnapshot()
+		if err != nil {
+			if _, ok := err.(validationError); ok {
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "snapshot export is not configured",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			stats.Incr("bricksllm.admin.get_export_snapshot_handler.export_snapshot_error", nil, 1)
+
+			logError(log, "error when exporting a snapshot", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/bundle-manager",
+				Title:    "exporting a snapshot error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		stats.Incr("bricksllm.admin.get_export_snapshot_handler.success", nil, 1)
+		c.JSON(http.StatusOK, ss)
+	}
+}
+
+func getImportSnapshotHandler(m BundleManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_import_snapshot_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_import_snapshot_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/config/import"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		cid := c.GetString(correlationId)
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			logError(log, "error when reading import snapshot request body", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/request-body-read",
+				Title:    "request body reader error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		ss := &bundle.SignedSnapshot{}
+		if err := json.Unmarshal(data, ss); err != nil {
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/json-unmarshal",
+				Title:    "json unmarshaller error",
+				Status:   http.StatusBadRequest,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		report, err := m.ImportSnapshot(ss)
+		if err != nil {
+			errType := "internal"
+
+			defer func() {
+				stats.Incr("bricksllm.admin.get_import_snapshot_handler.import_error", []string{
+					"error_type:" + errType,
+				}, 1)
+			}()
+
+			if _, ok := err.(validationError); ok {
+				errType = "validation"
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "snapshot validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			logError(log, "error when importing a snapshot", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/bundle-manager",
+				Title:    "importing a snapshot error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		stats.Incr("bricksllm.admin.get_import_snapshot_handler.success", nil, 1)
+		c.JSON(http.StatusOK, report)
+	}
+}
@@ -0,0 +1,102 @@
+package admin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/gin-gonic/gin"
+)
+
+type conflictError interface {
+	Conflict()
+}
+
+// computeETag derives the entity tag of a resource from its representation
+// in the admin api.
+func computeETag(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+func keyETag(k *key.ResponseKey) string {
+	return computeETag(k)
+}
+
+// settingETag leaves out the secrets of a setting, which are never returned
+// by the admin api. Changing them still changes the tag through updatedAt.
+func settingETag(s *provider.Setting) string {
+	copied := *s
+	copied.Setting = nil
+	copied.Egress = s.Egress.Redacted()
+
+	return computeETag(&copied)
+}
+
+func setETag(c *gin.Context, etag string) {
+	if len(etag) != 0 {
+		c.Header("ETag", etag)
+	}
+}
+
+// matchesIfMatch reports whether the If-Match header of a request accepts the
+// current entity tag of a resource. Requests without the header always match.
+func matchesIfMatch(c *gin.Context, current string) bool {
+	header := c.GetHeader("If-Match")
+	if len(header) == 0 {
+		return true
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+
+	return false
+}
+
+func abortWithPreconditionFailed(c *gin.Context, path, resource string) {
+	c.JSON(http.StatusPreconditionFailed, &ErrorResponse{
+		Type:     "/errors/precondition-failed",
+		Title:    resource + " was modified",
+		Status:   http.StatusPreconditionFailed,
+		Detail:   "If-Match does not match the current ETag of the " + resource + ". fetch the " + resource + " again before updating it.",
+		Instance: path,
+	})
+}
+
+func abortWithConflict(c *gin.Context, path string, err error) {
+	c.JSON(http.StatusConflict, &ErrorResponse{
+		Type:     "/errors/conflict",
+		Title:    "resource already exists",
+		Status:   http.StatusConflict,
+		Detail:   err.Error(),
+		Instance: path,
+	})
+}
+
+// resourceLocks serializes conditional updates of a resource so that the
+// If-Match check and the update cannot interleave with another update.
+type resourceLocks struct {
+	locks sync.Map
+}
+
+func (rl *resourceLocks) lock(id string) func() {
+	l, _ := rl.locks.LoadOrStore(id, &sync.Mutex{})
+	mu := l.(*sync.Mutex)
+	mu.Lock()
+
+	return mu.Unlock
+}
@@ -0,0 +1,108 @@
+package admin
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LogLevel is the level of the logger shared by every server. Changes apply
+// right away and last until the process restarts.
+type LogLevel interface {
+	Level() zapcore.Level
+	SetLevel(l zapcore.Level)
+}
+
+type logLevelResponse struct {
+	Level string `json:"level"`
+}
+
+func getGetLogLevelHandler(ll LogLevel) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_get_log_level_handler.requests", nil, 1)
+
+		c.JSON(http.StatusOK, &logLevelResponse{
+			Level: ll.Level().String(),
+		})
+	}
+}
+
+func getSetLogLevelHandler(ll LogLevel, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_set_log_level_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_set_log_level_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/log-level"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		cid := c.GetString(correlationId)
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			logError(log, "error when reading log level request body", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/request-body-read",
+				Title:    "request body reader error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		req := &logLevelResponse{}
+		if err := json.Unmarshal(data, req); err != nil {
+			logError(log, "error when unmarshalling log level request body", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/json-unmarshal",
+				Title:    "json unmarshaller error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		level, err := zapcore.ParseLevel(req.Level)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/validation",
+				Title:    "log level validation failed",
+				Status:   http.StatusBadRequest,
+				Detail:   "level can be debug, info, warn, error, dpanic, panic or fatal",
+				Instance: path,
+			})
+			return
+		}
+
+		previous := ll.Level()
+		ll.SetLevel(level)
+
+		// logged at warn so that the change shows up at any level below it.
+		log.Warn("log level changed", zap.String("from", previous.String()), zap.String("to", level.String()))
+
+		stats.Incr("bricksllm.admin.get_set_log_level_handler.success", nil, 1)
+		c.JSON(http.StatusOK, &logLevelResponse{
+			Level: level.String(),
+		})
+	}
+}
@@ -1,21 +1,73 @@
 package admin
 
 import (
+	"net/http"
 	"time"
 
+	"github.com/bricks-cloud/bricksllm/internal/admintoken"
+	"github.com/bricks-cloud/bricksllm/internal/encrypter"
+	"github.com/bricks-cloud/bricksllm/internal/logger"
+	"github.com/bricks-cloud/bricksllm/internal/tenant"
 	"github.com/bricks-cloud/bricksllm/internal/util"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
-func getAdminLoggerMiddleware(log *zap.Logger, prefix string, prod bool, adminPass string) gin.HandlerFunc {
+func getAdminLoggerMiddleware(log *zap.Logger, prefix string, prod bool, adminPass string, tms tenantMemStorage, ats adminTokenMemStorage, bs *Bootstrap, ag *AuthGuard) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if len(adminPass) != 0 && c.Request.Header.Get("X-API-KEY") != adminPass {
+		if !ag.allow(c, log) {
+			c.Abort()
+			return
+		}
+
+		token := c.Request.Header.Get("X-API-KEY")
+
+		var t *tenant.Tenant
+		var at *admintoken.Token
+		if len(token) != 0 {
+			hash := encrypter.Encrypt(token)
+			t = tms.GetTenantByTokenHash(hash)
+			at = ats.GetTokenByHash(hash)
+		}
+
+		if (t != nil && t.Revoked) || (at != nil && at.Revoked) {
+			ag.recordFailure(c, log)
+			c.Status(http.StatusUnauthorized)
+			c.Abort()
+			return
+		}
+
+		bootstrapped := bs.matches(token)
+		if bootstrapped && !allowsBootstrap(c) {
+			c.JSON(http.StatusForbidden, &ErrorResponse{
+				Type:     "/errors/forbidden",
+				Title:    "bootstrap token cannot access this endpoint",
+				Status:   http.StatusForbidden,
+				Detail:   "the bootstrap token can only be used for creating the first admin token",
+				Instance: c.FullPath(),
+			})
+			c.Abort()
+			return
+		}
+
+		// credentials are required once an admin password, an admin token or a
+		// bootstrap token is set up.
+		required := len(adminPass) != 0 || ats.HasTokens() || bs.active()
+		authenticated := at != nil || bootstrapped || (len(adminPass) != 0 && token == adminPass)
+
+		if t != nil {
+			c.Set(tenantIdKey, t.Id)
+		} else if required && !authenticated {
+			ag.recordFailure(c, log)
 			c.Status(200)
 			c.Abort()
 			return
 		}
 
+		if len(token) != 0 {
+			ag.recordSuccess(c, log)
+		}
+
 		c.Set(correlationId, util.NewUuid())
 		start := time.Now()
 		c.Next()
@@ -26,11 +78,11 @@ func getAdminLoggerMiddleware(log *zap.Logger, prefix string, prod bool, adminPa
 
 		if prod {
 			log.Info("request to admin management api",
-				zap.String(correlationId, c.GetString(correlationId)),
-				zap.Int("code", c.Writer.Status()),
-				zap.String("method", c.Request.Method),
-				zap.String("path", c.FullPath()),
-				zap.Int64("lantecyInMs", latency),
+				zap.String(logger.FieldRequestId, c.GetString(correlationId)),
+				zap.Int(logger.FieldStatusCode, c.Writer.Status()),
+				zap.String(logger.FieldMethod, c.Request.Method),
+				zap.String(logger.FieldPath, c.FullPath()),
+				zap.Int64(logger.FieldLatencyInMs, latency),
 			)
 		}
 	}
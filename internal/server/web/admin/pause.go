@@ -0,0 +1,228 @@
+package admin
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/pause"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type PauseManager interface {
+	Pause(scope, target, reason string, mt *pause.Maintenance) (*pause.Pause, error)
+	Resume(scope, target string) error
+	GetPauses(tenantId string) ([]*pause.Pause, error)
+}
+
+type pauseRequest struct {
+	Reason      string             `json:"reason"`
+	Maintenance *pause.Maintenance `json:"maintenance"`
+}
+
+// getPauseTarget reads the provider or the route a scope applies to from the
+// path parameters.
+func getPauseTarget(c *gin.Context, scope string) string {
+	if scope == pause.ScopeProvider {
+		return c.Param("provider")
+	}
+
+	if scope == pause.ScopeRoute {
+		return c.Param("id")
+	}
+
+	return ""
+}
+
+func getPauseHandler(m PauseManager, scope, path string, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tags := []string{"scope:" + scope}
+		stats.Incr("bricksllm.admin.get_pause_handler.requests", tags, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_pause_handler.latency", dur, tags, 1)
+		}()
+
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		cid := c.GetString(correlationId)
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			logError(log, "error when reading pause request body", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/request-body-read",
+				Title:    "request body reader error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		pr := &pauseRequest{}
+		if len(data) != 0 {
+			if err := json.Unmarshal(data, pr); err != nil {
+				logError(log, "error when unmarshalling pause request body", prod, cid, err)
+				c.JSON(http.StatusInternalServerError, &ErrorResponse{
+					Type:     "/errors/json-unmarshal",
+					Title:    "json unmarshaller error",
+					Status:   http.StatusInternalServerError,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+		}
+
+		p, err := m.Pause(scope, getPauseTarget(c, scope), pr.Reason, pr.Maintenance)
+		if err != nil {
+			if _, ok := err.(validationError); ok {
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "pause validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			if _, ok := err.(notFoundError); ok {
+				c.JSON(http.StatusNotFound, &ErrorResponse{
+					Type:     "/errors/not-found",
+					Title:    "route not found",
+					Status:   http.StatusNotFound,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			stats.Incr("bricksllm.admin.get_pause_handler.pause_error", tags, 1)
+
+			logError(log, "error when pausing traffic", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/pause-manager",
+				Title:    "pausing traffic error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		stats.Incr("bricksllm.admin.get_pause_handler.success", tags, 1)
+		c.JSON(http.StatusOK, p)
+	}
+}
+
+func getResumeHandler(m PauseManager, scope, path string, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tags := []string{"scope:" + scope}
+		stats.Incr("bricksllm.admin.get_resume_handler.requests", tags, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_resume_handler.latency", dur, tags, 1)
+		}()
+
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		cid := c.GetString(correlationId)
+		err := m.Resume(scope, getPauseTarget(c, scope))
+		if err != nil {
+			if _, ok := err.(notFoundError); ok {
+				c.JSON(http.StatusNotFound, &ErrorResponse{
+					Type:     "/errors/not-found",
+					Title:    "pause not found",
+					Status:   http.StatusNotFound,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			stats.Incr("bricksllm.admin.get_resume_handler.resume_error", tags, 1)
+
+			logError(log, "error when resuming traffic", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/pause-manager",
+				Title:    "resuming traffic error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		stats.Incr("bricksllm.admin.get_resume_handler.success", tags, 1)
+		c.Status(http.StatusOK)
+	}
+}
+
+func getGetPausesHandler(m PauseManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_get_pauses_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_get_pauses_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/pauses"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		cid := c.GetString(correlationId)
+		pauses, err := m.GetPauses(c.GetString(tenantIdKey))
+		if err != nil {
+			stats.Incr("bricksllm.admin.get_get_pauses_handler.get_pauses_error", nil, 1)
+
+			logError(log, "error when getting pauses", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/pause-manager",
+				Title:    "getting pauses error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		stats.Incr("bricksllm.admin.get_get_pauses_handler.success", nil, 1)
+		c.JSON(http.StatusOK, pauses)
+	}
+}
@@ -0,0 +1,201 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func healthsOfTenant(tenantId string, healths []*provider.Health) []*provider.Health {
+	if len(tenantId) == 0 {
+		return healths
+	}
+
+	selected := []*provider.Health{}
+	for _, h := range healths {
+		if h.TenantId == tenantId {
+			selected = append(selected, h)
+		}
+	}
+
+	return selected
+}
+
+func getGetProviderHealthsHandler(m ProviderSettingsManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_get_provider_healths_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_get_provider_healths_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/provider-health"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		cid := c.GetString(correlationId)
+		healths, err := m.GetHealths(c.QueryArray("ids"))
+		if err != nil {
+			errType := "internal"
+
+			defer func() {
+				stats.Incr("bricksllm.admin.get_get_provider_healths_handler.get_healths_error", []string{
+					"error_type:" + errType,
+				}, 1)
+			}()
+
+			if _, ok := err.(notFoundError); ok {
+				errType = "not_found"
+				c.JSON(http.StatusNotFound, &ErrorResponse{
+					Type:     "/errors/not-found",
+					Title:    "provider settings not found",
+					Status:   http.StatusNotFound,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			logError(log, "error when getting provider health", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/provider-settings-manager",
+				Title:    "getting provider health error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		stats.Incr("bricksllm.admin.get_get_provider_healths_handler.success", nil, 1)
+		c.JSON(http.StatusOK, healthsOfTenant(c.GetString(tenantIdKey), healths))
+	}
+}
+
+func getGetProviderHealthHandler(m ProviderSettingsManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_get_provider_health_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_get_provider_health_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/provider-settings/:id/health"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		cid := c.GetString(correlationId)
+		h, err := m.GetHealth(c.Param("id"))
+		if err != nil {
+			errType := "internal"
+
+			defer func() {
+				stats.Incr("bricksllm.admin.get_get_provider_health_handler.get_health_error", []string{
+					"error_type:" + errType,
+				}, 1)
+			}()
+
+			if _, ok := err.(notFoundError); ok {
+				errType = "not_found"
+				c.JSON(http.StatusNotFound, &ErrorResponse{
+					Type:     "/errors/not-found",
+					Title:    "provider setting not found",
+					Status:   http.StatusNotFound,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			logError(log, "error when getting provider health", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/provider-settings-manager",
+				Title:    "getting provider health error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		stats.Incr("bricksllm.admin.get_get_provider_health_handler.success", nil, 1)
+		c.JSON(http.StatusOK, h)
+	}
+}
+
+func getResetProviderHealthHandler(m ProviderSettingsManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_reset_provider_health_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_reset_provider_health_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/provider-settings/:id/health"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		cid := c.GetString(correlationId)
+		if _, err := m.GetSetting(c.Param("id")); err != nil {
+			c.JSON(http.StatusNotFound, &ErrorResponse{
+				Type:     "/errors/not-found",
+				Title:    "provider setting not found",
+				Status:   http.StatusNotFound,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		if err := m.ResetHealth(c.Param("id")); err != nil {
+			stats.Incr("bricksllm.admin.get_reset_provider_health_handler.reset_health_error", nil, 1)
+
+			logError(log, "error when resetting provider health", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/provider-settings-manager",
+				Title:    "resetting provider health error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		stats.Incr("bricksllm.admin.get_reset_provider_health_handler.success", nil, 1)
+		c.Status(http.StatusOK)
+	}
+}
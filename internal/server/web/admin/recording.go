@@ -0,0 +1,73 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type RecordingManager interface {
+	ShredRecordings(tenantId string) error
+}
+
+func getShredRecordingsHandler(m RecordingManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_shred_recordings_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_shred_recordings_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/recorded-requests/encryption-key"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		cid := c.GetString(correlationId)
+		tenantId := c.GetString(tenantIdKey)
+		if len(tenantId) == 0 {
+			tenantId = c.Query("tenantId")
+		}
+
+		err := m.ShredRecordings(tenantId)
+		if err != nil {
+			if _, ok := err.(notFoundError); ok {
+				c.JSON(http.StatusNotFound, &ErrorResponse{
+					Type:     "/errors/not-found",
+					Title:    "recording key not found",
+					Status:   http.StatusNotFound,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			stats.Incr("bricksllm.admin.get_shred_recordings_handler.shred_recordings_error", nil, 1)
+
+			logError(log, "error when shredding recorded requests", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/recording-manager",
+				Title:    "shredding recorded requests error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		stats.Incr("bricksllm.admin.get_shred_recordings_handler.success", nil, 1)
+		c.Status(http.StatusOK)
+	}
+}
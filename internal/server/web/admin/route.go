@@ -2,8 +2,10 @@ package admin
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/bricks-cloud/bricksllm/internal/route"
@@ -16,6 +18,8 @@ type RouteManager interface {
 	GetRoute(id string) (*route.Route, error)
 	GetRoutes() ([]*route.Route, error)
 	CreateRoute(r *route.Route) (*route.Route, error)
+	GetShadowResults(routeId string, start, end int64, limit int) ([]*route.ShadowResult, error)
+	CloneRoute(id string, cl *route.Clone) (*route.Route, error)
 }
 
 func getCreateRouteHandler(m RouteManager, log *zap.Logger, prod bool) gin.HandlerFunc {
@@ -68,6 +72,10 @@ func getCreateRouteHandler(m RouteManager, log *zap.Logger, prod bool) gin.Handl
 			return
 		}
 
+		if tenantId := c.GetString(tenantIdKey); len(tenantId) != 0 {
+			r.TenantId = tenantId
+		}
+
 		created, err := m.CreateRoute(r)
 		if err != nil {
 			errType := "internal"
@@ -90,6 +98,12 @@ func getCreateRouteHandler(m RouteManager, log *zap.Logger, prod bool) gin.Handl
 				return
 			}
 
+			if _, ok := err.(conflictError); ok {
+				errType = "conflict"
+				abortWithConflict(c, path, err)
+				return
+			}
+
 			logError(log, "error when creating a route", prod, cid, err)
 			c.JSON(http.StatusInternalServerError, &ErrorResponse{
 				Type:     "/errors/route-manager",
@@ -102,6 +116,7 @@ func getCreateRouteHandler(m RouteManager, log *zap.Logger, prod bool) gin.Handl
 		}
 
 		stats.Incr("bricksllm.admin.get_create_route_handler.success", nil, 1)
+		setETag(c, computeETag(created))
 		c.JSON(http.StatusOK, created)
 	}
 }
@@ -164,6 +179,7 @@ func getGetRouteHandler(m RouteManager, log *zap.Logger, prod bool) gin.HandlerF
 		}
 
 		stats.Incr("bricksllm.admin.get_get_route_handler.success", nil, 1)
+		setETag(c, computeETag(r))
 		c.JSON(http.StatusOK, r)
 	}
 }
@@ -192,6 +208,9 @@ func getGetRoutesHandler(m RouteManager, log *zap.Logger, prod bool) gin.Handler
 
 		cid := c.GetString(correlationId)
 		rs, err := m.GetRoutes()
+		if err == nil {
+			rs = routesOfTenant(c.GetString(tenantIdKey), rs)
+		}
 		if err != nil {
 			errType := "internal"
 			defer func() {
@@ -215,3 +234,110 @@ func getGetRoutesHandler(m RouteManager, log *zap.Logger, prod bool) gin.Handler
 		c.JSON(http.StatusOK, rs)
 	}
 }
+
+func getGetShadowResultsHandler(m RouteManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_get_shadow_results_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_get_shadow_results_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/routes/:id/shadow-results"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		cid := c.GetString(correlationId)
+
+		var startTs, endTs int64
+		for name, target := range map[string]*int64{"start": &startTs, "end": &endTs} {
+			parsed, err := strconv.ParseInt(c.Query(name), 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     fmt.Sprintf("/errors/bad-%s-query-param", name),
+					Title:    fmt.Sprintf("%s query cannot be parsed", name),
+					Status:   http.StatusBadRequest,
+					Detail:   fmt.Sprintf("%s query param must be int64", name),
+					Instance: path,
+				})
+				return
+			}
+
+			*target = parsed
+		}
+
+		limit := 100
+		if raw, ok := c.GetQuery("limit"); ok {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/bad-limit-query-param",
+					Title:    "limit query cannot be parsed",
+					Status:   http.StatusBadRequest,
+					Detail:   "limit query param must be int",
+					Instance: path,
+				})
+				return
+			}
+
+			limit = parsed
+		}
+
+		results, err := m.GetShadowResults(c.Param("id"), startTs, endTs, limit)
+		if err != nil {
+			errType := "internal"
+			defer func() {
+				stats.Incr("bricksllm.admin.get_get_shadow_results_handler.get_shadow_results_err", []string{
+					"error_type:" + errType,
+				}, 1)
+			}()
+
+			if _, ok := err.(validationError); ok {
+				errType = "validation"
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "shadow results request validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			if _, ok := err.(notFoundError); ok {
+				errType = "not_found"
+				c.JSON(http.StatusNotFound, &ErrorResponse{
+					Type:     "/errors/route-not-found",
+					Title:    "route not found error",
+					Status:   http.StatusNotFound,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			logError(log, "error when getting shadow results", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/route-manager",
+				Title:    "getting shadow results error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		stats.Incr("bricksllm.admin.get_get_shadow_results_handler.success", nil, 1)
+		c.JSON(http.StatusOK, results)
+	}
+}
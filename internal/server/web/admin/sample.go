@@ -0,0 +1,133 @@
+package admin
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type SampleManager interface {
+	ExportSamples(r *event.SampleExportRequest, w io.Writer) error
+}
+
+// sampleExportWriter sends the headers of an export with the first line so
+// that failed exports can still respond with an error.
+type sampleExportWriter struct {
+	c       *gin.Context
+	started bool
+}
+
+func (w *sampleExportWriter) start() {
+	if w.started {
+		return
+	}
+
+	w.started = true
+	w.c.Header("Content-Disposition", "attachment; filename=samples.jsonl")
+	w.c.Header("Content-Type", "application/jsonl")
+	w.c.Status(http.StatusOK)
+}
+
+func (w *sampleExportWriter) Write(data []byte) (int, error) {
+	w.start()
+	return w.c.Writer.Write(data)
+}
+
+func getExportSamplesHandler(m SampleManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_export_samples_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_export_samples_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/samples/export"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		cid := c.GetString(correlationId)
+		request := &event.SampleExportRequest{
+			Model:    c.Query("model"),
+			KeyId:    c.Query("keyId"),
+			Format:   c.Query("format"),
+			TenantId: c.GetString(tenantIdKey),
+		}
+
+		for name, target := range map[string]*int64{"start": &request.Start, "end": &request.End} {
+			parsed, err := strconv.ParseInt(c.Query(name), 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     fmt.Sprintf("/errors/bad-%s-query-param", name),
+					Title:    fmt.Sprintf("%s query cannot be parsed", name),
+					Status:   http.StatusBadRequest,
+					Detail:   fmt.Sprintf("%s query param must be int64", name),
+					Instance: path,
+				})
+				return
+			}
+
+			*target = parsed
+		}
+
+		w := &sampleExportWriter{c: c}
+		err := m.ExportSamples(request, w)
+		if err != nil {
+			errType := "internal"
+
+			defer func() {
+				stats.Incr("bricksllm.admin.get_export_samples_handler.export_samples_error", []string{
+					"error_type:" + errType,
+				}, 1)
+			}()
+
+			logError(log, "error when exporting samples", prod, cid, err)
+
+			// the export cannot be turned into an error response once lines
+			// have been sent.
+			if w.started {
+				return
+			}
+
+			if _, ok := err.(validationError); ok {
+				errType = "validation"
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "sample export request validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/sample-manager",
+				Title:    "sample export error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		w.start()
+		stats.Incr("bricksllm.admin.get_export_samples_handler.success", nil, 1)
+	}
+}
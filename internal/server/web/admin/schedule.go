@@ -0,0 +1,235 @@
+package admin
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/schedule"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ScheduleManager interface {
+	CreateSchedule(tenantId string, s *schedule.Schedule) (*schedule.Schedule, error)
+	GetSchedules(tenantId string) ([]*schedule.Schedule, error)
+	GetSchedule(tenantId, id string) (*schedule.Schedule, error)
+	UpdateSchedule(tenantId, id string, us *schedule.UpdateSchedule) (*schedule.Schedule, error)
+	DeleteSchedule(tenantId, id string) error
+	GetScheduleRuns(tenantId, id string) ([]*schedule.Run, error)
+}
+
+// writeScheduleError responds with the status matching an error of the
+// schedule manager.
+func writeScheduleError(c *gin.Context, log *zap.Logger, prod bool, handler, action, path string, err error) {
+	errType := "internal"
+	defer func() {
+		stats.Incr("bricksllm.admin."+handler+".schedule_manager_error", []string{
+			"error_type:" + errType,
+		}, 1)
+	}()
+
+	if _, ok := err.(validationError); ok {
+		errType = "validation"
+		c.JSON(http.StatusBadRequest, &ErrorResponse{
+			Type:     "/errors/validation",
+			Title:    "schedule validation failed",
+			Status:   http.StatusBadRequest,
+			Detail:   err.Error(),
+			Instance: path,
+		})
+		return
+	}
+
+	if _, ok := err.(notFoundError); ok {
+		errType = "not_found"
+		c.JSON(http.StatusNotFound, &ErrorResponse{
+			Type:     "/errors/not-found",
+			Title:    "schedule not found",
+			Status:   http.StatusNotFound,
+			Detail:   err.Error(),
+			Instance: path,
+		})
+		return
+	}
+
+	logError(log, "error when "+action, prod, c.GetString(correlationId), err)
+	c.JSON(http.StatusInternalServerError, &ErrorResponse{
+		Type:     "/errors/schedule-manager",
+		Title:    action + " error",
+		Status:   http.StatusInternalServerError,
+		Detail:   err.Error(),
+		Instance: path,
+	})
+}
+
+// readScheduleRequest unmarshals the body of a request into v and responds
+// with an error if it cannot.
+func readScheduleRequest(c *gin.Context, log *zap.Logger, prod bool, path string, v any) bool {
+	cid := c.GetString(correlationId)
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		logError(log, "error when reading schedule request body", prod, cid, err)
+		c.JSON(http.StatusInternalServerError, &ErrorResponse{
+			Type:     "/errors/request-body-read",
+			Title:    "request body reader error",
+			Status:   http.StatusInternalServerError,
+			Detail:   err.Error(),
+			Instance: path,
+		})
+		return false
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		logError(log, "error when unmarshalling schedule request body", prod, cid, err)
+		c.JSON(http.StatusBadRequest, &ErrorResponse{
+			Type:     "/errors/json-unmarshal",
+			Title:    "json unmarshaller error",
+			Status:   http.StatusBadRequest,
+			Detail:   err.Error(),
+			Instance: path,
+		})
+		return false
+	}
+
+	return true
+}
+
+func getCreateScheduleHandler(m ScheduleManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_create_schedule_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_create_schedule_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/schedules"
+		s := &schedule.Schedule{}
+		if !readScheduleRequest(c, log, prod, path, s) {
+			return
+		}
+
+		created, err := m.CreateSchedule(c.GetString(tenantIdKey), s)
+		if err != nil {
+			writeScheduleError(c, log, prod, "get_create_schedule_handler", "creating a schedule", path, err)
+			return
+		}
+
+		stats.Incr("bricksllm.admin.get_create_schedule_handler.success", nil, 1)
+		c.JSON(http.StatusOK, created)
+	}
+}
+
+func getGetSchedulesHandler(m ScheduleManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_get_schedules_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_get_schedules_handler.latency", dur, nil, 1)
+		}()
+
+		schedules, err := m.GetSchedules(c.GetString(tenantIdKey))
+		if err != nil {
+			writeScheduleError(c, log, prod, "get_get_schedules_handler", "getting schedules", "/api/schedules", err)
+			return
+		}
+
+		stats.Incr("bricksllm.admin.get_get_schedules_handler.success", nil, 1)
+		c.JSON(http.StatusOK, schedules)
+	}
+}
+
+func getGetScheduleHandler(m ScheduleManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_get_schedule_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_get_schedule_handler.latency", dur, nil, 1)
+		}()
+
+		s, err := m.GetSchedule(c.GetString(tenantIdKey), c.Param("id"))
+		if err != nil {
+			writeScheduleError(c, log, prod, "get_get_schedule_handler", "getting a schedule", "/api/schedules/:id", err)
+			return
+		}
+
+		stats.Incr("bricksllm.admin.get_get_schedule_handler.success", nil, 1)
+		c.JSON(http.StatusOK, s)
+	}
+}
+
+func getUpdateScheduleHandler(m ScheduleManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_update_schedule_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_update_schedule_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/schedules/:id"
+		us := &schedule.UpdateSchedule{}
+		if !readScheduleRequest(c, log, prod, path, us) {
+			return
+		}
+
+		updated, err := m.UpdateSchedule(c.GetString(tenantIdKey), c.Param("id"), us)
+		if err != nil {
+			writeScheduleError(c, log, prod, "get_update_schedule_handler", "updating a schedule", path, err)
+			return
+		}
+
+		stats.Incr("bricksllm.admin.get_update_schedule_handler.success", nil, 1)
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+func getDeleteScheduleHandler(m ScheduleManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_delete_schedule_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_delete_schedule_handler.latency", dur, nil, 1)
+		}()
+
+		if err := m.DeleteSchedule(c.GetString(tenantIdKey), c.Param("id")); err != nil {
+			writeScheduleError(c, log, prod, "get_delete_schedule_handler", "deleting a schedule", "/api/schedules/:id", err)
+			return
+		}
+
+		stats.Incr("bricksllm.admin.get_delete_schedule_handler.success", nil, 1)
+		c.Status(http.StatusOK)
+	}
+}
+
+func getGetScheduleRunsHandler(m ScheduleManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_get_schedule_runs_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_get_schedule_runs_handler.latency", dur, nil, 1)
+		}()
+
+		runs, err := m.GetScheduleRuns(c.GetString(tenantIdKey), c.Param("id"))
+		if err != nil {
+			writeScheduleError(c, log, prod, "get_get_schedule_runs_handler", "getting schedule runs", "/api/schedules/:id/runs", err)
+			return
+		}
+
+		stats.Incr("bricksllm.admin.get_get_schedule_runs_handler.success", nil, 1)
+		c.JSON(http.StatusOK, runs)
+	}
+}
@@ -0,0 +1,70 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/spendsync"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type SpendSyncer interface {
+	Enabled() bool
+	Local() (*spendsync.Counters, error)
+}
+
+func getGetSpendCountersHandler(s SpendSyncer, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_get_spend_counters_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_get_spend_counters_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/spend/counters"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		if !s.Enabled() {
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/validation",
+				Title:    "spend sync is not configured",
+				Status:   http.StatusBadRequest,
+				Detail:   "CLUSTER_ID is not set",
+				Instance: path,
+			})
+			return
+		}
+
+		cid := c.GetString(correlationId)
+		counters, err := s.Local()
+		if err != nil {
+			stats.Incr("bricksllm.admin.get_get_spend_counters_handler.local_error", nil, 1)
+
+			logError(log, "error when getting spend counters", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/spend-syncer",
+				Title:    "getting spend counters error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		stats.Incr("bricksllm.admin.get_get_spend_counters_handler.success", nil, 1)
+		c.JSON(http.StatusOK, counters)
+	}
+}
@@ -0,0 +1,499 @@
+package admin
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/bricks-cloud/bricksllm/internal/tenant"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// tenantIdKey is set on the context when a request is authenticated with
+// the admin token of a tenant instead of the admin password.
+const tenantIdKey = "tenantId"
+
+type TenantManager interface {
+	CreateTenant(t *tenant.Tenant) (*tenant.Tenant, error)
+	GetTenant(id string) (*tenant.Tenant, error)
+	GetTenants() ([]*tenant.Tenant, error)
+	UpdateTenant(id string, ut *tenant.UpdateTenant) (*tenant.Tenant, error)
+}
+
+type tenantMemStorage interface {
+	GetTenantByTokenHash(hash string) *tenant.Tenant
+}
+
+func superAdminOnly(c *gin.Context) {
+	if len(c.GetString(tenantIdKey)) != 0 {
+		stats.Incr("bricksllm.admin.super_admin_only.forbidden", nil, 1)
+
+		c.JSON(http.StatusForbidden, &ErrorResponse{
+			Type:     "/errors/forbidden",
+			Title:    "tenant admin tokens cannot access this endpoint",
+			Status:   http.StatusForbidden,
+			Detail:   "this endpoint manages resources shared by every tenant and requires the admin password",
+			Instance: c.FullPath(),
+		})
+		c.Abort()
+	}
+}
+
+func abortWithTenantResourceNotFound(c *gin.Context, resource string) {
+	c.JSON(http.StatusNotFound, &ErrorResponse{
+		Type:     "/errors/not-found",
+		Title:    resource + " not found error",
+		Status:   http.StatusNotFound,
+		Detail:   resource + " is not found for: " + c.Param("id"),
+		Instance: c.FullPath(),
+	})
+	c.Abort()
+}
+
+func abortWithTenantLookupError(c *gin.Context, log *zap.Logger, prod bool, err error) {
+	if _, ok := err.(notFoundError); ok {
+		c.JSON(http.StatusNotFound, &ErrorResponse{
+			Type:     "/errors/not-found",
+			Title:    "not found error",
+			Status:   http.StatusNotFound,
+			Detail:   err.Error(),
+			Instance: c.FullPath(),
+		})
+		c.Abort()
+		return
+	}
+
+	logError(log, "error when checking the tenant of a resource", prod, c.GetString(correlationId), err)
+	c.JSON(http.StatusInternalServerError, &ErrorResponse{
+		Type:     "/errors/tenant-lookup",
+		Title:    "checking the tenant of a resource errored out",
+		Status:   http.StatusInternalServerError,
+		Detail:   err.Error(),
+		Instance: c.FullPath(),
+	})
+	c.Abort()
+}
+
+// getKeyOfTenantMiddleware makes resources of other tenants indistinguishable
+// from missing ones for requests made with a tenant admin token.
+func getKeyOfTenantMiddleware(m KeyManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantId := c.GetString(tenantIdKey)
+		if len(tenantId) == 0 {
+			return
+		}
+
+		keys, err := m.GetKeys(nil, []string{c.Param("id")}, "")
+		if err != nil {
+			abortWithTenantLookupError(c, log, prod, err)
+			return
+		}
+
+		if len(keys) == 0 || keys[0].TenantId != tenantId {
+			abortWithTenantResourceNotFound(c, "key")
+			return
+		}
+	}
+}
+
+func getSettingOfTenantMiddleware(m ProviderSettingsManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantId := c.GetString(tenantIdKey)
+		if len(tenantId) == 0 {
+			return
+		}
+
+		setting, err := m.GetSetting(c.Param("id"))
+		if err != nil {
+			abortWithTenantLookupError(c, log, prod, err)
+			return
+		}
+
+		if setting.TenantId != tenantId {
+			abortWithTenantResourceNotFound(c, "provider setting")
+			return
+		}
+	}
+}
+
+func getRouteOfTenantMiddleware(m RouteManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantId := c.GetString(tenantIdKey)
+		if len(tenantId) == 0 {
+			return
+		}
+
+		r, err := m.GetRoute(c.Param("id"))
+		if err != nil {
+			abortWithTenantLookupError(c, log, prod, err)
+			return
+		}
+
+		if r.TenantId != tenantId {
+			abortWithTenantResourceNotFound(c, "route")
+			return
+		}
+	}
+}
+
+func getCreateTenantHandler(m TenantManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_create_tenant_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_create_tenant_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/tenants"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		cid := c.GetString(correlationId)
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			logError(log, "error when reading create a tenant request body", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/request-body-read",
+				Title:    "request body reader error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		t := &tenant.Tenant{}
+		err = json.Unmarshal(data, t)
+		if err != nil {
+			logError(log, "error when unmarshalling create a tenant request body", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/json-unmarshal",
+				Title:    "json unmarshaller error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		created, err := m.CreateTenant(t)
+		if err != nil {
+			errType := "internal"
+
+			defer func() {
+				stats.Incr("bricksllm.admin.get_create_tenant_handler.create_tenant_error", []string{
+					"error_type:" + errType,
+				}, 1)
+			}()
+
+			if _, ok := err.(validationError); ok {
+				errType = "validation"
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "tenant validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			logError(log, "error when creating a tenant", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/tenant-manager",
+				Title:    "creating a tenant error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		stats.Incr("bricksllm.admin.get_create_tenant_handler.success", nil, 1)
+		c.JSON(http.StatusOK, created)
+	}
+}
+
+func getGetTenantsHandler(m TenantManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_get_tenants_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_get_tenants_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/tenants"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		cid := c.GetString(correlationId)
+		tenants, err := m.GetTenants()
+		if err != nil {
+			stats.Incr("bricksllm.admin.get_get_tenants_handler.get_tenants_error", nil, 1)
+
+			logError(log, "error when getting tenants", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/tenant-manager",
+				Title:    "getting tenants error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		stats.Incr("bricksllm.admin.get_get_tenants_handler.success", nil, 1)
+		c.JSON(http.StatusOK, tenants)
+	}
+}
+
+func getGetTenantHandler(m TenantManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_get_tenant_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_get_tenant_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/tenants/:id"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		cid := c.GetString(correlationId)
+		t, err := m.GetTenant(c.Param("id"))
+		if err != nil {
+			errType := "internal"
+
+			defer func() {
+				stats.Incr("bricksllm.admin.get_get_tenant_handler.get_tenant_error", []string{
+					"error_type:" + errType,
+				}, 1)
+			}()
+
+			if _, ok := err.(notFoundError); ok {
+				errType = "not_found"
+				c.JSON(http.StatusNotFound, &ErrorResponse{
+					Type:     "/errors/not-found",
+					Title:    "tenant not found error",
+					Status:   http.StatusNotFound,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			logError(log, "error when getting a tenant", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/tenant-manager",
+				Title:    "getting a tenant error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		stats.Incr("bricksllm.admin.get_get_tenant_handler.success", nil, 1)
+		c.JSON(http.StatusOK, t)
+	}
+}
+
+func getUpdateTenantHandler(m TenantManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_update_tenant_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.admin.get_update_tenant_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/tenants/:id"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		cid := c.GetString(correlationId)
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			logError(log, "error when reading update a tenant request body", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/request-body-read",
+				Title:    "request body reader error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		ut := &tenant.UpdateTenant{}
+		err = json.Unmarshal(data, ut)
+		if err != nil {
+			logError(log, "error when unmarshalling update a tenant request body", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/json-unmarshal",
+				Title:    "json unmarshaller error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		updated, err := m.UpdateTenant(c.Param("id"), ut)
+		if err != nil {
+			errType := "internal"
+
+			defer func() {
+				stats.Incr("bricksllm.admin.get_update_tenant_handler.update_tenant_error", []string{
+					"error_type:" + errType,
+				}, 1)
+			}()
+
+			if _, ok := err.(validationError); ok {
+				errType = "validation"
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "tenant validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			if _, ok := err.(notFoundError); ok {
+				errType = "not_found"
+				c.JSON(http.StatusNotFound, &ErrorResponse{
+					Type:     "/errors/not-found",
+					Title:    "tenant not found error",
+					Status:   http.StatusNotFound,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			logError(log, "error when updating a tenant", prod, cid, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/tenant-manager",
+				Title:    "updating a tenant error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		stats.Incr("bricksllm.admin.get_update_tenant_handler.success", nil, 1)
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+// The helpers below drop resources of other tenants from list responses. The
+// admin password sees every resource.
+
+func keysOfTenant(tenantId string, keys []*key.ResponseKey) []*key.ResponseKey {
+	if len(tenantId) == 0 {
+		return keys
+	}
+
+	selected := []*key.ResponseKey{}
+	for _, k := range keys {
+		if k.TenantId == tenantId {
+			selected = append(selected, k)
+		}
+	}
+
+	return selected
+}
+
+func settingsOfTenant(tenantId string, settings []*provider.Setting) []*provider.Setting {
+	if len(tenantId) == 0 {
+		return settings
+	}
+
+	selected := []*provider.Setting{}
+	for _, setting := range settings {
+		if setting.TenantId == tenantId {
+			selected = append(selected, setting)
+		}
+	}
+
+	return selected
+}
+
+func routesOfTenant(tenantId string, routes []*route.Route) []*route.Route {
+	if len(tenantId) == 0 {
+		return routes
+	}
+
+	selected := []*route.Route{}
+	for _, r := range routes {
+		if r.TenantId == tenantId {
+			selected = append(selected, r)
+		}
+	}
+
+	return selected
+}
+
+func eventsOfTenant(tenantId string, events []*event.Event) []*event.Event {
+	if len(tenantId) == 0 {
+		return events
+	}
+
+	selected := []*event.Event{}
+	for _, e := range events {
+		if e.TenantId == tenantId {
+			selected = append(selected, e)
+		}
+	}
+
+	return selected
+}
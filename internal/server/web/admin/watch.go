@@ -0,0 +1,104 @@
+package admin
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/bricks-cloud/bricksllm/internal/watch"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const watchHeartbeatInterval = 15 * time.Second
+
+type ConfigWatcher interface {
+	Subscribe() (<-chan *watch.Change, func())
+	Notify(c *watch.Change)
+}
+
+// getWatchHandler streams configuration changes as server sent events until
+// the client disconnects. Clients are expected to reconnect and refetch the
+// configuration if the stream ends.
+func getWatchHandler(cw ConfigWatcher, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.admin.get_watch_handler.requests", nil, 1)
+
+		path := "/api/watch"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		resources := map[string]bool{}
+		for _, r := range c.QueryArray("resources") {
+			for _, split := range strings.Split(r, ",") {
+				if len(split) != 0 {
+					resources[split] = true
+				}
+			}
+		}
+
+		for r := range resources {
+			if r != watch.ResourceKey && r != watch.ResourceRoute && r != watch.ResourceProviderSetting {
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "watch request validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   "resources can only contain " + watch.ResourceKey + ", " + watch.ResourceRoute + " or " + watch.ResourceProviderSetting,
+					Instance: path,
+				})
+				return
+			}
+		}
+
+		tenantId := c.GetString(tenantIdKey)
+		changes, unsubscribe := cw.Subscribe()
+		defer unsubscribe()
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Status(http.StatusOK)
+		c.Writer.Flush()
+
+		heartbeat := time.NewTicker(watchHeartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-c.Request.Context().Done():
+				return
+			case <-heartbeat.C:
+				if _, err := c.Writer.WriteString(": heartbeat\n\n"); err != nil {
+					return
+				}
+
+				c.Writer.Flush()
+			case change, ok := <-changes:
+				if !ok {
+					stats.Incr("bricksllm.admin.get_watch_handler.disconnected", nil, 1)
+					return
+				}
+
+				if len(resources) != 0 && !resources[change.Resource] {
+					continue
+				}
+
+				if len(tenantId) != 0 && change.TenantId != tenantId {
+					continue
+				}
+
+				c.SSEvent("change", change)
+				c.Writer.Flush()
+			}
+		}
+	}
+}
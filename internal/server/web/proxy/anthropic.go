@@ -11,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bricks-cloud/bricksllm/internal/logger"
 	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
 	"github.com/bricks-cloud/bricksllm/internal/stats"
 	"github.com/gin-gonic/gin"
@@ -37,7 +38,10 @@ func copyHttpHeaders(source *http.Request, dest *http.Request) {
 		}
 	}
 
-	dest.Header.Set("Accept-Encoding", "*")
+	// leaving Accept-Encoding unset lets the http client negotiate gzip with the
+	// upstream and transparently decompress the body for usage parsing. client
+	// facing compression is handled by the compression middleware.
+	dest.Header.Del("Accept-Encoding")
 }
 
 func getCompletionHandler(r recorder, prod, private bool, client http.Client, kms keyMemStorage, log *zap.Logger, e anthropicEstimator, timeOut time.Duration) gin.HandlerFunc {
@@ -78,7 +82,7 @@ func getCompletionHandler(r recorder, prod, private bool, client http.Client, km
 		}
 
 		start := time.Now()
-		res, err := client.Do(req)
+		res, err := egressClient(c, client).Do(req)
 		if err != nil {
 			stats.Incr("bricksllm.proxy.get_completion_handler.http_client_error", nil, 1)
 
@@ -89,11 +93,7 @@ func getCompletionHandler(r recorder, prod, private bool, client http.Client, km
 
 		defer res.Body.Close()
 
-		for name, values := range res.Header {
-			for _, value := range values {
-				c.Header(name, value)
-			}
-		}
+		copyResponseHeaders(c, res.Header)
 
 		// model := c.GetString("model")
 
@@ -108,6 +108,11 @@ func getCompletionHandler(r recorder, prod, private bool, client http.Client, km
 				return
 			}
 
+			if !isWellFormedResponse(bytes) {
+				rejectMalformedResponse(c, "anthropic")
+				return
+			}
+
 			// var cost float64 = 0
 			// completionTokens := 0
 			completionRes := &anthropic.CompletionResponse{}
@@ -272,7 +277,7 @@ func logAnthropicErrorResponse(log *zap.Logger, data []byte, prod bool, cid stri
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 		}
 
 		if cr.Error != nil {
@@ -0,0 +1,272 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/message"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+)
+
+// anthropicBatchDiscount is the share of the regular token prices anthropic
+// charges for requests of message batches.
+const anthropicBatchDiscount = 0.5
+
+// batchItemsClaimSize is the number of batch results claimed with one round
+// trip to redis.
+const batchItemsClaimSize = 500
+
+const (
+	anthropicBatchesUrl   = "https://api.anthropic.com/v1/messages/batches"
+	batchIdMetadataKey    = "bricksllm_batch_id"
+	batchResultsFullPath  = "/api/providers/anthropic/v1/messages/batches/:batch_id/results"
+	anthropicBatchesProxy = "/api/providers/anthropic/v1/messages/batches"
+)
+
+type batchUsageStorage interface {
+	ClaimBatchItems(batchId string, customIds []string) ([]bool, error)
+}
+
+type batchItem struct {
+	customId      string
+	model         string
+	promptTks     int
+	completionTks int
+}
+
+// proxyBaseUrl is the url clients used to reach the proxy, so urls returned
+// by anthropic can point back at it.
+func proxyBaseUrl(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+
+	if proto := c.GetHeader("X-Forwarded-Proto"); len(proto) != 0 {
+		scheme = proto
+	}
+
+	host := c.Request.Host
+	if forwarded := c.GetHeader("X-Forwarded-Host"); len(forwarded) != 0 {
+		host = forwarded
+	}
+
+	return scheme + "://" + host
+}
+
+// chargeBatchItems publishes an event for every succeeded item of a batch
+// that has not been charged yet. Items are charged when their results are
+// retrieved, since that is the first time their usage is known, and each
+// event is a child of the event of the retrieving request.
+func chargeBatchItems(c *gin.Context, batchId string, items []*batchItem, ae anthropicEstimator, bus batchUsageStorage, pub publisher, log *zap.Logger, prod bool, cid string) {
+	if len(items) == 0 {
+		return
+	}
+
+	customIds := make([]string, 0, len(items))
+	for _, item := range items {
+		customIds = append(customIds, item.customId)
+	}
+
+	claimed, err := bus.ClaimBatchItems(batchId, customIds)
+	if err != nil {
+		stats.Incr("bricksllm.proxy.charge_batch_items.claim_batch_items_error", nil, 1)
+		logError(log, "error when claiming anthropic batch items", prod, cid, err)
+		return
+	}
+
+	var kc *key.ResponseKey
+	if raw, exists := c.Get("key"); exists {
+		kc, _ = raw.(*key.ResponseKey)
+	}
+
+	if kc == nil {
+		return
+	}
+
+	for i, item := range items {
+		if !claimed[i] {
+			stats.Incr("bricksllm.proxy.charge_batch_items.already_charged", nil, 1)
+			continue
+		}
+
+		cost, err := ae.EstimateTotalCost(item.model, item.promptTks, item.completionTks)
+		if err != nil {
+			stats.Incr("bricksllm.proxy.charge_batch_items.estimate_total_cost_error", nil, 1)
+			logError(log, "error when estimating anthropic batch item cost", prod, cid, err)
+		}
+
+		pub.Publish(message.Message{
+			Type: "event",
+			Data: &event.EventWithRequestAndContent{
+				Key: kc,
+				Event: &event.Event{
+					Id:                   util.NewUuid(),
+					CreatedAt:            time.Now().Unix(),
+					Tags:                 kc.Tags,
+					KeyId:                kc.KeyId,
+					CostInUsd:            cost * anthropicBatchDiscount,
+					Provider:             "anthropic",
+					Model:                item.model,
+					Status:               http.StatusOK,
+					PromptTokenCount:     item.promptTks,
+					CompletionTokenCount: item.completionTks,
+					Path:                 c.FullPath(),
+					Method:               c.Request.Method,
+					CustomId:             item.customId,
+					UserId:               c.GetString("userId"),
+					Metadata: map[string]string{
+						batchIdMetadataKey: batchId,
+					},
+					CorrelationId: cid,
+					TenantId:      kc.TenantId,
+					SettingId:     c.GetString("settingId"),
+					ParentId:      c.GetString("eventId"),
+				},
+			},
+		})
+
+		stats.Incr("bricksllm.proxy.charge_batch_items.charged", nil, 1)
+	}
+}
+
+// getMessageBatchesHandler forwards requests of the message batches api to
+// anthropic. Results of batches are streamed back line by line while the
+// usage of every succeeded request is charged at batch pricing.
+func getMessageBatchesHandler(prod bool, client http.Client, bus batchUsageStorage, pub publisher, log *zap.Logger, ae anthropicEstimator, timeOut time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tags := []string{
+			"path:" + c.FullPath(),
+		}
+
+		stats.Incr("bricksllm.proxy.get_message_batches_handler.requests", tags, 1)
+
+		if c == nil || c.Request == nil {
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] context is empty")
+			return
+		}
+
+		cid := c.GetString(correlationId)
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeOut)
+		defer cancel()
+
+		targetUrl := anthropicBatchesUrl + strings.TrimPrefix(c.Request.URL.Path, anthropicBatchesProxy)
+		if len(c.Request.URL.RawQuery) != 0 {
+			targetUrl += "?" + c.Request.URL.RawQuery
+		}
+
+		req, err := http.NewRequestWithContext(ctx, c.Request.Method, targetUrl, c.Request.Body)
+		if err != nil {
+			logError(log, "error when creating anthropic http request", prod, cid, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create anthropic http request")
+			return
+		}
+
+		copyHttpHeaders(c.Request, req)
+
+		start := time.Now()
+		res, err := egressClient(c, client).Do(req)
+		if err != nil {
+			stats.Incr("bricksllm.proxy.get_message_batches_handler.http_client_error", tags, 1)
+
+			logError(log, "error when sending http request to anthropic", prod, cid, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to send http request to anthropic")
+			return
+		}
+
+		defer res.Body.Close()
+
+		copyResponseHeaders(c, res.Header)
+
+		if res.StatusCode != http.StatusOK || c.FullPath() != batchResultsFullPath {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.proxy.get_message_batches_handler.latency", dur, tags, 1)
+
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				logError(log, "error when reading anthropic http response body", prod, cid, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read anthropic response body")
+				return
+			}
+
+			if res.StatusCode != http.StatusOK {
+				stats.Incr("bricksllm.proxy.get_message_batches_handler.error_response", tags, 1)
+				logAnthropicErrorResponse(log, body, prod, cid)
+				c.Data(res.StatusCode, "application/json", body)
+				return
+			}
+
+			stats.Incr("bricksllm.proxy.get_message_batches_handler.success", tags, 1)
+
+			// results urls are rewritten so sdks following them download
+			// results through the proxy and get charged.
+			body = bytes.ReplaceAll(body, []byte(anthropicBatchesUrl), []byte(proxyBaseUrl(c)+anthropicBatchesProxy))
+			c.Writer.Header().Del("Content-Length")
+
+			c.Data(res.StatusCode, "application/json", body)
+			return
+		}
+
+		stats.Incr("bricksllm.proxy.get_message_batches_handler.results_requests", tags, 1)
+
+		batchId := c.Param("batch_id")
+		buffer := bufio.NewReader(res.Body)
+		items := []*batchItem{}
+		defer func() {
+			chargeBatchItems(c, batchId, items, ae, bus, pub, log, prod, cid)
+		}()
+
+		c.Status(res.StatusCode)
+		c.Stream(func(w io.Writer) bool {
+			raw, err := buffer.ReadBytes('\n')
+			if len(raw) != 0 {
+				w.Write(raw)
+			}
+
+			if result := gjson.GetBytes(raw, "result"); result.Get("type").Str == "succeeded" {
+				usage := result.Get("message.usage")
+				items = append(items, &batchItem{
+					customId:      gjson.GetBytes(raw, "custom_id").Str,
+					model:         result.Get("message.model").Str,
+					promptTks:     int(usage.Get("input_tokens").Int()),
+					completionTks: int(usage.Get("output_tokens").Int()),
+				})
+			}
+
+			if len(items) >= batchItemsClaimSize {
+				chargeBatchItems(c, batchId, items, ae, bus, pub, log, prod, cid)
+				items = []*batchItem{}
+			}
+
+			if err != nil {
+				if err != io.EOF {
+					if errors.Is(err, context.DeadlineExceeded) {
+						stats.Incr("bricksllm.proxy.get_message_batches_handler.context_deadline_exceeded_error", tags, 1)
+					}
+
+					stats.Incr("bricksllm.proxy.get_message_batches_handler.read_bytes_error", tags, 1)
+					logError(log, "error when reading bytes from anthropic batch results", prod, cid, err)
+				}
+
+				return false
+			}
+
+			return true
+		})
+
+		stats.Timing("bricksllm.proxy.get_message_batches_handler.results_latency", time.Now().Sub(start), tags, 1)
+	}
+}
@@ -3,6 +3,7 @@ package proxy
 import (
 	"encoding/json"
 
+	"github.com/bricks-cloud/bricksllm/internal/logger"
 	goopenai "github.com/sashabaranov/go-openai"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -18,7 +19,7 @@ func logCreateAssistantRequest(log *zap.Logger, data []byte, prod, private bool,
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("model", ar.Model),
 			zap.Any("tools", ar.Tools),
 			zap.Any("file_ids", ar.FileIDs),
@@ -45,7 +46,7 @@ func logAssistantResponse(log *zap.Logger, data []byte, prod, private bool, cid
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("id", cid),
 			zap.String("object", a.Object),
 			zap.Int64("created_at", a.CreatedAt),
@@ -66,7 +67,7 @@ func logAssistantResponse(log *zap.Logger, data []byte, prod, private bool, cid
 func logRetrieveAssistantRequest(log *zap.Logger, data []byte, prod bool, cid, assistantId string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("id", assistantId),
 		}
 
@@ -84,7 +85,7 @@ func logModifyAssistantRequest(log *zap.Logger, data []byte, prod, private bool,
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("id", assistantId),
 			zap.String("model", ar.Model),
 			zap.Any("tools", ar.Tools),
@@ -105,7 +106,7 @@ func logModifyAssistantRequest(log *zap.Logger, data []byte, prod, private bool,
 func logDeleteAssistantRequest(log *zap.Logger, data []byte, prod bool, cid, assistantId string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("id", assistantId),
 		}
 
@@ -123,7 +124,7 @@ func logDeleteAssistantResponse(log *zap.Logger, data []byte, prod bool, cid str
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("id", adr.ID),
 			zap.String("object", adr.Object),
 			zap.Bool("deleted", adr.Deleted),
@@ -136,7 +137,7 @@ func logDeleteAssistantResponse(log *zap.Logger, data []byte, prod bool, cid str
 func logListAssistantsRequest(log *zap.Logger, prod bool, cid string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 		}
 
 		log.Info("openai list assistants request", fields...)
@@ -159,7 +160,7 @@ func logListAssistantsResponse(log *zap.Logger, data []byte, prod, private bool,
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.Any("assistants", assistants.Assistants),
 		}
 
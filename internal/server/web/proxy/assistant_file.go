@@ -3,6 +3,7 @@ package proxy
 import (
 	"encoding/json"
 
+	"github.com/bricks-cloud/bricksllm/internal/logger"
 	goopenai "github.com/sashabaranov/go-openai"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -18,7 +19,7 @@ func logCreateAssistantFileRequest(log *zap.Logger, data []byte, prod bool, cid,
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("assistant_id", aid),
 			zap.String("file_id", afr.FileID),
 		}
@@ -37,7 +38,7 @@ func logAssistantFileResponse(log *zap.Logger, data []byte, prod bool, cid strin
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("assistant_id", af.AssistantID),
 			zap.String("id", af.ID),
 			zap.String("object", af.Object),
@@ -51,7 +52,7 @@ func logAssistantFileResponse(log *zap.Logger, data []byte, prod bool, cid strin
 func logRetrieveAssistantFileRequest(log *zap.Logger, prod bool, cid, fid, aid string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("assistant_id", aid),
 			zap.String("file_id", fid),
 		}
@@ -63,7 +64,7 @@ func logRetrieveAssistantFileRequest(log *zap.Logger, prod bool, cid, fid, aid s
 func logDeleteAssistantFileRequest(log *zap.Logger, data []byte, prod bool, cid, fid, aid string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("assistant_id", aid),
 			zap.String("file_id", fid),
 		}
@@ -82,7 +83,7 @@ func logDeleteAssistantFileResponse(log *zap.Logger, data []byte, prod bool, cid
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("id", dr.ID),
 			zap.String("object", dr.Object),
 			zap.Bool("deleted", dr.Deleted),
@@ -95,7 +96,7 @@ func logDeleteAssistantFileResponse(log *zap.Logger, data []byte, prod bool, cid
 func logListAssistantFilesRequest(log *zap.Logger, prod bool, cid, aid string, params map[string]string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("assistant_id", aid),
 		}
 
@@ -129,7 +130,7 @@ func logListAssistantFilesResponse(log *zap.Logger, data []byte, prod bool, cid
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.Any("assistant_files", files.AssistantFiles),
 		}
 
@@ -1,6 +1,7 @@
 package proxy
 
 import (
+	"github.com/bricks-cloud/bricksllm/internal/logger"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -16,7 +17,7 @@ type SpeechRequest struct {
 func logCreateSpeechRequest(log *zap.Logger, sr *SpeechRequest, prod, private bool, cid string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("model", sr.Model),
 			zap.String("voice", sr.Voice),
 		}
@@ -40,7 +41,7 @@ func logCreateSpeechRequest(log *zap.Logger, sr *SpeechRequest, prod, private bo
 func logCreateTranscriptionRequest(log *zap.Logger, model, language, prompt, responseFormat string, temperature float64, prod, private bool, cid string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("model", model),
 		}
 
@@ -67,7 +68,7 @@ func logCreateTranscriptionRequest(log *zap.Logger, model, language, prompt, res
 func logCreateTranslationRequest(log *zap.Logger, model, prompt, responseFormat string, temperature float64, prod, private bool, cid string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("model", model),
 		}
 
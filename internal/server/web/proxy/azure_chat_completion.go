@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/bricks-cloud/bricksllm/internal/guardrail"
 	"github.com/bricks-cloud/bricksllm/internal/stats"
 	"github.com/gin-gonic/gin"
 	goopenai "github.com/sashabaranov/go-openai"
@@ -25,7 +26,7 @@ func buildAzureUrl(path, deploymentId, apiVersion, resourceName string) string {
 	return fmt.Sprintf("https://%s.openai.azure.com/openai/deployments/%s/embeddings?api-version=%s", resourceName, deploymentId, apiVersion)
 }
 
-func getAzureChatCompletionHandler(r recorder, prod, private bool, psm ProviderSettingsManager, client http.Client, kms keyMemStorage, log *zap.Logger, aoe azureEstimator, timeOut time.Duration) gin.HandlerFunc {
+func getAzureChatCompletionHandler(r recorder, prod, private bool, psm ProviderSettingsManager, client http.Client, kms keyMemStorage, log *zap.Logger, aoe azureEstimator, gc *guardrail.Client, timeOut time.Duration) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		stats.Incr("bricksllm.proxy.get_azure_chat_completion_handler.requests", nil, 1)
 
@@ -56,7 +57,7 @@ func getAzureChatCompletionHandler(r recorder, prod, private bool, psm ProviderS
 		}
 
 		start := time.Now()
-		res, err := client.Do(req)
+		res, err := egressClient(c, client).Do(req)
 		if err != nil {
 			stats.Incr("bricksllm.proxy.get_azure_chat_completion_handler.http_client_error", nil, 1)
 			logError(log, "error when sending chat completion http request to azure openai", prod, cid, err)
@@ -66,11 +67,7 @@ func getAzureChatCompletionHandler(r recorder, prod, private bool, psm ProviderS
 
 		defer res.Body.Close()
 
-		for name, values := range res.Header {
-			for _, value := range values {
-				c.Header(name, value)
-			}
-		}
+		copyResponseHeaders(c, res.Header)
 
 		if res.StatusCode == http.StatusOK && !isStreaming {
 			dur := time.Now().Sub(start)
@@ -83,6 +80,11 @@ func getAzureChatCompletionHandler(r recorder, prod, private bool, psm ProviderS
 				return
 			}
 
+			if !isWellFormedResponse(bytes) {
+				rejectMalformedResponse(c, "azure")
+				return
+			}
+
 			var cost float64 = 0
 			chatRes := &goopenai.ChatCompletionResponse{}
 			stats.Incr("bricksllm.proxy.get_azure_chat_completion_handler.success", nil, 1)
@@ -114,6 +116,7 @@ func getAzureChatCompletionHandler(r recorder, prod, private bool, psm ProviderS
 			c.Set("costInUsd", cost)
 			c.Set("promptTokenCount", chatRes.Usage.PromptTokens)
 			c.Set("completionTokenCount", chatRes.Usage.CompletionTokens)
+			c.Set("toolCallIds", responseToolCallIds(bytes))
 
 			c.Data(res.StatusCode, "application/json", bytes)
 			return
@@ -140,6 +143,7 @@ func getAzureChatCompletionHandler(r recorder, prod, private bool, psm ProviderS
 		// var totalCost float64 = 0
 		// var totalTokens int = 0
 		content := ""
+		toolCallIds := []string{}
 
 		model := ""
 		defer func() {
@@ -148,6 +152,7 @@ func getAzureChatCompletionHandler(r recorder, prod, private bool, psm ProviderS
 			}
 
 			c.Set("content", content)
+			c.Set("toolCallIds", toolCallIds)
 
 			// tks, cost, err := aoe.EstimateChatCompletionStreamCostWithTokenCounts(model, content)
 			// if err != nil {
@@ -171,10 +176,18 @@ func getAzureChatCompletionHandler(r recorder, prod, private bool, psm ProviderS
 
 		stats.Incr("bricksllm.proxy.get_azure_chat_completion_handler.streaming_requests", nil, 1)
 
+		maxStreamed := c.GetInt("maxStreamedTokens")
+		streamed := 0
+		sg := newStreamGuard(c, gc)
+
 		c.Stream(func(w io.Writer) bool {
 			raw, err := buffer.ReadBytes('\n')
 			if err != nil {
 				if err == io.EOF {
+					if sg != nil {
+						sg.flush()
+					}
+
 					return false
 				}
 
@@ -212,7 +225,14 @@ func getAzureChatCompletionHandler(r recorder, prod, private bool, psm ProviderS
 			}
 
 			noPrefixLine := bytes.TrimPrefix(noSpaceLine, headerData)
-			c.SSEvent("", " "+string(noPrefixLine))
+			if sg != nil {
+				if sg.send(noPrefixLine) {
+					stats.Incr("bricksllm.proxy.get_azure_chat_completion_handler.stream_blocked", nil, 1)
+					return false
+				}
+			} else {
+				c.SSEvent("", " "+string(noPrefixLine))
+			}
 
 			if string(noPrefixLine) == "[DONE]" {
 				return false
@@ -230,8 +250,22 @@ func getAzureChatCompletionHandler(r recorder, prod, private bool, psm ProviderS
 			}
 
 			if err == nil {
+				toolCallIds = append(toolCallIds, streamToolCallIds(chatCompletionStreamResp)...)
+
 				if len(chatCompletionStreamResp.Choices) > 0 && len(chatCompletionStreamResp.Choices[0].Delta.Content) != 0 {
 					content += chatCompletionStreamResp.Choices[0].Delta.Content
+					streamed++
+
+					if maxStreamed != 0 && streamed >= maxStreamed {
+						if sg != nil && sg.flush() {
+							stats.Incr("bricksllm.proxy.get_azure_chat_completion_handler.stream_blocked", nil, 1)
+							return false
+						}
+
+						stats.Incr("bricksllm.proxy.get_azure_chat_completion_handler.stream_truncated", nil, 1)
+						truncateStream(c, chatCompletionStreamResp)
+						return false
+					}
 				}
 			}
 
@@ -33,18 +33,16 @@ func getAzureEmbeddingsHandler(r recorder, prod, private bool, psm ProviderSetti
 		ctx, cancel := context.WithTimeout(context.Background(), timeOut)
 		defer cancel()
 
-		req, err := http.NewRequestWithContext(ctx, c.Request.Method, buildAzureUrl(c.FullPath(), c.Param("deployment_id"), c.Query("api-version"), c.GetString("resourceName")), c.Request.Body)
+		body, err := io.ReadAll(c.Request.Body)
 		if err != nil {
-			logError(log, "error when creating openai http request", prod, cid, err)
-			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create openai http request")
+			logError(log, "error when reading embedding request body", prod, cid, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read embedding request body")
 			return
 		}
 
-		copyHttpHeaders(c.Request, req)
-
 		start := time.Now()
 
-		res, err := client.Do(req)
+		status, header, bytes, err := sendEmbeddingBatches(ctx, c, client, buildAzureUrl(c.FullPath(), c.Param("deployment_id"), c.Query("api-version"), c.GetString("resourceName")), body)
 		if err != nil {
 			stats.Incr("bricksllm.proxy.get_azure_embeddings_handler.http_client_error", nil, 1)
 
@@ -52,15 +50,17 @@ func getAzureEmbeddingsHandler(r recorder, prod, private bool, psm ProviderSetti
 			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to send embedding request to azure openai")
 			return
 		}
-		defer res.Body.Close()
 
 		dur := time.Now().Sub(start)
 		stats.Timing("bricksllm.proxy.get_azure_embeddings_handler.latency", dur, nil, 1)
 
-		bytes, err := io.ReadAll(res.Body)
-		if err != nil {
-			logError(log, "error when reading openai embedding response body", prod, cid, err)
-			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read azure openai embedding response body")
+		if batches := c.GetInt("embeddingBatches"); batches > 1 {
+			stats.Incr("bricksllm.proxy.get_azure_embeddings_handler.split_requests", nil, 1)
+			stats.Incr("bricksllm.proxy.get_azure_embeddings_handler.batches", nil, float64(batches))
+		}
+
+		if status == http.StatusOK && !isWellFormedResponse(bytes) {
+			rejectMalformedResponse(c, "azure")
 			return
 		}
 
@@ -68,7 +68,7 @@ func getAzureEmbeddingsHandler(r recorder, prod, private bool, psm ProviderSetti
 		chatRes := &EmbeddingResponse{}
 		promptTokenCounts := 0
 		base64ChatRes := &EmbeddingResponseBase64{}
-		if res.StatusCode == http.StatusOK {
+		if status == http.StatusOK {
 			stats.Incr("bricksllm.proxy.get_azure_embeddings_handler.success", nil, 1)
 			stats.Timing("bricksllm.proxy.get_azure_embeddings_handler.success_latency", dur, nil, 1)
 
@@ -122,7 +122,7 @@ func getAzureEmbeddingsHandler(r recorder, prod, private bool, psm ProviderSetti
 		c.Set("costInUsd", cost)
 		c.Set("promptTokenCount", promptTokenCounts)
 
-		if res.StatusCode != http.StatusOK {
+		if status != http.StatusOK {
 			stats.Timing("bricksllm.proxy.get_azure_embeddings_handler.error_latency", dur, nil, 1)
 			stats.Incr("bricksllm.proxy.get_azure_embeddings_handler.error_response", nil, 1)
 
@@ -135,12 +135,8 @@ func getAzureEmbeddingsHandler(r recorder, prod, private bool, psm ProviderSetti
 			logOpenAiError(log, prod, cid, errorRes)
 		}
 
-		for name, values := range res.Header {
-			for _, value := range values {
-				c.Header(name, value)
-			}
-		}
+		copyResponseHeaders(c, header)
 
-		c.Data(res.StatusCode, "application/json", bytes)
+		c.Data(status, "application/json", bytes)
 	}
 }
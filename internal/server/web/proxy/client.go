@@ -0,0 +1,68 @@
+package proxy
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+const (
+	maxUserAgentLength = 255
+	maxSdkLength       = 64
+)
+
+var (
+	// official OpenAI and Anthropic SDKs identify themselves like
+	// "OpenAI/Python 1.30.1" or "AsyncAnthropic/JS 0.20.0".
+	officialSdkRegex = regexp.MustCompile(`^(?:Async)?(OpenAI|Anthropic)/([A-Za-z]+) v?([0-9A-Za-z.\-+]+)`)
+	productRegex     = regexp.MustCompile(`^([A-Za-z0-9._\-]+)(?:/v?([0-9A-Za-z.\-+]+))?`)
+)
+
+// clientInfo identifies the client library that made a proxy request.
+type clientInfo struct {
+	userAgent  string
+	sdk        string
+	sdkVersion string
+}
+
+// parseClient reads the sdk from the User-Agent and the X-Stainless headers
+// sent by official SDKs. Other clients are identified by the first product of
+// their User-Agent, e.g. python-requests or curl.
+func parseClient(h http.Header) *clientInfo {
+	ci := &clientInfo{
+		userAgent: truncateHeaderValue(strings.TrimSpace(h.Get("User-Agent")), maxUserAgentLength),
+	}
+
+	lang := strings.ToLower(h.Get("X-Stainless-Lang"))
+	version := h.Get("X-Stainless-Package-Version")
+
+	if matches := officialSdkRegex.FindStringSubmatch(ci.userAgent); matches != nil {
+		if len(lang) == 0 {
+			lang = strings.ToLower(matches[2])
+		}
+
+		if len(version) == 0 {
+			version = matches[3]
+		}
+
+		ci.sdk = strings.ToLower(matches[1]) + "-" + lang
+	} else if len(lang) != 0 {
+		ci.sdk = "stainless-" + lang
+	} else if matches := productRegex.FindStringSubmatch(ci.userAgent); matches != nil {
+		ci.sdk = strings.ToLower(matches[1])
+		version = matches[2]
+	}
+
+	ci.sdk = truncateHeaderValue(ci.sdk, maxSdkLength)
+	ci.sdkVersion = truncateHeaderValue(version, maxSdkLength)
+
+	return ci
+}
+
+func truncateHeaderValue(value string, max int) string {
+	if len(value) > max {
+		value = value[:max]
+	}
+
+	return strings.ToValidUTF8(value, "")
+}
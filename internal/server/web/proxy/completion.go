@@ -3,6 +3,7 @@ package proxy
 import (
 	"encoding/json"
 
+	"github.com/bricks-cloud/bricksllm/internal/logger"
 	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -18,7 +19,7 @@ func logCompletionRequest(log *zap.Logger, data []byte, prod, private bool, cid
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("model", cr.Model),
 			zap.Int("max_tokens_to_sample", cr.MaxTokensToSample),
 			zap.Any("stop_sequnces", cr.StopSequences),
@@ -50,7 +51,7 @@ func logCompletionResponse(log *zap.Logger, data []byte, prod, private bool, cid
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("stop_reason", cr.StopReason),
 			zap.String("model", cr.Model),
 		}
@@ -0,0 +1,200 @@
+package proxy
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	encodingGzip = "gzip"
+	encodingZstd = "zstd"
+)
+
+// supportedEncodings are in order of preference when a client accepts
+// several of them equally.
+var supportedEncodings = []string{encodingZstd, encodingGzip}
+
+type encoder interface {
+	io.Writer
+	Reset(w io.Writer)
+	Flush() error
+	Close() error
+}
+
+var encoderPools = map[string]*sync.Pool{
+	encodingGzip: {
+		New: func() interface{} {
+			w, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+			return w
+		},
+	},
+	encodingZstd: {
+		New: func() interface{} {
+			// a single goroutine per encoder since responses are small and
+			// encoders are pooled across requests.
+			w, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+			return w
+		},
+	},
+}
+
+type compressResponseWriter struct {
+	gin.ResponseWriter
+	encoding string
+	enc      encoder
+	decided  bool
+	compress bool
+}
+
+// shouldCompress is evaluated on the first write so that handlers have already
+// copied upstream headers and set the content type.
+func (w *compressResponseWriter) shouldCompress() bool {
+	if w.decided {
+		return w.compress
+	}
+
+	w.decided = true
+
+	h := w.ResponseWriter.Header()
+	if len(h.Get("Content-Encoding")) != 0 {
+		return false
+	}
+
+	if strings.HasPrefix(h.Get("Content-Type"), "text/event-stream") {
+		return false
+	}
+
+	status := w.ResponseWriter.Status()
+	if status == http.StatusNoContent || status == http.StatusNotModified {
+		return false
+	}
+
+	h.Set("Content-Encoding", w.encoding)
+	h.Add("Vary", "Accept-Encoding")
+	h.Del("Content-Length")
+
+	enc := encoderPools[w.encoding].Get().(encoder)
+	enc.Reset(w.ResponseWriter)
+
+	w.enc = enc
+	w.compress = true
+
+	return true
+}
+
+func (w *compressResponseWriter) Write(data []byte) (int, error) {
+	if !w.shouldCompress() {
+		return w.ResponseWriter.Write(data)
+	}
+
+	return w.enc.Write(data)
+}
+
+func (w *compressResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *compressResponseWriter) Flush() {
+	if w.enc != nil {
+		w.enc.Flush()
+	}
+
+	w.ResponseWriter.Flush()
+}
+
+func (w *compressResponseWriter) close() {
+	if w.enc == nil {
+		return
+	}
+
+	w.enc.Close()
+	encoderPools[w.encoding].Put(w.enc)
+	w.enc = nil
+}
+
+// negotiateEncoding returns the supported encoding with the highest quality
+// in an Accept-Encoding header, or an empty string when the response should
+// not be compressed.
+func negotiateEncoding(header string) string {
+	qualities := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		encoding := strings.ToLower(strings.TrimSpace(fields[0]))
+		if len(encoding) == 0 {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				parsed, err := strconv.ParseFloat(param[2:], 64)
+				if err == nil {
+					q = parsed
+				}
+			}
+		}
+
+		qualities[encoding] = q
+	}
+
+	selected, best := "", 0.0
+	for _, encoding := range supportedEncodings {
+		q, ok := qualities[encoding]
+		if !ok {
+			// encodings that are not listed are accepted through *.
+			q, ok = qualities["*"]
+		}
+
+		if ok && q > best {
+			selected, best = encoding, q
+		}
+	}
+
+	return selected
+}
+
+// getCompressionMiddleware compresses proxy responses toward the client with
+// gzip or zstd when the client negotiates it via Accept-Encoding. Upstream
+// bodies are still read in plain form by the handlers, so usage parsing is
+// unaffected.
+func getCompressionMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c == nil || c.Request == nil {
+			c.Next()
+			return
+		}
+
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if len(encoding) == 0 || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		w := &compressResponseWriter{
+			ResponseWriter: c.Writer,
+			encoding:       encoding,
+		}
+
+		c.Writer = w
+		defer func() {
+			if w.compress {
+				stats.Incr("bricksllm.proxy.get_compression_middleware.compressed_responses", []string{
+					"encoding:" + encoding,
+				}, 1)
+			}
+
+			w.close()
+		}()
+
+		c.Next()
+	}
+}
@@ -0,0 +1,202 @@
+package proxy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	cases := []struct {
+		header   string
+		expected string
+	}{
+		{header: "", expected: ""},
+		{header: "identity", expected: ""},
+		{header: "br", expected: ""},
+		{header: "gzip", expected: encodingGzip},
+		{header: "zstd", expected: encodingZstd},
+		{header: "GZIP", expected: encodingGzip},
+		{header: "gzip, deflate, br", expected: encodingGzip},
+		{header: "gzip, zstd", expected: encodingZstd},
+		{header: "zstd;q=0.5, gzip", expected: encodingGzip},
+		{header: "zstd, gzip;q=0.8", expected: encodingZstd},
+		{header: "gzip;q=0", expected: ""},
+		{header: "zstd;q=0, gzip;q=0.1", expected: encodingGzip},
+		{header: "*", expected: encodingZstd},
+		{header: "*;q=0.5, zstd;q=0", expected: encodingGzip},
+		{header: "*;q=0", expected: ""},
+		{header: "gzip;q=invalid", expected: encodingGzip},
+		{header: " gzip ; q=1.0 ,", expected: encodingGzip},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.header, func(t *testing.T) {
+			assert.Equal(t, tc.expected, negotiateEncoding(tc.header))
+		})
+	}
+}
+
+func decode(t *testing.T, encoding string, body []byte) []byte {
+	switch encoding {
+	case encodingGzip:
+		r, err := gzip.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+
+		decoded, err := io.ReadAll(r)
+		require.NoError(t, err)
+		return decoded
+	case encodingZstd:
+		r, err := zstd.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		defer r.Close()
+
+		decoded, err := io.ReadAll(r)
+		require.NoError(t, err)
+		return decoded
+	}
+
+	return body
+}
+
+const embeddingsResponse = `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.1,0.2,0.3]}],"model":"text-embedding-3-small","usage":{"prompt_tokens":8,"total_tokens":8}}`
+
+// newUpstream answers like a provider that gzips responses for clients
+// accepting it.
+func newUpstream(acceptEncodings *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*acceptEncodings = append(*acceptEncodings, r.Header.Get("Accept-Encoding"))
+
+		w.Header().Set("Content-Type", "application/json")
+		if negotiateEncoding(r.Header.Get("Accept-Encoding")) == encodingGzip {
+			w.Header().Set("Content-Encoding", "gzip")
+			gw := gzip.NewWriter(w)
+			gw.Write([]byte(embeddingsResponse))
+			gw.Close()
+			return
+		}
+
+		w.Write([]byte(embeddingsResponse))
+	}))
+}
+
+// newCompressedProxy forwards requests to the upstream the way provider
+// handlers do and parses the usage of the response.
+func newCompressedProxy(upstreamUrl string, promptTokens *int64) *gin.Engine {
+	router := gin.New()
+	router.Use(getCompressionMiddleware())
+	router.POST("/api/providers/openai/v1/embeddings", func(c *gin.Context) {
+		req, _ := http.NewRequest(http.MethodPost, upstreamUrl, c.Request.Body)
+		copyHttpHeaders(c.Request, req)
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			c.Status(http.StatusBadGateway)
+			return
+		}
+		defer res.Body.Close()
+
+		data, _ := io.ReadAll(res.Body)
+		*promptTokens = gjson.GetBytes(data, "usage.prompt_tokens").Int()
+
+		c.Data(res.StatusCode, "application/json", data)
+	})
+
+	return router
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	for _, encoding := range []string{encodingGzip, encodingZstd} {
+		t.Run("usage is parsed from upstream responses of "+encoding+" clients", func(t *testing.T) {
+			seen := []string{}
+			upstream := newUpstream(&seen)
+			defer upstream.Close()
+
+			var promptTokens int64
+			router := newCompressedProxy(upstream.URL, &promptTokens)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/providers/openai/v1/embeddings", bytes.NewReader([]byte(`{"input":"hi"}`)))
+			req.Header.Set("Accept-Encoding", encoding)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, encoding, w.Header().Get("Content-Encoding"))
+			assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+			assert.Equal(t, embeddingsResponse, string(decode(t, encoding, w.Body.Bytes())))
+
+			// the upstream is asked for gzip by the http client, which then
+			// decompresses the body, instead of the encoding of the client.
+			require.Len(t, seen, 1)
+			assert.Equal(t, encodingGzip, seen[0])
+			assert.Equal(t, int64(8), promptTokens)
+		})
+	}
+
+	t.Run("responses are not compressed without a supported encoding", func(t *testing.T) {
+		seen := []string{}
+		upstream := newUpstream(&seen)
+		defer upstream.Close()
+
+		var promptTokens int64
+		router := newCompressedProxy(upstream.URL, &promptTokens)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/providers/openai/v1/embeddings", bytes.NewReader([]byte(`{"input":"hi"}`)))
+		req.Header.Set("Accept-Encoding", "br")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, embeddingsResponse, w.Body.String())
+		assert.Equal(t, int64(8), promptTokens)
+	})
+
+	t.Run("streaming and already encoded responses are not compressed", func(t *testing.T) {
+		router := gin.New()
+		router.Use(getCompressionMiddleware())
+		router.GET("/stream", func(c *gin.Context) {
+			c.Header("Content-Type", "text/event-stream")
+			c.String(http.StatusOK, "data: {}\n\n")
+		})
+		router.GET("/encoded", func(c *gin.Context) {
+			c.Header("Content-Encoding", "br")
+			c.Data(http.StatusOK, "application/octet-stream", []byte("raw"))
+		})
+
+		for _, path := range []string{"/stream", "/encoded"} {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			req.Header.Set("Accept-Encoding", "zstd, gzip")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.NotEqual(t, encodingZstd, w.Header().Get("Content-Encoding"), path)
+			assert.NotEqual(t, encodingGzip, w.Header().Get("Content-Encoding"), path)
+		}
+	})
+
+	t.Run("pooled encoders are reused across responses", func(t *testing.T) {
+		router := gin.New()
+		router.Use(getCompressionMiddleware())
+		router.GET("/json", func(c *gin.Context) {
+			c.Data(http.StatusOK, "application/json", []byte(c.Query("v")))
+		})
+
+		for _, v := range []string{"first", "second", "third"} {
+			req := httptest.NewRequest(http.MethodGet, "/json?v="+v, nil)
+			req.Header.Set("Accept-Encoding", "zstd")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, v, string(decode(t, encodingZstd, w.Body.Bytes())))
+		}
+	})
+}
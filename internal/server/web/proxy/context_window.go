@@ -0,0 +1,178 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+const (
+	contextWindowMetadataKey       = "bricksllm_context_window"
+	droppedMessagesMetadataKey     = "bricksllm_context_window_dropped_messages"
+	contextWindowTokenizerFallback = "gpt-4"
+	contextWindowDecisionTruncated = "truncated"
+	contextWindowDecisionRerouted  = "rerouted"
+)
+
+// countContextTokens counts the prompt tokens of a request with the
+// tokenizer of model, or the one of gpt-4 for models tiktoken does not know.
+func countContextTokens(e estimator, model string, ccr *goopenai.ChatCompletionRequest) (int, error) {
+	tks, err := e.EstimateChatCompletionPromptTokenCounts(model, ccr)
+	if err == nil {
+		return tks, nil
+	}
+
+	return e.EstimateChatCompletionPromptTokenCounts(contextWindowTokenizerFallback, ccr)
+}
+
+func rejectContextWindow(c *gin.Context, tks, limit int, model string) {
+	stats.Incr("bricksllm.proxy.get_middleware.context_window_exceeded", nil, 1)
+	JSONError(c, http.StatusBadRequest, codeContextWindowExceeded, fmt.Sprintf("[BricksLLM] prompt of %d tokens exceeds the %d tokens available for prompts of model %s", tks, limit, model), map[string]interface{}{
+		"promptTokens":    tks,
+		"maxPromptTokens": limit,
+		"model":           model,
+	})
+	c.Abort()
+}
+
+// applyContextWindow enforces the context window policy of a chat completion
+// route. It returns the route and body to forward along with the decision
+// taken, or false after responding to the client.
+func applyContextWindow(c *gin.Context, rc *route.Route, body []byte, e estimator, kc *key.ResponseKey, settings []*provider.Setting, ps pauseMemStorage) (*route.Route, []byte, string, bool) {
+	policy := rc.ContextWindow
+	limit, model := policy.PromptLimit(rc.Steps)
+	if len(model) == 0 {
+		return rc, body, "", true
+	}
+
+	ccr := &goopenai.ChatCompletionRequest{}
+	if err := json.Unmarshal(body, ccr); err != nil {
+		return rc, body, "", true
+	}
+
+	tks, err := countContextTokens(e, model, ccr)
+	if err != nil {
+		stats.Incr("bricksllm.proxy.get_middleware.context_window_count_error", nil, 1)
+		return rc, body, "", true
+	}
+
+	if tks <= limit {
+		return rc, body, "", true
+	}
+
+	switch policy.Action {
+	case route.ContextWindowTruncate:
+		truncated, dropped, ok := truncateOldestMessages(e, model, body, ccr, limit)
+		if !ok {
+			rejectContextWindow(c, tks, limit, model)
+			return nil, nil, "", false
+		}
+
+		stats.Incr("bricksllm.proxy.get_middleware.context_window_truncated", nil, 1)
+		c.Set("droppedMessages", dropped)
+		return rc, truncated, contextWindowDecisionTruncated, true
+	case route.ContextWindowReroute:
+		rerouted, p := withoutPausedSteps(rc.Rerouted(), ps)
+		if p != nil {
+			rejectPaused(c, p)
+			return nil, nil, "", false
+		}
+
+		rerouted = withoutUnsettledSteps(rerouted, settings)
+		if len(rerouted.Steps) == 0 {
+			rejectContextWindow(c, tks, limit, model)
+			return nil, nil, "", false
+		}
+
+		if longLimit, longModel := policy.PromptLimit(rerouted.Steps); len(longModel) != 0 && tks > longLimit {
+			rejectContextWindow(c, tks, longLimit, longModel)
+			return nil, nil, "", false
+		}
+
+		for _, step := range rerouted.Steps {
+			if !kc.ModelPolicy.IsAllowed(step.Model) {
+				stats.Incr("bricksllm.proxy.get_middleware.route_model_not_allowed", nil, 1)
+				JSONError(c, http.StatusForbidden, codeModelNotAllowed, fmt.Sprintf("[BricksLLM] model %s of route %s is not allowed", step.Model, rc.Path), map[string]interface{}{
+					"model": step.Model,
+					"route": rc.Path,
+				})
+				c.Abort()
+				return nil, nil, "", false
+			}
+		}
+
+		stats.Incr("bricksllm.proxy.get_middleware.context_window_rerouted", nil, 1)
+		return rerouted, body, contextWindowDecisionRerouted, true
+	}
+
+	rejectContextWindow(c, tks, limit, model)
+	return nil, nil, "", false
+}
+
+// truncateOldestMessages drops the oldest messages that are not system
+// messages until the prompt fits limit. Tool results are dropped along with
+// the message that called the tools, and the last message is always kept.
+func truncateOldestMessages(e estimator, model string, body []byte, ccr *goopenai.ChatCompletionRequest, limit int) ([]byte, int, bool) {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, 0, false
+	}
+
+	raw := []json.RawMessage{}
+	if err := json.Unmarshal(fields["messages"], &raw); err != nil || len(raw) == 0 || len(raw) != len(ccr.Messages) {
+		return nil, 0, false
+	}
+
+	messages := ccr.Messages
+	dropped := 0
+	for {
+		start := -1
+		for index, message := range messages[:len(messages)-1] {
+			if message.Role != goopenai.ChatMessageRoleSystem {
+				start = index
+				break
+			}
+		}
+
+		if start == -1 {
+			return nil, 0, false
+		}
+
+		end := start + 1
+		for end < len(messages)-1 && messages[end].Role == goopenai.ChatMessageRoleTool {
+			end++
+		}
+
+		messages = append(messages[:start:start], messages[end:]...)
+		raw = append(raw[:start:start], raw[end:]...)
+		dropped += end - start
+
+		ccr.Messages = messages
+		tks, err := countContextTokens(e, model, ccr)
+		if err != nil {
+			return nil, 0, false
+		}
+
+		if tks <= limit {
+			data, err := json.Marshal(raw)
+			if err != nil {
+				return nil, 0, false
+			}
+
+			fields["messages"] = data
+			truncated, err := json.Marshal(fields)
+			if err != nil {
+				return nil, 0, false
+			}
+
+			return truncated, dropped, true
+		}
+	}
+}
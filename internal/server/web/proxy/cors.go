@@ -0,0 +1,142 @@
+package proxy
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/cors"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+)
+
+// corsPolicy returns the cors policy of a request. Routes with their own
+// policy replace the global policy for their requests.
+func corsPolicy(c *gin.Context, global *cors.Policy, rm routeManager) *cors.Policy {
+	if path := c.Request.URL.Path; strings.HasPrefix(path, "/api/routes/") {
+		if rc := rm.GetCorsRouteFromMemDb(strings.TrimPrefix(path, "/api/routes")); rc != nil {
+			return rc.Cors
+		}
+	}
+
+	return global
+}
+
+// corsResponseWriter keeps the cors headers of the proxy when handlers copy
+// the headers of provider responses, which can carry their own cors headers.
+type corsResponseWriter struct {
+	gin.ResponseWriter
+	headers map[string]string
+}
+
+func (w *corsResponseWriter) apply() {
+	if w.Written() {
+		return
+	}
+
+	for name, value := range w.headers {
+		w.Header().Set(name, value)
+	}
+}
+
+func (w *corsResponseWriter) WriteHeader(code int) {
+	w.apply()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *corsResponseWriter) WriteHeaderNow() {
+	w.apply()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *corsResponseWriter) Write(data []byte) (int, error) {
+	w.apply()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *corsResponseWriter) WriteString(s string) (int, error) {
+	w.apply()
+	return w.ResponseWriter.WriteString(s)
+}
+
+// getCorsMiddleware answers preflight requests and adds cors headers to the
+// responses of browsers calling the proxy from allowed origins. Preflight
+// requests are answered before the main middleware, since they carry no
+// credentials and must not be authenticated or recorded.
+func getCorsMiddleware(global *cors.Policy, rm routeManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if len(origin) == 0 {
+			c.Next()
+			return
+		}
+
+		preflight := c.Request.Method == http.MethodOptions && len(c.GetHeader("Access-Control-Request-Method")) != 0
+
+		p := corsPolicy(c, global, rm)
+		if p == nil {
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Add("Vary", "Origin")
+		if !p.AllowsOrigin(origin) {
+			stats.Incr("bricksllm.proxy.get_cors_middleware.origin_not_allowed", nil, 1)
+
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+
+			c.Next()
+			return
+		}
+
+		headers := map[string]string{
+			"Access-Control-Allow-Origin": origin,
+		}
+
+		if p.AllowsAnyOrigin() && !p.AllowCredentials {
+			headers["Access-Control-Allow-Origin"] = "*"
+		}
+
+		if p.AllowCredentials {
+			headers["Access-Control-Allow-Credentials"] = "true"
+		}
+
+		if !preflight {
+			headers["Access-Control-Expose-Headers"] = strings.Join(append([]string{requestIdHeader}, p.ExposedHeaders...), ", ")
+			c.Writer = &corsResponseWriter{
+				ResponseWriter: c.Writer,
+				headers:        headers,
+			}
+
+			c.Next()
+			return
+		}
+
+		for name, value := range headers {
+			c.Header(name, value)
+		}
+
+		stats.Incr("bricksllm.proxy.get_cors_middleware.preflight", nil, 1)
+
+		c.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+		c.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+
+		if p.AllowsAnyHeader() {
+			if requested := c.GetHeader("Access-Control-Request-Headers"); len(requested) != 0 {
+				c.Header("Access-Control-Allow-Headers", requested)
+			}
+		} else if len(p.AllowedHeaders) != 0 {
+			c.Header("Access-Control-Allow-Headers", strings.Join(p.AllowedHeaders, ", "))
+		}
+
+		if p.MaxAge != 0 {
+			c.Header("Access-Control-Max-Age", strconv.Itoa(p.MaxAge))
+		}
+
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
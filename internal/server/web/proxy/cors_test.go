@@ -0,0 +1,128 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bricks-cloud/bricksllm/internal/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCorsMiddleware(t *testing.T) {
+	global := &cors.Policy{AllowedOrigins: []string{"https://app.example.com"}, AllowedHeaders: []string{"Authorization", "Content-Type"}, MaxAge: 600}
+	rm := fakeRouteManager{
+		"/chat":   {Path: "/chat", Cors: &cors.Policy{AllowedOrigins: []string{"*"}, AllowedHeaders: []string{"*"}}},
+		"/shared": {Path: "/shared", Cors: &cors.Policy{AllowedOrigins: []string{"https://*.example.com"}, AllowCredentials: true}},
+	}
+
+	cases := []struct {
+		name     string
+		method   string
+		path     string
+		headers  map[string]string
+		expected int
+		response map[string]string
+	}{
+		{
+			name:     "preflight requests from allowed origins",
+			method:   http.MethodOptions,
+			path:     "/api/providers/openai/v1/chat/completions",
+			headers:  map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "POST"},
+			expected: http.StatusNoContent,
+			response: map[string]string{"Access-Control-Allow-Origin": "https://app.example.com", "Access-Control-Allow-Headers": "Authorization, Content-Type", "Access-Control-Max-Age": "600", "Access-Control-Allow-Credentials": ""},
+		},
+		{
+			name:     "preflight requests from other origins",
+			method:   http.MethodOptions,
+			path:     "/api/providers/openai/v1/chat/completions",
+			headers:  map[string]string{"Origin": "https://evil.example.com", "Access-Control-Request-Method": "POST"},
+			expected: http.StatusForbidden,
+			response: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:     "requested headers are allowed by routes allowing any header",
+			method:   http.MethodOptions,
+			path:     "/api/routes/chat",
+			headers:  map[string]string{"Origin": "https://any.dev", "Access-Control-Request-Method": "POST", "Access-Control-Request-Headers": "X-Custom"},
+			expected: http.StatusNoContent,
+			response: map[string]string{"Access-Control-Allow-Origin": "*", "Access-Control-Allow-Headers": "X-Custom"},
+		},
+		{
+			name:     "route policies replace the global policy",
+			method:   http.MethodOptions,
+			path:     "/api/routes/shared",
+			headers:  map[string]string{"Origin": "https://team.example.com", "Access-Control-Request-Method": "POST"},
+			expected: http.StatusNoContent,
+			response: map[string]string{"Access-Control-Allow-Origin": "https://team.example.com", "Access-Control-Allow-Credentials": "true"},
+		},
+		{
+			name:     "requests from allowed origins",
+			method:   http.MethodPost,
+			path:     "/api/providers/openai/v1/chat/completions",
+			headers:  map[string]string{"Origin": "https://app.example.com"},
+			expected: http.StatusOK,
+			response: map[string]string{"Access-Control-Allow-Origin": "https://app.example.com", "Access-Control-Expose-Headers": requestIdHeader, "Vary": "Origin"},
+		},
+		{
+			name:     "requests from other origins are passed on without cors headers",
+			method:   http.MethodPost,
+			path:     "/api/providers/openai/v1/chat/completions",
+			headers:  map[string]string{"Origin": "https://evil.example.com"},
+			expected: http.StatusOK,
+			response: map[string]string{"Access-Control-Allow-Origin": "https://provider.example.com", "Access-Control-Expose-Headers": ""},
+		},
+		{
+			name:     "requests without origins",
+			method:   http.MethodOptions,
+			path:     "/api/providers/openai/v1/chat/completions",
+			headers:  map[string]string{"Access-Control-Request-Method": "POST"},
+			expected: http.StatusOK,
+			response: map[string]string{"Access-Control-Allow-Origin": "https://provider.example.com", "Vary": ""},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(getCorsMiddleware(global, rm))
+			r.Handle(tc.method, "/api/*path", func(c *gin.Context) {
+				// cors headers of providers are replaced by the ones of the proxy.
+				c.Header("Access-Control-Allow-Origin", "https://provider.example.com")
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			for name, value := range tc.headers {
+				req.Header.Set(name, value)
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expected, w.Code)
+			for name, value := range tc.response {
+				assert.Equal(t, value, w.Header().Get(name), name)
+			}
+		})
+	}
+
+	t.Run("proxies without a policy", func(t *testing.T) {
+		r := gin.New()
+		r.Use(getCorsMiddleware(nil, fakeRouteManager{}))
+		r.OPTIONS("/api/*path", func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		req := httptest.NewRequest(http.MethodOptions, "/api/providers/openai/v1/chat/completions", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", "POST")
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+}
@@ -42,6 +42,8 @@ func getContentFromJson(bytes []byte, contentLoc string) string {
 	return content
 }
 
+const upstreamErrorMetadataKey = "bricksllm_upstream_error"
+
 type Error struct {
 	Type    string `json:"type"`
 	Message string `json:"message"`
@@ -68,7 +70,7 @@ func getCustomProviderHandler(prod, private bool, psm ProviderSettingsManager, c
 		rc, ok := raw.(*custom.RouteConfig)
 		if !exists || !ok {
 			stats.Incr("bricksllm.proxy.get_custom_provider_handler.route_config_not_found", tags, 1)
-			JSON(c, http.StatusNotFound, "[BricksLLM] requested route config is not found")
+			JSONError(c, http.StatusNotFound, codeRouteNotFound, "[BricksLLM] requested route config is not found", nil)
 			return
 		}
 
@@ -99,7 +101,7 @@ func getCustomProviderHandler(prod, private bool, psm ProviderSettingsManager, c
 		}
 
 		start := time.Now()
-		res, err := client.Do(req)
+		res, err := egressClient(c, client).Do(req)
 		if err != nil {
 			stats.Incr("bricksllm.proxy.get_custom_provider_handler.http_client_error", tags, 1)
 
@@ -120,6 +122,11 @@ func getCustomProviderHandler(prod, private bool, psm ProviderSettingsManager, c
 				return
 			}
 
+			if !isWellFormedResponse(bytes) {
+				rejectMalformedResponse(c, c.Param("provider"))
+				return
+			}
+
 			c.Set("response", bytes)
 
 			// tks, err := countTokensFromJson(bytes, rc.ResponseCompletionLocation)
@@ -143,6 +150,10 @@ func getCustomProviderHandler(prod, private bool, psm ProviderSettingsManager, c
 				return
 			}
 
+			if msg := rc.ExtractError(bytes); len(msg) != 0 {
+				c.Set("upstreamError", msg)
+			}
+
 			logError(log, "error response from the custom provider", prod, cid, errors.New(string(bytes)))
 			c.Data(res.StatusCode, "application/json", bytes)
 			return
@@ -150,9 +161,22 @@ func getCustomProviderHandler(prod, private bool, psm ProviderSettingsManager, c
 
 		buffer := bufio.NewReader(res.Body)
 		aggregated := ""
+		usage := &custom.Usage{}
 		defer func() {
 			c.Set("content", aggregated)
 
+			if usage.PromptTokens != nil {
+				c.Set("promptTokenCount", *usage.PromptTokens)
+			}
+
+			if usage.CompletionTokens != nil {
+				c.Set("completionTokenCount", *usage.CompletionTokens)
+			}
+
+			if usage.CostInUsd != nil {
+				c.Set("costInUsd", *usage.CostInUsd)
+			}
+
 			// tks, err := custom.Count(aggregated)
 			// if err != nil {
 			// 	stats.Incr("bricksllm.proxy.get_custom_provider_handler.count_error", nil, 1)
@@ -211,6 +235,8 @@ func getCustomProviderHandler(prod, private bool, psm ProviderSettingsManager, c
 				return false
 			}
 
+			rc.ExtractUsage(noPrefixLine, usage)
+
 			content := getContentFromJson(noPrefixLine, rc.StreamResponseCompletionLocation)
 			aggregated += content
 
@@ -0,0 +1,176 @@
+package proxy
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/guardrail"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	decisionOutcomeHit       = "hit"
+	decisionOutcomeMiss      = "miss"
+	decisionOutcomePassed    = "passed"
+	decisionOutcomeFlagged   = "flagged"
+	decisionOutcomeBlocked   = "blocked"
+	decisionOutcomeError     = "error"
+	decisionOutcomeSelected  = "selected"
+	decisionOutcomeCoalesced = "coalesced"
+	decisionOutcomeSucceeded = "succeeded"
+	decisionOutcomeFailed    = "failed"
+)
+
+// recordDecision adds decisions to the trail kept for the event of the
+// request.
+func recordDecision(c *gin.Context, decisions ...*event.Decision) {
+	if raw, ok := c.Get("decisions"); ok {
+		if existing, ok := raw.([]*event.Decision); ok {
+			decisions = append(existing, decisions...)
+		}
+	}
+
+	c.Set("decisions", decisions)
+}
+
+func guardrailDecision(v *guardrail.Verdict) *event.Decision {
+	d := &event.Decision{
+		Stage:       event.DecisionStageGuardrail,
+		Outcome:     decisionOutcomePassed,
+		Detail:      v.Stage + ":" + v.Guardrail,
+		Provider:    v.Provider,
+		LatencyInMs: v.LatencyInMs,
+	}
+
+	if v.Flagged {
+		d.Outcome = decisionOutcomeFlagged
+		d.Detail += " " + strings.Join(v.Categories, ",")
+	}
+
+	if len(v.Error) != 0 {
+		d.Outcome = decisionOutcomeError
+		d.Detail += " " + v.Error
+	}
+
+	if v.Blocked {
+		d.Outcome = decisionOutcomeBlocked
+	}
+
+	return d
+}
+
+// attemptDecisions turns the calls made while running the steps of a route
+// into decisions. The first call of a step after the first one is a fallback
+// and every following call of the same step is a retry.
+func attemptDecisions(attempts []*route.Attempt) []*event.Decision {
+	decisions := []*event.Decision{}
+	for _, a := range attempts {
+		d := &event.Decision{
+			Stage:       event.DecisionStageUpstream,
+			Outcome:     decisionOutcomeSucceeded,
+			Detail:      "step " + strconv.Itoa(a.Step),
+			Provider:    a.Provider,
+			Model:       a.Model,
+			Status:      a.Status,
+			LatencyInMs: a.LatencyInMs,
+		}
+
+		if a.Retry != 0 {
+			d.Stage = event.DecisionStageRetry
+		} else if a.Step != 0 {
+			d.Stage = event.DecisionStageFallback
+		}
+
+		if a.Status != http.StatusOK {
+			d.Outcome = decisionOutcomeFailed
+		}
+
+		if len(a.Error) != 0 {
+			d.Detail += " " + a.Error
+		}
+
+		decisions = append(decisions, d)
+	}
+
+	return decisions
+}
+
+// requestDecisions returns the decision trail of a request. Decisions taken
+// by the middleware before the request was handled come first, followed by
+// the ones recorded by handlers. Requests forwarded to a single provider get
+// one upstream decision for the call of their handler.
+func requestDecisions(c *gin.Context, selectedProvider string) []*event.Decision {
+	decisions := []*event.Decision{}
+
+	if settingId := c.GetString("overriddenSettingId"); len(settingId) != 0 {
+		decisions = append(decisions, &event.Decision{
+			Stage:    event.DecisionStageOverride,
+			Outcome:  decisionOutcomeSelected,
+			Detail:   "setting " + settingId,
+			Provider: selectedProvider,
+		})
+	}
+
+	if complexity := c.GetString("complexity"); len(complexity) != 0 {
+		decisions = append(decisions, &event.Decision{
+			Stage:   event.DecisionStageComplexity,
+			Outcome: complexity,
+			Detail:  "score " + strconv.FormatFloat(c.GetFloat64("complexityScore"), 'f', 2, 64),
+		})
+	}
+
+	if from := c.GetString("downgradedFrom"); len(from) != 0 {
+		decisions = append(decisions, &event.Decision{
+			Stage:   event.DecisionStageDowngrade,
+			Outcome: decisionOutcomeSelected,
+			Detail:  "downgraded from " + from,
+		})
+	}
+
+	if window := c.GetString("contextWindow"); len(window) != 0 {
+		d := &event.Decision{
+			Stage:   event.DecisionStageContextWindow,
+			Outcome: window,
+		}
+
+		if dropped := c.GetInt("droppedMessages"); dropped != 0 {
+			d.Detail = strconv.Itoa(dropped) + " messages dropped"
+		}
+
+		decisions = append(decisions, d)
+	}
+
+	upstream := false
+	if raw, ok := c.Get("decisions"); ok {
+		if recorded, ok := raw.([]*event.Decision); ok {
+			for _, d := range recorded {
+				if d.Stage == event.DecisionStageUpstream || d.Stage == event.DecisionStageRetry || d.Stage == event.DecisionStageFallback || (d.Stage == event.DecisionStageCache && d.Outcome == decisionOutcomeHit) {
+					upstream = true
+				}
+			}
+
+			decisions = append(decisions, recorded...)
+		}
+	}
+
+	if !upstream && !c.IsAborted() && len(selectedProvider) != 0 && !strings.HasPrefix(c.FullPath(), "/api/routes") {
+		d := &event.Decision{
+			Stage:    event.DecisionStageUpstream,
+			Outcome:  decisionOutcomeSucceeded,
+			Provider: selectedProvider,
+			Model:    c.GetString("model"),
+			Status:   c.Writer.Status(),
+		}
+
+		if d.Status >= http.StatusBadRequest {
+			d.Outcome = decisionOutcomeFailed
+		}
+
+		decisions = append(decisions, d)
+	}
+
+	return decisions
+}
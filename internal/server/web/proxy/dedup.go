@@ -0,0 +1,97 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type routeResult struct {
+	status      int
+	header      http.Header
+	body        []byte
+	original    []byte
+	transformed bool
+	provider    string
+	model       string
+	settingId   string
+	latency     time.Duration
+	err         error
+}
+
+type dedupCall struct {
+	done chan struct{}
+	res  *routeResult
+}
+
+// deduplicator makes sure that only one upstream call is made for identical
+// route requests. Requests arriving while the call is in flight, or within
+// the window after it succeeded, receive the same result.
+type deduplicator struct {
+	lock  sync.Mutex
+	calls map[string]*dedupCall
+}
+
+func newDeduplicator() *deduplicator {
+	return &deduplicator{
+		calls: map[string]*dedupCall{},
+	}
+}
+
+// do reports whether the result was shared from another request. fn runs in
+// its own goroutine so that a caller giving up on ctx, including the one that
+// started the call, does not fail the other requests waiting for the result.
+func (d *deduplicator) do(ctx context.Context, key string, window time.Duration, fn func() *routeResult) (*routeResult, bool, error) {
+	d.lock.Lock()
+	call, shared := d.calls[key]
+	if !shared {
+		call = &dedupCall{
+			done: make(chan struct{}),
+		}
+
+		d.calls[key] = call
+		go d.run(key, call, window, fn)
+	}
+	d.lock.Unlock()
+
+	select {
+	case <-call.done:
+		return call.res, shared, nil
+	case <-ctx.Done():
+		return nil, shared, ctx.Err()
+	}
+}
+
+func (d *deduplicator) run(key string, call *dedupCall, window time.Duration, fn func() *routeResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			call.res = &routeResult{
+				err: fmt.Errorf("deduplicated request panicked: %v", r),
+			}
+		}
+
+		close(call.done)
+
+		if window <= 0 || call.res.err != nil || call.res.status != http.StatusOK {
+			d.forget(key, call)
+			return
+		}
+
+		time.AfterFunc(window, func() {
+			d.forget(key, call)
+		})
+	}()
+
+	call.res = fn()
+}
+
+func (d *deduplicator) forget(key string, call *dedupCall) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.calls[key] == call {
+		delete(d.calls, key)
+	}
+}
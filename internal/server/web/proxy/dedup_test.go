@@ -0,0 +1,218 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingCall counts the calls made and answers them once released.
+type blockingCall struct {
+	calls   int32
+	release chan struct{}
+	res     *routeResult
+}
+
+func newBlockingCall(res *routeResult) *blockingCall {
+	return &blockingCall{
+		release: make(chan struct{}),
+		res:     res,
+	}
+}
+
+func (b *blockingCall) run() *routeResult {
+	atomic.AddInt32(&b.calls, 1)
+	<-b.release
+
+	return b.res
+}
+
+func (b *blockingCall) count() int {
+	return int(atomic.LoadInt32(&b.calls))
+}
+
+func TestDeduplicator_Do(t *testing.T) {
+	ok := &routeResult{status: http.StatusOK, body: []byte(`{"id":"1"}`)}
+
+	t.Run("identical requests in flight are merged", func(t *testing.T) {
+		d := newDeduplicator()
+		call := newBlockingCall(ok)
+
+		var (
+			wg      sync.WaitGroup
+			started int32
+		)
+		results := make([]*routeResult, 5)
+		shared := make([]bool, 5)
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				atomic.AddInt32(&started, 1)
+				res, s, err := d.do(context.Background(), "key", time.Minute, call.run)
+				assert.NoError(t, err)
+
+				results[i], shared[i] = res, s
+			}(i)
+		}
+
+		// requests joining after the call finished share it through the
+		// window, so the call is made once either way.
+		require.Eventually(t, func() bool { return atomic.LoadInt32(&started) == 5 && call.count() == 1 }, time.Second, time.Millisecond)
+		close(call.release)
+		wg.Wait()
+
+		assert.Equal(t, 1, call.count())
+
+		leaders := 0
+		for i := range results {
+			assert.Same(t, ok, results[i])
+			if !shared[i] {
+				leaders++
+			}
+		}
+
+		assert.Equal(t, 1, leaders)
+	})
+
+	t.Run("different keys are not merged", func(t *testing.T) {
+		d := newDeduplicator()
+		call := newBlockingCall(ok)
+		close(call.release)
+
+		_, sharedA, _ := d.do(context.Background(), "a", time.Minute, call.run)
+		_, sharedB, _ := d.do(context.Background(), "b", time.Minute, call.run)
+
+		assert.False(t, sharedA)
+		assert.False(t, sharedB)
+		assert.Equal(t, 2, call.count())
+	})
+
+	cases := []struct {
+		name   string
+		res    *routeResult
+		window time.Duration
+		reuses bool
+	}{
+		{name: "successful results are shared within the window", res: ok, window: time.Minute, reuses: true},
+		{name: "results are not shared without a window", res: ok, window: 0, reuses: false},
+		{name: "errors are not shared", res: &routeResult{err: errors.New("connection reset")}, window: time.Minute, reuses: false},
+		{name: "error responses are not shared", res: &routeResult{status: http.StatusTooManyRequests}, window: time.Minute, reuses: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := newDeduplicator()
+			call := newBlockingCall(tc.res)
+			close(call.release)
+
+			first, shared, err := d.do(context.Background(), "key", tc.window, call.run)
+			require.NoError(t, err)
+			assert.False(t, shared)
+			assert.Same(t, tc.res, first)
+
+			second, shared, err := d.do(context.Background(), "key", tc.window, call.run)
+			require.NoError(t, err)
+			assert.Same(t, tc.res, second)
+			assert.Equal(t, tc.reuses, shared)
+
+			expected := 2
+			if tc.reuses {
+				expected = 1
+			}
+
+			assert.Equal(t, expected, call.count())
+		})
+	}
+
+	t.Run("results are forgotten once the window expires", func(t *testing.T) {
+		d := newDeduplicator()
+		call := newBlockingCall(ok)
+		close(call.release)
+
+		_, shared, _ := d.do(context.Background(), "key", 20*time.Millisecond, call.run)
+		assert.False(t, shared)
+
+		_, shared, _ = d.do(context.Background(), "key", 20*time.Millisecond, call.run)
+		assert.True(t, shared)
+
+		require.Eventually(t, func() bool {
+			d.lock.Lock()
+			defer d.lock.Unlock()
+
+			return len(d.calls) == 0
+		}, time.Second, time.Millisecond)
+
+		_, shared, _ = d.do(context.Background(), "key", 20*time.Millisecond, call.run)
+		assert.False(t, shared)
+		assert.Equal(t, 2, call.count())
+	})
+
+	t.Run("canceled leaders do not fail merged requests", func(t *testing.T) {
+		d := newDeduplicator()
+		call := newBlockingCall(ok)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		leaderErr := make(chan error, 1)
+		go func() {
+			_, _, err := d.do(ctx, "key", time.Minute, call.run)
+			leaderErr <- err
+		}()
+
+		require.Eventually(t, func() bool { return call.count() == 1 }, time.Second, time.Millisecond)
+
+		followerRes := make(chan *routeResult, 1)
+		go func() {
+			res, shared, err := d.do(context.Background(), "key", time.Minute, call.run)
+			assert.NoError(t, err)
+			assert.True(t, shared)
+			followerRes <- res
+		}()
+
+		cancel()
+		assert.ErrorIs(t, <-leaderErr, context.Canceled)
+
+		close(call.release)
+		assert.Same(t, ok, <-followerRes)
+		assert.Equal(t, 1, call.count())
+	})
+
+	t.Run("canceled followers stop waiting", func(t *testing.T) {
+		d := newDeduplicator()
+		call := newBlockingCall(ok)
+		defer close(call.release)
+
+		go d.do(context.Background(), "key", 0, call.run)
+		require.Eventually(t, func() bool { return call.count() == 1 }, time.Second, time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		res, shared, err := d.do(ctx, "key", 0, call.run)
+		assert.Nil(t, res)
+		assert.True(t, shared)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("panics fail every merged request", func(t *testing.T) {
+		d := newDeduplicator()
+
+		res, _, err := d.do(context.Background(), "key", time.Minute, func() *routeResult {
+			panic("boom")
+		})
+		require.NoError(t, err)
+		assert.EqualError(t, res.err, "deduplicated request panicked: boom")
+
+		d.lock.Lock()
+		defer d.lock.Unlock()
+		assert.Empty(t, d.calls)
+	})
+}
@@ -0,0 +1,48 @@
+package proxy
+
+import (
+	"net/http"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// egressClient returns the client set up for the egress configuration of
+// the selected provider setting, if there is one.
+func egressClient(c *gin.Context, client http.Client) *http.Client {
+	if raw, exists := c.Get("egress_client"); exists {
+		if ec, ok := raw.(*http.Client); ok {
+			return ec
+		}
+	}
+
+	return &client
+}
+
+// getEgressMiddleware sets the client for the egress configuration of the
+// selected provider setting.
+func getEgressMiddleware(egc *provider.EgressClients, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := proxyKey(c); !ok {
+			return
+		}
+
+		settings := proxySettings(c)
+		if len(settings) == 0 || settings[0] == nil || settings[0].Egress.IsEmpty() {
+			return
+		}
+
+		ec, err := egc.Get(settings[0].Egress)
+		if err != nil {
+			stats.Incr("bricksllm.proxy.get_middleware.get_egress_client_error", nil, 1)
+			logError(log, "error when getting egress client", prod, c.GetString(correlationId), err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] invalid egress configuration")
+			c.Abort()
+			return
+		}
+
+		c.Set("egress_client", ec)
+	}
+}
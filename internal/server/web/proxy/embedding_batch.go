@@ -0,0 +1,196 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider/custom"
+	"github.com/gin-gonic/gin"
+)
+
+// limits of a single embeddings request to OpenAI and Azure OpenAI.
+const (
+	maxEmbeddingBatchInputs = 2048
+	maxEmbeddingBatchTokens = 300000
+)
+
+type embeddingBatchUsage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+// embeddingBatchResponse keeps the embeddings of a batch as raw json so that
+// float and base64 encoded responses are merged the same way.
+type embeddingBatchResponse struct {
+	Object string                       `json:"object"`
+	Data   []map[string]json.RawMessage `json:"data"`
+	Model  string                       `json:"model"`
+	Usage  embeddingBatchUsage          `json:"usage"`
+}
+
+// splitEmbeddingRequest splits the input of an embeddings request into
+// bodies that fit within the input and token limits of one upstream request.
+// Requests that fit, or whose input is not a list, are returned as is.
+func splitEmbeddingRequest(body []byte) ([][]byte, []int, error) {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, nil, err
+	}
+
+	inputs := []json.RawMessage{}
+	if err := json.Unmarshal(fields["input"], &inputs); err != nil || len(inputs) == 0 {
+		return [][]byte{body}, []int{0}, nil
+	}
+
+	// a single list of token ids is one input.
+	if trimmed := bytes.TrimSpace(inputs[0]); len(trimmed) != 0 && trimmed[0] != '"' && trimmed[0] != '[' {
+		return [][]byte{body}, []int{0}, nil
+	}
+
+	batches := [][]json.RawMessage{}
+	offsets := []int{}
+	current := []json.RawMessage{}
+	tokens := 0
+	for index, input := range inputs {
+		tks, err := countEmbeddingInputTokens(input)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(current) != 0 && (len(current) == maxEmbeddingBatchInputs || tokens+tks > maxEmbeddingBatchTokens) {
+			batches = append(batches, current)
+			offsets = append(offsets, index-len(current))
+			current = []json.RawMessage{}
+			tokens = 0
+		}
+
+		current = append(current, input)
+		tokens += tks
+	}
+
+	if len(batches) == 0 {
+		return [][]byte{body}, []int{0}, nil
+	}
+
+	batches = append(batches, current)
+	offsets = append(offsets, len(inputs)-len(current))
+
+	bodies := [][]byte{}
+	for _, batch := range batches {
+		data, err := json.Marshal(batch)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		fields["input"] = data
+		b, err := json.Marshal(fields)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		bodies = append(bodies, b)
+	}
+
+	return bodies, offsets, nil
+}
+
+func countEmbeddingInputTokens(input json.RawMessage) (int, error) {
+	var text string
+	if err := json.Unmarshal(input, &text); err == nil {
+		return custom.Count(text)
+	}
+
+	ids := []int{}
+	if err := json.Unmarshal(input, &ids); err != nil {
+		return 0, fmt.Errorf("embedding input is neither a string nor a list of tokens: %v", err)
+	}
+
+	return len(ids), nil
+}
+
+// sendEmbeddingBatches sends the body of an embeddings request to target,
+// split into several upstream requests when it exceeds the limits of one.
+// The first failed batch is returned as the response. Otherwise the
+// embeddings of all batches are merged in the order of the original input
+// with their usage summed.
+func sendEmbeddingBatches(ctx context.Context, c *gin.Context, client http.Client, target string, body []byte) (int, http.Header, []byte, error) {
+	bodies, offsets, err := splitEmbeddingRequest(body)
+	if err != nil {
+		bodies, offsets = [][]byte{body}, []int{0}
+	}
+
+	c.Set("embeddingBatches", len(bodies))
+
+	responses := [][]byte{}
+	var header http.Header
+	for _, b := range bodies {
+		req, err := http.NewRequestWithContext(ctx, c.Request.Method, target, bytes.NewReader(b))
+		if err != nil {
+			return 0, nil, nil, err
+		}
+
+		copyHttpHeaders(c.Request, req)
+
+		res, err := egressClient(c, client).Do(req)
+		if err != nil {
+			return 0, nil, nil, err
+		}
+
+		data, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return 0, nil, nil, err
+		}
+
+		if res.StatusCode != http.StatusOK || len(bodies) == 1 {
+			return res.StatusCode, res.Header, data, nil
+		}
+
+		header = res.Header
+		responses = append(responses, data)
+	}
+
+	merged, err := mergeEmbeddingResponses(responses, offsets)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	header = header.Clone()
+	header.Del("Content-Length")
+
+	return http.StatusOK, header, merged, nil
+}
+
+func mergeEmbeddingResponses(responses [][]byte, offsets []int) ([]byte, error) {
+	merged := &embeddingBatchResponse{
+		Data: []map[string]json.RawMessage{},
+	}
+
+	for i, data := range responses {
+		res := &embeddingBatchResponse{}
+		if err := json.Unmarshal(data, res); err != nil {
+			return nil, err
+		}
+
+		for _, embedding := range res.Data {
+			index := 0
+			if err := json.Unmarshal(embedding["index"], &index); err != nil {
+				return nil, err
+			}
+
+			embedding["index"] = json.RawMessage(fmt.Sprint(index + offsets[i]))
+			merged.Data = append(merged.Data, embedding)
+		}
+
+		merged.Object = res.Object
+		merged.Model = res.Model
+		merged.Usage.PromptTokens += res.Usage.PromptTokens
+		merged.Usage.TotalTokens += res.Usage.TotalTokens
+	}
+
+	return json.Marshal(merged)
+}
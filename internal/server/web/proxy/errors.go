@@ -0,0 +1,121 @@
+package proxy
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// machine readable codes of errors returned by the gateway.
+const (
+	codeInvalidRequest            = "invalid_request"
+	codeInvalidApiKey             = "invalid_api_key"
+	codeForbidden                 = "forbidden"
+	codeNotFound                  = "not_found"
+	codeRequestTimeout            = "request_timeout"
+	codeRateLimitExceeded         = "rate_limit_exceeded"
+	codeInternalError             = "internal_error"
+	codeServiceUnavailable        = "service_unavailable"
+	codeKeyNotActive              = "key_not_active"
+	codeSessionTokenLimitExceeded = "session_token_limit_exceeded"
+	codeSessionCostLimitExceeded  = "session_cost_limit_exceeded"
+	codeModelNotAllowed           = "model_not_allowed"
+	codePathNotAllowed            = "path_not_allowed"
+	codeStreamingNotAllowed       = "streaming_not_allowed"
+	codeLoopDetected              = "loop_detected"
+	codeTrafficPaused             = "traffic_paused"
+	codeMaintenance               = "maintenance"
+	codeRouteNotFound             = "route_not_found"
+	codeProviderNotFound          = "provider_not_found"
+	codeProviderUnhealthy         = "provider_unhealthy"
+	codePromptTemplateNotFound    = "prompt_template_not_found"
+	codePolicyDenied              = "policy_denied"
+	codeResidencyNotSatisfied     = "residency_not_satisfied"
+	codeEmbeddingDimensions       = "embedding_dimensions_mismatch"
+	codeRedisUnavailable          = "redis_unavailable"
+	codeInvalidSignature          = "invalid_signature"
+	codeContextWindowExceeded     = "context_window_exceeded"
+	codeGuardrailFlagged          = "guardrail_flagged"
+	codeGuardrailUnavailable      = "guardrail_unavailable"
+	codeMalformedUpstreamResponse = "malformed_upstream_response"
+	codePassThroughNotFound       = "pass_through_not_found"
+)
+
+var errorTypes = map[int]string{
+	http.StatusBadRequest:          "invalid_request_error",
+	http.StatusUnauthorized:        "authentication_error",
+	http.StatusForbidden:           "permission_error",
+	http.StatusNotFound:            "not_found_error",
+	http.StatusRequestTimeout:      "timeout_error",
+	http.StatusTooManyRequests:     "rate_limit_error",
+	http.StatusServiceUnavailable:  "service_unavailable_error",
+	http.StatusInternalServerError: "server_error",
+}
+
+var errorCodes = map[int]string{
+	http.StatusBadRequest:          codeInvalidRequest,
+	http.StatusUnauthorized:        codeInvalidApiKey,
+	http.StatusForbidden:           codeForbidden,
+	http.StatusNotFound:            codeNotFound,
+	http.StatusRequestTimeout:      codeRequestTimeout,
+	http.StatusTooManyRequests:     codeRateLimitExceeded,
+	http.StatusServiceUnavailable:  codeServiceUnavailable,
+	http.StatusInternalServerError: codeInternalError,
+}
+
+// gatewayErrorResponse is shaped like the error object of OpenAI so that
+// official SDKs surface gateway errors to callers.
+type gatewayErrorResponse struct {
+	Error *gatewayError `json:"error"`
+}
+
+type gatewayError struct {
+	Message   string                 `json:"message"`
+	Type      string                 `json:"type"`
+	Param     *string                `json:"param"`
+	Code      string                 `json:"code"`
+	RequestId string                 `json:"request_id,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+func getErrorType(status int) string {
+	if t, ok := errorTypes[status]; ok {
+		return t
+	}
+
+	if status >= http.StatusInternalServerError {
+		return "server_error"
+	}
+
+	return "invalid_request_error"
+}
+
+// JSON writes an error with the default code of its status.
+func JSON(c *gin.Context, status int, message string) {
+	code, ok := errorCodes[status]
+	if !ok {
+		code = codeInvalidRequest
+		if status >= http.StatusInternalServerError {
+			code = codeInternalError
+		}
+	}
+
+	JSONError(c, status, code, message, nil)
+}
+
+// gatewayErrorKey marks responses written by the gateway so that they are not
+// mistaken for upstream responses.
+const gatewayErrorKey = "gatewayError"
+
+func JSONError(c *gin.Context, status int, code, message string, details map[string]interface{}) {
+	c.Set(gatewayErrorKey, true)
+	c.JSON(status, &gatewayErrorResponse{
+		Error: &gatewayError{
+			Message:   message,
+			Type:      getErrorType(status),
+			Code:      code,
+			RequestId: c.GetString(correlationId),
+			Details:   details,
+		},
+	})
+}
@@ -0,0 +1,67 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// requestIdHeader returns the id of the event of a request so that clients
+// can refer to the request when giving feedback.
+const requestIdHeader = "X-BricksLLM-Request-Id"
+
+type FeedbackManager interface {
+	SubmitFeedback(k *key.ResponseKey, r *event.FeedbackRequest) (*event.Feedback, error)
+}
+
+func isFeedbackPath(fullPath string) bool {
+	return fullPath == "/api/feedback"
+}
+
+func getSubmitFeedbackHandler(fm FeedbackManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.proxy.get_submit_feedback_handler.requests", nil, 1)
+
+		cid := c.GetString(correlationId)
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			logError(log, "error when reading feedback request body", prod, cid, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read request body")
+			return
+		}
+
+		fr := &event.FeedbackRequest{}
+		if err := json.Unmarshal(data, fr); err != nil {
+			JSON(c, http.StatusBadRequest, "[BricksLLM] request body must be a json object")
+			return
+		}
+
+		f, err := fm.SubmitFeedback(getSelfKey(c), fr)
+		if err != nil {
+			if _, ok := err.(validationError); ok {
+				JSON(c, http.StatusBadRequest, "[BricksLLM] "+err.Error())
+				return
+			}
+
+			if _, ok := err.(notFoundError); ok {
+				JSON(c, http.StatusNotFound, "[BricksLLM] "+err.Error())
+				return
+			}
+
+			stats.Incr("bricksllm.proxy.get_submit_feedback_handler.submit_feedback_error", nil, 1)
+			logError(log, "error when submitting feedback", prod, cid, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to submit feedback")
+			return
+		}
+
+		stats.Incr("bricksllm.proxy.get_submit_feedback_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, f)
+	}
+}
@@ -3,8 +3,8 @@ package proxy
 import (
 	"encoding/json"
 
+	"github.com/bricks-cloud/bricksllm/internal/logger"
 	goopenai "github.com/sashabaranov/go-openai"
-
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -12,7 +12,7 @@ import (
 func logListFilesRequest(log *zap.Logger, prod bool, cid string, params map[string]string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 		}
 
 		if v, ok := params["purpose"]; ok {
@@ -33,7 +33,7 @@ func logListFilesResponse(log *zap.Logger, data []byte, prod bool, cid string) {
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.Any("files", files.Files),
 		}
 
@@ -44,7 +44,7 @@ func logListFilesResponse(log *zap.Logger, data []byte, prod bool, cid string) {
 func logRetrieveFileRequest(log *zap.Logger, prod bool, cid, fid string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("file_id", fid),
 		}
 
@@ -62,7 +62,7 @@ func logRetrieveFileResponse(log *zap.Logger, data []byte, prod bool, cid string
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("id", file.ID),
 			zap.Int("bytes", file.Bytes),
 			zap.Int64("createdAt", file.CreatedAt),
@@ -80,7 +80,7 @@ func logRetrieveFileResponse(log *zap.Logger, data []byte, prod bool, cid string
 func logDeleteFileRequest(log *zap.Logger, prod bool, cid, fid string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("file_id", fid),
 		}
 
@@ -98,7 +98,7 @@ func logDeleteFileResponse(log *zap.Logger, data []byte, prod bool, cid string)
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("id", dr.Id),
 			zap.String("object", dr.Object),
 			zap.Bool("deleted", dr.Deleted),
@@ -111,7 +111,7 @@ func logDeleteFileResponse(log *zap.Logger, data []byte, prod bool, cid string)
 func logRetrieveFileContentRequest(log *zap.Logger, data []byte, prod bool, cid, fid string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("file_id", fid),
 		}
 
@@ -122,7 +122,7 @@ func logRetrieveFileContentRequest(log *zap.Logger, data []byte, prod bool, cid,
 func logRetrieveFileContentResponse(log *zap.Logger, data []byte, prod bool, cid string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 		}
 
 		log.Info("openai retrieve file content response", fields...)
@@ -132,7 +132,7 @@ func logRetrieveFileContentResponse(log *zap.Logger, data []byte, prod bool, cid
 func logUploadFileRequest(log *zap.Logger, prod bool, cid, purpose string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("purpose", purpose),
 		}
 
@@ -150,7 +150,7 @@ func logUploadFileResponse(log *zap.Logger, data []byte, prod bool, cid string)
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("id", file.ID),
 			zap.Int("bytes", file.Bytes),
 			zap.Int64("createdAt", file.CreatedAt),
@@ -0,0 +1,108 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/guardrail"
+	"github.com/bricks-cloud/bricksllm/internal/prompt"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+)
+
+const guardrailVerdictsMetadataKey = "bricksllm_guardrail_verdicts"
+
+// runGuardrails checks messages with the guardrails of a stage, keeps the
+// verdicts for the event of the request and returns the one blocking the
+// request if any.
+func runGuardrails(c *gin.Context, gc *guardrail.Client, gs []*guardrail.Guardrail, stage string, messages []*prompt.Message) *guardrail.Verdict {
+	if len(gs) == 0 {
+		return nil
+	}
+
+	verdicts := gc.CheckAll(gs, stage, messages)
+	recordGuardrailVerdicts(verdicts)
+	keepGuardrailVerdicts(c, verdicts)
+
+	return guardrail.Blocking(verdicts)
+}
+
+func recordGuardrailVerdicts(verdicts []*guardrail.Verdict) {
+	for _, v := range verdicts {
+		tags := []string{
+			"guardrail:" + v.Guardrail,
+			"provider:" + v.Provider,
+			"stage:" + v.Stage,
+		}
+
+		stats.Timing("bricksllm.proxy.run_guardrails.latency", time.Duration(v.LatencyInMs)*time.Millisecond, tags, 1)
+
+		if len(v.Error) != 0 {
+			stats.Incr("bricksllm.proxy.run_guardrails.error", tags, 1)
+		}
+
+		if v.Flagged {
+			stats.Incr("bricksllm.proxy.run_guardrails.flagged", tags, 1)
+		}
+	}
+}
+
+// keepGuardrailVerdicts adds verdicts to the ones kept for the event of the
+// request and to its decision trail.
+func keepGuardrailVerdicts(c *gin.Context, verdicts []*guardrail.Verdict) {
+	for _, v := range verdicts {
+		recordDecision(c, guardrailDecision(v))
+	}
+
+	if raw, ok := c.Get("guardrailVerdicts"); ok {
+		if existing, ok := raw.([]*guardrail.Verdict); ok {
+			verdicts = append(existing, verdicts...)
+		}
+	}
+
+	c.Set("guardrailVerdicts", verdicts)
+}
+
+func rejectGuardrail(c *gin.Context, v *guardrail.Verdict) {
+	details := map[string]interface{}{
+		"guardrail": v.Guardrail,
+		"stage":     v.Stage,
+	}
+
+	if len(v.Error) != 0 {
+		JSONError(c, http.StatusServiceUnavailable, codeGuardrailUnavailable, fmt.Sprintf("[BricksLLM] guardrail %s is unavailable", v.Guardrail), details)
+		return
+	}
+
+	details["categories"] = v.Categories
+
+	subject := "prompt"
+	if v.Stage == guardrail.StageOutput {
+		subject = "response"
+	}
+
+	JSONError(c, http.StatusBadRequest, codeGuardrailFlagged, fmt.Sprintf("[BricksLLM] %s is flagged by guardrail %s", subject, v.Guardrail), details)
+}
+
+// guardrailVerdictsMetadata returns the verdicts of a request for the
+// metadata of its event.
+func guardrailVerdictsMetadata(c *gin.Context) string {
+	raw, ok := c.Get("guardrailVerdicts")
+	if !ok {
+		return ""
+	}
+
+	verdicts, ok := raw.([]*guardrail.Verdict)
+	if !ok || len(verdicts) == 0 {
+		return ""
+	}
+
+	data, err := json.Marshal(verdicts)
+	if err != nil {
+		return ""
+	}
+
+	return string(data)
+}
@@ -3,6 +3,7 @@ package proxy
 import (
 	"encoding/json"
 
+	"github.com/bricks-cloud/bricksllm/internal/logger"
 	goopenai "github.com/sashabaranov/go-openai"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -11,7 +12,7 @@ import (
 func logCreateImageRequest(log *zap.Logger, ir *goopenai.ImageRequest, prod, private bool, cid string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("model", ir.Model),
 			zap.Int("n", ir.N),
 			zap.String("quality", ir.Quality),
@@ -32,7 +33,7 @@ func logCreateImageRequest(log *zap.Logger, ir *goopenai.ImageRequest, prod, pri
 func logEditImageRequest(log *zap.Logger, prompt, model string, n int, size, responseFormat, user string, prod, private bool, cid string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 		}
 
 		if !private && len(prompt) != 0 {
@@ -66,7 +67,7 @@ func logEditImageRequest(log *zap.Logger, prompt, model string, n int, size, res
 func logImageVariationsRequest(log *zap.Logger, model string, n int, size, responseFormat, user string, prod, private bool, cid string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 		}
 
 		if len(model) != 0 {
@@ -103,7 +104,7 @@ func logImageResponse(log *zap.Logger, data []byte, prod, private bool, cid stri
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.Int64("created", ir.Created),
 		}
 
@@ -0,0 +1,393 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/bricks-cloud/bricksllm/internal/webhook"
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+)
+
+const (
+	// asyncHeader submits a request as a job that is answered with the id of
+	// the job instead of waiting for the response.
+	asyncHeader      = "X-BricksLLM-Async"
+	webhookUrlHeader = "X-BricksLLM-Webhook-Url"
+
+	maxJobResponseSize = 10 * 1024 * 1024
+	maxJobRetryDelay   = time.Minute
+	webhookAttempts    = 3
+)
+
+type JobManager interface {
+	CreateJob(k *key.ResponseKey, path, webhookUrl string) (*event.Job, error)
+	GetJob(k *key.ResponseKey, id string) (*event.Job, error)
+	UpdateJob(j *event.Job) error
+}
+
+func isJobPath(fullPath string) bool {
+	return fullPath == "/api/jobs/:id"
+}
+
+type asyncRequest struct {
+	job    *event.Job
+	method string
+	url    string
+	header http.Header
+	body   []byte
+}
+
+// jobResponseWriter keeps the response of a job in memory.
+type jobResponseWriter struct {
+	header    http.Header
+	status    int
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (w *jobResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *jobResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *jobResponseWriter) Write(data []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+
+	if w.body.Len()+len(data) > maxJobResponseSize {
+		w.truncated = true
+		return len(data), nil
+	}
+
+	return w.body.Write(data)
+}
+
+func (w *jobResponseWriter) Flush() {}
+
+// asyncRunner sends jobs through the proxy itself, so that they are
+// authenticated, limited and recorded like any other request. Keys are only
+// kept in memory, which is why jobs are sent by the instance that accepted
+// them.
+type asyncRunner struct {
+	handler     http.Handler
+	jm          JobManager
+	queue       chan *asyncRequest
+	maxAttempts int
+	client      http.Client
+	log         *zap.Logger
+	prod        bool
+}
+
+func newAsyncRunner(jm JobManager, queueSize, maxAttempts int, webhookHosts []string, log *zap.Logger, prod bool) *asyncRunner {
+	return &asyncRunner{
+		jm:          jm,
+		queue:       make(chan *asyncRequest, queueSize),
+		maxAttempts: maxAttempts,
+		client:      webhook.NewClient(webhookHosts, 10*time.Second),
+		log:         log,
+		prod:        prod,
+	}
+}
+
+func (ar *asyncRunner) start(handler http.Handler, workers int) {
+	ar.handler = handler
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for r := range ar.queue {
+				ar.run(r)
+			}
+		}()
+	}
+}
+
+func (ar *asyncRunner) enqueue(r *asyncRequest) bool {
+	select {
+	case ar.queue <- r:
+		return true
+	default:
+		return false
+	}
+}
+
+func isRetryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusInternalServerError || status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout
+}
+
+// retryDelay backs off exponentially unless the response asks for a delay.
+func retryDelay(attempt int, header http.Header) time.Duration {
+	if secs, err := strconv.Atoi(header.Get("Retry-After")); err == nil && secs > 0 {
+		if d := time.Duration(secs) * time.Second; d < maxJobRetryDelay {
+			return d
+		}
+
+		return maxJobRetryDelay
+	}
+
+	d := time.Duration(1<<attempt) * time.Second
+	if d > maxJobRetryDelay {
+		return maxJobRetryDelay
+	}
+
+	return d
+}
+
+func (ar *asyncRunner) update(j *event.Job) {
+	if err := ar.jm.UpdateJob(j); err != nil {
+		stats.Incr("bricksllm.proxy.async_runner.update_job_error", nil, 1)
+		logError(ar.log, "error when updating job", ar.prod, j.Id, err)
+	}
+}
+
+func (ar *asyncRunner) run(r *asyncRequest) {
+	j := r.job
+
+	var w *jobResponseWriter
+	for {
+		j.Attempts++
+		j.Status = event.JobStatusRunning
+		ar.update(j)
+
+		req, err := http.NewRequestWithContext(withVerifiedSignature(context.Background()), r.method, r.url, bytes.NewReader(r.body))
+		if err != nil {
+			j.Status = event.JobStatusFailed
+			j.Error = err.Error()
+			ar.update(j)
+			return
+		}
+
+		req.Header = r.header.Clone()
+
+		w = &jobResponseWriter{
+			header: http.Header{},
+		}
+
+		ar.handler.ServeHTTP(w, req)
+
+		if !isRetryableStatus(w.status) || j.Attempts >= ar.maxAttempts {
+			break
+		}
+
+		stats.Incr("bricksllm.proxy.async_runner.retry", []string{fmt.Sprintf("status:%d", w.status)}, 1)
+		time.Sleep(retryDelay(j.Attempts, w.header))
+	}
+
+	j.StatusCode = w.status
+	j.RequestId = w.header.Get(requestIdHeader)
+
+	switch {
+	case w.truncated:
+		j.Status = event.JobStatusFailed
+		j.Error = "response is too large to be kept"
+	case json.Valid(w.body.Bytes()):
+		j.Response = w.body.Bytes()
+	default:
+		data, _ := json.Marshal(w.body.String())
+		j.Response = data
+	}
+
+	if !w.truncated {
+		j.Status = event.JobStatusCompleted
+		if w.status < 200 || w.status >= 300 {
+			j.Status = event.JobStatusFailed
+			j.Error = fmt.Sprintf("request failed with status %d", w.status)
+		}
+	}
+
+	ar.update(j)
+	stats.Incr("bricksllm.proxy.async_runner.finished", []string{"status:" + string(j.Status)}, 1)
+
+	if len(j.WebhookUrl) != 0 {
+		ar.notify(j)
+	}
+}
+
+// notify posts a finished job to its webhook url.
+func (ar *asyncRunner) notify(j *event.Job) {
+	data, err := json.Marshal(j)
+	if err != nil {
+		logError(ar.log, "error when marshalling job", ar.prod, j.Id, err)
+		return
+	}
+
+	for attempt := 1; attempt <= webhookAttempts; attempt++ {
+		err = ar.post(j, data)
+		if err == nil {
+			stats.Incr("bricksllm.proxy.async_runner.notify.success", nil, 1)
+			return
+		}
+
+		time.Sleep(retryDelay(attempt, http.Header{}))
+	}
+
+	stats.Incr("bricksllm.proxy.async_runner.notify.error", nil, 1)
+	logError(ar.log, "error when calling job webhook", ar.prod, j.Id, err)
+}
+
+func (ar *asyncRunner) post(j *event.Job, data []byte) error {
+	req, err := http.NewRequest(http.MethodPost, j.WebhookUrl, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-BricksLLM-Job-Id", j.Id)
+
+	res, err := ar.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+// getAsyncMiddleware answers requests sent with the async header with a job
+// and queues them. The key is checked again when the job is sent.
+func getAsyncMiddleware(ar *asyncRunner, a authenticator, log *zap.Logger, prod, private bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.EqualFold(c.GetHeader(asyncHeader), "true") {
+			return
+		}
+
+		stats.Incr("bricksllm.proxy.get_async_middleware.requests", nil, 1)
+
+		cid := util.NewUuid()
+		c.Set(correlationId, cid)
+
+		if c.Request.Method != http.MethodPost || !isReplayablePath(c.FullPath()) {
+			JSON(c, http.StatusBadRequest, "[BricksLLM] async requests are only supported for chat completions, embeddings and routes")
+			c.Abort()
+			return
+		}
+
+		if private {
+			JSON(c, http.StatusBadRequest, "[BricksLLM] async requests are not supported in strict privacy mode")
+			c.Abort()
+			return
+		}
+
+		kc, err := a.AuthenticateKey(c.Request)
+		if err != nil {
+			stats.Incr("bricksllm.proxy.get_async_middleware.authentication_error", nil, 1)
+			JSON(c, http.StatusUnauthorized, "[BricksLLM] not authorized")
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			logError(log, "error when reading async request body", prod, cid, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read request body")
+			c.Abort()
+			return
+		}
+
+		if !verifySignature(c, kc, body) {
+			return
+		}
+
+		if gjson.GetBytes(body, "stream").Bool() {
+			JSON(c, http.StatusBadRequest, "[BricksLLM] async requests cannot be streamed")
+			c.Abort()
+			return
+		}
+
+		if len(ar.queue) == cap(ar.queue) {
+			stats.Incr("bricksllm.proxy.get_async_middleware.queue_full", nil, 1)
+			JSON(c, http.StatusTooManyRequests, "[BricksLLM] async queue is full")
+			c.Abort()
+			return
+		}
+
+		j, err := ar.jm.CreateJob(kc, c.Request.URL.Path, c.GetHeader(webhookUrlHeader))
+		if err != nil {
+			if _, ok := err.(validationError); ok {
+				JSON(c, http.StatusBadRequest, "[BricksLLM] "+err.Error())
+				c.Abort()
+				return
+			}
+
+			stats.Incr("bricksllm.proxy.get_async_middleware.create_job_error", nil, 1)
+			logError(log, "error when creating job", prod, cid, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create job")
+			c.Abort()
+			return
+		}
+
+		header := c.Request.Header.Clone()
+		header.Del(asyncHeader)
+		header.Del(webhookUrlHeader)
+		header.Del("Accept-Encoding")
+		header.Del("Content-Length")
+
+		queued := ar.enqueue(&asyncRequest{
+			job:    j,
+			method: c.Request.Method,
+			url:    c.Request.URL.String(),
+			header: header,
+			body:   body,
+		})
+
+		if !queued {
+			j.Status = event.JobStatusFailed
+			j.Error = "async queue is full"
+			ar.update(j)
+
+			stats.Incr("bricksllm.proxy.get_async_middleware.queue_full", nil, 1)
+			JSON(c, http.StatusTooManyRequests, "[BricksLLM] async queue is full")
+			c.Abort()
+			return
+		}
+
+		stats.Incr("bricksllm.proxy.get_async_middleware.success", nil, 1)
+
+		c.JSON(http.StatusAccepted, j)
+		c.Abort()
+	}
+}
+
+func getGetJobHandler(jm JobManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.proxy.get_get_job_handler.requests", nil, 1)
+
+		cid := c.GetString(correlationId)
+		j, err := jm.GetJob(getSelfKey(c), c.Param("id"))
+		if err != nil {
+			if _, ok := err.(notFoundError); ok {
+				JSON(c, http.StatusNotFound, "[BricksLLM] "+err.Error())
+				return
+			}
+
+			stats.Incr("bricksllm.proxy.get_get_job_handler.get_job_error", nil, 1)
+			logError(log, "error when getting job", prod, cid, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to get job")
+			return
+		}
+
+		stats.Incr("bricksllm.proxy.get_get_job_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, j)
+	}
+}
@@ -0,0 +1,227 @@
+package proxy
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/server/web"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeJobManager records the jobs it is asked to update.
+type fakeJobManager struct {
+	JobManager
+	createErr error
+	updates   []event.Job
+}
+
+func (m *fakeJobManager) CreateJob(k *key.ResponseKey, path, webhookUrl string) (*event.Job, error) {
+	if m.createErr != nil {
+		return nil, m.createErr
+	}
+
+	return &event.Job{Id: "j1", KeyId: k.KeyId, Path: path, Status: event.JobStatusQueued, WebhookUrl: webhookUrl}, nil
+}
+
+func (m *fakeJobManager) UpdateJob(j *event.Job) error {
+	m.updates = append(m.updates, *j)
+
+	return nil
+}
+
+func TestRetryDelay(t *testing.T) {
+	cases := []struct {
+		name       string
+		attempt    int
+		retryAfter string
+		expected   time.Duration
+	}{
+		{name: "first attempt", attempt: 1, expected: 2 * time.Second},
+		{name: "exponential backoff", attempt: 3, expected: 8 * time.Second},
+		{name: "backoff is capped", attempt: 10, expected: maxJobRetryDelay},
+		{name: "retry after of the response", attempt: 1, retryAfter: "5", expected: 5 * time.Second},
+		{name: "retry after is capped", attempt: 1, retryAfter: "3600", expected: maxJobRetryDelay},
+		{name: "invalid retry after", attempt: 2, retryAfter: "soon", expected: 4 * time.Second},
+		{name: "zero retry after", attempt: 2, retryAfter: "0", expected: 4 * time.Second},
+		{name: "negative retry after", attempt: 2, retryAfter: "-5", expected: 4 * time.Second},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			header := http.Header{}
+			if len(tc.retryAfter) != 0 {
+				header.Set("Retry-After", tc.retryAfter)
+			}
+
+			assert.Equal(t, tc.expected, retryDelay(tc.attempt, header))
+		})
+	}
+}
+
+func TestJobResponseWriter(t *testing.T) {
+	t.Run("status of the first write is kept", func(t *testing.T) {
+		w := &jobResponseWriter{header: http.Header{}}
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("{}"))
+
+		assert.Equal(t, http.StatusTooManyRequests, w.status)
+	})
+
+	t.Run("writes without a status are ok", func(t *testing.T) {
+		w := &jobResponseWriter{header: http.Header{}}
+		w.Write([]byte("{}"))
+
+		assert.Equal(t, http.StatusOK, w.status)
+	})
+
+	t.Run("responses up to the limit are kept", func(t *testing.T) {
+		w := &jobResponseWriter{header: http.Header{}}
+		w.Write(bytes.Repeat([]byte("a"), maxJobResponseSize-1))
+		w.Write([]byte("a"))
+
+		assert.False(t, w.truncated)
+		assert.Equal(t, maxJobResponseSize, w.body.Len())
+	})
+
+	t.Run("responses over the limit are truncated", func(t *testing.T) {
+		w := &jobResponseWriter{header: http.Header{}}
+		w.Write(bytes.Repeat([]byte("a"), maxJobResponseSize-1))
+		n, err := w.Write([]byte("aa"))
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+		assert.True(t, w.truncated)
+		assert.Equal(t, maxJobResponseSize-1, w.body.Len())
+	})
+}
+
+func TestAsyncRunner_Run(t *testing.T) {
+	cases := []struct {
+		name      string
+		status    int
+		body      string
+		jobStatus event.JobStatus
+		response  string
+		err       string
+	}{
+		{name: "json responses", status: http.StatusOK, body: `{"id":"chatcmpl"}`, jobStatus: event.JobStatusCompleted, response: `{"id":"chatcmpl"}`},
+		{name: "other responses are kept as strings", status: http.StatusOK, body: "ok", jobStatus: event.JobStatusCompleted, response: `"ok"`},
+		{name: "failed requests", status: http.StatusBadRequest, body: `{"error":"bad"}`, jobStatus: event.JobStatusFailed, response: `{"error":"bad"}`, err: "request failed with status 400"},
+		{name: "responses that are too large", status: http.StatusOK, body: strings.Repeat("a", maxJobResponseSize+1), jobStatus: event.JobStatusFailed, err: "response is too large to be kept"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			jm := &fakeJobManager{}
+			ar := newAsyncRunner(jm, 1, 1, nil, zap.NewNop(), false)
+			ar.handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set(requestIdHeader, "r1")
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.body))
+			})
+
+			ar.run(&asyncRequest{job: &event.Job{Id: "j1"}, method: http.MethodPost, url: "/api/routes/chat", header: http.Header{}})
+
+			require.Len(t, jm.updates, 2)
+			assert.Equal(t, event.JobStatusRunning, jm.updates[0].Status)
+
+			j := jm.updates[1]
+			assert.Equal(t, tc.jobStatus, j.Status)
+			assert.Equal(t, tc.status, j.StatusCode)
+			assert.Equal(t, "r1", j.RequestId)
+			assert.Equal(t, 1, j.Attempts)
+			assert.Equal(t, tc.err, j.Error)
+			if len(tc.response) != 0 {
+				assert.JSONEq(t, tc.response, string(j.Response))
+			} else {
+				assert.Empty(t, j.Response)
+			}
+		})
+	}
+}
+
+func TestGetAsyncMiddleware(t *testing.T) {
+	chatPath := "/api/providers/openai/v1/chat/completions"
+
+	cases := []struct {
+		name      string
+		path      string
+		async     bool
+		body      string
+		private   bool
+		authErr   error
+		createErr error
+		queueSize int
+		expected  int
+		queued    bool
+	}{
+		{name: "requests without the header are not async", path: chatPath, body: `{}`, queueSize: 1, expected: http.StatusOK},
+		{name: "async requests are queued", path: chatPath, async: true, body: `{"model":"gpt-4o"}`, queueSize: 1, expected: http.StatusAccepted, queued: true},
+		{name: "route requests", path: "/api/routes/chat", async: true, body: `{}`, queueSize: 1, expected: http.StatusAccepted, queued: true},
+		{name: "other paths", path: "/api/providers/openai/v1/images/generations", async: true, body: `{}`, queueSize: 1, expected: http.StatusBadRequest},
+		{name: "strict privacy mode", path: chatPath, async: true, body: `{}`, private: true, queueSize: 1, expected: http.StatusBadRequest},
+		{name: "unauthorized requests", path: chatPath, async: true, body: `{}`, authErr: errors.New("unauthorized"), queueSize: 1, expected: http.StatusUnauthorized},
+		{name: "streamed requests", path: chatPath, async: true, body: `{"stream":true}`, queueSize: 1, expected: http.StatusBadRequest},
+		{name: "full queues", path: chatPath, async: true, body: `{}`, queueSize: 0, expected: http.StatusTooManyRequests},
+		{name: "invalid webhook urls", path: chatPath, async: true, body: `{}`, createErr: internal_errors.NewValidationError("webhook url must be an https url"), queueSize: 1, expected: http.StatusBadRequest},
+		{name: "jobs that cannot be created", path: chatPath, async: true, body: `{}`, createErr: errors.New("database is down"), queueSize: 1, expected: http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			jm := &fakeJobManager{createErr: tc.createErr}
+			ar := newAsyncRunner(jm, tc.queueSize, 1, nil, zap.NewNop(), false)
+			a := &fakeKeyAuthenticator{kc: &key.ResponseKey{KeyId: "k1"}, err: tc.authErr}
+
+			router, err := web.NewRouter(nil)
+			require.NoError(t, err)
+
+			handler := func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			}
+			router.Use(getAsyncMiddleware(ar, a, zap.NewNop(), false, tc.private))
+			router.POST(chatPath, handler)
+			router.POST("/api/providers/openai/v1/images/generations", handler)
+			router.POST("/api/routes/*route", handler)
+
+			req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+			if tc.async {
+				req.Header.Set(asyncHeader, "true")
+			}
+			req.Header.Set(webhookUrlHeader, "https://example.com/hook")
+			req.Header.Set("Authorization", "Bearer key")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expected, w.Code)
+
+			if !tc.queued {
+				assert.Empty(t, ar.queue)
+				return
+			}
+
+			require.Len(t, ar.queue, 1)
+			r := <-ar.queue
+			assert.Equal(t, "j1", r.job.Id)
+			assert.Equal(t, "https://example.com/hook", r.job.WebhookUrl)
+			assert.Equal(t, tc.path, r.url)
+			assert.Equal(t, []byte(tc.body), r.body)
+			assert.Equal(t, "Bearer key", r.header.Get("Authorization"))
+			assert.Empty(t, r.header.Get(asyncHeader))
+			assert.Empty(t, r.header.Get(webhookUrlHeader))
+		})
+	}
+}
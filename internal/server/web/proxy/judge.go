@@ -0,0 +1,148 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	goopenai "github.com/sashabaranov/go-openai"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+)
+
+// judgeReasonMetadataKey records why the judge gave an event its score.
+const judgeReasonMetadataKey = "bricksllm_judge_reason"
+
+// events are inserted asynchronously, so storing a score is retried until
+// the event of the request shows up.
+const (
+	judgeScoreAttempts = 5
+	judgeScoreBackoff  = 2 * time.Second
+)
+
+type judgeRecorder interface {
+	SetEventScore(eventId string, score float64, reasonKey, reason string) (bool, error)
+}
+
+type judgeRequest struct {
+	route    *route.Route
+	settings map[string]*provider.Setting
+	key      *key.ResponseKey
+	client   http.Client
+	egress   *provider.EgressClients
+	request  *http.Request
+	body     []byte
+	response []byte
+	eventId  string
+	cid      string
+}
+
+// conversationText renders the messages of a chat completion request for the
+// judge model.
+func conversationText(body []byte) string {
+	lines := []string{}
+
+	for _, m := range gjson.GetBytes(body, "messages").Array() {
+		content := m.Get("content")
+		text := content.String()
+
+		if content.IsArray() {
+			parts := []string{}
+			for _, p := range content.Array() {
+				if p.Get("type").String() == "text" {
+					parts = append(parts, p.Get("text").String())
+				}
+			}
+
+			text = strings.Join(parts, "\n")
+		}
+
+		lines = append(lines, fmt.Sprintf("%s: %s", m.Get("role").String(), text))
+	}
+
+	return strings.Join(lines, "\n\n")
+}
+
+// runJudge has the judge model of the route score the response of a request
+// and stores the score on the event of the request. It runs after the client
+// has been answered.
+func runJudge(jr *judgeRequest, rec judgeRecorder, log *zap.Logger, prod bool) {
+	stats.Incr("bricksllm.proxy.run_judge.requests", nil, 1)
+
+	data, err := json.Marshal(&goopenai.ChatCompletionRequest{
+		Model: jr.route.Judge.Step.Model,
+		Messages: []goopenai.ChatCompletionMessage{
+			{Role: goopenai.ChatMessageRoleSystem, Content: jr.route.Judge.Instruction()},
+			{Role: goopenai.ChatMessageRoleUser, Content: fmt.Sprintf("Conversation:\n%s\n\nResponse:\n%s", conversationText(jr.body), gjson.GetBytes(jr.response, "choices.0.message.content").Str)},
+		},
+	})
+	if err != nil {
+		logError(log, "error when marshalling judge request", prod, jr.cid, err)
+		return
+	}
+
+	forwarded := jr.request
+	forwarded.Body = io.NopCloser(bytes.NewReader(data))
+	forwarded.ContentLength = int64(len(data))
+
+	runRes, err := jr.route.JudgeRoute().RunSteps(&route.Request{
+		Settings:  jr.settings,
+		Key:       jr.key,
+		Client:    jr.client,
+		Egress:    jr.egress,
+		Forwarded: forwarded,
+	})
+	if err != nil {
+		stats.Incr("bricksllm.proxy.run_judge.run_steps_error", nil, 1)
+		logError(log, "error when running judge step", prod, jr.cid, err)
+		return
+	}
+
+	defer runRes.Cancel()
+	defer runRes.Response.Body.Close()
+
+	body, err := io.ReadAll(runRes.Response.Body)
+	if err != nil {
+		logError(log, "error when reading judge response body", prod, jr.cid, err)
+		return
+	}
+
+	if runRes.Response.StatusCode != http.StatusOK {
+		stats.Incr("bricksllm.proxy.run_judge.error_response", nil, 1)
+		logError(log, "error response from judge model", prod, jr.cid, fmt.Errorf("judge model responded with status %d: %s", runRes.Response.StatusCode, gjson.GetBytes(body, "error.message").Str))
+		return
+	}
+
+	score, reason, err := route.ParseScore(gjson.GetBytes(body, "choices.0.message.content").Str)
+	if err != nil {
+		stats.Incr("bricksllm.proxy.run_judge.parse_score_error", nil, 1)
+		logError(log, "error when parsing judge score", prod, jr.cid, err)
+		return
+	}
+
+	for attempt := 1; attempt <= judgeScoreAttempts; attempt++ {
+		stored, err := rec.SetEventScore(jr.eventId, score, judgeReasonMetadataKey, reason)
+		if err != nil {
+			stats.Incr("bricksllm.proxy.run_judge.set_event_score_error", nil, 1)
+			logError(log, "error when storing judge score", prod, jr.cid, err)
+			return
+		}
+
+		if stored {
+			stats.Incr("bricksllm.proxy.run_judge.success", nil, 1)
+			return
+		}
+
+		time.Sleep(judgeScoreBackoff)
+	}
+
+	stats.Incr("bricksllm.proxy.run_judge.event_not_found", nil, 1)
+}
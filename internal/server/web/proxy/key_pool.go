@@ -0,0 +1,34 @@
+package proxy
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/gin-gonic/gin"
+)
+
+// rotateRateLimitedKey moves the setting of a request that was rate limited by
+// the upstream past the api key the request was sent with. Route requests
+// rotate their keys while running their steps.
+func rotateRateLimitedKey(c *gin.Context) {
+	if c.Writer.Status() != http.StatusTooManyRequests || c.GetBool(gatewayErrorKey) {
+		return
+	}
+
+	if strings.HasPrefix(c.FullPath(), "/api/routes") {
+		return
+	}
+
+	raw, exists := c.Get("settings")
+	if !exists {
+		return
+	}
+
+	settings, ok := raw.([]*provider.Setting)
+	if !ok || len(settings) == 0 || settings[0] == nil {
+		return
+	}
+
+	settings[0].RateLimited(getAuthTokenFromHeader(c))
+}
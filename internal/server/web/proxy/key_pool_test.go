@@ -0,0 +1,62 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotateRateLimitedKey(t *testing.T) {
+	cases := []struct {
+		name         string
+		path         string
+		status       int
+		gatewayError bool
+		rotated      bool
+	}{
+		{name: "keys rate limited by providers are rotated", path: "/api/providers/openai/v1/chat/completions", status: http.StatusTooManyRequests, rotated: true},
+		{name: "rate limits of the proxy", path: "/api/providers/openai/v1/chat/completions", status: http.StatusTooManyRequests, gatewayError: true, rotated: false},
+		{name: "other responses", path: "/api/providers/openai/v1/chat/completions", status: http.StatusInternalServerError, rotated: false},
+		{name: "route requests rotate keys while running their steps", path: "/api/routes/chat", status: http.StatusTooManyRequests, rotated: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &provider.Setting{Id: t.Name(), Provider: "openai", Setting: map[string]string{
+				provider.ApiKeysParam:     "k1,k2",
+				provider.KeyRotationParam: provider.KeyRotationOnRateLimit,
+			}}
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("settings", []*provider.Setting{s})
+				c.Next()
+				rotateRateLimitedKey(c)
+			})
+			handler := func(c *gin.Context) {
+				if tc.gatewayError {
+					JSONError(c, tc.status, "rate_limited", "[BricksLLM] too many requests", nil)
+					return
+				}
+
+				c.Status(tc.status)
+			}
+			router.POST("/api/providers/openai/v1/chat/completions", handler)
+			router.POST("/api/routes/*route", handler)
+
+			req := httptest.NewRequest(http.MethodPost, tc.path, nil)
+			req.Header.Set("Authorization", "Bearer "+s.NextApiKey())
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			expected := "k1"
+			if tc.rotated {
+				expected = "k2"
+			}
+			assert.Equal(t, expected, s.NextApiKey())
+		})
+	}
+}
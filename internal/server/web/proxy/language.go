@@ -0,0 +1,73 @@
+package proxy
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/language"
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+// promptText collects the text language detection runs on. Only user
+// messages are used when there are any since system prompts are often
+// written in a different language than the traffic.
+func promptText(body []byte) string {
+	parts := []string{}
+
+	messages := gjson.GetBytes(body, "messages").Array()
+	for _, onlyUser := range []bool{true, false} {
+		for _, m := range messages {
+			if onlyUser && m.Get("role").String() != "user" {
+				continue
+			}
+
+			content := m.Get("content")
+			if !content.IsArray() {
+				parts = append(parts, content.String())
+				continue
+			}
+
+			for _, p := range content.Array() {
+				if p.Get("type").String() == "text" {
+					parts = append(parts, p.Get("text").String())
+				}
+			}
+		}
+
+		if len(parts) != 0 {
+			return strings.Join(parts, "\n")
+		}
+	}
+
+	if prompt := gjson.GetBytes(body, "prompt"); prompt.Type == gjson.String {
+		return prompt.String()
+	}
+
+	input := gjson.GetBytes(body, "input")
+	if !input.IsArray() {
+		return input.String()
+	}
+
+	for _, i := range input.Array() {
+		if i.Type == gjson.String {
+			parts = append(parts, i.String())
+		}
+	}
+
+	return strings.Join(parts, "\n")
+}
+
+// getLanguageMiddleware detects the language of the prompt of a request for
+// routes and events.
+func getLanguageMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := proxyKey(c); !ok || c.Request.Method == http.MethodGet {
+			return
+		}
+
+		if lang := language.Detect(promptText(requestBody(c))); len(lang) != 0 {
+			c.Set("language", lang)
+		}
+	}
+}
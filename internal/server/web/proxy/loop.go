@@ -0,0 +1,123 @@
+package proxy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+)
+
+func normalizeText(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// requestFingerprint identifies near identical chat completion requests by
+// their model and last message, ignoring case and whitespace. Agents stuck
+// in a loop keep sending the same last message while the history grows.
+func requestFingerprint(keyId, sessionId string, body []byte) string {
+	last := gjson.Result{}
+	if messages := gjson.GetBytes(body, "messages").Array(); len(messages) != 0 {
+		last = messages[len(messages)-1]
+	}
+
+	content := last.Get("content")
+	text := content.Str
+	if content.Type != gjson.String {
+		text = content.Raw
+	}
+
+	h := sha256.New()
+	for _, part := range []string{keyId, sessionId, gjson.GetBytes(body, "model").Str, last.Get("role").Str, normalizeText(text)} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// repeatedToolCall returns the tool that the conversation called at least
+// max times with the same arguments.
+func repeatedToolCall(body []byte, max int) string {
+	counts := map[string]int{}
+
+	for _, m := range gjson.GetBytes(body, "messages").Array() {
+		if m.Get("role").Str != "assistant" {
+			continue
+		}
+
+		for _, tc := range m.Get("tool_calls").Array() {
+			name := tc.Get("function.name").Str
+			call := fmt.Sprintf("%s\x00%s", name, normalizeText(tc.Get("function.arguments").Str))
+
+			counts[call]++
+			if counts[call] >= max {
+				return name
+			}
+		}
+	}
+
+	return ""
+}
+
+// rejectLoop throttles unless the key is set up to block loops. Throttled
+// requests are told when the repeat window ends.
+func rejectLoop(c *gin.Context, lp *key.LoopProtection, message string, retryAfter time.Duration) {
+	if lp.Action == key.LoopActionBlock {
+		JSONError(c, http.StatusForbidden, codeLoopDetected, message, nil)
+		c.Abort()
+		return
+	}
+
+	if retryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	}
+
+	JSONError(c, http.StatusTooManyRequests, codeLoopDetected, message, nil)
+	c.Abort()
+}
+
+// getLoopProtectionMiddleware rejects chat completion requests of agents
+// that repeat the same tool call or request.
+func getLoopProtectionMiddleware(ss sessionStorage, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		kc, ok := proxyKey(c)
+		if !ok || kc.LoopProtection.IsEmpty() || !isChatCompletionPath(c.FullPath()) {
+			return
+		}
+
+		lp := kc.LoopProtection
+		body := requestBody(c)
+
+		if lp.MaxToolCallRepeats != 0 {
+			if name := repeatedToolCall(body, lp.MaxToolCallRepeats); len(name) != 0 {
+				stats.Incr("bricksllm.proxy.get_middleware.tool_call_loop_detected", nil, 1)
+				rejectLoop(c, lp, fmt.Sprintf("[BricksLLM] agent loop detected: tool %s was called %d times with the same arguments", name, lp.MaxToolCallRepeats), 0)
+				return
+			}
+		}
+
+		if lp.MaxRepeats != 0 {
+			count, ttl, err := ss.IncrementRepeats(requestFingerprint(kc.KeyId, c.GetString("sessionId"), body), lp.GetWindow())
+			if err != nil {
+				stats.Incr("bricksllm.proxy.get_middleware.increment_repeats_error", nil, 1)
+				logError(log, "error when incrementing request repeats", prod, c.GetString(correlationId), err)
+			}
+
+			if err == nil && count > int64(lp.MaxRepeats) {
+				stats.Incr("bricksllm.proxy.get_middleware.repeated_request_loop_detected", nil, 1)
+				rejectLoop(c, lp, fmt.Sprintf("[BricksLLM] agent loop detected: the same request was sent %d times within %s", count, lp.Window), ttl)
+				return
+			}
+		}
+	}
+}
@@ -3,6 +3,7 @@ package proxy
 import (
 	"encoding/json"
 
+	"github.com/bricks-cloud/bricksllm/internal/logger"
 	goopenai "github.com/sashabaranov/go-openai"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -18,7 +19,7 @@ func logCreateMessageRequest(log *zap.Logger, data []byte, prod, private bool, c
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("role", mr.Role),
 			zap.Any("file_ids", mr.FileIds),
 			zap.Any("metadata", mr.Metadata),
@@ -48,7 +49,7 @@ func logMessageResponse(log *zap.Logger, data []byte, prod, private bool, cid st
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("id", m.ID),
 			zap.String("object", m.Object),
 			zap.Int("created_at", m.CreatedAt),
@@ -67,7 +68,7 @@ func logMessageResponse(log *zap.Logger, data []byte, prod, private bool, cid st
 func logRetrieveMessageRequest(log *zap.Logger, prod bool, cid, mid, tid string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("thread_id", tid),
 			zap.String("message_id", mid),
 		}
@@ -86,7 +87,7 @@ func logModifyMessageRequest(log *zap.Logger, data []byte, prod, private bool, c
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("thread_id", tid),
 			zap.String("message_id", mid),
 			zap.Any("metadata", mr.Metadata),
@@ -103,7 +104,7 @@ func logModifyMessageRequest(log *zap.Logger, data []byte, prod, private bool, c
 func logListMessagesRequest(log *zap.Logger, data []byte, prod bool, cid, tid string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("thread_id", tid),
 		}
 
@@ -129,7 +130,7 @@ func logListMessagesResponse(log *zap.Logger, data []byte, prod, private bool, c
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.Any("messages", ms.Messages),
 		}
 
@@ -3,6 +3,7 @@ package proxy
 import (
 	"encoding/json"
 
+	"github.com/bricks-cloud/bricksllm/internal/logger"
 	goopenai "github.com/sashabaranov/go-openai"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -11,7 +12,7 @@ import (
 func logRetrieveMessageFileRequest(log *zap.Logger, prod bool, cid, tid, mid, fid string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("thread_id", tid),
 			zap.String("message_id", mid),
 			zap.String("file_id", fid),
@@ -31,7 +32,7 @@ func logRetrieveMessageFileResponse(log *zap.Logger, data []byte, prod bool, cid
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("id", mf.ID),
 			zap.String("object", mf.Object),
 			zap.Int("created_at", mf.CreatedAt),
@@ -45,7 +46,7 @@ func logRetrieveMessageFileResponse(log *zap.Logger, data []byte, prod bool, cid
 func logListMessageFilesRequest(log *zap.Logger, data []byte, prod bool, cid, tid, mid string, params map[string]string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("thread_id", tid),
 			zap.String("message_id", mid),
 		}
@@ -80,7 +81,7 @@ func logListMessageFilesResponse(log *zap.Logger, data []byte, prod bool, cid st
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.Any("message_files", files.MessageFiles),
 		}
 
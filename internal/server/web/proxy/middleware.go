@@ -3,18 +3,24 @@ package proxy
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
 	"github.com/bricks-cloud/bricksllm/internal/event"
 	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/logger"
+	logzap "github.com/bricks-cloud/bricksllm/internal/logger/zap"
 	"github.com/bricks-cloud/bricksllm/internal/message"
+	"github.com/bricks-cloud/bricksllm/internal/pause"
+	"github.com/bricks-cloud/bricksllm/internal/prompt"
 	"github.com/bricks-cloud/bricksllm/internal/provider"
 	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
-	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/provider/custom"
 	"github.com/bricks-cloud/bricksllm/internal/stats"
 	"github.com/bricks-cloud/bricksllm/internal/util"
 	"github.com/gin-gonic/gin"
@@ -24,6 +30,24 @@ import (
 	goopenai "github.com/sashabaranov/go-openai"
 )
 
+// metadataBodyField is a json body extension that can be used instead of the
+// X-BricksLLM-Metadata header. it is removed before the request is forwarded.
+const metadataBodyField = "bricksllm_metadata"
+
+// variablesBodyField carries the values used to render the prompt template
+// referenced by a route. it is removed before the request is forwarded.
+const variablesBodyField = "bricksllm_variables"
+
+func removeJsonField(body []byte, field string) ([]byte, error) {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+
+	delete(fields, field)
+	return json.Marshal(fields)
+}
+
 type rateLimitError interface {
 	Error() string
 	RateLimit()
@@ -42,6 +66,192 @@ type keyStorage interface {
 	UpdateKey(id string, uk *key.UpdateKey) (*key.ResponseKey, error)
 }
 
+type promptTemplateMemStorage interface {
+	GetPromptTemplate(name string, version int) *prompt.Template
+}
+
+// renderPromptTemplate replaces the variables of the request body with the
+// rendered template messages, followed by any messages sent by the client.
+func renderPromptTemplate(body []byte, t *prompt.Template) ([]byte, error) {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+
+	variables := map[string]string{}
+	if raw, ok := fields[variablesBodyField]; ok {
+		if err := json.Unmarshal(raw, &variables); err != nil {
+			return nil, internal_errors.NewValidationError(variablesBodyField + " must be an object of strings")
+		}
+	}
+
+	rendered, err := t.Render(variables)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []json.RawMessage{}
+	for _, m := range rendered {
+		data, err := json.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, data)
+	}
+
+	if raw, ok := fields["messages"]; ok {
+		existing := []json.RawMessage{}
+		if err := json.Unmarshal(raw, &existing); err != nil {
+			return nil, internal_errors.NewValidationError("messages must be an array")
+		}
+
+		messages = append(messages, existing...)
+	}
+
+	data, err := json.Marshal(messages)
+	if err != nil {
+		return nil, err
+	}
+
+	fields["messages"] = data
+	delete(fields, variablesBodyField)
+
+	return json.Marshal(fields)
+}
+
+// applyKeyParameters applies the default and overridden parameters of a key
+// to a chat completion request body, responding with an error if it cannot.
+func applyKeyParameters(c *gin.Context, kc *key.ResponseKey, body []byte, log *zap.Logger, prod bool, cid string) ([]byte, bool) {
+	applied, err := kc.Parameters.Apply(body)
+	if err != nil {
+		if _, ok := err.(validationError); ok {
+			stats.Incr("bricksllm.proxy.get_middleware.key_parameters_error", nil, 1)
+			JSON(c, http.StatusBadRequest, "[BricksLLM] "+err.Error())
+			c.Abort()
+			return nil, false
+		}
+
+		logError(log, "error when applying key parameters", prod, cid, err)
+		JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to apply key parameters")
+		c.Abort()
+		return nil, false
+	}
+
+	return applied, true
+}
+
+// enforceSystemPrompts applies the system prompts of a key or a route to the
+// messages of a chat completion request body.
+func enforceSystemPrompts(body []byte, prompts ...*prompt.SystemPrompt) ([]byte, error) {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+
+	messages := []json.RawMessage{}
+	if raw, ok := fields["messages"]; ok {
+		if err := json.Unmarshal(raw, &messages); err != nil {
+			return nil, internal_errors.NewValidationError("messages must be an array")
+		}
+	}
+
+	enforced, err := prompt.EnforceSystemPrompts(messages, prompts...)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(enforced)
+	if err != nil {
+		return nil, err
+	}
+
+	fields["messages"] = data
+
+	return json.Marshal(fields)
+}
+
+// getKeyScheduleMiddleware rejects requests of keys outside of their
+// schedules.
+func getKeyScheduleMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		kc, ok := proxyKey(c)
+		if !ok {
+			return
+		}
+
+		if !kc.Schedule.IsActive(time.Now()) {
+			stats.Incr("bricksllm.proxy.get_middleware.key_not_active", nil, 1)
+			JSONError(c, http.StatusForbidden, codeKeyNotActive, "[BricksLLM] key is not active at this time", nil)
+			c.Abort()
+			return
+		}
+	}
+}
+
+// getKeyParametersMiddleware applies the parameters of a key to chat
+// completion requests. Route requests get them in getRouteMiddleware.
+func getKeyParametersMiddleware(log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		kc, ok := proxyKey(c)
+		if !ok || kc.Parameters.IsEmpty() || !isChatCompletionPath(c.FullPath()) {
+			return
+		}
+
+		applied, ok := applyKeyParameters(c, kc, requestBody(c), log, prod, c.GetString(correlationId))
+		if !ok {
+			return
+		}
+
+		setRequestBody(c, applied)
+	}
+}
+
+// getSystemPromptMiddleware enforces the system prompt of a key on chat
+// completion requests. Route requests get it in getRouteMiddleware.
+func getSystemPromptMiddleware(log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		kc, ok := proxyKey(c)
+		if !ok || kc.SystemPrompt == nil || !isChatCompletionPath(c.FullPath()) {
+			return
+		}
+
+		enforced, err := enforceSystemPrompts(requestBody(c), kc.SystemPrompt)
+		if err != nil {
+			if _, ok := err.(validationError); ok {
+				stats.Incr("bricksllm.proxy.get_middleware.system_prompt_violation", nil, 1)
+				JSON(c, http.StatusBadRequest, "[BricksLLM] "+err.Error())
+				c.Abort()
+				return
+			}
+
+			logError(log, "error when enforcing key system prompt", prod, c.GetString(correlationId), err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to enforce system prompt")
+			c.Abort()
+			return
+		}
+
+		setRequestBody(c, enforced)
+	}
+}
+
+func isChatCompletionPath(fullPath string) bool {
+	return fullPath == "/api/providers/openai/v1/chat/completions" || fullPath == "/api/providers/azure/openai/deployments/:deployment_id/chat/completions"
+}
+
+type sessionStorage interface {
+	GetSessionUsage(keyId, sessionId string) (int64, int64, error)
+	IncrementRepeats(fingerprint string, window time.Duration) (int64, time.Duration, error)
+	SetToolCallRequest(keyId, sessionId string, toolCallIds []string, eventId string) error
+	GetToolCallRequest(keyId, sessionId string, toolCallIds []string) (string, error)
+	ClaimResponseUsage(responseId string) (bool, error)
+	ClaimBatchItems(batchId string, customIds []string) ([]bool, error)
+}
+
+type quotaStorage interface {
+	SetQuota(q *provider.Quota) error
+}
+
 type estimator interface {
 	EstimateChatCompletionPromptCostWithTokenCounts(r *goopenai.ChatCompletionRequest) (int, float64, error)
 	EstimateEmbeddingsCost(r *goopenai.EmbeddingRequest) (float64, error)
@@ -59,14 +269,17 @@ type azureEstimator interface {
 	EstimatePromptCost(model string, tks int) (float64, error)
 	EstimateTotalCost(model string, promptTks, completionTks int) (float64, error)
 	EstimateEmbeddingsInputCost(model string, tks int) (float64, error)
+	RegionalMultiplier(region string) float64
 }
 
 type authenticator interface {
 	AuthenticateHttpRequest(req *http.Request) (*key.ResponseKey, []*provider.Setting, error)
+	AuthenticateKey(req *http.Request) (*key.ResponseKey, error)
 }
 
 type validator interface {
 	Validate(k *key.ResponseKey, promptCost float64) error
+	GetBudgetUsage(k *key.ResponseKey) (float64, error)
 }
 
 type rateLimitManager interface {
@@ -74,20 +287,21 @@ type rateLimitManager interface {
 }
 
 type accessCache interface {
-	GetAccessStatus(key string) bool
+	GetAccessStatus(key string) (bool, error)
 }
 
-type encrypter interface {
-	Encrypt(secret string) string
+type driftMonitor interface {
+	ShouldSample() bool
+	Record(model string, estimated, reported int)
 }
 
-func JSON(c *gin.Context, code int, message string) {
-	c.JSON(code, &goopenai.ErrorResponse{
-		Error: &goopenai.APIError{
-			Message: message,
-			Code:    strconv.Itoa(code),
-		},
-	})
+type outageMonitor interface {
+	Allow(subsystem string) bool
+	AllowFailure(subsystem string) bool
+}
+
+type encrypter interface {
+	Encrypt(secret string) string
 }
 
 type notAuthorizedError interface {
@@ -98,10 +312,71 @@ type notFoundError interface {
 	NotFound()
 }
 
+type validationError interface {
+	Validation()
+}
+
 type publisher interface {
 	Publish(message.Message)
 }
 
+func isProviderNativelySupported(name string) bool {
+	return name == "openai" || name == "azure" || name == "anthropic"
+}
+
+// recordProviderQuota stores the rate limit headers that the handler copied
+// from the upstream response under the provider setting used for the request.
+func recordProviderQuota(c *gin.Context, qs quotaStorage, providerName string) error {
+	raw, exists := c.Get("settings")
+	if !exists {
+		return nil
+	}
+
+	settings, ok := raw.([]*provider.Setting)
+	if !ok || len(settings) == 0 || settings[0] == nil {
+		return nil
+	}
+
+	q := provider.ParseQuota(settings[0].Id, providerName, c.Writer.Header())
+	if q == nil {
+		return nil
+	}
+
+	tags := []string{"provider:" + providerName, "setting_id:" + q.SettingId}
+	if q.RemainingRequests != nil {
+		stats.Gauge("bricksllm.proxy.get_middleware.provider_remaining_requests", float64(*q.RemainingRequests), tags, 1)
+	}
+
+	if q.RemainingTokens != nil {
+		stats.Gauge("bricksllm.proxy.get_middleware.provider_remaining_tokens", float64(*q.RemainingTokens), tags, 1)
+	}
+
+	return qs.SetQuota(q)
+}
+
+// getSettingRegion returns the region of the provider setting that served the
+// request.
+func getSettingRegion(c *gin.Context) string {
+	raw, exists := c.Get("settings")
+	if !exists {
+		return ""
+	}
+
+	settings, ok := raw.([]*provider.Setting)
+	if !ok {
+		return ""
+	}
+
+	settingId := c.GetString("settingId")
+	for _, setting := range settings {
+		if setting != nil && setting.Id == settingId {
+			return setting.Region
+		}
+	}
+
+	return ""
+}
+
 func getProvider(c *gin.Context) string {
 	existing := c.GetString("provider")
 	if len(existing) != 0 {
@@ -131,7 +406,53 @@ func getProvider(c *gin.Context) string {
 	return ""
 }
 
-func getMiddleware(kms keyMemStorage, cpm CustomProvidersManager, rm routeManager, a authenticator, prod, private bool, e estimator, ae anthropicEstimator, aoe azureEstimator, v validator, ks keyStorage, log *zap.Logger, rlm rateLimitManager, pub publisher, prefix string, ac accessCache) gin.HandlerFunc {
+// proxyKey returns the key getMiddleware authenticated a request with.
+// Requests that are not proxied, such as self-service requests, have none.
+func proxyKey(c *gin.Context) (*key.ResponseKey, bool) {
+	raw, exists := c.Get("key")
+	if !exists {
+		return nil, false
+	}
+
+	kc, ok := raw.(*key.ResponseKey)
+	return kc, ok && kc != nil
+}
+
+func proxySettings(c *gin.Context) []*provider.Setting {
+	raw, _ := c.Get("settings")
+	settings, _ := raw.([]*provider.Setting)
+	return settings
+}
+
+// requestBody returns the request body as changed by the middlewares before.
+func requestBody(c *gin.Context) []byte {
+	raw, _ := c.Get("requestBody")
+	body, _ := raw.([]byte)
+	return body
+}
+
+// setRequestBody replaces the request body for the middlewares and handlers
+// after.
+func setRequestBody(c *gin.Context, body []byte) {
+	c.Set("requestBody", body)
+
+	if c.Request.Method != http.MethodGet {
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Request.ContentLength = int64(len(body))
+	}
+}
+
+// setEventMetadata sets a metadata field of an event, adding metadata to
+// events of requests sent without any.
+func setEventMetadata(evt *event.Event, k, v string) {
+	if evt.Metadata == nil {
+		evt.Metadata = map[string]string{}
+	}
+
+	evt.Metadata[k] = v
+}
+
+func getMiddleware(a authenticator, prod bool, aoe azureEstimator, log *zap.Logger, pub publisher, prefix string, qs quotaStorage, ss sessionStorage, ps pauseMemStorage, phr providerHealthRecorder, dm driftMonitor, tracingEnabled bool, rf *logzap.RequestFields) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if c == nil || c.Request == nil {
 			JSON(c, http.StatusInternalServerError, "[BricksLLM] request is empty")
@@ -144,13 +465,32 @@ func getMiddleware(kms keyMemStorage, cpm CustomProvidersManager, rm routeManage
 			return
 		}
 
+		if isSelfServicePath(c.FullPath()) || isFeedbackPath(c.FullPath()) || isJobPath(c.FullPath()) {
+			return
+		}
+
 		cid := util.NewUuid()
 		c.Set(correlationId, cid)
+		defer rf.Delete(cid)
 		start := time.Now()
 
+		// the event id is known upfront so that results computed after
+		// the response, such as judge scores, can be attached to the event.
+		eventId := util.NewUuid()
+		c.Set("eventId", eventId)
+		c.Header(requestIdHeader, eventId)
+
+		var tc *traceContext
+		if tracingEnabled {
+			tc = extractTraceContext(c.Request.Header)
+		}
+
+		ci := parseClient(c.Request.Header)
+
 		enrichedEvent := &event.EventWithRequestAndContent{}
 
 		customId := c.Request.Header.Get("X-CUSTOM-EVENT-ID")
+		var metadata map[string]string
 		defer func() {
 			dur := time.Now().Sub(start)
 			latency := int(dur.Milliseconds())
@@ -160,10 +500,12 @@ func getMiddleware(kms keyMemStorage, cpm CustomProvidersManager, rm routeManage
 			}
 
 			keyId := ""
+			tenantId := ""
 			tags := []string{}
 
 			if enrichedEvent.Key != nil {
 				keyId = enrichedEvent.Key.KeyId
+				tenantId = enrichedEvent.Key.TenantId
 				tags = enrichedEvent.Key.Tags
 			}
 
@@ -173,13 +515,15 @@ func getMiddleware(kms keyMemStorage, cpm CustomProvidersManager, rm routeManage
 
 			if prod {
 				log.Info("response to proxy",
-					zap.String(correlationId, c.GetString(correlationId)),
-					zap.String("provider", selectedProvider),
-					zap.String("keyId", keyId),
-					zap.Int("code", c.Writer.Status()),
-					zap.String("method", c.Request.Method),
-					zap.String("path", c.FullPath()),
-					zap.Int("lantecyInMs", latency),
+					zap.String(logger.FieldRequestId, c.GetString(correlationId)),
+					zap.String(logger.FieldKeyId, keyId),
+					zap.String(logger.FieldRoute, c.Param("route")),
+					zap.String(logger.FieldProvider, selectedProvider),
+					zap.String(logger.FieldModel, c.GetString("model")),
+					zap.Int(logger.FieldStatusCode, c.Writer.Status()),
+					zap.String(logger.FieldMethod, c.Request.Method),
+					zap.String(logger.FieldPath, c.FullPath()),
+					zap.Int(logger.FieldLatencyInMs, latency),
 				)
 			}
 
@@ -187,12 +531,21 @@ func getMiddleware(kms keyMemStorage, cpm CustomProvidersManager, rm routeManage
 				"status:" + strconv.Itoa(c.Writer.Status()),
 			}, 1)
 
+			if estimated := c.GetInt("estimatedPromptTokenCount"); estimated != 0 && c.Writer.Status() == http.StatusOK {
+				dm.Record(c.GetString("model"), estimated, c.GetInt("promptTokenCount"))
+			}
+
+			costInUsd := c.GetFloat64("costInUsd")
+			if selectedProvider == "azure" && costInUsd != 0 {
+				costInUsd *= aoe.RegionalMultiplier(getSettingRegion(c))
+			}
+
 			evt := &event.Event{
-				Id:                   util.NewUuid(),
+				Id:                   eventId,
 				CreatedAt:            time.Now().Unix(),
 				Tags:                 tags,
 				KeyId:                keyId,
-				CostInUsd:            c.GetFloat64("costInUsd"),
+				CostInUsd:            costInUsd,
 				Provider:             selectedProvider,
 				Model:                c.GetString("model"),
 				Status:               c.Writer.Status(),
@@ -202,6 +555,94 @@ func getMiddleware(kms keyMemStorage, cpm CustomProvidersManager, rm routeManage
 				Path:                 c.FullPath(),
 				Method:               c.Request.Method,
 				CustomId:             customId,
+				UserId:               c.GetString("userId"),
+				Route:                c.Param("route"),
+				Metadata:             metadata,
+				CorrelationId:        cid,
+				TenantId:             tenantId,
+				SessionId:            c.GetString("sessionId"),
+				Language:             c.GetString("language"),
+				SettingId:            c.GetString("settingId"),
+				ParentId:             c.GetString("parentId"),
+				UserAgent:            ci.userAgent,
+				Sdk:                  ci.sdk,
+				SdkVersion:           ci.sdkVersion,
+			}
+
+			if tc != nil {
+				evt.TraceId = tc.traceId
+				evt.SpanId = tc.spanId
+			}
+
+			if c.GetBool("outputTruncated") {
+				setEventMetadata(evt, truncatedMetadataKey, string(goopenai.FinishReasonLength))
+			}
+
+			if c.GetBool("streamBlocked") {
+				setEventMetadata(evt, truncatedMetadataKey, string(goopenai.FinishReasonContentFilter))
+			}
+
+			if settingId := c.GetString("overriddenSettingId"); len(settingId) != 0 {
+				setEventMetadata(evt, settingOverrideMetadataKey, settingId)
+			}
+
+			if tks := c.GetInt("originalPromptTokens"); tks != 0 {
+				setEventMetadata(evt, originalPromptTokensMetadataKey, strconv.Itoa(tks))
+				setEventMetadata(evt, compressedPromptTokensMetadataKey, strconv.Itoa(c.GetInt("compressedPromptTokens")))
+			}
+
+			if from := c.GetString("downgradedFrom"); len(from) != 0 {
+				setEventMetadata(evt, downgradedMetadataKey, from)
+			}
+
+			if decision := c.GetString("complexity"); len(decision) != 0 {
+				setEventMetadata(evt, complexityMetadataKey, decision)
+				setEventMetadata(evt, complexityScoreMetadataKey, strconv.FormatFloat(c.GetFloat64("complexityScore"), 'f', 2, 64))
+			}
+
+			if decision := c.GetString("contextWindow"); len(decision) != 0 {
+				setEventMetadata(evt, contextWindowMetadataKey, decision)
+				if dropped := c.GetInt("droppedMessages"); dropped != 0 {
+					setEventMetadata(evt, droppedMessagesMetadataKey, strconv.Itoa(dropped))
+				}
+			}
+
+			if c.GetBool("retrieved") {
+				setEventMetadata(evt, retrievalLatencyMetadataKey, strconv.Itoa(c.GetInt("retrievalLatency")))
+				setEventMetadata(evt, retrievalChunkIdsMetadataKey, strings.Join(c.GetStringSlice("retrievalChunkIds"), ","))
+			}
+
+			if verdicts := guardrailVerdictsMetadata(c); len(verdicts) != 0 {
+				setEventMetadata(evt, guardrailVerdictsMetadataKey, verdicts)
+			}
+
+			if upstreamErr := c.GetString("upstreamError"); len(upstreamErr) != 0 {
+				setEventMetadata(evt, upstreamErrorMetadataKey, upstreamErr)
+			}
+
+			if decisions := requestDecisions(c, selectedProvider); len(decisions) != 0 {
+				evt.Decisions = decisions
+			}
+
+			if isProviderNativelySupported(selectedProvider) {
+				if err := recordProviderQuota(c, qs, selectedProvider); err != nil {
+					stats.Incr("bricksllm.proxy.get_middleware.record_provider_quota_error", nil, 1)
+					logError(log, "error when recording provider quota", prod, cid, err)
+				}
+			}
+
+			rotateRateLimitedKey(c)
+
+			if err := recordProviderHealth(c, phr, selectedProvider); err != nil {
+				stats.Incr("bricksllm.proxy.get_middleware.record_provider_health_error", nil, 1)
+				logError(log, "error when recording provider health", prod, cid, err)
+			}
+
+			if ids := c.GetStringSlice("toolCallIds"); len(ids) != 0 && len(evt.SessionId) != 0 {
+				if err := ss.SetToolCallRequest(keyId, evt.SessionId, ids, eventId); err != nil {
+					stats.Incr("bricksllm.proxy.get_middleware.set_tool_call_request_error", nil, 1)
+					logError(log, "error when setting tool call request", prod, cid, err)
+				}
 			}
 
 			enrichedEvent.Event = evt
@@ -210,11 +651,29 @@ func getMiddleware(kms keyMemStorage, cpm CustomProvidersManager, rm routeManage
 				enrichedEvent.Content = content
 			}
 
+			if req, ok := c.Get("request"); ok {
+				enrichedEvent.Request = req
+			}
+
+			if raw, ok := c.Get("route_config"); ok {
+				if rc, ok := raw.(*custom.RouteConfig); ok {
+					enrichedEvent.RouteConfig = rc
+				}
+			}
+
 			resp, ok := c.Get("response")
 			if ok {
 				enrichedEvent.Response = resp
 			}
 
+			if raw, ok := c.Get("recordedRequest"); ok {
+				enrichedEvent.RecordedRequest, _ = raw.(*event.RecordedRequest)
+			}
+
+			if raw, ok := c.Get("sample"); ok {
+				enrichedEvent.Sample, _ = raw.(*event.Sample)
+			}
+
 			pub.Publish(message.Message{
 				Type: "event",
 				Data: enrichedEvent,
@@ -228,6 +687,19 @@ func getMiddleware(kms keyMemStorage, cpm CustomProvidersManager, rm routeManage
 			return
 		}
 
+		if p := ps.GetPause(pause.ScopeGlobal, ""); p != nil {
+			rejectPaused(c, p)
+			return
+		}
+
+		if p := ps.GetPause(pause.ScopeProvider, getProvider(c)); p != nil {
+			rejectPaused(c, p)
+			return
+		}
+
+		settingOverride := c.Request.Header.Get(settingOverrideHeader)
+		providerOverride := c.Request.Header.Get(providerOverrideHeader)
+
 		kc, settings, err := a.AuthenticateHttpRequest(c.Request)
 		enrichedEvent.Key = kc
 		_, ok := err.(notAuthorizedError)
@@ -246,6 +718,27 @@ func getMiddleware(kms keyMemStorage, cpm CustomProvidersManager, rm routeManage
 			return
 		}
 
+		if _, ok := err.(unavailableError); ok {
+			stats.Incr("bricksllm.proxy.get_middleware.provider_unhealthy", nil, 1)
+			JSONError(c, http.StatusServiceUnavailable, codeProviderUnhealthy, "[BricksLLM] "+err.Error(), nil)
+			c.Abort()
+			return
+		}
+
+		if _, ok := err.(residencyError); ok {
+			stats.Incr("bricksllm.proxy.get_middleware.residency_not_satisfied", nil, 1)
+			JSONError(c, http.StatusForbidden, codeResidencyNotSatisfied, "[BricksLLM] "+err.Error(), nil)
+			c.Abort()
+			return
+		}
+
+		if _, ok := err.(validationError); ok {
+			stats.Incr("bricksllm.proxy.get_middleware.provider_override_error", nil, 1)
+			JSONError(c, http.StatusBadRequest, codeProviderNotFound, "[BricksLLM] "+err.Error(), nil)
+			c.Abort()
+			return
+		}
+
 		if err != nil {
 			stats.Incr("bricksllm.proxy.get_middleware.authenticate_http_request_error", nil, 1)
 			logError(log, "error when authenticating http requests", prod, cid, err)
@@ -257,6 +750,28 @@ func getMiddleware(kms keyMemStorage, cpm CustomProvidersManager, rm routeManage
 		c.Set("key", kc)
 		c.Set("settings", settings)
 
+		// route requests record the setting of the step that responded.
+		if len(settings) != 0 && settings[0] != nil && !strings.HasPrefix(c.FullPath(), "/api/routes") {
+			c.Set("settingId", settings[0].Id)
+		}
+
+		if len(settingOverride) != 0 {
+			c.Set("overriddenSettingId", settingOverride)
+		} else if len(providerOverride) != 0 && len(settings) != 0 && !strings.HasPrefix(c.FullPath(), "/api/routes") {
+			c.Set("overriddenSettingId", settings[0].Id)
+		}
+
+		if sessionId := c.GetHeader("X-BricksLLM-Session-Id"); len(sessionId) != 0 {
+			if !event.IsValidSessionId(sessionId) {
+				stats.Incr("bricksllm.proxy.get_middleware.invalid_session_id", nil, 1)
+				JSON(c, http.StatusBadRequest, "[BricksLLM] session id is invalid")
+				c.Abort()
+				return
+			}
+
+			c.Set("sessionId", sessionId)
+		}
+
 		if len(settings) >= 1 {
 			if strings.HasPrefix(c.FullPath(), "/api/providers/azure/openai") {
 				selected := settings[0]
@@ -270,26 +785,131 @@ func getMiddleware(kms keyMemStorage, cpm CustomProvidersManager, rm routeManage
 		body, err := io.ReadAll(c.Request.Body)
 		if err != nil {
 			logError(log, "error when reading request body", prod, cid, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read request body")
+			c.Abort()
+			return
+		}
+
+		if !verifySignature(c, kc, body) {
+			return
+		}
+
+		if raw := c.GetHeader("X-BricksLLM-Metadata"); len(raw) != 0 {
+			parsed, err := event.ParseMetadata([]byte(raw))
+			if err != nil {
+				stats.Incr("bricksllm.proxy.get_middleware.invalid_metadata", nil, 1)
+				JSON(c, http.StatusBadRequest, "[BricksLLM] "+err.Error())
+				c.Abort()
+				return
+			}
+
+			metadata = parsed
+		}
+
+		if result := gjson.GetBytes(body, metadataBodyField); result.IsObject() {
+			parsed, err := event.ParseMetadata([]byte(result.Raw))
+			if err != nil {
+				stats.Incr("bricksllm.proxy.get_middleware.invalid_metadata", nil, 1)
+				JSON(c, http.StatusBadRequest, "[BricksLLM] "+err.Error())
+				c.Abort()
+				return
+			}
+
+			if metadata == nil {
+				metadata = map[string]string{}
+			}
+
+			for k, v := range parsed {
+				metadata[k] = v
+			}
+
+			stripped, err := removeJsonField(body, metadataBodyField)
+			if err != nil {
+				logError(log, "error when removing metadata from request body", prod, cid, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to remove metadata from request body")
+				c.Abort()
+				return
+			}
+
+			body = stripped
+		}
+
+		parentId := c.GetHeader(parentRequestIdHeader)
+		if len(parentId) != 0 && !event.IsValidRequestId(parentId) {
+			stats.Incr("bricksllm.proxy.get_middleware.invalid_parent_request_id", nil, 1)
+			JSON(c, http.StatusBadRequest, "[BricksLLM] parent request id is invalid")
+			c.Abort()
 			return
 		}
 
-		if c.Request.Method != http.MethodGet {
-			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		// follow-up requests carrying tool results are linked to the request
+		// that made the tool calls when they belong to the same session.
+		if sessionId := c.GetString("sessionId"); len(parentId) == 0 && len(sessionId) != 0 && (isChatCompletionPath(c.FullPath()) || isResponsesPath(c.FullPath()) || strings.HasPrefix(c.FullPath(), "/api/routes")) {
+			if ids := requestToolCallIds(body); len(ids) != 0 {
+				found, err := ss.GetToolCallRequest(kc.KeyId, sessionId, ids)
+				if err != nil {
+					stats.Incr("bricksllm.proxy.get_middleware.get_tool_call_request_error", nil, 1)
+					logError(log, "error when getting tool call request", prod, cid, err)
+				}
+
+				parentId = found
+			}
+		}
+
+		c.Set("parentId", parentId)
+
+		if !kc.IsFeatureEnabled(key.FeatureTracing, tracingEnabled) {
+			tc = nil
+		} else if tc == nil {
+			tc = extractTraceContext(c.Request.Header)
 		}
 
+		fields := []zap.Field{
+			zap.String(logger.FieldKeyId, kc.KeyId),
+			zap.String(logger.FieldRoute, c.Param("route")),
+			zap.String(logger.FieldProvider, getProvider(c)),
+			zap.String(logger.FieldModel, gjson.GetBytes(body, "model").Str),
+		}
+
+		if tc != nil {
+			fields = append(fields, tc.logFields()...)
+		}
+
+		rf.Set(cid, fields...)
+
+		setRequestBody(c, body)
+
+		c.Next()
+	}
+}
+
+// getRequestMiddleware parses requests for the handlers and the events of
+// the requests, and checks that the key can call the path and the model of a
+// request.
+func getRequestMiddleware(cpm CustomProvidersManager, e estimator, dm driftMonitor, log *zap.Logger, prod, private bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		kc, ok := proxyKey(c)
+		if !ok {
+			return
+		}
+
+		settings := proxySettings(c)
+		body := requestBody(c)
+		cid := c.GetString(correlationId)
+
 		// var cost float64 = 0
 
 		if c.FullPath() == "/api/providers/anthropic/v1/complete" {
 			logCompletionRequest(log, body, prod, private, cid)
 
 			cr := &anthropic.CompletionRequest{}
-			err = json.Unmarshal(body, cr)
+			err := json.Unmarshal(body, cr)
 			if err != nil {
 				logError(log, "error when unmarshalling anthropic completion request", prod, cid, err)
 				return
 			}
 
-			enrichedEvent.Request = cr
+			c.Set("request", cr)
 
 			// tks := ae.Count(cr.Prompt)
 			// tks += anthropicPromptMagicNum
@@ -315,19 +935,18 @@ func getMiddleware(kms keyMemStorage, cpm CustomProvidersManager, rm routeManage
 			providerName := c.Param("provider")
 
 			rc := cpm.GetRouteConfigFromMem(providerName, c.Param("wildcard"))
-			enrichedEvent.RouteConfig = rc
 
 			cp := cpm.GetCustomProviderFromMem(providerName)
 			if cp == nil {
 				stats.Incr("bricksllm.proxy.get_middleware.provider_not_found", nil, 1)
-				JSON(c, http.StatusNotFound, "[BricksLLM] requested custom provider is not found")
+				JSONError(c, http.StatusNotFound, codeProviderNotFound, "[BricksLLM] requested custom provider is not found", nil)
 				c.Abort()
 				return
 			}
 
 			if rc == nil {
 				stats.Incr("bricksllm.proxy.get_middleware.route_config_not_found", nil, 1)
-				JSON(c, http.StatusNotFound, "[BricksLLM] route config is not found")
+				JSONError(c, http.StatusNotFound, codeRouteNotFound, "[BricksLLM] route config is not found", nil)
 				c.Abort()
 				return
 			}
@@ -335,12 +954,7 @@ func getMiddleware(kms keyMemStorage, cpm CustomProvidersManager, rm routeManage
 			c.Set("provider", cp)
 			c.Set("route_config", rc)
 
-			enrichedEvent.Request = body
-
-			customResponse, ok := c.Get("response")
-			if ok {
-				enrichedEvent.Response = customResponse
-			}
+			c.Set("request", body)
 
 			// tks, err := countTokensFromJson(body, rc.RequestPromptLocation)
 			// if err != nil {
@@ -361,71 +975,16 @@ func getMiddleware(kms keyMemStorage, cpm CustomProvidersManager, rm routeManage
 			}
 		}
 
-		if strings.HasPrefix(c.FullPath(), "/api/routes") {
-			r := c.Param("route")
-			rc := rm.GetRouteFromMemDb(r)
-
-			if rc == nil {
-				stats.Incr("bricksllm.proxy.get_middleware.route_config_not_found", nil, 1)
-				JSON(c, http.StatusNotFound, "[BricksLLM] route config is not found")
-				c.Abort()
-				return
-			}
-
-			c.Set("route_config", rc)
-
-			if rc.ShouldRunEmbeddings() {
-				er := &goopenai.EmbeddingRequest{}
-				err = json.Unmarshal(body, er)
-				if err != nil {
-					logError(log, "error when unmarshalling route embedding request", prod, cid, err)
-					return
-				}
-
-				if rc.CacheConfig != nil && rc.CacheConfig.Enabled {
-					c.Set("cache_key", route.ComputeCacheKeyForEmbeddingsRequest(r, er))
-				}
-
-				c.Set("encoding_format", string(er.EncodingFormat))
-
-				logEmbeddingRequest(log, prod, private, cid, er)
-			}
-
-			if !rc.ShouldRunEmbeddings() {
-				ccr := &goopenai.ChatCompletionRequest{}
-
-				err = json.Unmarshal(body, ccr)
-				if err != nil {
-					logError(log, "error when unmarshalling route chat completion request", prod, cid, err)
-					return
-				}
-
-				enrichedEvent.Request = ccr
-
-				logRequest(log, prod, private, cid, ccr)
-
-				if ccr.Stream {
-					stats.Incr("bricksllm.proxy.get_middleware.streaming_not_allowed", nil, 1)
-					JSON(c, http.StatusForbidden, "[BricksLLM] streaming is not allowed")
-					c.Abort()
-					return
-				}
-
-				if rc.CacheConfig != nil && rc.CacheConfig.Enabled {
-					c.Set("cache_key", route.ComputeCacheKeyForChatCompletionRequest(r, ccr))
-				}
-			}
-		}
-
 		if c.FullPath() == "/api/providers/azure/openai/deployments/:deployment_id/chat/completions" {
 			ccr := &goopenai.ChatCompletionRequest{}
-			err = json.Unmarshal(body, ccr)
+			err := json.Unmarshal(body, ccr)
 			if err != nil {
 				logError(log, "error when unmarshalling azure openai chat completion request", prod, cid, err)
 				return
 			}
 
-			enrichedEvent.Request = ccr
+			c.Set("request", ccr)
+			c.Set("userId", ccr.User)
 
 			logRequest(log, prod, private, cid, ccr)
 
@@ -443,7 +1002,7 @@ func getMiddleware(kms keyMemStorage, cpm CustomProvidersManager, rm routeManage
 
 		if c.FullPath() == "/api/providers/azure/openai/deployments/:deployment_id/embeddings" {
 			er := &goopenai.EmbeddingRequest{}
-			err = json.Unmarshal(body, er)
+			err := json.Unmarshal(body, er)
 			if err != nil {
 				logError(log, "error when unmarshalling azure openai embedding request", prod, cid, err)
 				return
@@ -451,6 +1010,7 @@ func getMiddleware(kms keyMemStorage, cpm CustomProvidersManager, rm routeManage
 
 			c.Set("model", "ada")
 			c.Set("encoding_format", string(er.EncodingFormat))
+			c.Set("userId", er.User)
 
 			logEmbeddingRequest(log, prod, private, cid, er)
 
@@ -463,15 +1023,22 @@ func getMiddleware(kms keyMemStorage, cpm CustomProvidersManager, rm routeManage
 
 		if c.FullPath() == "/api/providers/openai/v1/chat/completions" {
 			ccr := &goopenai.ChatCompletionRequest{}
-			err = json.Unmarshal(body, ccr)
+			err := json.Unmarshal(body, ccr)
 			if err != nil {
 				logError(log, "error when unmarshalling chat completion request", prod, cid, err)
 				return
 			}
 
-			enrichedEvent.Request = ccr
+			c.Set("request", ccr)
 
 			c.Set("model", ccr.Model)
+			c.Set("userId", ccr.User)
+
+			if !ccr.Stream && dm.ShouldSample() {
+				if tks, err := e.EstimateChatCompletionPromptTokenCounts(ccr.Model, ccr); err == nil {
+					c.Set("estimatedPromptTokenCount", tks)
+				}
+			}
 
 			logRequest(log, prod, private, cid, ccr)
 
@@ -491,7 +1058,7 @@ func getMiddleware(kms keyMemStorage, cpm CustomProvidersManager, rm routeManage
 
 		if c.FullPath() == "/api/providers/openai/v1/embeddings" {
 			er := &goopenai.EmbeddingRequest{}
-			err = json.Unmarshal(body, er)
+			err := json.Unmarshal(body, er)
 			if err != nil {
 				logError(log, "error when unmarshalling embedding request", prod, cid, err)
 				return
@@ -499,6 +1066,7 @@ func getMiddleware(kms keyMemStorage, cpm CustomProvidersManager, rm routeManage
 
 			c.Set("model", string(er.Model))
 			c.Set("encoding_format", string(er.EncodingFormat))
+			c.Set("userId", er.User)
 
 			logEmbeddingRequest(log, prod, private, cid, er)
 
@@ -509,6 +1077,20 @@ func getMiddleware(kms keyMemStorage, cpm CustomProvidersManager, rm routeManage
 			// }
 		}
 
+		if isResponsesPath(c.FullPath()) && c.Request.Method == http.MethodPost {
+			c.Set("model", gjson.GetBytes(body, "model").Str)
+			c.Set("userId", gjson.GetBytes(body, "user").Str)
+
+			if gjson.GetBytes(body, "stream").Bool() {
+				c.Set("stream", true)
+			}
+		}
+
+		// background responses can be streamed again when they are retrieved.
+		if c.FullPath() == "/api/providers/openai/v1/responses/:response_id" && c.Request.Method == http.MethodGet && c.Query("stream") == "true" {
+			c.Set("stream", true)
+		}
+
 		if c.FullPath() == "/api/providers/openai/v1/images/generations" && c.Request.Method == http.MethodPost {
 			ir := &goopenai.ImageRequest{}
 			err := json.Unmarshal(body, ir)
@@ -600,15 +1182,17 @@ func getMiddleware(kms keyMemStorage, cpm CustomProvidersManager, rm routeManage
 
 		if len(kc.AllowedPaths) != 0 && !containsPath(kc.AllowedPaths, c.FullPath(), c.Request.Method) {
 			stats.Incr("bricksllm.proxy.get_middleware.path_not_allowed", nil, 1)
-			JSON(c, http.StatusForbidden, "[BricksLLM] path is not allowed")
+			JSONError(c, http.StatusForbidden, codePathNotAllowed, "[BricksLLM] path is not allowed", nil)
 			c.Abort()
 			return
 		}
 
 		model := c.GetString("model")
-		if !isModelAllowed(model, settings) {
+		if !isModelAllowed(model, settings) || !kc.ModelPolicy.IsAllowed(model) {
 			stats.Incr("bricksllm.proxy.get_middleware.model_not_allowed", nil, 1)
-			JSON(c, http.StatusForbidden, "[BricksLLM] model is not allowed")
+			JSONError(c, http.StatusForbidden, codeModelNotAllowed, fmt.Sprintf("[BricksLLM] model %s is not allowed", model), map[string]interface{}{
+				"model": model,
+			})
 			c.Abort()
 			return
 		}
@@ -787,14 +1371,6 @@ func getMiddleware(kms keyMemStorage, cpm CustomProvidersManager, rm routeManage
 			logRetrieveFileContentRequest(log, body, prod, cid, fid)
 		}
 
-		if ac.GetAccessStatus(kc.KeyId) {
-			stats.Incr("bricksllm.proxy.get_middleware.rate_limited", nil, 1)
-			JSON(c, http.StatusTooManyRequests, "[BricksLLM] too many requests")
-			c.Abort()
-			return
-		}
-
-		c.Next()
 	}
 }
 
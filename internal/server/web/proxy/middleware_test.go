@@ -0,0 +1,184 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	logzap "github.com/bricks-cloud/bricksllm/internal/logger/zap"
+	"github.com/bricks-cloud/bricksllm/internal/message"
+	"github.com/bricks-cloud/bricksllm/internal/pause"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/server/web"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakePublisher struct {
+	messages []message.Message
+}
+
+func (p *fakePublisher) Publish(m message.Message) {
+	p.messages = append(p.messages, m)
+}
+
+// fakePauseStorage returns its global pause for the global scope only.
+type fakePauseStorage struct {
+	global *pause.Pause
+}
+
+func (s fakePauseStorage) GetPause(scope, target string) *pause.Pause {
+	if scope == pause.ScopeGlobal {
+		return s.global
+	}
+
+	return nil
+}
+
+type fakeHealthRecorder struct{}
+
+func (fakeHealthRecorder) RecordStatus(setting *provider.Setting, status int) error {
+	return nil
+}
+
+type fakeDriftMonitor struct{}
+
+func (fakeDriftMonitor) ShouldSample() bool {
+	return false
+}
+
+func (fakeDriftMonitor) Record(model string, estimated, reported int) {}
+
+func TestIsModelAllowed(t *testing.T) {
+	cases := []struct {
+		name     string
+		model    string
+		settings []*provider.Setting
+		expected bool
+	}{
+		{name: "requests without a model", model: "", settings: []*provider.Setting{{AllowedModels: []string{"gpt-4o"}}}, expected: true},
+		{name: "settings without allowed models", model: "gpt-4o", settings: []*provider.Setting{{}}, expected: true},
+		{name: "allowed models", model: "gpt-4o", settings: []*provider.Setting{{AllowedModels: []string{"gpt-4o-mini", "gpt-4o"}}}, expected: true},
+		{name: "other models", model: "gpt-4", settings: []*provider.Setting{{AllowedModels: []string{"gpt-4o"}}}, expected: false},
+		{name: "models allowed by any setting", model: "gpt-4", settings: []*provider.Setting{{AllowedModels: []string{"gpt-4o"}}, {AllowedModels: []string{"gpt-4"}}}, expected: true},
+		{name: "wildcards are matched exactly", model: "gpt-4o-mini", settings: []*provider.Setting{{AllowedModels: []string{"gpt-4o-*"}}}, expected: false},
+		{name: "families are matched exactly", model: "gpt-4o-2024-08-06", settings: []*provider.Setting{{AllowedModels: []string{"family:gpt-4o"}}}, expected: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, isModelAllowed(tc.model, tc.settings))
+		})
+	}
+}
+
+func TestGetMiddleware(t *testing.T) {
+	chatPath := "/api/providers/openai/v1/chat/completions"
+	otherDay := strings.ToLower(time.Now().UTC().Add(48 * time.Hour).Weekday().String()[:3])
+
+	cases := []struct {
+		name     string
+		kc       *key.ResponseKey
+		body     string
+		expected int
+		received string
+		metadata map[string]string
+		recorded bool
+	}{
+		{
+			name:     "requests are passed to the handler",
+			kc:       &key.ResponseKey{KeyId: "k1"},
+			body:     `{"model":"gpt-4o"}`,
+			expected: http.StatusOK,
+			received: `{"model":"gpt-4o"}`,
+		},
+		{
+			name:     "bodies changed by middlewares are passed on",
+			kc:       &key.ResponseKey{KeyId: "k1", OutputCaps: &key.OutputCaps{MaxTokens: 10}},
+			body:     `{"model":"gpt-4o","bricksllm_metadata":{"team":"a"}}`,
+			expected: http.StatusOK,
+			received: `{"model":"gpt-4o","max_tokens":10}`,
+			metadata: map[string]string{"team": "a"},
+		},
+		{
+			name:     "requests rejected by middlewares",
+			kc:       &key.ResponseKey{KeyId: "k1", Schedule: &key.Schedule{Windows: []*key.ActiveWindow{{Days: []string{otherDay}, Start: "00:00", End: "23:59"}}}},
+			body:     `{"model":"gpt-4o"}`,
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "recorded requests",
+			kc:       &key.ResponseKey{KeyId: "k1", FeatureFlags: map[string]bool{key.FeatureRecordRequests: true}},
+			body:     `{"model":"gpt-4o"}`,
+			expected: http.StatusOK,
+			received: `{"model":"gpt-4o"}`,
+			recorded: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			pub := &fakePublisher{}
+			a := &fakeKeyAuthenticator{kc: tc.kc, settings: []*provider.Setting{{Id: "s1", Provider: "openai"}}}
+			router, err := web.NewRouter(nil)
+			require.NoError(t, err)
+
+			router.Use(getMiddleware(a, false, nil, zap.NewNop(), pub, "proxy", nil, nil, fakePauseStorage{}, fakeHealthRecorder{}, fakeDriftMonitor{}, false, logzap.NewRequestFields()))
+			router.Use(getKeyScheduleMiddleware())
+			router.Use(getOutputCapsMiddleware(zap.NewNop(), false))
+			router.Use(getRequestMiddleware(nil, nil, fakeDriftMonitor{}, zap.NewNop(), false, false))
+			router.Use(getRecordingMiddleware(false, 0, false))
+
+			received := ""
+			router.POST(chatPath, func(c *gin.Context) {
+				data, _ := io.ReadAll(c.Request.Body)
+				received = string(data)
+				c.String(http.StatusOK, "ok")
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, chatPath, strings.NewReader(tc.body)))
+
+			assert.Equal(t, tc.expected, w.Code)
+			if len(tc.received) != 0 {
+				assert.JSONEq(t, tc.received, received)
+			}
+
+			require.Len(t, pub.messages, 1)
+			evt, ok := pub.messages[0].Data.(*event.EventWithRequestAndContent)
+			require.True(t, ok)
+
+			assert.Equal(t, "k1", evt.Event.KeyId)
+			assert.Equal(t, tc.expected, evt.Event.Status)
+			assert.Equal(t, tc.metadata, evt.Event.Metadata)
+
+			if tc.expected == http.StatusOK {
+				assert.Equal(t, "gpt-4o", evt.Event.Model)
+				assert.NotNil(t, evt.Request)
+			}
+
+			if tc.recorded {
+				require.NotNil(t, evt.RecordedRequest)
+				assert.Equal(t, "ok", string(evt.RecordedRequest.Response))
+			} else {
+				assert.Nil(t, evt.RecordedRequest)
+			}
+		})
+	}
+}
+
+func TestSetEventMetadata(t *testing.T) {
+	evt := &event.Event{}
+	setEventMetadata(evt, "a", "1")
+	assert.Equal(t, map[string]string{"a": "1"}, evt.Metadata)
+
+	setEventMetadata(evt, "b", "2")
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, evt.Metadata)
+}
@@ -0,0 +1,146 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/mock"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+)
+
+type mockResponseMemStorage interface {
+	GetMockResponse(model string) *mock.Response
+}
+
+func isMockedPath(fullPath string) bool {
+	return isChatCompletionPath(fullPath) || isEmbeddingsPath(fullPath)
+}
+
+func isEmbeddingsPath(fullPath string) bool {
+	return fullPath == "/api/providers/openai/v1/embeddings" || fullPath == "/api/providers/azure/openai/deployments/:deployment_id/embeddings"
+}
+
+// wait simulates upstream latency and returns false if the client went away.
+func wait(c *gin.Context, ms int) bool {
+	if ms <= 0 {
+		return true
+	}
+
+	select {
+	case <-c.Request.Context().Done():
+		return false
+	case <-time.After(time.Duration(ms) * time.Millisecond):
+		return true
+	}
+}
+
+func serveMockResponse(c *gin.Context, mms mockResponseMemStorage, body []byte, log *zap.Logger, prod bool, cid string) {
+	c.Set("provider", mock.ProviderName)
+
+	if !isMockedPath(c.FullPath()) {
+		stats.Incr("bricksllm.proxy.serve_mock_response.unsupported_path", nil, 1)
+		JSON(c, http.StatusBadRequest, "[BricksLLM] mock provider only supports chat completions and embeddings")
+		return
+	}
+
+	model := c.GetString("model")
+	if len(model) == 0 {
+		model = gjson.GetBytes(body, "model").Str
+	}
+
+	if len(model) == 0 {
+		model = c.Param("deployment_id")
+	}
+
+	c.Set("model", model)
+
+	mr := mms.GetMockResponse(model)
+	if mr == nil {
+		mr = &mock.Response{
+			Model:   model,
+			Content: mock.DefaultContent,
+		}
+	}
+
+	if !wait(c, mr.LatencyInMs) {
+		return
+	}
+
+	if mr.ShouldFail() {
+		stats.Incr("bricksllm.proxy.serve_mock_response.simulated_error", nil, 1)
+		c.JSON(mr.GetErrorStatusCode(), mock.NewErrorResponse("[BricksLLM] simulated error from mock provider"))
+		return
+	}
+
+	if isEmbeddingsPath(c.FullPath()) {
+		res := mock.NewEmbeddingResponse(model, mock.ParseEmbeddingInputs(body))
+		c.Set("promptTokenCount", res.Usage.PromptTokens)
+		c.JSON(http.StatusOK, res)
+		return
+	}
+
+	content := mr.Content
+	if len(mr.Body) != 0 {
+		content = gjson.GetBytes(mr.Body, "choices.0.message.content").Str
+	}
+
+	if !c.GetBool("stream") {
+		if len(mr.Body) != 0 {
+			c.Set("completionTokenCount", int(gjson.GetBytes(mr.Body, "usage.completion_tokens").Int()))
+			c.Data(http.StatusOK, "application/json", mr.Body)
+			return
+		}
+
+		res := mock.NewChatCompletionResponse(model, content)
+		c.Set("completionTokenCount", res.Usage.CompletionTokens)
+		c.JSON(http.StatusOK, res)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+
+	chunks := mock.NewChatCompletionChunks(model, content)
+	for index, chunk := range chunks {
+		if index != 0 && !wait(c, mr.ChunkDelayInMs) {
+			return
+		}
+
+		data, err := json.Marshal(chunk)
+		if err != nil {
+			logError(log, "error when marshalling mock chat completion chunk", prod, cid, err)
+			return
+		}
+
+		c.SSEvent("", " "+string(data))
+		c.Writer.Flush()
+	}
+
+	c.SSEvent("", " [DONE]")
+	c.Writer.Flush()
+
+	c.Set("completionTokenCount", len(chunks)-2)
+}
+
+// getMockMiddleware answers requests of mock provider settings.
+func getMockMiddleware(mms mockResponseMemStorage, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := proxyKey(c); !ok {
+			return
+		}
+
+		settings := proxySettings(c)
+		if len(settings) == 0 || settings[0].Provider != mock.ProviderName {
+			return
+		}
+
+		serveMockResponse(c, mms, requestBody(c), log, prod, c.GetString(correlationId))
+		c.Abort()
+	}
+}
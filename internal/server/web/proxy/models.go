@@ -3,6 +3,7 @@ package proxy
 import (
 	"encoding/json"
 
+	"github.com/bricks-cloud/bricksllm/internal/logger"
 	goopenai "github.com/sashabaranov/go-openai"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -18,7 +19,7 @@ func logListModelsResponse(log *zap.Logger, data []byte, prod bool, cid string)
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.Any("models", models.Models),
 		}
 
@@ -29,7 +30,7 @@ func logListModelsResponse(log *zap.Logger, data []byte, prod bool, cid string)
 func logRetrieveModelRequest(log *zap.Logger, data []byte, prod bool, cid, model string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("model", model),
 		}
 
@@ -47,7 +48,7 @@ func logRetrieveModelResponse(log *zap.Logger, data []byte, prod bool, cid strin
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("id", model.ID),
 			zap.Int64("created", model.CreatedAt),
 			zap.String("object", model.Object),
@@ -61,7 +62,7 @@ func logRetrieveModelResponse(log *zap.Logger, data []byte, prod bool, cid strin
 func logDeleteModelRequest(log *zap.Logger, data []byte, prod bool, cid, model string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("model", model),
 		}
 
@@ -85,7 +86,7 @@ func logDeleteModelResponse(log *zap.Logger, data []byte, prod bool, cid string)
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("id", resp.Id),
 			zap.String("object", resp.Object),
 			zap.Bool("deleted", resp.Deleted),
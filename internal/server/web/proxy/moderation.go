@@ -3,6 +3,7 @@ package proxy
 import (
 	"encoding/json"
 
+	"github.com/bricks-cloud/bricksllm/internal/logger"
 	goopenai "github.com/sashabaranov/go-openai"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -23,7 +24,7 @@ func logCreateModerationRequest(log *zap.Logger, data []byte, prod, private bool
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 		}
 
 		if !private {
@@ -52,7 +53,7 @@ func logCreateModerationResponse(log *zap.Logger, data []byte, prod bool, cid st
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("id", mr.ID),
 			zap.String("model", mr.Model),
 			zap.Any("results", mr.Results),
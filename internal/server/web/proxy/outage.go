@@ -0,0 +1,65 @@
+package proxy
+
+import (
+	"net/http"
+
+	"github.com/bricks-cloud/bricksllm/internal/outage"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// writeRedisUnavailable stops a request that depends on a subsystem whose
+// Redis is unavailable and fails closed.
+func writeRedisUnavailable(c *gin.Context, subsystem string) {
+	stats.Incr("bricksllm.proxy.get_middleware.redis_unavailable", []string{"subsystem:" + subsystem}, 1)
+
+	JSONError(c, http.StatusServiceUnavailable, codeRedisUnavailable, "[BricksLLM] "+subsystem+" is temporarily unavailable", map[string]interface{}{
+		"subsystem": subsystem,
+	})
+	c.Abort()
+}
+
+// getAccessMiddleware rejects requests of rate limited keys and requests
+// that depend on a subsystem whose Redis is unavailable.
+func getAccessMiddleware(ac accessCache, om outageMonitor, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		kc, ok := proxyKey(c)
+		if !ok {
+			return
+		}
+
+		limited, err := ac.GetAccessStatus(kc.KeyId)
+		if err != nil {
+			stats.Incr("bricksllm.proxy.get_middleware.get_access_status_error", nil, 1)
+			logError(log, "error when getting key access status", prod, c.GetString(correlationId), err)
+
+			if !om.AllowFailure(outage.SubsystemAccess) {
+				writeRedisUnavailable(c, outage.SubsystemAccess)
+				return
+			}
+		}
+
+		if limited {
+			stats.Incr("bricksllm.proxy.get_middleware.rate_limited", nil, 1)
+			JSON(c, http.StatusTooManyRequests, "[BricksLLM] too many requests")
+			c.Abort()
+			return
+		}
+
+		if kc.RateLimitOverTime != 0 && !om.Allow(outage.SubsystemRateLimit) {
+			writeRedisUnavailable(c, outage.SubsystemRateLimit)
+			return
+		}
+
+		if (kc.CostLimitInUsd != 0 || kc.GetCostLimitInUsdOverTime() != 0) && !om.Allow(outage.SubsystemCostLimit) {
+			writeRedisUnavailable(c, outage.SubsystemCostLimit)
+			return
+		}
+
+		if len(c.GetString("cache_key")) != 0 && !om.Allow(outage.SubsystemCache) {
+			writeRedisUnavailable(c, outage.SubsystemCache)
+			return
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package proxy
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/outage"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type fakeAccessCache struct {
+	limited bool
+	err     error
+}
+
+func (ca fakeAccessCache) GetAccessStatus(key string) (bool, error) {
+	return ca.limited, ca.err
+}
+
+// fakeOutageMonitor stops requests that depend on its unavailable
+// subsystems.
+type fakeOutageMonitor map[string]bool
+
+func (m fakeOutageMonitor) Allow(subsystem string) bool {
+	return !m[subsystem]
+}
+
+func (m fakeOutageMonitor) AllowFailure(subsystem string) bool {
+	return !m[subsystem]
+}
+
+func TestGetAccessMiddleware(t *testing.T) {
+	cases := []struct {
+		name        string
+		kc          *key.ResponseKey
+		ac          fakeAccessCache
+		unavailable fakeOutageMonitor
+		cacheKey    string
+		expected    int
+		subsystem   string
+	}{
+		{name: "requests of keys that are not limited", kc: &key.ResponseKey{KeyId: "k1", RateLimitOverTime: 10, CostLimitInUsd: 1}, expected: http.StatusOK},
+		{name: "requests of rate limited keys", kc: &key.ResponseKey{KeyId: "k1"}, ac: fakeAccessCache{limited: true}, expected: http.StatusTooManyRequests},
+		{name: "access statuses that cannot be read fail closed", kc: &key.ResponseKey{KeyId: "k1"}, ac: fakeAccessCache{err: errors.New("redis: connection refused")}, unavailable: fakeOutageMonitor{outage.SubsystemAccess: true}, expected: http.StatusServiceUnavailable, subsystem: outage.SubsystemAccess},
+		{name: "access statuses that cannot be read fail open", kc: &key.ResponseKey{KeyId: "k1"}, ac: fakeAccessCache{err: errors.New("redis: connection refused")}, expected: http.StatusOK},
+		{name: "rate limited keys while rate limits are unavailable", kc: &key.ResponseKey{KeyId: "k1", RateLimitOverTime: 10}, unavailable: fakeOutageMonitor{outage.SubsystemRateLimit: true}, expected: http.StatusServiceUnavailable, subsystem: outage.SubsystemRateLimit},
+		{name: "keys without rate limits while rate limits are unavailable", kc: &key.ResponseKey{KeyId: "k1"}, unavailable: fakeOutageMonitor{outage.SubsystemRateLimit: true}, expected: http.StatusOK},
+		{name: "cost limited keys while cost limits are unavailable", kc: &key.ResponseKey{KeyId: "k1", CostLimitInUsd: 1}, unavailable: fakeOutageMonitor{outage.SubsystemCostLimit: true}, expected: http.StatusServiceUnavailable, subsystem: outage.SubsystemCostLimit},
+		{name: "cached requests while the cache is unavailable", kc: &key.ResponseKey{KeyId: "k1"}, cacheKey: "cache-key", unavailable: fakeOutageMonitor{outage.SubsystemCache: true}, expected: http.StatusServiceUnavailable, subsystem: outage.SubsystemCache},
+		{name: "requests that are not cached while the cache is unavailable", kc: &key.ResponseKey{KeyId: "k1"}, unavailable: fakeOutageMonitor{outage.SubsystemCache: true}, expected: http.StatusOK},
+		{name: "requests without keys", expected: http.StatusOK},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tc.kc != nil {
+					c.Set("key", tc.kc)
+				}
+
+				if len(tc.cacheKey) != 0 {
+					c.Set("cache_key", tc.cacheKey)
+				}
+			}, getAccessMiddleware(tc.ac, tc.unavailable, zap.NewNop(), false))
+			router.POST("/api/providers/openai/v1/chat/completions", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/providers/openai/v1/chat/completions", nil))
+
+			assert.Equal(t, tc.expected, w.Code)
+			if len(tc.subsystem) != 0 {
+				assert.Contains(t, w.Body.String(), codeRedisUnavailable)
+				assert.Contains(t, w.Body.String(), `"subsystem":"`+tc.subsystem+`"`)
+			}
+		})
+	}
+}
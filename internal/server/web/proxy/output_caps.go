@@ -0,0 +1,95 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	goopenai "github.com/sashabaranov/go-openai"
+	"go.uber.org/zap"
+)
+
+const truncatedMetadataKey = "bricksllm_truncated"
+
+// capMaxTokens lowers max_tokens and max_completion_tokens to the cap. A cap
+// is set when the request does not ask for a limit.
+func capMaxTokens(body []byte, limit int) ([]byte, error) {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+
+	capped := false
+	for _, name := range []string{"max_tokens", "max_completion_tokens"} {
+		raw, ok := fields[name]
+		if !ok {
+			continue
+		}
+
+		requested, err := strconv.ParseFloat(string(raw), 64)
+		if err == nil && requested > 0 && requested <= float64(limit) {
+			capped = true
+			continue
+		}
+
+		fields[name] = json.RawMessage(strconv.Itoa(limit))
+		capped = true
+	}
+
+	if !capped {
+		fields["max_tokens"] = json.RawMessage(strconv.Itoa(limit))
+	}
+
+	return json.Marshal(fields)
+}
+
+// truncateStream ends a chat completion stream the same way providers do
+// when max_tokens is reached.
+func truncateStream(c *gin.Context, last *goopenai.ChatCompletionStreamResponse) {
+	chunk := map[string]any{
+		"id":      last.ID,
+		"object":  last.Object,
+		"created": last.Created,
+		"model":   last.Model,
+		"choices": []map[string]any{
+			{
+				"index":         0,
+				"delta":         map[string]any{},
+				"finish_reason": goopenai.FinishReasonLength,
+			},
+		},
+	}
+
+	if data, err := json.Marshal(chunk); err == nil {
+		c.SSEvent("", " "+string(data))
+	}
+
+	c.SSEvent("", " [DONE]")
+	c.Set("outputTruncated", true)
+}
+
+// getOutputCapsMiddleware caps the tokens chat completions of a key can ask
+// for and sets the number of tokens its streams are truncated at.
+func getOutputCapsMiddleware(log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		kc, ok := proxyKey(c)
+		if !ok || kc.OutputCaps == nil || !isChatCompletionPath(c.FullPath()) {
+			return
+		}
+
+		if kc.OutputCaps.MaxTokens != 0 {
+			capped, err := capMaxTokens(requestBody(c), kc.OutputCaps.MaxTokens)
+			if err != nil {
+				logError(log, "error when capping max tokens", prod, c.GetString(correlationId), err)
+				JSON(c, http.StatusBadRequest, "[BricksLLM] request body must be a json object")
+				c.Abort()
+				return
+			}
+
+			setRequestBody(c, capped)
+		}
+
+		c.Set("maxStreamedTokens", kc.OutputCaps.MaxStreamedTokens)
+	}
+}
@@ -0,0 +1,108 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/gin-gonic/gin"
+	goopenai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCapMaxTokens(t *testing.T) {
+	cases := []struct {
+		name     string
+		body     string
+		expected string
+		err      bool
+	}{
+		{name: "requests without a limit are capped", body: `{"model":"gpt-4o"}`, expected: `{"max_tokens":100,"model":"gpt-4o"}`},
+		{name: "requests asking for more are lowered", body: `{"max_tokens":500}`, expected: `{"max_tokens":100}`},
+		{name: "requests asking for less are kept", body: `{"max_tokens":50}`, expected: `{"max_tokens":50}`},
+		{name: "max completion tokens are lowered", body: `{"max_completion_tokens":500}`, expected: `{"max_completion_tokens":100}`},
+		{name: "both limits are capped", body: `{"max_tokens":50,"max_completion_tokens":500}`, expected: `{"max_completion_tokens":100,"max_tokens":50}`},
+		{name: "limits that are not positive numbers are replaced", body: `{"max_tokens":"all"}`, expected: `{"max_tokens":100}`},
+		{name: "bodies that are not json objects", body: `[1]`, err: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			capped, err := capMaxTokens([]byte(tc.body), 100)
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.expected, string(capped))
+		})
+	}
+}
+
+func TestTruncateStream(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	truncateStream(c, &goopenai.ChatCompletionStreamResponse{ID: "chatcmpl-1", Object: "chat.completion.chunk", Created: 1700000000, Model: "gpt-4o"})
+
+	events := strings.Split(strings.TrimSpace(w.Body.String()), "\n\n")
+	require.Len(t, events, 2)
+
+	assert.JSONEq(t, `{"id":"chatcmpl-1","object":"chat.completion.chunk","created":1700000000,"model":"gpt-4o","choices":[{"index":0,"delta":{},"finish_reason":"length"}]}`, strings.TrimSpace(strings.TrimPrefix(events[0], "data:")))
+	assert.Equal(t, "data: [DONE]", events[1])
+	assert.True(t, c.GetBool("outputTruncated"))
+}
+
+func TestGetOutputCapsMiddleware(t *testing.T) {
+	chatPath := "/api/providers/openai/v1/chat/completions"
+
+	cases := []struct {
+		name        string
+		path        string
+		caps        *key.OutputCaps
+		body        string
+		expected    int
+		received    string
+		maxStreamed int
+	}{
+		{name: "max tokens are capped", path: chatPath, caps: &key.OutputCaps{MaxTokens: 10, MaxStreamedTokens: 5}, body: `{"model":"gpt-4o","max_tokens":100}`, expected: http.StatusOK, received: `{"model":"gpt-4o","max_tokens":10}`, maxStreamed: 5},
+		{name: "streams are capped without max tokens", path: chatPath, caps: &key.OutputCaps{MaxStreamedTokens: 5}, body: `{"model":"gpt-4o","max_tokens":100}`, expected: http.StatusOK, received: `{"model":"gpt-4o","max_tokens":100}`, maxStreamed: 5},
+		{name: "keys without caps", path: chatPath, body: `{"model":"gpt-4o","max_tokens":100}`, expected: http.StatusOK, received: `{"model":"gpt-4o","max_tokens":100}`},
+		{name: "requests other than chat completions", path: "/api/providers/openai/v1/embeddings", caps: &key.OutputCaps{MaxTokens: 10}, body: `{"model":"text-embedding-3-small"}`, expected: http.StatusOK, received: `{"model":"text-embedding-3-small"}`},
+		{name: "bodies that are not json objects", path: chatPath, caps: &key.OutputCaps{MaxTokens: 10}, body: `[]`, expected: http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			received, maxStreamed := "", 0
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("key", &key.ResponseKey{KeyId: "k1", OutputCaps: tc.caps})
+				setRequestBody(c, []byte(tc.body))
+			}, getOutputCapsMiddleware(zap.NewNop(), false))
+
+			handler := func(c *gin.Context) {
+				data, _ := io.ReadAll(c.Request.Body)
+				received, maxStreamed = string(data), c.GetInt("maxStreamedTokens")
+			}
+			router.POST("/api/providers/openai/v1/chat/completions", handler)
+			router.POST("/api/providers/openai/v1/embeddings", handler)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body)))
+
+			assert.Equal(t, tc.expected, w.Code)
+			if len(tc.received) != 0 {
+				assert.JSONEq(t, tc.received, received)
+			}
+			assert.Equal(t, tc.maxStreamed, maxStreamed)
+		})
+	}
+}
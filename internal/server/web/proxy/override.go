@@ -0,0 +1,12 @@
+package proxy
+
+// headers callers use to direct a request to one of the provider settings
+// of their key.
+const (
+	providerOverrideHeader = "X-BricksLLM-Provider"
+	settingOverrideHeader  = "X-BricksLLM-Setting-Id"
+)
+
+// settingOverrideMetadataKey records the provider setting a caller directed
+// a request to.
+const settingOverrideMetadataKey = "bricksllm_setting_id"
@@ -0,0 +1,242 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/passthrough"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+)
+
+type passThroughMemStorage interface {
+	GetPassThrough(provider string) *passthrough.PassThrough
+}
+
+// buildPassThroughUrl returns the url of the provider api a pass-through
+// request is forwarded to.
+func buildPassThroughUrl(c *gin.Context, provider string) (string, error) {
+	base := ""
+	switch provider {
+	case "openai":
+		base = "https://api.openai.com"
+	case "anthropic":
+		base = "https://api.anthropic.com"
+	case "azure":
+		resourceName := c.GetString("resourceName")
+		if len(resourceName) == 0 {
+			return "", errors.New("resource name is not set in the azure provider setting")
+		}
+
+		base = fmt.Sprintf("https://%s.openai.azure.com/openai", resourceName)
+	default:
+		return "", fmt.Errorf("provider %s cannot be passed through", provider)
+	}
+
+	target := base + c.Param("wildcard")
+	if len(c.Request.URL.RawQuery) != 0 {
+		target += "?" + c.Request.URL.RawQuery
+	}
+
+	return target, nil
+}
+
+func isEventStream(header http.Header) bool {
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	return err == nil && mediaType == "text/event-stream"
+}
+
+// setPassThroughUsage charges a pass-through request with the usage found in
+// its response. The cost is estimated from the token counts when the response
+// does not report it.
+func setPassThroughUsage(c *gin.Context, provider string, u *passthrough.Usage, e estimator, ae anthropicEstimator, aoe azureEstimator) error {
+	if len(u.Model) != 0 {
+		c.Set("model", u.Model)
+	}
+
+	promptTks, completionTks := 0, 0
+	if u.PromptTokens != nil {
+		promptTks = *u.PromptTokens
+		c.Set("promptTokenCount", promptTks)
+	}
+
+	if u.CompletionTokens != nil {
+		completionTks = *u.CompletionTokens
+		c.Set("completionTokenCount", completionTks)
+	}
+
+	if u.CostInUsd != nil {
+		c.Set("costInUsd", *u.CostInUsd)
+		return nil
+	}
+
+	if len(u.Model) == 0 || promptTks+completionTks == 0 {
+		return nil
+	}
+
+	var cost float64
+	var err error
+	switch provider {
+	case "anthropic":
+		cost, err = ae.EstimateTotalCost(u.Model, promptTks, completionTks)
+	case "azure":
+		cost, err = aoe.EstimateTotalCost(u.Model, promptTks, completionTks)
+	default:
+		cost, err = e.EstimateTotalCost(u.Model, promptTks, completionTks)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	c.Set("costInUsd", cost)
+	return nil
+}
+
+func getProviderPassThroughHandler(provider string, pts passThroughMemStorage, e estimator, ae anthropicEstimator, aoe azureEstimator, prod bool, client http.Client, log *zap.Logger, timeOut time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tags := []string{
+			"provider:" + provider,
+		}
+
+		stats.Incr("bricksllm.proxy.get_provider_pass_through_handler.requests", tags, 1)
+
+		if c == nil || c.Request == nil {
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] context is empty")
+			return
+		}
+
+		pt := pts.GetPassThrough(provider)
+		if pt == nil {
+			stats.Incr("bricksllm.proxy.get_provider_pass_through_handler.pass_through_not_found", tags, 1)
+			JSONError(c, http.StatusNotFound, codePassThroughNotFound, "[BricksLLM] pass-through is not enabled for provider", map[string]interface{}{
+				"provider": provider,
+			})
+			return
+		}
+
+		cid := c.GetString(correlationId)
+		ctx, cancel := context.WithTimeout(context.Background(), timeOut)
+		defer cancel()
+
+		targetUrl, err := buildPassThroughUrl(c, provider)
+		if err != nil {
+			logError(log, "error when building pass-through url", prod, cid, err)
+			JSONError(c, http.StatusBadRequest, codeInvalidRequest, "[BricksLLM] "+err.Error(), nil)
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			logError(log, "error when reading pass-through request body", prod, cid, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read pass-through request body")
+			return
+		}
+
+		req, err := http.NewRequestWithContext(ctx, c.Request.Method, targetUrl, io.NopCloser(bytes.NewReader(body)))
+		if err != nil {
+			logError(log, "error when creating pass-through http request", prod, cid, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create pass-through http request")
+			return
+		}
+
+		copyHttpHeaders(c.Request, req)
+
+		start := time.Now()
+		res, err := egressClient(c, client).Do(req)
+		if err != nil {
+			stats.Incr("bricksllm.proxy.get_provider_pass_through_handler.http_client_error", tags, 1)
+
+			logError(log, "error when sending pass-through request", prod, cid, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to send pass-through request")
+			return
+		}
+		defer res.Body.Close()
+
+		copyResponseHeaders(c, res.Header)
+
+		// the model of the request is charged unless the response names one.
+		usage := &passthrough.Usage{}
+		if model := gjson.GetBytes(body, "model"); model.Type == gjson.String {
+			usage.Model = model.Str
+		}
+
+		rule := pt.MatchUsageRule(c.Request.Method, c.Param("wildcard"))
+
+		if res.StatusCode != http.StatusOK || !isEventStream(res.Header) {
+			data, err := io.ReadAll(res.Body)
+			if err != nil {
+				logError(log, "error when reading pass-through response body", prod, cid, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read pass-through response body")
+				return
+			}
+
+			stats.Timing("bricksllm.proxy.get_provider_pass_through_handler.latency", time.Now().Sub(start), tags, 1)
+
+			if res.StatusCode != http.StatusOK {
+				stats.Incr("bricksllm.proxy.get_provider_pass_through_handler.error_response", tags, 1)
+				logError(log, "error response from the pass-through provider", prod, cid, errors.New(string(data)))
+			} else if rule != nil && json.Valid(data) {
+				rule.Extract(data, usage)
+				if err := setPassThroughUsage(c, provider, usage, e, ae, aoe); err != nil {
+					stats.Incr("bricksllm.proxy.get_provider_pass_through_handler.estimate_total_cost_error", tags, 1)
+					logError(log, "error when estimating pass-through cost", prod, cid, err)
+				}
+			}
+
+			c.Data(res.StatusCode, res.Header.Get("Content-Type"), data)
+			return
+		}
+
+		stats.Incr("bricksllm.proxy.get_provider_pass_through_handler.streaming_requests", tags, 1)
+
+		buffer := bufio.NewReader(res.Body)
+		defer func() {
+			if rule == nil {
+				return
+			}
+
+			if err := setPassThroughUsage(c, provider, usage, e, ae, aoe); err != nil {
+				stats.Incr("bricksllm.proxy.get_provider_pass_through_handler.estimate_total_cost_error", tags, 1)
+				logError(log, "error when estimating pass-through streaming cost", prod, cid, err)
+			}
+		}()
+
+		c.Stream(func(w io.Writer) bool {
+			raw, err := buffer.ReadBytes('\n')
+			if len(raw) != 0 {
+				if _, werr := w.Write(raw); werr != nil {
+					return false
+				}
+			}
+
+			if err != nil {
+				if err != io.EOF {
+					stats.Incr("bricksllm.proxy.get_provider_pass_through_handler.read_bytes_error", tags, 1)
+					logError(log, "error when reading bytes from pass-through response", prod, cid, err)
+				}
+
+				return false
+			}
+
+			noSpaceLine := bytes.TrimSpace(raw)
+			if rule != nil && bytes.HasPrefix(noSpaceLine, headerData) {
+				rule.Extract(bytes.TrimSpace(bytes.TrimPrefix(noSpaceLine, headerData)), usage)
+			}
+
+			return true
+		})
+
+		stats.Timing("bricksllm.proxy.get_provider_pass_through_handler.streaming_latency", time.Now().Sub(start), tags, 1)
+	}
+}
@@ -0,0 +1,207 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/passthrough"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type fakePassThroughStorage map[string]*passthrough.PassThrough
+
+func (s fakePassThroughStorage) GetPassThrough(provider string) *passthrough.PassThrough {
+	return s[provider]
+}
+
+type fakeTotalCostEstimator struct {
+	estimator
+}
+
+func (fakeTotalCostEstimator) EstimateTotalCost(model string, promptTks, completionTks int) (float64, error) {
+	return float64(promptTks+completionTks) / 100, nil
+}
+
+// streamRecorder lets handlers stream responses to a recorder, since gin
+// streams only to writers that notify closed connections.
+type streamRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (streamRecorder) CloseNotify() <-chan bool {
+	return make(chan bool)
+}
+
+// fakeProvider answers every request sent through a client with the same
+// response and keeps the url of the last request.
+type fakeProvider struct {
+	url         string
+	contentType string
+	status      int
+	body        string
+}
+
+func (p *fakeProvider) RoundTrip(req *http.Request) (*http.Response, error) {
+	p.url = req.URL.String()
+
+	return &http.Response{
+		StatusCode: p.status,
+		Header:     http.Header{"Content-Type": {p.contentType}, "Set-Cookie": {"session=abc"}},
+		Body:       io.NopCloser(strings.NewReader(p.body)),
+		Request:    req,
+	}, nil
+}
+
+func TestGetProviderPassThroughHandler(t *testing.T) {
+	pts := fakePassThroughStorage{
+		"openai": {Provider: "openai", UsageRules: []*passthrough.UsageRule{
+			{Method: http.MethodPost, Path: "/v1/realtime/sessions", PromptTokensLocation: "usage.input_tokens", CompletionTokensLocation: "usage.output_tokens"},
+			{Path: "/v1/priced", CostLocation: "usage.cost"},
+		}},
+	}
+
+	cases := []struct {
+		name        string
+		provider    string
+		path        string
+		body        string
+		status      int
+		contentType string
+		response    string
+		expected    int
+		url         string
+		cost        float64
+	}{
+		{
+			name:        "usage is estimated from token counts",
+			provider:    "openai",
+			path:        "/v1/realtime/sessions?beta=true",
+			body:        `{"model":"gpt-4o-realtime"}`,
+			status:      http.StatusOK,
+			contentType: "application/json",
+			response:    `{"usage":{"input_tokens":100,"output_tokens":50}}`,
+			expected:    http.StatusOK,
+			url:         "https://api.openai.com/v1/realtime/sessions?beta=true",
+			cost:        1.5,
+		},
+		{
+			name:        "costs reported by responses are charged",
+			provider:    "openai",
+			path:        "/v1/priced",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			response:    `{"usage":{"cost":0.25}}`,
+			expected:    http.StatusOK,
+			url:         "https://api.openai.com/v1/priced",
+			cost:        0.25,
+		},
+		{
+			name:        "usage is read from the events of streams",
+			provider:    "openai",
+			path:        "/v1/realtime/sessions",
+			body:        `{"model":"gpt-4o-realtime"}`,
+			status:      http.StatusOK,
+			contentType: "text/event-stream",
+			response:    "data: {\"usage\":{\"input_tokens\":100,\"output_tokens\":1}}\n\ndata: {\"usage\":{\"input_tokens\":100,\"output_tokens\":100}}\n\ndata: [DONE]\n\n",
+			expected:    http.StatusOK,
+			url:         "https://api.openai.com/v1/realtime/sessions",
+			cost:        2,
+		},
+		{
+			name:        "error responses are not charged",
+			provider:    "openai",
+			path:        "/v1/realtime/sessions",
+			body:        `{"model":"gpt-4o-realtime"}`,
+			status:      http.StatusBadRequest,
+			contentType: "application/json",
+			response:    `{"usage":{"input_tokens":100,"output_tokens":50}}`,
+			expected:    http.StatusBadRequest,
+			url:         "https://api.openai.com/v1/realtime/sessions",
+		},
+		{
+			name:        "paths without usage rules are not charged",
+			provider:    "openai",
+			path:        "/v1/other",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			response:    `{"usage":{"cost":0.25}}`,
+			expected:    http.StatusOK,
+			url:         "https://api.openai.com/v1/other",
+		},
+		{
+			name:     "providers without a pass-through",
+			provider: "anthropic",
+			path:     "/v1/messages/batches",
+			expected: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &fakeProvider{status: tc.status, contentType: tc.contentType, body: tc.response}
+
+			cost, charged := 0.0, false
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Next()
+				cost, charged = c.GetFloat64("costInUsd"), c.Keys["costInUsd"] != nil
+			})
+			router.Any("/api/providers/"+tc.provider+"/passthrough/*wildcard", getProviderPassThroughHandler(tc.provider, pts, fakeTotalCostEstimator{}, nil, nil, false, http.Client{Transport: p}, zap.NewNop(), time.Second))
+
+			w := streamRecorder{httptest.NewRecorder()}
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/providers/"+tc.provider+"/passthrough"+tc.path, strings.NewReader(tc.body)))
+
+			assert.Equal(t, tc.expected, w.Code)
+			assert.Equal(t, tc.url, p.url)
+			assert.Equal(t, tc.cost != 0, charged)
+			assert.InDelta(t, tc.cost, cost, 1e-9)
+
+			if len(tc.response) != 0 {
+				assert.Equal(t, tc.response, w.Body.String())
+				assert.Empty(t, w.Header().Get("Set-Cookie"))
+			}
+		})
+	}
+}
+
+func TestBuildPassThroughUrl(t *testing.T) {
+	cases := []struct {
+		name         string
+		provider     string
+		resourceName string
+		expected     string
+		err          bool
+	}{
+		{name: "openai", provider: "openai", expected: "https://api.openai.com/v1/files?limit=1"},
+		{name: "anthropic", provider: "anthropic", expected: "https://api.anthropic.com/v1/files?limit=1"},
+		{name: "azure", provider: "azure", resourceName: "team", expected: "https://team.openai.azure.com/openai/v1/files?limit=1"},
+		{name: "azure without a resource name", provider: "azure", err: true},
+		{name: "other providers", provider: "vllm", err: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/providers/passthrough/v1/files?limit=1", nil)
+			c.Params = gin.Params{{Key: "wildcard", Value: "/v1/files"}}
+			if len(tc.resourceName) != 0 {
+				c.Set("resourceName", tc.resourceName)
+			}
+
+			target, err := buildPassThroughUrl(c, tc.provider)
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, target)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package proxy
+
+import (
+	"github.com/bricks-cloud/bricksllm/internal/pause"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+)
+
+type pauseMemStorage interface {
+	GetPause(scope, target string) *pause.Pause
+}
+
+func rejectPaused(c *gin.Context, p *pause.Pause) {
+	stats.Incr("bricksllm.proxy.get_middleware.paused", []string{"scope:" + p.Scope}, 1)
+
+	writePausedResponse(c, p)
+	c.Abort()
+}
+
+// writePausedResponse writes the static response of a scope in maintenance
+// mode or the error of a pause.
+func writePausedResponse(c *gin.Context, p *pause.Pause) {
+	mt := p.Maintenance
+	if mt != nil && len(mt.Body) != 0 {
+		c.Set(gatewayErrorKey, true)
+		c.Data(mt.GetStatusCode(), "application/json", mt.Body)
+		return
+	}
+
+	details := map[string]interface{}{
+		"scope": p.Scope,
+	}
+
+	if len(p.Target) != 0 {
+		details["target"] = p.Target
+	}
+
+	if mt != nil && len(mt.Message) != 0 {
+		JSONError(c, mt.GetStatusCode(), codeMaintenance, "[BricksLLM] "+mt.Message, details)
+		return
+	}
+
+	message := "[BricksLLM] traffic is paused"
+	if len(p.Reason) != 0 {
+		message += ": " + p.Reason
+	}
+
+	code := codeTrafficPaused
+	if mt != nil {
+		code = codeMaintenance
+	}
+
+	JSONError(c, mt.GetStatusCode(), code, message, details)
+}
+
+// withoutPausedSteps drops the steps calling paused providers so that a route
+// falls back to its remaining steps. The pause is returned if no step is left.
+func withoutPausedSteps(rc *route.Route, ps pauseMemStorage) (*route.Route, *pause.Pause) {
+	steps := make([]*route.Step, 0, len(rc.Steps))
+
+	var last *pause.Pause
+	for _, step := range rc.Steps {
+		if p := ps.GetPause(pause.ScopeProvider, step.Provider); p != nil {
+			last = p
+			continue
+		}
+
+		steps = append(steps, step)
+	}
+
+	if last == nil {
+		return rc, nil
+	}
+
+	if len(steps) == 0 {
+		return rc, last
+	}
+
+	copied := *rc
+	copied.Steps = steps
+	return &copied, nil
+}
@@ -0,0 +1,157 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	goopenai "github.com/sashabaranov/go-openai"
+	"github.com/tidwall/gjson"
+)
+
+type pipelineResult struct {
+	body      []byte
+	costInUsd float64
+
+	// stopped is set when a stage stopped the pipeline, in which case
+	// content is the response of the route and the steps are not run.
+	stopped  bool
+	content  string
+	provider string
+	model    string
+}
+
+// runPipeline runs the stages of the pipeline of a route chat completion
+// request in order. The cost of every stage that ran is returned even when a
+// later stage fails.
+func runPipeline(rc *route.Route, req *route.Request, body []byte, e estimator, aoe azureEstimator) (*pipelineResult, error) {
+	ccr := &goopenai.ChatCompletionRequest{}
+	if err := json.Unmarshal(body, ccr); err != nil {
+		return nil, err
+	}
+
+	result := &pipelineResult{
+		body: body,
+	}
+
+	variables := route.NewPipelineVariables(route.LastUserContent(ccr))
+	for _, stage := range rc.Pipeline.Stages {
+		messages, err := route.RenderMessages(stage.Messages, variables)
+		if err != nil {
+			return result, err
+		}
+
+		outcome, err := runPipelineStage(rc, req, stage, messages, e, aoe)
+		if err != nil {
+			return result, fmt.Errorf("pipeline stage %s: %w", stage.Name, err)
+		}
+
+		result.costInUsd += outcome.CostInUsd
+		variables[stage.Name] = outcome.Content
+
+		if content, ok := stage.Stops(outcome.Content); ok {
+			result.stopped = true
+			result.content = content
+			result.provider = outcome.Provider
+			result.model = outcome.Model
+
+			return result, nil
+		}
+	}
+
+	if len(rc.Pipeline.Messages) == 0 {
+		return result, nil
+	}
+
+	messages, err := route.RenderMessages(rc.Pipeline.Messages, variables)
+	if err != nil {
+		return result, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return result, err
+	}
+
+	data, err := json.Marshal(messages)
+	if err != nil {
+		return result, err
+	}
+
+	fields["messages"] = data
+	replaced, err := json.Marshal(fields)
+	if err != nil {
+		return result, err
+	}
+
+	result.body = replaced
+
+	return result, nil
+}
+
+// runPipelineStage sends the rendered messages of a stage to its step.
+func runPipelineStage(rc *route.Route, req *route.Request, stage *route.PipelineStage, messages []goopenai.ChatCompletionMessage, e estimator, aoe azureEstimator) (*route.ShadowOutcome, error) {
+	data, err := json.Marshal(&goopenai.ChatCompletionRequest{
+		Model:    stage.Step.Model,
+		Messages: messages,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	forwarded := req.Forwarded.Clone(context.Background())
+	forwarded.Body = io.NopCloser(bytes.NewReader(data))
+	forwarded.ContentLength = int64(len(data))
+
+	runRes, err := rc.PipelineStageRoute(stage).RunSteps(&route.Request{
+		Settings:  req.Settings,
+		Key:       req.Key,
+		Client:    req.Client,
+		Egress:    req.Egress,
+		Forwarded: forwarded,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	defer runRes.Cancel()
+	defer runRes.Response.Body.Close()
+
+	body, err := io.ReadAll(runRes.Response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if runRes.Response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("model responded with status %d: %s", runRes.Response.StatusCode, gjson.GetBytes(body, "error.message").Str)
+	}
+
+	return newShadowOutcome(e, aoe, false, runRes.Provider, runRes.Model, runRes.Response.StatusCode, 0, body), nil
+}
+
+// newPipelineStopResponse returns the chat completion response of a route
+// whose pipeline was stopped by a stage.
+func newPipelineStopResponse(pr *pipelineResult) *goopenai.ChatCompletionResponse {
+	return &goopenai.ChatCompletionResponse{
+		ID:      "chatcmpl-" + util.NewUuid(),
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   pr.model,
+		Choices: []goopenai.ChatCompletionChoice{
+			{
+				Index: 0,
+				Message: goopenai.ChatCompletionMessage{
+					Role:    goopenai.ChatMessageRoleAssistant,
+					Content: pr.content,
+				},
+				FinishReason: goopenai.FinishReasonStop,
+			},
+		},
+	}
+}
@@ -0,0 +1,161 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+	goopenai "github.com/sashabaranov/go-openai"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+)
+
+type policyMemStorage interface {
+	GetPolicies() []*policy.Compiled
+}
+
+// policyDenial is returned by applyPolicies when a deny policy matches.
+type policyDenial struct {
+	p *policy.Compiled
+}
+
+func (d *policyDenial) Error() string {
+	if len(d.p.Message) != 0 {
+		return d.p.Message
+	}
+
+	return "request is denied by policy " + d.p.Name
+}
+
+func newPolicyInput(c *gin.Context, kc *key.ResponseKey, body []byte, e estimator) *policy.Input {
+	model := gjson.GetBytes(body, "model").String()
+
+	return &policy.Input{
+		Model:    model,
+		Provider: getProvider(c),
+		Path:     c.FullPath(),
+		Method:   c.Request.Method,
+		KeyId:    kc.KeyId,
+		KeyName:  kc.Name,
+		Tags:     kc.Tags,
+		Headers:  c.Request.Header,
+		Time:     time.Now(),
+		CountTokens: func() int {
+			if isChatCompletionPath(c.FullPath()) {
+				ccr := &goopenai.ChatCompletionRequest{}
+				if err := json.Unmarshal(body, ccr); err == nil {
+					if tks, err := e.EstimateChatCompletionPromptTokenCounts(model, ccr); err == nil {
+						return tks
+					}
+				}
+			}
+
+			// roughly four characters per token for requests that cannot
+			// be counted.
+			return len(body) / 4
+		},
+	}
+}
+
+// applyPolicies evaluates the enabled policies in the order of their
+// priority and applies the actions of the ones that match. An allow policy
+// stops the evaluation, a deny policy rejects the request.
+func applyPolicies(c *gin.Context, pms policyMemStorage, kc *key.ResponseKey, body []byte, e estimator) ([]byte, error) {
+	policies := pms.GetPolicies()
+	if len(policies) == 0 {
+		return body, nil
+	}
+
+	in := newPolicyInput(c, kc, body, e)
+	for _, p := range policies {
+		if !p.Program.Eval(in) {
+			continue
+		}
+
+		stats.Incr("bricksllm.proxy.apply_policies.matched", []string{
+			"action:" + string(p.Action),
+		}, 1)
+
+		switch p.Action {
+		case policy.ActionAllow:
+			return body, nil
+		case policy.ActionDeny:
+			return nil, &policyDenial{p: p}
+		case policy.ActionSetModel:
+			updated, err := setModel(body, p.Model)
+			if err != nil {
+				return nil, err
+			}
+
+			body = updated
+			in.Model = p.Model
+		case policy.ActionCapMaxTokens:
+			if !isChatCompletionPath(c.FullPath()) {
+				continue
+			}
+
+			capped, err := capMaxTokens(body, p.MaxTokens)
+			if err != nil {
+				return nil, err
+			}
+
+			body = capped
+		}
+	}
+
+	return body, nil
+}
+
+func setModel(body []byte, model string) ([]byte, error) {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(model)
+	if err != nil {
+		return nil, err
+	}
+
+	fields["model"] = data
+	return json.Marshal(fields)
+}
+
+func rejectPolicy(c *gin.Context, d *policyDenial) {
+	stats.Incr("bricksllm.proxy.get_middleware.policy_denied", nil, 1)
+
+	JSONError(c, http.StatusForbidden, codePolicyDenied, "[BricksLLM] "+d.Error(), map[string]interface{}{
+		"policyId": d.p.Id,
+	})
+	c.Abort()
+}
+
+// getPolicyMiddleware applies the policies matching a request, which can
+// reject it or change its body.
+func getPolicyMiddleware(pms policyMemStorage, e estimator, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		kc, ok := proxyKey(c)
+		if !ok || c.Request.Method == http.MethodGet {
+			return
+		}
+
+		applied, err := applyPolicies(c, pms, kc, requestBody(c), e)
+		if err != nil {
+			if d, ok := err.(*policyDenial); ok {
+				rejectPolicy(c, d)
+				return
+			}
+
+			logError(log, "error when applying policies", prod, c.GetString(correlationId), err)
+			JSON(c, http.StatusBadRequest, "[BricksLLM] request body must be a json object")
+			c.Abort()
+			return
+		}
+
+		setRequestBody(c, applied)
+	}
+}
@@ -0,0 +1,222 @@
+package proxy
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/gin-gonic/gin"
+	goopenai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePolicyStorage []*policy.Compiled
+
+func (s fakePolicyStorage) GetPolicies() []*policy.Compiled {
+	return s
+}
+
+type fakePromptEstimator struct {
+	estimator
+	tokens int
+}
+
+func (e *fakePromptEstimator) EstimateChatCompletionPromptTokenCounts(model string, r *goopenai.ChatCompletionRequest) (int, error) {
+	return e.tokens, nil
+}
+
+func compilePolicies(t *testing.T, policies ...*policy.Policy) fakePolicyStorage {
+	compiled := fakePolicyStorage{}
+	for _, p := range policies {
+		c, err := policy.NewCompiled(p)
+		require.NoError(t, err)
+
+		compiled = append(compiled, c)
+	}
+
+	return compiled
+}
+
+// runPolicies applies the policies to a request of the path and returns the
+// resulting body.
+func runPolicies(t *testing.T, pms policyMemStorage, path string, body string) (string, error) {
+	var (
+		updated []byte
+		err     error
+	)
+
+	router := gin.New()
+	handler := func(c *gin.Context) {
+		data, _ := io.ReadAll(c.Request.Body)
+		updated, err = applyPolicies(c, pms, &key.ResponseKey{KeyId: "key-1", Name: "production", Tags: []string{"team-a"}}, data, &fakePromptEstimator{tokens: 500})
+	}
+	router.POST("/api/providers/openai/v1/chat/completions", handler)
+	router.POST("/api/providers/openai/v1/embeddings", handler)
+
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader([]byte(body)))
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	return string(updated), err
+}
+
+func TestApplyPolicies(t *testing.T) {
+	const (
+		chatPath       = "/api/providers/openai/v1/chat/completions"
+		embeddingsPath = "/api/providers/openai/v1/embeddings"
+		chatBody       = `{"model":"gpt-4o","max_tokens":4000,"messages":[{"role":"user","content":"hi"}]}`
+	)
+
+	cases := []struct {
+		name     string
+		policies []*policy.Policy
+		path     string
+		body     string
+		expected string
+		denied   string
+	}{
+		{
+			name:     "requests pass without policies",
+			path:     chatPath,
+			body:     chatBody,
+			expected: chatBody,
+		},
+		{
+			name: "requests pass when no policy matches",
+			policies: []*policy.Policy{
+				{Id: "p1", Name: "no gpt-4", Expression: `model == "gpt-4"`, Action: policy.ActionDeny},
+			},
+			path:     chatPath,
+			body:     chatBody,
+			expected: chatBody,
+		},
+		{
+			name: "deny rejects matching requests",
+			policies: []*policy.Policy{
+				{Id: "p1", Name: "no gpt-4o", Expression: `model == "gpt-4o"`, Action: policy.ActionDeny},
+			},
+			path:   chatPath,
+			body:   chatBody,
+			denied: "request is denied by policy no gpt-4o",
+		},
+		{
+			name: "deny uses the message of the policy",
+			policies: []*policy.Policy{
+				{Id: "p1", Name: "budget", Expression: `tokens > 100`, Action: policy.ActionDeny, Message: "prompt is too long"},
+			},
+			path:   chatPath,
+			body:   chatBody,
+			denied: "prompt is too long",
+		},
+		{
+			name: "allow stops evaluating later policies",
+			policies: []*policy.Policy{
+				{Id: "p1", Name: "team a", Expression: `hasTag("team-a")`, Action: policy.ActionAllow},
+				{Id: "p2", Name: "no gpt-4o", Expression: `model == "gpt-4o"`, Action: policy.ActionDeny},
+			},
+			path:     chatPath,
+			body:     chatBody,
+			expected: chatBody,
+		},
+		{
+			name: "earlier deny wins over later allow",
+			policies: []*policy.Policy{
+				{Id: "p1", Name: "no gpt-4o", Expression: `model == "gpt-4o"`, Action: policy.ActionDeny},
+				{Id: "p2", Name: "team a", Expression: `hasTag("team-a")`, Action: policy.ActionAllow},
+			},
+			path:   chatPath,
+			body:   chatBody,
+			denied: "request is denied by policy no gpt-4o",
+		},
+		{
+			name: "setModel replaces the model",
+			policies: []*policy.Policy{
+				{Id: "p1", Name: "downgrade", Expression: `model == "gpt-4o"`, Action: policy.ActionSetModel, Model: "gpt-4o-mini"},
+			},
+			path:     chatPath,
+			body:     chatBody,
+			expected: `{"max_tokens":4000,"messages":[{"role":"user","content":"hi"}],"model":"gpt-4o-mini"}`,
+		},
+		{
+			name: "later policies see the replaced model",
+			policies: []*policy.Policy{
+				{Id: "p1", Name: "downgrade", Expression: `model == "gpt-4o"`, Action: policy.ActionSetModel, Model: "gpt-4o-mini"},
+				{Id: "p2", Name: "no mini", Expression: `model == "gpt-4o-mini"`, Action: policy.ActionDeny},
+			},
+			path:   chatPath,
+			body:   chatBody,
+			denied: "request is denied by policy no mini",
+		},
+		{
+			name: "capMaxTokens lowers max_tokens of chat completions",
+			policies: []*policy.Policy{
+				{Id: "p1", Name: "cap", Expression: `true`, Action: policy.ActionCapMaxTokens, MaxTokens: 256},
+			},
+			path:     chatPath,
+			body:     chatBody,
+			expected: `{"max_tokens":256,"messages":[{"role":"user","content":"hi"}],"model":"gpt-4o"}`,
+		},
+		{
+			name: "capMaxTokens is skipped for other requests",
+			policies: []*policy.Policy{
+				{Id: "p1", Name: "cap", Expression: `true`, Action: policy.ActionCapMaxTokens, MaxTokens: 256},
+				{Id: "p2", Name: "no embeddings", Expression: `path.endsWith("/embeddings")`, Action: policy.ActionDeny},
+			},
+			path:   embeddingsPath,
+			body:   `{"model":"text-embedding-3-small","input":"hi"}`,
+			denied: "request is denied by policy no embeddings",
+		},
+		{
+			name: "modifications accumulate",
+			policies: []*policy.Policy{
+				{Id: "p1", Name: "downgrade", Expression: `provider == "openai"`, Action: policy.ActionSetModel, Model: "gpt-4o-mini"},
+				{Id: "p2", Name: "cap", Expression: `model == "gpt-4o-mini"`, Action: policy.ActionCapMaxTokens, MaxTokens: 256},
+				{Id: "p3", Name: "stop", Expression: `true`, Action: policy.ActionAllow},
+				{Id: "p4", Name: "unreachable", Expression: `true`, Action: policy.ActionDeny},
+			},
+			path:     chatPath,
+			body:     chatBody,
+			expected: `{"max_tokens":256,"messages":[{"role":"user","content":"hi"}],"model":"gpt-4o-mini"}`,
+		},
+		{
+			name: "tokens of other requests are estimated from their size",
+			policies: []*policy.Policy{
+				{Id: "p1", Name: "budget", Expression: `tokens > 100`, Action: policy.ActionDeny},
+			},
+			path:     embeddingsPath,
+			body:     `{"model":"text-embedding-3-small","input":"hi"}`,
+			expected: `{"model":"text-embedding-3-small","input":"hi"}`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			body, err := runPolicies(t, compilePolicies(t, tc.policies...), tc.path, tc.body)
+
+			if len(tc.denied) != 0 {
+				d := &policyDenial{}
+				require.True(t, errors.As(err, &d))
+				assert.Equal(t, tc.denied, d.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, body)
+		})
+	}
+
+	t.Run("modifying a body that is not json fails", func(t *testing.T) {
+		policies := compilePolicies(t, &policy.Policy{Id: "p1", Name: "downgrade", Expression: `true`, Action: policy.ActionSetModel, Model: "gpt-4o-mini"})
+
+		_, err := runPolicies(t, policies, chatPath, "not json")
+		require.Error(t, err)
+
+		d := &policyDenial{}
+		assert.False(t, errors.As(err, &d))
+	})
+}
@@ -0,0 +1,149 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	goopenai "github.com/sashabaranov/go-openai"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	originalPromptTokensMetadataKey   = "bricksllm_original_prompt_tokens"
+	compressedPromptTokensMetadataKey = "bricksllm_compressed_prompt_tokens"
+)
+
+// minModelCompressionTokens keeps short messages from being sent to the
+// compression model since rewriting them saves less than the call costs.
+const minModelCompressionTokens = 200
+
+type compressionResult struct {
+	body             []byte
+	originalTokens   int
+	compressedTokens int
+	costInUsd        float64
+}
+
+func countPromptTokens(e estimator, model string, ccr *goopenai.ChatCompletionRequest, body []byte) int {
+	if tks, err := e.EstimateChatCompletionPromptTokenCounts(model, ccr); err == nil {
+		return tks
+	}
+
+	return len(body) / 4
+}
+
+// compressPrompt compresses the messages of a route chat completion request.
+// It returns nil when the prompt is below the minimum tokens of the route.
+// Only the messages field of the body is replaced so that fields unknown to
+// the gateway are forwarded as they are.
+func compressPrompt(rc *route.Route, req *route.Request, body []byte, e estimator, aoe azureEstimator) (*compressionResult, error) {
+	ccr := &goopenai.ChatCompletionRequest{}
+	if err := json.Unmarshal(body, ccr); err != nil {
+		return nil, err
+	}
+
+	model := rc.Steps[0].Model
+	result := &compressionResult{
+		originalTokens: countPromptTokens(e, model, ccr, body),
+	}
+
+	if result.originalTokens < rc.Compression.MinTokens {
+		return nil, nil
+	}
+
+	rc.Compression.Compress(ccr)
+
+	if rc.Compression.Step != nil {
+		for i, m := range ccr.Messages {
+			if m.Role != goopenai.ChatMessageRoleSystem && m.Role != goopenai.ChatMessageRoleUser {
+				continue
+			}
+
+			if len(m.MultiContent) != 0 || len(m.Content)/4 < minModelCompressionTokens {
+				continue
+			}
+
+			compressed, cost, err := runCompressionStep(rc, req, m.Content, e, aoe)
+			if err != nil {
+				return nil, err
+			}
+
+			result.costInUsd += cost
+			if len(compressed) != 0 && len(compressed) < len(m.Content) {
+				ccr.Messages[i].Content = compressed
+			}
+		}
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+
+	messages, err := json.Marshal(ccr.Messages)
+	if err != nil {
+		return nil, err
+	}
+
+	fields["messages"] = messages
+	compressed, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	result.body = compressed
+	result.compressedTokens = countPromptTokens(e, model, ccr, compressed)
+
+	return result, nil
+}
+
+// runCompressionStep has the compression model of the route rewrite content
+// and returns the rewritten content with the cost of the call.
+func runCompressionStep(rc *route.Route, req *route.Request, content string, e estimator, aoe azureEstimator) (string, float64, error) {
+	data, err := json.Marshal(&goopenai.ChatCompletionRequest{
+		Model: rc.Compression.Step.Model,
+		Messages: []goopenai.ChatCompletionMessage{
+			{Role: goopenai.ChatMessageRoleSystem, Content: rc.Compression.Instruction()},
+			{Role: goopenai.ChatMessageRoleUser, Content: content},
+		},
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	forwarded := req.Forwarded.Clone(context.Background())
+	forwarded.Body = io.NopCloser(bytes.NewReader(data))
+	forwarded.ContentLength = int64(len(data))
+
+	runRes, err := rc.CompressionRoute().RunSteps(&route.Request{
+		Settings:  req.Settings,
+		Key:       req.Key,
+		Client:    req.Client,
+		Egress:    req.Egress,
+		Forwarded: forwarded,
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	defer runRes.Cancel()
+	defer runRes.Response.Body.Close()
+
+	body, err := io.ReadAll(runRes.Response.Body)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if runRes.Response.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("compression model responded with status %d: %s", runRes.Response.StatusCode, gjson.GetBytes(body, "error.message").Str)
+	}
+
+	outcome := newShadowOutcome(e, aoe, false, runRes.Provider, runRes.Model, runRes.Response.StatusCode, 0, body)
+
+	return outcome.Content, outcome.CostInUsd, nil
+}
@@ -0,0 +1,75 @@
+package proxy
+
+import (
+	"github.com/bricks-cloud/bricksllm/internal/mock"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/gin-gonic/gin"
+)
+
+type providerHealthRecorder interface {
+	RecordStatus(setting *provider.Setting, status int) error
+}
+
+type unavailableError interface {
+	Unavailable()
+}
+
+type residencyError interface {
+	Residency()
+}
+
+// recordProviderHealth records the upstream status under the setting of the
+// provider that served the request. Responses written by the gateway itself
+// never reached the upstream and are ignored.
+func recordProviderHealth(c *gin.Context, phr providerHealthRecorder, providerName string) error {
+	if c.GetBool(gatewayErrorKey) {
+		return nil
+	}
+
+	raw, exists := c.Get("settings")
+	if !exists {
+		return nil
+	}
+
+	settings, ok := raw.([]*provider.Setting)
+	if !ok {
+		return nil
+	}
+
+	for _, setting := range settings {
+		if setting == nil || setting.Provider == mock.ProviderName {
+			continue
+		}
+
+		if setting.Provider == providerName {
+			return phr.RecordStatus(setting, c.Writer.Status())
+		}
+	}
+
+	return nil
+}
+
+// withoutUnsettledSteps drops the steps of providers that have no setting
+// left for the request after unhealthy settings were skipped.
+func withoutUnsettledSteps(rc *route.Route, settings []*provider.Setting) *route.Route {
+	source := map[string]bool{}
+	for _, setting := range settings {
+		source[setting.Provider] = true
+	}
+
+	steps := make([]*route.Step, 0, len(rc.Steps))
+	for _, step := range rc.Steps {
+		if source[step.Provider] {
+			steps = append(steps, step)
+		}
+	}
+
+	if len(steps) == len(rc.Steps) {
+		return rc
+	}
+
+	copied := *rc
+	copied.Steps = steps
+	return &copied
+}
@@ -12,10 +12,16 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/bricks-cloud/bricksllm/internal/cors"
 	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/guardrail"
 	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/logger"
+	logzap "github.com/bricks-cloud/bricksllm/internal/logger/zap"
+	"github.com/bricks-cloud/bricksllm/internal/passthrough"
 	"github.com/bricks-cloud/bricksllm/internal/provider"
 	"github.com/bricks-cloud/bricksllm/internal/provider/custom"
+	"github.com/bricks-cloud/bricksllm/internal/server/web"
 	"github.com/bricks-cloud/bricksllm/internal/stats"
 	"github.com/gin-gonic/gin"
 	goopenai "github.com/sashabaranov/go-openai"
@@ -36,6 +42,7 @@ const (
 type ProxyServer struct {
 	server *http.Server
 	log    *zap.Logger
+	sr     *scheduleRunner
 }
 
 type recorder interface {
@@ -55,14 +62,48 @@ type CustomProvidersManager interface {
 	GetCustomProviderFromMem(name string) *custom.Provider
 }
 
-func NewProxyServer(log *zap.Logger, mode, privacyMode string, c cache, m KeyManager, rm routeManager, a authenticator, psm ProviderSettingsManager, cpm CustomProvidersManager, ks keyStorage, kms keyMemStorage, e estimator, ae anthropicEstimator, aoe azureEstimator, v validator, r recorder, pub publisher, rlm rateLimitManager, timeOut time.Duration, ac accessCache) (*ProxyServer, error) {
-	router := gin.New()
+func NewProxyServer(log *zap.Logger, mode, privacyMode string, c cache, m KeyManager, rm routeManager, a authenticator, psm ProviderSettingsManager, cpm CustomProvidersManager, ks keyStorage, kms keyMemStorage, e estimator, ae anthropicEstimator, aoe azureEstimator, v validator, r recorder, pub publisher, rlm rateLimitManager, timeOut time.Duration, ac accessCache, qs quotaStorage, ss sessionStorage, ptms promptTemplateMemStorage, mms mockResponseMemStorage, pms policyMemStorage, pthms passThroughMemStorage, ps pauseMemStorage, phr providerHealthRecorder, om outageMonitor, dm driftMonitor, ssm SelfServiceManager, fm FeedbackManager, jm JobManager, scm ScheduleManager, sd deliverer, sh shadowRecorder, jr judgeRecorder, enableCompression, recordRequests, tracingEnabled bool, samplingPercentage float64, asyncWorkers, asyncQueueSize, asyncMaxAttempts int, asyncWebhookHosts []string, schedulePollInterval time.Duration, cp *cors.Policy, rf *logzap.RequestFields, trustedProxies []string) (*ProxyServer, error) {
+	router, err := web.NewRouter(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
 	prod := mode == "production"
 	private := privacyMode == "strict"
 
-	router.Use(getMiddleware(kms, cpm, rm, a, prod, private, e, ae, aoe, v, ks, log, rlm, pub, "proxy", ac))
+	router.Use(getCorsMiddleware(cp, rm))
+
+	if enableCompression {
+		router.Use(getCompressionMiddleware())
+	}
 
 	client := http.Client{}
+	egc := provider.NewEgressClients(client)
+	gc := guardrail.NewClient(client)
+
+	ar := newAsyncRunner(jm, asyncQueueSize, asyncMaxAttempts, asyncWebhookHosts, log, prod)
+	ar.start(router, asyncWorkers)
+
+	sr := newScheduleRunner(scm, sd, schedulePollInterval, log, prod)
+	sr.start(router)
+
+	router.Use(getAsyncMiddleware(ar, a, log, prod, private))
+	router.Use(getMiddleware(a, prod, aoe, log, pub, "proxy", qs, ss, ps, phr, dm, tracingEnabled, rf))
+	router.Use(getKeyScheduleMiddleware())
+	router.Use(getEgressMiddleware(egc, log, prod))
+	router.Use(getSessionLimitsMiddleware(ss, log, prod))
+	router.Use(getKeyParametersMiddleware(log, prod))
+	router.Use(getSystemPromptMiddleware(log, prod))
+	router.Use(getOutputCapsMiddleware(log, prod))
+	router.Use(getStreamGuardrailsMiddleware())
+	router.Use(getLoopProtectionMiddleware(ss, log, prod))
+	router.Use(getPolicyMiddleware(pms, e, log, prod))
+	router.Use(getLanguageMiddleware())
+	router.Use(getRouteMiddleware(rm, v, ss, ptms, ps, newRetriever(), e, aoe, egc, log, prod, private))
+	router.Use(getRequestMiddleware(cpm, e, dm, log, prod, private))
+	router.Use(getAccessMiddleware(ac, om, log, prod))
+	router.Use(getRecordingMiddleware(recordRequests, samplingPercentage, private))
+	router.Use(getMockMiddleware(mms, log, prod))
 
 	// health check
 	router.POST("/api/health", getGetHealthCheckHandler())
@@ -73,11 +114,18 @@ func NewProxyServer(log *zap.Logger, mode, privacyMode string, c cache, m KeyMan
 	router.POST("/api/providers/openai/v1/audio/translations", getPassThroughHandler(r, prod, private, client, log, timeOut))
 
 	// completions
-	router.POST("/api/providers/openai/v1/chat/completions", getChatCompletionHandler(r, prod, private, psm, client, kms, log, e, timeOut))
+	router.POST("/api/providers/openai/v1/chat/completions", getChatCompletionHandler(r, prod, private, psm, client, kms, log, e, gc, timeOut))
 
 	// embeddings
 	router.POST("/api/providers/openai/v1/embeddings", getEmbeddingHandler(r, prod, private, psm, client, kms, log, e, timeOut))
 
+	// responses
+	router.POST("/api/providers/openai/v1/responses", getResponsesHandler(prod, client, ss, log, e, timeOut))
+	router.GET("/api/providers/openai/v1/responses/:response_id", getResponsesHandler(prod, client, ss, log, e, timeOut))
+	router.DELETE("/api/providers/openai/v1/responses/:response_id", getResponsesHandler(prod, client, ss, log, e, timeOut))
+	router.POST("/api/providers/openai/v1/responses/:response_id/cancel", getResponsesHandler(prod, client, ss, log, e, timeOut))
+	router.GET("/api/providers/openai/v1/responses/:response_id/input_items", getResponsesHandler(prod, client, ss, log, e, timeOut))
+
 	// moderations
 	router.POST("/api/providers/openai/v1/moderations", getPassThroughHandler(r, prod, private, client, log, timeOut))
 
@@ -139,17 +187,42 @@ func NewProxyServer(log *zap.Logger, mode, privacyMode string, c cache, m KeyMan
 	router.POST("/api/providers/openai/v1/images/variations", getPassThroughHandler(r, prod, private, client, log, timeOut))
 
 	// azure
-	router.POST("/api/providers/azure/openai/deployments/:deployment_id/chat/completions", getAzureChatCompletionHandler(r, prod, private, psm, client, kms, log, aoe, timeOut))
+	router.POST("/api/providers/azure/openai/deployments/:deployment_id/chat/completions", getAzureChatCompletionHandler(r, prod, private, psm, client, kms, log, aoe, gc, timeOut))
 	router.POST("/api/providers/azure/openai/deployments/:deployment_id/embeddings", getAzureEmbeddingsHandler(r, prod, private, psm, client, kms, log, aoe, timeOut))
 
 	// anthropic
 	router.POST("/api/providers/anthropic/v1/complete", getCompletionHandler(r, prod, private, client, kms, log, ae, timeOut))
+	router.POST("/api/providers/anthropic/v1/messages/batches", getMessageBatchesHandler(prod, client, ss, pub, log, ae, timeOut))
+	router.GET("/api/providers/anthropic/v1/messages/batches", getMessageBatchesHandler(prod, client, ss, pub, log, ae, timeOut))
+	router.GET("/api/providers/anthropic/v1/messages/batches/:batch_id", getMessageBatchesHandler(prod, client, ss, pub, log, ae, timeOut))
+	router.DELETE("/api/providers/anthropic/v1/messages/batches/:batch_id", getMessageBatchesHandler(prod, client, ss, pub, log, ae, timeOut))
+	router.POST("/api/providers/anthropic/v1/messages/batches/:batch_id/cancel", getMessageBatchesHandler(prod, client, ss, pub, log, ae, timeOut))
+	router.GET("/api/providers/anthropic/v1/messages/batches/:batch_id/results", getMessageBatchesHandler(prod, client, ss, pub, log, ae, timeOut))
+
+	// pass-through
+	for _, method := range passthrough.Methods {
+		router.Handle(method, "/api/providers/openai/passthrough/*wildcard", getProviderPassThroughHandler("openai", pthms, e, ae, aoe, prod, client, log, timeOut))
+		router.Handle(method, "/api/providers/azure/openai/passthrough/*wildcard", getProviderPassThroughHandler("azure", pthms, e, ae, aoe, prod, client, log, timeOut))
+		router.Handle(method, "/api/providers/anthropic/passthrough/*wildcard", getProviderPassThroughHandler("anthropic", pthms, e, ae, aoe, prod, client, log, timeOut))
+	}
 
 	// custom provider
 	router.POST("/api/custom/providers/:provider/*wildcard", getCustomProviderHandler(prod, private, psm, cpm, client, log, timeOut))
 
 	// custom route
-	router.POST("/api/routes/*route", getRouteHandler(prod, private, rm, c, aoe, e, r, client, log, timeOut))
+	router.POST("/api/routes/*route", getRouteHandler(prod, private, rm, c, aoe, e, r, sh, jr, client, egc, newDeduplicator(), gc, log, timeOut))
+
+	// self service
+	self := router.Group("/api/self", getSelfServiceMiddleware(a))
+	self.GET("/usage", getGetSelfUsageHandler(ssm, log, prod))
+	self.POST("/rotate", getRotateSelfKeyHandler(ssm, log, prod))
+	self.PUT("/personal-cost-limit", getSetPersonalCostLimitHandler(ssm, log, prod))
+
+	// feedback
+	router.POST("/api/feedback", getSelfServiceMiddleware(a), getSubmitFeedbackHandler(fm, log, prod))
+
+	// jobs
+	router.GET("/api/jobs/:id", getSelfServiceMiddleware(a), getGetJobHandler(jm, log, prod))
 
 	srv := &http.Server{
 		Addr:    ":8002",
@@ -159,6 +232,7 @@ func NewProxyServer(log *zap.Logger, mode, privacyMode string, c cache, m KeyMan
 	return &ProxyServer{
 		log:    log,
 		server: srv,
+		sr:     sr,
 	}, nil
 }
 
@@ -529,7 +603,7 @@ func getPassThroughHandler(r recorder, prod, private bool, client http.Client, l
 
 		start := time.Now()
 
-		res, err := client.Do(req)
+		res, err := egressClient(c, client).Do(req)
 		if err != nil {
 			stats.Incr("bricksllm.proxy.get_pass_through_handler.http_client_error", tags, 1)
 
@@ -727,14 +801,10 @@ func getPassThroughHandler(r recorder, prod, private bool, client http.Client, l
 			logOpenAiError(log, prod, cid, errorRes)
 		}
 
-		for name, values := range res.Header {
-			for _, value := range values {
-				c.Header(name, value)
-			}
-		}
+		copyResponseHeaders(c, res.Header)
 
-		if len(res.Header.Get("content-type")) != 0 {
-			c.Data(res.StatusCode, res.Header.Get("content-type"), bytes)
+		if contentType := normalizeContentType(res.Header.Get("content-type")); len(contentType) != 0 {
+			c.Data(res.StatusCode, contentType, bytes)
 			return
 		}
 
@@ -955,18 +1025,16 @@ func getEmbeddingHandler(r recorder, prod, private bool, psm ProviderSettingsMan
 		ctx, cancel := context.WithTimeout(context.Background(), timeOut)
 		defer cancel()
 
-		req, err := http.NewRequestWithContext(ctx, c.Request.Method, "https://api.openai.com/v1/embeddings", c.Request.Body)
+		body, err := io.ReadAll(c.Request.Body)
 		if err != nil {
-			logError(log, "error when creating openai http request", prod, id, err)
-			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create openai http request")
+			logError(log, "error when reading embedding request body", prod, id, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read embedding request body")
 			return
 		}
 
-		copyHttpHeaders(c.Request, req)
-
 		start := time.Now()
 
-		res, err := client.Do(req)
+		status, header, bytes, err := sendEmbeddingBatches(ctx, c, client, "https://api.openai.com/v1/embeddings", body)
 		if err != nil {
 			stats.Incr("bricksllm.proxy.get_embedding_handler.http_client_error", nil, 1)
 
@@ -974,15 +1042,17 @@ func getEmbeddingHandler(r recorder, prod, private bool, psm ProviderSettingsMan
 			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to send embedding request to openai")
 			return
 		}
-		defer res.Body.Close()
 
 		dur := time.Now().Sub(start)
 		stats.Timing("bricksllm.proxy.get_embedding_handler.latency", dur, nil, 1)
 
-		bytes, err := io.ReadAll(res.Body)
-		if err != nil {
-			logError(log, "error when reading openai embedding response body", prod, id, err)
-			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read openai embedding response body")
+		if batches := c.GetInt("embeddingBatches"); batches > 1 {
+			stats.Incr("bricksllm.proxy.get_embedding_handler.split_requests", nil, 1)
+			stats.Incr("bricksllm.proxy.get_embedding_handler.batches", nil, float64(batches))
+		}
+
+		if status == http.StatusOK && !isWellFormedResponse(bytes) {
+			rejectMalformedResponse(c, "openai")
 			return
 		}
 
@@ -990,7 +1060,7 @@ func getEmbeddingHandler(r recorder, prod, private bool, psm ProviderSettingsMan
 		chatRes := &EmbeddingResponse{}
 		promptTokenCounts := 0
 		base64ChatRes := &EmbeddingResponseBase64{}
-		if res.StatusCode == http.StatusOK {
+		if status == http.StatusOK {
 			stats.Incr("bricksllm.proxy.get_embedding_handler.success", nil, 1)
 			stats.Timing("bricksllm.proxy.get_embedding_handler.success_latency", dur, nil, 1)
 
@@ -1044,7 +1114,7 @@ func getEmbeddingHandler(r recorder, prod, private bool, psm ProviderSettingsMan
 		c.Set("costInUsd", cost)
 		c.Set("promptTokenCount", promptTokenCounts)
 
-		if res.StatusCode != http.StatusOK {
+		if status != http.StatusOK {
 			stats.Timing("bricksllm.proxy.get_embedding_handler.error_latency", dur, nil, 1)
 			stats.Incr("bricksllm.proxy.get_embedding_handler.error_response", nil, 1)
 
@@ -1057,13 +1127,9 @@ func getEmbeddingHandler(r recorder, prod, private bool, psm ProviderSettingsMan
 			logOpenAiError(log, prod, id, errorRes)
 		}
 
-		for name, values := range res.Header {
-			for _, value := range values {
-				c.Header(name, value)
-			}
-		}
+		copyResponseHeaders(c, header)
 
-		c.Data(res.StatusCode, "application/json", bytes)
+		c.Data(status, "application/json", bytes)
 	}
 }
 
@@ -1075,7 +1141,7 @@ var (
 	errorPrefix           = []byte(`data: {"error":`)
 )
 
-func getChatCompletionHandler(r recorder, prod, private bool, psm ProviderSettingsManager, client http.Client, kms keyMemStorage, log *zap.Logger, e estimator, timeOut time.Duration) gin.HandlerFunc {
+func getChatCompletionHandler(r recorder, prod, private bool, psm ProviderSettingsManager, client http.Client, kms keyMemStorage, log *zap.Logger, e estimator, gc *guardrail.Client, timeOut time.Duration) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		stats.Incr("bricksllm.proxy.get_chat_completion_handler.requests", nil, 1)
 
@@ -1113,7 +1179,7 @@ func getChatCompletionHandler(r recorder, prod, private bool, psm ProviderSettin
 		}
 
 		start := time.Now()
-		res, err := client.Do(req)
+		res, err := egressClient(c, client).Do(req)
 		if err != nil {
 			stats.Incr("bricksllm.proxy.get_chat_completion_handler.http_client_error", nil, 1)
 
@@ -1124,11 +1190,7 @@ func getChatCompletionHandler(r recorder, prod, private bool, psm ProviderSettin
 
 		defer res.Body.Close()
 
-		for name, values := range res.Header {
-			for _, value := range values {
-				c.Header(name, value)
-			}
-		}
+		copyResponseHeaders(c, res.Header)
 
 		model := c.GetString("model")
 
@@ -1143,6 +1205,11 @@ func getChatCompletionHandler(r recorder, prod, private bool, psm ProviderSettin
 				return
 			}
 
+			if !isWellFormedResponse(bytes) {
+				rejectMalformedResponse(c, "openai")
+				return
+			}
+
 			var cost float64 = 0
 			chatRes := &goopenai.ChatCompletionResponse{}
 			stats.Incr("bricksllm.proxy.get_chat_completion_handler.success", nil, 1)
@@ -1172,6 +1239,7 @@ func getChatCompletionHandler(r recorder, prod, private bool, psm ProviderSettin
 			c.Set("costInUsd", cost)
 			c.Set("promptTokenCount", chatRes.Usage.PromptTokens)
 			c.Set("completionTokenCount", chatRes.Usage.CompletionTokens)
+			c.Set("toolCallIds", responseToolCallIds(bytes))
 
 			c.Data(res.StatusCode, "application/json", bytes)
 			return
@@ -1198,8 +1266,10 @@ func getChatCompletionHandler(r recorder, prod, private bool, psm ProviderSettin
 		// var totalCost float64 = 0
 		// var totalTokens int = 0
 		content := ""
+		toolCallIds := []string{}
 		defer func() {
 			c.Set("content", content)
+			c.Set("toolCallIds", toolCallIds)
 
 			// tks, cost, err := e.EstimateChatCompletionStreamCostWithTokenCounts(model, content)
 			// if err != nil {
@@ -1217,10 +1287,18 @@ func getChatCompletionHandler(r recorder, prod, private bool, psm ProviderSettin
 
 		stats.Incr("bricksllm.proxy.get_chat_completion_handler.streaming_requests", nil, 1)
 
+		maxStreamed := c.GetInt("maxStreamedTokens")
+		streamed := 0
+		sg := newStreamGuard(c, gc)
+
 		c.Stream(func(w io.Writer) bool {
 			raw, err := buffer.ReadBytes('\n')
 			if err != nil {
 				if err == io.EOF {
+					if sg != nil {
+						sg.flush()
+					}
+
 					return false
 				}
 
@@ -1258,7 +1336,14 @@ func getChatCompletionHandler(r recorder, prod, private bool, psm ProviderSettin
 			}
 
 			noPrefixLine := bytes.TrimPrefix(noSpaceLine, headerData)
-			c.SSEvent("", " "+string(noPrefixLine))
+			if sg != nil {
+				if sg.send(noPrefixLine) {
+					stats.Incr("bricksllm.proxy.get_chat_completion_handler.stream_blocked", nil, 1)
+					return false
+				}
+			} else {
+				c.SSEvent("", " "+string(noPrefixLine))
+			}
 
 			if string(noPrefixLine) == "[DONE]" {
 				return false
@@ -1272,8 +1357,22 @@ func getChatCompletionHandler(r recorder, prod, private bool, psm ProviderSettin
 			}
 
 			if err == nil {
+				toolCallIds = append(toolCallIds, streamToolCallIds(chatCompletionStreamResp)...)
+
 				if len(chatCompletionStreamResp.Choices) > 0 && len(chatCompletionStreamResp.Choices[0].Delta.Content) != 0 {
 					content += chatCompletionStreamResp.Choices[0].Delta.Content
+					streamed++
+
+					if maxStreamed != 0 && streamed >= maxStreamed {
+						if sg != nil && sg.flush() {
+							stats.Incr("bricksllm.proxy.get_chat_completion_handler.stream_blocked", nil, 1)
+							return false
+						}
+
+						stats.Incr("bricksllm.proxy.get_chat_completion_handler.stream_truncated", nil, 1)
+						truncateStream(c, chatCompletionStreamResp)
+						return false
+					}
 				}
 			}
 
@@ -1299,6 +1398,13 @@ func (ps *ProxyServer) Run() {
 		// embeddings
 		ps.log.Info("PORT 8002 | POST   | /api/providers/openai/v1/embeddings is ready for forwarding embeddings requests to openai")
 
+		// responses
+		ps.log.Info("PORT 8002 | POST   | /api/providers/openai/v1/responses is ready for forwarding responses requests to openai")
+		ps.log.Info("PORT 8002 | GET    | /api/providers/openai/v1/responses/:response_id is ready for retrieving an openai response")
+		ps.log.Info("PORT 8002 | DELETE | /api/providers/openai/v1/responses/:response_id is ready for deleting an openai response")
+		ps.log.Info("PORT 8002 | POST   | /api/providers/openai/v1/responses/:response_id/cancel is ready for cancelling an openai background response")
+		ps.log.Info("PORT 8002 | GET    | /api/providers/openai/v1/responses/:response_id/input_items is ready for listing the input items of an openai response")
+
 		// moderations
 		ps.log.Info("PORT 8002 | POST   | /api/providers/openai/v1/moderations is ready for forwarding moderation requests to openai")
 
@@ -1363,6 +1469,17 @@ func (ps *ProxyServer) Run() {
 
 		// anthropic
 		ps.log.Info("PORT 8002 | POST   | /api/providers/anthropic/v1/complete is ready for forwarding completion requests to anthropic")
+		ps.log.Info("PORT 8002 | POST   | /api/providers/anthropic/v1/messages/batches is ready for forwarding create message batch requests to anthropic")
+		ps.log.Info("PORT 8002 | GET    | /api/providers/anthropic/v1/messages/batches is ready for forwarding list message batches requests to anthropic")
+		ps.log.Info("PORT 8002 | GET    | /api/providers/anthropic/v1/messages/batches/:batch_id is ready for forwarding retrieve message batch requests to anthropic")
+		ps.log.Info("PORT 8002 | DELETE | /api/providers/anthropic/v1/messages/batches/:batch_id is ready for forwarding delete message batch requests to anthropic")
+		ps.log.Info("PORT 8002 | POST   | /api/providers/anthropic/v1/messages/batches/:batch_id/cancel is ready for forwarding cancel message batch requests to anthropic")
+		ps.log.Info("PORT 8002 | GET    | /api/providers/anthropic/v1/messages/batches/:batch_id/results is ready for forwarding message batch results requests to anthropic")
+
+		// pass-through
+		ps.log.Info("PORT 8002 | ANY    | /api/providers/openai/passthrough/*wildcard is ready for passing requests through to openai")
+		ps.log.Info("PORT 8002 | ANY    | /api/providers/azure/openai/passthrough/*wildcard is ready for passing requests through to azure openai")
+		ps.log.Info("PORT 8002 | ANY    | /api/providers/anthropic/passthrough/*wildcard is ready for passing requests through to anthropic")
 
 		// custom provider
 		ps.log.Info("PORT 8002 | POST   | /api/custom/providers/:provider/*wildcard is ready for forwarding requests to custom providers")
@@ -1370,6 +1487,9 @@ func (ps *ProxyServer) Run() {
 		// custom route
 		ps.log.Info("PORT 8002 | POST   | /api/routes/*route is ready for forwarding requests to a custom route")
 
+		// jobs
+		ps.log.Info("PORT 8002 | GET    | /api/jobs/:id is ready for retrieving an async job")
+
 		if err := ps.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			ps.log.Sugar().Fatalf("error proxy server listening: %v", err)
 			return
@@ -1381,7 +1501,7 @@ func logEmbeddingResponse(log *zap.Logger, prod, private bool, cid string, r *Em
 	if prod {
 		log.Info("openai embeddings response",
 			zap.Time("createdAt", time.Now()),
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.Object("response", zapcore.ObjectMarshalerFunc(
 				func(enc zapcore.ObjectEncoder) error {
 					enc.AddString("object", r.Object)
@@ -1430,7 +1550,7 @@ func logBase64EmbeddingResponse(log *zap.Logger, prod, private bool, cid string,
 	if prod {
 		log.Info("openai embeddings response",
 			zap.Time("createdAt", time.Now()),
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.Object("response", zapcore.ObjectMarshalerFunc(
 				func(enc zapcore.ObjectEncoder) error {
 					enc.AddString("object", r.Object)
@@ -1473,7 +1593,7 @@ func logChatCompletionResponse(log *zap.Logger, prod, private bool, cid string,
 	if prod {
 		log.Info("openai chat completion response",
 			zap.Time("createdAt", time.Now()),
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.Object("response", zapcore.ObjectMarshalerFunc(
 				func(enc zapcore.ObjectEncoder) error {
 					enc.AddString("id", r.ID)
@@ -1523,7 +1643,7 @@ func logChatCompletionResponse(log *zap.Logger, prod, private bool, cid string,
 func logEmbeddingRequest(log *zap.Logger, prod, private bool, id string, r *goopenai.EmbeddingRequest) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, id),
+			zap.String(logger.FieldRequestId, id),
 			zap.String("model", string(r.Model)),
 			zap.String("encoding_format", string(r.EncodingFormat)),
 			zap.String("user", r.User),
@@ -1541,7 +1661,7 @@ func logRequest(log *zap.Logger, prod, private bool, id string, r *goopenai.Chat
 	if prod {
 		log.Info("openai chat completion request",
 			zap.Time("createdAt", time.Now()),
-			zap.String(correlationId, id),
+			zap.String(logger.FieldRequestId, id),
 			zap.Object("request", zapcore.ObjectMarshalerFunc(
 				func(enc zapcore.ObjectEncoder) error {
 					enc.AddString("model", r.Model)
@@ -1678,23 +1798,25 @@ func logRequest(log *zap.Logger, prod, private bool, id string, r *goopenai.Chat
 
 func logOpenAiError(log *zap.Logger, prod bool, id string, errRes *goopenai.ErrorResponse) {
 	if prod {
-		log.Info("openai error response", zap.String(correlationId, id), zap.Any("error", errRes))
+		log.Info("openai error response", zap.String(logger.FieldRequestId, id), zap.Any("error", errRes))
 		return
 	}
 
-	log.Sugar().Infof("correlationId:%s | %s ", id, "openai error response")
+	log.Sugar().Infof("request_id:%s | %s ", id, "openai error response")
 }
 
 func logError(log *zap.Logger, msg string, prod bool, id string, err error) {
 	if prod {
-		log.Debug(msg, zap.String(correlationId, id), zap.Error(err))
+		log.Debug(msg, zap.String(logger.FieldRequestId, id), zap.Error(err))
 		return
 	}
 
-	log.Sugar().Debugf("correlationId:%s | %s | %v", id, msg, err)
+	log.Sugar().Debugf("request_id:%s | %s | %v", id, msg, err)
 }
 
 func (ps *ProxyServer) Shutdown(ctx context.Context) error {
+	ps.sr.stop()
+
 	if err := ps.server.Shutdown(ctx); err != nil {
 		ps.log.Sugar().Infof("error shutting down proxy server: %v", err)
 
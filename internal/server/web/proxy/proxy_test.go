@@ -0,0 +1,16 @@
+package proxy
+
+import (
+	"os"
+	"testing"
+
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	stats.InitializeClient("", nil)
+	gin.SetMode(gin.TestMode)
+
+	os.Exit(m.Run())
+}
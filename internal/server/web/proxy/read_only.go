@@ -0,0 +1,214 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/cors"
+	"github.com/bricks-cloud/bricksllm/internal/mock"
+	"github.com/bricks-cloud/bricksllm/internal/pause"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+	goopenai "github.com/sashabaranov/go-openai"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+)
+
+// readOnlyPause answers the requests a read-only proxy cannot serve.
+var readOnlyPause = &pause.Pause{
+	Scope: pause.ScopeGlobal,
+	Maintenance: &pause.Maintenance{
+		StatusCode: http.StatusServiceUnavailable,
+		Message:    "proxy is in read-only mode and only serves cached and mock responses",
+		CacheOnly:  true,
+	},
+}
+
+// ReadOnlyServer answers requests of a proxy that could not reach Postgres at
+// startup. It authenticates keys against the last snapshot of the
+// configuration and only serves cache hits of routes and responses of mock
+// providers, since neither calls a provider or needs events to be recorded.
+// Every other request gets the maintenance response of a global pause or a
+// read-only maintenance error.
+type ReadOnlyServer struct {
+	server *http.Server
+	log    *zap.Logger
+}
+
+func NewReadOnlyServer(log *zap.Logger, mode string, a authenticator, rm routeManager, ca cache, mms mockResponseMemStorage, ps pauseMemStorage, cp *cors.Policy) *ReadOnlyServer {
+	router := gin.New()
+	prod := mode == "production"
+
+	router.Use(getCorsMiddleware(cp, rm))
+
+	router.POST("/api/health", getGetHealthCheckHandler())
+
+	handler := getReadOnlyHandler(a, rm, ca, mms, ps, log, prod)
+	router.POST("/api/providers/openai/v1/chat/completions", handler)
+	router.POST("/api/providers/openai/v1/embeddings", handler)
+	router.POST("/api/providers/azure/openai/deployments/:deployment_id/chat/completions", handler)
+	router.POST("/api/providers/azure/openai/deployments/:deployment_id/embeddings", handler)
+	router.POST("/api/routes/*route", handler)
+
+	router.NoRoute(func(c *gin.Context) {
+		stats.Incr("bricksllm.proxy.read_only.unsupported_path", nil, 1)
+		writePausedResponse(c, readOnlyMaintenance(ps))
+	})
+
+	return &ReadOnlyServer{
+		log: log,
+		server: &http.Server{
+			Addr:    ":8002",
+			Handler: router,
+		},
+	}
+}
+
+// readOnlyMaintenance prefers the maintenance response of a global pause set
+// by operators, since pauses are kept in Redis and stay available.
+func readOnlyMaintenance(ps pauseMemStorage) *pause.Pause {
+	if p := ps.GetPause(pause.ScopeGlobal, ""); p != nil && p.Maintenance != nil {
+		return p
+	}
+
+	return readOnlyPause
+}
+
+func getReadOnlyHandler(a authenticator, rm routeManager, ca cache, mms mockResponseMemStorage, ps pauseMemStorage, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.proxy.read_only.requests", nil, 1)
+
+		cid := util.NewUuid()
+		c.Set(correlationId, cid)
+
+		kc, settings, err := a.AuthenticateHttpRequest(c.Request)
+		if _, ok := err.(notAuthorizedError); ok {
+			stats.Incr("bricksllm.proxy.read_only.authentication_error", nil, 1)
+			JSON(c, http.StatusUnauthorized, "[BricksLLM] not authorized")
+			return
+		}
+
+		if _, ok := err.(notFoundError); ok {
+			stats.Incr("bricksllm.proxy.read_only.not_found_error", nil, 1)
+			JSON(c, http.StatusNotFound, "[BricksLLM] route not found")
+			return
+		}
+
+		if err != nil {
+			stats.Incr("bricksllm.proxy.read_only.authenticate_http_request_error", nil, 1)
+			logError(log, "error when authenticating http requests in read-only mode", prod, cid, err)
+			writePausedResponse(c, readOnlyMaintenance(ps))
+			return
+		}
+
+		if !kc.Schedule.IsActive(time.Now()) {
+			stats.Incr("bricksllm.proxy.read_only.key_not_active", nil, 1)
+			JSONError(c, http.StatusForbidden, codeKeyNotActive, "[BricksLLM] key is not active at this time", nil)
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			logError(log, "error when reading request body in read-only mode", prod, cid, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] cannot read request body")
+			return
+		}
+
+		if strings.HasPrefix(c.FullPath(), "/api/routes") {
+			serveReadOnlyRoute(c, kc.TenantId, rm, ca, ps, body, log, prod, cid)
+			return
+		}
+
+		if len(settings) != 0 && settings[0].Provider == mock.ProviderName {
+			stats.Incr("bricksllm.proxy.read_only.mock_response", nil, 1)
+
+			c.Set("stream", gjson.GetBytes(body, "stream").Bool())
+			serveMockResponse(c, mms, body, log, prod, cid)
+			return
+		}
+
+		stats.Incr("bricksllm.proxy.read_only.provider_request_rejected", nil, 1)
+		writePausedResponse(c, readOnlyMaintenance(ps))
+	}
+}
+
+// serveReadOnlyRoute answers a route request from its cache. Only the cache
+// keys of the route itself are looked up, so responses cached for language,
+// complexity or downgrade variants of the route are not served.
+func serveReadOnlyRoute(c *gin.Context, tenantId string, rm routeManager, ca cache, ps pauseMemStorage, body []byte, log *zap.Logger, prod bool, cid string) {
+	start := time.Now()
+	r := c.Param("route")
+
+	rc := rm.GetRouteFromMemDb(tenantId, r)
+	if rc == nil {
+		stats.Incr("bricksllm.proxy.read_only.route_config_not_found", nil, 1)
+		JSONError(c, http.StatusNotFound, codeRouteNotFound, "[BricksLLM] route config is not found", nil)
+		return
+	}
+
+	cacheKey := ""
+	if rc.CacheConfig != nil && rc.CacheConfig.Enabled {
+		cachePrefix := tenantId + r
+		if rc.ShouldRunEmbeddings() {
+			er := &goopenai.EmbeddingRequest{}
+			if err := json.Unmarshal(body, er); err == nil {
+				cacheKey = route.ComputeCacheKeyForEmbeddingsRequest(cachePrefix, er)
+			}
+		} else {
+			ccr := &goopenai.ChatCompletionRequest{}
+			if err := json.Unmarshal(body, ccr); err == nil && !ccr.Stream {
+				cacheKey = route.ComputeCacheKeyForChatCompletionRequest(cachePrefix, ccr)
+			}
+		}
+	}
+
+	if len(cacheKey) != 0 {
+		bytes, err := ca.GetBytes(cacheKey)
+		if err == nil && len(bytes) != 0 {
+			stats.Incr("bricksllm.proxy.read_only.cache_hit", nil, 1)
+
+			setRouteResponseHeaders(c, rc, "", "cached", "hit", time.Since(start))
+			c.Data(http.StatusOK, "application/json", bytes)
+			return
+		}
+
+		if err != nil {
+			logError(log, "error when getting cached route response in read-only mode", prod, cid, err)
+		}
+	}
+
+	stats.Incr("bricksllm.proxy.read_only.cache_miss", nil, 1)
+	writePausedResponse(c, readOnlyMaintenance(ps))
+}
+
+func (s *ReadOnlyServer) Run() {
+	go func() {
+		s.log.Info("read-only proxy server listening at 8002")
+		s.log.Info("PORT 8002 | POST   | /api/providers/openai/v1/chat/completions is ready for serving mock responses")
+		s.log.Info("PORT 8002 | POST   | /api/providers/openai/v1/embeddings is ready for serving mock responses")
+		s.log.Info("PORT 8002 | POST   | /api/providers/azure/openai/deployments/:deployment_id/chat/completions is ready for serving mock responses")
+		s.log.Info("PORT 8002 | POST   | /api/providers/azure/openai/deployments/:deployment_id/embeddings is ready for serving mock responses")
+		s.log.Info("PORT 8002 | POST   | /api/routes/*route is ready for serving cached route responses")
+
+		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			s.log.Sugar().Fatalf("error read-only proxy server listening: %v", err)
+			return
+		}
+	}()
+}
+
+func (s *ReadOnlyServer) Shutdown(ctx context.Context) error {
+	if err := s.server.Shutdown(ctx); err != nil {
+		s.log.Sugar().Infof("error shutting down read-only proxy server: %v", err)
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/mock"
+	"github.com/bricks-cloud/bricksllm/internal/pause"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	goopenai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeRouteManager map[string]*route.Route
+
+func (m fakeRouteManager) GetRouteFromMemDb(tenantId, path string) *route.Route {
+	return m[path]
+}
+
+func (m fakeRouteManager) GetCorsRouteFromMemDb(path string) *route.Route {
+	return m[path]
+}
+
+type fakeCache map[string][]byte
+
+func (ca fakeCache) GetBytes(key string) ([]byte, error) {
+	return ca[key], nil
+}
+
+func (ca fakeCache) StoreBytes(key string, value []byte, ttl time.Duration) error {
+	ca[key] = value
+	return nil
+}
+
+type fakeMockStorage struct{}
+
+func (fakeMockStorage) GetMockResponse(model string) *mock.Response {
+	return nil
+}
+
+func TestReadOnlyServer(t *testing.T) {
+	chatPath := "/api/providers/openai/v1/chat/completions"
+	cached := `{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`
+
+	ccr := &goopenai.ChatCompletionRequest{}
+	require.NoError(t, json.Unmarshal([]byte(cached), ccr))
+
+	rm := fakeRouteManager{
+		"/chat": {Path: "/chat", CacheConfig: &route.CacheConfig{Enabled: true}},
+	}
+	ca := fakeCache{route.ComputeCacheKeyForChatCompletionRequest("t1/chat", ccr): []byte(`{"id":"cached"}`)}
+
+	maintenance := &pause.Pause{Scope: pause.ScopeGlobal, Maintenance: &pause.Maintenance{StatusCode: http.StatusServiceUnavailable, Body: []byte(`{"error":"down for maintenance"}`)}}
+
+	cases := []struct {
+		name     string
+		path     string
+		body     string
+		provider string
+		authErr  error
+		global   *pause.Pause
+		expected int
+		response string
+	}{
+		{name: "cached route responses are served", path: "/api/routes/chat", body: cached, expected: http.StatusOK, response: `{"id":"cached"}`},
+		{name: "route requests that are not cached", path: "/api/routes/chat", body: `{"model":"gpt-4o","messages":[{"role":"user","content":"hello"}]}`, expected: http.StatusServiceUnavailable, response: codeMaintenance},
+		{name: "unknown routes", path: "/api/routes/other", body: cached, expected: http.StatusNotFound},
+		{name: "mock responses are served", path: chatPath, body: `{"model":"gpt-4o"}`, provider: mock.ProviderName, expected: http.StatusOK, response: mock.DefaultContent},
+		{name: "provider requests are rejected", path: chatPath, body: `{"model":"gpt-4o"}`, provider: "openai", expected: http.StatusServiceUnavailable, response: codeMaintenance},
+		{name: "unsupported paths are rejected", path: "/api/providers/openai/v1/images/generations", body: `{}`, provider: "openai", expected: http.StatusServiceUnavailable, response: codeMaintenance},
+		{name: "maintenance responses of global pauses are preferred", path: chatPath, body: `{}`, provider: "openai", global: maintenance, expected: http.StatusServiceUnavailable, response: "down for maintenance"},
+		{name: "unauthorized requests", path: chatPath, body: `{}`, authErr: errors.New("database is down"), expected: http.StatusServiceUnavailable, response: codeMaintenance},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := &fakeKeyAuthenticator{kc: &key.ResponseKey{KeyId: "k1", TenantId: "t1"}, settings: []*provider.Setting{{Provider: tc.provider}}, err: tc.authErr}
+			s := NewReadOnlyServer(zap.NewNop(), "development", a, rm, ca, fakeMockStorage{}, fakePauseStorage{global: tc.global}, nil)
+
+			w := httptest.NewRecorder()
+			s.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body)))
+
+			assert.Equal(t, tc.expected, w.Code)
+			assert.Contains(t, w.Body.String(), tc.response)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package proxy
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/gin-gonic/gin"
+)
+
+// maxRecordedResponseSize caps how much of a response is kept for replays.
+const maxRecordedResponseSize = 1 << 20
+
+type recordingResponseWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *recordingResponseWriter) Write(data []byte) (int, error) {
+	if w.body.Len()+len(data) <= maxRecordedResponseSize {
+		w.body.Write(data)
+	}
+
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func isReplayablePath(fullPath string) bool {
+	return isChatCompletionPath(fullPath) || isEmbeddingsPath(fullPath) || strings.HasPrefix(fullPath, "/api/routes/")
+}
+
+// isSampledPath reports whether requests of a path can be sampled for
+// evaluations. Route requests without messages are dropped when sampled.
+func isSampledPath(fullPath string) bool {
+	return isChatCompletionPath(fullPath) || strings.HasPrefix(fullPath, "/api/routes/")
+}
+
+// getRecordingMiddleware captures the requests and responses of recorded and
+// sampled requests for their events.
+func getRecordingMiddleware(recordRequests bool, samplingPercentage float64, private bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		kc, ok := proxyKey(c)
+		if !ok {
+			return
+		}
+
+		recording := kc.IsFeatureEnabled(key.FeatureRecordRequests, recordRequests) && !private && isReplayablePath(c.FullPath())
+		sampled := kc.IsFeatureEnabled(key.FeatureSampling, true) && samplingPercentage > 0 && !private && isSampledPath(c.FullPath()) && rand.Float64()*100 < samplingPercentage
+		if !recording && !sampled {
+			return
+		}
+
+		// sampled requests share the response capture of recordings.
+		rw := &recordingResponseWriter{
+			ResponseWriter: c.Writer,
+			body:           &bytes.Buffer{},
+		}
+
+		c.Writer = rw
+		body := requestBody(c)
+
+		c.Next()
+
+		if recording {
+			c.Set("recordedRequest", &event.RecordedRequest{
+				Request:  body,
+				Response: rw.body.Bytes(),
+			})
+		}
+
+		if sampled {
+			c.Set("sample", &event.Sample{
+				Request:  body,
+				Response: rw.body.Bytes(),
+				Streamed: c.GetString("content"),
+			})
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+)
+
+type responseUsageStorage interface {
+	ClaimResponseUsage(responseId string) (bool, error)
+}
+
+func isResponsesPath(fullPath string) bool {
+	return fullPath == "/api/providers/openai/v1/responses"
+}
+
+// chargeResponse sets the usage of a finished response for the event of the
+// request. Background responses are only finished when they are retrieved,
+// possibly many times, so the usage of a response is charged once no matter
+// which request returns it first.
+func chargeResponse(c *gin.Context, e estimator, rus responseUsageStorage, response gjson.Result, log *zap.Logger, prod bool, cid string) {
+	status := response.Get("status").Str
+	if status != "completed" && status != "incomplete" {
+		return
+	}
+
+	usage := response.Get("usage")
+	if !usage.Exists() {
+		return
+	}
+
+	model := c.GetString("model")
+	if len(model) == 0 {
+		model = response.Get("model").Str
+		c.Set("model", model)
+	}
+
+	claimed, err := rus.ClaimResponseUsage(response.Get("id").Str)
+	if err != nil {
+		stats.Incr("bricksllm.proxy.charge_response.claim_response_usage_error", nil, 1)
+		logError(log, "error when claiming openai response usage", prod, cid, err)
+		return
+	}
+
+	if !claimed {
+		stats.Incr("bricksllm.proxy.charge_response.already_charged", nil, 1)
+		return
+	}
+
+	promptTks := int(usage.Get("input_tokens").Int())
+	completionTks := int(usage.Get("output_tokens").Int())
+
+	cost, err := e.EstimateTotalCost(model, promptTks, completionTks)
+	if err != nil {
+		stats.Incr("bricksllm.proxy.charge_response.estimate_total_cost_error", nil, 1)
+		logError(log, "error when estimating openai response cost", prod, cid, err)
+	}
+
+	c.Set("costInUsd", cost)
+	c.Set("promptTokenCount", promptTks)
+	c.Set("completionTokenCount", completionTks)
+	c.Set("toolCallIds", responseToolCallIds([]byte(response.Raw)))
+}
+
+// getResponsesHandler forwards requests of the responses api to openai and
+// charges the usage of responses created, streamed or retrieved through it.
+func getResponsesHandler(prod bool, client http.Client, rus responseUsageStorage, log *zap.Logger, e estimator, timeOut time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tags := []string{
+			"path:" + c.FullPath(),
+		}
+
+		stats.Incr("bricksllm.proxy.get_responses_handler.requests", tags, 1)
+
+		if c == nil || c.Request == nil {
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] context is empty")
+			return
+		}
+
+		cid := c.GetString(correlationId)
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeOut)
+		defer cancel()
+
+		targetUrl := "https://api.openai.com/v1" + strings.TrimPrefix(c.Request.URL.Path, "/api/providers/openai/v1")
+		if len(c.Request.URL.RawQuery) != 0 {
+			targetUrl += "?" + c.Request.URL.RawQuery
+		}
+
+		req, err := http.NewRequestWithContext(ctx, c.Request.Method, targetUrl, c.Request.Body)
+		if err != nil {
+			logError(log, "error when creating openai http request", prod, cid, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create openai http request")
+			return
+		}
+
+		copyHttpHeaders(c.Request, req)
+
+		isStreaming := c.GetBool("stream")
+		if isStreaming {
+			req.Header.Set("Accept", "text/event-stream")
+			req.Header.Set("Cache-Control", "no-cache")
+			req.Header.Set("Connection", "keep-alive")
+		}
+
+		start := time.Now()
+		res, err := egressClient(c, client).Do(req)
+		if err != nil {
+			stats.Incr("bricksllm.proxy.get_responses_handler.http_client_error", tags, 1)
+
+			logError(log, "error when sending http request to openai", prod, cid, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to send http request to openai")
+			return
+		}
+
+		defer res.Body.Close()
+
+		copyResponseHeaders(c, res.Header)
+
+		if res.StatusCode != http.StatusOK || !isStreaming {
+			dur := time.Now().Sub(start)
+			stats.Timing("bricksllm.proxy.get_responses_handler.latency", dur, tags, 1)
+
+			bytes, err := io.ReadAll(res.Body)
+			if err != nil {
+				logError(log, "error when reading openai http response body", prod, cid, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read openai response body")
+				return
+			}
+
+			if res.StatusCode != http.StatusOK {
+				stats.Incr("bricksllm.proxy.get_responses_handler.error_response", tags, 1)
+				logAnthropicErrorResponse(log, bytes, prod, cid)
+				c.Data(res.StatusCode, "application/json", bytes)
+				return
+			}
+
+			if !isWellFormedResponse(bytes) {
+				rejectMalformedResponse(c, "openai")
+				return
+			}
+
+			stats.Incr("bricksllm.proxy.get_responses_handler.success", tags, 1)
+
+			if gjson.GetBytes(bytes, "object").Str == "response" {
+				chargeResponse(c, e, rus, gjson.ParseBytes(bytes), log, prod, cid)
+			}
+
+			c.Data(res.StatusCode, "application/json", bytes)
+			return
+		}
+
+		stats.Incr("bricksllm.proxy.get_responses_handler.streaming_requests", tags, 1)
+
+		buffer := bufio.NewReader(res.Body)
+		content := ""
+		defer func() {
+			c.Set("content", content)
+		}()
+
+		c.Status(res.StatusCode)
+		c.Stream(func(w io.Writer) bool {
+			raw, err := buffer.ReadBytes('\n')
+			if len(raw) != 0 {
+				w.Write(raw)
+			}
+
+			if err != nil {
+				if err != io.EOF {
+					if errors.Is(err, context.DeadlineExceeded) {
+						stats.Incr("bricksllm.proxy.get_responses_handler.context_deadline_exceeded_error", tags, 1)
+					}
+
+					stats.Incr("bricksllm.proxy.get_responses_handler.read_bytes_error", tags, 1)
+					logError(log, "error when reading bytes from openai response stream", prod, cid, err)
+				}
+
+				return false
+			}
+
+			line := bytes.TrimSpace(raw)
+			if !bytes.HasPrefix(line, headerData) {
+				return true
+			}
+
+			data := gjson.ParseBytes(bytes.TrimSpace(bytes.TrimPrefix(line, headerData)))
+			switch data.Get("type").Str {
+			case "response.output_text.delta":
+				content += data.Get("delta").Str
+			case "response.completed", "response.incomplete":
+				chargeResponse(c, e, rus, data.Get("response"), log, prod, cid)
+			}
+
+			return true
+		})
+
+		stats.Timing("bricksllm.proxy.get_responses_handler.streaming_latency", time.Now().Sub(start), tags, 1)
+	}
+}
@@ -0,0 +1,234 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/vector"
+	goopenai "github.com/sashabaranov/go-openai"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	retrievalLatencyMetadataKey  = "bricksllm_retrieval_latency_ms"
+	retrievalChunkIdsMetadataKey = "bricksllm_retrieval_chunk_ids"
+)
+
+// retrievalInstruction introduces the chunks prepended to routes without a
+// prompt template.
+const retrievalInstruction = "Use the following context to answer. If it is not relevant, ignore it.\n\n"
+
+type retrievalResult struct {
+	body      []byte
+	chunkIds  []string
+	latency   time.Duration
+	costInUsd float64
+}
+
+type retriever struct {
+	vc *vector.Clients
+}
+
+func newRetriever() *retriever {
+	return &retriever{
+		vc: vector.NewClients(http.Client{}),
+	}
+}
+
+// retrievalQuery returns the query of a request, which is a variable of the
+// prompt template when the route names one and the last user message
+// otherwise.
+func retrievalQuery(rc *route.Route, fields map[string]json.RawMessage) (string, error) {
+	if len(rc.Retrieval.QueryVariable) != 0 {
+		variables := map[string]string{}
+		if raw, ok := fields[variablesBodyField]; ok {
+			if err := json.Unmarshal(raw, &variables); err != nil {
+				return "", internal_errors.NewValidationError(variablesBodyField + " must be an object of strings")
+			}
+		}
+
+		return variables[rc.Retrieval.QueryVariable], nil
+	}
+
+	ccr := &goopenai.ChatCompletionRequest{}
+	if raw, ok := fields["messages"]; ok {
+		if err := json.Unmarshal(raw, &ccr.Messages); err != nil {
+			return "", internal_errors.NewValidationError("messages must be an array")
+		}
+	}
+
+	return route.LastUserContent(ccr), nil
+}
+
+// retrieve fetches the chunks closest to the query of a route chat
+// completion request and adds them to the body. It has to run before the
+// prompt template of the route is rendered.
+func (rt *retriever) retrieve(rc *route.Route, req *route.Request, body []byte, e estimator, aoe azureEstimator) (*retrievalResult, error) {
+	start := time.Now()
+	result := &retrievalResult{
+		body: body,
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+
+	query, err := retrievalQuery(rc, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	// templates are still rendered with an empty context for requests
+	// without a query.
+	chunks := []*vector.Chunk{}
+	if len(strings.TrimSpace(query)) != 0 {
+		found, cost, err := rt.search(rc, req, query, e, aoe)
+		result.costInUsd = cost
+		if err != nil {
+			return result, err
+		}
+
+		chunks = found
+	}
+
+	for _, c := range chunks {
+		result.chunkIds = append(result.chunkIds, c.Id)
+	}
+
+	formatted := route.FormatChunks(chunks)
+	if rc.PromptTemplate != nil {
+		variables := map[string]string{}
+		if raw, ok := fields[variablesBodyField]; ok {
+			if err := json.Unmarshal(raw, &variables); err != nil {
+				return result, internal_errors.NewValidationError(variablesBodyField + " must be an object of strings")
+			}
+		}
+
+		// the retrieved chunks take precedence over a variable of the
+		// same name sent by the client.
+		variables[rc.Retrieval.GetVariable()] = formatted
+		data, err := json.Marshal(variables)
+		if err != nil {
+			return result, err
+		}
+
+		fields[variablesBodyField] = data
+	} else if len(chunks) != 0 {
+		messages := []json.RawMessage{}
+		if raw, ok := fields["messages"]; ok {
+			if err := json.Unmarshal(raw, &messages); err != nil {
+				return result, internal_errors.NewValidationError("messages must be an array")
+			}
+		}
+
+		system, err := json.Marshal(&goopenai.ChatCompletionMessage{
+			Role:    goopenai.ChatMessageRoleSystem,
+			Content: retrievalInstruction + formatted,
+		})
+		if err != nil {
+			return result, err
+		}
+
+		data, err := json.Marshal(append([]json.RawMessage{system}, messages...))
+		if err != nil {
+			return result, err
+		}
+
+		fields["messages"] = data
+	}
+
+	injected, err := json.Marshal(fields)
+	if err != nil {
+		return result, err
+	}
+
+	result.body = injected
+	result.latency = time.Since(start)
+
+	return result, nil
+}
+
+// search returns the relevant chunks closest to query with the cost of
+// embedding it.
+func (rt *retriever) search(rc *route.Route, req *route.Request, query string, e estimator, aoe azureEstimator) ([]*vector.Chunk, float64, error) {
+	embedding, cost, err := embedRetrievalQuery(rc, req, query, e, aoe)
+	if err != nil {
+		return nil, cost, err
+	}
+
+	store, err := rt.vc.Get(rc.Retrieval.Store)
+	if err != nil {
+		return nil, cost, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rc.Retrieval.GetTimeout())
+	defer cancel()
+
+	chunks, err := store.Query(ctx, embedding, rc.Retrieval.TopK)
+	if err != nil {
+		return nil, cost, err
+	}
+
+	return rc.Retrieval.Relevant(chunks), cost, nil
+}
+
+// embedRetrievalQuery embeds query with the embedding step of the route and
+// returns the vector with the cost of the call.
+func embedRetrievalQuery(rc *route.Route, req *route.Request, query string, e estimator, aoe azureEstimator) ([]float64, float64, error) {
+	data, err := json.Marshal(map[string]any{
+		"model": rc.Retrieval.EmbeddingStep.Model,
+		"input": query,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	forwarded := req.Forwarded.Clone(context.Background())
+	forwarded.Body = io.NopCloser(bytes.NewReader(data))
+	forwarded.ContentLength = int64(len(data))
+
+	runRes, err := rc.RetrievalRoute().RunSteps(&route.Request{
+		Settings:  req.Settings,
+		Key:       req.Key,
+		Client:    req.Client,
+		Egress:    req.Egress,
+		Forwarded: forwarded,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer runRes.Cancel()
+	defer runRes.Response.Body.Close()
+
+	body, err := io.ReadAll(runRes.Response.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if runRes.Response.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("embedding model responded with status %d: %s", runRes.Response.StatusCode, gjson.GetBytes(body, "error.message").Str)
+	}
+
+	outcome := newShadowOutcome(e, aoe, true, runRes.Provider, runRes.Model, runRes.Response.StatusCode, 0, body)
+
+	embedding := []float64{}
+	for _, v := range gjson.GetBytes(body, "data.0.embedding").Array() {
+		embedding = append(embedding, v.Float())
+	}
+
+	if len(embedding) == 0 {
+		return nil, outcome.CostInUsd, fmt.Errorf("embedding model returned an empty embedding")
+	}
+
+	return embedding, outcome.CostInUsd, nil
+}
@@ -1,15 +1,21 @@
 package proxy
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/guardrail"
 	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/pause"
 	"github.com/bricks-cloud/bricksllm/internal/provider"
 	"github.com/bricks-cloud/bricksllm/internal/route"
 	"github.com/bricks-cloud/bricksllm/internal/stats"
@@ -18,8 +24,21 @@ import (
 	"go.uber.org/zap"
 )
 
+// downgradedMetadataKey records the models a route request was downgraded
+// from.
+const downgradedMetadataKey = "bricksllm_downgraded_from"
+
+const (
+	// complexityHeader forces the steps of a route with complexity routing
+	// to the simple or complex ones.
+	complexityHeader           = "X-BricksLLM-Complexity"
+	complexityMetadataKey      = "bricksllm_complexity"
+	complexityScoreMetadataKey = "bricksllm_complexity_score"
+)
+
 type routeManager interface {
-	GetRouteFromMemDb(path string) *route.Route
+	GetRouteFromMemDb(tenantId, path string) *route.Route
+	GetCorsRouteFromMemDb(path string) *route.Route
 }
 
 type cache interface {
@@ -27,7 +46,7 @@ type cache interface {
 	GetBytes(key string) ([]byte, error)
 }
 
-func getRouteHandler(prod, private bool, rm routeManager, ca cache, aoe azureEstimator, e estimator, r recorder, client http.Client, log *zap.Logger, timeOut time.Duration) gin.HandlerFunc {
+func getRouteHandler(prod, private bool, rm routeManager, ca cache, aoe azureEstimator, e estimator, r recorder, sh shadowRecorder, jr judgeRecorder, client http.Client, egc *provider.EgressClients, dd *deduplicator, gc *guardrail.Client, log *zap.Logger, timeOut time.Duration) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		trueStart := time.Now()
 
@@ -53,7 +72,7 @@ func getRouteHandler(prod, private bool, rm routeManager, ca cache, aoe azureEst
 		rc, ok := raw.(*route.Route)
 		if !exists || !ok {
 			stats.Incr("bricksllm.proxy.get_route_handeler.route_config_not_found", tags, 1)
-			JSON(c, http.StatusNotFound, "[BricksLLM] route config not found")
+			JSONError(c, http.StatusNotFound, codeRouteNotFound, "[BricksLLM] route config not found", nil)
 			return
 		}
 
@@ -63,13 +82,31 @@ func getRouteHandler(prod, private bool, rm routeManager, ca cache, aoe azureEst
 		if shouldCache {
 			bytes, err := ca.GetBytes(cacheKey)
 			if err == nil && len(bytes) != 0 {
+				recordDecision(c, &event.Decision{
+					Stage:   event.DecisionStageCache,
+					Outcome: decisionOutcomeHit,
+				})
+
 				stats.Incr("bricksllm.proxy.get_route_handeler.success", nil, 1)
 				stats.Timing("bricksllm.proxy.get_route_handeler.success_latency", time.Now().Sub(trueStart), nil, 1)
 
 				c.Set("provider", "cached")
+				c.Set("costInUsd", c.GetFloat64("retrievalCostInUsd"))
+				setRouteResponseHeaders(c, rc, "", "cached", "hit", time.Since(trueStart))
 				c.Data(http.StatusOK, "application/json", bytes)
 				return
 			}
+
+			recordDecision(c, &event.Decision{
+				Stage:   event.DecisionStageCache,
+				Outcome: decisionOutcomeMiss,
+			})
+		}
+
+		if p, ok := c.Get("maintenance"); ok {
+			stats.Incr("bricksllm.proxy.get_route_handeler.maintenance_cache_miss", tags, 1)
+			writePausedResponse(c, p.(*pause.Pause))
+			return
 		}
 
 		raw, exists = c.Get("settings")
@@ -86,57 +123,198 @@ func getRouteHandler(prod, private bool, rm routeManager, ca cache, aoe azureEst
 		}
 
 		cid := c.GetString(correlationId)
-		start := time.Now()
-		runRes, err := rc.RunSteps(&route.Request{
+
+		var body []byte
+		shouldShadow := rc.Shadow != nil && rc.Shadow.ShouldShadow()
+		shouldCompress := rc.Compression != nil && !rc.ShouldRunEmbeddings()
+		shouldJudge := rc.Judge != nil && !rc.ShouldRunEmbeddings() && rc.Judge.ShouldJudge()
+		shouldPipeline := rc.Pipeline != nil && !rc.ShouldRunEmbeddings()
+		shouldGuard := rc.Guardrails != nil && !rc.ShouldRunEmbeddings()
+		if shouldShadow || rc.Dedup != nil || shouldCompress || shouldJudge || shouldPipeline || shouldGuard {
+			data, err := io.ReadAll(c.Request.Body)
+			if err != nil {
+				logError(log, "error when reading route request body", prod, cid, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read route request body")
+				return
+			}
+
+			body = data
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		req := &route.Request{
 			Settings:  settingsMap,
 			Key:       kc,
 			Client:    client,
+			Egress:    egc,
 			Forwarded: c.Request,
-		})
+		}
 
-		if err != nil {
-			if errors.Is(err, context.DeadlineExceeded) {
+		if shouldGuard && len(rc.Guardrails.Input) != 0 {
+			ccr := &goopenai.ChatCompletionRequest{}
+			if err := json.Unmarshal(body, ccr); err != nil {
+				logError(log, "error when unmarshalling route chat completion request", prod, cid, err)
+				JSON(c, http.StatusBadRequest, "[BricksLLM] request body is not a valid chat completion request")
+				return
+			}
+
+			if blocked := runGuardrails(c, gc, rc.Guardrails.Input, guardrail.StageInput, route.GuardrailInput(ccr)); blocked != nil {
+				stats.Incr("bricksllm.proxy.get_route_handeler.input_guardrail_blocked", tags, 1)
+				c.Set("costInUsd", c.GetFloat64("retrievalCostInUsd"))
+				rejectGuardrail(c, blocked)
+				return
+			}
+		}
+
+		// identical requests are coalesced by their original body since
+		// compressing with a model is not deterministic.
+		original := body
+		var compressed *compressionResult
+		if shouldCompress {
+			cr, err := compressPrompt(rc, req, body, e, aoe)
+			if err != nil {
+				stats.Incr("bricksllm.proxy.get_route_handeler.compress_prompt_error", tags, 1)
+				logError(log, "error when compressing route prompt", prod, cid, err)
+			}
+
+			if err == nil && cr != nil {
+				stats.Incr("bricksllm.proxy.get_route_handeler.prompt_compressed", tags, 1)
+
+				compressed = cr
+				body = cr.body
+				c.Request.Body = io.NopCloser(bytes.NewReader(body))
+				c.Request.ContentLength = int64(len(body))
+				c.Set("originalPromptTokens", cr.originalTokens)
+				c.Set("compressedPromptTokens", cr.compressedTokens)
+			}
+		}
+
+		// every request runs its own retrieval and pipeline so their calls
+		// are charged even when the steps are coalesced.
+		extraCost := c.GetFloat64("retrievalCostInUsd")
+		if shouldPipeline {
+			pr, err := runPipeline(rc, req, body, e, aoe)
+			if pr != nil {
+				extraCost += pr.costInUsd
+			}
+
+			if err != nil {
+				stats.Incr("bricksllm.proxy.get_route_handeler.run_pipeline_error", tags, 1)
+				logError(log, "error when running route pipeline", prod, cid, err)
+				c.Set("costInUsd", extraCost)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] cannot run route pipeline")
+				return
+			}
+
+			if pr.stopped {
+				stats.Incr("bricksllm.proxy.get_route_handeler.pipeline_stopped", tags, 1)
+
+				c.Set("provider", pr.provider)
+				c.Set("model", pr.model)
+				c.Set("costInUsd", extraCost)
+				setRouteResponseHeaders(c, rc, pr.model, pr.provider, "none", time.Since(trueStart))
+				c.JSON(http.StatusOK, newPipelineStopResponse(pr))
+				return
+			}
+
+			body = pr.body
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+			c.Request.ContentLength = int64(len(body))
+		}
+
+		run := func() *routeResult {
+			return runRoute(rc, req, log, prod, cid, tags)
+		}
+
+		var result *routeResult
+		shared := false
+		if rc.Dedup != nil {
+			// the call outlives the request starting it when that request
+			// is canceled while others wait for the result.
+			req.Forwarded = c.Request.Clone(context.Background())
+
+			var err error
+			result, shared, err = dd.do(c.Request.Context(), route.ComputeDedupKey(kc.KeyId, rc.Id, rc.Variant(), original), rc.Dedup.GetWindow(), run)
+			if err != nil {
+				stats.Incr("bricksllm.proxy.get_route_handeler.dedup_wait_canceled", tags, 1)
+				JSON(c, http.StatusRequestTimeout, "[BricksLLM] request canceled while waiting for identical request")
+				return
+			}
+
+			if shared {
+				stats.Incr("bricksllm.proxy.get_route_handeler.dedup_coalesced", tags, 1)
+				recordDecision(c, &event.Decision{
+					Stage:    event.DecisionStageDedup,
+					Outcome:  decisionOutcomeCoalesced,
+					Provider: result.provider,
+					Model:    result.model,
+					Status:   result.status,
+				})
+			}
+		} else {
+			result = run()
+		}
+
+		// coalesced requests did not call any provider themselves.
+		if !shared {
+			recordDecision(c, attemptDecisions(req.Attempts)...)
+		}
+
+		if result.err != nil {
+			c.Set("costInUsd", extraCost)
+
+			if errors.Is(result.err, context.DeadlineExceeded) {
 				stats.Incr("bricksllm.proxy.get_route_handeler.timeout", tags, 1)
-				logError(log, "running steps time out", prod, cid, err)
+				logError(log, "running steps time out", prod, cid, result.err)
 				JSON(c, http.StatusRequestTimeout, "[BricksLLM] request timeout")
 				return
 			}
 
+			if errors.Is(result.err, errMalformedUpstreamResponse) {
+				c.Set("provider", result.provider)
+				c.Set("model", result.model)
+				rejectMalformedResponse(c, result.provider)
+				return
+			}
+
 			stats.Incr("bricksllm.proxy.get_route_handeler.run_steps_error", tags, 1)
-			logError(log, "error when running steps", prod, cid, err)
+			logError(log, "error when running steps", prod, cid, result.err)
 			JSON(c, http.StatusInternalServerError, "[BricksLLM] cannot run route steps")
 			return
 		}
 
-		defer runRes.Cancel()
+		c.Set("model", result.model)
+		c.Set("settingId", result.settingId)
 
-		c.Set("model", runRes.Model)
-
-		res := runRes.Response
-		defer res.Body.Close()
+		// responses blocked by a guardrail are charged but neither cached
+		// nor returned.
+		var blocked *guardrail.Verdict
+		if shouldGuard && result.status == http.StatusOK {
+			blocked = runGuardrails(c, gc, rc.Guardrails.Output, guardrail.StageOutput, route.GuardrailOutput(result.original))
+		}
 
-		dur := time.Now().Sub(start)
-		stats.Timing("bricksllm.proxy.get_route_handeler.latency", dur, nil, 1)
+		if result.status == http.StatusOK && !rc.ShouldRunEmbeddings() {
+			c.Set("toolCallIds", responseToolCallIds(result.original))
+		}
 
-		bytes, err := io.ReadAll(res.Body)
-		if err != nil {
-			logError(log, "error when reading route response body", prod, cid, err)
-			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read route response body")
-			return
+		// only the request that made the upstream call is charged, stored
+		// in the cache and shadowed.
+		if shared {
+			c.Set("provider", result.provider)
 		}
 
-		if res.StatusCode == http.StatusOK {
+		if result.status == http.StatusOK && !shared {
 			stats.Incr("bricksllm.proxy.get_route_handeler.success", nil, 1)
-			stats.Timing("bricksllm.proxy.get_route_handeler.success_latency", dur, nil, 1)
+			stats.Timing("bricksllm.proxy.get_route_handeler.success_latency", result.latency, nil, 1)
 
-			if shouldCache && rc.CacheConfig != nil {
+			if shouldCache && rc.CacheConfig != nil && blocked == nil {
 				parsed, err := time.ParseDuration(rc.CacheConfig.Ttl)
 				if err != nil {
 					logError(log, "error when parsing cache config ttl", prod, cid, err)
 				}
 
 				if err == nil {
-					err := ca.StoreBytes(cacheKey, bytes, parsed)
+					err := ca.StoreBytes(cacheKey, result.body, parsed)
 					if err != nil {
 						logError(log, "error when storing cached response", prod, cid, err)
 					}
@@ -144,18 +322,34 @@ func getRouteHandler(prod, private bool, rm routeManager, ca cache, aoe azureEst
 
 			}
 
-			err := parseResult(c, ca, kc, rc.ShouldRunEmbeddings(), bytes, e, aoe, r, runRes.Model, runRes.Provider)
+			err := parseResult(c, ca, kc, rc.ShouldRunEmbeddings(), result.original, e, aoe, r, result.model, result.provider)
 			if err != nil {
 				logError(log, "error when parsing run steps result", prod, cid, err)
 			}
 		}
 
-		if res.StatusCode != http.StatusOK {
-			stats.Timing("bricksllm.proxy.get_azure_embeddings_handler.error_latency", dur, nil, 1)
+		// the compression model is charged to the request that made the
+		// upstream call even if the call failed.
+		if compressed != nil && compressed.costInUsd != 0 && !shared {
+			c.Set("costInUsd", c.GetFloat64("costInUsd")+compressed.costInUsd)
+		}
+
+		if extraCost != 0 {
+			c.Set("costInUsd", c.GetFloat64("costInUsd")+extraCost)
+		}
+
+		if blocked != nil {
+			stats.Incr("bricksllm.proxy.get_route_handeler.output_guardrail_blocked", tags, 1)
+			rejectGuardrail(c, blocked)
+			return
+		}
+
+		if result.status != http.StatusOK {
+			stats.Timing("bricksllm.proxy.get_azure_embeddings_handler.error_latency", result.latency, nil, 1)
 			stats.Incr("bricksllm.proxy.get_azure_embeddings_handler.error_response", nil, 1)
 
 			errorRes := &goopenai.ErrorResponse{}
-			err = json.Unmarshal(bytes, errorRes)
+			err := json.Unmarshal(result.body, errorRes)
 			if err != nil {
 				logError(log, "error when unmarshalling azure openai embedding error response body", prod, cid, err)
 			}
@@ -163,14 +357,138 @@ func getRouteHandler(prod, private bool, rm routeManager, ca cache, aoe azureEst
 			logOpenAiError(log, prod, cid, errorRes)
 		}
 
-		for name, values := range res.Header {
-			for _, value := range values {
-				c.Header(name, value)
-			}
+		copyResponseHeaders(c, result.header)
+
+		if result.transformed {
+			c.Writer.Header().Del("Content-Length")
+		}
+
+		cacheStatus := "none"
+		if shouldCache {
+			cacheStatus = "miss"
+		}
+
+		setRouteResponseHeaders(c, rc, result.model, result.provider, cacheStatus, time.Since(trueStart))
+
+		c.Data(result.status, "application/json", result.body)
+
+		if shouldShadow && !shared {
+			primary := newShadowOutcome(e, aoe, rc.ShouldRunEmbeddings(), result.provider, result.model, result.status, result.latency, result.original)
+
+			go runShadow(&shadowRequest{
+				route:    rc,
+				settings: settingsMap,
+				key:      kc,
+				client:   client,
+				egress:   egc,
+				request:  c.Request.Clone(context.Background()),
+				body:     body,
+				cid:      cid,
+				primary:  primary,
+			}, e, aoe, sh, log, prod)
+		}
+
+		if shouldJudge && result.status == http.StatusOK && !shared {
+			go runJudge(&judgeRequest{
+				route:    rc,
+				settings: settingsMap,
+				key:      kc,
+				client:   client,
+				egress:   egc,
+				request:  c.Request.Clone(context.Background()),
+				body:     original,
+				response: result.original,
+				eventId:  c.GetString("eventId"),
+				cid:      cid,
+			}, jr, log, prod)
+		}
+	}
+}
+
+// setRouteResponseHeaders adds the response headers configured on a route.
+func setRouteResponseHeaders(c *gin.Context, rc *route.Route, model, provider, cacheStatus string, latency time.Duration) {
+	if len(rc.ResponseHeaders) == 0 {
+		return
+	}
+
+	rendered := route.RenderResponseHeaders(rc.ResponseHeaders, map[string]string{
+		"model":       model,
+		"provider":    provider,
+		"cache":       cacheStatus,
+		"costInUsd":   strconv.FormatFloat(c.GetFloat64("costInUsd"), 'f', -1, 64),
+		"latencyInMs": strconv.FormatInt(latency.Milliseconds(), 10),
+		"route":       rc.Path,
+	})
+
+	for name, value := range rendered {
+		c.Header(name, value)
+	}
+}
+
+// runRoute runs the steps of a route and reads the response so that it can
+// be handed to every request coalesced by the deduplicator.
+func runRoute(rc *route.Route, req *route.Request, log *zap.Logger, prod bool, cid string, tags []string) *routeResult {
+	start := time.Now()
+	runRes, err := rc.RunSteps(req)
+	if err != nil {
+		return &routeResult{
+			err: err,
+		}
+	}
+
+	defer runRes.Cancel()
+
+	res := runRes.Response
+	defer res.Body.Close()
+
+	dur := time.Now().Sub(start)
+	stats.Timing("bricksllm.proxy.get_route_handeler.latency", dur, nil, 1)
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		logError(log, "error when reading route response body", prod, cid, err)
+		return &routeResult{
+			err: err,
+		}
+	}
+
+	// malformed responses are neither cached nor handed to coalesced
+	// requests.
+	if res.StatusCode == http.StatusOK && !isWellFormedResponse(data) {
+		return &routeResult{
+			err:      errMalformedUpstreamResponse,
+			provider: runRes.Provider,
+			model:    runRes.Model,
+		}
+	}
+
+	result := &routeResult{
+		status:    res.StatusCode,
+		header:    res.Header,
+		body:      data,
+		original:  data,
+		provider:  runRes.Provider,
+		model:     runRes.Model,
+		settingId: runRes.SettingId,
+		latency:   dur,
+	}
+
+	// the original response is kept for cost estimation since transforms
+	// are allowed to remove fields such as usage.
+	if res.StatusCode == http.StatusOK && !rc.ShouldRunEmbeddings() && len(rc.ResponseTransforms) != 0 {
+		transformed, err := route.ApplyResponseTransforms(rc.ResponseTransforms, data)
+		if err != nil {
+			stats.Incr("bricksllm.proxy.get_route_handeler.apply_response_transforms_error", tags, 1)
+			logError(log, "error when applying route response transforms", prod, cid, err)
 		}
 
-		c.Data(res.StatusCode, "application/json", bytes)
+		if err == nil {
+			result.body = transformed
+			result.transformed = true
+		}
 	}
+
+	return result
 }
 
 func parseResult(c *gin.Context, ca cache, kc *key.ResponseKey, runEmbeddings bool, bytes []byte, e estimator, aoe azureEstimator, r recorder, model, provider string) error {
@@ -271,3 +589,298 @@ func parseResult(c *gin.Context, ca cache, kc *key.ResponseKey, runEmbeddings bo
 
 	return nil
 }
+
+// getRouteMiddleware selects the steps of the route a request is sent to and
+// prepares the request body for them.
+func getRouteMiddleware(rm routeManager, v validator, ss sessionStorage, ptms promptTemplateMemStorage, ps pauseMemStorage, rt *retriever, e estimator, aoe azureEstimator, egc *provider.EgressClients, log *zap.Logger, prod, private bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		kc, ok := proxyKey(c)
+		if !ok || !strings.HasPrefix(c.FullPath(), "/api/routes") {
+			return
+		}
+
+		settings := proxySettings(c)
+		body := requestBody(c)
+		cid := c.GetString(correlationId)
+
+		r := c.Param("route")
+		rc := rm.GetRouteFromMemDb(kc.TenantId, r)
+
+		if rc == nil {
+			stats.Incr("bricksllm.proxy.get_middleware.route_config_not_found", nil, 1)
+			JSONError(c, http.StatusNotFound, codeRouteNotFound, "[BricksLLM] route config is not found", nil)
+			c.Abort()
+			return
+		}
+
+		if !allowRouteClient(c, rc, ss, log, prod, cid) {
+			return
+		}
+
+		cachePrefix := kc.TenantId + r
+		languageSelected := false
+		if lang := c.GetString("language"); len(lang) != 0 {
+			if selected, ok := rc.ForLanguage(lang); ok {
+				stats.Incr("bricksllm.proxy.get_middleware.route_language_selected", []string{
+					"language:" + lang,
+				}, 1)
+
+				rc = selected
+				cachePrefix += ":" + lang
+				languageSelected = true
+			}
+		}
+
+		if rc.Complexity != nil && !languageSelected {
+			score := route.ScoreComplexity(promptText(body))
+			selected, decision := rc.ForComplexity(score, strings.ToLower(c.GetHeader(complexityHeader)))
+			stats.Incr("bricksllm.proxy.get_middleware.route_complexity_selected", []string{
+				"complexity:" + decision,
+			}, 1)
+
+			rc = selected
+			cachePrefix += ":" + decision
+			c.Set("complexity", decision)
+			c.Set("complexityScore", score)
+		}
+
+		if rc.Downgrade != nil {
+			usage, err := v.GetBudgetUsage(kc)
+			if err != nil {
+				stats.Incr("bricksllm.proxy.get_middleware.get_budget_usage_error", nil, 1)
+				logError(log, "error when getting key budget usage", prod, cid, err)
+			}
+
+			if err == nil && usage*100 >= rc.Downgrade.BudgetPercentage {
+				downgraded, from := rc.Downgraded()
+				if len(from) != 0 {
+					stats.Incr("bricksllm.proxy.get_middleware.route_downgraded", nil, 1)
+					rc = downgraded
+					cachePrefix += ":downgraded"
+					c.Set("downgradedFrom", from)
+				}
+			}
+		}
+
+		p := ps.GetPause(pause.ScopeRoute, rc.Id)
+		if p == nil {
+			rc, p = withoutPausedSteps(rc, ps)
+		}
+
+		if p != nil {
+			if !p.Maintenance.ServesCache() || rc.CacheConfig == nil || !rc.CacheConfig.Enabled {
+				rejectPaused(c, p)
+				return
+			}
+
+			c.Set("maintenance", p)
+		}
+
+		rc = withoutUnsettledSteps(rc, settings)
+		c.Set("route_config", rc)
+
+		for _, step := range rc.Steps {
+			if !kc.ModelPolicy.IsAllowed(step.Model) {
+				stats.Incr("bricksllm.proxy.get_middleware.route_model_not_allowed", nil, 1)
+				JSONError(c, http.StatusForbidden, codeModelNotAllowed, fmt.Sprintf("[BricksLLM] model %s of route %s is not allowed", step.Model, r), map[string]interface{}{
+					"model": step.Model,
+					"route": r,
+				})
+				c.Abort()
+				return
+			}
+		}
+
+		if !rc.ShouldRunEmbeddings() && rc.Retrieval != nil {
+			settingsMap := map[string]*provider.Setting{}
+			for _, setting := range settings {
+				settingsMap[setting.Id] = setting
+			}
+
+			rr, err := rt.retrieve(rc, &route.Request{
+				Settings:  settingsMap,
+				Key:       kc,
+				Egress:    egc,
+				Forwarded: c.Request,
+			}, body, e, aoe)
+			if rr != nil {
+				c.Set("retrievalCostInUsd", rr.costInUsd)
+			}
+
+			if err != nil {
+				if _, ok := err.(validationError); ok {
+					stats.Incr("bricksllm.proxy.get_middleware.retrieval_request_error", nil, 1)
+					JSON(c, http.StatusBadRequest, "[BricksLLM] "+err.Error())
+					c.Abort()
+					return
+				}
+
+				stats.Incr("bricksllm.proxy.get_middleware.retrieval_error", nil, 1)
+				c.Set("costInUsd", c.GetFloat64("retrievalCostInUsd"))
+				logError(log, "error when retrieving route context", prod, cid, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to retrieve route context")
+				c.Abort()
+				return
+			}
+
+			stats.Timing("bricksllm.proxy.get_middleware.retrieval_latency", rr.latency, nil, 1)
+
+			c.Set("retrieved", true)
+			c.Set("retrievalLatency", int(rr.latency.Milliseconds()))
+			c.Set("retrievalChunkIds", rr.chunkIds)
+
+			body = rr.body
+			setRequestBody(c, body)
+		}
+
+		if !rc.ShouldRunEmbeddings() && rc.PromptTemplate != nil {
+			t := ptms.GetPromptTemplate(rc.PromptTemplate.Name, rc.PromptTemplate.Version)
+			if t == nil {
+				stats.Incr("bricksllm.proxy.get_middleware.prompt_template_not_found", nil, 1)
+				JSONError(c, http.StatusNotFound, codePromptTemplateNotFound, "[BricksLLM] prompt template is not found", nil)
+				c.Abort()
+				return
+			}
+
+			rendered, err := renderPromptTemplate(body, t)
+			if err != nil {
+				if _, ok := err.(validationError); ok {
+					stats.Incr("bricksllm.proxy.get_middleware.prompt_template_render_error", nil, 1)
+					JSON(c, http.StatusBadRequest, "[BricksLLM] "+err.Error())
+					c.Abort()
+					return
+				}
+
+				logError(log, "error when rendering prompt template", prod, cid, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to render prompt template")
+				c.Abort()
+				return
+			}
+
+			body = rendered
+			setRequestBody(c, body)
+		}
+
+		if !rc.ShouldRunEmbeddings() && !kc.Parameters.IsEmpty() {
+			applied, ok := applyKeyParameters(c, kc, body, log, prod, cid)
+			if !ok {
+				return
+			}
+
+			body = applied
+			setRequestBody(c, body)
+		}
+
+		if !rc.ShouldRunEmbeddings() && (kc.SystemPrompt != nil || rc.SystemPrompt != nil) {
+			enforced, err := enforceSystemPrompts(body, kc.SystemPrompt, rc.SystemPrompt)
+			if err != nil {
+				if _, ok := err.(validationError); ok {
+					stats.Incr("bricksllm.proxy.get_middleware.system_prompt_violation", nil, 1)
+					JSON(c, http.StatusBadRequest, "[BricksLLM] "+err.Error())
+					c.Abort()
+					return
+				}
+
+				logError(log, "error when enforcing route system prompt", prod, cid, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to enforce system prompt")
+				c.Abort()
+				return
+			}
+
+			body = enforced
+			setRequestBody(c, body)
+		}
+
+		if len(rc.RequestRules) != 0 {
+			transformed, err := route.ApplyRequestRules(rc.RequestRules, c.Request.Header, body)
+			if err != nil {
+				if _, ok := err.(validationError); ok {
+					stats.Incr("bricksllm.proxy.get_middleware.request_rules_error", nil, 1)
+					JSON(c, http.StatusBadRequest, "[BricksLLM] "+err.Error())
+					c.Abort()
+					return
+				}
+
+				logError(log, "error when applying route request rules", prod, cid, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to apply request rules")
+				c.Abort()
+				return
+			}
+
+			body = transformed
+			setRequestBody(c, body)
+		}
+
+		if !rc.ShouldRunEmbeddings() && rc.ContextWindow != nil {
+			selected, updated, decision, ok := applyContextWindow(c, rc, body, e, kc, settings, ps)
+			if !ok {
+				return
+			}
+
+			if len(decision) != 0 {
+				rc = selected
+				cachePrefix += ":" + decision
+				c.Set("route_config", rc)
+				c.Set("contextWindow", decision)
+
+				body = updated
+				setRequestBody(c, body)
+			}
+		}
+
+		if rc.ShouldRunEmbeddings() {
+			er := &goopenai.EmbeddingRequest{}
+			err := json.Unmarshal(body, er)
+			if err != nil {
+				logError(log, "error when unmarshalling route embedding request", prod, cid, err)
+				return
+			}
+
+			if rc.Embeddings != nil {
+				if err := rc.Embeddings.CheckDimensions(rc.Steps, er.Dimensions); err != nil {
+					stats.Incr("bricksllm.proxy.get_middleware.embedding_dimensions_mismatch", nil, 1)
+					JSONError(c, http.StatusBadRequest, codeEmbeddingDimensions, "[BricksLLM] "+err.Error(), map[string]interface{}{
+						"dimensions": rc.Embeddings.Dimensions,
+					})
+					c.Abort()
+					return
+				}
+			}
+
+			if rc.CacheConfig != nil && rc.CacheConfig.Enabled {
+				c.Set("cache_key", route.ComputeCacheKeyForEmbeddingsRequest(cachePrefix, er))
+			}
+
+			c.Set("encoding_format", string(er.EncodingFormat))
+			c.Set("userId", er.User)
+
+			logEmbeddingRequest(log, prod, private, cid, er)
+		}
+
+		if !rc.ShouldRunEmbeddings() {
+			ccr := &goopenai.ChatCompletionRequest{}
+
+			err := json.Unmarshal(body, ccr)
+			if err != nil {
+				logError(log, "error when unmarshalling route chat completion request", prod, cid, err)
+				return
+			}
+
+			c.Set("request", ccr)
+			c.Set("userId", ccr.User)
+
+			logRequest(log, prod, private, cid, ccr)
+
+			if ccr.Stream {
+				stats.Incr("bricksllm.proxy.get_middleware.streaming_not_allowed", nil, 1)
+				JSONError(c, http.StatusForbidden, codeStreamingNotAllowed, "[BricksLLM] streaming is not allowed", nil)
+				c.Abort()
+				return
+			}
+
+			if rc.CacheConfig != nil && rc.CacheConfig.Enabled {
+				c.Set("cache_key", route.ComputeCacheKeyForChatCompletionRequest(cachePrefix, ccr))
+			}
+		}
+	}
+}
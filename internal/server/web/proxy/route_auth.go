@@ -0,0 +1,53 @@
+package proxy
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// allowRouteClient limits the requests per client ip of routes with their
+// own auth, and the requests of every client together when the route sets
+// TotalRequestsPerMinute. Storage errors let requests through like other
+// redis backed limits of the proxy.
+func allowRouteClient(c *gin.Context, rc *route.Route, ss sessionStorage, log *zap.Logger, prod bool, cid string) bool {
+	if rc.Auth == nil {
+		return true
+	}
+
+	// requests rejected per client ip do not use up the limit of the route.
+	if !allowRouteRequests(c, ss, "route-auth:"+rc.Id+":"+c.ClientIP(), rc.Auth.RequestsPerMinute, log, prod, cid) {
+		return false
+	}
+
+	return allowRouteRequests(c, ss, "route-auth:"+rc.Id, rc.Auth.TotalRequestsPerMinute, log, prod, cid)
+}
+
+func allowRouteRequests(c *gin.Context, ss sessionStorage, counter string, limit int, log *zap.Logger, prod bool, cid string) bool {
+	if limit <= 0 {
+		return true
+	}
+
+	count, left, err := ss.IncrementRepeats(counter, time.Minute)
+	if err != nil {
+		stats.Incr("bricksllm.proxy.allow_route_client.increment_error", nil, 1)
+		logError(log, "error when counting route client requests", prod, cid, err)
+		return true
+	}
+
+	if count > int64(limit) {
+		stats.Incr("bricksllm.proxy.allow_route_client.rate_limited", nil, 1)
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(left.Seconds()))))
+		JSON(c, http.StatusTooManyRequests, "[BricksLLM] too many requests to this route")
+		c.Abort()
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,96 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/server/web"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeRepeatsStorage struct {
+	sessionStorage
+	counts map[string]int64
+}
+
+func (s *fakeRepeatsStorage) IncrementRepeats(fingerprint string, window time.Duration) (int64, time.Duration, error) {
+	s.counts[fingerprint]++
+	return s.counts[fingerprint], window, nil
+}
+
+func newRouteClientRouter(t *testing.T, rc *route.Route, ss sessionStorage, trustedProxies []string) *gin.Engine {
+	router, err := web.NewRouter(trustedProxies)
+	require.NoError(t, err)
+
+	router.POST("/api/routes/*route", func(c *gin.Context) {
+		if !allowRouteClient(c, rc, ss, zap.NewNop(), false, "") {
+			return
+		}
+
+		c.Status(http.StatusOK)
+	})
+
+	return router
+}
+
+func sendRouteRequest(router *gin.Engine, remoteAddr, forwardedFor string) int {
+	req := httptest.NewRequest(http.MethodPost, "/api/routes/public", nil)
+	req.RemoteAddr = remoteAddr
+	if len(forwardedFor) != 0 {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	return w.Code
+}
+
+func TestAllowRouteClient(t *testing.T) {
+	t.Run("changing X-Forwarded-For does not reset the limit of a client", func(t *testing.T) {
+		rc := &route.Route{Id: "r", Auth: &route.Auth{Mode: route.AuthModePublic, RequestsPerMinute: 2}}
+		router := newRouteClientRouter(t, rc, &fakeRepeatsStorage{counts: map[string]int64{}}, nil)
+
+		assert.Equal(t, http.StatusOK, sendRouteRequest(router, "203.0.113.7:4000", "1.1.1.1"))
+		assert.Equal(t, http.StatusOK, sendRouteRequest(router, "203.0.113.7:4000", "2.2.2.2"))
+		assert.Equal(t, http.StatusTooManyRequests, sendRouteRequest(router, "203.0.113.7:4000", "3.3.3.3"))
+		assert.Equal(t, http.StatusOK, sendRouteRequest(router, "198.51.100.2:4000", ""))
+	})
+
+	t.Run("clients behind trusted proxies are told apart by X-Forwarded-For", func(t *testing.T) {
+		rc := &route.Route{Id: "r", Auth: &route.Auth{Mode: route.AuthModePublic, RequestsPerMinute: 1}}
+		router := newRouteClientRouter(t, rc, &fakeRepeatsStorage{counts: map[string]int64{}}, []string{"10.0.0.0/8"})
+
+		assert.Equal(t, http.StatusOK, sendRouteRequest(router, "10.0.0.1:4000", "203.0.113.7"))
+		assert.Equal(t, http.StatusTooManyRequests, sendRouteRequest(router, "10.0.0.2:4000", "203.0.113.7"))
+		assert.Equal(t, http.StatusOK, sendRouteRequest(router, "10.0.0.1:4000", "198.51.100.2"))
+	})
+
+	t.Run("the total limit caps every client together", func(t *testing.T) {
+		rc := &route.Route{Id: "r", Auth: &route.Auth{Mode: route.AuthModePublic, RequestsPerMinute: 1, TotalRequestsPerMinute: 2}}
+		ss := &fakeRepeatsStorage{counts: map[string]int64{}}
+		router := newRouteClientRouter(t, rc, ss, nil)
+
+		assert.Equal(t, http.StatusOK, sendRouteRequest(router, "203.0.113.1:4000", ""))
+		assert.Equal(t, http.StatusTooManyRequests, sendRouteRequest(router, "203.0.113.1:4000", ""))
+		assert.Equal(t, http.StatusOK, sendRouteRequest(router, "203.0.113.2:4000", ""))
+		assert.Equal(t, http.StatusTooManyRequests, sendRouteRequest(router, "203.0.113.3:4000", ""))
+
+		// requests rejected per client do not use up the total limit.
+		assert.Equal(t, int64(3), ss.counts["route-auth:r"])
+	})
+
+	t.Run("routes without auth are not limited", func(t *testing.T) {
+		router := newRouteClientRouter(t, &route.Route{Id: "r"}, &fakeRepeatsStorage{counts: map[string]int64{}}, nil)
+
+		for i := 0; i < 5; i++ {
+			assert.Equal(t, http.StatusOK, sendRouteRequest(router, "203.0.113.7:4000", ""))
+		}
+	})
+}
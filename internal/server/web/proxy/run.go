@@ -3,6 +3,7 @@ package proxy
 import (
 	"encoding/json"
 
+	"github.com/bricks-cloud/bricksllm/internal/logger"
 	goopenai "github.com/sashabaranov/go-openai"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -18,7 +19,7 @@ func logCreateRunRequest(log *zap.Logger, data []byte, prod bool, cid string) {
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("assistant_id", rr.AssistantID),
 			zap.Stringp("instruction", rr.Instructions),
 			zap.Stringp("model", rr.Model),
@@ -40,7 +41,7 @@ func logRunResponse(log *zap.Logger, data []byte, prod, private bool, cid string
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("id", r.ID),
 			zap.String("object", r.Object),
 			zap.Int64("created_at", r.CreatedAt),
@@ -71,7 +72,7 @@ func logRunResponse(log *zap.Logger, data []byte, prod, private bool, cid string
 func logRetrieveRunRequest(log *zap.Logger, data []byte, prod bool, cid, tid, rid string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("thread_id", tid),
 			zap.String("run_id", rid),
 		}
@@ -90,7 +91,7 @@ func logModifyRunRequest(log *zap.Logger, data []byte, prod bool, cid, tid, rid
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("thread_id", tid),
 			zap.String("run_id", rid),
 			zap.Any("metadata", rr.Metadata),
@@ -103,7 +104,7 @@ func logModifyRunRequest(log *zap.Logger, data []byte, prod bool, cid, tid, rid
 func logListRunsRequest(log *zap.Logger, data []byte, prod bool, cid, tid string, params map[string]string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("thread_id", tid),
 		}
 
@@ -143,7 +144,7 @@ func logListRunsResponse(log *zap.Logger, data []byte, prod, private bool, cid s
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.Any("runs", r.Runs),
 		}
 
@@ -161,7 +162,7 @@ func logSubmitToolOutputsRequest(log *zap.Logger, data []byte, prod bool, cid, t
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("thread_id", tid),
 			zap.String("run_id", rid),
 			zap.Any("tool_outputs", r.ToolOutputs),
@@ -174,7 +175,7 @@ func logSubmitToolOutputsRequest(log *zap.Logger, data []byte, prod bool, cid, t
 func logCancelARunRequest(log *zap.Logger, data []byte, prod bool, cid, tid, rid string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("thread_id", tid),
 			zap.String("run_id", rid),
 		}
@@ -193,7 +194,7 @@ func logCreateThreadAndRunRequest(log *zap.Logger, data []byte, prod, private bo
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("assistant_id", r.AssistantID),
 			zap.Any("thread", r.Thread),
 			zap.Stringp("model", r.Model),
@@ -212,7 +213,7 @@ func logCreateThreadAndRunRequest(log *zap.Logger, data []byte, prod, private bo
 func logRetrieveRunStepRequest(log *zap.Logger, data []byte, prod bool, cid, tid, rid, sid string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("thread_id", tid),
 			zap.String("run_id", rid),
 			zap.String("step_id", sid),
@@ -232,7 +233,7 @@ func logRetrieveRunStepResponse(log *zap.Logger, data []byte, prod bool, cid str
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("id", rs.ID),
 			zap.String("object", rs.Object),
 			zap.Int64("created_at", rs.CreatedAt),
@@ -257,7 +258,7 @@ func logRetrieveRunStepResponse(log *zap.Logger, data []byte, prod bool, cid str
 func logListRunStepsRequest(log *zap.Logger, data []byte, prod bool, cid, tid, rid string, params map[string]string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("thread_id", tid),
 			zap.String("run_id", rid),
 		}
@@ -292,7 +293,7 @@ func logListRunStepsResponse(log *zap.Logger, data []byte, prod bool, cid string
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.Any("run_steps", rsl.RunSteps),
 		}
 
@@ -0,0 +1,106 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+)
+
+var errMalformedUpstreamResponse = errors.New("upstream response is not well formed json")
+
+// hopByHopHeaders only apply to the connection between the proxy and a
+// provider and are never relayed to clients.
+var hopByHopHeaders = map[string]bool{
+	"Connection":          true,
+	"Keep-Alive":          true,
+	"Proxy-Authenticate":  true,
+	"Proxy-Authorization": true,
+	"Proxy-Connection":    true,
+	"Te":                  true,
+	"Trailer":             true,
+	"Transfer-Encoding":   true,
+	"Upgrade":             true,
+}
+
+// isRelayedHeader reports whether a header of a provider response is relayed
+// to clients. Cookies of providers are dropped along with hop-by-hop headers,
+// including the ones listed by the Connection header.
+func isRelayedHeader(header http.Header, name string) bool {
+	name = http.CanonicalHeaderKey(name)
+	if hopByHopHeaders[name] || name == "Set-Cookie" || name == "Set-Cookie2" {
+		return false
+	}
+
+	for _, value := range header.Values("Connection") {
+		for _, listed := range strings.Split(value, ",") {
+			if http.CanonicalHeaderKey(strings.TrimSpace(listed)) == name {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// normalizeContentType lowercases the media type of a content type and drops
+// content types that cannot be parsed.
+func normalizeContentType(value string) string {
+	mediaType, params, err := mime.ParseMediaType(value)
+	if err != nil {
+		return ""
+	}
+
+	return mime.FormatMediaType(mediaType, params)
+}
+
+// copyResponseHeaders relays the headers of a provider response to the
+// client after sanitizing them.
+func copyResponseHeaders(c *gin.Context, header http.Header) {
+	for name, values := range header {
+		if !isRelayedHeader(header, name) {
+			continue
+		}
+
+		for _, value := range values {
+			if http.CanonicalHeaderKey(name) == "Content-Type" {
+				value = normalizeContentType(value)
+				if len(value) == 0 {
+					continue
+				}
+			}
+
+			c.Header(name, value)
+		}
+	}
+}
+
+// isWellFormedResponse reports whether the body of a successful provider
+// response can be relayed and cached. Every provider answers non streaming
+// requests with json.
+func isWellFormedResponse(body []byte) bool {
+	return json.Valid(body)
+}
+
+// rejectMalformedResponse answers a request with 502 instead of relaying a
+// successful provider response that is not well formed.
+func rejectMalformedResponse(c *gin.Context, provider string) {
+	stats.Incr("bricksllm.proxy.reject_malformed_response.requests", []string{
+		"provider:" + provider,
+	}, 1)
+
+	c.Set("upstreamError", errMalformedUpstreamResponse.Error())
+
+	// headers describing the body of the provider do not apply to the error.
+	c.Writer.Header().Del("Content-Length")
+	c.Writer.Header().Del("Content-Encoding")
+	c.Writer.Header().Del("Content-Type")
+
+	JSONError(c, http.StatusBadGateway, codeMalformedUpstreamResponse, "[BricksLLM] provider returned a malformed response", map[string]interface{}{
+		"provider": provider,
+	})
+}
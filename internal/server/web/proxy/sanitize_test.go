@@ -0,0 +1,104 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopyResponseHeaders(t *testing.T) {
+	cases := []struct {
+		name    string
+		header  http.Header
+		relayed map[string]string
+		dropped []string
+	}{
+		{
+			name:    "headers of providers are relayed",
+			header:  http.Header{"X-Request-Id": {"req-1"}, "Openai-Processing-Ms": {"120"}},
+			relayed: map[string]string{"X-Request-Id": "req-1", "Openai-Processing-Ms": "120"},
+		},
+		{
+			name:    "hop-by-hop headers are dropped",
+			header:  http.Header{"Connection": {"keep-alive"}, "Keep-Alive": {"timeout=5"}, "Transfer-Encoding": {"chunked"}, "Upgrade": {"h2c"}},
+			dropped: []string{"Connection", "Keep-Alive", "Transfer-Encoding", "Upgrade"},
+		},
+		{
+			name:    "headers listed by the connection header are dropped",
+			header:  http.Header{"Connection": {"close, x-internal-trace"}, "X-Internal-Trace": {"abc"}, "X-Request-Id": {"req-1"}},
+			relayed: map[string]string{"X-Request-Id": "req-1"},
+			dropped: []string{"X-Internal-Trace"},
+		},
+		{
+			name:    "cookies are dropped",
+			header:  http.Header{"Set-Cookie": {"session=abc"}, "Set-Cookie2": {"session=abc"}},
+			dropped: []string{"Set-Cookie", "Set-Cookie2"},
+		},
+		{
+			name:    "content types are normalized",
+			header:  http.Header{"Content-Type": {"Application/JSON; charset=utf-8"}},
+			relayed: map[string]string{"Content-Type": "application/json; charset=utf-8"},
+		},
+		{
+			name:    "content types that cannot be parsed are dropped",
+			header:  http.Header{"Content-Type": {"application/json; charset"}},
+			dropped: []string{"Content-Type"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			copyResponseHeaders(c, tc.header)
+
+			for name, value := range tc.relayed {
+				assert.Equal(t, value, w.Header().Get(name), name)
+			}
+
+			for _, name := range tc.dropped {
+				assert.Empty(t, w.Header().Values(name), name)
+			}
+		})
+	}
+}
+
+func TestIsWellFormedResponse(t *testing.T) {
+	cases := []struct {
+		name     string
+		body     string
+		expected bool
+	}{
+		{name: "json objects", body: `{"id":"chatcmpl-1"}`, expected: true},
+		{name: "truncated json", body: `{"id":"chatcmpl-1"`, expected: false},
+		{name: "html error pages", body: `<html><body>Bad Gateway</body></html>`, expected: false},
+		{name: "empty bodies", body: ``, expected: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, isWellFormedResponse([]byte(tc.body)))
+		})
+	}
+}
+
+func TestRejectMalformedResponse(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	c.Header("Content-Length", "42")
+	c.Header("Content-Encoding", "gzip")
+	c.Header("Content-Type", "text/html")
+
+	rejectMalformedResponse(c, "openai")
+
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+	assert.Contains(t, w.Body.String(), codeMalformedUpstreamResponse)
+	assert.Equal(t, errMalformedUpstreamResponse.Error(), c.GetString("upstreamError"))
+}
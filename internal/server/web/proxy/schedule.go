@@ -0,0 +1,184 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	auth "github.com/bricks-cloud/bricksllm/internal/authenticator"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/schedule"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"go.uber.org/zap"
+)
+
+type ScheduleManager interface {
+	GetDueSchedules(now time.Time) ([]*schedule.Schedule, error)
+	ClaimSchedule(s *schedule.Schedule, now time.Time) (bool, error)
+	GetScheduleKey(s *schedule.Schedule) (*key.ResponseKey, error)
+	CreateScheduleRun(r *schedule.Run) error
+}
+
+type deliverer interface {
+	Deliver(s *schedule.Schedule, r *schedule.Result, data []byte) (string, error)
+}
+
+// scheduleRunner sends the requests of due schedules through the proxy
+// itself, authenticated with the hash of their key, so that runs are limited
+// and recorded like any other request of the key.
+type scheduleRunner struct {
+	handler  http.Handler
+	sm       ScheduleManager
+	d        deliverer
+	interval time.Duration
+	done     chan bool
+	log      *zap.Logger
+	prod     bool
+	now      func() time.Time
+}
+
+func newScheduleRunner(sm ScheduleManager, d deliverer, interval time.Duration, log *zap.Logger, prod bool) *scheduleRunner {
+	return &scheduleRunner{
+		sm:       sm,
+		d:        d,
+		interval: interval,
+		done:     make(chan bool),
+		log:      log,
+		prod:     prod,
+		now:      time.Now,
+	}
+}
+
+func (sr *scheduleRunner) start(handler http.Handler) {
+	sr.handler = handler
+	ticker := time.NewTicker(sr.interval)
+
+	go func() {
+		for {
+			select {
+			case <-sr.done:
+				ticker.Stop()
+				sr.log.Info("schedule runner stopped")
+				return
+			case <-ticker.C:
+				sr.poll()
+			}
+		}
+	}()
+}
+
+func (sr *scheduleRunner) stop() {
+	sr.done <- true
+}
+
+func (sr *scheduleRunner) poll() {
+	now := sr.now()
+	schedules, err := sr.sm.GetDueSchedules(now)
+	if err != nil {
+		stats.Incr("bricksllm.proxy.schedule_runner.get_due_schedules_error", nil, 1)
+		sr.log.Sugar().Debugf("error when getting due schedules: %v", err)
+		return
+	}
+
+	for _, s := range schedules {
+		claimed, err := sr.sm.ClaimSchedule(s, now)
+		if err != nil {
+			stats.Incr("bricksllm.proxy.schedule_runner.claim_schedule_error", nil, 1)
+			logError(sr.log, "error when claiming schedule", sr.prod, s.Id, err)
+			continue
+		}
+
+		if claimed {
+			go sr.run(s)
+		}
+	}
+}
+
+func (sr *scheduleRunner) run(s *schedule.Schedule) {
+	stats.Incr("bricksllm.proxy.schedule_runner.run.requests", nil, 1)
+
+	r := &schedule.Run{
+		Id:         util.NewUuid(),
+		ScheduleId: s.Id,
+		StartedAt:  sr.now().Unix(),
+	}
+
+	if err := sr.send(s, r); err != nil {
+		r.Status = schedule.RunStatusFailed
+		r.Error = err.Error()
+	}
+
+	r.FinishedAt = sr.now().Unix()
+
+	stats.Incr("bricksllm.proxy.schedule_runner.run.finished", []string{"status:" + string(r.Status)}, 1)
+
+	if err := sr.sm.CreateScheduleRun(r); err != nil {
+		stats.Incr("bricksllm.proxy.schedule_runner.run.create_schedule_run_error", nil, 1)
+		logError(sr.log, "error when creating schedule run", sr.prod, s.Id, err)
+	}
+}
+
+func (sr *scheduleRunner) send(s *schedule.Schedule, r *schedule.Run) error {
+	k, err := sr.sm.GetScheduleKey(s)
+	if err != nil {
+		return err
+	}
+
+	ctx := withVerifiedSignature(auth.WithKeyHash(context.Background(), k.Key))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/api/routes"+s.RoutePath, bytes.NewReader(s.Request))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-BricksLLM-Metadata", fmt.Sprintf(`{"bricksllm_schedule_id":%q}`, s.Id))
+
+	w := &jobResponseWriter{
+		header: http.Header{},
+	}
+
+	sr.handler.ServeHTTP(w, req)
+
+	r.StatusCode = w.status
+	r.RequestId = w.header.Get(requestIdHeader)
+
+	if w.truncated {
+		return fmt.Errorf("response is too large to be delivered")
+	}
+
+	result := &schedule.Result{
+		ScheduleId: s.Id,
+		RunId:      r.Id,
+		RequestId:  r.RequestId,
+		StatusCode: w.status,
+		RanAt:      r.StartedAt,
+	}
+
+	result.Response = w.body.Bytes()
+	if !json.Valid(result.Response) {
+		data, _ := json.Marshal(w.body.String())
+		result.Response = data
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	r.Location, err = sr.d.Deliver(s, result, data)
+	if err != nil {
+		return err
+	}
+
+	if w.status < 200 || w.status >= 300 {
+		return fmt.Errorf("request failed with status %d", w.status)
+	}
+
+	r.Status = schedule.RunStatusCompleted
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package proxy
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/schedule"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// fakeScheduleManager stores the next runs of schedules like the database
+// and records the times schedules were claimed at.
+type fakeScheduleManager struct {
+	ScheduleManager
+	schedules []*schedule.Schedule
+	claims    []time.Time
+}
+
+func (m *fakeScheduleManager) GetDueSchedules(now time.Time) ([]*schedule.Schedule, error) {
+	due := []*schedule.Schedule{}
+	for _, s := range m.schedules {
+		if s.NextRunAt != 0 && s.NextRunAt <= now.Unix() {
+			copied := *s
+			due = append(due, &copied)
+		}
+	}
+
+	return due, nil
+}
+
+func (m *fakeScheduleManager) ClaimSchedule(s *schedule.Schedule, now time.Time) (bool, error) {
+	for _, stored := range m.schedules {
+		if stored.Id == s.Id && stored.NextRunAt == s.NextRunAt {
+			stored.NextRunAt = s.NextRun(now)
+			m.claims = append(m.claims, now.UTC())
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (m *fakeScheduleManager) GetScheduleKey(s *schedule.Schedule) (*key.ResponseKey, error) {
+	return nil, errors.New("key is not found")
+}
+
+func (m *fakeScheduleManager) CreateScheduleRun(r *schedule.Run) error {
+	return nil
+}
+
+// pollUntil polls every interval from start until end with the clock of the
+// runner set to the time of the poll.
+func pollUntil(sr *scheduleRunner, start, end time.Time, interval time.Duration) {
+	var lock sync.Mutex
+	current := start
+	sr.now = func() time.Time {
+		lock.Lock()
+		defer lock.Unlock()
+
+		return current
+	}
+
+	for !current.After(end) {
+		sr.poll()
+
+		lock.Lock()
+		current = current.Add(interval)
+		lock.Unlock()
+	}
+}
+
+func TestScheduleRunner_Poll(t *testing.T) {
+	cases := []struct {
+		name     string
+		cron     string
+		timezone string
+		start    time.Time
+		end      time.Time
+		interval time.Duration
+		expected []time.Time
+	}{
+		{
+			name:     "runs are claimed when they are due",
+			cron:     "0 */6 * * *",
+			start:    time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC),
+			end:      time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC),
+			interval: 10 * time.Minute,
+			expected: []time.Time{
+				time.Date(2024, 1, 1, 6, 0, 0, 0, time.UTC),
+				time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+				time.Date(2024, 1, 1, 18, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:     "late polls claim the missed run once",
+			cron:     "0 * * * *",
+			start:    time.Date(2024, 1, 1, 0, 30, 0, 0, time.UTC),
+			end:      time.Date(2024, 1, 1, 6, 0, 0, 0, time.UTC),
+			interval: 5 * time.Hour,
+			expected: []time.Time{
+				time.Date(2024, 1, 1, 5, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:     "skipped times run once when clocks are turned forward",
+			cron:     "30 2 * * *",
+			timezone: "America/New_York",
+			start:    time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC),
+			end:      time.Date(2024, 3, 11, 12, 0, 0, 0, time.UTC),
+			interval: 10 * time.Minute,
+			expected: []time.Time{
+				time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC),
+				time.Date(2024, 3, 11, 6, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:     "repeated times run once when clocks are turned back",
+			cron:     "30 1 * * *",
+			timezone: "America/New_York",
+			start:    time.Date(2024, 11, 2, 12, 0, 0, 0, time.UTC),
+			end:      time.Date(2024, 11, 4, 12, 0, 0, 0, time.UTC),
+			interval: 10 * time.Minute,
+			expected: []time.Time{
+				time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC),
+				time.Date(2024, 11, 4, 6, 30, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &schedule.Schedule{Id: "s1", Cron: tc.cron, Timezone: tc.timezone}
+			s.NextRunAt = s.NextRun(tc.start)
+
+			sm := &fakeScheduleManager{schedules: []*schedule.Schedule{s}}
+			sr := newScheduleRunner(sm, nil, time.Minute, zap.NewNop(), false)
+
+			pollUntil(sr, tc.start, tc.end, tc.interval)
+
+			assert.Equal(t, tc.expected, sm.claims)
+		})
+	}
+
+	t.Run("paused schedules are not claimed", func(t *testing.T) {
+		s := &schedule.Schedule{Id: "s1", Cron: "* * * * *", Paused: true}
+		start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		s.NextRunAt = s.NextRun(start)
+
+		sm := &fakeScheduleManager{schedules: []*schedule.Schedule{s}}
+		sr := newScheduleRunner(sm, nil, time.Minute, zap.NewNop(), false)
+
+		pollUntil(sr, start, start.Add(time.Hour), time.Minute)
+
+		assert.Empty(t, sm.claims)
+	})
+}
@@ -0,0 +1,131 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type SelfServiceManager interface {
+	GetUsage(k *key.ResponseKey) (*key.Usage, error)
+	RotateKey(k *key.ResponseKey) (*key.RotatedKey, error)
+	SetPersonalCostLimit(k *key.ResponseKey, pcl *key.PersonalCostLimit) (*key.Usage, error)
+}
+
+func isSelfServicePath(fullPath string) bool {
+	return strings.HasPrefix(fullPath, "/api/self")
+}
+
+// getSelfServiceMiddleware authenticates owners of keys with the key itself.
+func getSelfServiceMiddleware(a authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(correlationId, util.NewUuid())
+
+		kc, err := a.AuthenticateKey(c.Request)
+		if err != nil {
+			stats.Incr("bricksllm.proxy.get_self_service_middleware.authentication_error", nil, 1)
+			JSON(c, http.StatusUnauthorized, "[BricksLLM] not authorized")
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read request body")
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// a leaked key alone must not be enough to rotate the key or change
+		// its limits.
+		if !verifySignature(c, kc, body) {
+			return
+		}
+
+		c.Set("key", kc)
+	}
+}
+
+func getSelfKey(c *gin.Context) *key.ResponseKey {
+	raw, _ := c.Get("key")
+	kc, _ := raw.(*key.ResponseKey)
+	return kc
+}
+
+func getGetSelfUsageHandler(ssm SelfServiceManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.proxy.get_get_self_usage_handler.requests", nil, 1)
+
+		cid := c.GetString(correlationId)
+		u, err := ssm.GetUsage(getSelfKey(c))
+		if err != nil {
+			stats.Incr("bricksllm.proxy.get_get_self_usage_handler.get_usage_error", nil, 1)
+			logError(log, "error when getting key usage", prod, cid, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to get key usage")
+			return
+		}
+
+		c.JSON(http.StatusOK, u)
+	}
+}
+
+func getRotateSelfKeyHandler(ssm SelfServiceManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.proxy.get_rotate_self_key_handler.requests", nil, 1)
+
+		cid := c.GetString(correlationId)
+		rotated, err := ssm.RotateKey(getSelfKey(c))
+		if err != nil {
+			stats.Incr("bricksllm.proxy.get_rotate_self_key_handler.rotate_key_error", nil, 1)
+			logError(log, "error when rotating key", prod, cid, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to rotate key")
+			return
+		}
+
+		c.JSON(http.StatusOK, rotated)
+	}
+}
+
+func getSetPersonalCostLimitHandler(ssm SelfServiceManager, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats.Incr("bricksllm.proxy.get_set_personal_cost_limit_handler.requests", nil, 1)
+
+		cid := c.GetString(correlationId)
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			logError(log, "error when reading personal cost limit request body", prod, cid, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read request body")
+			return
+		}
+
+		pcl := &key.PersonalCostLimit{}
+		if err := json.Unmarshal(data, pcl); err != nil {
+			JSON(c, http.StatusBadRequest, "[BricksLLM] request body must be a json object")
+			return
+		}
+
+		u, err := ssm.SetPersonalCostLimit(getSelfKey(c), pcl)
+		if err != nil {
+			if _, ok := err.(validationError); ok {
+				JSON(c, http.StatusBadRequest, "[BricksLLM] "+err.Error())
+				return
+			}
+
+			stats.Incr("bricksllm.proxy.get_set_personal_cost_limit_handler.set_personal_cost_limit_error", nil, 1)
+			logError(log, "error when setting personal cost limit", prod, cid, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to set personal cost limit")
+			return
+		}
+
+		c.JSON(http.StatusOK, u)
+	}
+}
@@ -0,0 +1,110 @@
+package proxy
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/server/web"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeKeyAuthenticator struct {
+	authenticator
+	kc       *key.ResponseKey
+	settings []*provider.Setting
+	err      error
+}
+
+func (a *fakeKeyAuthenticator) AuthenticateKey(req *http.Request) (*key.ResponseKey, error) {
+	return a.kc, a.err
+}
+
+func (a *fakeKeyAuthenticator) AuthenticateHttpRequest(req *http.Request) (*key.ResponseKey, []*provider.Setting, error) {
+	return a.kc, a.settings, a.err
+}
+
+func newSelfServiceRouter(t *testing.T, kc *key.ResponseKey) (*gin.Engine, *[]byte) {
+	router, err := web.NewRouter(nil)
+	require.NoError(t, err)
+
+	received := []byte{}
+	self := router.Group("/api/self", getSelfServiceMiddleware(&fakeKeyAuthenticator{kc: kc}))
+	self.POST("/rotate", func(c *gin.Context) {
+		buf := new(bytes.Buffer)
+		buf.ReadFrom(c.Request.Body)
+		received = buf.Bytes()
+
+		c.Status(http.StatusOK)
+	})
+	self.PUT("/personal-cost-limit", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	return router, &received
+}
+
+func sendSigned(router *gin.Engine, s *key.Signing, method, signedUri, sentUri string, signedBody, sentBody []byte, at time.Time) int {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+
+	req := httptest.NewRequest(method, sentUri, bytes.NewReader(sentBody))
+	if s != nil {
+		req.Header.Set(timestampHeader, timestamp)
+		req.Header.Set(signatureHeader, s.Sign(timestamp, method, signedUri, signedBody))
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	return w.Code
+}
+
+func TestSelfServiceMiddleware_Signing(t *testing.T) {
+	s := &key.Signing{Secret: "0123456789abcdef0123456789abcdef"}
+	body := []byte(`{"costLimitInUsd":10}`)
+
+	t.Run("rotating a signing key without a signature is rejected", func(t *testing.T) {
+		router, _ := newSelfServiceRouter(t, &key.ResponseKey{KeyId: "k", Signing: s})
+
+		assert.Equal(t, http.StatusUnauthorized, sendSigned(router, nil, http.MethodPost, "", "/api/self/rotate", nil, nil, time.Now()))
+	})
+
+	t.Run("signed requests reach the handler with their body", func(t *testing.T) {
+		router, received := newSelfServiceRouter(t, &key.ResponseKey{KeyId: "k", Signing: s})
+
+		assert.Equal(t, http.StatusOK, sendSigned(router, s, http.MethodPost, "/api/self/rotate", "/api/self/rotate", body, body, time.Now()))
+		assert.Equal(t, body, *received)
+	})
+
+	t.Run("stale signatures are rejected", func(t *testing.T) {
+		router, _ := newSelfServiceRouter(t, &key.ResponseKey{KeyId: "k", Signing: s})
+
+		assert.Equal(t, http.StatusUnauthorized, sendSigned(router, s, http.MethodPost, "/api/self/rotate", "/api/self/rotate", nil, nil, time.Now().Add(-10*time.Minute)))
+	})
+
+	t.Run("tampered bodies are rejected", func(t *testing.T) {
+		router, _ := newSelfServiceRouter(t, &key.ResponseKey{KeyId: "k", Signing: s})
+
+		assert.Equal(t, http.StatusUnauthorized, sendSigned(router, s, http.MethodPut, "/api/self/personal-cost-limit", "/api/self/personal-cost-limit", body, []byte(`{"costLimitInUsd":1000}`), time.Now()))
+	})
+
+	t.Run("tampered query strings are rejected", func(t *testing.T) {
+		router, _ := newSelfServiceRouter(t, &key.ResponseKey{KeyId: "k", Signing: s})
+
+		assert.Equal(t, http.StatusUnauthorized, sendSigned(router, s, http.MethodPost, "/api/self/rotate?reason=a", "/api/self/rotate?reason=b", nil, nil, time.Now()))
+		assert.Equal(t, http.StatusOK, sendSigned(router, s, http.MethodPost, "/api/self/rotate?reason=a", "/api/self/rotate?reason=a", nil, nil, time.Now()))
+	})
+
+	t.Run("keys without signing do not need a signature", func(t *testing.T) {
+		router, _ := newSelfServiceRouter(t, &key.ResponseKey{KeyId: "k"})
+
+		assert.Equal(t, http.StatusOK, sendSigned(router, nil, http.MethodPost, "", "/api/self/rotate", nil, nil, time.Now()))
+	})
+}
@@ -0,0 +1,42 @@
+package proxy
+
+import (
+	"net/http"
+
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// getSessionLimitsMiddleware rejects requests of sessions that used up the
+// tokens or the cost the session limits of their key allow.
+func getSessionLimitsMiddleware(ss sessionStorage, log *zap.Logger, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		kc, ok := proxyKey(c)
+		sessionId := c.GetString("sessionId")
+		if !ok || kc.SessionLimits.IsEmpty() || len(sessionId) == 0 {
+			return
+		}
+
+		tokens, micros, err := ss.GetSessionUsage(kc.KeyId, sessionId)
+		if err != nil {
+			stats.Incr("bricksllm.proxy.get_middleware.get_session_usage_error", nil, 1)
+			logError(log, "error when getting session usage", prod, c.GetString(correlationId), err)
+			return
+		}
+
+		if kc.SessionLimits.MaxTokens != 0 && tokens >= int64(kc.SessionLimits.MaxTokens) {
+			stats.Incr("bricksllm.proxy.get_middleware.session_token_limit_exceeded", nil, 1)
+			JSONError(c, http.StatusTooManyRequests, codeSessionTokenLimitExceeded, "[BricksLLM] session token limit exceeded", nil)
+			c.Abort()
+			return
+		}
+
+		if kc.SessionLimits.MaxCostInUsd != 0 && float64(micros) >= kc.SessionLimits.MaxCostInUsd*1000000 {
+			stats.Incr("bricksllm.proxy.get_middleware.session_cost_limit_exceeded", nil, 1)
+			JSONError(c, http.StatusTooManyRequests, codeSessionCostLimitExceeded, "[BricksLLM] session cost limit exceeded", nil)
+			c.Abort()
+			return
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package proxy
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+)
+
+type shadowRecorder interface {
+	InsertShadowResult(r *route.ShadowResult) error
+}
+
+type shadowRequest struct {
+	route    *route.Route
+	settings map[string]*provider.Setting
+	key      *key.ResponseKey
+	client   http.Client
+	egress   *provider.EgressClients
+	request  *http.Request
+	body     []byte
+	cid      string
+	primary  *route.ShadowOutcome
+}
+
+// newShadowOutcome reads tokens and content from the response body of a
+// step. Cost is only estimated for openai and azure, same as route requests.
+func newShadowOutcome(e estimator, aoe azureEstimator, runEmbeddings bool, provider, model string, status int, latency time.Duration, body []byte) *route.ShadowOutcome {
+	outcome := &route.ShadowOutcome{
+		Provider:    provider,
+		Model:       model,
+		Status:      status,
+		LatencyInMs: int(latency.Milliseconds()),
+	}
+
+	if status != http.StatusOK {
+		outcome.Error = gjson.GetBytes(body, "error.message").Str
+		return outcome
+	}
+
+	outcome.PromptTokenCount = int(gjson.GetBytes(body, "usage.prompt_tokens").Int())
+	outcome.CompletionTokenCount = int(gjson.GetBytes(body, "usage.completion_tokens").Int())
+
+	var cost float64
+	var err error
+
+	if runEmbeddings {
+		total := int(gjson.GetBytes(body, "usage.total_tokens").Int())
+
+		if provider == "azure" {
+			cost, err = aoe.EstimateEmbeddingsInputCost(model, total)
+		} else if provider == "openai" {
+			cost, err = e.EstimateEmbeddingsInputCost(model, total)
+		}
+	}
+
+	if !runEmbeddings {
+		outcome.Content = gjson.GetBytes(body, "choices.0.message.content").Str
+
+		if provider == "azure" {
+			cost, err = aoe.EstimateTotalCost(gjson.GetBytes(body, "model").Str, outcome.PromptTokenCount, outcome.CompletionTokenCount)
+		} else if provider == "openai" {
+			cost, err = e.EstimateTotalCost(gjson.GetBytes(body, "model").Str, outcome.PromptTokenCount, outcome.CompletionTokenCount)
+		}
+	}
+
+	if err != nil {
+		outcome.Error = err.Error()
+	}
+
+	outcome.CostInUsd = cost
+
+	return outcome
+}
+
+// runShadow sends the request to the shadow step of the route and records
+// both outcomes. It runs after the client has been answered.
+func runShadow(sr *shadowRequest, e estimator, aoe azureEstimator, sh shadowRecorder, log *zap.Logger, prod bool) {
+	stats.Incr("bricksllm.proxy.run_shadow.requests", nil, 1)
+
+	forwarded := sr.request
+	forwarded.Body = io.NopCloser(bytes.NewReader(sr.body))
+
+	shadowRoute := sr.route.ShadowRoute()
+	step := sr.route.Shadow.Step
+
+	result := &route.ShadowResult{
+		Id:            util.NewUuid(),
+		CreatedAt:     time.Now().Unix(),
+		RouteId:       sr.route.Id,
+		CorrelationId: sr.cid,
+		Primary:       sr.primary,
+	}
+
+	start := time.Now()
+	runRes, err := shadowRoute.RunSteps(&route.Request{
+		Settings:  sr.settings,
+		Key:       sr.key,
+		Client:    sr.client,
+		Egress:    sr.egress,
+		Forwarded: forwarded,
+	})
+
+	if err != nil {
+		stats.Incr("bricksllm.proxy.run_shadow.run_steps_error", nil, 1)
+
+		result.Shadow = &route.ShadowOutcome{
+			Provider:    step.Provider,
+			Model:       step.Model,
+			LatencyInMs: int(time.Now().Sub(start).Milliseconds()),
+			Error:       err.Error(),
+		}
+	}
+
+	if err == nil {
+		defer runRes.Cancel()
+		defer runRes.Response.Body.Close()
+
+		body, err := io.ReadAll(runRes.Response.Body)
+		dur := time.Now().Sub(start)
+
+		if err != nil {
+			result.Shadow = &route.ShadowOutcome{
+				Provider:    runRes.Provider,
+				Model:       runRes.Model,
+				Status:      runRes.Response.StatusCode,
+				LatencyInMs: int(dur.Milliseconds()),
+				Error:       err.Error(),
+			}
+		}
+
+		if err == nil {
+			result.Shadow = newShadowOutcome(e, aoe, sr.route.ShouldRunEmbeddings(), runRes.Provider, runRes.Model, runRes.Response.StatusCode, dur, body)
+		}
+	}
+
+	if err := sh.InsertShadowResult(result); err != nil {
+		stats.Incr("bricksllm.proxy.run_shadow.insert_shadow_result_error", nil, 1)
+		logError(log, "error when inserting shadow result", prod, sr.cid, err)
+		return
+	}
+
+	stats.Incr("bricksllm.proxy.run_shadow.success", nil, 1)
+}
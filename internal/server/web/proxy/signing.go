@@ -0,0 +1,55 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	signatureHeader = "X-BricksLLM-Signature"
+	timestampHeader = "X-BricksLLM-Timestamp"
+)
+
+type signatureVerifiedContextKey struct{}
+
+// withVerifiedSignature marks a request sent by the gateway itself, such as
+// a queued job whose signature was verified when it was accepted or a
+// scheduled run, so that it is not verified again.
+func withVerifiedSignature(ctx context.Context) context.Context {
+	return context.WithValue(ctx, signatureVerifiedContextKey{}, true)
+}
+
+// verifySignature rejects requests of keys that require signing when their
+// signature is missing, stale or does not match the body.
+func verifySignature(c *gin.Context, kc *key.ResponseKey, body []byte) bool {
+	if kc == nil || kc.Signing == nil {
+		return true
+	}
+
+	if verified, ok := c.Request.Context().Value(signatureVerifiedContextKey{}).(bool); ok && verified {
+		return true
+	}
+
+	timestamp := c.GetHeader(timestampHeader)
+	signature := c.GetHeader(signatureHeader)
+	if len(timestamp) == 0 || len(signature) == 0 {
+		stats.Incr("bricksllm.proxy.verify_signature.missing", nil, 1)
+		JSONError(c, http.StatusUnauthorized, codeInvalidSignature, "[BricksLLM] request signature is required for this key", nil)
+		c.Abort()
+		return false
+	}
+
+	if !kc.Signing.Verify(timestamp, signature, c.Request.Method, c.Request.URL.RequestURI(), body, time.Now()) {
+		stats.Incr("bricksllm.proxy.verify_signature.invalid", nil, 1)
+		JSONError(c, http.StatusUnauthorized, codeInvalidSignature, "[BricksLLM] request signature is invalid or expired", nil)
+		c.Abort()
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,144 @@
+package proxy
+
+import (
+	"encoding/json"
+	"unicode/utf8"
+
+	"github.com/bricks-cloud/bricksllm/internal/guardrail"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/prompt"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/gin-gonic/gin"
+	goopenai "github.com/sashabaranov/go-openai"
+	"github.com/tidwall/gjson"
+)
+
+// streamGuard holds back the chunks of a chat completion stream until the
+// stream guardrails of the key checked their content. Each window is checked
+// together with the window before it, so content split across two windows
+// is caught even though the first half was already sent.
+type streamGuard struct {
+	c        *gin.Context
+	gc       *guardrail.Client
+	sg       *key.StreamGuardrails
+	pending  [][]byte
+	last     []byte
+	window   string
+	previous string
+}
+
+// newStreamGuard returns nil when the key has no stream guardrails.
+func newStreamGuard(c *gin.Context, gc *guardrail.Client) *streamGuard {
+	raw, ok := c.Get("streamGuardrails")
+	if !ok {
+		return nil
+	}
+
+	sg, ok := raw.(*key.StreamGuardrails)
+	if !ok || sg.IsEmpty() {
+		return nil
+	}
+
+	return &streamGuard{
+		c:  c,
+		gc: gc,
+		sg: sg,
+	}
+}
+
+// send buffers a chunk of the stream and returns whether the stream was cut.
+func (g *streamGuard) send(data []byte) bool {
+	if string(data) == "[DONE]" {
+		if g.flush() {
+			return true
+		}
+
+		g.c.SSEvent("", " [DONE]")
+		return false
+	}
+
+	g.pending = append(g.pending, data)
+	g.last = data
+	g.window += gjson.GetBytes(data, "choices.0.delta.content").Str
+
+	if utf8.RuneCountInString(g.window) < g.sg.WindowSize {
+		return false
+	}
+
+	return g.flush()
+}
+
+// flush checks the buffered window and sends its chunks to the client. When
+// a guardrail blocks the window, the stream is cut instead and flush returns
+// true.
+func (g *streamGuard) flush() bool {
+	if len(g.window) != 0 {
+		stats.Incr("bricksllm.proxy.stream_guard.flush.windows", nil, 1)
+
+		verdicts := g.gc.CheckAll(g.sg.Guardrails, guardrail.StageStream, []*prompt.Message{
+			{Role: goopenai.ChatMessageRoleAssistant, Content: g.previous + g.window},
+		})
+
+		recordGuardrailVerdicts(verdicts)
+
+		if v := guardrail.Blocking(verdicts); v != nil {
+			stats.Incr("bricksllm.proxy.stream_guard.flush.blocked", nil, 1)
+
+			keepGuardrailVerdicts(g.c, []*guardrail.Verdict{v})
+			g.cut()
+			return true
+		}
+
+		g.previous = g.window
+		g.window = ""
+	}
+
+	for _, data := range g.pending {
+		g.c.SSEvent("", " "+string(data))
+	}
+
+	g.pending = nil
+
+	return false
+}
+
+// cut drops the buffered window and ends the stream with the message of the
+// stream guardrails the same way providers end filtered streams.
+func (g *streamGuard) cut() {
+	chunk := map[string]any{
+		"id":      gjson.GetBytes(g.last, "id").Str,
+		"object":  gjson.GetBytes(g.last, "object").Str,
+		"created": gjson.GetBytes(g.last, "created").Int(),
+		"model":   gjson.GetBytes(g.last, "model").Str,
+		"choices": []map[string]any{
+			{
+				"index": 0,
+				"delta": map[string]any{
+					"content": g.sg.Message,
+				},
+				"finish_reason": goopenai.FinishReasonContentFilter,
+			},
+		},
+	}
+
+	if data, err := json.Marshal(chunk); err == nil {
+		g.c.SSEvent("", " "+string(data))
+	}
+
+	g.c.SSEvent("", " [DONE]")
+	g.pending = nil
+	g.c.Set("streamBlocked", true)
+}
+
+// getStreamGuardrailsMiddleware sets the stream guardrails of a key for the
+// chat completion handlers.
+func getStreamGuardrailsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		kc, ok := proxyKey(c)
+		if !ok || kc.StreamGuardrails.IsEmpty() || !isChatCompletionPath(c.FullPath()) {
+			return
+		}
+
+		c.Set("streamGuardrails", kc.StreamGuardrails)
+	}
+}
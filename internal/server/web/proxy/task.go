@@ -0,0 +1,61 @@
+package proxy
+
+import (
+	goopenai "github.com/sashabaranov/go-openai"
+	"github.com/tidwall/gjson"
+)
+
+// parentRequestIdHeader links a request to the request it follows up on,
+// for example the request whose tool calls it sends the results of.
+const parentRequestIdHeader = "X-BricksLLM-Parent-Request-Id"
+
+// requestToolCallIds returns the ids of the tool calls whose results are sent
+// in a chat completion or responses request.
+func requestToolCallIds(body []byte) []string {
+	ids := []string{}
+	results := gjson.GetBytes(body, `messages.#(role=="tool")#.tool_call_id`).Array()
+	results = append(results, gjson.GetBytes(body, `input.#(type=="function_call_output")#.call_id`).Array()...)
+	for _, id := range results {
+		if len(id.Str) != 0 {
+			ids = append(ids, id.Str)
+		}
+	}
+
+	return ids
+}
+
+// responseToolCallIds returns the ids of the tool calls made in a chat
+// completion or responses response body.
+func responseToolCallIds(body []byte) []string {
+	ids := []string{}
+	for _, id := range gjson.GetBytes(body, `output.#(type=="function_call")#.call_id`).Array() {
+		if len(id.Str) != 0 {
+			ids = append(ids, id.Str)
+		}
+	}
+
+	for _, choice := range gjson.GetBytes(body, "choices.#.message.tool_calls.#.id").Array() {
+		for _, id := range choice.Array() {
+			if len(id.Str) != 0 {
+				ids = append(ids, id.Str)
+			}
+		}
+	}
+
+	return ids
+}
+
+// streamToolCallIds returns the ids of the tool calls started in a chat
+// completion stream chunk. Later chunks of a tool call leave its id empty.
+func streamToolCallIds(chunk *goopenai.ChatCompletionStreamResponse) []string {
+	ids := []string{}
+	for _, choice := range chunk.Choices {
+		for _, tc := range choice.Delta.ToolCalls {
+			if len(tc.ID) != 0 {
+				ids = append(ids, tc.ID)
+			}
+		}
+	}
+
+	return ids
+}
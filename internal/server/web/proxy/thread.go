@@ -3,8 +3,8 @@ package proxy
 import (
 	"encoding/json"
 
+	"github.com/bricks-cloud/bricksllm/internal/logger"
 	goopenai "github.com/sashabaranov/go-openai"
-
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -25,7 +25,7 @@ func logCreateThreadRequest(log *zap.Logger, data []byte, prod, private bool, ci
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.Any("metadata", tr.Metadata),
 			zap.Any("messages", tr.Messages),
 		}
@@ -44,7 +44,7 @@ func logThreadResponse(log *zap.Logger, data []byte, prod bool, cid string) {
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("id", t.ID),
 			zap.String("object", t.Object),
 			zap.Int64("created_at", t.CreatedAt),
@@ -58,7 +58,7 @@ func logThreadResponse(log *zap.Logger, data []byte, prod bool, cid string) {
 func logRetrieveThreadRequest(log *zap.Logger, prod bool, cid, tid string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("id", tid),
 		}
 
@@ -76,7 +76,7 @@ func logModifyThreadRequest(log *zap.Logger, data []byte, prod bool, cid, tid st
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("id", tid),
 			zap.Any("metadata", tr.Metadata),
 		}
@@ -88,7 +88,7 @@ func logModifyThreadRequest(log *zap.Logger, data []byte, prod bool, cid, tid st
 func logDeleteThreadRequest(log *zap.Logger, prod bool, cid, tid string) {
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("id", tid),
 		}
 
@@ -106,7 +106,7 @@ func logDeleteThreadResponse(log *zap.Logger, data []byte, prod bool, cid string
 
 	if prod {
 		fields := []zapcore.Field{
-			zap.String(correlationId, cid),
+			zap.String(logger.FieldRequestId, cid),
 			zap.String("id", tdr.ID),
 			zap.String("object", tdr.Object),
 			zap.Bool("deleted", tdr.Deleted),
@@ -0,0 +1,77 @@
+package proxy
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+var traceparentRegex = regexp.MustCompile(`^[0-9a-f]{2}-([0-9a-f]{32})-([0-9a-f]{16})-[0-9a-f]{2}$`)
+
+// traceContext identifies the span of the caller that made a proxy request.
+// Ids are kept in the format of the header they were read from, hex for W3C
+// trace context and decimal for Datadog.
+type traceContext struct {
+	traceId string
+	spanId  string
+	w3c     bool
+}
+
+// extractTraceContext reads the W3C traceparent header, which is sent by
+// OpenTelemetry and recent Datadog tracers, and falls back to the Datadog
+// propagation headers. It returns nil when neither is valid.
+func extractTraceContext(h http.Header) *traceContext {
+	if matches := traceparentRegex.FindStringSubmatch(strings.TrimSpace(h.Get("traceparent"))); matches != nil {
+		if strings.Trim(matches[1], "0") != "" && strings.Trim(matches[2], "0") != "" {
+			return &traceContext{
+				traceId: matches[1],
+				spanId:  matches[2],
+				w3c:     true,
+			}
+		}
+	}
+
+	traceId, err := strconv.ParseUint(h.Get("x-datadog-trace-id"), 10, 64)
+	if err != nil || traceId == 0 {
+		return nil
+	}
+
+	spanId, err := strconv.ParseUint(h.Get("x-datadog-parent-id"), 10, 64)
+	if err != nil || spanId == 0 {
+		return nil
+	}
+
+	return &traceContext{
+		traceId: strconv.FormatUint(traceId, 10),
+		spanId:  strconv.FormatUint(spanId, 10),
+	}
+}
+
+// datadogIds returns the ids in the decimal format Datadog correlates logs
+// with traces by. Only the lower 64 bits of W3C trace ids are used.
+func (tc *traceContext) datadogIds() (string, string) {
+	if !tc.w3c {
+		return tc.traceId, tc.spanId
+	}
+
+	traceId, _ := strconv.ParseUint(tc.traceId[16:], 16, 64)
+	spanId, _ := strconv.ParseUint(tc.spanId, 16, 64)
+
+	return strconv.FormatUint(traceId, 10), strconv.FormatUint(spanId, 10)
+}
+
+// logFields returns the fields Datadog and Tempo use to link log lines to
+// traces.
+func (tc *traceContext) logFields() []zap.Field {
+	ddTraceId, ddSpanId := tc.datadogIds()
+
+	return []zap.Field{
+		zap.String("trace_id", tc.traceId),
+		zap.String("span_id", tc.spanId),
+		zap.String("dd.trace_id", ddTraceId),
+		zap.String("dd.span_id", ddSpanId),
+	}
+}
@@ -0,0 +1,16 @@
+package web
+
+import "github.com/gin-gonic/gin"
+
+// NewRouter returns a router that only takes the client ip from forwarded
+// headers of trusted proxies. Otherwise clients could dodge rate limits and
+// lockouts keyed by their ip by sending a different X-Forwarded-For with
+// every request.
+func NewRouter(trustedProxies []string) (*gin.Engine, error) {
+	router := gin.New()
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, err
+	}
+
+	return router, nil
+}
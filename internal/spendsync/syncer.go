@@ -0,0 +1,253 @@
+package spendsync
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"go.uber.org/zap"
+)
+
+// Counter is the spend of a key in a cluster in micro dollars. PeriodEnd is
+// the end of the cost limit period of PeriodTotal in unix milliseconds.
+type Counter struct {
+	KeyId       string       `json:"keyId"`
+	Total       int64        `json:"total"`
+	Unit        key.TimeUnit `json:"unit,omitempty"`
+	PeriodEnd   int64        `json:"periodEnd,omitempty"`
+	PeriodTotal int64        `json:"periodTotal,omitempty"`
+}
+
+// Counters are the spend of the keys with cost limits in a cluster.
+type Counters struct {
+	ClusterId string     `json:"clusterId"`
+	Counters  []*Counter `json:"counters"`
+}
+
+type KeyStorage interface {
+	GetAllKeys() ([]*key.ResponseKey, error)
+}
+
+type TotalStorage interface {
+	GetLocalCounter(keyId string) (int64, error)
+	MergeRemoteCounter(keyId, clusterId string, value int64) error
+}
+
+type PeriodStorage interface {
+	GetLocalCounter(keyId string, timeUnit key.TimeUnit) (int64, int64, error)
+	MergeRemoteCounter(keyId string, timeUnit key.TimeUnit, clusterId string, periodEnd int64, value int64) error
+}
+
+// Syncer keeps the cost limit counters of clusters sharing the same keys
+// approximately consistent. Each cluster only ever increments its own
+// counters and pulls the counters of its peers into a slot per cluster,
+// keeping the largest value seen. Budgets are enforced against the sum of
+// all slots, so spend in another cluster counts at most one interval late.
+type Syncer struct {
+	clusterId string
+	peers     []string
+	token     string
+	interval  time.Duration
+	client    http.Client
+	ks        KeyStorage
+	ts        TotalStorage
+	ps        PeriodStorage
+	done      chan bool
+	log       *zap.Logger
+}
+
+func NewSyncer(clusterId string, peers []string, token string, interval time.Duration, ks KeyStorage, ts TotalStorage, ps PeriodStorage, log *zap.Logger) *Syncer {
+	return &Syncer{
+		clusterId: clusterId,
+		peers:     peers,
+		token:     token,
+		interval:  interval,
+		client: http.Client{
+			Timeout: interval,
+		},
+		ks:   ks,
+		ts:   ts,
+		ps:   ps,
+		done: make(chan bool),
+		log:  log,
+	}
+}
+
+func (s *Syncer) Enabled() bool {
+	return len(s.clusterId) != 0
+}
+
+func hasCostLimit(k *key.ResponseKey) bool {
+	return !k.Revoked && (k.CostLimitInUsd != 0 || k.GetCostLimitInUsdOverTime() != 0)
+}
+
+// Local returns the spend of this cluster only, so peers never count spend
+// they synced themselves.
+func (s *Syncer) Local() (*Counters, error) {
+	keys, err := s.ks.GetAllKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	counters := &Counters{
+		ClusterId: s.clusterId,
+		Counters:  []*Counter{},
+	}
+
+	for _, k := range keys {
+		if !hasCostLimit(k) {
+			continue
+		}
+
+		total, err := s.ts.GetLocalCounter(k.KeyId)
+		if err != nil {
+			return nil, err
+		}
+
+		c := &Counter{
+			KeyId: k.KeyId,
+			Total: total,
+		}
+
+		if len(k.CostLimitInUsdUnit) != 0 {
+			c.Unit = k.CostLimitInUsdUnit
+			c.PeriodTotal, c.PeriodEnd, err = s.ps.GetLocalCounter(k.KeyId, k.CostLimitInUsdUnit)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if c.Total == 0 && c.PeriodTotal == 0 {
+			continue
+		}
+
+		counters.Counters = append(counters.Counters, c)
+	}
+
+	return counters, nil
+}
+
+func (s *Syncer) fetch(peer string) (*Counters, error) {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(peer, "/")+"/api/spend/counters", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("X-API-KEY", s.token)
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("peer responded with status %d: %s", res.StatusCode, string(body))
+	}
+
+	counters := &Counters{}
+	if err := json.Unmarshal(body, counters); err != nil {
+		return nil, err
+	}
+
+	if len(counters.ClusterId) == 0 || counters.ClusterId == s.clusterId {
+		return nil, fmt.Errorf("peer reported cluster id %q which must be set and differ from %q", counters.ClusterId, s.clusterId)
+	}
+
+	return counters, nil
+}
+
+// merge keeps the counters of a peer for the keys with cost limits in this
+// cluster. Period counters are only merged when both clusters use the same
+// unit, since the buckets of different units cannot be compared.
+func (s *Syncer) merge(counters *Counters, keys map[string]*key.ResponseKey) error {
+	for _, c := range counters.Counters {
+		k, ok := keys[c.KeyId]
+		if !ok {
+			continue
+		}
+
+		if err := s.ts.MergeRemoteCounter(c.KeyId, counters.ClusterId, c.Total); err != nil {
+			return err
+		}
+
+		if len(c.Unit) == 0 || c.Unit != k.CostLimitInUsdUnit {
+			continue
+		}
+
+		if err := s.ps.MergeRemoteCounter(c.KeyId, c.Unit, counters.ClusterId, c.PeriodEnd, c.PeriodTotal); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Syncer) sync() {
+	all, err := s.ks.GetAllKeys()
+	if err != nil {
+		stats.Incr("bricksllm.spendsync.sync.get_all_keys_error", nil, 1)
+		s.log.Sugar().Debugf("error when getting keys for spend sync: %v", err)
+		return
+	}
+
+	keys := map[string]*key.ResponseKey{}
+	for _, k := range all {
+		if hasCostLimit(k) {
+			keys[k.KeyId] = k
+		}
+	}
+
+	for _, peer := range s.peers {
+		start := time.Now()
+
+		counters, err := s.fetch(peer)
+		if err != nil {
+			stats.Incr("bricksllm.spendsync.sync.fetch_error", nil, 1)
+			s.log.Sugar().Debugf("error when fetching spend counters from %s: %v", peer, err)
+			continue
+		}
+
+		if err := s.merge(counters, keys); err != nil {
+			stats.Incr("bricksllm.spendsync.sync.merge_error", nil, 1)
+			s.log.Sugar().Debugf("error when merging spend counters of cluster %s: %v", counters.ClusterId, err)
+			continue
+		}
+
+		stats.Timing("bricksllm.spendsync.sync.latency", time.Since(start), []string{"cluster:" + counters.ClusterId}, 1)
+	}
+}
+
+func (s *Syncer) Listen() {
+	ticker := time.NewTicker(s.interval)
+	s.log.Sugar().Infof("spend syncer of cluster %s started syncing with %d peers", s.clusterId, len(s.peers))
+
+	go func() {
+		for {
+			select {
+			case <-s.done:
+				ticker.Stop()
+				s.log.Info("spend syncer stopped")
+				return
+			case <-ticker.C:
+				s.sync()
+			}
+		}
+	}()
+}
+
+func (s *Syncer) Stop() {
+	s.log.Info("shutting down spend syncer...")
+
+	s.done <- true
+}
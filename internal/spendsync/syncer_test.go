@@ -0,0 +1,176 @@
+package spendsync
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	stats.InitializeClient("", nil)
+
+	os.Exit(m.Run())
+}
+
+type fakeKeyStorage []*key.ResponseKey
+
+func (s fakeKeyStorage) GetAllKeys() ([]*key.ResponseKey, error) {
+	return s, nil
+}
+
+type fakeTotalStorage struct {
+	local  map[string]int64
+	merged map[string]int64
+}
+
+func (s *fakeTotalStorage) GetLocalCounter(keyId string) (int64, error) {
+	return s.local[keyId], nil
+}
+
+func (s *fakeTotalStorage) MergeRemoteCounter(keyId, clusterId string, value int64) error {
+	s.merged[clusterId+"/"+keyId] = value
+	return nil
+}
+
+type fakePeriodStorage struct {
+	local  map[string]int64
+	merged map[string]int64
+}
+
+func (s *fakePeriodStorage) GetLocalCounter(keyId string, timeUnit key.TimeUnit) (int64, int64, error) {
+	return s.local[keyId], 1000, nil
+}
+
+func (s *fakePeriodStorage) MergeRemoteCounter(keyId string, timeUnit key.TimeUnit, clusterId string, periodEnd int64, value int64) error {
+	s.merged[clusterId+"/"+keyId+"/"+string(timeUnit)] = value
+	return nil
+}
+
+func newFakeSyncer(clusterId string, peers []string, keys fakeKeyStorage) (*Syncer, *fakeTotalStorage, *fakePeriodStorage) {
+	ts := &fakeTotalStorage{local: map[string]int64{}, merged: map[string]int64{}}
+	ps := &fakePeriodStorage{local: map[string]int64{}, merged: map[string]int64{}}
+
+	return NewSyncer(clusterId, peers, "token", time.Second, keys, ts, ps, zap.NewNop()), ts, ps
+}
+
+func TestSyncer_Local(t *testing.T) {
+	keys := fakeKeyStorage{
+		{KeyId: "total", CostLimitInUsd: 10},
+		{KeyId: "period", CostLimitInUsdOverTime: 5, CostLimitInUsdUnit: key.DayTimeUnit},
+		{KeyId: "unlimited"},
+		{KeyId: "revoked", CostLimitInUsd: 10, Revoked: true},
+		{KeyId: "unused", CostLimitInUsd: 10},
+	}
+
+	s, ts, ps := newFakeSyncer("a", nil, keys)
+	ts.local["total"] = 100
+	ts.local["period"] = 50
+	ts.local["unlimited"] = 70
+	ts.local["revoked"] = 80
+	ps.local["period"] = 20
+
+	counters, err := s.Local()
+	require.NoError(t, err)
+
+	assert.Equal(t, "a", counters.ClusterId)
+	assert.Equal(t, []*Counter{
+		{KeyId: "total", Total: 100},
+		{KeyId: "period", Total: 50, Unit: key.DayTimeUnit, PeriodEnd: 1000, PeriodTotal: 20},
+	}, counters.Counters)
+}
+
+func TestSyncer_Sync(t *testing.T) {
+	keys := fakeKeyStorage{
+		{KeyId: "total", CostLimitInUsd: 10},
+		{KeyId: "period", CostLimitInUsdOverTime: 5, CostLimitInUsdUnit: key.DayTimeUnit},
+		{KeyId: "hourly", CostLimitInUsdOverTime: 5, CostLimitInUsdUnit: key.HourTimeUnit},
+	}
+
+	cases := []struct {
+		name     string
+		status   int
+		counters *Counters
+		totals   map[string]int64
+		periods  map[string]int64
+	}{
+		{
+			name:   "counters of peers are merged",
+			status: http.StatusOK,
+			counters: &Counters{ClusterId: "b", Counters: []*Counter{
+				{KeyId: "total", Total: 30},
+				{KeyId: "period", Total: 40, Unit: key.DayTimeUnit, PeriodEnd: 1000, PeriodTotal: 10},
+			}},
+			totals:  map[string]int64{"b/total": 30, "b/period": 40},
+			periods: map[string]int64{"b/period/d": 10},
+		},
+		{
+			name:   "period counters of other units are not merged",
+			status: http.StatusOK,
+			counters: &Counters{ClusterId: "b", Counters: []*Counter{
+				{KeyId: "hourly", Total: 40, Unit: key.DayTimeUnit, PeriodEnd: 1000, PeriodTotal: 10},
+			}},
+			totals:  map[string]int64{"b/hourly": 40},
+			periods: map[string]int64{},
+		},
+		{
+			name:   "counters of unknown keys are ignored",
+			status: http.StatusOK,
+			counters: &Counters{ClusterId: "b", Counters: []*Counter{
+				{KeyId: "unknown", Total: 40},
+			}},
+			totals:  map[string]int64{},
+			periods: map[string]int64{},
+		},
+		{
+			name:     "peers reporting the cluster id of this cluster",
+			status:   http.StatusOK,
+			counters: &Counters{ClusterId: "a", Counters: []*Counter{{KeyId: "total", Total: 30}}},
+			totals:   map[string]int64{},
+			periods:  map[string]int64{},
+		},
+		{
+			name:     "peers without a cluster id",
+			status:   http.StatusOK,
+			counters: &Counters{Counters: []*Counter{{KeyId: "total", Total: 30}}},
+			totals:   map[string]int64{},
+			periods:  map[string]int64{},
+		},
+		{
+			name:     "failed requests",
+			status:   http.StatusUnauthorized,
+			counters: &Counters{ClusterId: "b", Counters: []*Counter{{KeyId: "total", Total: 30}}},
+			totals:   map[string]int64{},
+			periods:  map[string]int64{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			token := ""
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				token = r.Header.Get("X-API-KEY")
+				assert.Equal(t, "/api/spend/counters", r.URL.Path)
+
+				w.WriteHeader(tc.status)
+				json.NewEncoder(w).Encode(tc.counters)
+			}))
+			defer server.Close()
+
+			s, ts, ps := newFakeSyncer("a", []string{server.URL + "/"}, keys)
+			s.sync()
+
+			assert.Equal(t, "token", token)
+			assert.Equal(t, tc.totals, ts.merged)
+			assert.Equal(t, tc.periods, ps.merged)
+		})
+	}
+}
@@ -13,11 +13,13 @@ type Client struct {
 
 var instance *Client
 
-func InitializeClient(provider string) error {
+// InitializeClient sets up the metrics client. Tags are added to every metric,
+// e.g. the environment labels of the deployment.
+func InitializeClient(provider string, tags []string) error {
 	if instance == nil {
 		instance = &Client{}
 		if provider == "datadog" {
-			statsd, err := statsd.New("127.0.0.1:8125")
+			statsd, err := statsd.New("127.0.0.1:8125", statsd.WithTags(tags))
 			if err != nil {
 				return err
 			}
@@ -37,3 +39,7 @@ func Incr(name string, tags []string, rate float64) {
 func Timing(name string, value time.Duration, tags []string, rate float64) {
 	instance.statsdc.Timing(name, value, tags, rate)
 }
+
+func Gauge(name string, value float64, tags []string, rate float64) {
+	instance.statsdc.Gauge(name, value, tags, rate)
+}
@@ -0,0 +1,110 @@
+package memdb
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/admintoken"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"go.uber.org/zap"
+)
+
+type AdminTokensStorage interface {
+	GetAdminTokens() ([]*admintoken.Token, error)
+}
+
+type AdminTokensMemDb struct {
+	external AdminTokensStorage
+	tokens   map[string]*admintoken.Token
+	lock     sync.RWMutex
+	done     chan bool
+	interval time.Duration
+	log      *zap.Logger
+}
+
+func NewAdminTokensMemDb(ex AdminTokensStorage, log *zap.Logger, interval time.Duration) (*AdminTokensMemDb, error) {
+	mdb := &AdminTokensMemDb{
+		external: ex,
+		tokens:   map[string]*admintoken.Token{},
+		log:      log,
+		interval: interval,
+		done:     make(chan bool),
+	}
+
+	if err := mdb.load(); err != nil {
+		return nil, err
+	}
+
+	return mdb, nil
+}
+
+func (mdb *AdminTokensMemDb) load() error {
+	tokens, err := mdb.external.GetAdminTokens()
+	if err != nil {
+		return err
+	}
+
+	updated := map[string]*admintoken.Token{}
+	for _, t := range tokens {
+		updated[t.TokenHash] = t
+	}
+
+	mdb.lock.Lock()
+	defer mdb.lock.Unlock()
+
+	mdb.tokens = updated
+
+	return nil
+}
+
+func (mdb *AdminTokensMemDb) GetTokenByHash(hash string) *admintoken.Token {
+	mdb.lock.RLock()
+	defer mdb.lock.RUnlock()
+
+	return mdb.tokens[hash]
+}
+
+// HasTokens reports whether any admin token was ever created, including
+// revoked ones.
+func (mdb *AdminTokensMemDb) HasTokens() bool {
+	mdb.lock.RLock()
+	defer mdb.lock.RUnlock()
+
+	return len(mdb.tokens) != 0
+}
+
+// SetToken makes a token created by this instance usable before the next
+// sync.
+func (mdb *AdminTokensMemDb) SetToken(t *admintoken.Token) {
+	mdb.lock.Lock()
+	defer mdb.lock.Unlock()
+
+	mdb.tokens[t.TokenHash] = t
+}
+
+func (mdb *AdminTokensMemDb) Listen() {
+	ticker := time.NewTicker(mdb.interval)
+	mdb.log.Info("admin tokens memdb started listening for admin token updates")
+
+	go func() {
+		for {
+			select {
+			case <-mdb.done:
+				mdb.log.Info("admin tokens memdb stopped")
+				return
+			case <-ticker.C:
+				if err := mdb.load(); err != nil {
+					stats.Incr("bricksllm.memdb.admin_tokens_memdb.listen.get_admin_tokens_error", nil, 1)
+
+					mdb.log.Sugar().Debugf("memdb failed to get admin tokens: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+func (mdb *AdminTokensMemDb) Stop() {
+	mdb.log.Info("shutting down admin tokens memdb...")
+
+	mdb.done <- true
+}
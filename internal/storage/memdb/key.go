@@ -18,6 +18,7 @@ type MemDb struct {
 	external       Storage
 	lastUpdated    int64
 	hashToKeys     map[string]*key.ResponseKey
+	keyIdToHash    map[string]string
 	hashToKeysLock sync.RWMutex
 	done           chan bool
 	interval       time.Duration
@@ -26,6 +27,7 @@ type MemDb struct {
 
 func NewMemDb(ex Storage, log *zap.Logger, interval time.Duration) (*MemDb, error) {
 	hashToKeys := map[string]*key.ResponseKey{}
+	keyIdToHash := map[string]string{}
 
 	keys, err := ex.GetAllKeys()
 	if err != nil {
@@ -36,6 +38,7 @@ func NewMemDb(ex Storage, log *zap.Logger, interval time.Duration) (*MemDb, erro
 	var latetest int64 = -1
 	for _, k := range keys {
 		hashToKeys[k.Key] = k
+		keyIdToHash[k.KeyId] = k.Key
 		numberOfKeys++
 		if k.UpdatedAt > latetest {
 			latetest = k.UpdatedAt
@@ -49,6 +52,7 @@ func NewMemDb(ex Storage, log *zap.Logger, interval time.Duration) (*MemDb, erro
 	return &MemDb{
 		external:    ex,
 		hashToKeys:  hashToKeys,
+		keyIdToHash: keyIdToHash,
 		log:         log,
 		lastUpdated: latetest,
 		interval:    interval,
@@ -57,6 +61,9 @@ func NewMemDb(ex Storage, log *zap.Logger, interval time.Duration) (*MemDb, erro
 }
 
 func (mdb *MemDb) GetKey(hash string) *key.ResponseKey {
+	mdb.hashToKeysLock.RLock()
+	defer mdb.hashToKeysLock.RUnlock()
+
 	k, ok := mdb.hashToKeys[hash]
 	if ok {
 		return k
@@ -65,18 +72,38 @@ func (mdb *MemDb) GetKey(hash string) *key.ResponseKey {
 	return nil
 }
 
-func (mdb *MemDb) SetKey(k *key.ResponseKey) {
+func (mdb *MemDb) GetKeyById(keyId string) *key.ResponseKey {
 	mdb.hashToKeysLock.RLock()
 	defer mdb.hashToKeysLock.RUnlock()
 
+	hash, ok := mdb.keyIdToHash[keyId]
+	if !ok {
+		return nil
+	}
+
+	return mdb.hashToKeys[hash]
+}
+
+// SetKey drops the previous hash of a key so that rotated secrets stop
+// working.
+func (mdb *MemDb) SetKey(k *key.ResponseKey) {
+	mdb.hashToKeysLock.Lock()
+	defer mdb.hashToKeysLock.Unlock()
+
+	if previous, ok := mdb.keyIdToHash[k.KeyId]; ok && previous != k.Key {
+		delete(mdb.hashToKeys, previous)
+	}
+
 	mdb.hashToKeys[k.Key] = k
+	mdb.keyIdToHash[k.KeyId] = k.Key
 }
 
 func (mdb *MemDb) RemoveKey(k *key.ResponseKey) {
-	mdb.hashToKeysLock.RLock()
-	defer mdb.hashToKeysLock.RUnlock()
+	mdb.hashToKeysLock.Lock()
+	defer mdb.hashToKeysLock.Unlock()
 
 	delete(mdb.hashToKeys, k.Key)
+	delete(mdb.keyIdToHash, k.KeyId)
 }
 
 func (mdb *MemDb) Listen() {
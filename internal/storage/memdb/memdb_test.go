@@ -0,0 +1,14 @@
+package memdb
+
+import (
+	"os"
+	"testing"
+
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+)
+
+func TestMain(m *testing.M) {
+	stats.InitializeClient("", nil)
+
+	os.Exit(m.Run())
+}
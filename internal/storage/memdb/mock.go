@@ -0,0 +1,98 @@
+package memdb
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/mock"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"go.uber.org/zap"
+)
+
+type MockResponsesStorage interface {
+	GetMockResponses() ([]*mock.Response, error)
+}
+
+type MockResponsesMemDb struct {
+	external  MockResponsesStorage
+	responses map[string]*mock.Response
+	lock      sync.RWMutex
+	done      chan bool
+	interval  time.Duration
+	log       *zap.Logger
+}
+
+func NewMockResponsesMemDb(ex MockResponsesStorage, log *zap.Logger, interval time.Duration) (*MockResponsesMemDb, error) {
+	mdb := &MockResponsesMemDb{
+		external:  ex,
+		responses: map[string]*mock.Response{},
+		log:       log,
+		interval:  interval,
+		done:      make(chan bool),
+	}
+
+	if err := mdb.load(); err != nil {
+		return nil, err
+	}
+
+	return mdb, nil
+}
+
+func (mdb *MockResponsesMemDb) load() error {
+	responses, err := mdb.external.GetMockResponses()
+	if err != nil {
+		return err
+	}
+
+	updated := map[string]*mock.Response{}
+	for _, r := range responses {
+		updated[r.Model] = r
+	}
+
+	mdb.lock.Lock()
+	defer mdb.lock.Unlock()
+
+	mdb.responses = updated
+
+	return nil
+}
+
+// GetMockResponse falls back to the mock response of any model if the model
+// does not have its own.
+func (mdb *MockResponsesMemDb) GetMockResponse(model string) *mock.Response {
+	mdb.lock.RLock()
+	defer mdb.lock.RUnlock()
+
+	if r, ok := mdb.responses[model]; ok {
+		return r
+	}
+
+	return mdb.responses[mock.AnyModel]
+}
+
+func (mdb *MockResponsesMemDb) Listen() {
+	ticker := time.NewTicker(mdb.interval)
+	mdb.log.Info("mock responses memdb started listening for mock response updates")
+
+	go func() {
+		for {
+			select {
+			case <-mdb.done:
+				mdb.log.Info("mock responses memdb stopped")
+				return
+			case <-ticker.C:
+				if err := mdb.load(); err != nil {
+					stats.Incr("bricksllm.memdb.mock_responses_memdb.listen.get_mock_responses_error", nil, 1)
+
+					mdb.log.Sugar().Debugf("memdb failed to get mock responses: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+func (mdb *MockResponsesMemDb) Stop() {
+	mdb.log.Info("shutting down mock responses memdb...")
+
+	mdb.done <- true
+}
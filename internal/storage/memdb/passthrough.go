@@ -0,0 +1,95 @@
+package memdb
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/passthrough"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"go.uber.org/zap"
+)
+
+type PassThroughsStorage interface {
+	GetPassThroughs() ([]*passthrough.PassThrough, error)
+}
+
+// PassThroughsMemDb keeps the enabled pass-throughs by provider.
+type PassThroughsMemDb struct {
+	external PassThroughsStorage
+	pts      map[string]*passthrough.PassThrough
+	lock     sync.RWMutex
+	done     chan bool
+	interval time.Duration
+	log      *zap.Logger
+}
+
+func NewPassThroughsMemDb(ex PassThroughsStorage, log *zap.Logger, interval time.Duration) (*PassThroughsMemDb, error) {
+	mdb := &PassThroughsMemDb{
+		external: ex,
+		pts:      map[string]*passthrough.PassThrough{},
+		log:      log,
+		interval: interval,
+		done:     make(chan bool),
+	}
+
+	if err := mdb.load(); err != nil {
+		return nil, err
+	}
+
+	return mdb, nil
+}
+
+func (mdb *PassThroughsMemDb) load() error {
+	pts, err := mdb.external.GetPassThroughs()
+	if err != nil {
+		return err
+	}
+
+	updated := map[string]*passthrough.PassThrough{}
+	for _, p := range pts {
+		if !p.Disabled {
+			updated[p.Provider] = p
+		}
+	}
+
+	mdb.lock.Lock()
+	defer mdb.lock.Unlock()
+
+	mdb.pts = updated
+
+	return nil
+}
+
+func (mdb *PassThroughsMemDb) GetPassThrough(provider string) *passthrough.PassThrough {
+	mdb.lock.RLock()
+	defer mdb.lock.RUnlock()
+
+	return mdb.pts[provider]
+}
+
+func (mdb *PassThroughsMemDb) Listen() {
+	ticker := time.NewTicker(mdb.interval)
+	mdb.log.Info("pass-throughs memdb started listening for pass-through updates")
+
+	go func() {
+		for {
+			select {
+			case <-mdb.done:
+				mdb.log.Info("pass-throughs memdb stopped")
+				return
+			case <-ticker.C:
+				if err := mdb.load(); err != nil {
+					stats.Incr("bricksllm.memdb.pass_throughs_memdb.listen.get_pass_throughs_error", nil, 1)
+
+					mdb.log.Sugar().Debugf("memdb failed to get pass-throughs: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+func (mdb *PassThroughsMemDb) Stop() {
+	mdb.log.Info("shutting down pass-throughs memdb...")
+
+	mdb.done <- true
+}
@@ -0,0 +1,105 @@
+package memdb
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/pause"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"go.uber.org/zap"
+)
+
+type PausesStorage interface {
+	GetPauses() ([]*pause.Pause, error)
+}
+
+// PausesMemDb mirrors every pause instead of only the updated ones so that
+// resumed scopes disappear on the next sync.
+type PausesMemDb struct {
+	external PausesStorage
+	pauses   map[string]*pause.Pause
+	lock     sync.RWMutex
+	done     chan bool
+	interval time.Duration
+	log      *zap.Logger
+}
+
+func NewPausesMemDb(ex PausesStorage, log *zap.Logger, interval time.Duration) (*PausesMemDb, error) {
+	mdb := &PausesMemDb{
+		external: ex,
+		pauses:   map[string]*pause.Pause{},
+		log:      log,
+		interval: interval,
+		done:     make(chan bool),
+	}
+
+	if err := mdb.sync(); err != nil {
+		return nil, err
+	}
+
+	return mdb, nil
+}
+
+func (mdb *PausesMemDb) sync() error {
+	pauses, err := mdb.external.GetPauses()
+	if err != nil {
+		return err
+	}
+
+	updated := map[string]*pause.Pause{}
+	for _, p := range pauses {
+		updated[p.Id()] = p
+	}
+
+	mdb.lock.Lock()
+	defer mdb.lock.Unlock()
+
+	for id := range updated {
+		if _, ok := mdb.pauses[id]; !ok {
+			mdb.log.Sugar().Infof("pauses memdb picked up a pause: %s", id)
+		}
+	}
+
+	for id := range mdb.pauses {
+		if _, ok := updated[id]; !ok {
+			mdb.log.Sugar().Infof("pauses memdb picked up a resume: %s", id)
+		}
+	}
+
+	mdb.pauses = updated
+	return nil
+}
+
+func (mdb *PausesMemDb) GetPause(scope, target string) *pause.Pause {
+	mdb.lock.RLock()
+	defer mdb.lock.RUnlock()
+
+	return mdb.pauses[pause.Id(scope, target)]
+}
+
+func (mdb *PausesMemDb) Listen() {
+	ticker := time.NewTicker(mdb.interval)
+	mdb.log.Info("pauses memdb started listening for pause updates")
+
+	go func() {
+		for {
+			select {
+			case <-mdb.done:
+				mdb.log.Info("pauses memdb stopped")
+				return
+			case <-ticker.C:
+				if err := mdb.sync(); err != nil {
+					stats.Incr("bricksllm.memdb.pauses_memdb.listen.get_pauses_error", nil, 1)
+
+					mdb.log.Sugar().Debugf("memdb failed to update pauses: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+func (mdb *PausesMemDb) Stop() {
+	mdb.log.Info("shutting down pauses memdb...")
+
+	mdb.done <- true
+}
@@ -0,0 +1,118 @@
+package memdb
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"go.uber.org/zap"
+)
+
+type PoliciesStorage interface {
+	GetPolicies() ([]*policy.Policy, error)
+}
+
+// PoliciesMemDb keeps the enabled policies compiled and ordered by priority
+// so that the proxy does not parse expressions per request.
+type PoliciesMemDb struct {
+	external PoliciesStorage
+	policies []*policy.Compiled
+	lock     sync.RWMutex
+	done     chan bool
+	interval time.Duration
+	log      *zap.Logger
+}
+
+func NewPoliciesMemDb(ex PoliciesStorage, log *zap.Logger, interval time.Duration) (*PoliciesMemDb, error) {
+	mdb := &PoliciesMemDb{
+		external: ex,
+		policies: []*policy.Compiled{},
+		log:      log,
+		interval: interval,
+		done:     make(chan bool),
+	}
+
+	if err := mdb.load(); err != nil {
+		return nil, err
+	}
+
+	return mdb, nil
+}
+
+func (mdb *PoliciesMemDb) load() error {
+	policies, err := mdb.external.GetPolicies()
+	if err != nil {
+		return err
+	}
+
+	mdb.lock.RLock()
+	compiled := map[string]*policy.Compiled{}
+	for _, c := range mdb.policies {
+		compiled[c.Id] = c
+	}
+	mdb.lock.RUnlock()
+
+	updated := []*policy.Compiled{}
+	for _, p := range policies {
+		if p.Disabled {
+			continue
+		}
+
+		if c, ok := compiled[p.Id]; ok && c.Expression == p.Expression {
+			updated = append(updated, &policy.Compiled{Policy: p, Program: c.Program})
+			continue
+		}
+
+		c, err := policy.NewCompiled(p)
+		if err != nil {
+			stats.Incr("bricksllm.memdb.policies_memdb.load.compile_error", nil, 1)
+
+			mdb.log.Sugar().Debugf("memdb failed to compile policy %s: %v", p.Id, err)
+			continue
+		}
+
+		updated = append(updated, c)
+	}
+
+	mdb.lock.Lock()
+	defer mdb.lock.Unlock()
+
+	mdb.policies = updated
+
+	return nil
+}
+
+func (mdb *PoliciesMemDb) GetPolicies() []*policy.Compiled {
+	mdb.lock.RLock()
+	defer mdb.lock.RUnlock()
+
+	return mdb.policies
+}
+
+func (mdb *PoliciesMemDb) Listen() {
+	ticker := time.NewTicker(mdb.interval)
+	mdb.log.Info("policies memdb started listening for policy updates")
+
+	go func() {
+		for {
+			select {
+			case <-mdb.done:
+				mdb.log.Info("policies memdb stopped")
+				return
+			case <-ticker.C:
+				if err := mdb.load(); err != nil {
+					stats.Incr("bricksllm.memdb.policies_memdb.listen.get_policies_error", nil, 1)
+
+					mdb.log.Sugar().Debugf("memdb failed to get policies: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+func (mdb *PoliciesMemDb) Stop() {
+	mdb.log.Info("shutting down policies memdb...")
+
+	mdb.done <- true
+}
@@ -0,0 +1,142 @@
+package memdb
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakePoliciesStorage struct {
+	policies []*policy.Policy
+	err      error
+}
+
+func (s *fakePoliciesStorage) GetPolicies() ([]*policy.Policy, error) {
+	return s.policies, s.err
+}
+
+func newPoliciesMemDb(t *testing.T, s *fakePoliciesStorage) *PoliciesMemDb {
+	mdb, err := NewPoliciesMemDb(s, zap.NewNop(), time.Minute)
+	require.NoError(t, err)
+
+	return mdb
+}
+
+func getPolicyIds(mdb *PoliciesMemDb) []string {
+	ids := []string{}
+	for _, p := range mdb.GetPolicies() {
+		ids = append(ids, p.Id)
+	}
+
+	return ids
+}
+
+func TestPoliciesMemDb_Load(t *testing.T) {
+	gpt4o := &policy.Input{Model: "gpt-4o"}
+
+	t.Run("unchanged expressions keep their compiled program", func(t *testing.T) {
+		s := &fakePoliciesStorage{policies: []*policy.Policy{
+			{Id: "p1", Expression: `model == "gpt-4o"`, Action: policy.ActionDeny},
+		}}
+		mdb := newPoliciesMemDb(t, s)
+		before := mdb.GetPolicies()[0]
+
+		s.policies = []*policy.Policy{
+			{Id: "p1", Expression: `model == "gpt-4o"`, Action: policy.ActionAllow, Priority: 2},
+		}
+		require.NoError(t, mdb.load())
+
+		after := mdb.GetPolicies()[0]
+		assert.Same(t, before.Program, after.Program)
+		assert.Equal(t, policy.ActionAllow, after.Action)
+		assert.Equal(t, 2, after.Priority)
+	})
+
+	t.Run("changed expressions are recompiled", func(t *testing.T) {
+		s := &fakePoliciesStorage{policies: []*policy.Policy{
+			{Id: "p1", Expression: `model == "gpt-4o"`, Action: policy.ActionDeny},
+		}}
+		mdb := newPoliciesMemDb(t, s)
+		before := mdb.GetPolicies()[0]
+		assert.True(t, before.Program.Eval(gpt4o))
+
+		s.policies = []*policy.Policy{
+			{Id: "p1", Expression: `model == "gpt-4"`, Action: policy.ActionDeny},
+		}
+		require.NoError(t, mdb.load())
+
+		after := mdb.GetPolicies()[0]
+		assert.NotSame(t, before.Program, after.Program)
+		assert.False(t, after.Program.Eval(gpt4o))
+	})
+
+	t.Run("disabled and deleted policies are dropped", func(t *testing.T) {
+		s := &fakePoliciesStorage{policies: []*policy.Policy{
+			{Id: "p1", Expression: `true`},
+			{Id: "p2", Expression: `true`},
+			{Id: "p3", Expression: `true`},
+		}}
+		mdb := newPoliciesMemDb(t, s)
+		assert.Equal(t, []string{"p1", "p2", "p3"}, getPolicyIds(mdb))
+
+		s.policies = []*policy.Policy{
+			{Id: "p1", Expression: `true`, Disabled: true},
+			{Id: "p3", Expression: `true`},
+		}
+		require.NoError(t, mdb.load())
+		assert.Equal(t, []string{"p3"}, getPolicyIds(mdb))
+
+		s.policies = []*policy.Policy{
+			{Id: "p1", Expression: `true`},
+			{Id: "p3", Expression: `true`},
+		}
+		require.NoError(t, mdb.load())
+		assert.Equal(t, []string{"p1", "p3"}, getPolicyIds(mdb))
+	})
+
+	t.Run("policies keep the order of the storage", func(t *testing.T) {
+		s := &fakePoliciesStorage{policies: []*policy.Policy{
+			{Id: "p1", Expression: `true`, Priority: 1},
+			{Id: "p2", Expression: `true`, Priority: 2},
+		}}
+		mdb := newPoliciesMemDb(t, s)
+
+		s.policies = []*policy.Policy{
+			{Id: "p2", Expression: `true`, Priority: 0},
+			{Id: "p1", Expression: `true`, Priority: 1},
+		}
+		require.NoError(t, mdb.load())
+		assert.Equal(t, []string{"p2", "p1"}, getPolicyIds(mdb))
+	})
+
+	t.Run("policies that do not compile are skipped", func(t *testing.T) {
+		s := &fakePoliciesStorage{policies: []*policy.Policy{
+			{Id: "p1", Expression: `model == 1`},
+			{Id: "p2", Expression: `true`},
+		}}
+		mdb := newPoliciesMemDb(t, s)
+		assert.Equal(t, []string{"p2"}, getPolicyIds(mdb))
+	})
+
+	t.Run("failed loads keep the previous policies", func(t *testing.T) {
+		s := &fakePoliciesStorage{policies: []*policy.Policy{
+			{Id: "p1", Expression: `true`},
+		}}
+		mdb := newPoliciesMemDb(t, s)
+
+		s.err = errors.New("connection refused")
+		s.policies = nil
+		assert.Error(t, mdb.load())
+		assert.Equal(t, []string{"p1"}, getPolicyIds(mdb))
+	})
+
+	t.Run("failed initial loads fail the memdb", func(t *testing.T) {
+		_, err := NewPoliciesMemDb(&fakePoliciesStorage{err: errors.New("connection refused")}, zap.NewNop(), time.Minute)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,104 @@
+package memdb
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/pricing"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"go.uber.org/zap"
+)
+
+type PricesStorage interface {
+	GetPrices() ([]*pricing.Price, error)
+}
+
+// PricesMemDb reloads the whole prices table on every tick since the table is
+// small and deleted prices have to be dropped from memory as well.
+type PricesMemDb struct {
+	external PricesStorage
+	prices   map[string]*pricing.Price
+	lock     sync.RWMutex
+	done     chan bool
+	interval time.Duration
+	log      *zap.Logger
+}
+
+func priceKey(provider, model string) string {
+	return provider + "/" + model
+}
+
+func NewPricesMemDb(ex PricesStorage, log *zap.Logger, interval time.Duration) (*PricesMemDb, error) {
+	mdb := &PricesMemDb{
+		external: ex,
+		prices:   map[string]*pricing.Price{},
+		log:      log,
+		interval: interval,
+		done:     make(chan bool),
+	}
+
+	if err := mdb.load(); err != nil {
+		return nil, err
+	}
+
+	return mdb, nil
+}
+
+func (mdb *PricesMemDb) load() error {
+	prices, err := mdb.external.GetPrices()
+	if err != nil {
+		return err
+	}
+
+	updated := map[string]*pricing.Price{}
+	for _, p := range prices {
+		updated[priceKey(p.Provider, p.Model)] = p
+	}
+
+	mdb.lock.Lock()
+	defer mdb.lock.Unlock()
+
+	mdb.prices = updated
+
+	return nil
+}
+
+// GetCostPerMillionTokens returns the overridden cost of a model if there is one.
+func (mdb *PricesMemDb) GetCostPerMillionTokens(provider, model, costType string) (float64, bool) {
+	mdb.lock.RLock()
+	defer mdb.lock.RUnlock()
+
+	p, ok := mdb.prices[priceKey(provider, model)]
+	if !ok {
+		return 0, false
+	}
+
+	return p.GetCost(costType)
+}
+
+func (mdb *PricesMemDb) Listen() {
+	ticker := time.NewTicker(mdb.interval)
+	mdb.log.Info("prices memdb started listening for price updates")
+
+	go func() {
+		for {
+			select {
+			case <-mdb.done:
+				mdb.log.Info("prices memdb stopped")
+				return
+			case <-ticker.C:
+				if err := mdb.load(); err != nil {
+					stats.Incr("bricksllm.memdb.prices_memdb.listen.get_prices_error", nil, 1)
+
+					mdb.log.Sugar().Debugf("memdb failed to get prices: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+func (mdb *PricesMemDb) Stop() {
+	mdb.log.Info("shutting down prices memdb...")
+
+	mdb.done <- true
+}
@@ -0,0 +1,109 @@
+package memdb
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/prompt"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"go.uber.org/zap"
+)
+
+type PromptTemplatesStorage interface {
+	GetPromptTemplates() ([]*prompt.Template, error)
+}
+
+// PromptTemplatesMemDb keeps every version of every template in memory so
+// that routes pinned to older versions can be rendered without a db lookup.
+type PromptTemplatesMemDb struct {
+	external  PromptTemplatesStorage
+	templates map[string]map[int]*prompt.Template
+	latest    map[string]*prompt.Template
+	lock      sync.RWMutex
+	done      chan bool
+	interval  time.Duration
+	log       *zap.Logger
+}
+
+func NewPromptTemplatesMemDb(ex PromptTemplatesStorage, log *zap.Logger, interval time.Duration) (*PromptTemplatesMemDb, error) {
+	mdb := &PromptTemplatesMemDb{
+		external:  ex,
+		templates: map[string]map[int]*prompt.Template{},
+		latest:    map[string]*prompt.Template{},
+		log:       log,
+		interval:  interval,
+		done:      make(chan bool),
+	}
+
+	if err := mdb.load(); err != nil {
+		return nil, err
+	}
+
+	return mdb, nil
+}
+
+func (mdb *PromptTemplatesMemDb) load() error {
+	templates, err := mdb.external.GetPromptTemplates()
+	if err != nil {
+		return err
+	}
+
+	versions := map[string]map[int]*prompt.Template{}
+	latest := map[string]*prompt.Template{}
+	for _, t := range templates {
+		if _, ok := versions[t.Name]; !ok {
+			versions[t.Name] = map[int]*prompt.Template{}
+		}
+
+		versions[t.Name][t.Version] = t
+		if existing, ok := latest[t.Name]; !ok || existing.Version < t.Version {
+			latest[t.Name] = t
+		}
+	}
+
+	mdb.lock.Lock()
+	defer mdb.lock.Unlock()
+
+	mdb.templates = versions
+	mdb.latest = latest
+
+	return nil
+}
+
+func (mdb *PromptTemplatesMemDb) GetPromptTemplate(name string, version int) *prompt.Template {
+	mdb.lock.RLock()
+	defer mdb.lock.RUnlock()
+
+	if version == 0 {
+		return mdb.latest[name]
+	}
+
+	return mdb.templates[name][version]
+}
+
+func (mdb *PromptTemplatesMemDb) Listen() {
+	ticker := time.NewTicker(mdb.interval)
+	mdb.log.Info("prompt templates memdb started listening for prompt template updates")
+
+	go func() {
+		for {
+			select {
+			case <-mdb.done:
+				mdb.log.Info("prompt templates memdb stopped")
+				return
+			case <-ticker.C:
+				if err := mdb.load(); err != nil {
+					stats.Incr("bricksllm.memdb.prompt_templates_memdb.listen.get_prompt_templates_error", nil, 1)
+
+					mdb.log.Sugar().Debugf("memdb failed to get prompt templates: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+func (mdb *PromptTemplatesMemDb) Stop() {
+	mdb.log.Info("shutting down prompt templates memdb...")
+
+	mdb.done <- true
+}
@@ -0,0 +1,106 @@
+package memdb
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"go.uber.org/zap"
+)
+
+type ProviderHealthStorage interface {
+	GetUnhealthy() ([]*provider.Health, error)
+}
+
+// ProviderHealthMemDb mirrors every unhealthy setting so that settings that
+// were reset are used again on the next sync.
+type ProviderHealthMemDb struct {
+	external  ProviderHealthStorage
+	unhealthy map[string]*provider.Health
+	lock      sync.RWMutex
+	done      chan bool
+	interval  time.Duration
+	log       *zap.Logger
+}
+
+func NewProviderHealthMemDb(ex ProviderHealthStorage, log *zap.Logger, interval time.Duration) (*ProviderHealthMemDb, error) {
+	mdb := &ProviderHealthMemDb{
+		external:  ex,
+		unhealthy: map[string]*provider.Health{},
+		log:       log,
+		interval:  interval,
+		done:      make(chan bool),
+	}
+
+	if err := mdb.sync(); err != nil {
+		return nil, err
+	}
+
+	return mdb, nil
+}
+
+func (mdb *ProviderHealthMemDb) sync() error {
+	healths, err := mdb.external.GetUnhealthy()
+	if err != nil {
+		return err
+	}
+
+	updated := map[string]*provider.Health{}
+	for _, h := range healths {
+		updated[h.SettingId] = h
+	}
+
+	mdb.lock.Lock()
+	defer mdb.lock.Unlock()
+
+	for id := range updated {
+		if _, ok := mdb.unhealthy[id]; !ok {
+			mdb.log.Sugar().Infof("provider health memdb picked up an unhealthy setting: %s", id)
+		}
+	}
+
+	for id := range mdb.unhealthy {
+		if _, ok := updated[id]; !ok {
+			mdb.log.Sugar().Infof("provider health memdb picked up a recovered setting: %s", id)
+		}
+	}
+
+	mdb.unhealthy = updated
+	return nil
+}
+
+func (mdb *ProviderHealthMemDb) IsUnhealthy(settingId string) bool {
+	mdb.lock.RLock()
+	defer mdb.lock.RUnlock()
+
+	_, ok := mdb.unhealthy[settingId]
+	return ok
+}
+
+func (mdb *ProviderHealthMemDb) Listen() {
+	ticker := time.NewTicker(mdb.interval)
+	mdb.log.Info("provider health memdb started listening for provider health updates")
+
+	go func() {
+		for {
+			select {
+			case <-mdb.done:
+				mdb.log.Info("provider health memdb stopped")
+				return
+			case <-ticker.C:
+				if err := mdb.sync(); err != nil {
+					stats.Incr("bricksllm.memdb.provider_health_memdb.listen.get_unhealthy_error", nil, 1)
+
+					mdb.log.Sugar().Debugf("memdb failed to update provider health: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+func (mdb *ProviderHealthMemDb) Stop() {
+	mdb.log.Info("shutting down provider health memdb...")
+
+	mdb.done <- true
+}
@@ -1,6 +1,7 @@
 package memdb
 
 import (
+	"sort"
 	"sync"
 	"time"
 
@@ -14,10 +15,19 @@ type RoutesStorage interface {
 	GetUpdatedRoutes(updatedAt int64) ([]*route.Route, error)
 }
 
+// routeKey partitions routes by tenant. Paths always start with a slash so
+// the key cannot be ambiguous.
+func routeKey(tenantId, path string) string {
+	return tenantId + path
+}
+
 type RoutesMemDb struct {
 	external    RoutesStorage
 	lastUpdated int64
 	pathToRoute map[string]*route.Route
+	patterns    map[string][]*route.Route
+	authRoutes  []*route.Route
+	corsRoutes  []*route.Route
 	lock        sync.RWMutex
 	done        chan bool
 	interval    time.Duration
@@ -26,6 +36,9 @@ type RoutesMemDb struct {
 
 func NewRoutesMemDb(ex RoutesStorage, log *zap.Logger, interval time.Duration) (*RoutesMemDb, error) {
 	pathToRoute := map[string]*route.Route{}
+	patterns := map[string][]*route.Route{}
+	authRoutes := []*route.Route{}
+	corsRoutes := []*route.Route{}
 
 	routes, err := ex.GetRoutes()
 	if err != nil {
@@ -35,7 +48,19 @@ func NewRoutesMemDb(ex RoutesStorage, log *zap.Logger, interval time.Duration) (
 	numberOfRoutes := 0
 	var latetest int64 = -1
 	for _, r := range routes {
-		pathToRoute[r.Path] = r
+		pathToRoute[routeKey(r.TenantId, r.Path)] = r
+		if route.IsPathPattern(r.Path) {
+			patterns[r.TenantId] = setPattern(patterns[r.TenantId], r)
+		}
+
+		if r.Auth != nil {
+			authRoutes = setPattern(authRoutes, r)
+		}
+
+		if r.Cors != nil {
+			corsRoutes = setPattern(corsRoutes, r)
+		}
+
 		numberOfRoutes++
 		if r.UpdatedAt > latetest {
 			latetest = r.UpdatedAt
@@ -49,6 +74,9 @@ func NewRoutesMemDb(ex RoutesStorage, log *zap.Logger, interval time.Duration) (
 	return &RoutesMemDb{
 		external:    ex,
 		pathToRoute: pathToRoute,
+		patterns:    patterns,
+		authRoutes:  authRoutes,
+		corsRoutes:  corsRoutes,
 		log:         log,
 		lastUpdated: latetest,
 		interval:    interval,
@@ -56,20 +84,98 @@ func NewRoutesMemDb(ex RoutesStorage, log *zap.Logger, interval time.Duration) (
 	}, nil
 }
 
-func (mdb *RoutesMemDb) GetRoute(path string) *route.Route {
-	r, ok := mdb.pathToRoute[path]
+// GetRoute returns the route of a literal path before trying the patterns
+// of the tenant in order of precedence.
+func (mdb *RoutesMemDb) GetRoute(tenantId, path string) *route.Route {
+	mdb.lock.RLock()
+	defer mdb.lock.RUnlock()
+
+	r, ok := mdb.pathToRoute[routeKey(tenantId, path)]
 	if ok {
 		return r
 	}
 
+	for _, pr := range mdb.patterns[tenantId] {
+		if _, ok := route.MatchPath(pr.Path, path); ok {
+			return pr
+		}
+	}
+
 	return nil
 }
 
 func (mdb *RoutesMemDb) SetRoute(r *route.Route) {
+	mdb.lock.Lock()
+	defer mdb.lock.Unlock()
+
+	mdb.pathToRoute[routeKey(r.TenantId, r.Path)] = r
+	if route.IsPathPattern(r.Path) {
+		mdb.patterns[r.TenantId] = setPattern(mdb.patterns[r.TenantId], r)
+	}
+
+	if r.Auth != nil {
+		mdb.authRoutes = setPattern(mdb.authRoutes, r)
+	}
+
+	if r.Cors != nil {
+		mdb.corsRoutes = setPattern(mdb.corsRoutes, r)
+	}
+}
+
+// GetAuthRoute returns the route with its own auth that serves a path. Their
+// paths are distinct across tenants so that they can be found before the
+// tenant of a request is known.
+func (mdb *RoutesMemDb) GetAuthRoute(path string) *route.Route {
 	mdb.lock.RLock()
 	defer mdb.lock.RUnlock()
 
-	mdb.pathToRoute[r.Path] = r
+	for _, r := range mdb.authRoutes {
+		if _, ok := route.MatchPath(r.Path, path); ok {
+			return r
+		}
+	}
+
+	return nil
+}
+
+// GetCorsRoute returns the route with its own cors policy that serves a path.
+// Preflight requests carry no credentials, so their paths are distinct across
+// tenants like the paths of routes with their own auth.
+func (mdb *RoutesMemDb) GetCorsRoute(path string) *route.Route {
+	mdb.lock.RLock()
+	defer mdb.lock.RUnlock()
+
+	for _, r := range mdb.corsRoutes {
+		if _, ok := route.MatchPath(r.Path, path); ok {
+			return r
+		}
+	}
+
+	return nil
+}
+
+// setPattern replaces or adds a route and keeps the routes ordered by the
+// precedence of their paths. Literal paths come before patterns. Routes with
+// the same path are ordered by id so that the order does not depend on the
+// order they are loaded in.
+func setPattern(routes []*route.Route, r *route.Route) []*route.Route {
+	updated := []*route.Route{}
+	for _, existing := range routes {
+		if existing.Id != r.Id {
+			updated = append(updated, existing)
+		}
+	}
+
+	updated = append(updated, r)
+	sort.SliceStable(updated, func(i, j int) bool {
+		if updated[i].Path == updated[j].Path {
+			return updated[i].Id < updated[j].Id
+		}
+
+		return route.HasPrecedence(updated[i].Path, updated[j].Path)
+	})
+
+	return updated
 }
 
 func (mdb *RoutesMemDb) Listen() {
@@ -103,7 +209,7 @@ func (mdb *RoutesMemDb) Listen() {
 						lastUpdated = r.UpdatedAt
 					}
 
-					existing := mdb.GetRoute(r.Path)
+					existing := mdb.GetRoute(r.TenantId, r.Path)
 					if existing == nil || r.UpdatedAt > existing.UpdatedAt {
 						mdb.log.Sugar().Infof("routes memdb updated a route: %s", r.Path)
 						numberOfUpdated += 1
@@ -0,0 +1,152 @@
+package memdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/cors"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeRoutesStorage struct {
+	routes []*route.Route
+}
+
+func (s *fakeRoutesStorage) GetRoutes() ([]*route.Route, error) {
+	return s.routes, nil
+}
+
+func (s *fakeRoutesStorage) GetUpdatedRoutes(updatedAt int64) ([]*route.Route, error) {
+	return nil, nil
+}
+
+func newRoutesMemDb(t *testing.T, routes ...*route.Route) *RoutesMemDb {
+	mdb, err := NewRoutesMemDb(&fakeRoutesStorage{routes: routes}, zap.NewNop(), time.Minute)
+	require.NoError(t, err)
+
+	return mdb
+}
+
+func getRouteId(r *route.Route) string {
+	if r == nil {
+		return ""
+	}
+
+	return r.Id
+}
+
+func TestRoutesMemDb_GetRoute(t *testing.T) {
+	mdb := newRoutesMemDb(t,
+		&route.Route{Id: "wildcard", Path: "/v1/*rest"},
+		&route.Route{Id: "param", Path: "/v1/:version/chat"},
+		&route.Route{Id: "literal", Path: "/v1/stable/chat"},
+		&route.Route{Id: "deep-param", Path: "/v1/:version/chat/:id"},
+		&route.Route{Id: "root", Path: "/"},
+		&route.Route{Id: "other-tenant", TenantId: "t1", Path: "/v1/:version/chat"},
+	)
+
+	cases := []struct {
+		name     string
+		tenantId string
+		path     string
+		expected string
+	}{
+		{name: "literal over parameter", path: "/v1/stable/chat", expected: "literal"},
+		{name: "parameter over wildcard", path: "/v1/beta/chat", expected: "param"},
+		{name: "longer parameter pattern over wildcard", path: "/v1/beta/chat/1", expected: "deep-param"},
+		{name: "wildcard catches the rest", path: "/v1/beta/embeddings", expected: "wildcard"},
+		{name: "wildcard catches deeper paths", path: "/v1/beta/chat/1/2", expected: "wildcard"},
+		{name: "wildcard catches trailing slashes", path: "/v1/stable/chat/", expected: "wildcard"},
+		{name: "wildcard catches empty rests", path: "/v1/", expected: "wildcard"},
+		{name: "wildcard requires its segment", path: "/v1", expected: ""},
+		{name: "root", path: "/", expected: "root"},
+		{name: "unknown path", path: "/v2/chat", expected: ""},
+		{name: "patterns of the tenant", tenantId: "t1", path: "/v1/beta/chat", expected: "other-tenant"},
+		{name: "routes of other tenants are not matched", tenantId: "t1", path: "/v1/beta/embeddings", expected: ""},
+		{name: "literals of other tenants are not matched", tenantId: "t1", path: "/", expected: ""},
+		{name: "patterns of unknown tenants are not matched", tenantId: "t2", path: "/v1/beta/chat", expected: ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, getRouteId(mdb.GetRoute(tc.tenantId, tc.path)))
+		})
+	}
+}
+
+func TestRoutesMemDb_TrailingSlashes(t *testing.T) {
+	mdb := newRoutesMemDb(t,
+		&route.Route{Id: "literal", Path: "/chat"},
+		&route.Route{Id: "slashed", Path: "/embeddings/"},
+		&route.Route{Id: "param", Path: "/models/:id"},
+	)
+
+	assert.Equal(t, "literal", getRouteId(mdb.GetRoute("", "/chat")))
+	assert.Nil(t, mdb.GetRoute("", "/chat/"))
+	assert.Equal(t, "slashed", getRouteId(mdb.GetRoute("", "/embeddings/")))
+	assert.Nil(t, mdb.GetRoute("", "/embeddings"))
+	assert.Equal(t, "param", getRouteId(mdb.GetRoute("", "/models/gpt")))
+	assert.Nil(t, mdb.GetRoute("", "/models/gpt/"))
+	assert.Nil(t, mdb.GetRoute("", "/models/"))
+}
+
+func TestRoutesMemDb_SetRoute(t *testing.T) {
+	t.Run("precedence does not depend on the order of registration", func(t *testing.T) {
+		routes := []*route.Route{
+			{Id: "wildcard", Path: "/v1/*rest"},
+			{Id: "param", Path: "/v1/:version"},
+			{Id: "literal", Path: "/v1/stable"},
+		}
+
+		forward := newRoutesMemDb(t)
+		backward := newRoutesMemDb(t)
+		for i := range routes {
+			forward.SetRoute(routes[i])
+			backward.SetRoute(routes[len(routes)-1-i])
+		}
+
+		for _, mdb := range []*RoutesMemDb{forward, backward} {
+			assert.Equal(t, "literal", getRouteId(mdb.GetRoute("", "/v1/stable")))
+			assert.Equal(t, "param", getRouteId(mdb.GetRoute("", "/v1/beta")))
+			assert.Equal(t, "wildcard", getRouteId(mdb.GetRoute("", "/v1/beta/chat")))
+		}
+	})
+
+	t.Run("updated routes replace their previous registration", func(t *testing.T) {
+		mdb := newRoutesMemDb(t, &route.Route{Id: "param", Path: "/v1/:version", UpdatedAt: 1})
+
+		mdb.SetRoute(&route.Route{Id: "param", Path: "/v1/:version", UpdatedAt: 2})
+
+		require.Len(t, mdb.patterns[""], 1)
+		assert.Equal(t, int64(2), mdb.GetRoute("", "/v1/beta").UpdatedAt)
+	})
+
+	t.Run("conflicting patterns resolve the same regardless of load order", func(t *testing.T) {
+		a := &route.Route{Id: "a", Path: "/v1/:x"}
+		b := &route.Route{Id: "b", Path: "/v1/:y"}
+		c := &route.Route{Id: "c", Path: "/v1/:x"}
+
+		for _, order := range [][]*route.Route{{a, b, c}, {c, b, a}, {b, c, a}} {
+			mdb := newRoutesMemDb(t, order...)
+			assert.Equal(t, "a", getRouteId(mdb.GetRoute("", "/v1/beta")))
+		}
+	})
+}
+
+func TestRoutesMemDb_GetAuthRoute(t *testing.T) {
+	mdb := newRoutesMemDb(t,
+		&route.Route{Id: "no-auth", Path: "/public/stable"},
+		&route.Route{Id: "t1-wildcard", TenantId: "t1", Path: "/public/*rest", Auth: &route.Auth{Mode: route.AuthModePublic}},
+		&route.Route{Id: "t2-param", TenantId: "t2", Path: "/public/:name", Auth: &route.Auth{Mode: route.AuthModePublic}},
+		&route.Route{Id: "cors", TenantId: "t2", Path: "/browser/*rest", Cors: &cors.Policy{}},
+	)
+
+	assert.Equal(t, "t2-param", getRouteId(mdb.GetAuthRoute("/public/stable")))
+	assert.Equal(t, "t1-wildcard", getRouteId(mdb.GetAuthRoute("/public/stable/chat")))
+	assert.Nil(t, mdb.GetAuthRoute("/browser/chat"))
+	assert.Equal(t, "cors", getRouteId(mdb.GetCorsRoute("/browser/chat")))
+	assert.Nil(t, mdb.GetCorsRoute("/public/stable"))
+}
@@ -0,0 +1,92 @@
+package memdb
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/stats"
+	"github.com/bricks-cloud/bricksllm/internal/tenant"
+	"go.uber.org/zap"
+)
+
+type TenantsStorage interface {
+	GetTenants() ([]*tenant.Tenant, error)
+}
+
+type TenantsMemDb struct {
+	external TenantsStorage
+	tenants  map[string]*tenant.Tenant
+	lock     sync.RWMutex
+	done     chan bool
+	interval time.Duration
+	log      *zap.Logger
+}
+
+func NewTenantsMemDb(ex TenantsStorage, log *zap.Logger, interval time.Duration) (*TenantsMemDb, error) {
+	mdb := &TenantsMemDb{
+		external: ex,
+		tenants:  map[string]*tenant.Tenant{},
+		log:      log,
+		interval: interval,
+		done:     make(chan bool),
+	}
+
+	if err := mdb.load(); err != nil {
+		return nil, err
+	}
+
+	return mdb, nil
+}
+
+func (mdb *TenantsMemDb) load() error {
+	tenants, err := mdb.external.GetTenants()
+	if err != nil {
+		return err
+	}
+
+	updated := map[string]*tenant.Tenant{}
+	for _, t := range tenants {
+		updated[t.AdminTokenHash] = t
+	}
+
+	mdb.lock.Lock()
+	defer mdb.lock.Unlock()
+
+	mdb.tenants = updated
+
+	return nil
+}
+
+func (mdb *TenantsMemDb) GetTenantByTokenHash(hash string) *tenant.Tenant {
+	mdb.lock.RLock()
+	defer mdb.lock.RUnlock()
+
+	return mdb.tenants[hash]
+}
+
+func (mdb *TenantsMemDb) Listen() {
+	ticker := time.NewTicker(mdb.interval)
+	mdb.log.Info("tenants memdb started listening for tenant updates")
+
+	go func() {
+		for {
+			select {
+			case <-mdb.done:
+				mdb.log.Info("tenants memdb stopped")
+				return
+			case <-ticker.C:
+				if err := mdb.load(); err != nil {
+					stats.Incr("bricksllm.memdb.tenants_memdb.listen.get_tenants_error", nil, 1)
+
+					mdb.log.Sugar().Debugf("memdb failed to get tenants: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+func (mdb *TenantsMemDb) Stop() {
+	mdb.log.Info("shutting down tenants memdb...")
+
+	mdb.done <- true
+}
@@ -0,0 +1,128 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/admintoken"
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+func (s *Store) CreateAdminTokensTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS admin_tokens (
+		id VARCHAR(255) PRIMARY KEY,
+		created_at BIGINT NOT NULL,
+		updated_at BIGINT NOT NULL,
+		name VARCHAR(255) NOT NULL,
+		revoked BOOLEAN NOT NULL,
+		token_hash VARCHAR(255) NOT NULL UNIQUE
+	)`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+const adminTokenColumns = "id, created_at, updated_at, name, revoked, token_hash"
+
+func scanAdminToken(row rowScanner) (*admintoken.Token, error) {
+	t := &admintoken.Token{}
+
+	if err := row.Scan(
+		&t.Id,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+		&t.Name,
+		&t.Revoked,
+		&t.TokenHash,
+	); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (s *Store) CreateAdminToken(t *admintoken.Token) (*admintoken.Token, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO admin_tokens (%s)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING %s
+	`, adminTokenColumns, adminTokenColumns)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	return scanAdminToken(s.db.QueryRowContext(ctxTimeout, query,
+		t.Id,
+		t.CreatedAt,
+		t.UpdatedAt,
+		t.Name,
+		t.Revoked,
+		t.TokenHash,
+	))
+}
+
+func (s *Store) GetAdminTokens() ([]*admintoken.Token, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, fmt.Sprintf("SELECT %s FROM admin_tokens ORDER BY created_at", adminTokenColumns))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []*admintoken.Token{}
+	for rows.Next() {
+		t, err := scanAdminToken(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, t)
+	}
+
+	return tokens, nil
+}
+
+func (s *Store) UpdateAdminToken(id string, ut *admintoken.UpdateToken) (*admintoken.Token, error) {
+	values := []any{
+		id,
+		ut.UpdatedAt,
+	}
+	fields := []string{"updated_at = $2"}
+
+	if ut.Name != nil {
+		values = append(values, *ut.Name)
+		fields = append(fields, fmt.Sprintf("name = $%d", len(values)))
+	}
+
+	if ut.Revoked != nil {
+		values = append(values, *ut.Revoked)
+		fields = append(fields, fmt.Sprintf("revoked = $%d", len(values)))
+	}
+
+	query := fmt.Sprintf("UPDATE admin_tokens SET %s WHERE id = $1 RETURNING %s", strings.Join(fields, ","), adminTokenColumns)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	updated, err := scanAdminToken(s.db.QueryRowContext(ctxTimeout, query, values...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError("admin token is not found for: " + id)
+		}
+
+		return nil, err
+	}
+
+	return updated, nil
+}
@@ -0,0 +1,251 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/lib/pq"
+)
+
+var dimensionToColumn = map[string]string{
+	event.DimensionKeyId:      "events.key_id",
+	event.DimensionTag:        "tags_table.tag",
+	event.DimensionModel:      "events.model",
+	event.DimensionProvider:   "events.provider",
+	event.DimensionRoute:      "events.route",
+	event.DimensionPath:       "events.path",
+	event.DimensionUserId:     "events.user_id",
+	event.DimensionCustomId:   "events.custom_id",
+	event.DimensionLanguage:   "events.language",
+	event.DimensionSdk:        "events.sdk",
+	event.DimensionSdkVersion: "events.sdk_version",
+	event.DimensionUserAgent:  "events.user_agent",
+}
+
+// aggregationQuery returns the query of an aggregation and its arguments.
+// Names of metadata, environment and tag dimensions are passed as arguments.
+func aggregationQuery(r *event.AggregationRequest) (string, []any, error) {
+	increment := r.Increment()
+	bucket := fmt.Sprintf("%d", r.Start)
+	if increment > 0 {
+		bucket = fmt.Sprintf("(events.created_at / %d) * %d", increment, increment)
+	}
+
+	selectQuery := fmt.Sprintf("SELECT %s AS time_stamp, COUNT(events.event_id) AS num_of_requests, COALESCE(SUM(events.cost_in_usd),0) AS cost_in_usd, COALESCE(SUM(events.prompt_token_count),0) AS prompt_token_count, COALESCE(SUM(events.completion_token_count),0) AS completion_token_count, COALESCE(SUM(CASE WHEN events.status_code = 200 THEN 1 ELSE 0 END),0) AS success_count, COUNT(events.score) AS scored_count, COALESCE(AVG(events.score),0) AS average_score, COUNT(*) FILTER (WHERE events.feedback->>'thumb' = 'up') AS thumbs_up_count, COUNT(*) FILTER (WHERE events.feedback->>'thumb' = 'down') AS thumbs_down_count, COUNT(events.feedback->'rating') AS rated_count, COALESCE(AVG((events.feedback->>'rating')::float8),0) AS average_rating", bucket)
+	groupByQuery := "GROUP BY time_stamp"
+	tagJoins := ""
+
+	args := []any{r.Start, r.End}
+	conditions := []string{"events.created_at >= $1", "events.created_at <= $2"}
+
+	for index, dimension := range r.GroupBy {
+		column, ok := dimensionToColumn[dimension]
+		if !ok && strings.HasPrefix(dimension, event.MetadataDimensionPrefix) {
+			args = append(args, strings.TrimPrefix(dimension, event.MetadataDimensionPrefix))
+			column, ok = fmt.Sprintf("events.metadata->>$%d::text", len(args)), true
+		}
+
+		if !ok && strings.HasPrefix(dimension, event.EnvironmentDimensionPrefix) {
+			args = append(args, strings.TrimPrefix(dimension, event.EnvironmentDimensionPrefix))
+			column, ok = fmt.Sprintf("events.environment->>$%d::text", len(args)), true
+		}
+
+		if !ok && strings.HasPrefix(dimension, event.TagDimensionPrefix) {
+			args = append(args, strings.TrimPrefix(dimension, event.TagDimensionPrefix))
+			alias := fmt.Sprintf("tag_dimension_%d", index)
+			tagJoins += fmt.Sprintf(" LEFT JOIN LATERAL (SELECT substring(tag FROM length($%d::text) + 2) AS value FROM unnest(events.tags) AS tag WHERE left(tag, length($%d::text) + 1) = $%d::text || ':' ORDER BY tag LIMIT 1) AS %s ON true", len(args), len(args), len(args), alias)
+			column, ok = alias+".value", true
+		}
+
+		if !ok {
+			return "", nil, fmt.Errorf("dimension %s is not supported", dimension)
+		}
+
+		// dimensions are grouped by their alias instead of repeating their
+		// expressions.
+		alias := fmt.Sprintf("dimension_%d", index)
+		selectQuery += fmt.Sprintf(", %s AS %s", column, alias)
+		groupByQuery += fmt.Sprintf(", %s", alias)
+	}
+
+	fromQuery := "FROM events"
+	if containsDimension(r.GroupBy, event.DimensionTag) {
+		fromQuery += " LEFT JOIN LATERAL unnest(events.tags) AS tags_table(tag) ON true"
+	}
+
+	fromQuery += tagJoins
+
+	if len(r.KeyIds) != 0 {
+		args = append(args, pq.Array(r.KeyIds))
+		conditions = append(conditions, fmt.Sprintf("events.key_id = ANY($%d)", len(args)))
+	}
+
+	if len(r.Tags) != 0 {
+		args = append(args, pq.Array(r.Tags))
+		conditions = append(conditions, fmt.Sprintf("events.tags @> $%d", len(args)))
+	}
+
+	if len(r.Models) != 0 {
+		args = append(args, pq.Array(r.Models))
+		conditions = append(conditions, fmt.Sprintf("events.model = ANY($%d)", len(args)))
+	}
+
+	if len(r.Providers) != 0 {
+		args = append(args, pq.Array(r.Providers))
+		conditions = append(conditions, fmt.Sprintf("events.provider = ANY($%d)", len(args)))
+	}
+
+	if len(r.TenantId) != 0 {
+		args = append(args, r.TenantId)
+		conditions = append(conditions, fmt.Sprintf("events.tenant_id = $%d", len(args)))
+	}
+
+	if len(r.Metadata) != 0 {
+		data, err := json.Marshal(r.Metadata)
+		if err != nil {
+			return "", nil, err
+		}
+
+		args = append(args, string(data))
+		conditions = append(conditions, fmt.Sprintf("events.metadata @> $%d::jsonb", len(args)))
+	}
+
+	if len(r.Environment) != 0 {
+		data, err := json.Marshal(r.Environment)
+		if err != nil {
+			return "", nil, err
+		}
+
+		args = append(args, string(data))
+		conditions = append(conditions, fmt.Sprintf("events.environment @> $%d::jsonb", len(args)))
+	}
+
+	return fmt.Sprintf("%s %s WHERE %s %s ORDER BY time_stamp", selectQuery, fromQuery, strings.Join(conditions, " AND "), groupByQuery), args, nil
+}
+
+func (s *Store) GetAggregatedEventDataPoints(r *event.AggregationRequest) ([]*event.AggregatedDataPoint, error) {
+	query, args, err := aggregationQuery(r)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	data := []*event.AggregatedDataPoint{}
+	for rows.Next() {
+		dp := &event.AggregatedDataPoint{
+			Dimensions: map[string]string{},
+		}
+
+		values := make([]sql.NullString, len(r.GroupBy))
+		dest := []any{
+			&dp.TimeStamp,
+			&dp.NumberOfRequests,
+			&dp.CostInUsd,
+			&dp.PromptTokenCount,
+			&dp.CompletionTokenCount,
+			&dp.SuccessCount,
+			&dp.ScoredCount,
+			&dp.AverageScore,
+			&dp.ThumbsUpCount,
+			&dp.ThumbsDownCount,
+			&dp.RatedCount,
+			&dp.AverageRating,
+		}
+
+		for index := range values {
+			dest = append(dest, &values[index])
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		for index, dimension := range r.GroupBy {
+			dp.Dimensions[dimension] = values[index].String
+		}
+
+		data = append(data, dp)
+	}
+
+	return data, nil
+}
+
+func containsDimension(dimensions []string, target string) bool {
+	for _, dimension := range dimensions {
+		if dimension == target {
+			return true
+		}
+	}
+
+	return false
+}
+
+var topMetricToQuery = map[string]struct {
+	name      string
+	value     string
+	condition string
+}{
+	event.TopKeysBySpend:     {name: "key_id", value: "COALESCE(SUM(cost_in_usd),0)"},
+	event.TopModelsByTokens:  {name: "model", value: "COALESCE(SUM(prompt_token_count + completion_token_count),0)"},
+	event.TopKeysByErrors:    {name: "key_id", value: "COUNT(*)", condition: "AND status_code >= 400"},
+	event.TopRoutesByLatency: {name: "COALESCE(NULLIF(route, ''), path)", value: "COALESCE(AVG(latency_in_ms),0)"},
+}
+
+func (s *Store) GetTopEntries(r *event.TopRequest) ([]*event.TopEntry, error) {
+	q, ok := topMetricToQuery[r.Metric]
+	if !ok {
+		return nil, fmt.Errorf("metric %s is not supported", r.Metric)
+	}
+
+	args := []any{r.Start, r.End, r.Limit}
+	condition := q.condition
+	if len(r.TenantId) != 0 {
+		args = append(args, r.TenantId)
+		condition += " AND tenant_id = $4"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s AS name, %s AS value, COUNT(*) AS num_of_requests
+		FROM events
+		WHERE created_at >= $1 AND created_at <= $2 %s
+		GROUP BY name
+		ORDER BY value DESC
+		LIMIT $3
+	`, q.name, q.value, condition)
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*event.TopEntry{}
+	for rows.Next() {
+		var name sql.NullString
+		entry := &event.TopEntry{}
+
+		if err := rows.Scan(&name, &entry.Value, &entry.NumberOfRequests); err != nil {
+			return nil, err
+		}
+
+		entry.Name = name.String
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,75 @@
+package postgresql
+
+import (
+	"testing"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregationQuery(t *testing.T) {
+	cases := []struct {
+		name     string
+		r        *event.AggregationRequest
+		contains []string
+		args     []any
+	}{
+		{
+			name:     "columns",
+			r:        &event.AggregationRequest{Start: 1, End: 2, GroupBy: []string{event.DimensionModel}},
+			contains: []string{"events.model AS dimension_0", "GROUP BY time_stamp, dimension_0"},
+			args:     []any{int64(1), int64(2)},
+		},
+		{
+			name:     "metadata keys are arguments",
+			r:        &event.AggregationRequest{Start: 1, End: 2, GroupBy: []string{"metadata.team"}},
+			contains: []string{"events.metadata->>$3::text AS dimension_0", "GROUP BY time_stamp, dimension_0"},
+			args:     []any{int64(1), int64(2), "team"},
+		},
+		{
+			name:     "environment labels are arguments",
+			r:        &event.AggregationRequest{Start: 1, End: 2, GroupBy: []string{"env.region"}},
+			contains: []string{"events.environment->>$3::text AS dimension_0"},
+			args:     []any{int64(1), int64(2), "region"},
+		},
+		{
+			name:     "tag names are arguments",
+			r:        &event.AggregationRequest{Start: 1, End: 2, GroupBy: []string{"tags.team"}},
+			contains: []string{"left(tag, length($3::text) + 1) = $3::text || ':'", "tag_dimension_0.value AS dimension_0"},
+			args:     []any{int64(1), int64(2), "team"},
+		},
+		{
+			name:     "filters come after dimensions",
+			r:        &event.AggregationRequest{Start: 1, End: 2, GroupBy: []string{"metadata.team", event.DimensionKeyId, "env.region"}, Models: []string{"gpt-4o"}},
+			contains: []string{"events.metadata->>$3::text AS dimension_0", "events.key_id AS dimension_1", "events.environment->>$4::text AS dimension_2", "events.model = ANY($5)", "GROUP BY time_stamp, dimension_0, dimension_1, dimension_2"},
+			args:     []any{int64(1), int64(2), "team", "region", pq.Array([]string{"gpt-4o"})},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			query, args, err := aggregationQuery(tc.r)
+			require.NoError(t, err)
+
+			for _, part := range tc.contains {
+				assert.Contains(t, query, part)
+			}
+			assert.Equal(t, tc.args, args)
+		})
+	}
+
+	t.Run("names of dimensions are not part of the query", func(t *testing.T) {
+		name := "x' OR '1'='1"
+		query, _, err := aggregationQuery(&event.AggregationRequest{Start: 1, End: 2, GroupBy: []string{"metadata." + name, "env." + name, "tags." + name}})
+		require.NoError(t, err)
+
+		assert.NotContains(t, query, name)
+	})
+
+	t.Run("unknown dimensions", func(t *testing.T) {
+		_, _, err := aggregationQuery(&event.AggregationRequest{Start: 1, End: 2, GroupBy: []string{"unknown"}})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,115 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/bricks-cloud/bricksllm/internal/bundle"
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+)
+
+func (s *Store) CreateBundlesTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS bundles (
+		tenant_id VARCHAR(255) NOT NULL DEFAULT '',
+		name VARCHAR(255) NOT NULL,
+		generation BIGINT NOT NULL,
+		applied_at BIGINT NOT NULL,
+		results JSONB NOT NULL,
+		PRIMARY KEY (tenant_id, name)
+	)`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) GetBundleStatus(tenantId, name string) (*bundle.Status, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	var data []byte
+	st := &bundle.Status{}
+	if err := s.db.QueryRowContext(ctxTimeout, "SELECT tenant_id, name, generation, applied_at, results FROM bundles WHERE tenant_id = $1 AND name = $2", tenantId, name).Scan(
+		&st.TenantId,
+		&st.Name,
+		&st.Generation,
+		&st.AppliedAt,
+		&data,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError("bundle is not found for: " + name)
+		}
+
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &st.Results); err != nil {
+		return nil, err
+	}
+
+	return st, nil
+}
+
+// ApplyBundle records the status of a bundle and writes its keys and routes
+// in a single transaction. Recording the status first locks the bundle until
+// the transaction ends, so concurrent applies of the same bundle are
+// serialized and only newer generations are written.
+func (s *Store) ApplyBundle(st *bundle.Status, created []*key.RequestKey, updated map[string]*key.UpdateKey, routes []*route.Route) error {
+	data, err := json.Marshal(st.Results)
+	if err != nil {
+		return err
+	}
+
+	return s.transaction(func(tx *Store) error {
+		query := `
+			INSERT INTO bundles (tenant_id, name, generation, applied_at, results)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (tenant_id, name) DO UPDATE
+			SET generation = EXCLUDED.generation, applied_at = EXCLUDED.applied_at, results = EXCLUDED.results
+			WHERE bundles.generation < EXCLUDED.generation
+			RETURNING generation
+		`
+
+		ctxTimeout, cancel := context.WithTimeout(context.Background(), tx.wt)
+		defer cancel()
+
+		var generation int64
+		if err := tx.db.QueryRowContext(ctxTimeout, query, st.TenantId, st.Name, st.Generation, st.AppliedAt, data).Scan(&generation); err != nil {
+			if err == sql.ErrNoRows {
+				return internal_errors.NewConflictError(fmt.Sprintf("bundle %s is already at generation %d or newer", st.Name, st.Generation))
+			}
+
+			return err
+		}
+
+		for _, rk := range created {
+			if _, err := tx.CreateKey(rk); err != nil {
+				return err
+			}
+		}
+
+		for id, uk := range updated {
+			if _, err := tx.UpdateKey(id, uk); err != nil {
+				return err
+			}
+		}
+
+		for _, r := range routes {
+			if _, err := tx.CreateRoute(r); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,22 @@
+package postgresql
+
+import (
+	"context"
+)
+
+// AlterEventsTableForClients must run after AlterEventsTableForTracing since
+// events are read with SELECT *.
+func (s *Store) AlterEventsTableForClients() error {
+	alterTableQuery := `
+		ALTER TABLE events ADD COLUMN IF NOT EXISTS user_agent VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS sdk VARCHAR(64) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS sdk_version VARCHAR(64) NOT NULL DEFAULT '';
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package postgresql
+
+import (
+	"context"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/event"
+)
+
+// AlterEventsTableForDecisions must run after AlterEventsTableForEnvironment
+// since events are read with SELECT *.
+func (s *Store) AlterEventsTableForDecisions() error {
+	alterTableQuery := `
+		ALTER TABLE events ADD COLUMN IF NOT EXISTS decisions JSONB;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetRequestEvent returns the event of a request. Events of other tenants
+// are not found unless tenantId is empty.
+func (s *Store) GetRequestEvent(tenantId, requestId string) (*event.Event, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, "SELECT * FROM events WHERE event_id = $1 AND ($2 = '' OR tenant_id = $2)", requestId, tenantId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events, err := scanEvents(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(events) == 0 {
+		return nil, internal_errors.NewNotFoundError("request " + requestId + " is not found")
+	}
+
+	return events[0], nil
+}
@@ -0,0 +1,34 @@
+package postgresql
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+)
+
+// AlterTablesForEgress must run after AlterTablesForTenants since provider
+// settings and routes are read with SELECT *.
+func (s *Store) AlterTablesForEgress() error {
+	alterTableQuery := `
+		ALTER TABLE provider_settings ADD COLUMN IF NOT EXISTS egress JSONB;
+		ALTER TABLE routes ADD COLUMN IF NOT EXISTS egress JSONB;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func unmarshalEgress(data []byte, e **provider.Egress) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(data, e)
+}
@@ -0,0 +1,22 @@
+package postgresql
+
+import (
+	"context"
+)
+
+// AlterEventsTableForEnvironment must run after AlterEventsTableForClients
+// since events are read with SELECT *.
+func (s *Store) AlterEventsTableForEnvironment() error {
+	alterTableQuery := `
+		ALTER TABLE events ADD COLUMN IF NOT EXISTS environment JSONB;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
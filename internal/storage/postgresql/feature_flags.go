@@ -0,0 +1,18 @@
+package postgresql
+
+import "context"
+
+func (s *Store) AlterKeysTableForFeatureFlags() error {
+	alterTableQuery := `
+		ALTER TABLE keys ADD COLUMN IF NOT EXISTS feature_flags JSONB
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
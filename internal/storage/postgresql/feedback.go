@@ -0,0 +1,54 @@
+package postgresql
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+)
+
+// AlterEventsTableForFeedback must run after AlterTablesForJudge since events
+// are read with SELECT *.
+func (s *Store) AlterEventsTableForFeedback() error {
+	alterTableQuery := `
+		ALTER TABLE events ADD COLUMN IF NOT EXISTS feedback JSONB;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetEventFeedback stores feedback on an event of a key. Feedback given
+// earlier is replaced. It returns false when the key has no such event.
+func (s *Store) SetEventFeedback(eventId, keyId string, f *event.Feedback) (bool, error) {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return false, err
+	}
+
+	query := `
+		UPDATE events SET feedback = $3
+		WHERE event_id = $1 AND key_id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, eventId, keyId, data)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
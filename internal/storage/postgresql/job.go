@@ -0,0 +1,187 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/event"
+)
+
+func (s *Store) CreateJobsTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS jobs (
+		id VARCHAR(255) PRIMARY KEY,
+		created_at BIGINT NOT NULL,
+		updated_at BIGINT NOT NULL,
+		key_id VARCHAR(255) NOT NULL,
+		tenant_id VARCHAR(255) NOT NULL,
+		path VARCHAR(255) NOT NULL,
+		status VARCHAR(255) NOT NULL,
+		attempts INT NOT NULL,
+		request_id VARCHAR(255) NOT NULL,
+		status_code INT NOT NULL,
+		response BYTEA,
+		error TEXT NOT NULL,
+		webhook_url TEXT NOT NULL,
+		expires_at BIGINT NOT NULL
+	);
+	ALTER TABLE jobs ADD COLUMN IF NOT EXISTS instance_id VARCHAR(255) NOT NULL DEFAULT '';
+	CREATE INDEX IF NOT EXISTS jobs_status_updated_at_idx ON jobs (status, updated_at);
+	CREATE INDEX IF NOT EXISTS jobs_expires_at_idx ON jobs (expires_at);
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+const jobColumns = "id, created_at, updated_at, key_id, tenant_id, path, status, attempts, request_id, status_code, response, error, webhook_url, expires_at, instance_id"
+
+func scanJob(row rowScanner) (*event.Job, error) {
+	j := &event.Job{}
+	var response []byte
+
+	if err := row.Scan(
+		&j.Id,
+		&j.CreatedAt,
+		&j.UpdatedAt,
+		&j.KeyId,
+		&j.TenantId,
+		&j.Path,
+		&j.Status,
+		&j.Attempts,
+		&j.RequestId,
+		&j.StatusCode,
+		&response,
+		&j.Error,
+		&j.WebhookUrl,
+		&j.ExpiresAt,
+		&j.InstanceId,
+	); err != nil {
+		return nil, err
+	}
+
+	if len(response) != 0 {
+		j.Response = response
+	}
+
+	return j, nil
+}
+
+func (s *Store) CreateJob(j *event.Job) error {
+	query := fmt.Sprintf(`
+		INSERT INTO jobs (%s)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	`, jobColumns)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctxTimeout, query,
+		j.Id,
+		j.CreatedAt,
+		j.UpdatedAt,
+		j.KeyId,
+		j.TenantId,
+		j.Path,
+		j.Status,
+		j.Attempts,
+		j.RequestId,
+		j.StatusCode,
+		[]byte(j.Response),
+		j.Error,
+		j.WebhookUrl,
+		j.ExpiresAt,
+		j.InstanceId,
+	)
+
+	return err
+}
+
+func (s *Store) GetJob(id string) (*event.Job, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	j, err := scanJob(s.db.QueryRowContext(ctxTimeout, fmt.Sprintf("SELECT %s FROM jobs WHERE id = $1", jobColumns), id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError("job is not found for: " + id)
+		}
+
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// UpdateJob stores the progress of a job. Jobs that already finished, such
+// as jobs failed as stale, are left untouched.
+func (s *Store) UpdateJob(j *event.Job) error {
+	query := `
+		UPDATE jobs SET updated_at = $2, status = $3, attempts = $4, request_id = $5, status_code = $6, response = $7, error = $8
+		WHERE id = $1 AND status NOT IN ($9, $10)
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctxTimeout, query, j.Id, j.UpdatedAt, j.Status, j.Attempts, j.RequestId, j.StatusCode, []byte(j.Response), j.Error, event.JobStatusCompleted, event.JobStatusFailed)
+	return err
+}
+
+// FailStaleJobs fails jobs that have not made progress since staleBefore,
+// which happens when the instance sending them stopped.
+func (s *Store) FailStaleJobs(staleBefore, now int64, reason string) (int64, error) {
+	query := `
+		UPDATE jobs SET status = $1, updated_at = $2, error = $3
+		WHERE status IN ($4, $5) AND updated_at < $6
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctxTimeout, query, event.JobStatusFailed, now, reason, event.JobStatusQueued, event.JobStatusRunning, staleBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// FailInstanceJobs fails the jobs that an instance did not finish before it
+// was restarted.
+func (s *Store) FailInstanceJobs(instanceId string, now int64, reason string) (int64, error) {
+	query := `
+		UPDATE jobs SET status = $1, updated_at = $2, error = $3
+		WHERE status IN ($4, $5) AND instance_id = $6
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctxTimeout, query, event.JobStatusFailed, now, reason, event.JobStatusQueued, event.JobStatusRunning, instanceId)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (s *Store) DeleteExpiredJobs(now int64) (int64, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctxTimeout, "DELETE FROM jobs WHERE expires_at < $1", now)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,48 @@
+package postgresql
+
+import (
+	"context"
+)
+
+// AlterTablesForJudge must run after AlterRoutesTableForCompression and
+// AlterEventsTableForSettings since routes and events are read with SELECT *.
+func (s *Store) AlterTablesForJudge() error {
+	alterTableQuery := `
+		ALTER TABLE routes ADD COLUMN IF NOT EXISTS judge JSONB;
+		ALTER TABLE events ADD COLUMN IF NOT EXISTS score FLOAT8;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetEventScore stores the score of the judge on an event. The reason is kept
+// in the metadata of the event under reasonKey. It returns false when the
+// event has not been inserted yet.
+func (s *Store) SetEventScore(eventId string, score float64, reasonKey, reason string) (bool, error) {
+	query := `
+		UPDATE events SET score = $2, metadata = COALESCE(metadata, '{}'::jsonb) || jsonb_build_object($3::text, $4::text)
+		WHERE event_id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, eventId, score, reasonKey, reason)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
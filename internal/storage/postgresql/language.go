@@ -0,0 +1,23 @@
+package postgresql
+
+import (
+	"context"
+)
+
+// AlterTablesForLanguage must run after AlterRoutesTableForDowngrade and
+// AlterTablesForSessions since routes and events are read with SELECT *.
+func (s *Store) AlterTablesForLanguage() error {
+	alterTableQuery := `
+		ALTER TABLE routes ADD COLUMN IF NOT EXISTS language JSONB;
+		ALTER TABLE events ADD COLUMN IF NOT EXISTS language VARCHAR(255) NOT NULL DEFAULT '';
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
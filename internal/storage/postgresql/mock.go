@@ -0,0 +1,204 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/mock"
+)
+
+func (s *Store) CreateMockResponsesTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS mock_responses (
+		id VARCHAR(255) PRIMARY KEY,
+		created_at BIGINT NOT NULL,
+		updated_at BIGINT NOT NULL,
+		model VARCHAR(255) NOT NULL UNIQUE,
+		content TEXT NOT NULL,
+		body JSONB,
+		latency_in_ms INT NOT NULL,
+		chunk_delay_in_ms INT NOT NULL,
+		error_rate FLOAT8 NOT NULL,
+		error_status_code INT NOT NULL
+	)`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+const mockResponseColumns = "id, created_at, updated_at, model, content, body, latency_in_ms, chunk_delay_in_ms, error_rate, error_status_code"
+
+func scanMockResponse(row rowScanner) (*mock.Response, error) {
+	r := &mock.Response{}
+	var body []byte
+
+	if err := row.Scan(
+		&r.Id,
+		&r.CreatedAt,
+		&r.UpdatedAt,
+		&r.Model,
+		&r.Content,
+		&body,
+		&r.LatencyInMs,
+		&r.ChunkDelayInMs,
+		&r.ErrorRate,
+		&r.ErrorStatusCode,
+	); err != nil {
+		return nil, err
+	}
+
+	if len(body) != 0 {
+		r.Body = body
+	}
+
+	return r, nil
+}
+
+func nullableJson(data []byte) any {
+	if len(data) == 0 {
+		return nil
+	}
+
+	return data
+}
+
+func (s *Store) CreateMockResponse(r *mock.Response) (*mock.Response, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO mock_responses (%s)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (model) DO NOTHING
+		RETURNING %s
+	`, mockResponseColumns, mockResponseColumns)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	created, err := scanMockResponse(s.db.QueryRowContext(ctxTimeout, query,
+		r.Id,
+		r.CreatedAt,
+		r.UpdatedAt,
+		r.Model,
+		r.Content,
+		nullableJson(r.Body),
+		r.LatencyInMs,
+		r.ChunkDelayInMs,
+		r.ErrorRate,
+		r.ErrorStatusCode,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, NewDuplicationError(fmt.Sprintf("mock response of model %s already exists", r.Model))
+		}
+
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (s *Store) GetMockResponses() ([]*mock.Response, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, fmt.Sprintf("SELECT %s FROM mock_responses ORDER BY model", mockResponseColumns))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	responses := []*mock.Response{}
+	for rows.Next() {
+		r, err := scanMockResponse(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		responses = append(responses, r)
+	}
+
+	return responses, nil
+}
+
+func (s *Store) UpdateMockResponse(id string, ur *mock.UpdateResponse) (*mock.Response, error) {
+	values := []any{
+		id,
+		ur.UpdatedAt,
+	}
+	fields := []string{"updated_at = $2"}
+
+	if ur.Content != nil {
+		values = append(values, *ur.Content)
+		fields = append(fields, fmt.Sprintf("content = $%d", len(values)))
+	}
+
+	if ur.Body != nil {
+		values = append(values, nullableJson(*ur.Body))
+		fields = append(fields, fmt.Sprintf("body = $%d", len(values)))
+	}
+
+	if ur.LatencyInMs != nil {
+		values = append(values, *ur.LatencyInMs)
+		fields = append(fields, fmt.Sprintf("latency_in_ms = $%d", len(values)))
+	}
+
+	if ur.ChunkDelayInMs != nil {
+		values = append(values, *ur.ChunkDelayInMs)
+		fields = append(fields, fmt.Sprintf("chunk_delay_in_ms = $%d", len(values)))
+	}
+
+	if ur.ErrorRate != nil {
+		values = append(values, *ur.ErrorRate)
+		fields = append(fields, fmt.Sprintf("error_rate = $%d", len(values)))
+	}
+
+	if ur.ErrorStatusCode != nil {
+		values = append(values, *ur.ErrorStatusCode)
+		fields = append(fields, fmt.Sprintf("error_status_code = $%d", len(values)))
+	}
+
+	query := fmt.Sprintf("UPDATE mock_responses SET %s WHERE id = $1 RETURNING %s", strings.Join(fields, ","), mockResponseColumns)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	updated, err := scanMockResponse(s.db.QueryRowContext(ctxTimeout, query, values...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError("mock response is not found for: " + id)
+		}
+
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+func (s *Store) DeleteMockResponse(id string) error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctxTimeout, "DELETE FROM mock_responses WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return internal_errors.NewNotFoundError("mock response is not found for: " + id)
+	}
+
+	return nil
+}
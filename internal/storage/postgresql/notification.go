@@ -0,0 +1,199 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/notification"
+	"github.com/lib/pq"
+)
+
+func (s *Store) CreateNotificationIntegrationsTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS notification_integrations (
+		id VARCHAR(255) PRIMARY KEY,
+		created_at BIGINT NOT NULL,
+		updated_at BIGINT NOT NULL,
+		name VARCHAR(255) NOT NULL DEFAULT '',
+		type VARCHAR(255) NOT NULL,
+		events TEXT[] NOT NULL,
+		settings JSONB NOT NULL
+	)`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+const integrationColumns = "id, created_at, updated_at, name, type, events, settings"
+
+// integrationSettings holds the settings of every integration type in the
+// settings column.
+type integrationSettings struct {
+	Slack     *notification.Slack     `json:"slack,omitempty"`
+	PagerDuty *notification.PagerDuty `json:"pagerDuty,omitempty"`
+	Opsgenie  *notification.Opsgenie  `json:"opsgenie,omitempty"`
+}
+
+func scanIntegration(row rowScanner) (*notification.Integration, error) {
+	i := &notification.Integration{}
+	var settings []byte
+
+	if err := row.Scan(
+		&i.Id,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Type,
+		pq.Array(&i.Events),
+		&settings,
+	); err != nil {
+		return nil, err
+	}
+
+	is := &integrationSettings{}
+	if err := json.Unmarshal(settings, is); err != nil {
+		return nil, err
+	}
+
+	i.Slack = is.Slack
+	i.PagerDuty = is.PagerDuty
+	i.Opsgenie = is.Opsgenie
+
+	return i, nil
+}
+
+func marshalIntegrationSettings(i *notification.Integration) ([]byte, error) {
+	return json.Marshal(&integrationSettings{
+		Slack:     i.Slack,
+		PagerDuty: i.PagerDuty,
+		Opsgenie:  i.Opsgenie,
+	})
+}
+
+func (s *Store) CreateIntegration(i *notification.Integration) (*notification.Integration, error) {
+	settings, err := marshalIntegrationSettings(i)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO notification_integrations (%s)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING %s
+	`, integrationColumns, integrationColumns)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	return scanIntegration(s.db.QueryRowContext(ctxTimeout, query,
+		i.Id,
+		i.CreatedAt,
+		i.UpdatedAt,
+		i.Name,
+		i.Type,
+		pq.Array(i.Events),
+		settings,
+	))
+}
+
+func (s *Store) GetIntegrations() ([]*notification.Integration, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, fmt.Sprintf("SELECT %s FROM notification_integrations ORDER BY created_at", integrationColumns))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	integrations := []*notification.Integration{}
+	for rows.Next() {
+		i, err := scanIntegration(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		integrations = append(integrations, i)
+	}
+
+	return integrations, nil
+}
+
+func (s *Store) GetIntegration(id string) (*notification.Integration, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	i, err := scanIntegration(s.db.QueryRowContext(ctxTimeout, fmt.Sprintf("SELECT %s FROM notification_integrations WHERE id = $1", integrationColumns), id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError("notification integration is not found for: " + id)
+		}
+
+		return nil, err
+	}
+
+	return i, nil
+}
+
+func (s *Store) UpdateIntegration(i *notification.Integration) (*notification.Integration, error) {
+	settings, err := marshalIntegrationSettings(i)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE notification_integrations SET updated_at = $2, name = $3, events = $4, settings = $5
+		WHERE id = $1
+		RETURNING %s
+	`, integrationColumns)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	updated, err := scanIntegration(s.db.QueryRowContext(ctxTimeout, query,
+		i.Id,
+		i.UpdatedAt,
+		i.Name,
+		pq.Array(i.Events),
+		settings,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError("notification integration is not found for: " + i.Id)
+		}
+
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+func (s *Store) DeleteIntegration(id string) error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctxTimeout, "DELETE FROM notification_integrations WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return internal_errors.NewNotFoundError("notification integration is not found for: " + id)
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package postgresql
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+)
+
+func (s *Store) AlterKeysTableForParameters() error {
+	alterTableQuery := `
+		ALTER TABLE keys ADD COLUMN IF NOT EXISTS parameters JSONB
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func setParameters(pk *key.ResponseKey, data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	pm := &key.Parameters{}
+	if err := json.Unmarshal(data, pm); err != nil {
+		return err
+	}
+
+	pk.Parameters = pm
+
+	return nil
+}
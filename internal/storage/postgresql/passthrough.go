@@ -0,0 +1,168 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/passthrough"
+)
+
+func (s *Store) CreatePassThroughsTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS pass_throughs (
+		id VARCHAR(255) PRIMARY KEY,
+		created_at BIGINT NOT NULL,
+		updated_at BIGINT NOT NULL,
+		provider VARCHAR(255) NOT NULL UNIQUE,
+		usage_rules JSONB,
+		disabled BOOLEAN NOT NULL
+	)`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+const passThroughColumns = "id, created_at, updated_at, provider, usage_rules, disabled"
+
+func scanPassThrough(row rowScanner) (*passthrough.PassThrough, error) {
+	p := &passthrough.PassThrough{}
+	var rules []byte
+
+	if err := row.Scan(
+		&p.Id,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+		&p.Provider,
+		&rules,
+		&p.Disabled,
+	); err != nil {
+		return nil, err
+	}
+
+	p.UsageRules = []*passthrough.UsageRule{}
+	if len(rules) != 0 {
+		if err := json.Unmarshal(rules, &p.UsageRules); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+func (s *Store) CreatePassThrough(p *passthrough.PassThrough) (*passthrough.PassThrough, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO pass_throughs (%s)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING %s
+	`, passThroughColumns, passThroughColumns)
+
+	rules, err := json.Marshal(p.UsageRules)
+	if err != nil {
+		return nil, err
+	}
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	return scanPassThrough(s.db.QueryRowContext(ctxTimeout, query,
+		p.Id,
+		p.CreatedAt,
+		p.UpdatedAt,
+		p.Provider,
+		rules,
+		p.Disabled,
+	))
+}
+
+func (s *Store) GetPassThroughs() ([]*passthrough.PassThrough, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, fmt.Sprintf("SELECT %s FROM pass_throughs ORDER BY created_at", passThroughColumns))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pts := []*passthrough.PassThrough{}
+	for rows.Next() {
+		p, err := scanPassThrough(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		pts = append(pts, p)
+	}
+
+	return pts, nil
+}
+
+func (s *Store) UpdatePassThrough(id string, up *passthrough.UpdatePassThrough) (*passthrough.PassThrough, error) {
+	values := []any{
+		id,
+		up.UpdatedAt,
+	}
+	fields := []string{"updated_at = $2"}
+
+	if up.UsageRules != nil {
+		rules, err := json.Marshal(*up.UsageRules)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, rules)
+		fields = append(fields, fmt.Sprintf("usage_rules = $%d", len(values)))
+	}
+
+	if up.Disabled != nil {
+		values = append(values, *up.Disabled)
+		fields = append(fields, fmt.Sprintf("disabled = $%d", len(values)))
+	}
+
+	query := fmt.Sprintf("UPDATE pass_throughs SET %s WHERE id = $1 RETURNING %s", strings.Join(fields, ","), passThroughColumns)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	updated, err := scanPassThrough(s.db.QueryRowContext(ctxTimeout, query, values...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError("pass-through is not found for: " + id)
+		}
+
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+func (s *Store) DeletePassThrough(id string) error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctxTimeout, "DELETE FROM pass_throughs WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return internal_errors.NewNotFoundError("pass-through is not found for: " + id)
+	}
+
+	return nil
+}
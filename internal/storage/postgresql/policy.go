@@ -0,0 +1,210 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+)
+
+func (s *Store) CreatePoliciesTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS policies (
+		id VARCHAR(255) PRIMARY KEY,
+		created_at BIGINT NOT NULL,
+		updated_at BIGINT NOT NULL,
+		name VARCHAR(255) NOT NULL,
+		expression TEXT NOT NULL,
+		action VARCHAR(255) NOT NULL,
+		model VARCHAR(255) NOT NULL,
+		max_tokens INT NOT NULL,
+		priority INT NOT NULL,
+		message TEXT NOT NULL,
+		disabled BOOLEAN NOT NULL
+	)`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+const policyColumns = "id, created_at, updated_at, name, expression, action, model, max_tokens, priority, message, disabled"
+
+func scanPolicy(row rowScanner) (*policy.Policy, error) {
+	p := &policy.Policy{}
+
+	if err := row.Scan(
+		&p.Id,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+		&p.Name,
+		&p.Expression,
+		&p.Action,
+		&p.Model,
+		&p.MaxTokens,
+		&p.Priority,
+		&p.Message,
+		&p.Disabled,
+	); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (s *Store) CreatePolicy(p *policy.Policy) (*policy.Policy, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO policies (%s)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING %s
+	`, policyColumns, policyColumns)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	return scanPolicy(s.db.QueryRowContext(ctxTimeout, query,
+		p.Id,
+		p.CreatedAt,
+		p.UpdatedAt,
+		p.Name,
+		p.Expression,
+		p.Action,
+		p.Model,
+		p.MaxTokens,
+		p.Priority,
+		p.Message,
+		p.Disabled,
+	))
+}
+
+func (s *Store) GetPolicy(id string) (*policy.Policy, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	p, err := scanPolicy(s.db.QueryRowContext(ctxTimeout, fmt.Sprintf("SELECT %s FROM policies WHERE id = $1", policyColumns), id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError("policy is not found for: " + id)
+		}
+
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (s *Store) GetPolicies() ([]*policy.Policy, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, fmt.Sprintf("SELECT %s FROM policies ORDER BY priority, created_at", policyColumns))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := []*policy.Policy{}
+	for rows.Next() {
+		p, err := scanPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		policies = append(policies, p)
+	}
+
+	return policies, nil
+}
+
+func (s *Store) UpdatePolicy(id string, up *policy.UpdatePolicy) (*policy.Policy, error) {
+	values := []any{
+		id,
+		up.UpdatedAt,
+	}
+	fields := []string{"updated_at = $2"}
+
+	if up.Name != nil {
+		values = append(values, *up.Name)
+		fields = append(fields, fmt.Sprintf("name = $%d", len(values)))
+	}
+
+	if up.Expression != nil {
+		values = append(values, *up.Expression)
+		fields = append(fields, fmt.Sprintf("expression = $%d", len(values)))
+	}
+
+	if up.Action != nil {
+		values = append(values, *up.Action)
+		fields = append(fields, fmt.Sprintf("action = $%d", len(values)))
+	}
+
+	if up.Model != nil {
+		values = append(values, *up.Model)
+		fields = append(fields, fmt.Sprintf("model = $%d", len(values)))
+	}
+
+	if up.MaxTokens != nil {
+		values = append(values, *up.MaxTokens)
+		fields = append(fields, fmt.Sprintf("max_tokens = $%d", len(values)))
+	}
+
+	if up.Priority != nil {
+		values = append(values, *up.Priority)
+		fields = append(fields, fmt.Sprintf("priority = $%d", len(values)))
+	}
+
+	if up.Message != nil {
+		values = append(values, *up.Message)
+		fields = append(fields, fmt.Sprintf("message = $%d", len(values)))
+	}
+
+	if up.Disabled != nil {
+		values = append(values, *up.Disabled)
+		fields = append(fields, fmt.Sprintf("disabled = $%d", len(values)))
+	}
+
+	query := fmt.Sprintf("UPDATE policies SET %s WHERE id = $1 RETURNING %s", strings.Join(fields, ","), policyColumns)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	updated, err := scanPolicy(s.db.QueryRowContext(ctxTimeout, query, values...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError("policy is not found for: " + id)
+		}
+
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+func (s *Store) DeletePolicy(id string) error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctxTimeout, "DELETE FROM policies WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return internal_errors.NewNotFoundError("policy is not found for: " + id)
+	}
+
+	return nil
+}
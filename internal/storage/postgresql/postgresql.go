@@ -7,22 +7,34 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net"
 	"strings"
 	"time"
 
 	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
 	"github.com/bricks-cloud/bricksllm/internal/event"
 	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/prompt"
 	"github.com/bricks-cloud/bricksllm/internal/provider"
 
 	"github.com/lib/pq"
 	_ "github.com/lib/pq"
 )
 
+// executor is implemented by both connections and transactions so that
+// queries of the store can run inside a transaction.
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
 type Store struct {
-	db *sql.DB
-	wt time.Duration
-	rt time.Duration
+	db   executor
+	conn *sql.DB
+	wt   time.Duration
+	rt   time.Duration
 }
 
 func NewStore(connStr string, wt time.Duration, rt time.Duration) (*Store, error) {
@@ -32,12 +44,37 @@ func NewStore(connStr string, wt time.Duration, rt time.Duration) (*Store, error
 	}
 
 	return &Store{
-		db: db,
-		wt: wt,
-		rt: rt,
+		db:   db,
+		conn: db,
+		wt:   wt,
+		rt:   rt,
 	}, nil
 }
 
+// Ping checks that the database is reachable.
+func (s *Store) Ping() error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	return s.conn.PingContext(ctxTimeout)
+}
+
+// transaction runs fn with a store whose queries are part of a single
+// transaction. The transaction is committed if fn succeeds.
+func (s *Store) transaction(fn func(tx *Store) error) error {
+	tx, err := s.conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(&Store{db: tx, conn: s.conn, wt: s.wt, rt: s.rt}); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
 func (s *Store) CreateProviderSettingsTable() error {
 	createTableQuery := `
 	CREATE TABLE IF NOT EXISTS provider_settings (
@@ -87,6 +124,10 @@ func (s *Store) CreateKeysTable() error {
 	return nil
 }
 
+// keyColumns are the columns of keys in the order they are scanned in, so
+// that scans do not depend on the order columns were added in.
+const keyColumns = "name, created_at, updated_at, tags, revoked, key_id, key, revoked_reason, cost_limit_in_usd, cost_limit_in_usd_over_time, cost_limit_in_usd_unit, rate_limit_over_time, rate_limit_unit, ttl, setting_id, allowed_paths, setting_ids, system_prompt, tenant_id, output_caps, session_limits, loop_protection, model_policy, schedule, personal_cost_limit_in_usd, allowed_regions, recording_retention, feature_flags, signing, parameters, stream_guardrails"
+
 func (s *Store) AlterKeysTable() error {
 	alterTableQuery := `
 		DO $$
@@ -101,7 +142,82 @@ func (s *Store) AlterKeysTable() error {
 			END IF;
 		END
 		$$;
-		ALTER TABLE keys ADD COLUMN IF NOT EXISTS setting_id VARCHAR(255), ADD COLUMN IF NOT EXISTS allowed_paths JSONB, ADD COLUMN IF NOT EXISTS setting_ids VARCHAR(255)[] NOT NULL DEFAULT ARRAY[]::VARCHAR(255)[];
+		ALTER TABLE keys ADD COLUMN IF NOT EXISTS setting_id VARCHAR(255), ADD COLUMN IF NOT EXISTS allowed_paths JSONB, ADD COLUMN IF NOT EXISTS setting_ids VARCHAR(255)[] NOT NULL DEFAULT ARRAY[]::VARCHAR(255)[], ADD COLUMN IF NOT EXISTS system_prompt JSONB;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) AlterKeysTableForOutputCaps() error {
+	alterTableQuery := `
+		ALTER TABLE keys ADD COLUMN IF NOT EXISTS output_caps JSONB
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) AlterKeysTableForLoopProtection() error {
+	alterTableQuery := `
+		ALTER TABLE keys ADD COLUMN IF NOT EXISTS loop_protection JSONB
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) AlterKeysTableForModelPolicy() error {
+	alterTableQuery := `
+		ALTER TABLE keys ADD COLUMN IF NOT EXISTS model_policy JSONB
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) AlterKeysTableForSchedule() error {
+	alterTableQuery := `
+		ALTER TABLE keys ADD COLUMN IF NOT EXISTS schedule JSONB
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) AlterKeysTableForPersonalCostLimit() error {
+	alterTableQuery := `
+		ALTER TABLE keys ADD COLUMN IF NOT EXISTS personal_cost_limit_in_usd FLOAT8
 	`
 
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
@@ -142,7 +258,7 @@ func (s *Store) CreateEventsTable() error {
 
 func (s *Store) AlterEventsTable() error {
 	alterTableQuery := `
-		ALTER TABLE events ADD COLUMN IF NOT EXISTS path VARCHAR(255), ADD COLUMN IF NOT EXISTS method VARCHAR(255), ADD COLUMN IF NOT EXISTS custom_id VARCHAR(255)
+		ALTER TABLE events ADD COLUMN IF NOT EXISTS path VARCHAR(255), ADD COLUMN IF NOT EXISTS method VARCHAR(255), ADD COLUMN IF NOT EXISTS custom_id VARCHAR(255), ADD COLUMN IF NOT EXISTS user_id VARCHAR(255), ADD COLUMN IF NOT EXISTS route VARCHAR(255), ADD COLUMN IF NOT EXISTS metadata JSONB, ADD COLUMN IF NOT EXISTS original_cost_in_usd FLOAT8, ADD COLUMN IF NOT EXISTS correlation_id VARCHAR(255)
 	`
 
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
@@ -209,12 +325,44 @@ func (s *Store) DropKeysTable() error {
 	return nil
 }
 
-func (s *Store) InsertEvent(e *event.Event) error {
-	query := `
-		INSERT INTO events (event_id, created_at, tags, key_id, cost_in_usd, provider, model, status_code, prompt_token_count, completion_token_count, latency_in_ms, path, method, custom_id)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+const insertEventQuery = `
+		INSERT INTO events (event_id, created_at, tags, key_id, cost_in_usd, provider, model, status_code, prompt_token_count, completion_token_count, latency_in_ms, path, method, custom_id, user_id, route, metadata, correlation_id, tenant_id, session_id, language, setting_id, parent_id, trace_id, span_id, user_agent, sdk, sdk_version, environment, decisions)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30)
 	`
 
+func (s *Store) InsertEvent(e *event.Event) error {
+	query := insertEventQuery
+
+	var metadata []byte
+	if len(e.Metadata) != 0 {
+		data, err := json.Marshal(e.Metadata)
+		if err != nil {
+			return err
+		}
+
+		metadata = data
+	}
+
+	var environment []byte
+	if len(e.Environment) != 0 {
+		data, err := json.Marshal(e.Environment)
+		if err != nil {
+			return err
+		}
+
+		environment = data
+	}
+
+	var decisions []byte
+	if len(e.Decisions) != 0 {
+		data, err := json.Marshal(e.Decisions)
+		if err != nil {
+			return err
+		}
+
+		decisions = data
+	}
+
 	values := []any{
 		e.Id,
 		e.CreatedAt,
@@ -230,17 +378,58 @@ func (s *Store) InsertEvent(e *event.Event) error {
 		e.Path,
 		e.Method,
 		e.CustomId,
+		e.UserId,
+		e.Route,
+		metadata,
+		e.CorrelationId,
+		e.TenantId,
+		e.SessionId,
+		e.Language,
+		e.SettingId,
+		e.ParentId,
+		e.TraceId,
+		e.SpanId,
+		e.UserAgent,
+		e.Sdk,
+		e.SdkVersion,
+		environment,
+		decisions,
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), s.wt)
 	defer cancel()
 	if _, err := s.db.ExecContext(ctx, query, values...); err != nil {
+		if isUnavailable(err) {
+			return internal_errors.NewUnavailableError("postgresql is unavailable: " + err.Error())
+		}
+
 		return err
 	}
 
 	return nil
 }
 
+// isUnavailable reports whether an error was caused by the database not being
+// reachable rather than by the query.
+func isUnavailable(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var ne net.Error
+	if errors.As(err, &ne) {
+		return true
+	}
+
+	var pe *pq.Error
+	if errors.As(err, &pe) {
+		// connection exceptions and shutdowns during a failover.
+		return pe.Code.Class() == "08" || pe.Code == "57P01" || pe.Code == "57P02" || pe.Code == "57P03"
+	}
+
+	return false
+}
+
 func (s *Store) GetEvents(customId string, keyIds []string, start int64, end int64) ([]*event.Event, error) {
 	if len(customId) == 0 && len(keyIds) == 0 {
 		return nil, errors.New("neither customId nor keyIds are specified")
@@ -269,21 +458,39 @@ func (s *Store) GetEvents(customId string, keyIds []string, start int64, end int
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
 	defer cancel()
 
-	events := []*event.Event{}
 	rows, err := s.db.QueryContext(ctxTimeout, query)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return events, nil
+			return []*event.Event{}, nil
 		}
 		return nil, err
 	}
 	defer rows.Close()
 
+	return scanEvents(rows)
+}
+
+// scanEvents reads rows selected with SELECT * from the events table. The scan
+// order follows the column order produced by CreateEventsTable, AlterEventsTable,
+// AlterTablesForTenants, AlterTablesForSessions and the later alterations of
+// the events table up to AlterEventsTableForDecisions.
+func scanEvents(rows *sql.Rows) ([]*event.Event, error) {
+	events := []*event.Event{}
 	for rows.Next() {
 		var e event.Event
 		var path sql.NullString
 		var method sql.NullString
 		var customId sql.NullString
+		var userId sql.NullString
+		var route sql.NullString
+		var metadata []byte
+		var originalCost sql.NullFloat64
+		var correlationId sql.NullString
+		var sessionId sql.NullString
+		var score sql.NullFloat64
+		var feedback []byte
+		var environment []byte
+		var decisions []byte
 
 		if err := rows.Scan(
 			&e.Id,
@@ -300,14 +507,67 @@ func (s *Store) GetEvents(customId string, keyIds []string, start int64, end int
 			&path,
 			&method,
 			&customId,
+			&userId,
+			&route,
+			&metadata,
+			&originalCost,
+			&correlationId,
+			&e.TenantId,
+			&sessionId,
+			&e.Language,
+			&e.SettingId,
+			&score,
+			&feedback,
+			&e.ParentId,
+			&e.TraceId,
+			&e.SpanId,
+			&e.UserAgent,
+			&e.Sdk,
+			&e.SdkVersion,
+			&environment,
+			&decisions,
 		); err != nil {
 			return nil, err
 		}
 
+		if len(metadata) != 0 {
+			if err := json.Unmarshal(metadata, &e.Metadata); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(environment) != 0 {
+			if err := json.Unmarshal(environment, &e.Environment); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(decisions) != 0 {
+			if err := json.Unmarshal(decisions, &e.Decisions); err != nil {
+				return nil, err
+			}
+		}
+
 		pe := &e
 		pe.Path = path.String
 		pe.Method = method.String
 		pe.CustomId = customId.String
+		pe.UserId = userId.String
+		pe.Route = route.String
+		pe.OriginalCostInUsd = originalCost.Float64
+		pe.CorrelationId = correlationId.String
+		pe.SessionId = sessionId.String
+
+		if score.Valid {
+			pe.Score = &score.Float64
+		}
+
+		if len(feedback) != 0 {
+			pe.Feedback = &event.Feedback{}
+			if err := json.Unmarshal(feedback, pe.Feedback); err != nil {
+				return nil, err
+			}
+		}
 
 		events = append(events, pe)
 	}
@@ -507,7 +767,7 @@ func (s *Store) GetKeys(tags, keyIds []string, provider string) ([]*key.Response
 
 	query := ""
 
-	selectionQuery := "SELECT * FROM keys "
+	selectionQuery := fmt.Sprintf("SELECT %s FROM keys ", keyColumns)
 
 	index := 1
 	if len(tags) != 0 {
@@ -564,6 +824,17 @@ func (s *Store) GetKeys(tags, keyIds []string, provider string) ([]*key.Response
 	for rows.Next() {
 		var k key.ResponseKey
 		var settingId sql.NullString
+		var spdata []byte
+		var ocdata []byte
+		var sldata []byte
+		var lpdata []byte
+		var mpdata []byte
+		var scdata []byte
+		var ffdata []byte
+		var sgdata []byte
+		var pmdata []byte
+		var sgrdata []byte
+		var pcl sql.NullFloat64
 		var data []byte
 		if err := rows.Scan(
 			&k.Name,
@@ -583,6 +854,20 @@ func (s *Store) GetKeys(tags, keyIds []string, provider string) ([]*key.Response
 			&settingId,
 			&data,
 			pq.Array(&k.SettingIds),
+			&spdata,
+			&k.TenantId,
+			&ocdata,
+			&sldata,
+			&lpdata,
+			&mpdata,
+			&scdata,
+			&pcl,
+			pq.Array(&k.AllowedRegions),
+			&k.RecordingRetention,
+			&ffdata,
+			&sgdata,
+			&pmdata,
+			&sgrdata,
 		); err != nil {
 			return nil, err
 		}
@@ -599,6 +884,83 @@ func (s *Store) GetKeys(tags, keyIds []string, provider string) ([]*key.Response
 			pk.AllowedPaths = pathConfigs
 		}
 
+		if len(spdata) != 0 {
+			sp := &prompt.SystemPrompt{}
+			if err := json.Unmarshal(spdata, sp); err != nil {
+				return nil, err
+			}
+
+			pk.SystemPrompt = sp
+		}
+
+		if len(ocdata) != 0 {
+			oc := &key.OutputCaps{}
+			if err := json.Unmarshal(ocdata, oc); err != nil {
+				return nil, err
+			}
+
+			pk.OutputCaps = oc
+		}
+
+		if len(sldata) != 0 {
+			sl := &key.SessionLimits{}
+			if err := json.Unmarshal(sldata, sl); err != nil {
+				return nil, err
+			}
+
+			pk.SessionLimits = sl
+		}
+
+		if len(lpdata) != 0 {
+			lp := &key.LoopProtection{}
+			if err := json.Unmarshal(lpdata, lp); err != nil {
+				return nil, err
+			}
+
+			pk.LoopProtection = lp
+		}
+
+		if len(mpdata) != 0 {
+			mp := &key.ModelPolicy{}
+			if err := json.Unmarshal(mpdata, mp); err != nil {
+				return nil, err
+			}
+
+			pk.ModelPolicy = mp
+		}
+
+		if len(scdata) != 0 {
+			sc := &key.Schedule{}
+			if err := json.Unmarshal(scdata, sc); err != nil {
+				return nil, err
+			}
+
+			pk.Schedule = sc
+		}
+
+		if len(ffdata) != 0 {
+			ff := map[string]bool{}
+			if err := json.Unmarshal(ffdata, &ff); err != nil {
+				return nil, err
+			}
+
+			pk.FeatureFlags = ff
+		}
+
+		if err := setSigning(pk, sgdata); err != nil {
+			return nil, err
+		}
+
+		if err := setParameters(pk, pmdata); err != nil {
+			return nil, err
+		}
+
+		if err := setStreamGuardrails(pk, sgrdata); err != nil {
+			return nil, err
+		}
+
+		pk.PersonalCostLimitInUsd = pcl.Float64
+
 		keys = append(keys, pk)
 	}
 
@@ -609,7 +971,7 @@ func (s *Store) GetKey(keyId string) (*key.ResponseKey, error) {
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
 	defer cancel()
 
-	rows, err := s.db.QueryContext(ctxTimeout, "SELECT * FROM keys WHERE key_id = $1", keyId)
+	rows, err := s.db.QueryContext(ctxTimeout, fmt.Sprintf("SELECT %s FROM keys WHERE key_id = $1", keyColumns), keyId)
 	if err != nil {
 		return nil, err
 	}
@@ -619,6 +981,17 @@ func (s *Store) GetKey(keyId string) (*key.ResponseKey, error) {
 	for rows.Next() {
 		var k key.ResponseKey
 		var settingId sql.NullString
+		var spdata []byte
+		var ocdata []byte
+		var sldata []byte
+		var lpdata []byte
+		var mpdata []byte
+		var scdata []byte
+		var ffdata []byte
+		var sgdata []byte
+		var pmdata []byte
+		var sgrdata []byte
+		var pcl sql.NullFloat64
 		var data []byte
 
 		if err := rows.Scan(
@@ -639,6 +1012,20 @@ func (s *Store) GetKey(keyId string) (*key.ResponseKey, error) {
 			&settingId,
 			&data,
 			pq.Array(&k.SettingIds),
+			&spdata,
+			&k.TenantId,
+			&ocdata,
+			&sldata,
+			&lpdata,
+			&mpdata,
+			&scdata,
+			&pcl,
+			pq.Array(&k.AllowedRegions),
+			&k.RecordingRetention,
+			&ffdata,
+			&sgdata,
+			&pmdata,
+			&sgrdata,
 		); err != nil {
 			return nil, err
 		}
@@ -655,6 +1042,83 @@ func (s *Store) GetKey(keyId string) (*key.ResponseKey, error) {
 			pk.AllowedPaths = pathConfigs
 		}
 
+		if len(spdata) != 0 {
+			sp := &prompt.SystemPrompt{}
+			if err := json.Unmarshal(spdata, sp); err != nil {
+				return nil, err
+			}
+
+			pk.SystemPrompt = sp
+		}
+
+		if len(ocdata) != 0 {
+			oc := &key.OutputCaps{}
+			if err := json.Unmarshal(ocdata, oc); err != nil {
+				return nil, err
+			}
+
+			pk.OutputCaps = oc
+		}
+
+		if len(sldata) != 0 {
+			sl := &key.SessionLimits{}
+			if err := json.Unmarshal(sldata, sl); err != nil {
+				return nil, err
+			}
+
+			pk.SessionLimits = sl
+		}
+
+		if len(lpdata) != 0 {
+			lp := &key.LoopProtection{}
+			if err := json.Unmarshal(lpdata, lp); err != nil {
+				return nil, err
+			}
+
+			pk.LoopProtection = lp
+		}
+
+		if len(mpdata) != 0 {
+			mp := &key.ModelPolicy{}
+			if err := json.Unmarshal(mpdata, mp); err != nil {
+				return nil, err
+			}
+
+			pk.ModelPolicy = mp
+		}
+
+		if len(scdata) != 0 {
+			sc := &key.Schedule{}
+			if err := json.Unmarshal(scdata, sc); err != nil {
+				return nil, err
+			}
+
+			pk.Schedule = sc
+		}
+
+		if len(ffdata) != 0 {
+			ff := map[string]bool{}
+			if err := json.Unmarshal(ffdata, &ff); err != nil {
+				return nil, err
+			}
+
+			pk.FeatureFlags = ff
+		}
+
+		if err := setSigning(pk, sgdata); err != nil {
+			return nil, err
+		}
+
+		if err := setParameters(pk, pmdata); err != nil {
+			return nil, err
+		}
+
+		if err := setStreamGuardrails(pk, sgrdata); err != nil {
+			return nil, err
+		}
+
+		pk.PersonalCostLimitInUsd = pcl.Float64
+
 		keys = append(keys, pk)
 	}
 
@@ -672,6 +1136,7 @@ func (s *Store) GetProviderSetting(id string) (*provider.Setting, error) {
 	setting := &provider.Setting{}
 	var data []byte
 	var name sql.NullString
+	var egdata []byte
 	err := s.db.QueryRowContext(ctxTimeout, "SELECT * FROM provider_settings WHERE $1 = id", id).Scan(
 		&setting.Id,
 		&setting.CreatedAt,
@@ -680,6 +1145,9 @@ func (s *Store) GetProviderSetting(id string) (*provider.Setting, error) {
 		&data,
 		&name,
 		pq.Array(&setting.AllowedModels),
+		&setting.TenantId,
+		&egdata,
+		&setting.Region,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -689,6 +1157,10 @@ func (s *Store) GetProviderSetting(id string) (*provider.Setting, error) {
 		return nil, err
 	}
 
+	if err := unmarshalEgress(egdata, &setting.Egress); err != nil {
+		return nil, err
+	}
+
 	return setting, nil
 }
 
@@ -716,6 +1188,7 @@ func (s *Store) GetProviderSettings(withSecret bool, ids []string) ([]*provider.
 		setting := &provider.Setting{}
 		var data []byte
 		var name sql.NullString
+		var egdata []byte
 		if err := rows.Scan(
 			&setting.Id,
 			&setting.CreatedAt,
@@ -724,6 +1197,9 @@ func (s *Store) GetProviderSettings(withSecret bool, ids []string) ([]*provider.
 			&data,
 			&name,
 			pq.Array(&setting.AllowedModels),
+			&setting.TenantId,
+			&egdata,
+			&setting.Region,
 		); err != nil {
 			return nil, err
 		}
@@ -736,6 +1212,14 @@ func (s *Store) GetProviderSettings(withSecret bool, ids []string) ([]*provider.
 			setting.Setting = m
 		}
 
+		if err := unmarshalEgress(egdata, &setting.Egress); err != nil {
+			return nil, err
+		}
+
+		if !withSecret {
+			setting.Egress = setting.Egress.Redacted()
+		}
+
 		setting.Name = name.String
 		settings = append(settings, setting)
 	}
@@ -751,7 +1235,7 @@ func (s *Store) GetAllKeys() ([]*key.ResponseKey, error) {
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
 	defer cancel()
 
-	rows, err := s.db.QueryContext(ctxTimeout, "SELECT * FROM keys")
+	rows, err := s.db.QueryContext(ctxTimeout, fmt.Sprintf("SELECT %s FROM keys", keyColumns))
 	if err != nil {
 		return nil, err
 	}
@@ -761,6 +1245,17 @@ func (s *Store) GetAllKeys() ([]*key.ResponseKey, error) {
 	for rows.Next() {
 		var k key.ResponseKey
 		var settingId sql.NullString
+		var spdata []byte
+		var ocdata []byte
+		var sldata []byte
+		var lpdata []byte
+		var mpdata []byte
+		var scdata []byte
+		var ffdata []byte
+		var sgdata []byte
+		var pmdata []byte
+		var sgrdata []byte
+		var pcl sql.NullFloat64
 		var data []byte
 		if err := rows.Scan(
 			&k.Name,
@@ -780,6 +1275,20 @@ func (s *Store) GetAllKeys() ([]*key.ResponseKey, error) {
 			&settingId,
 			&data,
 			pq.Array(&k.SettingIds),
+			&spdata,
+			&k.TenantId,
+			&ocdata,
+			&sldata,
+			&lpdata,
+			&mpdata,
+			&scdata,
+			&pcl,
+			pq.Array(&k.AllowedRegions),
+			&k.RecordingRetention,
+			&ffdata,
+			&sgdata,
+			&pmdata,
+			&sgrdata,
 		); err != nil {
 			return nil, err
 		}
@@ -795,35 +1304,116 @@ func (s *Store) GetAllKeys() ([]*key.ResponseKey, error) {
 			pk.AllowedPaths = pathConfigs
 		}
 
-		keys = append(keys, pk)
-	}
+		if len(spdata) != 0 {
+			sp := &prompt.SystemPrompt{}
+			if err := json.Unmarshal(spdata, sp); err != nil {
+				return nil, err
+			}
 
-	return keys, nil
-}
+			pk.SystemPrompt = sp
+		}
 
-func (s *Store) GetUpdatedProviderSettings(updatedAt int64) ([]*provider.Setting, error) {
-	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
-	defer cancel()
+		if len(ocdata) != 0 {
+			oc := &key.OutputCaps{}
+			if err := json.Unmarshal(ocdata, oc); err != nil {
+				return nil, err
+			}
 
-	rows, err := s.db.QueryContext(ctxTimeout, "SELECT * FROM provider_settings WHERE updated_at >= $1", updatedAt)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+			pk.OutputCaps = oc
+		}
 
-	settings := []*provider.Setting{}
-	for rows.Next() {
-		setting := &provider.Setting{}
-		var data []byte
-		var name sql.NullString
-		if err := rows.Scan(
-			&setting.Id,
-			&setting.CreatedAt,
+		if len(sldata) != 0 {
+			sl := &key.SessionLimits{}
+			if err := json.Unmarshal(sldata, sl); err != nil {
+				return nil, err
+			}
+
+			pk.SessionLimits = sl
+		}
+
+		if len(lpdata) != 0 {
+			lp := &key.LoopProtection{}
+			if err := json.Unmarshal(lpdata, lp); err != nil {
+				return nil, err
+			}
+
+			pk.LoopProtection = lp
+		}
+
+		if len(mpdata) != 0 {
+			mp := &key.ModelPolicy{}
+			if err := json.Unmarshal(mpdata, mp); err != nil {
+				return nil, err
+			}
+
+			pk.ModelPolicy = mp
+		}
+
+		if len(scdata) != 0 {
+			sc := &key.Schedule{}
+			if err := json.Unmarshal(scdata, sc); err != nil {
+				return nil, err
+			}
+
+			pk.Schedule = sc
+		}
+
+		if len(ffdata) != 0 {
+			ff := map[string]bool{}
+			if err := json.Unmarshal(ffdata, &ff); err != nil {
+				return nil, err
+			}
+
+			pk.FeatureFlags = ff
+		}
+
+		if err := setSigning(pk, sgdata); err != nil {
+			return nil, err
+		}
+
+		if err := setParameters(pk, pmdata); err != nil {
+			return nil, err
+		}
+
+		if err := setStreamGuardrails(pk, sgrdata); err != nil {
+			return nil, err
+		}
+
+		pk.PersonalCostLimitInUsd = pcl.Float64
+
+		keys = append(keys, pk)
+	}
+
+	return keys, nil
+}
+
+func (s *Store) GetUpdatedProviderSettings(updatedAt int64) ([]*provider.Setting, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, "SELECT * FROM provider_settings WHERE updated_at >= $1", updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	settings := []*provider.Setting{}
+	for rows.Next() {
+		setting := &provider.Setting{}
+		var data []byte
+		var name sql.NullString
+		var egdata []byte
+		if err := rows.Scan(
+			&setting.Id,
+			&setting.CreatedAt,
 			&setting.UpdatedAt,
 			&setting.Provider,
 			&data,
 			&name,
 			pq.Array(&setting.AllowedModels),
+			&setting.TenantId,
+			&egdata,
+			&setting.Region,
 		); err != nil {
 			return nil, err
 		}
@@ -833,6 +1423,10 @@ func (s *Store) GetUpdatedProviderSettings(updatedAt int64) ([]*provider.Setting
 			return nil, err
 		}
 
+		if err := unmarshalEgress(egdata, &setting.Egress); err != nil {
+			return nil, err
+		}
+
 		setting.Setting = m
 		setting.Name = name.String
 		settings = append(settings, setting)
@@ -845,7 +1439,7 @@ func (s *Store) GetUpdatedKeys(updatedAt int64) ([]*key.ResponseKey, error) {
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
 	defer cancel()
 
-	rows, err := s.db.QueryContext(ctxTimeout, "SELECT * FROM keys WHERE updated_at >= $1", updatedAt)
+	rows, err := s.db.QueryContext(ctxTimeout, fmt.Sprintf("SELECT %s FROM keys WHERE updated_at >= $1", keyColumns), updatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -855,6 +1449,17 @@ func (s *Store) GetUpdatedKeys(updatedAt int64) ([]*key.ResponseKey, error) {
 	for rows.Next() {
 		var k key.ResponseKey
 		var settingId sql.NullString
+		var spdata []byte
+		var ocdata []byte
+		var sldata []byte
+		var lpdata []byte
+		var mpdata []byte
+		var scdata []byte
+		var ffdata []byte
+		var sgdata []byte
+		var pmdata []byte
+		var sgrdata []byte
+		var pcl sql.NullFloat64
 		var data []byte
 		if err := rows.Scan(
 			&k.Name,
@@ -874,6 +1479,20 @@ func (s *Store) GetUpdatedKeys(updatedAt int64) ([]*key.ResponseKey, error) {
 			&settingId,
 			&data,
 			pq.Array(&k.SettingIds),
+			&spdata,
+			&k.TenantId,
+			&ocdata,
+			&sldata,
+			&lpdata,
+			&mpdata,
+			&scdata,
+			&pcl,
+			pq.Array(&k.AllowedRegions),
+			&k.RecordingRetention,
+			&ffdata,
+			&sgdata,
+			&pmdata,
+			&sgrdata,
 		); err != nil {
 			return nil, err
 		}
@@ -889,6 +1508,83 @@ func (s *Store) GetUpdatedKeys(updatedAt int64) ([]*key.ResponseKey, error) {
 			pk.AllowedPaths = pathConfigs
 		}
 
+		if len(spdata) != 0 {
+			sp := &prompt.SystemPrompt{}
+			if err := json.Unmarshal(spdata, sp); err != nil {
+				return nil, err
+			}
+
+			pk.SystemPrompt = sp
+		}
+
+		if len(ocdata) != 0 {
+			oc := &key.OutputCaps{}
+			if err := json.Unmarshal(ocdata, oc); err != nil {
+				return nil, err
+			}
+
+			pk.OutputCaps = oc
+		}
+
+		if len(sldata) != 0 {
+			sl := &key.SessionLimits{}
+			if err := json.Unmarshal(sldata, sl); err != nil {
+				return nil, err
+			}
+
+			pk.SessionLimits = sl
+		}
+
+		if len(lpdata) != 0 {
+			lp := &key.LoopProtection{}
+			if err := json.Unmarshal(lpdata, lp); err != nil {
+				return nil, err
+			}
+
+			pk.LoopProtection = lp
+		}
+
+		if len(mpdata) != 0 {
+			mp := &key.ModelPolicy{}
+			if err := json.Unmarshal(mpdata, mp); err != nil {
+				return nil, err
+			}
+
+			pk.ModelPolicy = mp
+		}
+
+		if len(scdata) != 0 {
+			sc := &key.Schedule{}
+			if err := json.Unmarshal(scdata, sc); err != nil {
+				return nil, err
+			}
+
+			pk.Schedule = sc
+		}
+
+		if len(ffdata) != 0 {
+			ff := map[string]bool{}
+			if err := json.Unmarshal(ffdata, &ff); err != nil {
+				return nil, err
+			}
+
+			pk.FeatureFlags = ff
+		}
+
+		if err := setSigning(pk, sgdata); err != nil {
+			return nil, err
+		}
+
+		if err := setParameters(pk, pmdata); err != nil {
+			return nil, err
+		}
+
+		if err := setStreamGuardrails(pk, sgrdata); err != nil {
+			return nil, err
+		}
+
+		pk.PersonalCostLimitInUsd = pcl.Float64
+
 		keys = append(keys, pk)
 	}
 
@@ -993,15 +1689,215 @@ func (s *Store) UpdateKey(id string, uk *key.UpdateKey) (*key.ResponseKey, error
 
 		values = append(values, data)
 		fields = append(fields, fmt.Sprintf("allowed_paths = $%d", counter))
+		counter++
+	}
+
+	if uk.SystemPrompt != nil {
+		var data []byte
+		if len(uk.SystemPrompt.Content) != 0 {
+			marshalled, err := json.Marshal(uk.SystemPrompt)
+			if err != nil {
+				return nil, err
+			}
+
+			data = marshalled
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("system_prompt = $%d", counter))
+		counter++
+	}
+
+	// output caps without any cap remove the existing ones.
+	if uk.OutputCaps != nil {
+		var data []byte
+		if !uk.OutputCaps.IsEmpty() {
+			marshalled, err := json.Marshal(uk.OutputCaps)
+			if err != nil {
+				return nil, err
+			}
+
+			data = marshalled
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("output_caps = $%d", counter))
+		counter++
+	}
+
+	// session limits without any limit remove the existing ones.
+	if uk.SessionLimits != nil {
+		var data []byte
+		if !uk.SessionLimits.IsEmpty() {
+			marshalled, err := json.Marshal(uk.SessionLimits)
+			if err != nil {
+				return nil, err
+			}
+
+			data = marshalled
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("session_limits = $%d", counter))
+		counter++
+	}
+
+	// loop protection without any threshold removes the existing one.
+	if uk.LoopProtection != nil {
+		var data []byte
+		if !uk.LoopProtection.IsEmpty() {
+			marshalled, err := json.Marshal(uk.LoopProtection)
+			if err != nil {
+				return nil, err
+			}
+
+			data = marshalled
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("loop_protection = $%d", counter))
+		counter++
+	}
+
+	// a policy without rules removes the existing one.
+	if uk.ModelPolicy != nil {
+		var data []byte
+		if !uk.ModelPolicy.IsEmpty() {
+			marshalled, err := json.Marshal(uk.ModelPolicy)
+			if err != nil {
+				return nil, err
+			}
+
+			data = marshalled
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("model_policy = $%d", counter))
+		counter++
+	}
+
+	// a schedule without windows removes the existing one.
+	if uk.Schedule != nil {
+		var data []byte
+		if !uk.Schedule.IsEmpty() {
+			marshalled, err := json.Marshal(uk.Schedule)
+			if err != nil {
+				return nil, err
+			}
+
+			data = marshalled
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("schedule = $%d", counter))
+		counter++
+	}
+
+	if len(uk.Key) != 0 {
+		values = append(values, uk.Key)
+		fields = append(fields, fmt.Sprintf("key = $%d", counter))
+		counter++
+	}
+
+	if uk.PersonalCostLimitInUsd != nil {
+		values = append(values, *uk.PersonalCostLimitInUsd)
+		fields = append(fields, fmt.Sprintf("personal_cost_limit_in_usd = $%d", counter))
+		counter++
+	}
+
+	if uk.AllowedRegions != nil {
+		values = append(values, sliceToSqlStringArray(*uk.AllowedRegions))
+		fields = append(fields, fmt.Sprintf("allowed_regions = $%d", counter))
+		counter++
+	}
+
+	if uk.RecordingRetention != nil {
+		values = append(values, *uk.RecordingRetention)
+		fields = append(fields, fmt.Sprintf("recording_retention = $%d", counter))
+		counter++
+	}
+
+	if uk.FeatureFlags != nil {
+		var data []byte
+		if len(uk.FeatureFlags) != 0 {
+			marshalled, err := json.Marshal(uk.FeatureFlags)
+			if err != nil {
+				return nil, err
+			}
+
+			data = marshalled
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("feature_flags = $%d", counter))
+		counter++
 	}
 
-	query := fmt.Sprintf("UPDATE keys SET %s WHERE key_id = $1 RETURNING *;", strings.Join(fields, ","))
+	if uk.Signing != nil {
+		var data []byte
+		if len(uk.Signing.Secret) != 0 {
+			marshalled, err := json.Marshal(uk.Signing)
+			if err != nil {
+				return nil, err
+			}
+
+			data = marshalled
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("signing = $%d", counter))
+		counter++
+	}
+
+	if uk.Parameters != nil {
+		var data []byte
+		if !uk.Parameters.IsEmpty() {
+			marshalled, err := json.Marshal(uk.Parameters)
+			if err != nil {
+				return nil, err
+			}
+
+			data = marshalled
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("parameters = $%d", counter))
+		counter++
+	}
+
+	if uk.StreamGuardrails != nil {
+		var data []byte
+		if !uk.StreamGuardrails.IsEmpty() {
+			marshalled, err := json.Marshal(uk.StreamGuardrails)
+			if err != nil {
+				return nil, err
+			}
+
+			data = marshalled
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("stream_guardrails = $%d", counter))
+	}
+
+	query := fmt.Sprintf("UPDATE keys SET %s WHERE key_id = $1 RETURNING %s;", strings.Join(fields, ","), keyColumns)
 
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
 	defer cancel()
 
 	var k key.ResponseKey
 	var settingId sql.NullString
+	var spdata []byte
+	var ocdata []byte
+	var sldata []byte
+	var lpdata []byte
+	var mpdata []byte
+	var scdata []byte
+	var ffdata []byte
+	var sgdata []byte
+	var pmdata []byte
+	var sgrdata []byte
+	var pcl sql.NullFloat64
 	var data []byte
 	if err := s.db.QueryRowContext(ctxTimeout, query, values...).Scan(
 		&k.Name,
@@ -1021,6 +1917,20 @@ func (s *Store) UpdateKey(id string, uk *key.UpdateKey) (*key.ResponseKey, error
 		&settingId,
 		&data,
 		pq.Array(&k.SettingIds),
+		&spdata,
+		&k.TenantId,
+		&ocdata,
+		&sldata,
+		&lpdata,
+		&mpdata,
+		&scdata,
+		&pcl,
+		pq.Array(&k.AllowedRegions),
+		&k.RecordingRetention,
+		&ffdata,
+		&sgdata,
+		&pmdata,
+		&sgrdata,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, internal_errors.NewNotFoundError(fmt.Sprintf("key not found for id: %s", id))
@@ -1040,6 +1950,83 @@ func (s *Store) UpdateKey(id string, uk *key.UpdateKey) (*key.ResponseKey, error
 		pk.AllowedPaths = pathConfigs
 	}
 
+	if len(spdata) != 0 {
+		sp := &prompt.SystemPrompt{}
+		if err := json.Unmarshal(spdata, sp); err != nil {
+			return nil, err
+		}
+
+		pk.SystemPrompt = sp
+	}
+
+	if len(ocdata) != 0 {
+		oc := &key.OutputCaps{}
+		if err := json.Unmarshal(ocdata, oc); err != nil {
+			return nil, err
+		}
+
+		pk.OutputCaps = oc
+	}
+
+	if len(sldata) != 0 {
+		sl := &key.SessionLimits{}
+		if err := json.Unmarshal(sldata, sl); err != nil {
+			return nil, err
+		}
+
+		pk.SessionLimits = sl
+	}
+
+	if len(lpdata) != 0 {
+		lp := &key.LoopProtection{}
+		if err := json.Unmarshal(lpdata, lp); err != nil {
+			return nil, err
+		}
+
+		pk.LoopProtection = lp
+	}
+
+	if len(mpdata) != 0 {
+		mp := &key.ModelPolicy{}
+		if err := json.Unmarshal(mpdata, mp); err != nil {
+			return nil, err
+		}
+
+		pk.ModelPolicy = mp
+	}
+
+	if len(scdata) != 0 {
+		sc := &key.Schedule{}
+		if err := json.Unmarshal(scdata, sc); err != nil {
+			return nil, err
+		}
+
+		pk.Schedule = sc
+	}
+
+	if len(ffdata) != 0 {
+		ff := map[string]bool{}
+		if err := json.Unmarshal(ffdata, &ff); err != nil {
+			return nil, err
+		}
+
+		pk.FeatureFlags = ff
+	}
+
+	if err := setSigning(pk, sgdata); err != nil {
+		return nil, err
+	}
+
+	if err := setParameters(pk, pmdata); err != nil {
+		return nil, err
+	}
+
+	if err := setStreamGuardrails(pk, sgrdata); err != nil {
+		return nil, err
+	}
+
+	pk.PersonalCostLimitInUsd = pcl.Float64
+
 	return pk, nil
 }
 
@@ -1072,10 +2059,28 @@ func (s *Store) UpdateProviderSetting(id string, setting *provider.UpdateSetting
 	if setting.AllowedModels != nil {
 		values = append(values, sliceToSqlStringArray(*setting.AllowedModels))
 		fields = append(fields, fmt.Sprintf("allowed_models = $%d", d))
+		d++
 	}
 
-	query := fmt.Sprintf("UPDATE provider_settings SET %s WHERE id = $1 RETURNING id, created_at, updated_at, provider, name, allowed_models;", strings.Join(fields, ","))
+	if setting.Egress != nil {
+		data, err := json.Marshal(setting.Egress)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("egress = $%d", d))
+		d++
+	}
+
+	if setting.Region != nil {
+		values = append(values, *setting.Region)
+		fields = append(fields, fmt.Sprintf("region = $%d", d))
+	}
+
+	query := fmt.Sprintf("UPDATE provider_settings SET %s WHERE id = $1 RETURNING id, created_at, updated_at, provider, name, allowed_models, tenant_id, egress, region;", strings.Join(fields, ","))
 	updated := &provider.Setting{}
+	var egdata []byte
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
 	defer cancel()
 
@@ -1087,6 +2092,9 @@ func (s *Store) UpdateProviderSetting(id string, setting *provider.UpdateSetting
 		&updated.Provider,
 		&updated.Name,
 		pq.Array(&updated.AllowedModels),
+		&updated.TenantId,
+		&egdata,
+		&updated.Region,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, internal_errors.NewNotFoundError("provider setting is not found for: " + id)
@@ -1095,6 +2103,10 @@ func (s *Store) UpdateProviderSetting(id string, setting *provider.UpdateSetting
 		return nil, err
 	}
 
+	if err := unmarshalEgress(egdata, &updated.Egress); err != nil {
+		return nil, err
+	}
+
 	return updated, nil
 }
 
@@ -1121,9 +2133,9 @@ func (s *Store) CreateProviderSetting(setting *provider.Setting) (*provider.Sett
 	}
 
 	query := `
-		INSERT INTO provider_settings (id, created_at, updated_at, provider, setting, name, allowed_models)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id, created_at, updated_at, provider, name, allowed_models
+		INSERT INTO provider_settings (id, created_at, updated_at, provider, setting, name, allowed_models, tenant_id, egress, region)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at, updated_at, provider, name, allowed_models, tenant_id, egress, region
 	`
 
 	data, err := json.Marshal(setting.Setting)
@@ -1131,6 +2143,14 @@ func (s *Store) CreateProviderSetting(setting *provider.Setting) (*provider.Sett
 		return nil, err
 	}
 
+	var egbytes []byte
+	if setting.Egress != nil {
+		egbytes, err = json.Marshal(setting.Egress)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	values := []any{
 		setting.Id,
 		setting.CreatedAt,
@@ -1139,10 +2159,14 @@ func (s *Store) CreateProviderSetting(setting *provider.Setting) (*provider.Sett
 		data,
 		setting.Name,
 		sliceToSqlStringArray(setting.AllowedModels),
+		setting.TenantId,
+		egbytes,
+		setting.Region,
 	}
 
 	created := &provider.Setting{}
 	var name sql.NullString
+	var egdata []byte
 	if err := s.db.QueryRowContext(ctxTimeout, query, values...).Scan(
 		&created.Id,
 		&created.CreatedAt,
@@ -1150,26 +2174,115 @@ func (s *Store) CreateProviderSetting(setting *provider.Setting) (*provider.Sett
 		&created.Provider,
 		&name,
 		pq.Array(&created.AllowedModels),
+		&created.TenantId,
+		&egdata,
+		&created.Region,
 	); err != nil {
 		return nil, err
 	}
 
+	if err := unmarshalEgress(egdata, &created.Egress); err != nil {
+		return nil, err
+	}
+
 	created.Name = name.String
 	return created, nil
 }
 
-func (s *Store) CreateKey(rk *key.RequestKey) (*key.ResponseKey, error) {
-	query := `
-		INSERT INTO keys (name, created_at, updated_at, tags, revoked, key_id, key, revoked_reason, cost_limit_in_usd, cost_limit_in_usd_over_time, cost_limit_in_usd_unit, rate_limit_over_time, rate_limit_unit, ttl, setting_id, allowed_paths, setting_ids)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
-		RETURNING *;
+const createKeyQuery = `
+		INSERT INTO keys (name, created_at, updated_at, tags, revoked, key_id, key, revoked_reason, cost_limit_in_usd, cost_limit_in_usd_over_time, cost_limit_in_usd_unit, rate_limit_over_time, rate_limit_unit, ttl, setting_id, allowed_paths, setting_ids, system_prompt, tenant_id, output_caps, session_limits, loop_protection, model_policy, schedule, allowed_regions, recording_retention, feature_flags, signing, parameters, stream_guardrails)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30)
+		RETURNING ` + keyColumns + `;
 	`
 
+func (s *Store) CreateKey(rk *key.RequestKey) (*key.ResponseKey, error) {
+	query := createKeyQuery
+
 	rdata, err := json.Marshal(rk.AllowedPaths)
 	if err != nil {
 		return nil, err
 	}
 
+	var spvalue []byte
+	if rk.SystemPrompt != nil {
+		spvalue, err = json.Marshal(rk.SystemPrompt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var ocvalue []byte
+	if !rk.OutputCaps.IsEmpty() {
+		ocvalue, err = json.Marshal(rk.OutputCaps)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var slvalue []byte
+	if !rk.SessionLimits.IsEmpty() {
+		slvalue, err = json.Marshal(rk.SessionLimits)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var lpvalue []byte
+	if !rk.LoopProtection.IsEmpty() {
+		lpvalue, err = json.Marshal(rk.LoopProtection)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var mpvalue []byte
+	if !rk.ModelPolicy.IsEmpty() {
+		mpvalue, err = json.Marshal(rk.ModelPolicy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var scvalue []byte
+	if !rk.Schedule.IsEmpty() {
+		scvalue, err = json.Marshal(rk.Schedule)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var ffvalue []byte
+	if len(rk.FeatureFlags) != 0 {
+		ffvalue, err = json.Marshal(rk.FeatureFlags)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var sgvalue []byte
+	if rk.Signing != nil {
+		sgvalue, err = json.Marshal(rk.Signing)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var pmvalue []byte
+	if !rk.Parameters.IsEmpty() {
+		pmvalue, err = json.Marshal(rk.Parameters)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var sgrvalue []byte
+	if !rk.StreamGuardrails.IsEmpty() {
+		sgrvalue, err = json.Marshal(rk.StreamGuardrails)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	values := []any{
 		rk.Name,
 		rk.CreatedAt,
@@ -1188,6 +2301,19 @@ func (s *Store) CreateKey(rk *key.RequestKey) (*key.ResponseKey, error) {
 		rk.SettingId,
 		rdata,
 		sliceToSqlStringArray(rk.SettingIds),
+		spvalue,
+		rk.TenantId,
+		ocvalue,
+		slvalue,
+		lpvalue,
+		mpvalue,
+		scvalue,
+		sliceToSqlStringArray(rk.AllowedRegions),
+		rk.RecordingRetention,
+		ffvalue,
+		sgvalue,
+		pmvalue,
+		sgrvalue,
 	}
 
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
@@ -1196,6 +2322,17 @@ func (s *Store) CreateKey(rk *key.RequestKey) (*key.ResponseKey, error) {
 	var k key.ResponseKey
 
 	var settingId sql.NullString
+	var spdata []byte
+	var ocdata []byte
+	var sldata []byte
+	var lpdata []byte
+	var mpdata []byte
+	var scdata []byte
+	var ffdata []byte
+	var sgdata []byte
+	var pmdata []byte
+	var sgrdata []byte
+	var pcl sql.NullFloat64
 	var data []byte
 	if err := s.db.QueryRowContext(ctxTimeout, query, values...).Scan(
 		&k.Name,
@@ -1215,6 +2352,20 @@ func (s *Store) CreateKey(rk *key.RequestKey) (*key.ResponseKey, error) {
 		&settingId,
 		&data,
 		pq.Array(&k.SettingIds),
+		&spdata,
+		&k.TenantId,
+		&ocdata,
+		&sldata,
+		&lpdata,
+		&mpdata,
+		&scdata,
+		&pcl,
+		pq.Array(&k.AllowedRegions),
+		&k.RecordingRetention,
+		&ffdata,
+		&sgdata,
+		&pmdata,
+		&sgrdata,
 	); err != nil {
 		return nil, err
 	}
@@ -1231,6 +2382,83 @@ func (s *Store) CreateKey(rk *key.RequestKey) (*key.ResponseKey, error) {
 		pk.AllowedPaths = pathConfigs
 	}
 
+	if len(spdata) != 0 {
+		sp := &prompt.SystemPrompt{}
+		if err := json.Unmarshal(spdata, sp); err != nil {
+			return nil, err
+		}
+
+		pk.SystemPrompt = sp
+	}
+
+	if len(ocdata) != 0 {
+		oc := &key.OutputCaps{}
+		if err := json.Unmarshal(ocdata, oc); err != nil {
+			return nil, err
+		}
+
+		pk.OutputCaps = oc
+	}
+
+	if len(sldata) != 0 {
+		sl := &key.SessionLimits{}
+		if err := json.Unmarshal(sldata, sl); err != nil {
+			return nil, err
+		}
+
+		pk.SessionLimits = sl
+	}
+
+	if len(lpdata) != 0 {
+		lp := &key.LoopProtection{}
+		if err := json.Unmarshal(lpdata, lp); err != nil {
+			return nil, err
+		}
+
+		pk.LoopProtection = lp
+	}
+
+	if len(mpdata) != 0 {
+		mp := &key.ModelPolicy{}
+		if err := json.Unmarshal(mpdata, mp); err != nil {
+			return nil, err
+		}
+
+		pk.ModelPolicy = mp
+	}
+
+	if len(scdata) != 0 {
+		sc := &key.Schedule{}
+		if err := json.Unmarshal(scdata, sc); err != nil {
+			return nil, err
+		}
+
+		pk.Schedule = sc
+	}
+
+	if len(ffdata) != 0 {
+		ff := map[string]bool{}
+		if err := json.Unmarshal(ffdata, &ff); err != nil {
+			return nil, err
+		}
+
+		pk.FeatureFlags = ff
+	}
+
+	if err := setSigning(pk, sgdata); err != nil {
+		return nil, err
+	}
+
+	if err := setParameters(pk, pmdata); err != nil {
+		return nil, err
+	}
+
+	if err := setStreamGuardrails(pk, sgrdata); err != nil {
+		return nil, err
+	}
+
+	pk.PersonalCostLimitInUsd = pcl.Float64
+
 	return pk, nil
 }
 
@@ -0,0 +1,51 @@
+package postgresql
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var insertPattern = regexp.MustCompile(`(?s)INSERT INTO \w+ \((.*?)\)\s*VALUES \((.*?)\)`)
+
+func TestInsertQueries_PlaceholdersMatchColumns(t *testing.T) {
+	queries := map[string]string{
+		"insert event": insertEventQuery,
+		"create key":   createKeyQuery,
+	}
+
+	for name, query := range queries {
+		t.Run(name, func(t *testing.T) {
+			matches := insertPattern.FindStringSubmatch(query)
+			require.Len(t, matches, 3, "query is not an insert")
+
+			columns := strings.Split(matches[1], ",")
+			placeholders := strings.Split(matches[2], ",")
+
+			require.Equal(t, len(columns), len(placeholders), "every column needs exactly one placeholder")
+			for i, p := range placeholders {
+				assert.Equal(t, "$"+strconv.Itoa(i+1), strings.TrimSpace(p))
+			}
+		})
+	}
+}
+
+func TestKeyColumns_IncludeInsertedColumns(t *testing.T) {
+	selected := map[string]bool{}
+	for _, column := range strings.Split(keyColumns, ",") {
+		column = strings.TrimSpace(column)
+		require.False(t, selected[column], "column %s is selected twice", column)
+		selected[column] = true
+	}
+
+	matches := insertPattern.FindStringSubmatch(createKeyQuery)
+	require.Len(t, matches, 3)
+
+	for _, column := range strings.Split(matches[1], ",") {
+		assert.True(t, selected[strings.TrimSpace(column)], "column %s is not selected", strings.TrimSpace(column))
+	}
+}
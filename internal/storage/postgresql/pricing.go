@@ -0,0 +1,194 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/pricing"
+)
+
+func (s *Store) CreatePricesTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS prices (
+		id VARCHAR(255) PRIMARY KEY,
+		created_at BIGINT NOT NULL,
+		updated_at BIGINT NOT NULL,
+		provider VARCHAR(255) NOT NULL,
+		model VARCHAR(255) NOT NULL,
+		prompt_cost FLOAT8,
+		completion_cost FLOAT8,
+		embeddings_cost FLOAT8,
+		fine_tune_cost FLOAT8,
+		UNIQUE (provider, model)
+	)`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+const priceColumns = "id, created_at, updated_at, provider, model, prompt_cost, completion_cost, embeddings_cost, fine_tune_cost"
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPrice(row rowScanner) (*pricing.Price, error) {
+	p := &pricing.Price{}
+	var prompt, completion, embeddings, fineTune sql.NullFloat64
+
+	if err := row.Scan(
+		&p.Id,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+		&p.Provider,
+		&p.Model,
+		&prompt,
+		&completion,
+		&embeddings,
+		&fineTune,
+	); err != nil {
+		return nil, err
+	}
+
+	p.PromptCostPerMillionTokens = nullFloatToPointer(prompt)
+	p.CompletionCostPerMillionTokens = nullFloatToPointer(completion)
+	p.EmbeddingsCostPerMillionTokens = nullFloatToPointer(embeddings)
+	p.FineTuneCostPerMillionTokens = nullFloatToPointer(fineTune)
+
+	return p, nil
+}
+
+func nullFloatToPointer(f sql.NullFloat64) *float64 {
+	if !f.Valid {
+		return nil
+	}
+
+	return &f.Float64
+}
+
+func (s *Store) CreatePrice(p *pricing.Price) (*pricing.Price, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO prices (%s)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (provider, model) DO NOTHING
+		RETURNING %s
+	`, priceColumns, priceColumns)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	created, err := scanPrice(s.db.QueryRowContext(ctxTimeout, query,
+		p.Id,
+		p.CreatedAt,
+		p.UpdatedAt,
+		p.Provider,
+		p.Model,
+		p.PromptCostPerMillionTokens,
+		p.CompletionCostPerMillionTokens,
+		p.EmbeddingsCostPerMillionTokens,
+		p.FineTuneCostPerMillionTokens,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, NewDuplicationError(fmt.Sprintf("price of %s model %s already exists", p.Provider, p.Model))
+		}
+
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (s *Store) GetPrices() ([]*pricing.Price, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, fmt.Sprintf("SELECT %s FROM prices ORDER BY provider, model", priceColumns))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prices := []*pricing.Price{}
+	for rows.Next() {
+		p, err := scanPrice(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		prices = append(prices, p)
+	}
+
+	return prices, nil
+}
+
+func (s *Store) UpdatePrice(id string, up *pricing.UpdatePrice) (*pricing.Price, error) {
+	values := []any{
+		id,
+		up.UpdatedAt,
+	}
+	fields := []string{"updated_at = $2"}
+
+	columns := []struct {
+		name  string
+		value *float64
+	}{
+		{"prompt_cost", up.PromptCostPerMillionTokens},
+		{"completion_cost", up.CompletionCostPerMillionTokens},
+		{"embeddings_cost", up.EmbeddingsCostPerMillionTokens},
+		{"fine_tune_cost", up.FineTuneCostPerMillionTokens},
+	}
+
+	for _, column := range columns {
+		if column.value != nil {
+			values = append(values, *column.value)
+			fields = append(fields, fmt.Sprintf("%s = $%d", column.name, len(values)))
+		}
+	}
+
+	query := fmt.Sprintf("UPDATE prices SET %s WHERE id = $1 RETURNING %s", strings.Join(fields, ","), priceColumns)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	updated, err := scanPrice(s.db.QueryRowContext(ctxTimeout, query, values...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError("price is not found for: " + id)
+		}
+
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+func (s *Store) DeletePrice(id string) error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctxTimeout, "DELETE FROM prices WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return internal_errors.NewNotFoundError("price is not found for: " + id)
+	}
+
+	return nil
+}
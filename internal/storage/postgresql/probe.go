@@ -0,0 +1,217 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/probe"
+	"github.com/lib/pq"
+)
+
+func (s *Store) CreateProbesTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS probes (
+		id VARCHAR(255) PRIMARY KEY,
+		created_at BIGINT NOT NULL,
+		updated_at BIGINT NOT NULL,
+		name VARCHAR(255) NOT NULL,
+		setting_id VARCHAR(255) NOT NULL DEFAULT '',
+		model VARCHAR(255) NOT NULL DEFAULT '',
+		params JSONB,
+		route_id VARCHAR(255) NOT NULL DEFAULT '',
+		setting_ids TEXT[],
+		run_interval VARCHAR(255) NOT NULL,
+		timeout VARCHAR(255) NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS probe_results (
+		id VARCHAR(255) PRIMARY KEY,
+		probe_id VARCHAR(255) NOT NULL,
+		created_at BIGINT NOT NULL,
+		setting_id VARCHAR(255) NOT NULL DEFAULT '',
+		available BOOLEAN NOT NULL,
+		status INT NOT NULL,
+		latency_in_ms INT NOT NULL,
+		error TEXT NOT NULL DEFAULT ''
+	);
+	CREATE INDEX IF NOT EXISTS probe_results_probe_id_created_at_idx ON probe_results (probe_id, created_at DESC);
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+const probeColumns = "id, created_at, updated_at, name, setting_id, model, params, route_id, setting_ids, run_interval, timeout"
+
+func scanProbe(row rowScanner) (*probe.Probe, error) {
+	p := &probe.Probe{}
+	var params []byte
+
+	if err := row.Scan(
+		&p.Id,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+		&p.Name,
+		&p.SettingId,
+		&p.Model,
+		&params,
+		&p.RouteId,
+		pq.Array(&p.SettingIds),
+		&p.Interval,
+		&p.Timeout,
+	); err != nil {
+		return nil, err
+	}
+
+	if len(params) != 0 {
+		if err := json.Unmarshal(params, &p.Params); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+func (s *Store) CreateProbe(p *probe.Probe) (*probe.Probe, error) {
+	params, err := json.Marshal(p.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO probes (%s)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING %s
+	`, probeColumns, probeColumns)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	return scanProbe(s.db.QueryRowContext(ctxTimeout, query,
+		p.Id,
+		p.CreatedAt,
+		p.UpdatedAt,
+		p.Name,
+		p.SettingId,
+		p.Model,
+		params,
+		p.RouteId,
+		pq.Array(p.SettingIds),
+		p.Interval,
+		p.Timeout,
+	))
+}
+
+func (s *Store) GetProbes() ([]*probe.Probe, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, fmt.Sprintf("SELECT %s FROM probes ORDER BY created_at", probeColumns))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	probes := []*probe.Probe{}
+	for rows.Next() {
+		p, err := scanProbe(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		probes = append(probes, p)
+	}
+
+	return probes, nil
+}
+
+func (s *Store) GetProbe(id string) (*probe.Probe, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	p, err := scanProbe(s.db.QueryRowContext(ctxTimeout, fmt.Sprintf("SELECT %s FROM probes WHERE id = $1", probeColumns), id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError("probe is not found for: " + id)
+		}
+
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// DeleteProbe deletes a probe along with its results.
+func (s *Store) DeleteProbe(id string) error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctxTimeout, "DELETE FROM probes WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return internal_errors.NewNotFoundError("probe is not found for: " + id)
+	}
+
+	_, err = s.db.ExecContext(ctxTimeout, "DELETE FROM probe_results WHERE probe_id = $1", id)
+	return err
+}
+
+func (s *Store) InsertProbeResult(r *probe.Result) error {
+	query := `
+		INSERT INTO probe_results (id, probe_id, created_at, setting_id, available, status, latency_in_ms, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctxTimeout, query, r.Id, r.ProbeId, r.CreatedAt, r.SettingId, r.Available, r.Status, r.LatencyInMs, r.Error)
+	return err
+}
+
+func (s *Store) GetProbeResults(probeId string, start, end int64, limit int) ([]*probe.Result, error) {
+	query := `
+		SELECT id, probe_id, created_at, setting_id, available, status, latency_in_ms, error
+		FROM probe_results
+		WHERE probe_id = $1 AND created_at >= $2 AND created_at <= $3
+		ORDER BY created_at DESC
+		LIMIT $4
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, query, probeId, start, end, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []*probe.Result{}
+	for rows.Next() {
+		r := &probe.Result{}
+		if err := rows.Scan(&r.Id, &r.ProbeId, &r.CreatedAt, &r.SettingId, &r.Available, &r.Status, &r.LatencyInMs, &r.Error); err != nil {
+			return nil, err
+		}
+
+		results = append(results, r)
+	}
+
+	return results, nil
+}
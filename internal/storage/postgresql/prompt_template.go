@@ -0,0 +1,159 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/prompt"
+)
+
+func (s *Store) CreatePromptTemplatesTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS prompt_templates (
+		id VARCHAR(255) PRIMARY KEY,
+		created_at BIGINT NOT NULL,
+		name VARCHAR(255) NOT NULL,
+		version INT NOT NULL,
+		description TEXT NOT NULL,
+		messages JSONB NOT NULL,
+		UNIQUE (name, version)
+	)`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+const promptTemplateColumns = "id, created_at, name, version, description, messages"
+
+func scanPromptTemplate(row rowScanner) (*prompt.Template, error) {
+	t := &prompt.Template{}
+	var messages []byte
+
+	if err := row.Scan(
+		&t.Id,
+		&t.CreatedAt,
+		&t.Name,
+		&t.Version,
+		&t.Description,
+		&messages,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(messages, &t.Messages); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// CreatePromptTemplate stores the template as the next version of its name.
+// Concurrent creations of the same version are rejected by the unique constraint.
+func (s *Store) CreatePromptTemplate(t *prompt.Template) (*prompt.Template, error) {
+	messages, err := json.Marshal(t.Messages)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO prompt_templates (%s)
+		SELECT $1::VARCHAR, $2::BIGINT, $3::VARCHAR, COALESCE(MAX(version), 0) + 1, $4::TEXT, $5::JSONB FROM prompt_templates WHERE name = $3
+		ON CONFLICT (name, version) DO NOTHING
+		RETURNING %s
+	`, promptTemplateColumns, promptTemplateColumns)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	created, err := scanPromptTemplate(s.db.QueryRowContext(ctxTimeout, query, t.Id, t.CreatedAt, t.Name, t.Description, string(messages)))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, NewDuplicationError(fmt.Sprintf("a new version of prompt template %s was created concurrently", t.Name))
+		}
+
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (s *Store) getPromptTemplates(query string, args ...any) ([]*prompt.Template, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []*prompt.Template{}
+	for rows.Next() {
+		t, err := scanPromptTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		templates = append(templates, t)
+	}
+
+	return templates, nil
+}
+
+func (s *Store) GetPromptTemplates() ([]*prompt.Template, error) {
+	return s.getPromptTemplates(fmt.Sprintf("SELECT %s FROM prompt_templates ORDER BY name, version", promptTemplateColumns))
+}
+
+func (s *Store) GetPromptTemplateVersions(name string) ([]*prompt.Template, error) {
+	return s.getPromptTemplates(fmt.Sprintf("SELECT %s FROM prompt_templates WHERE name = $1 ORDER BY version", promptTemplateColumns), name)
+}
+
+// GetPromptTemplate returns the given version of a template, or the latest
+// version when version is 0.
+func (s *Store) GetPromptTemplate(name string, version int) (*prompt.Template, error) {
+	query := fmt.Sprintf("SELECT %s FROM prompt_templates WHERE name = $1 AND ($2 = 0 OR version = $2) ORDER BY version DESC LIMIT 1", promptTemplateColumns)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	t, err := scanPromptTemplate(s.db.QueryRowContext(ctxTimeout, query, name, version))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError(fmt.Sprintf("prompt template %s is not found", name))
+		}
+
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (s *Store) DeletePromptTemplate(name string) error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctxTimeout, "DELETE FROM prompt_templates WHERE name = $1", name)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return internal_errors.NewNotFoundError(fmt.Sprintf("prompt template %s is not found", name))
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/lib/pq"
+)
+
+// GetEventsForRecomputation pages through successful events ordered by
+// creation time and id so that the recomputation job can process large time
+// ranges in batches.
+func (s *Store) GetEventsForRecomputation(r *event.RecomputationRequest, afterCreatedAt int64, afterId string, limit int) ([]*event.Event, error) {
+	args := []any{r.Start, r.End, afterCreatedAt, afterId, limit}
+	conditions := ""
+
+	if len(r.Providers) != 0 {
+		args = append(args, pq.Array(r.Providers))
+		conditions += fmt.Sprintf(" AND provider = ANY($%d)", len(args))
+	}
+
+	if len(r.Models) != 0 {
+		args = append(args, pq.Array(r.Models))
+		conditions += fmt.Sprintf(" AND model = ANY($%d)", len(args))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT event_id, created_at, provider, model, path, cost_in_usd, prompt_token_count, completion_token_count
+		FROM events
+		WHERE created_at >= $1 AND created_at <= $2 AND status_code = 200 AND (created_at, event_id) > ($3, $4)%s
+		ORDER BY created_at, event_id
+		LIMIT $5
+	`, conditions)
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*event.Event{}
+	for rows.Next() {
+		e := &event.Event{}
+		var path, model, provider sql.NullString
+
+		if err := rows.Scan(
+			&e.Id,
+			&e.CreatedAt,
+			&provider,
+			&model,
+			&path,
+			&e.CostInUsd,
+			&e.PromptTokenCount,
+			&e.CompletionTokenCount,
+		); err != nil {
+			return nil, err
+		}
+
+		e.Provider = provider.String
+		e.Model = model.String
+		e.Path = path.String
+
+		events = append(events, e)
+	}
+
+	return events, nil
+}
+
+// UpdateEventCosts overwrites the cost of the given events. The first cost
+// ever recorded for an event is kept in original_cost_in_usd.
+func (s *Store) UpdateEventCosts(ids []string, costs []float64) error {
+	query := `
+		UPDATE events
+		SET original_cost_in_usd = COALESCE(events.original_cost_in_usd, events.cost_in_usd), cost_in_usd = data.cost
+		FROM (SELECT unnest($1::VARCHAR(255)[]) AS id, unnest($2::FLOAT8[]) AS cost) AS data
+		WHERE events.event_id = data.id
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	if _, err := s.db.ExecContext(ctx, query, pq.Array(ids), pq.Array(costs)); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+)
+
+// AlterEventsTableForSettings must run after AlterTablesForLanguage since
+// events are read with SELECT *.
+func (s *Store) AlterEventsTableForSettings() error {
+	alterTableQuery := `
+		ALTER TABLE events ADD COLUMN IF NOT EXISTS setting_id VARCHAR(255) NOT NULL DEFAULT '';
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) CreateReconciliationsTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS reconciliations (
+		day VARCHAR(10) NOT NULL,
+		setting_id VARCHAR(255) NOT NULL,
+		provider VARCHAR(255) NOT NULL,
+		tenant_id VARCHAR(255) NOT NULL,
+		recorded_cost_in_usd FLOAT8 NOT NULL,
+		reported_cost_in_usd FLOAT8 NOT NULL,
+		difference_in_usd FLOAT8 NOT NULL,
+		difference_percentage FLOAT8 NOT NULL,
+		flagged BOOLEAN NOT NULL,
+		error TEXT NOT NULL,
+		updated_at BIGINT NOT NULL,
+		PRIMARY KEY (day, setting_id)
+	)`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetRecordedCostsBySetting sums the spend recorded between start and end for
+// each provider setting.
+func (s *Store) GetRecordedCostsBySetting(start, end int64) (map[string]float64, error) {
+	query := `
+		SELECT setting_id, COALESCE(SUM(cost_in_usd), 0) FROM events
+		WHERE created_at >= $1 AND created_at < $2 AND setting_id <> ''
+		GROUP BY setting_id
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	costs := map[string]float64{}
+	for rows.Next() {
+		var settingId string
+		var cost float64
+		if err := rows.Scan(&settingId, &cost); err != nil {
+			return nil, err
+		}
+
+		costs[settingId] = cost
+	}
+
+	return costs, nil
+}
+
+// UpsertReconciliation stores a reconciliation, replacing the one of the same
+// day and setting so that late provider data can be reconciled again.
+func (s *Store) UpsertReconciliation(r *event.Reconciliation) error {
+	query := `
+		INSERT INTO reconciliations (day, setting_id, provider, tenant_id, recorded_cost_in_usd, reported_cost_in_usd, difference_in_usd, difference_percentage, flagged, error, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (day, setting_id) DO UPDATE SET
+			provider = EXCLUDED.provider,
+			tenant_id = EXCLUDED.tenant_id,
+			recorded_cost_in_usd = EXCLUDED.recorded_cost_in_usd,
+			reported_cost_in_usd = EXCLUDED.reported_cost_in_usd,
+			difference_in_usd = EXCLUDED.difference_in_usd,
+			difference_percentage = EXCLUDED.difference_percentage,
+			flagged = EXCLUDED.flagged,
+			error = EXCLUDED.error,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query,
+		r.Day,
+		r.SettingId,
+		r.Provider,
+		r.TenantId,
+		r.RecordedCostInUsd,
+		r.ReportedCostInUsd,
+		r.DifferenceInUsd,
+		r.DifferencePercentage,
+		r.Flagged,
+		r.Error,
+		r.UpdatedAt,
+	)
+
+	return err
+}
+
+func (s *Store) GetReconciliations(r *event.ReconciliationRequest) ([]*event.Reconciliation, error) {
+	args := []any{r.Start, r.End}
+	conditions := "day >= $1 AND day <= $2"
+
+	if len(r.SettingId) != 0 {
+		args = append(args, r.SettingId)
+		conditions += fmt.Sprintf(" AND setting_id = $%d", len(args))
+	}
+
+	if len(r.TenantId) != 0 {
+		args = append(args, r.TenantId)
+		conditions += fmt.Sprintf(" AND tenant_id = $%d", len(args))
+	}
+
+	if r.Flagged {
+		conditions += " AND flagged"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT day, setting_id, provider, tenant_id, recorded_cost_in_usd, reported_cost_in_usd, difference_in_usd, difference_percentage, flagged, error, updated_at
+		FROM reconciliations WHERE %s
+		ORDER BY day, setting_id
+	`, conditions)
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reconciliations := []*event.Reconciliation{}
+	for rows.Next() {
+		rc := &event.Reconciliation{}
+		if err := rows.Scan(
+			&rc.Day,
+			&rc.SettingId,
+			&rc.Provider,
+			&rc.TenantId,
+			&rc.RecordedCostInUsd,
+			&rc.ReportedCostInUsd,
+			&rc.DifferenceInUsd,
+			&rc.DifferencePercentage,
+			&rc.Flagged,
+			&rc.Error,
+			&rc.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		reconciliations = append(reconciliations, rc)
+	}
+
+	return reconciliations, nil
+}
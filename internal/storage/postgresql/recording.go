@@ -0,0 +1,113 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+func (s *Store) AlterTablesForRecordings() error {
+	alterTableQuery := `
+		ALTER TABLE keys ADD COLUMN IF NOT EXISTS recording_retention VARCHAR(255) NOT NULL DEFAULT '';
+		ALTER TABLE recorded_requests ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS expires_at BIGINT NOT NULL DEFAULT 0, ADD COLUMN IF NOT EXISTS encrypted BOOLEAN NOT NULL DEFAULT FALSE;
+		CREATE INDEX IF NOT EXISTS recorded_requests_expires_at_idx ON recorded_requests (expires_at) WHERE expires_at > 0;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) CreateRecordingKeysTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS recording_keys (
+		tenant_id VARCHAR(255) PRIMARY KEY,
+		created_at BIGINT NOT NULL,
+		key BYTEA NOT NULL
+	)`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) GetRecordingKey(tenantId string) ([]byte, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	var key []byte
+	if err := s.db.QueryRowContext(ctxTimeout, "SELECT key FROM recording_keys WHERE tenant_id = $1", tenantId).Scan(&key); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError("recording key is not found")
+		}
+
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// CreateRecordingKey stores the recording key of a tenant unless another one
+// was stored concurrently, and returns the key that is stored.
+func (s *Store) CreateRecordingKey(tenantId string, createdAt int64, key []byte) ([]byte, error) {
+	query := `
+		INSERT INTO recording_keys (tenant_id, created_at, key)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (tenant_id) DO UPDATE SET tenant_id = EXCLUDED.tenant_id
+		RETURNING key
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	var stored []byte
+	if err := s.db.QueryRowContext(ctxTimeout, query, tenantId, createdAt, key).Scan(&stored); err != nil {
+		return nil, err
+	}
+
+	return stored, nil
+}
+
+func (s *Store) DeleteRecordingKey(tenantId string) error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctxTimeout, "DELETE FROM recording_keys WHERE tenant_id = $1", tenantId)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return internal_errors.NewNotFoundError("recording key is not found")
+	}
+
+	return nil
+}
+
+func (s *Store) DeleteExpiredRecordedRequests(now int64) (int64, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctxTimeout, "DELETE FROM recorded_requests WHERE expires_at > 0 AND expires_at <= $1", now)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
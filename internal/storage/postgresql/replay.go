@@ -0,0 +1,84 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/event"
+)
+
+func (s *Store) CreateRecordedRequestsTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS recorded_requests (
+		event_id VARCHAR(255) PRIMARY KEY,
+		created_at BIGINT NOT NULL,
+		request BYTEA,
+		response BYTEA
+	)`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) InsertRecordedRequest(r *event.RecordedRequest) error {
+	query := `
+		INSERT INTO recorded_requests (event_id, created_at, request, response, tenant_id, expires_at, encrypted)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctxTimeout, query, r.EventId, r.CreatedAt, r.Request, r.Response, r.TenantId, r.ExpiresAt, r.Encrypted)
+	return err
+}
+
+func (s *Store) GetRecordedRequest(eventId string) (*event.RecordedRequest, error) {
+	query := `
+		SELECT event_id, created_at, request, response, tenant_id, expires_at, encrypted FROM recorded_requests WHERE event_id = $1
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	r := &event.RecordedRequest{}
+	err := s.db.QueryRowContext(ctxTimeout, query, eventId).Scan(&r.EventId, &r.CreatedAt, &r.Request, &r.Response, &r.TenantId, &r.ExpiresAt, &r.Encrypted)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError("recorded request is not found")
+		}
+
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (s *Store) GetEvent(id string) (*event.Event, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, "SELECT * FROM events WHERE event_id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events, err := scanEvents(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(events) == 0 {
+		return nil, internal_errors.NewNotFoundError("event is not found")
+	}
+
+	return events[0], nil
+}
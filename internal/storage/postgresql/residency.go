@@ -0,0 +1,23 @@
+package postgresql
+
+import (
+	"context"
+)
+
+// AlterTablesForResidency must run after AlterTablesForEgress since provider
+// settings are read with SELECT *.
+func (s *Store) AlterTablesForResidency() error {
+	alterTableQuery := `
+		ALTER TABLE keys ADD COLUMN IF NOT EXISTS allowed_regions VARCHAR(255)[];
+		ALTER TABLE provider_settings ADD COLUMN IF NOT EXISTS region VARCHAR(255) NOT NULL DEFAULT '';
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -33,6 +33,225 @@ func (s *Store) CreateRoutesTable() error {
 	return nil
 }
 
+func (s *Store) AlterRoutesTable() error {
+	alterTableQuery := `
+		ALTER TABLE routes ADD COLUMN IF NOT EXISTS slo JSONB, ADD COLUMN IF NOT EXISTS prompt_template JSONB, ADD COLUMN IF NOT EXISTS system_prompt JSONB, ADD COLUMN IF NOT EXISTS request_rules JSONB, ADD COLUMN IF NOT EXISTS response_transforms JSONB, ADD COLUMN IF NOT EXISTS shadow JSONB
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AlterRoutesTableForDedup must run after AlterTablesForEgress since routes
+// are read with SELECT *.
+func (s *Store) AlterRoutesTableForDedup() error {
+	alterTableQuery := `
+		ALTER TABLE routes ADD COLUMN IF NOT EXISTS dedup JSONB
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AlterRoutesTableForDowngrade must run after AlterRoutesTableForDedup since
+// routes are read with SELECT *.
+func (s *Store) AlterRoutesTableForDowngrade() error {
+	alterTableQuery := `
+		ALTER TABLE routes ADD COLUMN IF NOT EXISTS downgrade JSONB
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AlterRoutesTableForEmbeddings must run after AlterTablesForLanguage since
+// routes are read with SELECT *.
+func (s *Store) AlterRoutesTableForEmbeddings() error {
+	alterTableQuery := `
+		ALTER TABLE routes ADD COLUMN IF NOT EXISTS embeddings JSONB
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AlterRoutesTableForCompression must run after
+// AlterRoutesTableForEmbeddings since routes are read with SELECT *.
+func (s *Store) AlterRoutesTableForCompression() error {
+	alterTableQuery := `
+		ALTER TABLE routes ADD COLUMN IF NOT EXISTS compression JSONB
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AlterRoutesTableForResponseHeaders must run after AlterTablesForJudge
+// since routes are read with SELECT *.
+func (s *Store) AlterRoutesTableForResponseHeaders() error {
+	alterTableQuery := `
+		ALTER TABLE routes ADD COLUMN IF NOT EXISTS response_headers JSONB
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AlterRoutesTableForAuth must run after AlterRoutesTableForResponseHeaders
+// since routes are read with SELECT *.
+func (s *Store) AlterRoutesTableForAuth() error {
+	alterTableQuery := `
+		ALTER TABLE routes ADD COLUMN IF NOT EXISTS auth JSONB
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AlterRoutesTableForComplexity must run after AlterRoutesTableForAuth since
+// routes are read with SELECT *.
+func (s *Store) AlterRoutesTableForComplexity() error {
+	alterTableQuery := `
+		ALTER TABLE routes ADD COLUMN IF NOT EXISTS complexity JSONB
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AlterRoutesTableForContextWindow must run after
+// AlterRoutesTableForComplexity since routes are read with SELECT *.
+func (s *Store) AlterRoutesTableForContextWindow() error {
+	alterTableQuery := `
+		ALTER TABLE routes ADD COLUMN IF NOT EXISTS context_window JSONB
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AlterRoutesTableForPipeline must run after
+// AlterRoutesTableForContextWindow since routes are read with SELECT *.
+func (s *Store) AlterRoutesTableForPipeline() error {
+	alterTableQuery := `
+		ALTER TABLE routes ADD COLUMN IF NOT EXISTS pipeline JSONB
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AlterRoutesTableForRetrieval must run after AlterRoutesTableForPipeline
+// since routes are read with SELECT *.
+func (s *Store) AlterRoutesTableForRetrieval() error {
+	alterTableQuery := `
+		ALTER TABLE routes ADD COLUMN IF NOT EXISTS retrieval JSONB
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AlterRoutesTableForGuardrails must run after AlterRoutesTableForRetrieval
+// since routes are read with SELECT *.
+func (s *Store) AlterRoutesTableForGuardrails() error {
+	alterTableQuery := `
+		ALTER TABLE routes ADD COLUMN IF NOT EXISTS guardrails JSONB
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AlterRoutesTableForCors must run after AlterRoutesTableForGuardrails since
+// routes are read with SELECT *.
+func (s *Store) AlterRoutesTableForCors() error {
+	alterTableQuery := `
+		ALTER TABLE routes ADD COLUMN IF NOT EXISTS cors JSONB
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (s *Store) DropRoutesTable() error {
 	dropTableQuery := `DROP TABLE routes`
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
@@ -57,6 +276,216 @@ func (s *Store) CreateRoute(r *route.Route) (*route.Route, error) {
 		return nil, err
 	}
 
+	var slobytes []byte
+	if r.Slo != nil {
+		data, err := json.Marshal(r.Slo)
+		if err != nil {
+			return nil, err
+		}
+
+		slobytes = data
+	}
+
+	var ptbytes []byte
+	if r.PromptTemplate != nil {
+		data, err := json.Marshal(r.PromptTemplate)
+		if err != nil {
+			return nil, err
+		}
+
+		ptbytes = data
+	}
+
+	var spbytes []byte
+	if r.SystemPrompt != nil {
+		data, err := json.Marshal(r.SystemPrompt)
+		if err != nil {
+			return nil, err
+		}
+
+		spbytes = data
+	}
+
+	var rrbytes []byte
+	if len(r.RequestRules) != 0 {
+		data, err := json.Marshal(r.RequestRules)
+		if err != nil {
+			return nil, err
+		}
+
+		rrbytes = data
+	}
+
+	var rtbytes []byte
+	if len(r.ResponseTransforms) != 0 {
+		data, err := json.Marshal(r.ResponseTransforms)
+		if err != nil {
+			return nil, err
+		}
+
+		rtbytes = data
+	}
+
+	var shbytes []byte
+	if r.Shadow != nil {
+		data, err := json.Marshal(r.Shadow)
+		if err != nil {
+			return nil, err
+		}
+
+		shbytes = data
+	}
+
+	var egbytes []byte
+	if r.Egress != nil {
+		data, err := json.Marshal(r.Egress)
+		if err != nil {
+			return nil, err
+		}
+
+		egbytes = data
+	}
+
+	var ddbytes []byte
+	if r.Dedup != nil {
+		data, err := json.Marshal(r.Dedup)
+		if err != nil {
+			return nil, err
+		}
+
+		ddbytes = data
+	}
+
+	var dgbytes []byte
+	if r.Downgrade != nil {
+		data, err := json.Marshal(r.Downgrade)
+		if err != nil {
+			return nil, err
+		}
+
+		dgbytes = data
+	}
+
+	var lgbytes []byte
+	if r.Language != nil {
+		data, err := json.Marshal(r.Language)
+		if err != nil {
+			return nil, err
+		}
+
+		lgbytes = data
+	}
+
+	var embytes []byte
+	if r.Embeddings != nil {
+		data, err := json.Marshal(r.Embeddings)
+		if err != nil {
+			return nil, err
+		}
+
+		embytes = data
+	}
+
+	var cpbytes []byte
+	if r.Compression != nil {
+		data, err := json.Marshal(r.Compression)
+		if err != nil {
+			return nil, err
+		}
+
+		cpbytes = data
+	}
+
+	var jgbytes []byte
+	if r.Judge != nil {
+		data, err := json.Marshal(r.Judge)
+		if err != nil {
+			return nil, err
+		}
+
+		jgbytes = data
+	}
+
+	var rhbytes []byte
+	if len(r.ResponseHeaders) != 0 {
+		data, err := json.Marshal(r.ResponseHeaders)
+		if err != nil {
+			return nil, err
+		}
+
+		rhbytes = data
+	}
+
+	var aubytes []byte
+	if r.Auth != nil {
+		data, err := json.Marshal(r.Auth)
+		if err != nil {
+			return nil, err
+		}
+
+		aubytes = data
+	}
+
+	var cxbytes []byte
+	if r.Complexity != nil {
+		data, err := json.Marshal(r.Complexity)
+		if err != nil {
+			return nil, err
+		}
+
+		cxbytes = data
+	}
+
+	var cwbytes []byte
+	if r.ContextWindow != nil {
+		data, err := json.Marshal(r.ContextWindow)
+		if err != nil {
+			return nil, err
+		}
+
+		cwbytes = data
+	}
+
+	var plbytes []byte
+	if r.Pipeline != nil {
+		data, err := json.Marshal(r.Pipeline)
+		if err != nil {
+			return nil, err
+		}
+
+		plbytes = data
+	}
+
+	var rvbytes []byte
+	if r.Retrieval != nil {
+		data, err := json.Marshal(r.Retrieval)
+		if err != nil {
+			return nil, err
+		}
+
+		rvbytes = data
+	}
+
+	var grbytes []byte
+	if r.Guardrails != nil {
+		data, err := json.Marshal(r.Guardrails)
+		if err != nil {
+			return nil, err
+		}
+
+		grbytes = data
+	}
+
+	var crbytes []byte
+	if r.Cors != nil {
+		data, err := json.Marshal(r.Cors)
+		if err != nil {
+			return nil, err
+		}
+
+		crbytes = data
+	}
+
 	values := []any{
 		r.Id,
 		r.CreatedAt,
@@ -66,12 +495,34 @@ func (s *Store) CreateRoute(r *route.Route) (*route.Route, error) {
 		sliceToSqlStringArray(r.KeyIds),
 		sbytes,
 		cbytes,
+		slobytes,
+		ptbytes,
+		spbytes,
+		rrbytes,
+		rtbytes,
+		shbytes,
+		r.TenantId,
+		egbytes,
+		ddbytes,
+		dgbytes,
+		lgbytes,
+		embytes,
+		cpbytes,
+		jgbytes,
+		rhbytes,
+		aubytes,
+		cxbytes,
+		cwbytes,
+		plbytes,
+		rvbytes,
+		grbytes,
+		crbytes,
 	}
 
 	query := `
-	INSERT INTO routes (id, created_at, updated_at, name, path, key_ids, steps, cache_config)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-	RETURNING id, created_at, updated_at, name, path, key_ids, steps, cache_config
+	INSERT INTO routes (id, created_at, updated_at, name, path, key_ids, steps, cache_config, slo, prompt_template, system_prompt, request_rules, response_transforms, shadow, tenant_id, egress, dedup, downgrade, language, embeddings, compression, judge, response_headers, auth, complexity, context_window, pipeline, retrieval, guardrails, cors)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30)
+	RETURNING id, created_at, updated_at, name, path, key_ids, steps, cache_config, slo, prompt_template, system_prompt, request_rules, response_transforms, shadow, tenant_id, egress, dedup, downgrade, language, embeddings, compression, judge, response_headers, auth, complexity, context_window, pipeline, retrieval, guardrails, cors
 `
 
 	created := &route.Route{}
@@ -81,6 +532,27 @@ func (s *Store) CreateRoute(r *route.Route) (*route.Route, error) {
 
 	var cdata []byte
 	var sdata []byte
+	var slodata []byte
+	var ptdata []byte
+	var spdata []byte
+	var rrdata []byte
+	var rtdata []byte
+	var shdata []byte
+	var egdata []byte
+	var dddata []byte
+	var dgdata []byte
+	var lgdata []byte
+	var emdata []byte
+	var cpdata []byte
+	var jgdata []byte
+	var rhdata []byte
+	var audata []byte
+	var cxdata []byte
+	var cwdata []byte
+	var pldata []byte
+	var rvdata []byte
+	var grdata []byte
+	var crdata []byte
 	if err := s.db.QueryRowContext(ctxTimeout, query, values...).Scan(
 		&created.Id,
 		&created.CreatedAt,
@@ -90,6 +562,28 @@ func (s *Store) CreateRoute(r *route.Route) (*route.Route, error) {
 		pq.Array(&created.KeyIds),
 		&sdata,
 		&cdata,
+		&slodata,
+		&ptdata,
+		&spdata,
+		&rrdata,
+		&rtdata,
+		&shdata,
+		&created.TenantId,
+		&egdata,
+		&dddata,
+		&dgdata,
+		&lgdata,
+		&emdata,
+		&cpdata,
+		&jgdata,
+		&rhdata,
+		&audata,
+		&cxdata,
+		&cwdata,
+		&pldata,
+		&rvdata,
+		&grdata,
+		&crdata,
 	); err != nil {
 		return nil, err
 	}
@@ -98,8 +592,134 @@ func (s *Store) CreateRoute(r *route.Route) (*route.Route, error) {
 		return nil, err
 	}
 
-	if err := json.Unmarshal(cdata, &created.CacheConfig); err != nil {
-		return nil, err
+	if err := json.Unmarshal(cdata, &created.CacheConfig); err != nil {
+		return nil, err
+	}
+
+	if len(slodata) != 0 {
+		if err := json.Unmarshal(slodata, &created.Slo); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(ptdata) != 0 {
+		if err := json.Unmarshal(ptdata, &created.PromptTemplate); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(spdata) != 0 {
+		if err := json.Unmarshal(spdata, &created.SystemPrompt); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rrdata) != 0 {
+		if err := json.Unmarshal(rrdata, &created.RequestRules); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rtdata) != 0 {
+		if err := json.Unmarshal(rtdata, &created.ResponseTransforms); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(shdata) != 0 {
+		if err := json.Unmarshal(shdata, &created.Shadow); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(egdata) != 0 {
+		if err := json.Unmarshal(egdata, &created.Egress); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(dddata) != 0 {
+		if err := json.Unmarshal(dddata, &created.Dedup); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(dgdata) != 0 {
+		if err := json.Unmarshal(dgdata, &created.Downgrade); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(lgdata) != 0 {
+		if err := json.Unmarshal(lgdata, &created.Language); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(emdata) != 0 {
+		if err := json.Unmarshal(emdata, &created.Embeddings); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cpdata) != 0 {
+		if err := json.Unmarshal(cpdata, &created.Compression); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(jgdata) != 0 {
+		if err := json.Unmarshal(jgdata, &created.Judge); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rhdata) != 0 {
+		if err := json.Unmarshal(rhdata, &created.ResponseHeaders); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(audata) != 0 {
+		if err := json.Unmarshal(audata, &created.Auth); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cxdata) != 0 {
+		if err := json.Unmarshal(cxdata, &created.Complexity); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cwdata) != 0 {
+		if err := json.Unmarshal(cwdata, &created.ContextWindow); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(pldata) != 0 {
+		if err := json.Unmarshal(pldata, &created.Pipeline); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rvdata) != 0 {
+		if err := json.Unmarshal(rvdata, &created.Retrieval); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(grdata) != 0 {
+		if err := json.Unmarshal(grdata, &created.Guardrails); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(crdata) != 0 {
+		if err := json.Unmarshal(crdata, &created.Cors); err != nil {
+			return nil, err
+		}
 	}
 
 	return created, nil
@@ -111,6 +731,27 @@ func (s *Store) GetRoute(id string) (*route.Route, error) {
 
 	var cdata []byte
 	var sdata []byte
+	var slodata []byte
+	var ptdata []byte
+	var spdata []byte
+	var rrdata []byte
+	var rtdata []byte
+	var shdata []byte
+	var egdata []byte
+	var dddata []byte
+	var dgdata []byte
+	var lgdata []byte
+	var emdata []byte
+	var cpdata []byte
+	var jgdata []byte
+	var rhdata []byte
+	var audata []byte
+	var cxdata []byte
+	var cwdata []byte
+	var pldata []byte
+	var rvdata []byte
+	var grdata []byte
+	var crdata []byte
 	created := &route.Route{}
 	if err := s.db.QueryRowContext(ctxTimeout, "SELECT * FROM routes WHERE $1 = id", id).Scan(
 		&created.Id,
@@ -121,6 +762,28 @@ func (s *Store) GetRoute(id string) (*route.Route, error) {
 		pq.Array(&created.KeyIds),
 		&sdata,
 		&cdata,
+		&slodata,
+		&ptdata,
+		&spdata,
+		&rrdata,
+		&rtdata,
+		&shdata,
+		&created.TenantId,
+		&egdata,
+		&dddata,
+		&dgdata,
+		&lgdata,
+		&emdata,
+		&cpdata,
+		&jgdata,
+		&rhdata,
+		&audata,
+		&cxdata,
+		&cwdata,
+		&pldata,
+		&rvdata,
+		&grdata,
+		&crdata,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, internal_errors.NewNotFoundError("custom provider is not found")
@@ -137,17 +800,164 @@ func (s *Store) GetRoute(id string) (*route.Route, error) {
 		return nil, err
 	}
 
+	if len(slodata) != 0 {
+		if err := json.Unmarshal(slodata, &created.Slo); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(ptdata) != 0 {
+		if err := json.Unmarshal(ptdata, &created.PromptTemplate); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(spdata) != 0 {
+		if err := json.Unmarshal(spdata, &created.SystemPrompt); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rrdata) != 0 {
+		if err := json.Unmarshal(rrdata, &created.RequestRules); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rtdata) != 0 {
+		if err := json.Unmarshal(rtdata, &created.ResponseTransforms); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(shdata) != 0 {
+		if err := json.Unmarshal(shdata, &created.Shadow); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(egdata) != 0 {
+		if err := json.Unmarshal(egdata, &created.Egress); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(dddata) != 0 {
+		if err := json.Unmarshal(dddata, &created.Dedup); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(dgdata) != 0 {
+		if err := json.Unmarshal(dgdata, &created.Downgrade); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(lgdata) != 0 {
+		if err := json.Unmarshal(lgdata, &created.Language); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(emdata) != 0 {
+		if err := json.Unmarshal(emdata, &created.Embeddings); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cpdata) != 0 {
+		if err := json.Unmarshal(cpdata, &created.Compression); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(jgdata) != 0 {
+		if err := json.Unmarshal(jgdata, &created.Judge); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rhdata) != 0 {
+		if err := json.Unmarshal(rhdata, &created.ResponseHeaders); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(audata) != 0 {
+		if err := json.Unmarshal(audata, &created.Auth); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cxdata) != 0 {
+		if err := json.Unmarshal(cxdata, &created.Complexity); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cwdata) != 0 {
+		if err := json.Unmarshal(cwdata, &created.ContextWindow); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(pldata) != 0 {
+		if err := json.Unmarshal(pldata, &created.Pipeline); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rvdata) != 0 {
+		if err := json.Unmarshal(rvdata, &created.Retrieval); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(grdata) != 0 {
+		if err := json.Unmarshal(grdata, &created.Guardrails); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(crdata) != 0 {
+		if err := json.Unmarshal(crdata, &created.Cors); err != nil {
+			return nil, err
+		}
+	}
+
 	return created, nil
 }
 
-func (s *Store) GetRouteByPath(path string) (*route.Route, error) {
+func (s *Store) GetRouteByPath(tenantId, path string) (*route.Route, error) {
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
 	defer cancel()
 
 	var cdata []byte
 	var sdata []byte
+	var slodata []byte
+	var ptdata []byte
+	var spdata []byte
+	var rrdata []byte
+	var rtdata []byte
+	var shdata []byte
+	var egdata []byte
+	var dddata []byte
+	var dgdata []byte
+	var lgdata []byte
+	var emdata []byte
+	var cpdata []byte
+	var jgdata []byte
+	var rhdata []byte
+	var audata []byte
+	var cxdata []byte
+	var cwdata []byte
+	var pldata []byte
+	var rvdata []byte
+	var grdata []byte
+	var crdata []byte
 	created := &route.Route{}
-	if err := s.db.QueryRowContext(ctxTimeout, "SELECT * FROM routes WHERE $1 = path", path).Scan(
+	if err := s.db.QueryRowContext(ctxTimeout, "SELECT * FROM routes WHERE $1 = path AND $2 = tenant_id", path, tenantId).Scan(
 		&created.Id,
 		&created.CreatedAt,
 		&created.UpdatedAt,
@@ -156,6 +966,28 @@ func (s *Store) GetRouteByPath(path string) (*route.Route, error) {
 		pq.Array(&created.KeyIds),
 		&sdata,
 		&cdata,
+		&slodata,
+		&ptdata,
+		&spdata,
+		&rrdata,
+		&rtdata,
+		&shdata,
+		&created.TenantId,
+		&egdata,
+		&dddata,
+		&dgdata,
+		&lgdata,
+		&emdata,
+		&cpdata,
+		&jgdata,
+		&rhdata,
+		&audata,
+		&cxdata,
+		&cwdata,
+		&pldata,
+		&rvdata,
+		&grdata,
+		&crdata,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, internal_errors.NewNotFoundError("route is not found")
@@ -172,6 +1004,132 @@ func (s *Store) GetRouteByPath(path string) (*route.Route, error) {
 		return nil, err
 	}
 
+	if len(slodata) != 0 {
+		if err := json.Unmarshal(slodata, &created.Slo); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(ptdata) != 0 {
+		if err := json.Unmarshal(ptdata, &created.PromptTemplate); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(spdata) != 0 {
+		if err := json.Unmarshal(spdata, &created.SystemPrompt); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rrdata) != 0 {
+		if err := json.Unmarshal(rrdata, &created.RequestRules); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rtdata) != 0 {
+		if err := json.Unmarshal(rtdata, &created.ResponseTransforms); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(shdata) != 0 {
+		if err := json.Unmarshal(shdata, &created.Shadow); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(egdata) != 0 {
+		if err := json.Unmarshal(egdata, &created.Egress); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(dddata) != 0 {
+		if err := json.Unmarshal(dddata, &created.Dedup); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(dgdata) != 0 {
+		if err := json.Unmarshal(dgdata, &created.Downgrade); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(lgdata) != 0 {
+		if err := json.Unmarshal(lgdata, &created.Language); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(emdata) != 0 {
+		if err := json.Unmarshal(emdata, &created.Embeddings); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cpdata) != 0 {
+		if err := json.Unmarshal(cpdata, &created.Compression); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(jgdata) != 0 {
+		if err := json.Unmarshal(jgdata, &created.Judge); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rhdata) != 0 {
+		if err := json.Unmarshal(rhdata, &created.ResponseHeaders); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(audata) != 0 {
+		if err := json.Unmarshal(audata, &created.Auth); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cxdata) != 0 {
+		if err := json.Unmarshal(cxdata, &created.Complexity); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cwdata) != 0 {
+		if err := json.Unmarshal(cwdata, &created.ContextWindow); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(pldata) != 0 {
+		if err := json.Unmarshal(pldata, &created.Pipeline); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rvdata) != 0 {
+		if err := json.Unmarshal(rvdata, &created.Retrieval); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(grdata) != 0 {
+		if err := json.Unmarshal(grdata, &created.Guardrails); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(crdata) != 0 {
+		if err := json.Unmarshal(crdata, &created.Cors); err != nil {
+			return nil, err
+		}
+	}
+
 	return created, nil
 }
 
@@ -190,6 +1148,27 @@ func (s *Store) GetUpdatedRoutes(updatedAt int64) ([]*route.Route, error) {
 		r := &route.Route{}
 		var cdata []byte
 		var sdata []byte
+		var slodata []byte
+		var ptdata []byte
+		var spdata []byte
+		var rrdata []byte
+		var rtdata []byte
+		var shdata []byte
+		var egdata []byte
+		var dddata []byte
+		var dgdata []byte
+		var lgdata []byte
+		var emdata []byte
+		var cpdata []byte
+		var jgdata []byte
+		var rhdata []byte
+		var audata []byte
+		var cxdata []byte
+		var cwdata []byte
+		var pldata []byte
+		var rvdata []byte
+		var grdata []byte
+		var crdata []byte
 		if err := rows.Scan(
 			&r.Id,
 			&r.CreatedAt,
@@ -199,6 +1178,28 @@ func (s *Store) GetUpdatedRoutes(updatedAt int64) ([]*route.Route, error) {
 			pq.Array(&r.KeyIds),
 			&sdata,
 			&cdata,
+			&slodata,
+			&ptdata,
+			&spdata,
+			&rrdata,
+			&rtdata,
+			&shdata,
+			&r.TenantId,
+			&egdata,
+			&dddata,
+			&dgdata,
+			&lgdata,
+			&emdata,
+			&cpdata,
+			&jgdata,
+			&rhdata,
+			&audata,
+			&cxdata,
+			&cwdata,
+			&pldata,
+			&rvdata,
+			&grdata,
+			&crdata,
 		); err != nil {
 			return nil, err
 		}
@@ -211,6 +1212,132 @@ func (s *Store) GetUpdatedRoutes(updatedAt int64) ([]*route.Route, error) {
 			return nil, err
 		}
 
+		if len(slodata) != 0 {
+			if err := json.Unmarshal(slodata, &r.Slo); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(ptdata) != 0 {
+			if err := json.Unmarshal(ptdata, &r.PromptTemplate); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(spdata) != 0 {
+			if err := json.Unmarshal(spdata, &r.SystemPrompt); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(rrdata) != 0 {
+			if err := json.Unmarshal(rrdata, &r.RequestRules); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(rtdata) != 0 {
+			if err := json.Unmarshal(rtdata, &r.ResponseTransforms); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(shdata) != 0 {
+			if err := json.Unmarshal(shdata, &r.Shadow); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(egdata) != 0 {
+			if err := json.Unmarshal(egdata, &r.Egress); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(dddata) != 0 {
+			if err := json.Unmarshal(dddata, &r.Dedup); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(dgdata) != 0 {
+			if err := json.Unmarshal(dgdata, &r.Downgrade); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(lgdata) != 0 {
+			if err := json.Unmarshal(lgdata, &r.Language); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(emdata) != 0 {
+			if err := json.Unmarshal(emdata, &r.Embeddings); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(cpdata) != 0 {
+			if err := json.Unmarshal(cpdata, &r.Compression); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(jgdata) != 0 {
+			if err := json.Unmarshal(jgdata, &r.Judge); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(rhdata) != 0 {
+			if err := json.Unmarshal(rhdata, &r.ResponseHeaders); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(audata) != 0 {
+			if err := json.Unmarshal(audata, &r.Auth); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(cxdata) != 0 {
+			if err := json.Unmarshal(cxdata, &r.Complexity); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(cwdata) != 0 {
+			if err := json.Unmarshal(cwdata, &r.ContextWindow); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(pldata) != 0 {
+			if err := json.Unmarshal(pldata, &r.Pipeline); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(rvdata) != 0 {
+			if err := json.Unmarshal(rvdata, &r.Retrieval); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(grdata) != 0 {
+			if err := json.Unmarshal(grdata, &r.Guardrails); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(crdata) != 0 {
+			if err := json.Unmarshal(crdata, &r.Cors); err != nil {
+				return nil, err
+			}
+		}
+
 		routes = append(routes, r)
 	}
 
@@ -232,6 +1359,27 @@ func (s *Store) GetRoutes() ([]*route.Route, error) {
 		r := &route.Route{}
 		var cdata []byte
 		var sdata []byte
+		var slodata []byte
+		var ptdata []byte
+		var spdata []byte
+		var rrdata []byte
+		var rtdata []byte
+		var shdata []byte
+		var egdata []byte
+		var dddata []byte
+		var dgdata []byte
+		var lgdata []byte
+		var emdata []byte
+		var cpdata []byte
+		var jgdata []byte
+		var rhdata []byte
+		var audata []byte
+		var cxdata []byte
+		var cwdata []byte
+		var pldata []byte
+		var rvdata []byte
+		var grdata []byte
+		var crdata []byte
 		if err := rows.Scan(
 			&r.Id,
 			&r.CreatedAt,
@@ -241,6 +1389,28 @@ func (s *Store) GetRoutes() ([]*route.Route, error) {
 			pq.Array(&r.KeyIds),
 			&sdata,
 			&cdata,
+			&slodata,
+			&ptdata,
+			&spdata,
+			&rrdata,
+			&rtdata,
+			&shdata,
+			&r.TenantId,
+			&egdata,
+			&dddata,
+			&dgdata,
+			&lgdata,
+			&emdata,
+			&cpdata,
+			&jgdata,
+			&rhdata,
+			&audata,
+			&cxdata,
+			&cwdata,
+			&pldata,
+			&rvdata,
+			&grdata,
+			&crdata,
 		); err != nil {
 			return nil, err
 		}
@@ -253,6 +1423,132 @@ func (s *Store) GetRoutes() ([]*route.Route, error) {
 			return nil, err
 		}
 
+		if len(slodata) != 0 {
+			if err := json.Unmarshal(slodata, &r.Slo); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(ptdata) != 0 {
+			if err := json.Unmarshal(ptdata, &r.PromptTemplate); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(spdata) != 0 {
+			if err := json.Unmarshal(spdata, &r.SystemPrompt); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(rrdata) != 0 {
+			if err := json.Unmarshal(rrdata, &r.RequestRules); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(rtdata) != 0 {
+			if err := json.Unmarshal(rtdata, &r.ResponseTransforms); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(shdata) != 0 {
+			if err := json.Unmarshal(shdata, &r.Shadow); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(egdata) != 0 {
+			if err := json.Unmarshal(egdata, &r.Egress); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(dddata) != 0 {
+			if err := json.Unmarshal(dddata, &r.Dedup); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(dgdata) != 0 {
+			if err := json.Unmarshal(dgdata, &r.Downgrade); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(lgdata) != 0 {
+			if err := json.Unmarshal(lgdata, &r.Language); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(emdata) != 0 {
+			if err := json.Unmarshal(emdata, &r.Embeddings); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(cpdata) != 0 {
+			if err := json.Unmarshal(cpdata, &r.Compression); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(jgdata) != 0 {
+			if err := json.Unmarshal(jgdata, &r.Judge); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(rhdata) != 0 {
+			if err := json.Unmarshal(rhdata, &r.ResponseHeaders); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(audata) != 0 {
+			if err := json.Unmarshal(audata, &r.Auth); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(cxdata) != 0 {
+			if err := json.Unmarshal(cxdata, &r.Complexity); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(cwdata) != 0 {
+			if err := json.Unmarshal(cwdata, &r.ContextWindow); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(pldata) != 0 {
+			if err := json.Unmarshal(pldata, &r.Pipeline); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(rvdata) != 0 {
+			if err := json.Unmarshal(rvdata, &r.Retrieval); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(grdata) != 0 {
+			if err := json.Unmarshal(grdata, &r.Guardrails); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(crdata) != 0 {
+			if err := json.Unmarshal(crdata, &r.Cors); err != nil {
+				return nil, err
+			}
+		}
+
 		routes = append(routes, r)
 	}
 
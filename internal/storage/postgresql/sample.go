@@ -0,0 +1,122 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+)
+
+func (s *Store) CreateSamplesTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS samples (
+		id VARCHAR(255) PRIMARY KEY,
+		created_at BIGINT NOT NULL,
+		event_id VARCHAR(255) NOT NULL,
+		tenant_id VARCHAR(255) NOT NULL,
+		key_id VARCHAR(255) NOT NULL,
+		provider VARCHAR(255) NOT NULL,
+		model VARCHAR(255) NOT NULL,
+		messages JSONB NOT NULL,
+		completion TEXT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS samples_created_at_idx ON samples (created_at, id);
+	CREATE INDEX IF NOT EXISTS samples_event_id_idx ON samples (event_id);
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) InsertSample(sp *event.Sample) error {
+	query := `
+		INSERT INTO samples (id, created_at, event_id, tenant_id, key_id, provider, model, messages, completion)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query,
+		sp.Id,
+		sp.CreatedAt,
+		sp.EventId,
+		sp.TenantId,
+		sp.KeyId,
+		sp.Provider,
+		sp.Model,
+		[]byte(sp.Messages),
+		sp.Completion,
+	)
+
+	return err
+}
+
+// GetSamples pages through the samples of an export request ordered by
+// creation time and id.
+func (s *Store) GetSamples(r *event.SampleExportRequest, afterCreatedAt int64, afterId string, limit int) ([]*event.Sample, error) {
+	args := []any{afterCreatedAt, afterId, r.Start, r.End}
+	conditions := "(created_at, id) > ($1, $2) AND created_at >= $3 AND created_at <= $4"
+
+	if len(r.Model) != 0 {
+		args = append(args, r.Model)
+		conditions += fmt.Sprintf(" AND model = $%d", len(args))
+	}
+
+	if len(r.KeyId) != 0 {
+		args = append(args, r.KeyId)
+		conditions += fmt.Sprintf(" AND key_id = $%d", len(args))
+	}
+
+	if len(r.TenantId) != 0 {
+		args = append(args, r.TenantId)
+		conditions += fmt.Sprintf(" AND tenant_id = $%d", len(args))
+	}
+
+	args = append(args, limit)
+	query := fmt.Sprintf(`
+		SELECT id, created_at, event_id, tenant_id, key_id, provider, model, messages, completion FROM samples
+		WHERE %s
+		ORDER BY created_at, id
+		LIMIT $%d
+	`, conditions, len(args))
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	samples := []*event.Sample{}
+	for rows.Next() {
+		sp := &event.Sample{}
+		var messages []byte
+		if err := rows.Scan(
+			&sp.Id,
+			&sp.CreatedAt,
+			&sp.EventId,
+			&sp.TenantId,
+			&sp.KeyId,
+			&sp.Provider,
+			&sp.Model,
+			&messages,
+			&sp.Completion,
+		); err != nil {
+			return nil, err
+		}
+
+		sp.Messages = messages
+		samples = append(samples, sp)
+	}
+
+	return samples, nil
+}
@@ -0,0 +1,291 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/schedule"
+)
+
+func (s *Store) CreateSchedulesTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS schedules (
+		id VARCHAR(255) PRIMARY KEY,
+		created_at BIGINT NOT NULL,
+		updated_at BIGINT NOT NULL,
+		tenant_id VARCHAR(255) NOT NULL,
+		name VARCHAR(255) NOT NULL,
+		cron VARCHAR(255) NOT NULL,
+		timezone VARCHAR(255) NOT NULL,
+		key_id VARCHAR(255) NOT NULL,
+		route_path VARCHAR(255) NOT NULL,
+		request JSONB NOT NULL,
+		destination JSONB NOT NULL,
+		paused BOOLEAN NOT NULL,
+		last_run_at BIGINT NOT NULL,
+		next_run_at BIGINT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS schedules_next_run_at_idx ON schedules (next_run_at);
+	CREATE TABLE IF NOT EXISTS schedule_runs (
+		id VARCHAR(255) PRIMARY KEY,
+		schedule_id VARCHAR(255) NOT NULL,
+		started_at BIGINT NOT NULL,
+		finished_at BIGINT NOT NULL,
+		status VARCHAR(255) NOT NULL,
+		request_id VARCHAR(255) NOT NULL,
+		status_code INT NOT NULL,
+		location TEXT NOT NULL,
+		error TEXT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS schedule_runs_schedule_id_started_at_idx ON schedule_runs (schedule_id, started_at);
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+const scheduleColumns = "id, created_at, updated_at, tenant_id, name, cron, timezone, key_id, route_path, request, destination, paused, last_run_at, next_run_at"
+
+func scanSchedule(row rowScanner) (*schedule.Schedule, error) {
+	sc := &schedule.Schedule{}
+	var request []byte
+	var destination []byte
+
+	if err := row.Scan(
+		&sc.Id,
+		&sc.CreatedAt,
+		&sc.UpdatedAt,
+		&sc.TenantId,
+		&sc.Name,
+		&sc.Cron,
+		&sc.Timezone,
+		&sc.KeyId,
+		&sc.RoutePath,
+		&request,
+		&destination,
+		&sc.Paused,
+		&sc.LastRunAt,
+		&sc.NextRunAt,
+	); err != nil {
+		return nil, err
+	}
+
+	sc.Request = request
+
+	d := &schedule.Destination{}
+	if err := json.Unmarshal(destination, d); err != nil {
+		return nil, err
+	}
+
+	sc.Destination = d
+
+	return sc, nil
+}
+
+func (s *Store) querySchedules(query string, args ...any) ([]*schedule.Schedule, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []*schedule.Schedule{}
+	for rows.Next() {
+		sc, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		schedules = append(schedules, sc)
+	}
+
+	return schedules, rows.Err()
+}
+
+func (s *Store) CreateSchedule(sc *schedule.Schedule) error {
+	destination, err := json.Marshal(sc.Destination)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO schedules (%s)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`, scheduleColumns)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	_, err = s.db.ExecContext(ctxTimeout, query,
+		sc.Id,
+		sc.CreatedAt,
+		sc.UpdatedAt,
+		sc.TenantId,
+		sc.Name,
+		sc.Cron,
+		sc.Timezone,
+		sc.KeyId,
+		sc.RoutePath,
+		[]byte(sc.Request),
+		destination,
+		sc.Paused,
+		sc.LastRunAt,
+		sc.NextRunAt,
+	)
+
+	return err
+}
+
+func (s *Store) GetSchedule(id string) (*schedule.Schedule, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	sc, err := scanSchedule(s.db.QueryRowContext(ctxTimeout, fmt.Sprintf("SELECT %s FROM schedules WHERE id = $1", scheduleColumns), id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError("schedule is not found for: " + id)
+		}
+
+		return nil, err
+	}
+
+	return sc, nil
+}
+
+// GetSchedules returns the schedules of a tenant, or every schedule when
+// tenantId is empty.
+func (s *Store) GetSchedules(tenantId string) ([]*schedule.Schedule, error) {
+	if len(tenantId) == 0 {
+		return s.querySchedules(fmt.Sprintf("SELECT %s FROM schedules ORDER BY created_at", scheduleColumns))
+	}
+
+	return s.querySchedules(fmt.Sprintf("SELECT %s FROM schedules WHERE tenant_id = $1 ORDER BY created_at", scheduleColumns), tenantId)
+}
+
+func (s *Store) GetDueSchedules(now int64) ([]*schedule.Schedule, error) {
+	return s.querySchedules(fmt.Sprintf("SELECT %s FROM schedules WHERE NOT paused AND next_run_at != 0 AND next_run_at <= $1 ORDER BY next_run_at", scheduleColumns), now)
+}
+
+func (s *Store) UpdateSchedule(sc *schedule.Schedule) error {
+	destination, err := json.Marshal(sc.Destination)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE schedules SET updated_at = $2, name = $3, cron = $4, timezone = $5, request = $6, destination = $7, paused = $8, next_run_at = $9
+		WHERE id = $1
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctxTimeout, query, sc.Id, sc.UpdatedAt, sc.Name, sc.Cron, sc.Timezone, []byte(sc.Request), destination, sc.Paused, sc.NextRunAt)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return internal_errors.NewNotFoundError("schedule is not found for: " + sc.Id)
+	}
+
+	return nil
+}
+
+// ClaimSchedule moves a schedule to its next run only if no other instance
+// has done so since nextRunAt was read.
+func (s *Store) ClaimSchedule(id string, nextRunAt, newNextRunAt, now int64) (bool, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctxTimeout, "UPDATE schedules SET next_run_at = $3, last_run_at = $4 WHERE id = $1 AND next_run_at = $2 AND NOT paused", id, nextRunAt, newNextRunAt, now)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func (s *Store) DeleteSchedule(id string) error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctxTimeout, "DELETE FROM schedules WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return internal_errors.NewNotFoundError("schedule is not found for: " + id)
+	}
+
+	_, err = s.db.ExecContext(ctxTimeout, "DELETE FROM schedule_runs WHERE schedule_id = $1", id)
+	return err
+}
+
+func (s *Store) CreateScheduleRun(r *schedule.Run) error {
+	query := `
+		INSERT INTO schedule_runs (id, schedule_id, started_at, finished_at, status, request_id, status_code, location, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctxTimeout, query, r.Id, r.ScheduleId, r.StartedAt, r.FinishedAt, r.Status, r.RequestId, r.StatusCode, r.Location, r.Error)
+	return err
+}
+
+// GetScheduleRuns returns the latest runs of a schedule first.
+func (s *Store) GetScheduleRuns(scheduleId string, limit int) ([]*schedule.Run, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, `
+		SELECT id, schedule_id, started_at, finished_at, status, request_id, status_code, location, error
+		FROM schedule_runs WHERE schedule_id = $1 ORDER BY started_at DESC LIMIT $2
+	`, scheduleId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []*schedule.Run{}
+	for rows.Next() {
+		r := &schedule.Run{}
+		if err := rows.Scan(&r.Id, &r.ScheduleId, &r.StartedAt, &r.FinishedAt, &r.Status, &r.RequestId, &r.StatusCode, &r.Location, &r.Error); err != nil {
+			return nil, err
+		}
+
+		runs = append(runs, r)
+	}
+
+	return runs, rows.Err()
+}
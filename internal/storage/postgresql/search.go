@@ -0,0 +1,97 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/lib/pq"
+)
+
+// SearchEvents returns up to limit events matching the request, newest first.
+// Callers that need to know whether another page exists can ask for one more
+// event than they intend to return.
+func (s *Store) SearchEvents(r *event.EventSearchRequest, limit, offset int) ([]*event.Event, error) {
+	args := []any{r.Start, r.End}
+	conditions := []string{"created_at >= $1", "created_at <= $2"}
+
+	if len(r.KeyIds) != 0 {
+		args = append(args, pq.Array(r.KeyIds))
+		conditions = append(conditions, fmt.Sprintf("key_id = ANY($%d)", len(args)))
+	}
+
+	if len(r.Models) != 0 {
+		args = append(args, pq.Array(r.Models))
+		conditions = append(conditions, fmt.Sprintf("model = ANY($%d)", len(args)))
+	}
+
+	if len(r.Providers) != 0 {
+		args = append(args, pq.Array(r.Providers))
+		conditions = append(conditions, fmt.Sprintf("provider = ANY($%d)", len(args)))
+	}
+
+	if len(r.StatusCodes) != 0 {
+		args = append(args, pq.Array(r.StatusCodes))
+		conditions = append(conditions, fmt.Sprintf("status_code = ANY($%d)", len(args)))
+	}
+
+	if r.MinCostInUsd != nil {
+		args = append(args, *r.MinCostInUsd)
+		conditions = append(conditions, fmt.Sprintf("cost_in_usd >= $%d", len(args)))
+	}
+
+	if r.MaxCostInUsd != nil {
+		args = append(args, *r.MaxCostInUsd)
+		conditions = append(conditions, fmt.Sprintf("cost_in_usd <= $%d", len(args)))
+	}
+
+	if r.MinLatencyInMs != nil {
+		args = append(args, *r.MinLatencyInMs)
+		conditions = append(conditions, fmt.Sprintf("latency_in_ms >= $%d", len(args)))
+	}
+
+	if r.MaxLatencyInMs != nil {
+		args = append(args, *r.MaxLatencyInMs)
+		conditions = append(conditions, fmt.Sprintf("latency_in_ms <= $%d", len(args)))
+	}
+
+	if len(r.TenantId) != 0 {
+		args = append(args, r.TenantId)
+		conditions = append(conditions, fmt.Sprintf("tenant_id = $%d", len(args)))
+	}
+
+	if len(r.CorrelationId) != 0 {
+		args = append(args, r.CorrelationId)
+		conditions = append(conditions, fmt.Sprintf("correlation_id = $%d", len(args)))
+	}
+
+	if len(r.TraceId) != 0 {
+		args = append(args, r.TraceId)
+		conditions = append(conditions, fmt.Sprintf("trace_id = $%d", len(args)))
+	}
+
+	if len(r.Sdks) != 0 {
+		args = append(args, pq.Array(r.Sdks))
+		conditions = append(conditions, fmt.Sprintf("sdk = ANY($%d)", len(args)))
+	}
+
+	if len(r.SdkVersions) != 0 {
+		args = append(args, pq.Array(r.SdkVersions))
+		conditions = append(conditions, fmt.Sprintf("sdk_version = ANY($%d)", len(args)))
+	}
+
+	args = append(args, limit, offset)
+	query := fmt.Sprintf("SELECT * FROM events WHERE %s ORDER BY created_at DESC, event_id LIMIT $%d OFFSET $%d", strings.Join(conditions, " AND "), len(args)-1, len(args))
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanEvents(rows)
+}
@@ -0,0 +1,60 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+)
+
+// maxSessionEvents bounds the timeline of runaway sessions.
+const maxSessionEvents = 1000
+
+// AlterTablesForSessions must run after AlterTablesForTenants since events are
+// read with SELECT *.
+func (s *Store) AlterTablesForSessions() error {
+	alterTableQuery := `
+		ALTER TABLE keys ADD COLUMN IF NOT EXISTS session_limits JSONB;
+		ALTER TABLE events ADD COLUMN IF NOT EXISTS session_id VARCHAR(255) NOT NULL DEFAULT '';
+		CREATE INDEX IF NOT EXISTS events_session_id_created_at_idx ON events (session_id, created_at) WHERE session_id <> '';
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) GetSessionEvents(tenantId, sessionId, keyId string) ([]*event.Event, error) {
+	args := []any{sessionId}
+	conditions := []string{"session_id = $1"}
+
+	if len(tenantId) != 0 {
+		args = append(args, tenantId)
+		conditions = append(conditions, fmt.Sprintf("tenant_id = $%d", len(args)))
+	}
+
+	if len(keyId) != 0 {
+		args = append(args, keyId)
+		conditions = append(conditions, fmt.Sprintf("key_id = $%d", len(args)))
+	}
+
+	args = append(args, maxSessionEvents)
+	query := fmt.Sprintf("SELECT * FROM events WHERE %s ORDER BY created_at, event_id LIMIT $%d", strings.Join(conditions, " AND "), len(args))
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanEvents(rows)
+}
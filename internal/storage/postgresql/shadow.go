@@ -0,0 +1,99 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/bricks-cloud/bricksllm/internal/route"
+)
+
+func (s *Store) CreateShadowResultsTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS shadow_results (
+		id VARCHAR(255) PRIMARY KEY,
+		created_at BIGINT NOT NULL,
+		route_id VARCHAR(255) NOT NULL,
+		correlation_id VARCHAR(255),
+		primary_outcome JSONB NOT NULL,
+		shadow_outcome JSONB NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS shadow_results_route_id_created_at_idx ON shadow_results (route_id, created_at DESC);
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) InsertShadowResult(r *route.ShadowResult) error {
+	primary, err := json.Marshal(r.Primary)
+	if err != nil {
+		return err
+	}
+
+	shadow, err := json.Marshal(r.Shadow)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO shadow_results (id, created_at, route_id, correlation_id, primary_outcome, shadow_outcome)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	_, err = s.db.ExecContext(ctxTimeout, query, r.Id, r.CreatedAt, r.RouteId, r.CorrelationId, primary, shadow)
+	return err
+}
+
+func (s *Store) GetShadowResults(routeId string, start, end int64, limit int) ([]*route.ShadowResult, error) {
+	query := `
+		SELECT id, created_at, route_id, correlation_id, primary_outcome, shadow_outcome
+		FROM shadow_results
+		WHERE route_id = $1 AND created_at >= $2 AND created_at <= $3
+		ORDER BY created_at DESC
+		LIMIT $4
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, query, routeId, start, end, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []*route.ShadowResult{}
+	for rows.Next() {
+		r := &route.ShadowResult{}
+		var cid sql.NullString
+		var primary, shadow []byte
+
+		if err := rows.Scan(&r.Id, &r.CreatedAt, &r.RouteId, &cid, &primary, &shadow); err != nil {
+			return nil, err
+		}
+
+		r.CorrelationId = cid.String
+
+		if err := json.Unmarshal(primary, &r.Primary); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(shadow, &r.Shadow); err != nil {
+			return nil, err
+		}
+
+		results = append(results, r)
+	}
+
+	return results, nil
+}
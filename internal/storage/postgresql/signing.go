@@ -0,0 +1,39 @@
+package postgresql
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+)
+
+func (s *Store) AlterKeysTableForSigning() error {
+	alterTableQuery := `
+		ALTER TABLE keys ADD COLUMN IF NOT EXISTS signing JSONB
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func setSigning(pk *key.ResponseKey, data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	sg := &key.Signing{}
+	if err := json.Unmarshal(data, sg); err != nil {
+		return err
+	}
+
+	pk.Signing = sg
+	pk.SigningEnabled = true
+
+	return nil
+}
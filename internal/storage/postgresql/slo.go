@@ -0,0 +1,26 @@
+package postgresql
+
+import (
+	"context"
+)
+
+// GetRouteSloCounts returns the number of requests made to a route within the
+// time range together with the number of failed and slow requests. Only server
+// side failures count against availability.
+func (s *Store) GetRouteSloCounts(tenantId, path string, start, end int64, latencyThresholdInMs int) (int64, int64, int64, error) {
+	query := `
+		SELECT COUNT(*), COALESCE(SUM(CASE WHEN status_code >= 500 THEN 1 ELSE 0 END),0), COALESCE(SUM(CASE WHEN $4 > 0 AND latency_in_ms > $4 THEN 1 ELSE 0 END),0)
+		FROM events
+		WHERE route = $1 AND tenant_id = $5 AND created_at >= $2 AND created_at <= $3
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	var total, failed, slow int64
+	if err := s.db.QueryRowContext(ctx, query, path, start, end, latencyThresholdInMs, tenantId).Scan(&total, &failed, &slow); err != nil {
+		return 0, 0, 0, err
+	}
+
+	return total, failed, slow, nil
+}
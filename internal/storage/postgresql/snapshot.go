@@ -0,0 +1,162 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bricks-cloud/bricksllm/internal/bundle"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/lib/pq"
+)
+
+// existingIds returns which of ids are already stored in the column of a
+// table.
+func (s *Store) existingIds(table, column string, ids []string) (map[string]bool, error) {
+	existing := map[string]bool{}
+	if len(ids) == 0 {
+		return existing, nil
+	}
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, fmt.Sprintf("SELECT %s FROM %s WHERE %s = ANY($1)", column, table, column), pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		existing[id] = true
+	}
+
+	return existing, rows.Err()
+}
+
+func importResult(resource, id string, exists bool) *bundle.Result {
+	action := bundle.ActionCreated
+	if exists {
+		action = bundle.ActionUnchanged
+	}
+
+	return &bundle.Result{Resource: resource, Id: id, Action: action}
+}
+
+// ImportSnapshot creates the resources of a snapshot that do not exist yet in
+// a single transaction. Resources are created in dependency order, so keys
+// find their provider settings and routes find their keys, and are marked as
+// updated at importedAt so that every instance picks them up.
+func (s *Store) ImportSnapshot(snap *bundle.Snapshot, importedAt int64) (*bundle.ImportReport, error) {
+	report := &bundle.ImportReport{
+		ImportedAt: importedAt,
+		Results:    []*bundle.Result{},
+	}
+
+	err := s.transaction(func(tx *Store) error {
+		ids := []string{}
+		for _, setting := range snap.ProviderSettings {
+			ids = append(ids, setting.Id)
+		}
+
+		existing, err := tx.existingIds("provider_settings", "id", ids)
+		if err != nil {
+			return err
+		}
+
+		for _, setting := range snap.ProviderSettings {
+			report.Results = append(report.Results, importResult("providerSetting", setting.Id, existing[setting.Id]))
+			if existing[setting.Id] {
+				continue
+			}
+
+			setting.UpdatedAt = importedAt
+			if _, err := tx.CreateProviderSetting(setting); err != nil {
+				return err
+			}
+		}
+
+		ids = []string{}
+		for _, p := range snap.Policies {
+			ids = append(ids, p.Id)
+		}
+
+		existing, err = tx.existingIds("policies", "id", ids)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range snap.Policies {
+			report.Results = append(report.Results, importResult("policy", p.Id, existing[p.Id]))
+			if existing[p.Id] {
+				continue
+			}
+
+			p.UpdatedAt = importedAt
+			if _, err := tx.CreatePolicy(p); err != nil {
+				return err
+			}
+		}
+
+		ids = []string{}
+		for _, k := range snap.Keys {
+			ids = append(ids, k.KeyId)
+		}
+
+		existing, err = tx.existingIds("keys", "key_id", ids)
+		if err != nil {
+			return err
+		}
+
+		for _, k := range snap.Keys {
+			report.Results = append(report.Results, importResult("key", k.KeyId, existing[k.KeyId]))
+			if existing[k.KeyId] {
+				continue
+			}
+
+			k.UpdatedAt = importedAt
+			if _, err := tx.CreateKey(k.RequestKey); err != nil {
+				return err
+			}
+
+			if k.PersonalCostLimitInUsd > 0 {
+				if _, err := tx.UpdateKey(k.KeyId, &key.UpdateKey{PersonalCostLimitInUsd: &k.PersonalCostLimitInUsd}); err != nil {
+					return err
+				}
+			}
+		}
+
+		ids = []string{}
+		for _, r := range snap.Routes {
+			ids = append(ids, r.Id)
+		}
+
+		existing, err = tx.existingIds("routes", "id", ids)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range snap.Routes {
+			report.Results = append(report.Results, importResult("route", r.Id, existing[r.Id]))
+			if existing[r.Id] {
+				continue
+			}
+
+			r.UpdatedAt = importedAt
+			if _, err := tx.CreateRoute(r); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
@@ -0,0 +1,38 @@
+package postgresql
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+)
+
+func (s *Store) AlterKeysTableForStreamGuardrails() error {
+	alterTableQuery := `
+		ALTER TABLE keys ADD COLUMN IF NOT EXISTS stream_guardrails JSONB
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func setStreamGuardrails(pk *key.ResponseKey, data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	sg := &key.StreamGuardrails{}
+	if err := json.Unmarshal(data, sg); err != nil {
+		return err
+	}
+
+	pk.StreamGuardrails = sg
+
+	return nil
+}
@@ -0,0 +1,279 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/lib/pq"
+)
+
+func (s *Store) CreateSubjectRequestsTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS subject_requests (
+		id VARCHAR(255) PRIMARY KEY,
+		created_at BIGINT NOT NULL,
+		updated_at BIGINT NOT NULL,
+		tenant_id VARCHAR(255) NOT NULL,
+		action VARCHAR(255) NOT NULL,
+		user_id VARCHAR(255) NOT NULL,
+		metadata_key VARCHAR(255) NOT NULL,
+		status VARCHAR(255) NOT NULL,
+		report JSONB,
+		error TEXT NOT NULL,
+		export BYTEA
+	);
+	CREATE INDEX IF NOT EXISTS subject_requests_status_created_at_idx ON subject_requests (status, created_at);
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+const subjectRequestColumns = "id, created_at, updated_at, tenant_id, action, user_id, metadata_key, status, report, error"
+
+func scanSubjectRequest(row rowScanner) (*event.SubjectRequest, error) {
+	r := &event.SubjectRequest{}
+	var report []byte
+
+	if err := row.Scan(
+		&r.Id,
+		&r.CreatedAt,
+		&r.UpdatedAt,
+		&r.TenantId,
+		&r.Action,
+		&r.UserId,
+		&r.MetadataKey,
+		&r.Status,
+		&report,
+		&r.Error,
+	); err != nil {
+		return nil, err
+	}
+
+	if len(report) != 0 {
+		if err := json.Unmarshal(report, &r.Report); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+func (s *Store) CreateSubjectRequest(r *event.SubjectRequest) (*event.SubjectRequest, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO subject_requests (id, created_at, updated_at, tenant_id, action, user_id, metadata_key, status, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING %s
+	`, subjectRequestColumns)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	return scanSubjectRequest(s.db.QueryRowContext(ctxTimeout, query,
+		r.Id,
+		r.CreatedAt,
+		r.UpdatedAt,
+		r.TenantId,
+		r.Action,
+		r.UserId,
+		r.MetadataKey,
+		r.Status,
+		r.Error,
+	))
+}
+
+func (s *Store) GetSubjectRequest(id string) (*event.SubjectRequest, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	r, err := scanSubjectRequest(s.db.QueryRowContext(ctxTimeout, fmt.Sprintf("SELECT %s FROM subject_requests WHERE id = $1", subjectRequestColumns), id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError("subject request is not found for: " + id)
+		}
+
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (s *Store) GetSubjectExport(id string) ([]byte, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	var export []byte
+	err := s.db.QueryRowContext(ctxTimeout, "SELECT export FROM subject_requests WHERE id = $1", id).Scan(&export)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError("subject request is not found for: " + id)
+		}
+
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// ClaimSubjectRequest marks the oldest pending subject request as running and
+// returns it. Requests that have been running since before staleBefore are
+// claimed again so that requests of crashed instances are not lost. Rows
+// locked by other instances are skipped.
+func (s *Store) ClaimSubjectRequest(now, staleBefore int64) (*event.SubjectRequest, error) {
+	query := fmt.Sprintf(`
+		UPDATE subject_requests SET status = $1, updated_at = $2
+		WHERE id = (
+			SELECT id FROM subject_requests
+			WHERE status = $3 OR (status = $1 AND updated_at < $4)
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING %s
+	`, subjectRequestColumns)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	r, err := scanSubjectRequest(s.db.QueryRowContext(ctxTimeout, query, event.SubjectRequestStatusRunning, now, event.SubjectRequestStatusPending, staleBefore))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError("no subject request is pending")
+		}
+
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// FinishSubjectRequest stores the outcome of a subject request. The export is
+// only set for completed export requests.
+func (s *Store) FinishSubjectRequest(r *event.SubjectRequest, export []byte) error {
+	var report []byte
+	if r.Report != nil {
+		data, err := json.Marshal(r.Report)
+		if err != nil {
+			return err
+		}
+
+		report = data
+	}
+
+	query := `
+		UPDATE subject_requests SET status = $2, updated_at = $3, report = $4, error = $5, export = $6
+		WHERE id = $1
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctxTimeout, query, r.Id, r.Status, r.UpdatedAt, report, r.Error, export)
+	return err
+}
+
+// GetSubjectEvents pages through the events of the end user of a subject
+// request ordered by creation time and id.
+func (s *Store) GetSubjectEvents(r *event.SubjectRequest, afterCreatedAt int64, afterId string, limit int) ([]*event.Event, error) {
+	args := []any{afterCreatedAt, afterId, r.UserId}
+	conditions := "user_id = $3"
+
+	if len(r.MetadataKey) != 0 {
+		args = append(args, r.MetadataKey)
+		conditions = fmt.Sprintf("metadata ->> $%d = $3", len(args))
+	} else if len(r.Pseudonyms) != 0 {
+		args[2] = pq.Array(r.Pseudonyms)
+		conditions = "user_id = ANY($3)"
+	}
+
+	if len(r.TenantId) != 0 {
+		args = append(args, r.TenantId)
+		conditions += fmt.Sprintf(" AND tenant_id = $%d", len(args))
+	}
+
+	args = append(args, limit)
+	query := fmt.Sprintf(`
+		SELECT * FROM events
+		WHERE (created_at, event_id) > ($1, $2) AND %s
+		ORDER BY created_at, event_id
+		LIMIT $%d
+	`, conditions, len(args))
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanEvents(rows)
+}
+
+func (s *Store) GetRecordedRequests(eventIds []string) ([]*event.RecordedRequest, error) {
+	query := `
+		SELECT event_id, created_at, request, response, tenant_id, expires_at, encrypted FROM recorded_requests WHERE event_id = ANY($1)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, pq.Array(eventIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recorded := []*event.RecordedRequest{}
+	for rows.Next() {
+		r := &event.RecordedRequest{}
+		if err := rows.Scan(&r.EventId, &r.CreatedAt, &r.Request, &r.Response, &r.TenantId, &r.ExpiresAt, &r.Encrypted); err != nil {
+			return nil, err
+		}
+
+		recorded = append(recorded, r)
+	}
+
+	return recorded, nil
+}
+
+// DeleteSubjectEvents deletes the given events together with their recorded
+// requests, samples and the shadow results of their correlation ids, and
+// returns how many of each were deleted.
+func (s *Store) DeleteSubjectEvents(eventIds []string) (*event.SubjectReport, error) {
+	query := `
+		WITH matched AS (
+			SELECT event_id, correlation_id FROM events WHERE event_id = ANY($1)
+		), recorded AS (
+			DELETE FROM recorded_requests WHERE event_id IN (SELECT event_id FROM matched) RETURNING 1
+		), sampled AS (
+			DELETE FROM samples WHERE event_id IN (SELECT event_id FROM matched) RETURNING 1
+		), shadows AS (
+			DELETE FROM shadow_results WHERE correlation_id IN (SELECT correlation_id FROM matched WHERE correlation_id <> '') RETURNING 1
+		), deleted AS (
+			DELETE FROM events WHERE event_id IN (SELECT event_id FROM matched) RETURNING 1
+		)
+		SELECT (SELECT COUNT(*) FROM deleted), (SELECT COUNT(*) FROM recorded), (SELECT COUNT(*) FROM shadows), (SELECT COUNT(*) FROM sampled)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	report := &event.SubjectReport{}
+	if err := s.db.QueryRowContext(ctx, query, pq.Array(eventIds)).Scan(&report.Events, &report.RecordedRequests, &report.ShadowResults, &report.Samples); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
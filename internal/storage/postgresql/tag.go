@@ -0,0 +1,161 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/tag"
+	"github.com/lib/pq"
+)
+
+func (s *Store) CreateTagsTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS tags (
+		name VARCHAR(255) PRIMARY KEY,
+		created_at BIGINT NOT NULL,
+		updated_at BIGINT NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		allowed_values TEXT[] NOT NULL DEFAULT '{}'
+	)`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+const tagColumns = "name, created_at, updated_at, description, allowed_values"
+
+func scanTag(row rowScanner) (*tag.Tag, error) {
+	t := &tag.Tag{}
+
+	if err := row.Scan(
+		&t.Name,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+		&t.Description,
+		pq.Array(&t.AllowedValues),
+	); err != nil {
+		return nil, err
+	}
+
+	if t.AllowedValues == nil {
+		t.AllowedValues = []string{}
+	}
+
+	return t, nil
+}
+
+func (s *Store) CreateTag(t *tag.Tag) (*tag.Tag, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO tags (%s)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (name) DO NOTHING
+		RETURNING %s
+	`, tagColumns, tagColumns)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	created, err := scanTag(s.db.QueryRowContext(ctxTimeout, query,
+		t.Name,
+		t.CreatedAt,
+		t.UpdatedAt,
+		t.Description,
+		pq.Array(t.AllowedValues),
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, NewDuplicationError(fmt.Sprintf("tag %s already exists", t.Name))
+		}
+
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (s *Store) GetTags() ([]*tag.Tag, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, fmt.Sprintf("SELECT %s FROM tags ORDER BY name", tagColumns))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []*tag.Tag{}
+	for rows.Next() {
+		t, err := scanTag(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, t)
+	}
+
+	return tags, nil
+}
+
+func (s *Store) UpdateTag(name string, ut *tag.UpdateTag) (*tag.Tag, error) {
+	values := []any{
+		name,
+		ut.UpdatedAt,
+	}
+	fields := []string{"updated_at = $2"}
+
+	if ut.Description != nil {
+		values = append(values, *ut.Description)
+		fields = append(fields, fmt.Sprintf("description = $%d", len(values)))
+	}
+
+	if ut.AllowedValues != nil {
+		values = append(values, pq.Array(ut.AllowedValues))
+		fields = append(fields, fmt.Sprintf("allowed_values = $%d", len(values)))
+	}
+
+	query := fmt.Sprintf("UPDATE tags SET %s WHERE name = $1 RETURNING %s", strings.Join(fields, ","), tagColumns)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	updated, err := scanTag(s.db.QueryRowContext(ctxTimeout, query, values...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError("tag is not found for: " + name)
+		}
+
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+func (s *Store) DeleteTag(name string) error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctxTimeout, "DELETE FROM tags WHERE name = $1", name)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return internal_errors.NewNotFoundError("tag is not found for: " + name)
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+)
+
+// maxTaskEvents bounds the report of runaway agent tasks.
+const maxTaskEvents = 1000
+
+// maxTaskDepth bounds the walk up to the root request of a task.
+const maxTaskDepth = 100
+
+// AlterEventsTableForTasks must run after AlterEventsTableForFeedback since
+// events are read with SELECT *.
+func (s *Store) AlterEventsTableForTasks() error {
+	alterTableQuery := `
+		ALTER TABLE events ADD COLUMN IF NOT EXISTS parent_id VARCHAR(255) NOT NULL DEFAULT '';
+		CREATE INDEX IF NOT EXISTS events_parent_id_idx ON events (parent_id) WHERE parent_id <> '';
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetTaskEvents returns the events of the task an event belongs to. The root
+// event of the task comes first, followed by its descendants in the order
+// they were created.
+func (s *Store) GetTaskEvents(tenantId, eventId string) ([]*event.Event, error) {
+	query := `
+		WITH RECURSIVE ancestors AS (
+			SELECT event_id, parent_id, 0 AS depth FROM events WHERE event_id = $1 AND ($2 = '' OR tenant_id = $2)
+			UNION ALL
+			SELECT events.event_id, events.parent_id, ancestors.depth + 1 FROM events
+			JOIN ancestors ON events.event_id = ancestors.parent_id
+			WHERE ancestors.depth < $3
+		), root AS (
+			SELECT event_id FROM ancestors ORDER BY depth DESC LIMIT 1
+		), task AS (
+			SELECT event_id, 0 AS depth FROM root
+			UNION ALL
+			SELECT events.event_id, task.depth + 1 FROM events
+			JOIN task ON events.parent_id = task.event_id
+			WHERE task.depth < $3
+		)
+		SELECT * FROM events WHERE event_id IN (SELECT event_id FROM task LIMIT $4) AND ($2 = '' OR tenant_id = $2)
+		ORDER BY event_id = (SELECT event_id FROM root) DESC, created_at, event_id
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, eventId, tenantId, maxTaskDepth, maxTaskEvents)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanEvents(rows)
+}
@@ -0,0 +1,180 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/tenant"
+)
+
+func (s *Store) CreateTenantsTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS tenants (
+		id VARCHAR(255) PRIMARY KEY,
+		created_at BIGINT NOT NULL,
+		updated_at BIGINT NOT NULL,
+		name VARCHAR(255) NOT NULL UNIQUE,
+		revoked BOOLEAN NOT NULL,
+		admin_token_hash VARCHAR(255) NOT NULL UNIQUE
+	)`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AlterTablesForTenants adds the tenant of keys, provider settings, routes
+// and events. Existing rows belong to no tenant.
+func (s *Store) AlterTablesForTenants() error {
+	alterTableQuery := `
+		ALTER TABLE keys ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(255) NOT NULL DEFAULT '';
+		ALTER TABLE provider_settings ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(255) NOT NULL DEFAULT '';
+		ALTER TABLE routes ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(255) NOT NULL DEFAULT '';
+		ALTER TABLE events ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(255) NOT NULL DEFAULT '';
+		CREATE INDEX IF NOT EXISTS events_tenant_id_created_at_idx ON events (tenant_id, created_at);
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+const tenantColumns = "id, created_at, updated_at, name, revoked, admin_token_hash"
+
+func scanTenant(row rowScanner) (*tenant.Tenant, error) {
+	t := &tenant.Tenant{}
+
+	if err := row.Scan(
+		&t.Id,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+		&t.Name,
+		&t.Revoked,
+		&t.AdminTokenHash,
+	); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (s *Store) CreateTenant(t *tenant.Tenant) (*tenant.Tenant, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO tenants (%s)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (name) DO NOTHING
+		RETURNING %s
+	`, tenantColumns, tenantColumns)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	created, err := scanTenant(s.db.QueryRowContext(ctxTimeout, query,
+		t.Id,
+		t.CreatedAt,
+		t.UpdatedAt,
+		t.Name,
+		t.Revoked,
+		t.AdminTokenHash,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, NewDuplicationError(fmt.Sprintf("tenant %s already exists", t.Name))
+		}
+
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (s *Store) GetTenant(id string) (*tenant.Tenant, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	t, err := scanTenant(s.db.QueryRowContext(ctxTimeout, fmt.Sprintf("SELECT %s FROM tenants WHERE id = $1", tenantColumns), id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError("tenant is not found for: " + id)
+		}
+
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (s *Store) GetTenants() ([]*tenant.Tenant, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, fmt.Sprintf("SELECT %s FROM tenants ORDER BY name", tenantColumns))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tenants := []*tenant.Tenant{}
+	for rows.Next() {
+		t, err := scanTenant(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		tenants = append(tenants, t)
+	}
+
+	return tenants, nil
+}
+
+func (s *Store) UpdateTenant(id string, ut *tenant.UpdateTenant, tokenHash string) (*tenant.Tenant, error) {
+	values := []any{
+		id,
+		ut.UpdatedAt,
+	}
+	fields := []string{"updated_at = $2"}
+
+	if ut.Name != nil {
+		values = append(values, *ut.Name)
+		fields = append(fields, fmt.Sprintf("name = $%d", len(values)))
+	}
+
+	if ut.Revoked != nil {
+		values = append(values, *ut.Revoked)
+		fields = append(fields, fmt.Sprintf("revoked = $%d", len(values)))
+	}
+
+	if len(tokenHash) != 0 {
+		values = append(values, tokenHash)
+		fields = append(fields, fmt.Sprintf("admin_token_hash = $%d", len(values)))
+	}
+
+	query := fmt.Sprintf("UPDATE tenants SET %s WHERE id = $1 RETURNING %s", strings.Join(fields, ","), tenantColumns)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	updated, err := scanTenant(s.db.QueryRowContext(ctxTimeout, query, values...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError("tenant is not found for: " + id)
+		}
+
+		return nil, err
+	}
+
+	return updated, nil
+}
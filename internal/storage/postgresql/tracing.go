@@ -0,0 +1,23 @@
+package postgresql
+
+import (
+	"context"
+)
+
+// AlterEventsTableForTracing must run after AlterEventsTableForTasks since
+// events are read with SELECT *.
+func (s *Store) AlterEventsTableForTracing() error {
+	alterTableQuery := `
+		ALTER TABLE events ADD COLUMN IF NOT EXISTS trace_id VARCHAR(64) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS span_id VARCHAR(64) NOT NULL DEFAULT '';
+		CREATE INDEX IF NOT EXISTS events_trace_id_idx ON events (trace_id) WHERE trace_id <> '';
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
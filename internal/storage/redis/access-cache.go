@@ -38,11 +38,18 @@ func (ac *AccessCache) Set(key string, timeUnit key.TimeUnit) error {
 	return nil
 }
 
-func (ac *AccessCache) GetAccessStatus(key string) bool {
+func (ac *AccessCache) GetAccessStatus(key string) (bool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), ac.rt)
 	defer cancel()
 
-	result := ac.client.Get(ctx, key)
+	err := ac.client.Get(ctx, key).Err()
+	if err == redis.Nil {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
 
-	return result.Err() != redis.Nil
+	return true, nil
 }
@@ -0,0 +1,93 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// failed attempts are forgotten once a client stops failing for a day.
+const adminFailuresTtl = 24 * time.Hour
+
+type AdminAuthStore struct {
+	client *redis.Client
+	wt     time.Duration
+	rt     time.Duration
+}
+
+func NewAdminAuthStore(c *redis.Client, wt time.Duration, rt time.Duration) *AdminAuthStore {
+	return &AdminAuthStore{
+		client: c,
+		wt:     wt,
+		rt:     rt,
+	}
+}
+
+// IncrementRequests counts the admin requests of a client in the current
+// minute.
+func (as *AdminAuthStore) IncrementRequests(client string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), as.wt)
+	defer cancel()
+
+	k := "admin:requests:" + client + ":" + time.Now().UTC().Format("200601021504")
+
+	pipe := as.client.TxPipeline()
+	incr := pipe.Incr(ctx, k)
+	pipe.Expire(ctx, k, time.Minute)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	return incr.Val(), nil
+}
+
+func (as *AdminAuthStore) IncrementFailures(client string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), as.wt)
+	defer cancel()
+
+	k := "admin:failures:" + client
+
+	pipe := as.client.TxPipeline()
+	incr := pipe.Incr(ctx, k)
+	pipe.Expire(ctx, k, adminFailuresTtl)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	return incr.Val(), nil
+}
+
+func (as *AdminAuthStore) ResetFailures(client string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), as.wt)
+	defer cancel()
+
+	return as.client.Del(ctx, "admin:failures:"+client).Err()
+}
+
+func (as *AdminAuthStore) SetLockout(client string, d time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), as.wt)
+	defer cancel()
+
+	return as.client.Set(ctx, "admin:lockouts:"+client, 1, d).Err()
+}
+
+// GetLockout returns the time left in the lockout of a client or zero if
+// the client is not locked out.
+func (as *AdminAuthStore) GetLockout(client string) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), as.rt)
+	defer cancel()
+
+	ttl, err := as.client.PTTL(ctx, "admin:lockouts:"+client).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	if ttl < 0 {
+		return 0, nil
+	}
+
+	return ttl, nil
+}
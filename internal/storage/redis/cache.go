@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bricks-cloud/bricksllm/internal/key"
@@ -157,3 +158,59 @@ func (c *Cache) GetCounter(keyId string, rateLimitUnit key.TimeUnit) (int64, err
 	return counter, nil
 
 }
+
+// remoteFieldPrefix marks the fields of a counter that hold the spend synced
+// from other clusters.
+const remoteFieldPrefix = "@"
+
+// GetLocalCounter returns the spend of a key in this cluster during the
+// current period and when the period ends in unix milliseconds.
+func (c *Cache) GetLocalCounter(keyId string, timeUnit key.TimeUnit) (int64, int64, error) {
+	end, err := getCounterTtl(timeUnit)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), c.rt)
+	defer cancel()
+
+	fields, err := c.client.HGetAll(ctxTimeout, keyId).Result()
+	if err != nil && err != redis.Nil {
+		return 0, 0, err
+	}
+
+	var counter int64 = 0
+	for field, val := range fields {
+		if strings.HasPrefix(field, remoteFieldPrefix) {
+			continue
+		}
+
+		parsed, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		counter += parsed
+	}
+
+	return counter, end.UnixMilli(), nil
+}
+
+// MergeRemoteCounter keeps the spend of a key reported by another cluster for
+// the period ending at periodEnd. Reports of other periods are ignored since
+// the counter of this cluster is already past them or not there yet.
+func (c *Cache) MergeRemoteCounter(keyId string, timeUnit key.TimeUnit, clusterId string, periodEnd int64, value int64) error {
+	end, err := getCounterTtl(timeUnit)
+	if err != nil {
+		return err
+	}
+
+	if end.UnixMilli() != periodEnd {
+		return nil
+	}
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), c.wt)
+	defer cancel()
+
+	return mergeMaxScript.Run(ctxTimeout, c.client, []string{keyId}, remoteFieldPrefix+clusterId, value, periodEnd).Err()
+}
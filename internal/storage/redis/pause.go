@@ -0,0 +1,77 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/pause"
+	"github.com/redis/go-redis/v9"
+)
+
+const pausesKey = "pauses"
+
+type PauseStore struct {
+	client *redis.Client
+	wt     time.Duration
+	rt     time.Duration
+}
+
+func NewPauseStore(c *redis.Client, wt time.Duration, rt time.Duration) *PauseStore {
+	return &PauseStore{
+		client: c,
+		wt:     wt,
+		rt:     rt,
+	}
+}
+
+func (ps *PauseStore) SetPause(p *pause.Pause) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ps.wt)
+	defer cancel()
+
+	return ps.client.HSet(ctx, pausesKey, p.Id(), data).Err()
+}
+
+func (ps *PauseStore) DeletePause(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), ps.wt)
+	defer cancel()
+
+	deleted, err := ps.client.HDel(ctx, pausesKey, id).Result()
+	if err != nil {
+		return err
+	}
+
+	if deleted == 0 {
+		return internal_errors.NewNotFoundError("pause is not found: " + id)
+	}
+
+	return nil
+}
+
+func (ps *PauseStore) GetPauses() ([]*pause.Pause, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ps.rt)
+	defer cancel()
+
+	vals, err := ps.client.HGetAll(ctx, pausesKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	pauses := []*pause.Pause{}
+	for _, val := range vals {
+		p := &pause.Pause{}
+		if err := json.Unmarshal([]byte(val), p); err != nil {
+			return nil, err
+		}
+
+		pauses = append(pauses, p)
+	}
+
+	return pauses, nil
+}
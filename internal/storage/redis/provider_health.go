@@ -0,0 +1,132 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	unhealthySettingsKey   = "unhealthy_settings"
+	authFailuresKeyPrefix  = "auth_failures:"
+	authFailuresExpiration = 24 * time.Hour
+)
+
+type ProviderHealthStore struct {
+	client *redis.Client
+	wt     time.Duration
+	rt     time.Duration
+}
+
+func NewProviderHealthStore(c *redis.Client, wt time.Duration, rt time.Duration) *ProviderHealthStore {
+	return &ProviderHealthStore{
+		client: c,
+		wt:     wt,
+		rt:     rt,
+	}
+}
+
+func (hs *ProviderHealthStore) IncrementAuthFailures(settingId string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), hs.wt)
+	defer cancel()
+
+	pipe := hs.client.TxPipeline()
+	incr := pipe.Incr(ctx, authFailuresKeyPrefix+settingId)
+	pipe.Expire(ctx, authFailuresKeyPrefix+settingId, authFailuresExpiration)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	return incr.Val(), nil
+}
+
+func (hs *ProviderHealthStore) ResetAuthFailures(settingId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), hs.wt)
+	defer cancel()
+
+	return hs.client.Del(ctx, authFailuresKeyPrefix+settingId).Err()
+}
+
+func (hs *ProviderHealthStore) GetAuthFailures(settingIds []string) (map[string]int64, error) {
+	failures := map[string]int64{}
+	if len(settingIds) == 0 {
+		return failures, nil
+	}
+
+	keys := []string{}
+	for _, id := range settingIds {
+		keys = append(keys, authFailuresKeyPrefix+id)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), hs.rt)
+	defer cancel()
+
+	vals, err := hs.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for index, val := range vals {
+		str, ok := val.(string)
+		if !ok {
+			continue
+		}
+
+		parsed, err := strconv.ParseInt(str, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		failures[settingIds[index]] = parsed
+	}
+
+	return failures, nil
+}
+
+// MarkUnhealthy only succeeds for the first caller so that a setting is
+// reported once even if several instances see it failing.
+func (hs *ProviderHealthStore) MarkUnhealthy(h *provider.Health) (bool, error) {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return false, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), hs.wt)
+	defer cancel()
+
+	return hs.client.HSetNX(ctx, unhealthySettingsKey, h.SettingId, data).Result()
+}
+
+func (hs *ProviderHealthStore) DeleteUnhealthy(settingId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), hs.wt)
+	defer cancel()
+
+	return hs.client.HDel(ctx, unhealthySettingsKey, settingId).Err()
+}
+
+func (hs *ProviderHealthStore) GetUnhealthy() ([]*provider.Health, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), hs.rt)
+	defer cancel()
+
+	vals, err := hs.client.HGetAll(ctx, unhealthySettingsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	healths := []*provider.Health{}
+	for _, val := range vals {
+		h := &provider.Health{}
+		if err := json.Unmarshal([]byte(val), h); err != nil {
+			return nil, err
+		}
+
+		healths = append(healths, h)
+	}
+
+	return healths, nil
+}
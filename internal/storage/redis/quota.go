@@ -0,0 +1,61 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/redis/go-redis/v9"
+)
+
+// quotas are only a snapshot of the last upstream response, so they expire
+// instead of being reported long after they stopped being accurate.
+const quotaTtl = time.Hour
+
+type QuotaStore struct {
+	client *redis.Client
+	wt     time.Duration
+	rt     time.Duration
+}
+
+func NewQuotaStore(c *redis.Client, wt time.Duration, rt time.Duration) *QuotaStore {
+	return &QuotaStore{
+		client: c,
+		wt:     wt,
+		rt:     rt,
+	}
+}
+
+func (qs *QuotaStore) SetQuota(q *provider.Quota) error {
+	data, err := json.Marshal(q)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), qs.wt)
+	defer cancel()
+
+	return qs.client.Set(ctx, q.SettingId, data, quotaTtl).Err()
+}
+
+func (qs *QuotaStore) GetQuota(settingId string) (*provider.Quota, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), qs.rt)
+	defer cancel()
+
+	data, err := qs.client.Get(ctx, settingId).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	q := &provider.Quota{}
+	if err := json.Unmarshal(data, q); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
@@ -0,0 +1,210 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// session usage expires once a session has been idle for a day so that
+// sessions do not need to be closed explicitly.
+const sessionTtl = 24 * time.Hour
+
+const (
+	sessionTokensField = "tokens"
+	sessionMicrosField = "micros"
+)
+
+type SessionStore struct {
+	client *redis.Client
+	wt     time.Duration
+	rt     time.Duration
+}
+
+func NewSessionStore(c *redis.Client, wt time.Duration, rt time.Duration) *SessionStore {
+	return &SessionStore{
+		client: c,
+		wt:     wt,
+		rt:     rt,
+	}
+}
+
+func sessionKey(keyId, sessionId string) string {
+	return keyId + ":" + sessionId
+}
+
+func (ss *SessionStore) IncrementSessionUsage(keyId, sessionId string, tokens, micros int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), ss.wt)
+	defer cancel()
+
+	k := sessionKey(keyId, sessionId)
+
+	pipe := ss.client.TxPipeline()
+	pipe.HIncrBy(ctx, k, sessionTokensField, tokens)
+	pipe.HIncrBy(ctx, k, sessionMicrosField, micros)
+	pipe.Expire(ctx, k, sessionTtl)
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// GetSessionUsage returns the tokens and the cost in micro dollars spent by
+// a session.
+func (ss *SessionStore) GetSessionUsage(keyId, sessionId string) (int64, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ss.rt)
+	defer cancel()
+
+	vals, err := ss.client.HMGet(ctx, sessionKey(keyId, sessionId), sessionTokensField, sessionMicrosField).Result()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return parseSessionCounter(vals[0]), parseSessionCounter(vals[1]), nil
+}
+
+func parseSessionCounter(val interface{}) int64 {
+	str, ok := val.(string)
+	if !ok {
+		return 0
+	}
+
+	parsed, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return parsed
+}
+
+// IncrementRepeats counts a request fingerprint over a window that starts
+// with its first occurrence. It returns the count and the time left in the
+// window.
+func (ss *SessionStore) IncrementRepeats(fingerprint string, window time.Duration) (int64, time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ss.wt)
+	defer cancel()
+
+	k := "repeats:" + fingerprint
+
+	count, err := ss.client.Incr(ctx, k).Result()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if count == 1 {
+		if err := ss.client.Expire(ctx, k, window).Err(); err != nil {
+			return 0, 0, err
+		}
+
+		return count, window, nil
+	}
+
+	ttl, err := ss.client.PTTL(ctx, k).Result()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// a key left without expiration would count forever.
+	if ttl < 0 {
+		if err := ss.client.Expire(ctx, k, window).Err(); err != nil {
+			return 0, 0, err
+		}
+
+		ttl = window
+	}
+
+	return count, ttl, nil
+}
+
+func toolCallKey(keyId, sessionId, toolCallId string) string {
+	return "toolcalls:" + sessionKey(keyId, sessionId) + ":" + toolCallId
+}
+
+// SetToolCallRequest remembers the request that made tool calls in a session
+// so that the request sending their results can be linked to it.
+func (ss *SessionStore) SetToolCallRequest(keyId, sessionId string, toolCallIds []string, eventId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), ss.wt)
+	defer cancel()
+
+	pipe := ss.client.Pipeline()
+	for _, id := range toolCallIds {
+		pipe.Set(ctx, toolCallKey(keyId, sessionId, id), eventId, sessionTtl)
+	}
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// GetToolCallRequest returns the request that made the first of the tool
+// calls it knows about. It returns an empty string when none are known.
+func (ss *SessionStore) GetToolCallRequest(keyId, sessionId string, toolCallIds []string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ss.rt)
+	defer cancel()
+
+	keys := make([]string, 0, len(toolCallIds))
+	for _, id := range toolCallIds {
+		keys = append(keys, toolCallKey(keyId, sessionId, id))
+	}
+
+	vals, err := ss.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return "", err
+	}
+
+	for _, val := range vals {
+		if str, ok := val.(string); ok && len(str) != 0 {
+			return str, nil
+		}
+	}
+
+	return "", nil
+}
+
+// responseUsageTtl is how long the usage of an openai response is remembered
+// as charged. Responses are stored by openai for 30 days.
+const responseUsageTtl = 30 * 24 * time.Hour
+
+// ClaimResponseUsage reports whether the usage of a response is claimed for
+// the first time, so a response retrieved many times is charged once.
+func (ss *SessionStore) ClaimResponseUsage(responseId string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ss.wt)
+	defer cancel()
+
+	return ss.client.SetNX(ctx, "response_usage:"+responseId, 1, responseUsageTtl).Result()
+}
+
+// batchUsageTtl is how long the items of an anthropic message batch are
+// remembered as charged. Batch results are available for 29 days.
+const batchUsageTtl = 30 * 24 * time.Hour
+
+// ClaimBatchItems reports for each custom id of a message batch whether its
+// usage is claimed for the first time, so results downloaded many times or
+// only partially charge every item once.
+func (ss *SessionStore) ClaimBatchItems(batchId string, customIds []string) ([]bool, error) {
+	if len(customIds) == 0 {
+		return []bool{}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ss.wt)
+	defer cancel()
+
+	key := "batch_usage:" + batchId
+	pipe := ss.client.Pipeline()
+	cmds := make([]*redis.BoolCmd, 0, len(customIds))
+	for _, id := range customIds {
+		cmds = append(cmds, pipe.HSetNX(ctx, key, id, 1))
+	}
+	pipe.Expire(ctx, key, batchUsageTtl)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	claimed := make([]bool, 0, len(cmds))
+	for _, cmd := range cmds {
+		claimed = append(claimed, cmd.Val())
+	}
+
+	return claimed, nil
+}
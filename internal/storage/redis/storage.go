@@ -2,6 +2,7 @@ package redis
 
 import (
 	"context"
+	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -32,13 +33,45 @@ func (s *Store) DeleteCounter(keyId string) error {
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
 	defer cancel()
 
-	return s.client.Del(ctxTimeout, keyId).Err()
+	return s.client.Del(ctxTimeout, keyId, remoteCounterKey(keyId)).Err()
 }
 
+// GetCounter returns the spend of a key in this cluster and the spend synced
+// from other clusters.
 func (s *Store) GetCounter(keyId string) (int64, error) {
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
 	defer cancel()
 
+	pipe := s.client.Pipeline()
+	local := pipe.Get(ctxTimeout, keyId)
+	remote := pipe.HVals(ctxTimeout, remoteCounterKey(keyId))
+	_, err := pipe.Exec(ctxTimeout)
+	if err != nil && err != redis.Nil {
+		return 0, err
+	}
+
+	result, err := local.Int64()
+	if err != nil && err != redis.Nil {
+		return 0, err
+	}
+
+	for _, val := range remote.Val() {
+		parsed, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+
+		result += parsed
+	}
+
+	return result, nil
+}
+
+// GetLocalCounter returns the spend of a key in this cluster only.
+func (s *Store) GetLocalCounter(keyId string) (int64, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
 	val := s.client.Get(ctxTimeout, keyId)
 	result, err := val.Int64()
 	if err == nil {
@@ -51,3 +84,33 @@ func (s *Store) GetCounter(keyId string) (int64, error) {
 
 	return 0, err
 }
+
+// MergeRemoteCounter keeps the spend of a key reported by another cluster.
+// Counters only grow, so the largest value seen wins and stale or repeated
+// reports are harmless.
+func (s *Store) MergeRemoteCounter(keyId, clusterId string, value int64) error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	return mergeMaxScript.Run(ctxTimeout, s.client, []string{remoteCounterKey(keyId)}, clusterId, value, 0).Err()
+}
+
+func remoteCounterKey(keyId string) string {
+	return keyId + ":remote"
+}
+
+// mergeMaxScript sets a hash field to the larger of its value and ARGV[2].
+// When ARGV[3] is not zero, a hash without a ttl expires at ARGV[3] in unix
+// milliseconds.
+var mergeMaxScript = redis.NewScript(`
+local current = tonumber(redis.call('HGET', KEYS[1], ARGV[1]) or '0')
+local value = tonumber(ARGV[2])
+if value > current then
+	redis.call('HSET', KEYS[1], ARGV[1], value)
+end
+local expireAt = tonumber(ARGV[3])
+if expireAt > 0 and redis.call('PTTL', KEYS[1]) < 0 then
+	redis.call('PEXPIREAT', KEYS[1], expireAt)
+end
+return 0
+`)
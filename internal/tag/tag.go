@@ -0,0 +1,112 @@
+package tag
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+const separator = ":"
+
+var nameRegex = regexp.MustCompile(`^[A-Za-z0-9_\-\.]{1,64}$`)
+
+func IsValidName(name string) bool {
+	return nameRegex.MatchString(name)
+}
+
+// Tag is an entry of the tag taxonomy. Once the taxonomy has entries, key
+// tags must be written as name:value, or as a bare name for tags without
+// allowed values, using a name of the taxonomy.
+type Tag struct {
+	Name          string   `json:"name"`
+	CreatedAt     int64    `json:"createdAt"`
+	UpdatedAt     int64    `json:"updatedAt"`
+	Description   string   `json:"description"`
+	AllowedValues []string `json:"allowedValues"`
+}
+
+func validateAllowedValues(invalid []string, values []string) []string {
+	for index, value := range values {
+		if len(value) == 0 {
+			invalid = append(invalid, fmt.Sprintf("allowedValues.[%d]", index))
+		}
+	}
+
+	return invalid
+}
+
+func (t *Tag) Validate() error {
+	invalid := []string{}
+
+	if !IsValidName(t.Name) {
+		invalid = append(invalid, "name")
+	}
+
+	invalid = validateAllowedValues(invalid, t.AllowedValues)
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
+
+func (t *Tag) allows(value string, hasValue bool) bool {
+	if len(t.AllowedValues) == 0 {
+		return !hasValue || len(value) != 0
+	}
+
+	for _, allowed := range t.AllowedValues {
+		if hasValue && allowed == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+type UpdateTag struct {
+	UpdatedAt     int64    `json:"updatedAt"`
+	Description   *string  `json:"description"`
+	AllowedValues []string `json:"allowedValues"`
+}
+
+func (ut *UpdateTag) Validate() error {
+	invalid := validateAllowedValues([]string{}, ut.AllowedValues)
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
+
+// Split returns the name and the value of a key tag.
+func Split(t string) (string, string, bool) {
+	return strings.Cut(t, separator)
+}
+
+// Invalid returns the tags that are not part of the taxonomy. An empty
+// taxonomy accepts any tag.
+func Invalid(taxonomy []*Tag, tags []string) []string {
+	if len(taxonomy) == 0 {
+		return nil
+	}
+
+	byName := map[string]*Tag{}
+	for _, t := range taxonomy {
+		byName[t.Name] = t
+	}
+
+	invalid := []string{}
+	for _, t := range tags {
+		name, value, hasValue := Split(t)
+		if entry, ok := byName[name]; !ok || !entry.allows(value, hasValue) {
+			invalid = append(invalid, t)
+		}
+	}
+
+	return invalid
+}
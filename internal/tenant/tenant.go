@@ -0,0 +1,58 @@
+package tenant
+
+import (
+	"fmt"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+// Tenant is an isolated namespace of keys, provider settings, routes and
+// events. Resources created with the admin token of a tenant belong to it.
+type Tenant struct {
+	Id        string `json:"id"`
+	CreatedAt int64  `json:"createdAt"`
+	UpdatedAt int64  `json:"updatedAt"`
+	Name      string `json:"name"`
+	Revoked   bool   `json:"revoked"`
+
+	// AdminToken is only returned when a tenant is created or its token is
+	// rotated. Only the hash of the token is stored.
+	AdminToken     string `json:"adminToken,omitempty"`
+	AdminTokenHash string `json:"-"`
+}
+
+func (t *Tenant) Validate() error {
+	invalid := []string{}
+
+	if len(strings.TrimSpace(t.Name)) == 0 || len(t.Name) > 255 {
+		invalid = append(invalid, "name")
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
+
+type UpdateTenant struct {
+	UpdatedAt   int64   `json:"updatedAt"`
+	Name        *string `json:"name"`
+	Revoked     *bool   `json:"revoked"`
+	RotateToken bool    `json:"rotateToken"`
+}
+
+func (ut *UpdateTenant) Validate() error {
+	invalid := []string{}
+
+	if ut.Name != nil && (len(strings.TrimSpace(*ut.Name)) == 0 || len(*ut.Name) > 255) {
+		invalid = append(invalid, "name")
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
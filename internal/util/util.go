@@ -1,9 +1,20 @@
 package util
 
 import (
+	"regexp"
+
 	"github.com/google/uuid"
 )
 
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
 func NewUuid() string {
 	return uuid.New().String()
 }
+
+// IsValidId reports whether an id chosen by a client can be used for a
+// resource. Ids are at most 64 characters of letters, digits, dots,
+// underscores and dashes.
+func IsValidId(id string) bool {
+	return idPattern.MatchString(id)
+}
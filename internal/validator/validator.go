@@ -58,7 +58,7 @@ func (v *Validator) Validate(k *key.ResponseKey, promptCost float64) error {
 		return err
 	}
 
-	err = v.validateCostLimitOverTime(k.KeyId, k.CostLimitInUsdOverTime, k.CostLimitInUsdUnit)
+	err = v.validateCostLimitOverTime(k.KeyId, k.GetCostLimitInUsdOverTime(), k.CostLimitInUsdUnit)
 	if err != nil {
 		return err
 	}
@@ -71,6 +71,34 @@ func (v *Validator) Validate(k *key.ResponseKey, promptCost float64) error {
 	return nil
 }
 
+// GetBudgetUsage returns the largest share of its cost limits a key has used,
+// where 1 means a limit has been reached.
+func (v *Validator) GetBudgetUsage(k *key.ResponseKey) (float64, error) {
+	usage := 0.0
+
+	if limit := k.GetCostLimitInUsdOverTime(); limit != 0 {
+		cachedCost, err := v.clc.GetCounter(k.KeyId, k.CostLimitInUsdUnit)
+		if err != nil {
+			return 0, errors.New("failed to get cached token cost")
+		}
+
+		usage = float64(cachedCost) / float64(convertDollarToMicroDollars(limit))
+	}
+
+	if k.CostLimitInUsd != 0 {
+		existingTotalCost, err := v.cls.GetCounter(k.KeyId)
+		if err != nil {
+			return 0, errors.New("failed to get total token cost")
+		}
+
+		if total := float64(existingTotalCost) / float64(convertDollarToMicroDollars(k.CostLimitInUsd)); total > usage {
+			usage = total
+		}
+	}
+
+	return usage, nil
+}
+
 func (v *Validator) validateTtl(createdAt int64, ttl time.Duration) bool {
 	ttlInSecs := int64(ttl.Seconds())
 
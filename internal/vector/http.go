@@ -0,0 +1,145 @@
+package vector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+func postJson(ctx context.Context, client *http.Client, endpoint string, header map[string]string, payload any, out any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("vector store responded with status %d: %s", res.StatusCode, string(body))
+	}
+
+	return json.Unmarshal(body, out)
+}
+
+// payloadContent returns the content field of the metadata of a match.
+func payloadContent(payload map[string]any, field string) string {
+	if s, ok := payload[field].(string); ok {
+		return s
+	}
+
+	return ""
+}
+
+type pinecone struct {
+	cfg    *Config
+	client *http.Client
+}
+
+type pineconeResponse struct {
+	Matches []struct {
+		Id       string         `json:"id"`
+		Score    float64        `json:"score"`
+		Metadata map[string]any `json:"metadata"`
+	} `json:"matches"`
+}
+
+func (p *pinecone) Query(ctx context.Context, vector []float64, topK int) ([]*Chunk, error) {
+	payload := map[string]any{
+		"vector":          vector,
+		"topK":            topK,
+		"includeMetadata": true,
+	}
+
+	if len(p.cfg.Namespace) != 0 {
+		payload["namespace"] = p.cfg.Namespace
+	}
+
+	res := &pineconeResponse{}
+	err := postJson(ctx, p.client, strings.TrimSuffix(p.cfg.Url, "/")+"/query", map[string]string{
+		"Api-Key": p.cfg.ApiKey,
+	}, payload, res)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := []*Chunk{}
+	for _, m := range res.Matches {
+		chunks = append(chunks, &Chunk{
+			Id:      m.Id,
+			Content: payloadContent(m.Metadata, p.cfg.contentField()),
+			Score:   m.Score,
+		})
+	}
+
+	return chunks, nil
+}
+
+type qdrant struct {
+	cfg    *Config
+	client *http.Client
+}
+
+type qdrantResponse struct {
+	Result []struct {
+		Id      json.RawMessage `json:"id"`
+		Score   float64         `json:"score"`
+		Payload map[string]any  `json:"payload"`
+	} `json:"result"`
+}
+
+func (q *qdrant) Query(ctx context.Context, vector []float64, topK int) ([]*Chunk, error) {
+	header := map[string]string{}
+	if len(q.cfg.ApiKey) != 0 {
+		header["api-key"] = q.cfg.ApiKey
+	}
+
+	endpoint := fmt.Sprintf("%s/collections/%s/points/search", strings.TrimSuffix(q.cfg.Url, "/"), url.PathEscape(q.cfg.Collection))
+
+	res := &qdrantResponse{}
+	err := postJson(ctx, q.client, endpoint, header, map[string]any{
+		"vector":       vector,
+		"limit":        topK,
+		"with_payload": true,
+	}, res)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := []*Chunk{}
+	for _, point := range res.Result {
+		// ids of points are either unsigned integers or uuids.
+		id := strings.Trim(string(point.Id), `"`)
+
+		chunks = append(chunks, &Chunk{
+			Id:      id,
+			Content: payloadContent(point.Payload, q.cfg.contentField()),
+			Score:   point.Score,
+		})
+	}
+
+	return chunks, nil
+}
@@ -0,0 +1,80 @@
+package vector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	_ "github.com/lib/pq"
+)
+
+type pgvector struct {
+	cfg *Config
+	db  *sql.DB
+}
+
+func newPgvector(cfg *Config) (*pgvector, error) {
+	db, err := sql.Open("postgres", cfg.Url)
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(10)
+	db.SetMaxIdleConns(2)
+
+	return &pgvector{cfg: cfg, db: db}, nil
+}
+
+// withConfig shares the pool with routes querying other tables of the same
+// database.
+func (p *pgvector) withConfig(cfg *Config) *pgvector {
+	return &pgvector{cfg: cfg, db: p.db}
+}
+
+func formatVector(vector []float64) string {
+	values := make([]string, 0, len(vector))
+	for _, v := range vector {
+		values = append(values, strconv.FormatFloat(v, 'f', -1, 64))
+	}
+
+	return "[" + strings.Join(values, ",") + "]"
+}
+
+// Query ranks rows by cosine distance. Identifiers are validated when the
+// route is created so they can be formatted into the query.
+func (p *pgvector) Query(ctx context.Context, vector []float64, topK int) ([]*Chunk, error) {
+	idField := p.cfg.IdField
+	if len(idField) == 0 {
+		idField = defaultIdField
+	}
+
+	embeddingField := p.cfg.EmbeddingField
+	if len(embeddingField) == 0 {
+		embeddingField = defaultEmbeddingField
+	}
+
+	query := fmt.Sprintf(
+		"SELECT %s::text, %s, 1 - (%s <=> $1::vector) FROM %s ORDER BY %s <=> $1::vector LIMIT $2",
+		idField, p.cfg.contentField(), embeddingField, p.cfg.Table, embeddingField,
+	)
+
+	rows, err := p.db.QueryContext(ctx, query, formatVector(vector), topK)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	chunks := []*Chunk{}
+	for rows.Next() {
+		c := &Chunk{}
+		if err := rows.Scan(&c.Id, &c.Content, &c.Score); err != nil {
+			return nil, err
+		}
+
+		chunks = append(chunks, c)
+	}
+
+	return chunks, rows.Err()
+}
@@ -0,0 +1,172 @@
+package vector
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	StoreTypePgvector = "pgvector"
+	StoreTypePinecone = "pinecone"
+	StoreTypeQdrant   = "qdrant"
+)
+
+const (
+	defaultContentField   = "content"
+	defaultEmbeddingField = "embedding"
+	defaultIdField        = "id"
+)
+
+var identifierPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Config describes a vector store chunks are retrieved from. Url is a
+// postgres connection string for pgvector, the host of the index for
+// Pinecone and the base url of the server for Qdrant.
+type Config struct {
+	Type           string `json:"type"`
+	Url            string `json:"url"`
+	ApiKey         string `json:"apiKey,omitempty"`
+	Table          string `json:"table,omitempty"`
+	Collection     string `json:"collection,omitempty"`
+	Namespace      string `json:"namespace,omitempty"`
+	IdField        string `json:"idField,omitempty"`
+	ContentField   string `json:"contentField,omitempty"`
+	EmbeddingField string `json:"embeddingField,omitempty"`
+}
+
+// Chunk is a piece of a document returned by a vector store.
+type Chunk struct {
+	Id      string  `json:"id"`
+	Content string  `json:"content"`
+	Score   float64 `json:"score"`
+}
+
+type Store interface {
+	Query(ctx context.Context, vector []float64, topK int) ([]*Chunk, error)
+}
+
+func isTableName(name string) bool {
+	parts := strings.Split(name, ".")
+	if len(parts) > 2 {
+		return false
+	}
+
+	for _, part := range parts {
+		if !identifierPattern.MatchString(part) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Validate returns the invalid fields of the config prefixed with store.
+func (c *Config) Validate() []string {
+	invalid := []string{}
+
+	u, err := url.Parse(c.Url)
+	if len(c.Url) == 0 || err != nil {
+		invalid = append(invalid, "store.url")
+	}
+
+	switch c.Type {
+	case StoreTypePgvector:
+		if err == nil && u.Scheme != "postgres" && u.Scheme != "postgresql" {
+			invalid = append(invalid, "store.url")
+		}
+
+		if !isTableName(c.Table) {
+			invalid = append(invalid, "store.table")
+		}
+
+		for name, field := range map[string]string{"idField": c.IdField, "contentField": c.ContentField, "embeddingField": c.EmbeddingField} {
+			if len(field) != 0 && !identifierPattern.MatchString(field) {
+				invalid = append(invalid, "store."+name)
+			}
+		}
+	case StoreTypePinecone, StoreTypeQdrant:
+		if err == nil && u.Scheme != "https" && u.Scheme != "http" {
+			invalid = append(invalid, "store.url")
+		}
+
+		if c.Type == StoreTypePinecone && len(c.ApiKey) == 0 {
+			invalid = append(invalid, "store.apiKey")
+		}
+
+		if c.Type == StoreTypeQdrant && len(c.Collection) == 0 {
+			invalid = append(invalid, "store.collection")
+		}
+	default:
+		invalid = append(invalid, "store.type")
+	}
+
+	return invalid
+}
+
+// Redacted hides the api key and the password of the url.
+func (c *Config) Redacted() *Config {
+	if c == nil {
+		return nil
+	}
+
+	copied := *c
+	copied.ApiKey = ""
+
+	if u, err := url.Parse(c.Url); err == nil {
+		copied.Url = u.Redacted()
+	}
+
+	return &copied
+}
+
+func (c *Config) contentField() string {
+	if len(c.ContentField) != 0 {
+		return c.ContentField
+	}
+
+	return defaultContentField
+}
+
+// Clients hands out stores and keeps a connection pool per pgvector
+// database.
+type Clients struct {
+	client http.Client
+	mu     sync.Mutex
+	pools  map[string]*pgvector
+}
+
+func NewClients(client http.Client) *Clients {
+	return &Clients{
+		client: client,
+		pools:  map[string]*pgvector{},
+	}
+}
+
+func (c *Clients) Get(cfg *Config) (Store, error) {
+	switch cfg.Type {
+	case StoreTypePinecone:
+		return &pinecone{cfg: cfg, client: &c.client}, nil
+	case StoreTypeQdrant:
+		return &qdrant{cfg: cfg, client: &c.client}, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if pool, ok := c.pools[cfg.Url]; ok {
+		return pool.withConfig(cfg), nil
+	}
+
+	pool, err := newPgvector(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	c.pools[cfg.Url] = pool
+
+	return pool, nil
+}
@@ -0,0 +1,32 @@
+package watch
+
+const (
+	ResourceKey             = "key"
+	ResourceRoute           = "route"
+	ResourceProviderSetting = "providerSetting"
+)
+
+const (
+	ActionCreated = "created"
+	ActionUpdated = "updated"
+	ActionDeleted = "deleted"
+)
+
+// Change notifies watchers that a piece of configuration was created, updated
+// or deleted. It only identifies the resource, which has to be fetched from
+// the admin api to get its new state.
+type Change struct {
+	Resource  string `json:"resource"`
+	Action    string `json:"action"`
+	Id        string `json:"id"`
+	TenantId  string `json:"tenantId,omitempty"`
+	UpdatedAt int64  `json:"updatedAt"`
+}
+
+func GetAction(createdAt, updatedAt int64) string {
+	if createdAt == updatedAt {
+		return ActionCreated
+	}
+
+	return ActionUpdated
+}
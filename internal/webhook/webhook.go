@@ -0,0 +1,128 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+func isAllowedHost(host string, hosts []string) bool {
+	for _, allowed := range hosts {
+		if strings.EqualFold(allowed, host) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isPublicIp reports whether ip can be reached from outside of the network
+// of the proxy.
+func isPublicIp(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() && !ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+// ValidateUrl only accepts https urls. When hosts is not empty, the url has to
+// point to one of them. Otherwise urls of any host are accepted except for
+// addresses that are not public, which are checked again after resolving the
+// host when the webhook is sent.
+func ValidateUrl(raw string, hosts []string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || len(u.Hostname()) == 0 {
+		return internal_errors.NewValidationError("webhook url must be an https url")
+	}
+
+	if len(hosts) != 0 {
+		if isAllowedHost(u.Hostname(), hosts) {
+			return nil
+		}
+
+		return internal_errors.NewValidationError("webhook url host is not allowed: " + u.Hostname())
+	}
+
+	if strings.EqualFold(u.Hostname(), "localhost") {
+		return internal_errors.NewValidationError("webhook url host is not allowed: " + u.Hostname())
+	}
+
+	if ip := net.ParseIP(u.Hostname()); ip != nil && !isPublicIp(ip) {
+		return internal_errors.NewValidationError("webhook url host is not allowed: " + u.Hostname())
+	}
+
+	return nil
+}
+
+type resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+type dialer struct {
+	hosts    []string
+	resolver resolver
+	dialer   *net.Dialer
+}
+
+// dialContext connects to allowed hosts as they are. Other hosts are resolved
+// first and only connected to when all of their addresses are public, so
+// that hosts resolving to internal addresses cannot be used to reach
+// services next to the proxy.
+func (d *dialer) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if isAllowedHost(host, d.hosts) {
+		return d.dialer.DialContext(ctx, network, addr)
+	}
+
+	ips, err := d.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("webhook host %s has no addresses", host)
+	}
+
+	for _, ip := range ips {
+		if !isPublicIp(ip.IP) {
+			return nil, fmt.Errorf("webhook host %s resolves to an address that is not public: %s", host, ip.IP)
+		}
+	}
+
+	return d.dialer.DialContext(ctx, network, net.JoinHostPort(ips[0].IP.String(), port))
+}
+
+// NewClient returns a client for sending webhooks. Hosts are the hosts that
+// are allowed to have addresses that are not public. Requests are not sent
+// through proxies configured in the environment, which would hide the
+// address that is connected to.
+func NewClient(hosts []string, timeout time.Duration) http.Client {
+	d := &dialer{
+		hosts:    hosts,
+		resolver: net.DefaultResolver,
+		dialer: &net.Dialer{
+			Timeout: 10 * time.Second,
+		},
+	}
+
+	return http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         d.dialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		// redirects could lead to hosts that are not allowed.
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
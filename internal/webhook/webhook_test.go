@@ -0,0 +1,132 @@
+package webhook
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeResolver map[string][]string
+
+func (r fakeResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	addrs := []net.IPAddr{}
+	for _, ip := range r[host] {
+		addrs = append(addrs, net.IPAddr{IP: net.ParseIP(ip)})
+	}
+
+	return addrs, nil
+}
+
+func TestValidateUrl(t *testing.T) {
+	cases := []struct {
+		name     string
+		url      string
+		hosts    []string
+		expected bool
+	}{
+		{name: "https urls", url: "https://example.com/hook", expected: true},
+		{name: "http urls", url: "http://example.com/hook", expected: false},
+		{name: "urls without hosts", url: "https:///hook", expected: false},
+		{name: "invalid urls", url: "://example.com", expected: false},
+		{name: "public addresses", url: "https://93.184.216.34/hook", expected: true},
+		{name: "localhost", url: "https://LOCALHOST:8080/hook", expected: false},
+		{name: "loopback addresses", url: "https://127.0.0.1/hook", expected: false},
+		{name: "loopback ipv6 addresses", url: "https://[::1]/hook", expected: false},
+		{name: "private addresses", url: "https://10.0.0.1/hook", expected: false},
+		{name: "link-local addresses", url: "https://169.254.169.254/latest/meta-data", expected: false},
+		{name: "unspecified addresses", url: "https://0.0.0.0/hook", expected: false},
+		{name: "allowed hosts", url: "https://Hooks.Example.com/a", hosts: []string{"hooks.example.com"}, expected: true},
+		{name: "other hosts", url: "https://example.com/a", hosts: []string{"hooks.example.com"}, expected: false},
+		{name: "allowed hosts with private addresses", url: "https://10.0.0.1/a", hosts: []string{"10.0.0.1"}, expected: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateUrl(tc.url, tc.hosts)
+			assert.Equal(t, tc.expected, err == nil, "error: %v", err)
+		})
+	}
+}
+
+func TestDialer_DialContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	_, port, err := net.SplitHostPort(u.Host)
+	require.NoError(t, err)
+
+	resolver := fakeResolver{
+		"loopback.example.com":   {"127.0.0.1"},
+		"private.example.com":    {"93.184.216.34", "192.168.0.1"},
+		"metadata.example.com":   {"169.254.169.254"},
+		"unresolved.example.com": {},
+		"127.0.0.1":              {"127.0.0.1"},
+	}
+
+	cases := []struct {
+		name     string
+		host     string
+		hosts    []string
+		expected bool
+	}{
+		{name: "hosts resolving to loopback addresses", host: "loopback.example.com", expected: false},
+		{name: "hosts resolving to any private address", host: "private.example.com", expected: false},
+		{name: "hosts resolving to link-local addresses", host: "metadata.example.com", expected: false},
+		{name: "hosts without addresses", host: "unresolved.example.com", expected: false},
+		{name: "loopback addresses", host: "127.0.0.1", expected: false},
+		{name: "allowed hosts are not checked", host: "127.0.0.1", hosts: []string{"127.0.0.1"}, expected: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &dialer{hosts: tc.hosts, resolver: resolver, dialer: &net.Dialer{Timeout: time.Second}}
+
+			conn, err := d.dialContext(context.Background(), "tcp", net.JoinHostPort(tc.host, port))
+			if conn != nil {
+				conn.Close()
+			}
+
+			assert.Equal(t, tc.expected, err == nil, "error: %v", err)
+		})
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	redirected := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirected" {
+			redirected = true
+			return
+		}
+
+		http.Redirect(w, r, "/redirected", http.StatusFound)
+	}))
+	defer server.Close()
+
+	t.Run("addresses that are not public are refused", func(t *testing.T) {
+		client := NewClient(nil, time.Second)
+
+		_, err := client.Post(server.URL, "application/json", nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("redirects are not followed", func(t *testing.T) {
+		client := NewClient([]string{"127.0.0.1"}, time.Second)
+
+		res, err := client.Post(server.URL, "application/json", nil)
+		require.NoError(t, err)
+		res.Body.Close()
+
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.False(t, redirected)
+	})
+}